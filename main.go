@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/dynamofl/dynactl/pkg/commands"
 	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	version = "0.2.3"
-	verbose int
+	version        = "0.2.3"
+	verbose        int
+	requestTimeout time.Duration
+	kubeQPS        float32
+	kubeBurst      int
+	accelerators   []string
+	platform       string
 )
 
 func newRootCommand() *cobra.Command {
@@ -20,28 +32,154 @@ func newRootCommand() *cobra.Command {
 		Long: `A Go-based tool to manage customer's DevOps operations
 on Dynamo AI deployment and maintenance.`,
 		Version: version,
-		CompletionOptions: cobra.CompletionOptions{
-			DisableDefaultCmd: true,
-		},
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			utils.SetLogLevel(verbose)
+			if err := utils.SetPlatform(platform); err != nil {
+				return err
+			}
+			utils.SetRequestTimeout(requestTimeout)
+			utils.SetRateLimits(kubeQPS, kubeBurst)
+			utils.SetAcceleratorResources(resolveAcceleratorResources(cmd))
+			cmd.SetContext(utils.WithKubeClients(cmd.Context(), utils.NewKubeClients()))
 			utils.LogDebug("Starting dynactl with verbosity level %d", verbose)
+			return nil
 		},
 	}
 
+	rootCmd.SetVersionTemplate(fmt.Sprintf("dynactl version {{.Version}}\nmanifest schema %d, releases %s\n", utils.ManifestSchemaVersion, utils.SupportedReleases))
+
 	rootCmd.PersistentFlags().IntVarP(&verbose, "verbose", "v", 0, "Increase verbosity (can be used multiple times)")
+	rootCmd.SetGlobalNormalizationFunc(kubeFlagAliases)
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", utils.DefaultRequestTimeout, "Timeout for each Kubernetes API request (0 disables it; alias --kube-timeout)")
+	rootCmd.PersistentFlags().Float32Var(&kubeQPS, "kube-qps", utils.DefaultKubeQPS, "Kubernetes API requests per second before client-side throttling")
+	rootCmd.PersistentFlags().IntVar(&kubeBurst, "kube-burst", utils.DefaultKubeBurst, "Kubernetes API requests allowed in a burst above --kube-qps")
+	rootCmd.PersistentFlags().StringVar(&platform, "platform", utils.PlatformAuto, "Platform the cluster checks assume: auto (detect OpenShift), kubernetes, or openshift")
+	rootCmd.PersistentFlags().StringSliceVar(&accelerators, "accelerator-resource", nil, "Extended resources counted as accelerators, e.g. amd.com/gpu (glob patterns allowed; defaults to common GPU and accelerator resources)")
 
 	commands.AddArtifactsCommands(rootCmd)
 	commands.AddClusterCommands(rootCmd)
 	commands.AddGuardCommands(rootCmd)
 	commands.AddRegistryCommands(rootCmd)
+	commands.AddDeployCommands(rootCmd)
+	commands.AddBackupCommands(rootCmd)
+	commands.AddRunCommands(rootCmd)
+	commands.AddUpgradeCommands(rootCmd)
+	commands.AddSelfUpdateCommands(rootCmd)
+	commands.AddPluginCommands(rootCmd)
+	commands.AddTelemetryCommands(rootCmd)
+	commands.RegisterCompletions(rootCmd)
+	commands.EnableAuditing(rootCmd)
+	commands.EnableTelemetry(rootCmd)
 
 	return rootCmd
 }
 
+// kubeFlagAliases accepts --kube-timeout for --request-timeout, next to --kube-qps and --kube-burst
+func kubeFlagAliases(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "kube-timeout" {
+		name = "request-timeout"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// resolveAcceleratorResources prefers --accelerator-resource over the config file; an empty
+// result falls back to the built-in list
+func resolveAcceleratorResources(cmd *cobra.Command) []string {
+	if cmd.Flags().Changed("accelerator-resource") {
+		return accelerators
+	}
+	cfg, err := utils.LoadConfig()
+	if err != nil {
+		utils.LogWarning("Ignoring accelerator resources from config: %v", err)
+		return nil
+	}
+	return cfg.Cluster.AcceleratorResources
+}
+
+// startUpdateCheck refreshes the cached latest release in the background unless the command
+// is one where a notice would be noise. It returns the channel to report on, or "" to skip.
+func startUpdateCheck(ctx context.Context, rootCmd *cobra.Command) (string, <-chan struct{}) {
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	if err != nil || cmd == rootCmd || utils.UpdateCheckDisabled() {
+		return "", nil
+	}
+	switch cmd.Name() {
+	case "self-update", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return "", nil
+	}
+	if cmd.Parent() != nil && cmd.Parent().Name() == "completion" {
+		return "", nil
+	}
+
+	channel := utils.UpdateChannel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		utils.RefreshUpdateCheck(ctx, channel)
+	}()
+	return channel, done
+}
+
+// printUpdateHint reports a newer release on stderr, giving an in-flight check a moment to land
+func printUpdateHint(channel string, done <-chan struct{}) {
+	if channel == "" {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+	if hint := utils.NewVersionHint(version, channel); hint != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n", hint)
+	}
+}
+
+// startTelemetryFlush sends events queued by earlier runs in the background; it does nothing
+// unless the user opted in
+func startTelemetryFlush(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		utils.FlushTelemetry(ctx)
+	}()
+	return done
+}
+
+// waitForTelemetry gives an in-flight flush a moment to finish without holding up the command
+func waitForTelemetry(done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+}
+
 func main() {
-	if err := newRootCommand().Execute(); err != nil {
+	// Interrupting dynactl cancels in-flight Kubernetes calls instead of waiting for them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rootCmd := newRootCommand()
+	if handled, err := commands.DispatchPlugin(ctx, rootCmd, os.Args[1:]); handled {
+		stop()
+		var exitErr *utils.PluginExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+		if err != nil {
+			utils.LogError("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	channel, done := startUpdateCheck(ctx, rootCmd)
+	telemetryDone := startTelemetryFlush(ctx)
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
 		utils.LogError("%v", err)
+		waitForTelemetry(telemetryDone)
 		os.Exit(1)
 	}
+	printUpdateHint(channel, done)
+	waitForTelemetry(telemetryDone)
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -63,3 +64,44 @@ func createTestRootCommand() *cobra.Command {
 
 	return cmd
 }
+
+func TestRequestTimeoutFlag(t *testing.T) {
+	cmd := newRootCommand()
+	flag := cmd.PersistentFlags().Lookup("request-timeout")
+	if flag == nil {
+		t.Fatal("expected --request-timeout flag")
+	}
+	if flag.DefValue != utils.DefaultRequestTimeout.String() {
+		t.Errorf("--request-timeout default = %s, want %s", flag.DefValue, utils.DefaultRequestTimeout)
+	}
+}
+
+func TestKubeClientFlags(t *testing.T) {
+	cmd := newRootCommand()
+	for name, want := range map[string]string{
+		"kube-qps":   "50",
+		"kube-burst": "100",
+	} {
+		flag := cmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag", name)
+		}
+		if flag.DefValue != want {
+			t.Errorf("--%s default = %s, want %s", name, flag.DefValue, want)
+		}
+	}
+
+	sub, _, err := cmd.Find([]string{"cluster", "permission", "check"})
+	if err != nil {
+		t.Fatalf("failed to find cluster permission check: %v", err)
+	}
+	if err := sub.ParseFlags([]string{"--kube-timeout", "5s", "--kube-qps", "200"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if requestTimeout.String() != "5s" {
+		t.Errorf("--kube-timeout set the request timeout to %s, want 5s", requestTimeout)
+	}
+	if kubeQPS != 200 {
+		t.Errorf("--kube-qps = %v, want 200", kubeQPS)
+	}
+}
@@ -1,12 +1,14 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/dynamofl/dynactl/pkg/output"
 	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -14,12 +16,13 @@ import (
 // AddArtifactsCommands adds the artifacts commands to the root command.
 func AddArtifactsCommands(rootCmd *cobra.Command) {
 	artifactsCmd := &cobra.Command{
-		Use:   "artifacts",
-		Short: "Process artifacts for deployment and upgrade",
-		Long:  "Process artifacts for deployment and upgrade.",
+		Use:     "artifacts",
+		Aliases: []string{"art"},
+		Short:   "Process artifacts for deployment and upgrade",
+		Long:    "Process artifacts for deployment and upgrade.",
 	}
 
-	artifactsCmd.AddCommand(createPullCmd(), createMirrorCmd())
+	artifactsCmd.AddCommand(createPullCmd(), createMirrorCmd(), createPromoteCmd(), createListCmd(), createManifestCmd(), createFilterCmd(), createPushBundleCmd(), createPullBundleCmd(), createExportCmd(), createExtractCmd(), createLoadCmd(), createReleaseNotesCmd())
 	rootCmd.AddCommand(artifactsCmd)
 }
 
@@ -35,22 +38,30 @@ func createPullCmd() *cobra.Command {
 			imagesOnly, _ := cmd.Flags().GetBool("images")
 			modelsOnly, _ := cmd.Flags().GetBool("models")
 			chartsOnly, _ := cmd.Flags().GetBool("charts")
+			datasetsOnly, _ := cmd.Flags().GetBool("datasets")
+			licenseOnly, _ := cmd.Flags().GetBool("license")
 
 			if (url == "" && file == "") || (url != "" && file != "") {
 				return fmt.Errorf("exactly one of --url or --file must be set")
 			}
 
-			filtersSpecified := imagesOnly || modelsOnly || chartsOnly
+			filtersSpecified := imagesOnly || modelsOnly || chartsOnly || datasetsOnly || licenseOnly
 			pullOptions := utils.PullOptions{
-				IncludeImages: !filtersSpecified || imagesOnly,
-				IncludeModels: !filtersSpecified || modelsOnly,
-				IncludeCharts: !filtersSpecified || chartsOnly,
+				IncludeImages:   !filtersSpecified || imagesOnly,
+				IncludeModels:   !filtersSpecified || modelsOnly,
+				IncludeCharts:   !filtersSpecified || chartsOnly,
+				IncludeDatasets: !filtersSpecified || datasetsOnly,
+				IncludeLicense:  !filtersSpecified || licenseOnly,
+				Registry:        registryClientOptions(cmd),
 			}
 
 			manifestPath, err := prepareManifest(cmd, url, file, outputDir, "Output directory")
 			if err != nil {
 				return err
 			}
+			if err := verifyManifest(cmd, manifestPath); err != nil {
+				return err
+			}
 
 			_, err = processManifest(cmd, manifestPath, outputDir, pullOptions)
 			return err
@@ -63,6 +74,11 @@ func createPullCmd() *cobra.Command {
 	cmd.Flags().Bool("images", false, "Only pull container images")
 	cmd.Flags().Bool("models", false, "Only pull ML models")
 	cmd.Flags().Bool("charts", false, "Only pull Helm charts")
+	cmd.Flags().Bool("datasets", false, "Only pull evaluation datasets")
+	cmd.Flags().Bool("license", false, "Only pull the license file")
+	cmd.Flags().Bool("force", false, "Process a manifest from a newer release format than this dynactl supports")
+	addManifestVerificationFlags(cmd)
+	addRegistryClientFlags(cmd)
 
 	return cmd
 }
@@ -71,7 +87,12 @@ func createMirrorCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mirror",
 		Short: "Mirror a manifest and push pulled artifacts to a target registry",
-		Long:  "Mirror a manifest by pulling artifacts locally and pushing selected types to a target registry.",
+		Long: `Mirror a manifest by pulling artifacts locally and pushing selected types to a target registry.
+
+With --dry-run nothing is pulled or pushed: the plan of pulls and pushes is printed instead, with
+each image's size and how long it should take at --transfer-rate. -o json gives the plan in a form
+to attach to a change request.`,
+		Annotations: audited,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			url, _ := cmd.Flags().GetString("url")
 			file, _ := cmd.Flags().GetString("file")
@@ -81,6 +102,20 @@ func createMirrorCmd() *cobra.Command {
 			imagesFlag, _ := cmd.Flags().GetBool("images")
 			modelsFlag, _ := cmd.Flags().GetBool("models")
 			chartsFlag, _ := cmd.Flags().GetBool("charts")
+			datasetsFlag, _ := cmd.Flags().GetBool("datasets")
+			createProject, _ := cmd.Flags().GetBool("create-project")
+			retainLatest, _ := cmd.Flags().GetInt("retain-latest")
+			skipTargetCheck, _ := cmd.Flags().GetBool("skip-target-check")
+			skipHarborCheck, _ := cmd.Flags().GetBool("skip-harbor-check")
+			registryAPIURL, _ := cmd.Flags().GetString("registry-api-url")
+			stage, _ := cmd.Flags().GetBool("stage")
+			stageRecord, _ := cmd.Flags().GetString("stage-record")
+			transferReport, _ := cmd.Flags().GetString("transfer-report")
+			overridesFile, _ := cmd.Flags().GetString("target-overrides")
+			resume, _ := cmd.Flags().GetBool("resume")
+			pushConcurrency, _ := cmd.Flags().GetInt("push-concurrency")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			transferRateFlag, _ := cmd.Flags().GetString("transfer-rate")
 
 			if (url == "" && file == "") || (url != "" && file != "") {
 				return fmt.Errorf("exactly one of --url or --file must be set")
@@ -88,9 +123,40 @@ func createMirrorCmd() *cobra.Command {
 			if targetRegistry == "" {
 				return fmt.Errorf("--target-registry must be set")
 			}
+			if stage && (modelsFlag || chartsFlag || datasetsFlag) {
+				return fmt.Errorf("--stage only supports container images")
+			}
+			if pushConcurrency < 1 {
+				return fmt.Errorf("--push-concurrency must be at least 1")
+			}
+			if resume && cacheDirFlag == "" {
+				return fmt.Errorf("--resume needs the --cache-dir of the interrupted run")
+			}
+			transferRate, err := utils.ParseTransferRate(transferRateFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --transfer-rate %q: %v", transferRateFlag, err)
+			}
+			cfg, err := utils.LoadConfig()
+			if err != nil {
+				return err
+			}
+			if err := utils.ValidateHooks(cfg.Hooks.PrePush); err != nil {
+				return fmt.Errorf("invalid hooks.pre_push in config: %w", err)
+			}
+			var overrides map[string]utils.TargetOverride
+			if overridesFile != "" {
+				if overrides, err = utils.LoadTargetOverrides(overridesFile); err != nil {
+					return err
+				}
+				// Fail before the pull; the manifest's own overrides are checked once it is loaded
+				if stage {
+					if err := utils.CheckStagingOverrides(overrides); err != nil {
+						return err
+					}
+				}
+			}
 
 			var cacheDir string
-			var err error
 			cleanup := false
 			if cacheDirFlag != "" {
 				cacheDir = cacheDirFlag
@@ -108,13 +174,14 @@ func createMirrorCmd() *cobra.Command {
 				}
 			}
 
-			filtersSpecified := imagesFlag || modelsFlag || chartsFlag
+			filtersSpecified := imagesFlag || modelsFlag || chartsFlag || datasetsFlag
 			var pullOptions utils.PullOptions
 			if filtersSpecified {
 				pullOptions = utils.PullOptions{
-					IncludeImages: imagesFlag,
-					IncludeModels: modelsFlag,
-					IncludeCharts: chartsFlag,
+					IncludeImages:   imagesFlag,
+					IncludeModels:   modelsFlag,
+					IncludeCharts:   chartsFlag,
+					IncludeDatasets: datasetsFlag,
 				}
 			} else {
 				pullOptions = utils.PullOptions{
@@ -123,23 +190,120 @@ func createMirrorCmd() *cobra.Command {
 					IncludeCharts: false,
 				}
 			}
+			pullOptions.Registry = registryClientOptions(cmd)
+
+			// A dry run only reads the journal, and must not truncate one left for --resume
+			var journal *utils.MirrorJournal
+			if !dryRun || resume {
+				journal, err = utils.OpenMirrorJournal(cacheDir, resume)
+				if err != nil {
+					return err
+				}
+				defer journal.Close()
+			}
+			if resume {
+				cmd.Printf("Resuming from %s: %d artifact(s) already pulled, %d already pushed\n", journal.Path(), journal.Len(utils.JournalStagePulled), journal.Len(utils.JournalStagePushed))
+			}
+			pullOptions.Journal = journal
 
 			manifestPath, err := prepareManifest(cmd, url, file, cacheDir, "Cache directory")
 			if err != nil {
 				return err
 			}
+			if err := verifyManifest(cmd, manifestPath); err != nil {
+				return err
+			}
+			if dryRun {
+				return planMirror(cmd, manifestPath, cacheDir, targetRegistry, pullOptions, overrides, transferRate, !skipTargetCheck && !skipHarborCheck)
+			}
+
+			// Check the target registry before spending time on the pull
+			var target utils.RegistryTarget
+			if !skipTargetCheck && !skipHarborCheck && pullOptions.IncludeImages {
+				manifest, err := utils.LoadManifest(manifestPath)
+				if err != nil {
+					return fmt.Errorf("failed to load manifest: %v", err)
+				}
+				target = utils.DetectRegistryTarget(cmd.Context(), targetRegistry, utils.TargetOptions{
+					CreateProject: createProject,
+					RetainLatest:  retainLatest,
+					APIURL:        registryAPIURL,
+				})
+				if target != nil {
+					repos := utils.MirrorTargetRepositories(manifest.Images, targetRegistry, utils.MergeTargetOverrides(manifest.TargetOverrides, overrides))
+					if err := target.Prepare(cmd.Context(), repos); err != nil {
+						return err
+					}
+					cmd.Printf("✓ %s target %s verified\n", target.Kind(), targetRegistry)
+				}
+			}
 
 			manifest, err := processManifest(cmd, manifestPath, cacheDir, pullOptions)
 			if err != nil {
 				return err
 			}
 
-			cmd.Printf("\n=== Mirroring Artifacts to %s ===\n", targetRegistry)
 			mirrorOptions := utils.MirrorOptionsFromPull(pullOptions)
+			mirrorOptions.PrePushHooks = cfg.Hooks.PrePush
+			mirrorOptions.TargetOverrides = overrides
+			mirrorOptions.Journal = journal
+			mirrorOptions.PushConcurrency = pushConcurrency
+			if target != nil {
+				sizes := utils.MirrorBundleSizes(manifest.Images, cacheDir, targetRegistry, utils.MergeTargetOverrides(manifest.TargetOverrides, overrides))
+				if err := target.CheckCapacity(cmd.Context(), sizes); err != nil {
+					return err
+				}
+				cmd.Printf("✓ %s storage has room for the pulled images\n", target.Kind())
+				mirrorOptions.TargetRepository = target.TargetRepository
+			}
+
+			var record *utils.StagingRecord
+			if stage {
+				stagedOverrides := utils.MergeTargetOverrides(manifest.TargetOverrides, overrides)
+				if err := utils.CheckStagingOverrides(stagedOverrides); err != nil {
+					return err
+				}
+				record = &utils.StagingRecord{
+					ReleaseVersion:  manifest.ReleaseVersion,
+					StagingRegistry: targetRegistry,
+				}
+				mirrorOptions.Pushed = func(source, target, digest string) {
+					record.Images = append(record.Images, utils.NewStagedImage(source, target, digest, stagedOverrides))
+				}
+			}
+
+			var transfers []utils.TransferStat
+			mirrorOptions.Transferred = func(stat utils.TransferStat) {
+				transfers = append(transfers, stat)
+			}
+
+			cmd.Printf("\n=== Mirroring Artifacts to %s ===\n", targetRegistry)
 			if err := utils.MirrorArtifacts(manifest, cacheDir, targetRegistry, mirrorOptions); err != nil {
 				return err
 			}
 
+			if len(transfers) > 0 {
+				report := utils.NewTransferReport(transfers)
+				if err := displayTransferReport(cmd, report); err != nil {
+					return err
+				}
+				if transferReport != "" {
+					if err := writeTransferReport(transferReport, report); err != nil {
+						return err
+					}
+					cmd.Printf("Transfer report written to %s\n", transferReport)
+				}
+			}
+
+			if record != nil && len(record.Images) > 0 {
+				record.StagedAt = time.Now().UTC()
+				if err := utils.WriteStagingRecord(stageRecord, record); err != nil {
+					return err
+				}
+				cmd.Printf("✓ Staged %d image(s) in %s; record written to %s\n", len(record.Images), targetRegistry, stageRecord)
+				cmd.Printf("Once scans pass, run: dynactl artifacts promote --record %s --to <production registry>\n", stageRecord)
+			}
+
 			if cacheDirFlag == "" && keepCache {
 				cmd.Printf("Cache retained at: %s\n", cacheDir)
 			}
@@ -159,10 +323,683 @@ func createMirrorCmd() *cobra.Command {
 	cmd.Flags().Bool("images", false, "Mirror container images")
 	cmd.Flags().Bool("models", false, "Mirror ML models")
 	cmd.Flags().Bool("charts", false, "Mirror Helm charts")
+	cmd.Flags().Bool("datasets", false, "Mirror evaluation datasets")
+	cmd.Flags().Bool("create-project", false, "Create missing Harbor projects on the target registry")
+	cmd.Flags().Int("retain-latest", 0, "With --create-project, keep only the N most recently pushed tags per repository in created projects")
+	cmd.Flags().Bool("skip-target-check", false, "Skip the Harbor, Artifactory, and Nexus target checks")
+	cmd.Flags().Bool("skip-harbor-check", false, "Skip the Harbor project and quota checks")
+	_ = cmd.Flags().MarkDeprecated("skip-harbor-check", "use --skip-target-check instead")
+	cmd.Flags().String("registry-api-url", "", "Management API URL of the target registry when it differs from the registry host (e.g. a Nexus Docker connector port)")
+	cmd.Flags().Bool("force", false, "Process a manifest from a newer release format than this dynactl supports")
+	cmd.Flags().Bool("stage", false, "Treat --target-registry as a staging project and record what was pushed for a later promote")
+	cmd.Flags().String("stage-record", utils.DefaultStagingRecord, "File the --stage record is written to")
+	cmd.Flags().String("transfer-report", "", "Write per-image upload and dedup statistics to this JSON file")
+	cmd.Flags().Int("push-concurrency", 1, "Number of images to upload to the target registry at once")
+	cmd.Flags().Bool("resume", false, "Skip artifacts the journal in --cache-dir records as done by an interrupted run")
+	cmd.Flags().String("target-overrides", "", "YAML or JSON file of target_overrides routing source repositories elsewhere; entries win over the manifest's")
+	addPlanFlags(cmd, "Print the pulls and pushes the mirror would make, with sizes and estimated durations, instead of mirroring")
+	cmd.Flags().String("transfer-rate", "25Mi", "Transfer rate in bytes per second the --dry-run plan estimates durations with (e.g. 25Mi, 100M)")
+	addManifestVerificationFlags(cmd)
+	addRegistryClientFlags(cmd)
+
+	return cmd
+}
+
+// planMirror prints the plan of a mirror dry run. Sizes of images not yet cached are read from
+// their source manifests; with detectTarget, push destinations follow the registry product's
+// path rules, without anything being created on it.
+func planMirror(cmd *cobra.Command, manifestPath, cacheDir, targetRegistry string, pullOptions utils.PullOptions, overrides map[string]utils.TargetOverride, transferRate int64, detectTarget bool) error {
+	manifest, err := utils.LoadManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %v", err)
+	}
+	mirrorOptions := utils.MirrorOptionsFromPull(pullOptions)
+	mirrorOptions.TargetOverrides = overrides
+	mirrorOptions.Journal = pullOptions.Journal
+	if detectTarget && pullOptions.IncludeImages {
+		if target := utils.DetectRegistryTarget(cmd.Context(), targetRegistry, utils.TargetOptions{}); target != nil {
+			mirrorOptions.TargetRepository = target.TargetRepository
+		}
+	}
+	sizeOf := func(reference string) (int64, error) {
+		return utils.RemoteImageSize(reference, pullOptions.Registry)
+	}
+	plan, err := utils.PlanMirror(manifest, cacheDir, targetRegistry, mirrorOptions, transferRate, sizeOf)
+	if err != nil {
+		return err
+	}
+	return renderPlan(cmd, plan)
+}
+
+func createPromoteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Copy staged images into the production registry",
+		Long: `Copy images pushed by 'artifacts mirror --stage' from the staging project into the production
+registry under their original tags. Each image is copied by the digest recorded at staging time,
+and promotion is refused if any staged tag has since moved.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recordPath, _ := cmd.Flags().GetString("record")
+			production, _ := cmd.Flags().GetString("to")
+			createProject, _ := cmd.Flags().GetBool("create-project")
+			skipTargetCheck, _ := cmd.Flags().GetBool("skip-target-check")
+			registryAPIURL, _ := cmd.Flags().GetString("registry-api-url")
+
+			if production == "" {
+				return fmt.Errorf("--to must be set")
+			}
+			record, err := utils.LoadStagingRecord(recordPath)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSuffix(production, "/") == strings.TrimSuffix(record.StagingRegistry, "/") {
+				return fmt.Errorf("--to is the staging registry %s; promote into a different project", record.StagingRegistry)
+			}
+
+			var opts utils.PromoteOptions
+			if !skipTargetCheck {
+				target := utils.DetectRegistryTarget(cmd.Context(), production, utils.TargetOptions{
+					CreateProject: createProject,
+					APIURL:        registryAPIURL,
+				})
+				if target != nil {
+					if err := target.Prepare(cmd.Context(), utils.PromotionRepositories(record, production)); err != nil {
+						return err
+					}
+					cmd.Printf("✓ %s target %s verified\n", target.Kind(), production)
+					opts.TargetRepository = target.TargetRepository
+				}
+			}
+
+			cmd.Printf("\n=== Promoting %d image(s) from %s to %s ===\n", len(record.Images), record.StagingRegistry, production)
+			promoted, err := utils.PromoteImages(cmd.Context(), record, production, opts)
+			for _, image := range promoted {
+				cmd.Printf("✓ %s\n", image.Target)
+			}
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Promoted %d image(s) to %s\n", len(promoted), production)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("record", utils.DefaultStagingRecord, "Staging record written by 'artifacts mirror --stage'")
+	cmd.Flags().String("to", "", "Production registry or project to promote into")
+	cmd.Flags().Bool("create-project", false, "Create missing Harbor projects in the production registry")
+	cmd.Flags().Bool("skip-target-check", false, "Skip the Harbor, Artifactory, and Nexus target checks")
+	cmd.Flags().String("registry-api-url", "", "Management API URL of the production registry when it differs from the registry host")
+	// Not in RegisterCompletions: elsewhere --to is a file or a saved run, not a registry
+	_ = cmd.RegisterFlagCompletionFunc("to", completeRegistries)
+
+	return cmd
+}
+
+// releaseNotesFormats are the output formats of artifacts release-notes
+var releaseNotesFormats = []string{"text", "markdown", "json"}
+
+func createReleaseNotesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release-notes",
+		Short: "Show the release notes published with a manifest",
+		Long: `Fetches the release notes of a release from its manifest artifact in the registry and prints
+them. The notes are found through the manifest's ai.dynamo.release-notes annotation, an artifact
+of type application/vnd.dynamoai.release-notes.v1 attached to the manifest, or a markdown layer
+(such as RELEASE_NOTES.md or CHANGELOG.md) of the manifest artifact, in that order.
+
+The default text output renders the markdown for a terminal; -o markdown prints it unchanged,
+for pasting into a customer briefing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url, _ := cmd.Flags().GetString("url")
+			format, _ := cmd.Flags().GetString("output")
+
+			notes, err := utils.FetchReleaseNotes(cmd.Context(), url, registryClientOptions(cmd))
+			if err != nil {
+				return err
+			}
+			switch format {
+			case "markdown":
+				cmd.Print(notes.Markdown)
+			case "json":
+				data, err := json.MarshalIndent(notes, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal JSON: %v", err)
+				}
+				cmd.Println(string(data))
+			case "text":
+				cmd.Printf("Release notes for %s\n", notes.Manifest)
+				switch notes.Source {
+				case utils.ReleaseNotesFromLayer:
+					cmd.Printf("From %s in the manifest artifact\n\n", notes.File)
+				case utils.ReleaseNotesFromReferrer:
+					cmd.Printf("From %s, attached to the manifest\n\n", notes.Artifact)
+				default:
+					cmd.Printf("From %s, named by the manifest\n\n", notes.Artifact)
+				}
+				cmd.Print(utils.RenderMarkdownText(notes.Markdown))
+			default:
+				return fmt.Errorf("unsupported output format %q; use text, markdown, or json", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("url", "", "URL of the manifest (e.g., artifacts.dynamo.ai/dynamoai/manifest:3.23.0)")
+	_ = cmd.MarkFlagRequired("url")
+	cmd.Flags().StringP("output", "o", "text", "Output format: text, markdown, or json")
+	addRegistryClientFlags(cmd)
+	return cmd
+}
+
+func createListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the artifacts in a manifest file",
+		Long:    "Lists the container images, ML models, and Helm charts in a local manifest file without pulling them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			outputFormat, _ := cmd.Flags().GetString("output")
+			imagesOnly, _ := cmd.Flags().GetBool("images")
+			modelsOnly, _ := cmd.Flags().GetBool("models")
+			chartsOnly, _ := cmd.Flags().GetBool("charts")
+			datasetsOnly, _ := cmd.Flags().GetBool("datasets")
+			licenseOnly, _ := cmd.Flags().GetBool("license")
+
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := utils.LoadManifest(file)
+			if err != nil {
+				return fmt.Errorf("failed to load manifest: %v", err)
+			}
+			if err := checkManifestCompatibility(cmd, manifest); err != nil {
+				return err
+			}
+
+			components := utils.ManifestComponents(manifest, utils.PullOptions{
+				IncludeImages:   imagesOnly,
+				IncludeModels:   modelsOnly,
+				IncludeCharts:   chartsOnly,
+				IncludeDatasets: datasetsOnly,
+				IncludeLicense:  licenseOnly,
+			})
+			if components == nil {
+				components = []utils.Component{}
+			}
+
+			table := &output.Table{
+				Columns: []output.Column{
+					{Header: "TYPE", CSV: "type"},
+					{Header: "NAME", CSV: "name"},
+					{Header: "VERSION", CSV: "version"},
+					{Header: "URI", CSV: "uri"},
+					{Header: "MEDIA TYPE", CSV: "media_type", Wide: true},
+				},
+				Data: components,
+			}
+			for _, c := range components {
+				table.AddRow(c.Type, c.Name, c.Tag, c.URI, c.MediaType)
+			}
+
+			if output.IsTabular(outputFormat) {
+				cmd.Printf("Release %s for %s: %d artifacts\n", manifest.ReleaseVersion, manifest.CustomerName, len(components))
+			}
+			return renderer.Render(cmd.OutOrStdout(), table)
+		},
+	}
+
+	cmd.Flags().String("file", "", "Path to the manifest JSON file")
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+	cmd.Flags().Bool("images", false, "Only list container images")
+	cmd.Flags().Bool("models", false, "Only list ML models")
+	cmd.Flags().Bool("charts", false, "Only list Helm charts")
+	cmd.Flags().Bool("datasets", false, "Only list evaluation datasets")
+	cmd.Flags().Bool("license", false, "Only list the license file")
+	cmd.Flags().Bool("force", false, "Process a manifest from a newer release format than this dynactl supports")
+
+	return cmd
+}
+
+func createManifestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Release engineering helpers for manifest files",
+	}
+	cmd.AddCommand(createManifestBumpCmd())
+	return cmd
+}
+
+func createManifestBumpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Rewrite a manifest for a new release version",
+		Long: `Rewrites the release version segments and tags of every image, model, chart, dataset, and
+license URI in a manifest, then queries the registry to refresh pinned digests, chart and dataset
+checksums, and sizes. Fails without writing anything if an artifact of the new release is missing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			version, _ := cmd.Flags().GetString("version")
+			outFile, _ := cmd.Flags().GetString("out-file")
+			skipRefresh, _ := cmd.Flags().GetBool("skip-refresh")
+
+			manifest, err := utils.LoadManifest(file)
+			if err != nil {
+				return fmt.Errorf("failed to load manifest: %v", err)
+			}
+			if outFile == "" {
+				outFile = filepath.Join(filepath.Dir(file), fmt.Sprintf("manifest-%s.json", version))
+			}
+
+			result, err := utils.BumpManifest(cmd.Context(), manifest, utils.BumpOptions{
+				Version:  version,
+				Refresh:  !skipRefresh,
+				Registry: registryClientOptions(cmd),
+			})
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+
+			for _, change := range result.Changes {
+				cmd.Printf("  %s: %s -> %s\n", change.Field, change.Old, change.New)
+			}
+			for _, warning := range result.Warnings {
+				cmd.Printf("! %s\n", warning)
+			}
+			if err := utils.WriteManifest(outFile, result.Manifest); err != nil {
+				return err
+			}
+			cmd.Printf("✓ Wrote release %s manifest to %s (%d fields changed)\n", version, outFile, len(result.Changes))
+			if skipRefresh {
+				cmd.Printf("! Digests, checksums, and sizes were not refreshed; run without --skip-refresh before publishing\n")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("file", "", "Path to the manifest JSON file of the previous release")
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().String("version", "", "New release version")
+	_ = cmd.MarkFlagRequired("version")
+	cmd.Flags().String("out-file", "", "Where to write the new manifest (default: manifest-<version>.json next to --file)")
+	cmd.Flags().Bool("skip-refresh", false, "Only rewrite URIs, without querying the registry")
+	addRegistryClientFlags(cmd)
+
+	return cmd
+}
+
+func createFilterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "filter",
+		Short: "Reduce a manifest to the products a customer is entitled to",
+		Long: `Writes a copy of the manifest holding only the artifacts of the entitled products, plus the
+artifacts no product claims, which all products share. Entitlements default to the manifest's own
+"entitlements" list. Pull or mirror the reduced manifest to skip components the customer cannot run.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			entitlements, _ := cmd.Flags().GetStringSlice("entitlements")
+			outFile, _ := cmd.Flags().GetString("out-file")
+
+			manifest, err := utils.LoadManifest(file)
+			if err != nil {
+				return fmt.Errorf("failed to load manifest: %v", err)
+			}
+			if err := checkManifestCompatibility(cmd, manifest); err != nil {
+				return err
+			}
+
+			filtered, summary, err := utils.FilterManifestByEntitlements(manifest, entitlements)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			for _, removed := range summary.Removed {
+				cmd.Printf("  - %s\n", removed)
+			}
+			if err := utils.WriteManifest(outFile, filtered); err != nil {
+				return err
+			}
+			cmd.Printf("✓ Wrote manifest for %s to %s: kept %d artifacts, removed %d\n", strings.Join(summary.Entitlements, ", "), outFile, summary.Kept, len(summary.Removed))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("file", "", "Path to the manifest JSON file")
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().StringSlice("entitlements", nil, "Licensed products, e.g. guard,dpd (default: the manifest's entitlements)")
+	cmd.Flags().String("out-file", "", "Where to write the reduced manifest")
+	_ = cmd.MarkFlagRequired("out-file")
+	cmd.Flags().Bool("force", false, "Process a manifest from a newer release format than this dynactl supports")
+
+	return cmd
+}
+
+func createPushBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push-bundle <bucket-url>",
+		Short: "Upload pulled artifacts to S3, GCS, or Azure Blob storage",
+		Long: `Uploads a pulled artifacts directory, or a single file, to cloud object storage with
+multipart uploads. A SHA256SUMS file is written last so pull-bundle can verify every file.
+
+Bucket URLs are s3://bucket/path, gs://bucket/path, or az://account/container/path.
+Credentials are read from AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or ~/.aws/credentials,
+GOOGLE_OAUTH_ACCESS_TOKEN or gcloud, and AZURE_STORAGE_SAS_TOKEN or AZURE_STORAGE_KEY.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := cmd.Flags().GetString("dir")
+			partSize, _ := cmd.Flags().GetInt64("part-size")
+
+			store, prefix, err := utils.OpenBucket(args[0], partSize<<20)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("=== Uploading %s to %s ===\n", dir, args[0])
+			files, err := utils.PushBundle(cmd.Context(), store, prefix, dir)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("✓ Uploaded %d files (%s) and %s\n", len(files), bundleSize(files), utils.BundleChecksumsFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("dir", "./artifacts", "Artifacts directory or single file to upload")
+	cmd.Flags().Int64("part-size", utils.DefaultPartSize>>20, "Multipart upload part size in MiB (minimum 5)")
+
+	return cmd
+}
+
+func createPullBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pull-bundle <bucket-url>",
+		Short: "Download artifacts uploaded with push-bundle and verify their checksums",
+		Long: `Downloads the files listed in a bundle's SHA256SUMS from cloud object storage and verifies
+each one before moving it into place. Use --include to fetch individual artifacts.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+			include, _ := cmd.Flags().GetStringSlice("include")
+
+			store, prefix, err := utils.OpenBucket(args[0], 0)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("=== Downloading %s to %s ===\n", args[0], outputDir)
+			files, err := utils.PullBundle(cmd.Context(), store, prefix, outputDir, include)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("✓ Downloaded and verified %d files (%s)\n", len(files), bundleSize(files))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output-dir", "./artifacts", "Directory to download the bundle into")
+	cmd.Flags().StringSlice("include", nil, "Only download files whose path or name matches these glob patterns (e.g. '*.tar')")
+
+	return cmd
+}
+
+func createExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Pack pulled artifacts into a single compressed archive",
+		Long: `Packs a pulled artifacts directory into one tar archive for transfer into an air gap.
+zstd compresses multi-hundred-GB bundles much faster than gzip at a similar size; both use
+every CPU. gzip archives are written as concurrent gzip members, which gunzip and tar read
+as usual. Unpack with dynactl artifacts extract, or tar --zstd -xf / tar -xzf.
+
+Archives are reproducible: the same files give a byte-identical archive on any machine. The
+archive's SHA-256 is written to <archive>.sha256, which sha256sum -c can check.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := cmd.Flags().GetString("dir")
+			output, _ := cmd.Flags().GetString("archive")
+			compression, _ := cmd.Flags().GetString("compression")
+			level, _ := cmd.Flags().GetInt("compression-level")
+
+			opts := utils.ExportOptions{Compression: compression, Level: level}
+			if err := utils.ValidateExportOptions(opts); err != nil {
+				return err
+			}
+			if output == "" {
+				output = "dynactl-bundle" + utils.ArchiveExtension(compression)
+			}
+			cmd.Printf("=== Exporting %s to %s (%s) ===\n", dir, output, compression)
+			result, err := utils.ExportArtifacts(dir, output, opts)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("✓ Exported %d files (%s) to %s (%s)\n", result.Files, utils.FormatBytes(result.Bytes), result.Path, utils.FormatBytes(result.Size))
+			cmd.Printf("✓ Digest %s recorded in %s\n", result.Digest, result.Path+utils.ArchiveDigestSuffix)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("dir", "./artifacts", "Artifacts directory or single file to export")
+	cmd.Flags().String("archive", "", "Archive to write (default dynactl-bundle.tar.gz, .tar.zst, or .tar)")
+	cmd.Flags().String("compression", utils.CompressionGzip, "Compression: zstd, gzip, or none")
+	cmd.Flags().Int("compression-level", 0, "Compression level, 1-9 for gzip or 1-22 for zstd (0 uses the default)")
+
+	return cmd
+}
+
+func createExtractCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extract <archive>",
+		Short: "Unpack an archive written by artifacts export",
+		Long: `Unpacks a bundle archive into the artifacts directory. zstd, gzip, and uncompressed
+archives are detected from their contents, so renamed files work too. When the .sha256 file
+written by export sits next to the archive, the archive is checked against it first.
+
+Entries that would be written outside the output directory, directly or through a link,
+stop the extraction. Links are skipped unless --symlinks safe allows those that stay inside
+it. Existing files are never replaced silently: pass --overwrite or --skip-existing.
+Permissions and modification times are kept, and ownership too when run as root.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+			overwrite, _ := cmd.Flags().GetBool("overwrite")
+			skipExisting, _ := cmd.Flags().GetBool("skip-existing")
+			symlinks, _ := cmd.Flags().GetString("symlinks")
+
+			opts := utils.ExtractOptions{Symlinks: symlinks}
+			switch {
+			case overwrite && skipExisting:
+				return fmt.Errorf("--overwrite and --skip-existing cannot be used together")
+			case overwrite:
+				opts.Existing = utils.ExistingOverwrite
+			case skipExisting:
+				opts.Existing = utils.ExistingSkip
+			}
+
+			digest, err := utils.VerifyArchiveDigest(args[0])
+			if err != nil {
+				return err
+			}
+			if digest != "" {
+				cmd.Printf("✓ Archive matches %s (%s)\n", args[0]+utils.ArchiveDigestSuffix, digest)
+			} else {
+				cmd.Printf("! No %s found next to the archive; its integrity is not verified\n", utils.ArchiveDigestSuffix)
+			}
+
+			cmd.Printf("=== Extracting %s to %s ===\n", args[0], outputDir)
+			result, err := utils.ExtractArchive(args[0], outputDir, opts)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("✓ Extracted %d files", len(result.Files))
+			if len(result.Links) > 0 {
+				cmd.Printf(", %d links", len(result.Links))
+			}
+			if len(result.Skipped) > 0 {
+				cmd.Printf(" (%d entries skipped)", len(result.Skipped))
+			}
+			cmd.Println()
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output-dir", "./artifacts", "Directory to extract the archive into")
+	cmd.Flags().Bool("overwrite", false, "Replace files that already exist in the output directory")
+	cmd.Flags().Bool("skip-existing", false, "Keep files that already exist in the output directory")
+	cmd.Flags().String("symlinks", utils.SymlinksSkip, "Links in the archive: skip, safe (create those that stay inside the output directory), or fail")
+
+	return cmd
+}
+
+func createLoadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "load",
+		Short: "Import pulled images straight into node container runtimes",
+		Long: `Imports the pulled image archives of a manifest into the containerd of each node, for
+clusters without an internal registry. Images keep their original references, so workloads
+using them need an imagePullPolicy of IfNotPresent.
+
+With --nodes, archives are streamed to each host over ssh and imported with sudo. With
+--daemonset, a privileged DaemonSet is started on every node and archives are streamed into it
+through the Kubernetes API; its --loader-image must already be present on the nodes.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := cmd.Flags().GetString("dir")
+			file, _ := cmd.Flags().GetString("file")
+			runtime, _ := cmd.Flags().GetString("runtime")
+			nodes, _ := cmd.Flags().GetStringSlice("nodes")
+			sshUser, _ := cmd.Flags().GetString("ssh-user")
+			useDaemonSet, _ := cmd.Flags().GetBool("daemonset")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			loaderImage, _ := cmd.Flags().GetString("loader-image")
+			nodeSelector, _ := cmd.Flags().GetStringToString("node-selector")
+
+			if (len(nodes) == 0) == !useDaemonSet {
+				return fmt.Errorf("exactly one of --nodes or --daemonset must be set")
+			}
+			if _, err := utils.ImportCommand(runtime); err != nil {
+				return err
+			}
+
+			if file == "" {
+				var err error
+				if file, err = findManifestFile(dir); err != nil {
+					return err
+				}
+			}
+			manifest, err := utils.LoadManifest(file)
+			if err != nil {
+				return fmt.Errorf("failed to load manifest: %v", err)
+			}
+			archives, err := utils.ImageArchives(manifest, dir)
+			if err != nil {
+				return err
+			}
+
+			var results []utils.NodeLoadResult
+			if useDaemonSet {
+				kc, err := kubeChecker(cmd)
+				if err != nil {
+					return err
+				}
+				cmd.Printf("=== Loading %d images through DaemonSet in %s ===\n", len(archives), namespace)
+				results, err = kc.LoadImagesWithDaemonSet(cmd.Context(), utils.DaemonSetLoadOptions{
+					Namespace:    namespace,
+					Image:        loaderImage,
+					NodeSelector: nodeSelector,
+					Runtime:      runtime,
+				}, archives)
+				if err != nil {
+					return err
+				}
+			} else {
+				cmd.Printf("=== Loading %d images into %d nodes over ssh ===\n", len(archives), len(nodes))
+				if results, err = utils.LoadImagesOverSSH(cmd.Context(), nodes, sshUser, runtime, archives); err != nil {
+					return err
+				}
+			}
+
+			failed := 0
+			for _, result := range results {
+				if result.Err != nil {
+					failed++
+					cmd.Printf("✗ %s: %v\n", result.Node, result.Err)
+					continue
+				}
+				cmd.Printf("✓ %s: loaded %d images\n", result.Node, result.Loaded)
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to load images into %d of %d nodes", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("dir", "./artifacts", "Directory holding the pulled image archives")
+	cmd.Flags().String("file", "", "Path to the manifest JSON file (default: the manifest in --dir)")
+	cmd.Flags().String("runtime", utils.RuntimeContainerd, "Node container runtime: "+strings.Join(utils.ContainerRuntimes, ", "))
+	cmd.Flags().StringSlice("nodes", nil, "Node hosts to load images into over ssh")
+	cmd.Flags().String("ssh-user", "", "User for ssh connections (default: from ssh config)")
+	cmd.Flags().Bool("daemonset", false, "Load images through a privileged DaemonSet instead of ssh")
+	cmd.Flags().StringP("namespace", "n", "kube-system", "Namespace for the loader DaemonSet")
+	cmd.Flags().String("loader-image", utils.DefaultLoaderImage, "Image for the loader DaemonSet; must already be present on the nodes")
+	cmd.Flags().StringToString("node-selector", nil, "Only load into nodes with these labels (e.g. role=gpu)")
 
 	return cmd
 }
 
+// bundleSize formats the total size of transferred bundle files
+// displayTransferReport shows how much of each image was uploaded and how much the target
+// registry already had
+func displayTransferReport(cmd *cobra.Command, report *utils.TransferReport) error {
+	table := &output.Table{
+		Columns: []output.Column{
+			{Header: "IMAGE", MaxWidth: 60},
+			{Header: "LAYERS SENT"},
+			{Header: "UPLOADED"},
+			{Header: "SKIPPED"},
+			{Header: "TIME"},
+		},
+	}
+	for _, s := range append(report.Artifacts, report.Total) {
+		table.AddRow(s.Artifact, fmt.Sprintf("%d/%d", s.UploadedLayers, s.Layers),
+			utils.FormatBytes(s.UploadedBytes), utils.FormatBytes(s.SkippedBytes()), s.Duration.Round(time.Second).String())
+	}
+	cmd.Printf("\n=== Transfer Summary ===\n")
+	renderer, _ := output.NewRenderer(output.FormatTable)
+	if err := renderer.Render(cmd.OutOrStdout(), table); err != nil {
+		return err
+	}
+	cmd.Printf("✓ %s\n", report.Summary())
+	if report.BytesPerSecond > 0 {
+		cmd.Printf("Average upload rate: %s/s\n", utils.FormatBytes(report.BytesPerSecond))
+	}
+	return nil
+}
+
+func writeTransferReport(path string, report *utils.TransferReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer report: %w", err)
+	}
+	defer f.Close()
+	if err := report.WriteJSON(f); err != nil {
+		return fmt.Errorf("failed to write transfer report: %w", err)
+	}
+	return f.Close()
+}
+
+func bundleSize(files []utils.BundleFile) string {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return utils.FormatBytes(total)
+}
+
 func prepareManifest(cmd *cobra.Command, url, file, workspace, workspaceLabel string) (string, error) {
 	if url != "" {
 		if err := os.MkdirAll(workspace, 0o755); err != nil {
@@ -207,6 +1044,9 @@ func processManifest(cmd *cobra.Command, manifestPath, outputDir string, options
 	if err != nil {
 		return nil, fmt.Errorf("failed to load manifest: %v", err)
 	}
+	if err := checkManifestCompatibility(cmd, manifest); err != nil {
+		return nil, err
+	}
 	options = utils.NormalizePullOptions(options)
 
 	displayManifestInfo(cmd, manifest)
@@ -221,6 +1061,12 @@ func processManifest(cmd *cobra.Command, manifestPath, outputDir string, options
 	if options.IncludeCharts {
 		totalArtifacts += len(manifest.Charts)
 	}
+	if options.IncludeDatasets {
+		totalArtifacts += len(manifest.Datasets)
+	}
+	if options.IncludeLicense && manifest.License != nil {
+		totalArtifacts++
+	}
 	if totalArtifacts == 0 {
 		if strings.Contains(manifestPath, "testdata") {
 			utils.LogInfo("No artifacts found in manifest, skipping artifact pull")
@@ -236,7 +1082,12 @@ func processManifest(cmd *cobra.Command, manifestPath, outputDir string, options
 		utils.CheckHarborLogin(registry)
 	}
 
-	if err := utils.PullArtifacts(manifest, outputDir, options); err != nil {
+	if options.Registry.RateLimits == nil {
+		options.Registry.RateLimits = utils.NewRateLimitTracker()
+	}
+	err = utils.PullArtifacts(manifest, outputDir, options)
+	displayRateLimits(cmd, options.Registry.RateLimits.Statuses())
+	if err != nil {
 		return nil, fmt.Errorf("failed to pull artifacts from manifest: %v", err)
 	}
 
@@ -247,6 +1098,97 @@ func processManifest(cmd *cobra.Command, manifestPath, outputDir string, options
 	return manifest, nil
 }
 
+func addManifestVerificationFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("verify-manifest", false, "Verify the manifest's cosign or minisign signature before acting on it")
+	cmd.Flags().String("manifest-key", "", "Public key to verify the manifest with: cosign PEM or minisign (default: the key embedded in this build)")
+	cmd.Flags().String("manifest-signature", "", "Detached manifest signature (default: manifest.json.minisig or manifest.json.sig next to the manifest)")
+}
+
+// displayRateLimits prints the remaining quota and throttling of each registry that reported them
+func displayRateLimits(cmd *cobra.Command, statuses []utils.RateLimitStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	cmd.Printf("\nRegistry rate limits:\n")
+	for _, status := range statuses {
+		mark := "✓"
+		if status.Throttled > 0 || status.Remaining == 0 {
+			mark = "!"
+		}
+		cmd.Printf("  %s %s\n", mark, status)
+	}
+}
+
+// addRegistryClientFlags adds the flags that control how the source registry is reached
+func addRegistryClientFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("plain-http", false, "Use HTTP instead of HTTPS for the source registry")
+	cmd.Flags().Bool("insecure-skip-tls-verify", false, "Skip TLS certificate verification for the source registry")
+	cmd.Flags().String("ca-file", "", "PEM CA bundle to trust for a privately signed source registry")
+	cmd.Flags().Duration("registry-timeout", 0, "Timeout for each request to the source registry (default: no limit)")
+}
+
+func registryClientOptions(cmd *cobra.Command) utils.RegistryClientOptions {
+	plainHTTP, _ := cmd.Flags().GetBool("plain-http")
+	insecure, _ := cmd.Flags().GetBool("insecure-skip-tls-verify")
+	caFile, _ := cmd.Flags().GetString("ca-file")
+	timeout, _ := cmd.Flags().GetDuration("registry-timeout")
+	return utils.RegistryClientOptions{
+		PlainHTTP:             plainHTTP,
+		InsecureSkipTLSVerify: insecure,
+		CAFile:                caFile,
+		Timeout:               timeout,
+	}
+}
+
+// verifyManifest checks the manifest signature when --verify-manifest is set, before any
+// repository named in the manifest is contacted
+func verifyManifest(cmd *cobra.Command, manifestPath string) error {
+	verify, _ := cmd.Flags().GetBool("verify-manifest")
+	keyFile, _ := cmd.Flags().GetString("manifest-key")
+	signature, _ := cmd.Flags().GetString("manifest-signature")
+	if !verify {
+		if keyFile != "" || signature != "" {
+			return fmt.Errorf("--manifest-key and --manifest-signature require --verify-manifest")
+		}
+		return nil
+	}
+
+	var key []byte
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest key: %w", err)
+		}
+		key = data
+	}
+	if signature == "" {
+		found, err := utils.ManifestSignatureFile(manifestPath)
+		if err != nil {
+			return err
+		}
+		signature = found
+	}
+	if err := utils.VerifyManifestSignature(manifestPath, signature, key); err != nil {
+		return err
+	}
+	cmd.Printf("✓ Manifest signature verified (%s)\n", filepath.Base(signature))
+	return nil
+}
+
+// checkManifestCompatibility refuses manifests this build may misread unless --force is set, in
+// which case the problems are only logged
+func checkManifestCompatibility(cmd *cobra.Command, manifest *utils.ArtifactManifest) error {
+	err := utils.CheckManifestCompatibility(manifest)
+	if err == nil {
+		return nil
+	}
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		utils.LogWarning("Continuing despite incompatibility (--force): %v", err)
+		return nil
+	}
+	return err
+}
+
 func displayManifestInfo(cmd *cobra.Command, manifest *utils.ArtifactManifest) {
 	cmd.Printf("Manifest loaded successfully:\n")
 	cmd.Printf("  Customer: %s (%s)\n", manifest.CustomerName, manifest.CustomerID)
@@ -271,6 +1213,12 @@ func displayArtifactSummary(cmd *cobra.Command, manifest *utils.ArtifactManifest
 	if options.IncludeCharts && len(manifest.Charts) > 0 {
 		cmd.Printf("  Helm Charts: %d\n", len(manifest.Charts))
 	}
+	if options.IncludeDatasets && len(manifest.Datasets) > 0 {
+		cmd.Printf("  Datasets: %d\n", len(manifest.Datasets))
+	}
+	if options.IncludeLicense && manifest.License != nil {
+		cmd.Printf("  License: %s\n", manifest.License.URI)
+	}
 }
 
 func extractFilenameFromURL(url string) string {
@@ -337,6 +1285,16 @@ func extractRegistryFromManifest(manifest *utils.ArtifactManifest, options utils
 		}
 	}
 
+	if options.IncludeDatasets && len(manifest.Datasets) > 0 {
+		uri := strings.TrimPrefix(manifest.Datasets[0].URI, "oci://")
+		if strings.Contains(uri, "/") {
+			parts := strings.SplitN(uri, "/", 2)
+			if len(parts) == 2 {
+				return parts[0]
+			}
+		}
+	}
+
 	return ""
 }
 
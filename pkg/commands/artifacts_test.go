@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -42,6 +43,22 @@ func TestArtifactsCommands(t *testing.T) {
 	assert.NotNil(t, mirrorCmd.Flags().Lookup("images"), "mirror images flag should exist")
 	assert.NotNil(t, mirrorCmd.Flags().Lookup("models"), "mirror models flag should exist")
 	assert.NotNil(t, mirrorCmd.Flags().Lookup("charts"), "mirror charts flag should exist")
+
+	loadCmd := findSubcommand(artifactsCmd, "load")
+	assert.NotNil(t, loadCmd, "load command should exist")
+	assert.Equal(t, "containerd", loadCmd.Flags().Lookup("runtime").DefValue)
+}
+
+func TestLoadRequiresOneTransport(t *testing.T) {
+	for _, args := range [][]string{{"load"}, {"load", "--nodes", "node1", "--daemonset"}} {
+		rootCmd := &cobra.Command{}
+		AddArtifactsCommands(rootCmd)
+		rootCmd.SetArgs(append([]string{"artifacts"}, args...))
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SetErr(&bytes.Buffer{})
+		err := rootCmd.Execute()
+		assert.ErrorContains(t, err, "exactly one of --nodes or --daemonset", "args %v", args)
+	}
 }
 
 func TestExtractFilenameFromURL(t *testing.T) {
@@ -173,6 +190,18 @@ func TestArtifactsMirrorCommandValidation(t *testing.T) {
 	err = rootCmd.Execute()
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "--target-registry must be set")
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"artifacts", "mirror", "--file", manifestFile, "--target-registry", "registry.example.com", "--resume"})
+	err = rootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--resume needs the --cache-dir")
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"artifacts", "mirror", "--file", manifestFile, "--target-registry", "registry.example.com", "--push-concurrency", "0"})
+	err = rootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--push-concurrency must be at least 1")
 }
 
 // Helper function to find a subcommand by name
@@ -184,3 +213,144 @@ func findSubcommand(cmd *cobra.Command, name string) *cobra.Command {
 	}
 	return nil
 }
+
+func TestArtifactsListCommand(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	manifest := filepath.Join("..", "..", "testdata", "sample.manifest.json")
+	rootCmd.SetArgs([]string{"artifacts", "list", "--file", manifest, "--charts", "-o", "csv"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "type,name,version,uri,media_type", lines[0])
+	assert.Greater(t, len(lines), 1, "sample manifest should list charts")
+	for _, line := range lines[1:] {
+		assert.True(t, strings.HasPrefix(line, "helmChart,"), "only charts should be listed, got %q", line)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"artifacts", "list", "--file", manifest, "-o", "xml"})
+	err = rootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported output format")
+}
+
+func TestArtifactsListRejectsNewerManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	data := `{"schema_version": 99, "release_version": "3.22.2", "customer_name": "Acme", "images": ["registry.example/app:1.0"]}`
+	assert.NoError(t, os.WriteFile(manifest, []byte(data), 0o644))
+
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	rootCmd.SetArgs([]string{"artifacts", "list", "--file", manifest})
+	err := rootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "schema version 99")
+
+	rootCmd.SetArgs([]string{"artifacts", "list", "--file", manifest, "--force"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "registry.example/app")
+}
+
+func TestArtifactsExportAndExtract(t *testing.T) {
+	src := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "api.tar"), []byte("image"), 0o644))
+	archive := filepath.Join(t.TempDir(), "bundle.tar.zst")
+	dest := t.TempDir()
+
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	rootCmd.SetArgs([]string{"artifacts", "export", "--dir", src, "--archive", archive, "--compression", "zstd", "--compression-level", "19"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "Exported 1 files")
+
+	rootCmd.SetArgs([]string{"artifacts", "extract", archive, "--output-dir", dest})
+	assert.NoError(t, rootCmd.Execute())
+	data, err := os.ReadFile(filepath.Join(dest, "api.tar"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+
+	rootCmd.SetArgs([]string{"artifacts", "export", "--dir", src, "--compression", "none", "--compression-level", "3"})
+	assert.Error(t, rootCmd.Execute())
+}
+
+func TestArtifactsPullVerifiesManifestBeforePulling(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.json")
+	assert.NoError(t, os.WriteFile(manifest, []byte(`{"release_version": "3.22.2", "images": ["evil.example.com/api:1.0"]}`), 0o644))
+
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	rootCmd.SetArgs([]string{"artifacts", "pull", "--file", manifest, "--output-dir", dir, "--verify-manifest"})
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "no signature found")
+	assert.NotContains(t, buf.String(), "Pulling Artifacts")
+}
+
+func TestArtifactsManifestBumpCommand(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	outFile := filepath.Join(t.TempDir(), "manifest.json")
+	manifest := filepath.Join("..", "..", "testdata", "sample.manifest.json")
+	rootCmd.SetArgs([]string{"artifacts", "manifest", "bump", "--file", manifest, "--version", "3.23.0", "--out-file", outFile, "--skip-refresh"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "release_version: 3.22.2 -> 3.23.0")
+
+	data, err := os.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"release_version": "3.23.0"`)
+	assert.Contains(t, string(data), "artifacts.dynamo.ai/dynamoai/3.23.0/images/dynamoai-api:latest")
+}
+
+func TestArtifactsFilterCommand(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.json")
+	err := os.WriteFile(manifest, []byte(`{
+  "release_version": "3.22.2",
+  "images": ["oci://host/images/dynamoai-api:3.22.2", "oci://host/images/dynamoai-dpd:3.22.2"],
+  "models": [],
+  "charts": [],
+  "products": {"guard": {}, "dpd": {"images": ["oci://host/images/dynamoai-dpd:3.22.2"]}}
+}`), 0o644)
+	assert.NoError(t, err)
+
+	rootCmd := &cobra.Command{}
+	AddArtifactsCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	outFile := filepath.Join(dir, "guard.json")
+	rootCmd.SetArgs([]string{"artifacts", "filter", "--file", manifest, "--entitlements", "guard", "--out-file", outFile})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "kept 1 artifacts, removed 1")
+
+	data, err := os.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "dynamoai-dpd")
+	assert.Contains(t, string(data), "dynamoai-api")
+}
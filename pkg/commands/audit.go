@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// auditAnnotation marks commands that change state outside dynactl's own read-only checks;
+// every run of them is appended to the audit log
+const auditAnnotation = "dynactl.dynamo.ai/audit"
+
+// audited marks a command for the audit log
+var audited = map[string]string{auditAnnotation: "true"}
+
+// EnableAuditing wraps every command marked for auditing so its arguments, masked flags, and
+// result are recorded after it runs. Call it once all commands are registered.
+func EnableAuditing(root *cobra.Command) {
+	var walk func(*cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if cmd.Annotations[auditAnnotation] == "true" && cmd.RunE != nil {
+			cmd.RunE = auditRun(root, cmd.RunE)
+		}
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(root)
+}
+
+func auditRun(root *cobra.Command, run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		flags := map[string]string{}
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			flags[f.Name] = f.Value.String()
+		})
+		entry := utils.NewAuditEntry(cmd.CommandPath(), args, flags)
+		entry.Version = root.Version
+
+		err := run(cmd, args)
+		entry.Finish(err)
+		if auditErr := utils.RecordAudit(cmd.Context(), entry); auditErr != nil {
+			utils.LogWarning("Operation not recorded in the audit log: %v", auditErr)
+		}
+		return err
+	}
+}
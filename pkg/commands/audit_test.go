@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableAuditingRecordsMutatingCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	auditLog := filepath.Join(home, "audit.log")
+	t.Setenv("DYNACTL_AUDIT_LOG", auditLog)
+
+	rootCmd := &cobra.Command{Use: "dynactl", Version: "1.2.3"}
+	AddRegistryCommands(rootCmd)
+	EnableAuditing(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+
+	rootCmd.SetArgs([]string{"registry", "login", "registry.example", "-u", "robot", "-p", "hunter2"})
+	require.NoError(t, rootCmd.Execute())
+	rootCmd.SetArgs([]string{"registry", "list"})
+	require.NoError(t, rootCmd.Execute())
+
+	data, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2", "secrets must be masked")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1, "only the mutating login should be audited")
+	var entry utils.AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "dynactl registry login", entry.Command)
+	assert.Equal(t, []string{"registry.example"}, entry.Args)
+	assert.Equal(t, "robot", entry.Flags["username"])
+	assert.Equal(t, utils.AuditSuccess, entry.Result)
+	assert.Equal(t, "1.2.3", entry.Version)
+}
+
+func TestEnableAuditingMasksRunPassthroughSecrets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	auditLog := filepath.Join(home, "audit.log")
+	t.Setenv("DYNACTL_AUDIT_LOG", auditLog)
+
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddRunCommands(rootCmd)
+	EnableAuditing(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"run", "--in-cluster", "-n", "dynamo", "--image", "dynactl:v1", "--dry-run",
+		"--", "registry", "login", "r", "-u", "x", "--password", "hunter2"})
+	require.NoError(t, rootCmd.Execute())
+
+	data, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2", "secrets passed through to the in-cluster command must be masked")
+	var entry utils.AuditEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "dynactl run", entry.Command)
+	assert.Equal(t, []string{"registry", "login", "r", "-u", "x", "--password", "****"}, entry.Args)
+}
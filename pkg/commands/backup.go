@@ -0,0 +1,512 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/output"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// AddBackupCommands registers backup related commands with the root command.
+func AddBackupCommands(rootCmd *cobra.Command) {
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up Dynamo AI's stateful components",
+		Long:  "Commands that back up the database, configuration, Helm values, and volumes of a Dynamo AI namespace into versioned archives.",
+	}
+
+	backupCmd.AddCommand(createBackupCreateCmd())
+	backupCmd.AddCommand(createBackupListCmd())
+	backupCmd.AddCommand(createBackupRestoreCmd())
+	backupCmd.AddCommand(createBackupScheduleCmd())
+	rootCmd.AddCommand(backupCmd)
+}
+
+// backupDir resolves the backup directory from --dir, then the config file, then the default
+func backupDir(cmd *cobra.Command) (string, error) {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir, nil
+	}
+	cfg, err := utils.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Backup.Dir != "" {
+		return cfg.Backup.Dir, nil
+	}
+	return utils.DefaultBackupDir()
+}
+
+func createBackupCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Back up a Dynamo AI namespace into a backup archive",
+		Long: `Backs up the stateful components of a Dynamo AI namespace into <dir>/<name>.tar.gz:
+
+  database       pg_dump of the database, run in the database pod (--db-method exec), or a
+                 Backup taken by the CloudNativePG operator (--db-method cnpg)
+  configmaps     every ConfigMap in the namespace
+  secrets        every Secret, with values left out unless --include-secrets is set
+  helm-values    the values of the latest deployed revision of each Helm release, with values
+                 under keys such as password, token, or apiKey redacted unless
+                 --include-secrets is set
+  pvc-snapshots  a VolumeSnapshot of each bound PVC, left in the cluster
+
+The archive starts with backup.json, which records the format version, the dynactl version,
+and the outcome of each component; see backup list.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			name, _ := cmd.Flags().GetString("name")
+			components, _ := cmd.Flags().GetStringSlice("components")
+			includeSecrets, _ := cmd.Flags().GetBool("include-secrets")
+			snapshotClass, _ := cmd.Flags().GetString("snapshot-class")
+			snapshotTimeout, _ := cmd.Flags().GetDuration("snapshot-timeout")
+			dbMethod, _ := cmd.Flags().GetString("db-method")
+			dbSelector, _ := cmd.Flags().GetString("db-selector")
+			dbContainer, _ := cmd.Flags().GetString("db-container")
+			dbName, _ := cmd.Flags().GetString("db-name")
+			dbUser, _ := cmd.Flags().GetString("db-user")
+			dbCluster, _ := cmd.Flags().GetString("db-cluster")
+			retain, _ := cmd.Flags().GetInt("retain")
+
+			if retain < 0 {
+				return fmt.Errorf("--retain must not be negative")
+			}
+			dir, err := backupDir(cmd)
+			if err != nil {
+				return err
+			}
+			opts := utils.BackupOptions{
+				Namespace:  namespace,
+				Dir:        dir,
+				Name:       name,
+				Components: components,
+				Database: utils.DatabaseBackupOptions{
+					Method:    dbMethod,
+					Selector:  dbSelector,
+					Container: dbContainer,
+					Name:      dbName,
+					User:      dbUser,
+					Cluster:   dbCluster,
+				},
+				IncludeSecrets:  includeSecrets,
+				SnapshotClass:   snapshotClass,
+				SnapshotTimeout: snapshotTimeout,
+				DynactlVersion:  cmd.Root().Version,
+			}
+			if err := utils.ValidateBackupOptions(&opts); err != nil {
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			meta, err := kc.CreateBackup(ctx, opts)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			for _, c := range meta.Components {
+				marker := "✓"
+				if c.Status != utils.BackupOK {
+					marker = "!"
+				}
+				line := fmt.Sprintf("%s %-14s %s", marker, c.Name, c.Status)
+				if c.Bytes > 0 {
+					line += fmt.Sprintf(", %d file(s), %s", len(c.Files), utils.FormatBytes(c.Bytes))
+				}
+				if c.Message != "" {
+					line += " (" + c.Message + ")"
+				}
+				cmd.Println(line)
+			}
+			if opts.IncludeSecrets {
+				cmd.Println("! Secret values and Helm credentials are stored unencrypted in the archive; keep it somewhere safe")
+			}
+			cmd.Printf("✓ Backup %s written to %s (%s)\n", meta.Name, meta.Path, utils.FormatBytes(meta.Size))
+
+			if retain > 0 {
+				pruned, err := kc.PruneBackups(ctx, dir, namespace, retain)
+				if err != nil {
+					cmd.Printf("✗ Failed to prune old backups: %v\n", err)
+					return err
+				}
+				for _, b := range pruned {
+					cmd.Printf("✓ Pruned backup %s (%s)\n", b.Name, b.Created.Format(time.RFC3339))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("namespace", "n", "", "Namespace to back up (required)")
+	cmd.Flags().String("dir", "", "Directory to write the backup to (default: backup.dir from the config file, or ~/.dynactl/backups)")
+	cmd.Flags().String("name", "", "Backup name (default <namespace>-<UTC time>)")
+	cmd.Flags().StringSlice("components", nil, "Components to back up: "+strings.Join(utils.BackupComponents, ", ")+" (default all)")
+	cmd.Flags().Bool("include-secrets", false, "Store Secret values and credentials in Helm values in the archive instead of redacting them")
+	cmd.Flags().String("snapshot-class", "", "VolumeSnapshotClass for PVC snapshots (default: the cluster default)")
+	cmd.Flags().Duration("snapshot-timeout", 5*time.Minute, "How long to wait for PVC snapshots to be ready; 0 does not wait")
+	cmd.Flags().String("db-method", utils.DatabaseMethodExec, "How to back up the database: "+strings.Join(utils.DatabaseMethods, " or "))
+	cmd.Flags().String("db-selector", utils.DefaultDatabaseSelector, "Label selector of the database pod (exec method)")
+	cmd.Flags().String("db-container", "", "Container of the database pod to run pg_dump in (default: the first)")
+	cmd.Flags().String("db-name", "", "Database to dump (default: the pod's POSTGRES_DB)")
+	cmd.Flags().String("db-user", "", "User to dump as (default: the pod's POSTGRES_USER)")
+	cmd.Flags().String("db-cluster", "", "CloudNativePG Cluster to back up (cnpg method)")
+	cmd.Flags().Int("retain", 0, "After a successful backup, delete all but the newest N backups of the namespace and their snapshots (0 keeps all)")
+	cmd.MarkFlagRequired("namespace")
+	return cmd
+}
+
+func createBackupListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List backup archives",
+		Long:    "Lists the backups in the backup directory, oldest first, from the backup.json metadata stored in each archive.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, _ := cmd.Flags().GetString("output")
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+			dir, err := backupDir(cmd)
+			if err != nil {
+				return err
+			}
+			backups, err := utils.ListBackups(dir)
+			if err != nil {
+				return err
+			}
+			if backups == nil {
+				backups = []utils.BackupMetadata{}
+			}
+			if len(backups) == 0 && output.IsTabular(outputFormat) {
+				cmd.Printf("No backups in %s; run `dynactl backup create` to take one\n", dir)
+				return nil
+			}
+			return renderer.Render(cmd.OutOrStdout(), backupListTable(backups))
+		},
+	}
+	cmd.Flags().String("dir", "", "Directory to list backups from (default: backup.dir from the config file, or ~/.dynactl/backups)")
+	cmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+	return cmd
+}
+
+func createBackupRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <backup>",
+		Short: "Restore a backup archive into a namespace after validating the cluster",
+		Long: `Restores a backup, given by name (see backup list) or path, into its namespace or the one given
+with -n. The target cluster is validated first: it must run the same or a newer Kubernetes minor
+version, the namespace must not run workloads (unless --force), the StorageClasses the PVCs need
+must exist, and every snapshot must be reachable, either as a VolumeSnapshot in the namespace or
+through the storage handle recorded in the backup. Use --validate-only to stop there.
+
+Components are restored in dependency order: ConfigMaps and Secrets, PVCs recreated from their
+snapshots, the Helm values (written to --values-dir with the helm commands to reinstall each
+release), and last the database, whose dump is loaded with pg_restore once the reinstalled
+database pod is ready. Smoke checks then verify the PVCs bind, the database holds tables, and
+the pods start.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			components, _ := cmd.Flags().GetStringSlice("components")
+			storageClass, _ := cmd.Flags().GetString("storage-class")
+			valuesDir, _ := cmd.Flags().GetString("values-dir")
+			force, _ := cmd.Flags().GetBool("force")
+			validateOnly, _ := cmd.Flags().GetBool("validate-only")
+			dbSelector, _ := cmd.Flags().GetString("db-selector")
+			dbContainer, _ := cmd.Flags().GetString("db-container")
+			dbName, _ := cmd.Flags().GetString("db-name")
+			dbUser, _ := cmd.Flags().GetString("db-user")
+			dbWait, _ := cmd.Flags().GetDuration("db-wait")
+
+			dir, err := backupDir(cmd)
+			if err != nil {
+				return err
+			}
+			archive, err := utils.ResolveBackup(dir, args[0])
+			if err != nil {
+				return err
+			}
+			meta, err := utils.ReadBackupMetadata(archive)
+			if err != nil {
+				return err
+			}
+			if namespace == "" {
+				namespace = meta.Namespace
+			}
+			if valuesDir == "" {
+				valuesDir = meta.Name + "-values"
+			}
+			opts := utils.RestoreOptions{
+				Namespace:  namespace,
+				Components: components,
+				Database: utils.DatabaseBackupOptions{
+					Selector:  dbSelector,
+					Container: dbContainer,
+					Name:      dbName,
+					User:      dbUser,
+				},
+				DatabaseWait: dbWait,
+				StorageClass: storageClass,
+				ValuesDir:    valuesDir,
+				Force:        force,
+				OnStep: func(step utils.CheckResult) {
+					printCheckResult(cmd, step)
+				},
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			cmd.Printf("Validating the restore of %s (taken %s from %s) into %s\n", meta.Name, meta.Created.Local().Format("2006-01-02 15:04"), meta.Namespace, namespace)
+			checks, err := kc.ValidateRestore(ctx, meta, opts)
+			if err != nil {
+				return err
+			}
+			if err := renderCheckResults(cmd, "Restore", checks, ""); err != nil {
+				return err
+			}
+			if validateOnly {
+				return nil
+			}
+			if err := confirm(cmd, fmt.Sprintf("restore backup %s into namespace %s", meta.Name, namespace)); err != nil {
+				return err
+			}
+
+			cmd.Println("\nRestoring")
+			result, err := kc.RestoreBackup(ctx, archive, meta, opts)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			cmd.Println("\nSmoke checks")
+			if err := renderCheckResults(cmd, "Post-restore", result.Smoke, ""); err != nil {
+				return err
+			}
+			cmd.Printf("✓ Restored %s into %s\n", meta.Name, namespace)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("namespace", "n", "", "Namespace to restore into (default: the namespace the backup was taken from)")
+	cmd.Flags().String("dir", "", "Directory holding the backups (default: backup.dir from the config file, or ~/.dynactl/backups)")
+	cmd.Flags().StringSlice("components", nil, "Components to restore: "+strings.Join(utils.BackupComponents, ", ")+" (default all in the backup)")
+	cmd.Flags().String("storage-class", "", "StorageClass for every restored PVC (default: the one each PVC was backed up with)")
+	cmd.Flags().String("values-dir", "", "Directory to write the Helm values to (default ./<backup>-values)")
+	cmd.Flags().Bool("force", false, "Restore into a namespace that already runs workloads, updating existing objects")
+	cmd.Flags().Bool("validate-only", false, "Only validate the target cluster")
+	cmd.Flags().String("db-selector", utils.DefaultDatabaseSelector, "Label selector of the database pod to load the dump into")
+	cmd.Flags().String("db-container", "", "Container of the database pod to run pg_restore in (default: the first)")
+	cmd.Flags().String("db-name", "", "Database to restore into (default: the one backed up, or the pod's POSTGRES_DB)")
+	cmd.Flags().String("db-user", "", "User to restore as (default: the pod's POSTGRES_USER)")
+	cmd.Flags().Duration("db-wait", utils.DefaultDatabaseWait, "How long to wait for the database pod to be ready")
+	addYesFlag(cmd)
+	return cmd
+}
+
+// scheduledBackupFlags are the backup create flags backup schedule passes through to the CronJob
+var scheduledBackupFlags = []string{"components", "include-secrets", "snapshot-class", "snapshot-timeout", "db-method", "db-selector", "db-container", "db-name", "db-user", "db-cluster"}
+
+func createBackupScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run backups of a namespace in-cluster on a schedule",
+		Long: `Applies a CronJob that runs "dynactl backup create --retain N" in the namespace on a cron
+schedule, along with what it needs:
+
+  ServiceAccount, Role, RoleBinding   read access to the namespace, pod exec, and VolumeSnapshots
+  ClusterRole, ClusterRoleBinding     read access to VolumeSnapshotContents
+  PersistentVolumeClaim               where the archives are written, mounted at /backups
+
+All are named dynactl-backup (the cluster-scoped ones dynactl-backup-<namespace>). Running the
+command again updates them; an existing PVC is kept as it is. Backup flags such as --components
+are passed through to each run. --image must name an image with dynactl on its PATH.
+
+Use --dry-run to print the resources as YAML for review. For ArgoCD or Flux, --export-dir writes
+each resource to its own file and lists it in the directory's kustomization.yaml, to commit to the
+GitOps repository instead of applying.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			schedule, _ := cmd.Flags().GetString("cron")
+			retain, _ := cmd.Flags().GetInt("retain")
+			image, _ := cmd.Flags().GetString("image")
+			storageSize, _ := cmd.Flags().GetString("storage-size")
+			storageClass, _ := cmd.Flags().GetString("storage-class")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			exportDir, _ := cmd.Flags().GetString("export-dir")
+
+			// Check the pass-through flags here rather than at 2am
+			components, _ := cmd.Flags().GetStringSlice("components")
+			dbMethod, _ := cmd.Flags().GetString("db-method")
+			dbCluster, _ := cmd.Flags().GetString("db-cluster")
+			backupOpts := utils.BackupOptions{
+				Namespace:  namespace,
+				Components: components,
+				Database:   utils.DatabaseBackupOptions{Method: dbMethod, Cluster: dbCluster},
+			}
+			if err := utils.ValidateBackupOptions(&backupOpts); err != nil {
+				return err
+			}
+			var backupArgs []string
+			for _, name := range scheduledBackupFlags {
+				if f := cmd.Flags().Lookup(name); f.Changed {
+					if f.Value.Type() == "stringSlice" {
+						values, _ := cmd.Flags().GetStringSlice(name)
+						backupArgs = append(backupArgs, "--"+name+"="+strings.Join(values, ","))
+					} else {
+						backupArgs = append(backupArgs, "--"+name+"="+f.Value.String())
+					}
+				}
+			}
+
+			s, err := utils.NewBackupSchedule(utils.BackupScheduleOptions{
+				Namespace:    namespace,
+				Schedule:     schedule,
+				Retain:       retain,
+				Image:        image,
+				StorageSize:  storageSize,
+				StorageClass: storageClass,
+				BackupArgs:   backupArgs,
+			})
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				data, err := s.Render()
+				if err != nil {
+					return err
+				}
+				cmd.Print(string(data))
+				return nil
+			}
+			if exportDir != "" {
+				files, err := s.Export(exportDir)
+				if err != nil {
+					return err
+				}
+				printExported(cmd, exportDir, files)
+				return nil
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+			changes, err := kc.ApplyBackupSchedule(cmd.Context(), s)
+			for _, c := range changes {
+				cmd.Printf("✓ %s\n", c)
+			}
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			cmd.Printf("✓ Backups of %s scheduled at %q, keeping the newest %d\n", namespace, schedule, retain)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("namespace", "n", "", "Namespace to back up (required)")
+	cmd.Flags().String("cron", "", `Cron schedule, e.g. "0 2 * * *" or @daily (required)`)
+	cmd.Flags().Int("retain", 7, "Number of backups of the namespace to keep")
+	cmd.Flags().String("image", "", "Image with dynactl on its PATH to run the backups with (required)")
+	cmd.Flags().String("storage-size", utils.DefaultBackupStorageSize, "Size of the PVC backups are written to")
+	cmd.Flags().String("storage-class", "", "StorageClass of the PVC backups are written to (default: the cluster default)")
+	cmd.Flags().Bool("dry-run", false, "Print the resources as YAML instead of applying them")
+	addExportDirFlag(cmd)
+	cmd.Flags().StringSlice("components", nil, "Components to back up: "+strings.Join(utils.BackupComponents, ", ")+" (default all)")
+	cmd.Flags().Bool("include-secrets", false, "Store Secret values and credentials in Helm values in the archives instead of redacting them")
+	cmd.Flags().String("snapshot-class", "", "VolumeSnapshotClass for PVC snapshots (default: the cluster default)")
+	cmd.Flags().Duration("snapshot-timeout", 5*time.Minute, "How long each run waits for PVC snapshots to be ready")
+	cmd.Flags().String("db-method", utils.DatabaseMethodExec, "How to back up the database: "+strings.Join(utils.DatabaseMethods, " or "))
+	cmd.Flags().String("db-selector", utils.DefaultDatabaseSelector, "Label selector of the database pod (exec method)")
+	cmd.Flags().String("db-container", "", "Container of the database pod to run pg_dump in (default: the first)")
+	cmd.Flags().String("db-name", "", "Database to dump (default: the pod's POSTGRES_DB)")
+	cmd.Flags().String("db-user", "", "User to dump as (default: the pod's POSTGRES_USER)")
+	cmd.Flags().String("db-cluster", "", "CloudNativePG Cluster to back up (cnpg method)")
+	cmd.MarkFlagRequired("namespace")
+	cmd.MarkFlagRequired("cron")
+	cmd.MarkFlagRequired("image")
+	return cmd
+}
+
+// printCheckResult prints one check or step with its status marker
+func printCheckResult(cmd *cobra.Command, r utils.CheckResult) {
+	marker := "✓"
+	switch r.Status {
+	case utils.CheckFail:
+		marker = "✗"
+	case utils.CheckWarn:
+		marker = "!"
+	}
+	cmd.Printf("%s %-16s %s\n", marker, r.Name, r.Message)
+}
+
+// backupListTable summarizes each backup, marking components that were not fully captured
+func backupListTable(backups []utils.BackupMetadata) *output.Table {
+	table := &output.Table{
+		Columns: []output.Column{
+			{Header: "NAME", CSV: "name"},
+			{Header: "CREATED", CSV: "created"},
+			{Header: "NAMESPACE", CSV: "namespace"},
+			{Header: "COMPONENTS", CSV: "components"},
+			{Header: "SIZE", CSV: "size"},
+			{Header: "SECRETS", CSV: "secrets"},
+			{Header: "DYNACTL", CSV: "dynactl_version", Wide: true},
+			{Header: "SERVER", CSV: "server", Wide: true},
+			{Header: "PATH", CSV: "path", Wide: true},
+		},
+		Data: backups,
+	}
+	for _, b := range backups {
+		var components []string
+		for _, c := range b.Components {
+			if c.Status == utils.BackupOK {
+				components = append(components, c.Name)
+			} else {
+				components = append(components, c.Name+" ("+c.Status+")")
+			}
+		}
+		secrets := "-"
+		if containsComponent(b.Components, utils.BackupSecrets) {
+			secrets = "values"
+			if b.SecretsRedacted {
+				secrets = "redacted"
+			}
+		}
+		table.AddRow(b.Name, b.Created.Local().Format("2006-01-02 15:04"), b.Namespace, strings.Join(components, ", "),
+			utils.FormatBytes(b.Size), secrets, b.DynactlVersion, b.Server, b.Path)
+	}
+	return table
+}
+
+func containsComponent(components []utils.BackupComponent, name string) bool {
+	for _, c := range components {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupCommands(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddBackupCommands(rootCmd)
+
+	backupCmd := findSubcommand(rootCmd, "backup")
+	assert.NotNil(t, backupCmd, "backup command should exist")
+
+	createCmd := findSubcommand(backupCmd, "create")
+	assert.NotNil(t, createCmd, "create command should exist")
+	assert.Equal(t, audited, createCmd.Annotations)
+	assert.NotNil(t, createCmd.Flags().Lookup("include-secrets"), "include-secrets flag should exist")
+	assert.NotNil(t, findSubcommand(backupCmd, "list"), "list command should exist")
+
+	restoreCmd := findSubcommand(backupCmd, "restore")
+	assert.NotNil(t, restoreCmd, "restore command should exist")
+	assert.Equal(t, audited, restoreCmd.Annotations)
+	assert.NotNil(t, restoreCmd.Flags().Lookup("validate-only"), "validate-only flag should exist")
+	assert.NotNil(t, restoreCmd.Flags().Lookup("yes"), "yes flag should exist")
+
+	scheduleCmd := findSubcommand(backupCmd, "schedule")
+	assert.NotNil(t, scheduleCmd, "schedule command should exist")
+	assert.Equal(t, audited, scheduleCmd.Annotations)
+	assert.NotNil(t, createCmd.Flags().Lookup("retain"), "retain flag should exist")
+	for _, name := range scheduledBackupFlags {
+		assert.NotNil(t, createCmd.Flags().Lookup(name), "%s should be a backup create flag", name)
+	}
+}
+
+func TestBackupScheduleDryRun(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddBackupCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"backup", "schedule", "-n", "dynamo", "--cron", "0 2 * * *", "--retain", "3",
+		"--image", "registry.example.com/dynactl:v1.4.0", "--components", "database,configmaps", "--dry-run"})
+	assert.NoError(t, rootCmd.Execute())
+	out := buf.String()
+	assert.Contains(t, out, "kind: CronJob")
+	assert.Contains(t, out, "schedule: 0 2 * * *")
+	assert.Contains(t, out, "- --retain\n            - \"3\"\n")
+	assert.Contains(t, out, "- --components=database,configmaps")
+	assert.NotContains(t, out, "--db-method", "flags left at their defaults should not be passed through")
+}
+
+func TestBackupScheduleValidation(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--cron", "nightly"}, "invalid cron schedule"},
+		{[]string{"--cron", "@daily", "--components", "volumes"}, `unknown backup component "volumes"`},
+	} {
+		rootCmd := &cobra.Command{}
+		AddBackupCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"backup", "schedule", "-n", "dynamo", "--image", "dynactl:dev", "--dry-run"}, tc.args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", tc.args) {
+			assert.Contains(t, err.Error(), tc.want)
+		}
+	}
+}
+
+func TestBackupRestoreUnknownBackup(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	rootCmd := &cobra.Command{}
+	AddBackupCommands(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"backup", "restore", "pre-upgrade", "--dir", t.TempDir()})
+	err := rootCmd.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no backup named pre-upgrade")
+	}
+}
+
+func TestBackupCreateValidation(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--components", "database,volumes"}, `unknown backup component "volumes"`},
+		{[]string{"--db-method", "cnpg"}, "cluster name is required"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddBackupCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"backup", "create", "-n", "dynamo", "--dir", t.TempDir()}, tc.args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", tc.args) {
+			assert.Contains(t, err.Error(), tc.want)
+		}
+	}
+}
+
+func TestBackupListUsesConfiguredDir(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(config, []byte("backup:\n  dir: "+dir+"\n"), 0o644))
+	t.Setenv("DYNACTL_CONFIG", config)
+
+	rootCmd := &cobra.Command{}
+	AddBackupCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"backup", "list"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "No backups in "+dir)
+}
+
+func TestBackupListTable(t *testing.T) {
+	backups := []utils.BackupMetadata{{
+		Name:            "dynamo-20261017T090000Z",
+		Namespace:       "dynamo",
+		Created:         time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+		SecretsRedacted: true,
+		Size:            3 << 20,
+		Components: []utils.BackupComponent{
+			{Name: utils.BackupDatabase, Status: utils.BackupOK},
+			{Name: utils.BackupSecrets, Status: utils.BackupOK},
+			{Name: utils.BackupSnapshots, Status: utils.BackupPending},
+		},
+	}}
+	table := backupListTable(backups)
+	if assert.Len(t, table.Rows, 1) {
+		row := table.Rows[0]
+		assert.Equal(t, "database, secrets, pvc-snapshots (pending)", row[3])
+		assert.Equal(t, "redacted", row[5])
+	}
+}
@@ -1,23 +1,35 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/output"
 	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // AddClusterCommands adds the cluster commands to the root command
 func AddClusterCommands(rootCmd *cobra.Command) {
 	clusterCmd := &cobra.Command{
-		Use:   "cluster",
-		Short: "Handle cluster status",
-		Long:  "Handle cluster status for the deployment.",
+		Use:     "cluster",
+		Aliases: []string{"cl"},
+		Short:   "Handle cluster status",
+		Long:    "Handle cluster status for the deployment.",
 	}
 
 	// 'all check' - comprehensive check, requires namespace
 	allCmd := &cobra.Command{
 		Use:   "all",
 		Short: "Run all cluster checks",
-		Long:  "Runs all available cluster checks: version, node resources, namespace permissions, cluster permissions, and storage.",
+		Long:  "Runs all available cluster checks: version, node resources, namespace permissions, cluster permissions, storage, node disks, node clock skew, required operators, and certificate expiry.",
 	}
 	allCheckCmd := &cobra.Command{
 		Use:   "check [--namespace <namespace>]",
@@ -25,17 +37,30 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			namespace, _ := cmd.Flags().GetString("namespace")
 
-			kc, err := utils.NewKubernetesChecker()
+			kc, err := kubeChecker(cmd)
 			if err != nil {
 				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
 
+			noHistory, _ := cmd.Flags().GetBool("no-history")
+			thresholds, err := storageThresholds(cmd)
+			if err != nil {
+				return err
+			}
+
 			cmd.Printf("Running all cluster checks for namespace: %s\n", namespace)
 			cmd.Println()
 
+			var results []utils.CheckResult
+			record := func(name, message string, err error, failStatus string) {
+				results = append(results, checkResult(name, message, err, failStatus))
+			}
+			var capacity *utils.ClusterResourceSummary
+
 			// Version
-			version, err := kc.CheckKubernetesVersion()
+			version, err := kc.CheckKubernetesVersion(cmd.Context())
+			record(utils.PeriodicCheckVersion, version, err, utils.CheckFail)
 			if err != nil {
 				cmd.Printf("✗ Kubernetes version: %s\n", version)
 			} else {
@@ -43,15 +68,18 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 			}
 
 			// Nodes/resources
-			resources, err := kc.CheckResources("table")
+			nodes, summary, err := kc.GatherNodeResources(cmd.Context(), "")
 			if err != nil {
-				cmd.Printf("✗ Node resources: %s\n", resources)
+				cmd.Printf("✗ Node resources: %v\n", err)
 			} else {
-				cmd.Printf("✓ Node resources: %s\n", resources)
+				_ = output.RenderNodeResources(cmd.OutOrStdout(), "table", nodes, summary)
+				cmd.Printf("✓ Node resources: %s\n", summary)
+				capacity = &summary
 			}
 
 			// Namespace permissions
-			nsRBAC, err := kc.CheckNamespaceRBAC(namespace)
+			nsRBAC, err := kc.CheckNamespaceRBAC(cmd.Context(), namespace, utils.AccessSubject{})
+			record(utils.CheckNamespacePermissions, nsRBAC, err, utils.CheckFail)
 			if err != nil {
 				cmd.Printf("✗ Namespace permissions: %s\n", nsRBAC)
 			} else {
@@ -59,7 +87,8 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 			}
 
 			// Cluster permissions
-			clusterRBAC, err := kc.CheckClusterRBAC()
+			clusterRBAC, err := kc.CheckClusterRBAC(cmd.Context(), utils.AccessSubject{})
+			record(utils.CheckClusterPermissions, clusterRBAC, err, utils.CheckFail)
 			if err != nil {
 				cmd.Printf("✗ Cluster permissions: %s\n", clusterRBAC)
 			} else {
@@ -67,7 +96,8 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 			}
 
 			// Storage classes compatibility
-			scCompat, err := kc.CheckStorageClassesCompatibility()
+			scCompat, err := kc.CheckStorageClassesCompatibility(cmd.Context())
+			record(utils.CheckStorageClasses, scCompat, err, utils.CheckWarn)
 			if err != nil {
 				cmd.Printf("! StorageClasses: %s\n", scCompat)
 			} else {
@@ -75,11 +105,76 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 			}
 
 			// Storage capacity
-			storage, err := kc.CheckStorageCapacity()
+			storage, err := kc.CheckStorageCapacity(cmd.Context(), thresholds)
 			if err != nil {
-				cmd.Printf("! Storage capacity: %s\n", storage)
+				record(utils.PeriodicCheckStorage, "", err, utils.CheckWarn)
+				cmd.Printf("! Storage capacity: %v\n", err)
+			} else {
+				results = append(results, utils.CheckResult{Name: utils.PeriodicCheckStorage, Status: storage.Status, Message: storage.Message})
+				cmd.Println(statusMessage(storage.Status, "Storage capacity: "+storage.Message))
+				// Storage above the warn threshold is reported without failing the run
+				if storage.Status == utils.CheckFail {
+					err = storage.Err()
+				}
+			}
+
+			// Node disks
+			disk, diskErr := kc.CheckNodeDisk(cmd.Context(), utils.DefaultMinImageFsFree)
+			record(utils.CheckNodeDisk, disk, diskErr, utils.CheckWarn)
+			if diskErr != nil {
+				if disk == "" {
+					disk = diskErr.Error()
+				}
+				cmd.Printf("! Node disks: %s\n", disk)
+			} else {
+				cmd.Printf("✓ Node disks: %s\n", disk)
+			}
+
+			// Node clocks
+			skew, skewErr := kc.CheckClockSkew(cmd.Context(), utils.DefaultMaxClockSkew)
+			record(utils.CheckClockSkew, skew, skewErr, utils.CheckFail)
+			if skewErr != nil {
+				if skew == "" {
+					skew = skewErr.Error()
+				}
+				cmd.Printf("✗ Node clocks: %s\n", skew)
+				err = skewErr
+			} else {
+				cmd.Printf("✓ Node clocks: %s\n", skew)
+			}
+
+			// Operators
+			operators, opErr := kc.CheckOperators(cmd.Context(), utils.DefaultOperatorRequirements)
+			if opErr == nil {
+				var summary string
+				summary, opErr = utils.SummarizeOperators(operators)
+				record(utils.CheckOperators, summary, opErr, utils.CheckFail)
+				if opErr != nil {
+					cmd.Printf("✗ Operators: %s\n", summary)
+					err = opErr
+				} else {
+					cmd.Printf("✓ Operators: %s\n", summary)
+				}
+			} else {
+				record(utils.CheckOperators, "", opErr, utils.CheckWarn)
+				cmd.Printf("! Operators: %v\n", opErr)
+			}
+
+			// Certificate expiry
+			certs, certErr := kc.CheckCertificateExpiry(cmd.Context(), namespace, 30)
+			if certErr != nil {
+				record(utils.PeriodicCheckCerts, "", certErr, utils.CheckWarn)
+				cmd.Printf("! Certificates: %v\n", certErr)
+			} else if summary, expErr := summarizeCertificates(certs, 30); expErr != nil {
+				record(utils.PeriodicCheckCerts, summary, expErr, utils.CheckWarn)
+				cmd.Printf("! Certificates: %s\n", summary)
 			} else {
-				cmd.Printf("✓ Storage capacity: %s\n", storage)
+				record(utils.PeriodicCheckCerts, summary, nil, utils.CheckWarn)
+				cmd.Printf("✓ Certificates: %s\n", summary)
+			}
+
+			if !noHistory {
+				saveCheckHistory(utils.HistoryRecord{CheckReport: kc.NewCheckReport(results), Capacity: capacity})
 			}
 
 			cmd.Println()
@@ -93,65 +188,168 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 	}
 	allCheckCmd.Flags().StringP("namespace", "n", "", "Namespace to check permissions in")
 	allCheckCmd.MarkFlagRequired("namespace")
+	allCheckCmd.Flags().Bool("no-history", false, "Do not save the results to ~/.dynactl/history")
+	addStorageThresholdFlags(allCheckCmd)
 	allCmd.AddCommand(allCheckCmd)
 
 	// 'node check' - node status/resources, no namespace required
 	nodeCmd := &cobra.Command{
-		Use:   "node",
-		Short: "Check node status",
-		Long:  "Checks node readiness and aggregated CPU/memory resources.",
+		Use:     "node",
+		Aliases: []string{"nodes", "no"},
+		Short:   "Check node status",
+		Long:    "Checks node readiness and aggregated CPU/memory resources.",
 	}
 	nodeCheckCmd := &cobra.Command{
 		Use:   "check",
 		Short: "Check node status",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kc, err := utils.NewKubernetesChecker()
+			outputFormat, _ := cmd.Flags().GetString("output")
+			wide, _ := cmd.Flags().GetBool("wide")
+			noTrunc, _ := cmd.Flags().GetBool("no-trunc")
+			explain, _ := cmd.Flags().GetBool("explain")
+			if wide {
+				if cmd.Flags().Changed("output") && outputFormat != output.FormatWide {
+					return fmt.Errorf("--wide cannot be combined with --output %s", outputFormat)
+				}
+				outputFormat = output.FormatWide
+			}
+			outputFile, err := workbookOutputFile(cmd, outputFormat)
+			if err != nil {
+				return err
+			}
+			var renderer output.Renderer
+			if outputFile == "" {
+				if renderer, err = output.NewRenderer(outputFormat); err != nil {
+					return err
+				}
+			}
+			sortBy, _ := cmd.Flags().GetString("sort-by")
+			selectorFlag, _ := cmd.Flags().GetString("selector")
+			nodeLabels, _ := cmd.Flags().GetStringSlice("node-label")
+			selector, err := utils.NodeLabelSelector(selectorFlag, nodeLabels)
+			if err != nil {
+				return err
+			}
+			// Validate the sort key before contacting the cluster
+			if err := utils.SortNodeResources(nil, sortBy); err != nil {
+				return err
+			}
+			pricing, err := resolvePricing(cmd)
 			if err != nil {
-				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
 
-			outputFormat, _ := cmd.Flags().GetString("output")
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
 
-			cmd.Println("Checking node resources...")
-			resources, err := kc.CheckResources(outputFormat)
+			if output.IsTabular(outputFormat) {
+				cmd.Println("Checking node resources...")
+			}
+			nodes, summary, err := kc.GatherNodeResources(cmd.Context(), selector)
 			if err != nil {
-				cmd.Printf("✗ Node resources: %s\n", resources)
+				cmd.Printf("✗ Node resources: %v\n", err)
+				return err
+			}
+			if err := utils.SortNodeResources(nodes, sortBy); err != nil {
+				return err
+			}
+			if pricing != nil {
+				utils.ApplyNodePricing(nodes, &summary, pricing)
+			}
+			if outputFile != "" {
+				return writeWorkbook(cmd, outputFile, output.NodeWorkbookSheets(nodes, summary))
+			}
+			table := output.NodeResourcesTable(nodes, summary)
+			table.NoTruncate = noTrunc
+			if pricing != nil {
+				output.AddNodeCostColumn(table, nodes, pricing.Currency)
+			}
+
+			var diagnoses []utils.NodeDiagnosis
+			if explain && summary.ReadyNodes < summary.TotalNodes {
+				diagnoses, err = kc.ExplainNotReadyNodes(cmd.Context(), selector)
+				if err != nil {
+					cmd.Printf("✗ Node readiness: %v\n", err)
+					return err
+				}
+				data := table.Data.(output.NodeResources)
+				data.NotReady = diagnoses
+				table.Data = data
+			}
+
+			if err := renderer.Render(cmd.OutOrStdout(), table); err != nil {
 				return err
 			}
-			cmd.Printf("✓ Node resources: %s\n", resources)
+			if output.IsTabular(outputFormat) {
+				output.WriteClusterSummary(cmd.OutOrStdout(), summary)
+				output.WriteNodeVersionProblems(cmd.OutOrStdout(), nodes)
+				output.WriteNodeDiagnoses(cmd.OutOrStdout(), diagnoses)
+				if notReady := summary.TotalNodes - summary.ReadyNodes; notReady > 0 && !explain {
+					cmd.Printf("! %d node(s) not ready and skipped; rerun with --explain for details\n", notReady)
+				}
+				cmd.Printf("✓ Node resources: %s\n", summary)
+			}
 			return nil
 		},
 	}
-	nodeCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or csv")
+	addWorkbookFlags(nodeCheckCmd)
+	nodeCheckCmd.Flags().Bool("wide", false, "Add absolute requests/limits, zone, kubelet version, and taints (same as -o wide)")
+	nodeCheckCmd.Flags().Bool("no-trunc", false, "Print long node names and taints in full")
+	nodeCheckCmd.Flags().String("sort-by", utils.NodeSortInstanceType, "Sort nodes by: "+strings.Join(utils.NodeSortKeys, ", "))
+	nodeCheckCmd.Flags().StringP("selector", "l", "", "Only include nodes matching this label selector")
+	nodeCheckCmd.Flags().StringSlice("node-label", nil, "Only include nodes with this label (key or key=value, repeatable)")
+	addCostFlags(nodeCheckCmd)
+	nodeCheckCmd.Flags().Bool("explain", false, "Explain skipped NotReady nodes: failing conditions, transition times, and recent node events")
 	nodeCmd.AddCommand(nodeCheckCmd)
+	nodeCmd.AddCommand(createNodeRotateCmd())
 
 	// 'permission check' - namespace and cluster RBAC, namespace required
 	permCmd := &cobra.Command{
-		Use:   "permission",
-		Short: "Check permissions",
-		Long:  "Checks namespace-level and cluster-level permissions.",
+		Use:     "permission",
+		Aliases: []string{"perm", "permissions"},
+		Short:   "Check permissions",
+		Long:    "Checks namespace-level and cluster-level permissions.",
 	}
 	permCheckCmd := &cobra.Command{
-		Use:   "check [--namespace <namespace>]",
+		Use:   "check [--namespace <namespace>] [--as <user> | --as-serviceaccount <ns>/<name>]",
 		Short: "Check permissions in a namespace",
+		Long: `Checks that the current user may create the resources a deployment needs in the namespace, and
+CRDs cluster-wide.
+
+--as, --as-group, and --as-serviceaccount check someone else's permissions instead, such as the
+ServiceAccount a CI pipeline or GitOps controller deploys with, using a SubjectAccessReview. The
+current user then needs create on subjectaccessreviews.authorization.k8s.io.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			namespace, _ := cmd.Flags().GetString("namespace")
-			kc, err := utils.NewKubernetesChecker()
+			asUser, _ := cmd.Flags().GetString("as")
+			asGroups, _ := cmd.Flags().GetStringSlice("as-group")
+			asServiceAccount, _ := cmd.Flags().GetString("as-serviceaccount")
+
+			subject, err := utils.NewAccessSubject(asUser, asGroups, asServiceAccount)
+			if err != nil {
+				return err
+			}
+			kc, err := kubeChecker(cmd)
 			if err != nil {
 				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
-			nsRBAC, err := kc.CheckNamespaceRBAC(namespace)
+			if !subject.IsSelf() {
+				cmd.Printf("Checking permissions of %s\n", subject)
+			}
+			nsRBAC, err := kc.CheckNamespaceRBAC(cmd.Context(), namespace, subject)
 			if err != nil {
-				cmd.Printf("✗ Namespace permissions: %s\n", nsRBAC)
+				cmd.Printf("✗ Namespace permissions: %v\n", err)
 				return err
 			}
 			cmd.Printf("✓ Namespace permissions: %s\n", nsRBAC)
 
-			clusterRBAC, err := kc.CheckClusterRBAC()
+			clusterRBAC, err := kc.CheckClusterRBAC(cmd.Context(), subject)
 			if err != nil {
-				cmd.Printf("✗ Cluster permissions: %s\n", clusterRBAC)
+				cmd.Printf("✗ Cluster permissions: %v\n", err)
 				return err
 			}
 			cmd.Printf("✓ Cluster permissions: %s\n", clusterRBAC)
@@ -160,6 +358,9 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 	}
 	permCheckCmd.Flags().StringP("namespace", "n", "", "Namespace to check permissions in")
 	permCheckCmd.MarkFlagRequired("namespace")
+	permCheckCmd.Flags().String("as", "", "Check the permissions of this user instead of your own")
+	permCheckCmd.Flags().StringSlice("as-group", nil, "Group of the --as user or ServiceAccount (repeatable)")
+	permCheckCmd.Flags().String("as-serviceaccount", "", "Check the permissions of this ServiceAccount, as <namespace>/<name>")
 	permCmd.AddCommand(permCheckCmd)
 
 	// 'storage check' - storage classes compatibility and capacity
@@ -172,36 +373,1948 @@ func AddClusterCommands(rootCmd *cobra.Command) {
 		Use:   "check",
 		Short: "Check storage classes and capacity",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kc, err := utils.NewKubernetesChecker()
+			thresholds, err := storageThresholds(cmd)
+			if err != nil {
+				return err
+			}
+			kc, err := kubeChecker(cmd)
 			if err != nil {
 				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
 
-			scCompat, err := kc.CheckStorageClassesCompatibility()
+			scCompat, err := kc.CheckStorageClassesCompatibility(cmd.Context())
 			if err != nil {
 				cmd.Printf("! StorageClasses: %s\n", scCompat)
 			} else {
 				cmd.Printf("✓ StorageClasses: %s\n", scCompat)
 			}
 
-			storage, err := kc.CheckStorageCapacity()
+			storage, err := kc.CheckStorageCapacity(cmd.Context(), thresholds)
 			if err != nil {
-				cmd.Printf("! Storage capacity: %s\n", storage)
+				cmd.Printf("✗ Storage capacity: %v\n", err)
 				return err
 			}
-			cmd.Printf("✓ Storage capacity: %s\n", storage)
+			cmd.Println(statusMessage(storage.Status, "Storage capacity: "+storage.Message))
+			cmd.Println()
+			renderStorageClasses(cmd, storage)
+
+			if showPVCs, _ := cmd.Flags().GetBool("pvcs"); showPVCs {
+				namespace, _ := cmd.Flags().GetString("namespace")
+				usages, pvcErr := kc.ListPVCUsage(cmd.Context(), namespace)
+				if pvcErr != nil {
+					cmd.Printf("✗ PVC usage: %v\n", pvcErr)
+					return pvcErr
+				}
+				cmd.Println()
+				renderPVCUsage(cmd, usages, thresholds)
+			}
+			if storage.Status == utils.CheckFail {
+				return storage.Err()
+			}
 			return nil
 		},
 	}
+	storageCheckCmd.Flags().Bool("pvcs", false, "Show per-PVC filesystem usage and the components that own each claim")
+	storageCheckCmd.Flags().StringP("namespace", "n", "", "Limit --pvcs to a namespace (default all namespaces)")
+	addStorageThresholdFlags(storageCheckCmd)
 	storageCmd.AddCommand(storageCheckCmd)
 
+	// 'cert check' - TLS certificate expiry, namespace required
+	certCmd := &cobra.Command{
+		Use:     "cert",
+		Aliases: []string{"certs"},
+		Short:   "Check TLS certificates",
+		Long:    "Checks TLS Secrets, cert-manager Certificates, and ingress certificates for upcoming expiry.",
+	}
+	certCheckCmd := &cobra.Command{
+		Use:   "check [--namespace <namespace>]",
+		Short: "Check certificate expiry in a namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			days, _ := cmd.Flags().GetInt("days")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			certs, err := kc.CheckCertificateExpiry(cmd.Context(), namespace, days)
+			if err != nil {
+				cmd.Printf("✗ Certificates: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(certs, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderCertificates(cmd, certs)
+			}
+
+			summary, err := summarizeCertificates(certs, days)
+			if output != "json" {
+				cmd.Println()
+				if err != nil {
+					cmd.Printf("✗ Certificates: %s\n", summary)
+				} else {
+					cmd.Printf("✓ Certificates: %s\n", summary)
+				}
+			}
+			return err
+		},
+	}
+	certCheckCmd.Flags().StringP("namespace", "n", "", "Namespace to check certificates in")
+	certCheckCmd.MarkFlagRequired("namespace")
+	certCheckCmd.Flags().Int("days", 30, "Flag certificates that expire within this many days")
+	certCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	certCmd.AddCommand(certCheckCmd)
+
+	// 'deps check' - external dependency connectivity from inside the cluster
+	depsCmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Check external dependencies",
+		Long:  "Checks reachability and authentication to external services the deployment depends on.",
+	}
+	depsCheckCmd := &cobra.Command{
+		Use:         "check --config <deps.yaml>",
+		Short:       "Probe external dependencies from a pod inside the cluster",
+		Long:        "Starts a short-lived probe pod that tests reachability and, where credentials are configured, authentication to Postgres, Redis, S3/Blob and HTTP endpoints, SMTP servers, and OIDC issuers.",
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			image, _ := cmd.Flags().GetString("image")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			output, _ := cmd.Flags().GetString("output")
+
+			cfg, err := utils.LoadDependencyConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if namespace == "" {
+				namespace = cfg.Namespace
+			}
+			if namespace == "" {
+				return fmt.Errorf("a namespace is required: pass --namespace or set namespace in %s", configPath)
+			}
+			if image != "" {
+				cfg.Image = image
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			results, err := kc.CheckDependencies(cmd.Context(), namespace, cfg, timeout)
+			if err != nil {
+				cmd.Printf("✗ Dependency probe failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			}
+
+			failed := 0
+			for _, r := range results {
+				if r.Status != utils.DependencyOK {
+					failed++
+				}
+				if output == "json" {
+					continue
+				}
+				marker := "✓"
+				if r.Status != utils.DependencyOK {
+					marker = "✗"
+				}
+				cmd.Printf("%s %-20s %-10s %-40s %s\n", marker, r.Name, r.Type, r.Target, truncateMessage(r.Status+": "+r.Message, 100))
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d dependencies failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	depsCheckCmd.Flags().String("config", "", "Path to the dependency config (YAML)")
+	depsCheckCmd.MarkFlagRequired("config")
+	depsCheckCmd.Flags().StringP("namespace", "n", "", "Namespace to run the probe pod in (overrides the config)")
+	depsCheckCmd.Flags().String("image", "", "Probe pod image (overrides the config; default "+utils.DefaultProbeImage+")")
+	depsCheckCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the probe pod to finish")
+	depsCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	depsCmd.AddCommand(depsCheckCmd)
+
+	// 'oidc check' - SSO configuration validation, runs from the workstation
+	oidcCmd := &cobra.Command{
+		Use:   "oidc",
+		Short: "Check OIDC/SSO configuration",
+		Long:  "Validates the OIDC issuer and redirect URIs planned for single sign-on.",
+	}
+	oidcCheckCmd := &cobra.Command{
+		Use:   "check --issuer <url>",
+		Short: "Validate an OIDC issuer and redirect URIs before install",
+		Long:  "Fetches the issuer's discovery document and JWKS, checks that the advertised issuer matches exactly, and verifies that the planned redirect URIs are well-formed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			issuer, _ := cmd.Flags().GetString("issuer")
+			redirectURIs, _ := cmd.Flags().GetStringSlice("redirect-uri")
+			caFile, _ := cmd.Flags().GetString("ca-file")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			output, _ := cmd.Flags().GetString("output")
+
+			client, err := utils.NewOIDCHTTPClient(caFile, timeout)
+			if err != nil {
+				return err
+			}
+
+			results := utils.ValidateOIDC(cmd.Context(), client, utils.OIDCValidationOptions{
+				IssuerURL:    issuer,
+				RedirectURIs: redirectURIs,
+			})
+			return renderCheckResults(cmd, "OIDC", results, output)
+		},
+	}
+	oidcCheckCmd.Flags().String("issuer", "", "OIDC issuer URL, exactly as it will be configured")
+	oidcCheckCmd.MarkFlagRequired("issuer")
+	oidcCheckCmd.Flags().StringSlice("redirect-uri", nil, "Redirect URI that will be registered with the identity provider (repeatable)")
+	oidcCheckCmd.Flags().String("ca-file", "", "PEM CA bundle to trust for a privately signed identity provider")
+	oidcCheckCmd.Flags().Duration("timeout", 10*time.Second, "HTTP timeout per request")
+	oidcCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	oidcCmd.AddCommand(oidcCheckCmd)
+
+	// 'env' - environment fingerprint
+	envCmd := &cobra.Command{
+		Use:         "env",
+		Short:       "Fingerprint the cluster environment",
+		Long:        "Detects the cloud provider or distribution, CNI, ingress controllers, storage drivers, GPU operator, proxy settings, and whether public registries are reachable from a probe pod in the cluster, and prints the result as a JSON profile. Saved profiles are used by other commands to pick defaults.",
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			save, _ := cmd.Flags().GetBool("save")
+			file, _ := cmd.Flags().GetString("file")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			image, _ := cmd.Flags().GetString("image")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			profile, err := kc.FingerprintEnvironment(cmd.Context(), utils.EnvironmentOptions{Namespace: namespace, Image: image, ProbeTimeout: timeout})
+			if err != nil {
+				cmd.Printf("✗ Failed to fingerprint environment: %v\n", err)
+				return err
+			}
+
+			data, err := json.MarshalIndent(profile, "", "  ")
+			if err != nil {
+				cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+				return err
+			}
+			cmd.Println(string(data))
+
+			if save || file != "" {
+				path, err := utils.SaveEnvironmentProfile(profile, file)
+				if err != nil {
+					return err
+				}
+				utils.LogInfo("Saved environment profile to %s", path)
+			}
+			return nil
+		},
+	}
+	envCmd.Flags().Bool("save", false, "Save the profile to ~/.dynactl/environment.json for other commands")
+	envCmd.Flags().String("file", "", "Save the profile to this path instead")
+	envCmd.Flags().StringP("namespace", "n", "default", "Namespace to run the registry probe pod in")
+	envCmd.Flags().String("image", "", "Registry probe pod image (default "+utils.DefaultProbeImage+"); use a mirrored copy in air-gapped clusters")
+	envCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the registry probe pod to finish")
+
+	// 'check' - selected checks, once or on a schedule, with notifications
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Run selected cluster checks once or periodically",
+		Long:  "Runs the selected checks (version, nodes, storage, certs, license by default) and posts failures to Slack, Teams, or a generic webhook. --profile picks the checks and thresholds for a deployment size and reports failures of its advisory checks as warnings. With --daemon the checks repeat every --interval until interrupted, and unchanged failures are posted again only every --renotify.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			checkNames, _ := cmd.Flags().GetStringSlice("checks")
+			daemon, _ := cmd.Flags().GetBool("daemon")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			renotify, _ := cmd.Flags().GetDuration("renotify")
+			slackURL, _ := cmd.Flags().GetString("notify-slack")
+			teamsURL, _ := cmd.Flags().GetString("notify-teams")
+			webhookURL, _ := cmd.Flags().GetString("notify-webhook")
+			noHistory, _ := cmd.Flags().GetBool("no-history")
+			profileName, _ := cmd.Flags().GetString("profile")
+
+			checks, err := utils.ParsePeriodicChecks(checkNames)
+			if err != nil {
+				return err
+			}
+			thresholds, err := storageThresholds(cmd)
+			if err != nil {
+				return err
+			}
+			opts := utils.DefaultPeriodicCheckOptions
+			var profile *utils.CheckProfile
+			if profileName != "" {
+				if profile, err = utils.LookupCheckProfile(profileName); err != nil {
+					return err
+				}
+				// Explicit --checks and threshold flags override the profile
+				if len(checkNames) == 0 {
+					checks = profile.Checks
+				}
+				opts = profile.Options
+			}
+			if cmd.Flags().Changed("warn-threshold") || cmd.Flags().Changed("fail-threshold") || profile == nil {
+				opts.Storage = thresholds
+			}
+			if daemon && interval < time.Minute {
+				return fmt.Errorf("--interval must be at least 1m")
+			}
+
+			var notifiers []utils.Notifier
+			if slackURL != "" {
+				notifiers = append(notifiers, utils.SlackNotifier{WebhookURL: slackURL})
+			}
+			if teamsURL != "" {
+				notifiers = append(notifiers, utils.TeamsNotifier{WebhookURL: teamsURL})
+			}
+			if webhookURL != "" {
+				notifiers = append(notifiers, utils.WebhookNotifier{URL: webhookURL})
+			}
+
+			// Not the shared checker: its readiness probe would fail the command before an
+			// unreachable API server could be reported by the version check and notified
+			applyEvidenceDir(cmd)
+			kc, err := utils.NewKubernetesChecker()
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// Repeated runs read nodes, pods, and deployments from watches instead of listing them
+			if daemon {
+				if err := kc.StartCache(ctx); err != nil {
+					utils.LogWarning("Listing from the API server on every run: %v", err)
+				}
+			}
+
+			throttle := &utils.NotifyThrottle{Renotify: renotify}
+			runOnce := func() utils.CheckReport {
+				results := kc.RunPeriodicChecks(ctx, namespace, checks, opts)
+				if profile != nil {
+					results = utils.ApplyCheckProfile(results, profile)
+				}
+				report := kc.NewCheckReport(results)
+				cmd.Printf("[%s] %d checks, %d failing\n", report.Time.Format(time.RFC3339), len(report.Results), len(report.Failures))
+				for _, r := range report.Results {
+					cmd.Println(statusMessage(r.Status, fmt.Sprintf("%-10s %s", r.Name, r.Message)))
+				}
+				if !noHistory {
+					saveCheckHistory(utils.HistoryRecord{CheckReport: report})
+				}
+				if throttle.ShouldNotify(report) {
+					for _, n := range notifiers {
+						if err := n.Notify(ctx, report); err != nil {
+							utils.LogWarning("Failed to send %s notification: %v", n.Name(), err)
+						} else {
+							utils.LogInfo("Sent %s notification", n.Name())
+						}
+					}
+				}
+				return report
+			}
+
+			report := runOnce()
+			if !daemon {
+				if len(report.Failures) > 0 {
+					return fmt.Errorf("%d checks failed", len(report.Failures))
+				}
+				return nil
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					cmd.Println("Stopping scheduled checks")
+					return nil
+				case <-ticker.C:
+					cmd.Println()
+					runOnce()
+				}
+			}
+		},
+	}
+	checkCmd.Flags().StringP("namespace", "n", "", "Namespace for namespace-scoped checks (certs, license, ha)")
+	checkCmd.Flags().StringSlice("checks", nil, "Checks to run: version, nodes, storage, certs, license, clock, disk, operators, ha (default version, nodes, storage, certs, license, or the profile's)")
+	checkCmd.Flags().String("profile", "", "Deployment size profile setting checks, thresholds, and which checks are advisory: poc, standard, or enterprise")
+	checkCmd.Flags().Bool("daemon", false, "Keep running and repeat the checks every --interval")
+	checkCmd.Flags().Duration("interval", 6*time.Hour, "Time between runs in --daemon mode")
+	checkCmd.Flags().String("notify-slack", "", "Slack incoming webhook URL to post failures to")
+	checkCmd.Flags().String("notify-teams", "", "Microsoft Teams incoming webhook URL to post failures to")
+	checkCmd.Flags().String("notify-webhook", "", "URL to POST a JSON report to when checks fail")
+	checkCmd.Flags().Duration("renotify", 24*time.Hour, "In --daemon mode, repeat a notification for unchanged failures after this long (0 never repeats)")
+	checkCmd.Flags().Bool("no-history", false, "Do not save the results to ~/.dynactl/history")
+	addStorageThresholdFlags(checkCmd)
+
+	// 'events' - namespace-wide failure triage
+	eventsCmd := &cobra.Command{
+		Use:     "events --namespace <namespace>",
+		Aliases: []string{"ev"},
+		Short:   "Triage recent events and failures in a namespace",
+		Long:    "Aggregates Warning events, OOMKills, image pull failures, crash loops, and scheduling failures from the last --since window, grouped by the owning workload.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			since, _ := cmd.Flags().GetDuration("since")
+			failedOnly, _ := cmd.Flags().GetBool("failed-only")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			groups, err := kc.TriageNamespaceEvents(cmd.Context(), namespace, since, failedOnly)
+			if err != nil {
+				cmd.Printf("✗ Failed to collect events: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(groups, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+				return nil
+			}
+
+			renderEventTriage(cmd, namespace, since, groups)
+			return nil
+		},
+	}
+	eventsCmd.Flags().StringP("namespace", "n", "", "Namespace to triage")
+	eventsCmd.MarkFlagRequired("namespace")
+	eventsCmd.Flags().Duration("since", time.Hour, "Only include events newer than this duration")
+	eventsCmd.Flags().Bool("failed-only", false, "Only include warnings and failures")
+	eventsCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	clusterCmd.PersistentFlags().String("evidence-dir", "", "Save the raw API responses the checks are based on to a timestamped directory under this path")
+
 	// Add commands to cluster group
 	clusterCmd.AddCommand(allCmd)
 	clusterCmd.AddCommand(nodeCmd)
 	clusterCmd.AddCommand(permCmd)
 	clusterCmd.AddCommand(storageCmd)
+	clusterCmd.AddCommand(createDiskCmd())
+	clusterCmd.AddCommand(createImagePullCmd())
+	clusterCmd.AddCommand(createNetworkCmd())
+	clusterCmd.AddCommand(createHACmd())
+	clusterCmd.AddCommand(createClockCmd())
+	clusterCmd.AddCommand(createAdmissionCmd())
+	clusterCmd.AddCommand(createProviderCmd())
+	clusterCmd.AddCommand(createPodsCmd())
+	clusterCmd.AddCommand(createFitCmd())
+	clusterCmd.AddCommand(createOperatorsCmd())
+	clusterCmd.AddCommand(certCmd)
+	clusterCmd.AddCommand(depsCmd)
+	clusterCmd.AddCommand(oidcCmd)
+	clusterCmd.AddCommand(envCmd)
+	clusterCmd.AddCommand(checkCmd)
+	clusterCmd.AddCommand(eventsCmd)
+	clusterCmd.AddCommand(createHistoryCmd())
+	clusterCmd.AddCommand(createCompareCmd())
 
 	// Add cluster group to root command
 	rootCmd.AddCommand(clusterCmd)
 }
+
+// createDiskCmd builds 'cluster disk check', which reports ephemeral storage and image space per node
+func createDiskCmd() *cobra.Command {
+	diskCmd := &cobra.Command{
+		Use:   "disk",
+		Short: "Check node disks",
+		Long:  "Checks ephemeral storage, DiskPressure, and free image filesystem space on each node.",
+	}
+	diskCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check node disk pressure and ephemeral storage",
+		Long: `Reports ephemeral-storage allocatable and requested per node, with the free space of the node and
+image filesystems read from kubelet stats (requires get on nodes/proxy). Nodes under DiskPressure
+or with less than --min-imagefs-free for images are flagged, since large model images fail to
+pull onto full node disks.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			minFreeFlag, _ := cmd.Flags().GetString("min-imagefs-free")
+			minFree, err := resource.ParseQuantity(minFreeFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --min-imagefs-free %q: %w", minFreeFlag, err)
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+			usages, err := kc.ListNodeDiskUsage(cmd.Context(), minFree.Value())
+			if err != nil {
+				cmd.Printf("✗ Node disks: %v\n", err)
+				return err
+			}
+			renderNodeDiskUsage(cmd, usages)
+			cmd.Println()
+
+			summary, err := utils.SummarizeNodeDisk(usages, minFree.Value())
+			if err != nil {
+				cmd.Printf("! Node disks: %s\n", summary)
+				return err
+			}
+			cmd.Printf("✓ Node disks: %s\n", summary)
+			return nil
+		},
+	}
+	diskCheckCmd.Flags().String("min-imagefs-free", "20Gi", "Flag nodes with less free space than this for container images")
+	diskCmd.AddCommand(diskCheckCmd)
+	return diskCmd
+}
+
+// createImagePullCmd builds 'cluster imagepull check', which pulls an image from inside the cluster
+func createImagePullCmd() *cobra.Command {
+	imagePullCmd := &cobra.Command{
+		Use:   "imagepull",
+		Short: "Check that the cluster can pull images",
+		Long:  "Checks that nodes can pull images from the mirrored registry with the configured pull secrets.",
+	}
+	imagePullCheckCmd := &cobra.Command{
+		Use:         "check --image <ref> --namespace <namespace>",
+		Short:       "Pull an image from inside the cluster",
+		Annotations: audited,
+		Long: `Starts a short-lived pod in the namespace that pulls --image with imagePullPolicy Always, so the
+node's container runtime contacts the registry with the pod's pull secrets, CA trust, and proxy
+settings. Reports whether the pull succeeded and, if not, the registry's error with a hint. The pod
+is deleted afterwards; whether the image's container can run does not matter.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			image, _ := cmd.Flags().GetString("image")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			pullSecrets, _ := cmd.Flags().GetStringSlice("pull-secret")
+			serviceAccount, _ := cmd.Flags().GetString("service-account")
+			node, _ := cmd.Flags().GetString("node")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			result, err := kc.CheckImagePull(cmd.Context(), utils.ImagePullCheckOptions{
+				Image:          image,
+				Namespace:      namespace,
+				PullSecrets:    pullSecrets,
+				ServiceAccount: serviceAccount,
+				NodeName:       node,
+				Timeout:        timeout,
+			})
+			if err != nil {
+				cmd.Printf("✗ Image pull check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				for _, w := range result.Warnings {
+					cmd.Printf("! %s\n", w)
+				}
+				node := result.Node
+				if node == "" {
+					node = "unscheduled"
+				}
+				if result.Pulled {
+					cmd.Printf("✓ Pulled %s on node %s in %s\n", result.Image, node, result.Duration.Round(time.Second))
+				} else {
+					cmd.Printf("✗ Could not pull %s on node %s: %s\n", result.Image, node, result.Reason)
+					if result.Message != "" {
+						cmd.Printf("  %s\n", result.Message)
+					}
+					if result.Hint != "" {
+						cmd.Printf("  Hint: %s\n", result.Hint)
+					}
+				}
+			}
+			if !result.Pulled {
+				return fmt.Errorf("cluster cannot pull %s from %s", result.Image, result.Registry)
+			}
+			return nil
+		},
+	}
+	imagePullCheckCmd.Flags().String("image", "", "Image to pull, e.g. registry.example.com/dynamoai/dynamoai-api:3.22.2")
+	imagePullCheckCmd.MarkFlagRequired("image")
+	imagePullCheckCmd.Flags().StringP("namespace", "n", "", "Namespace to run the pull pod in")
+	imagePullCheckCmd.MarkFlagRequired("namespace")
+	imagePullCheckCmd.Flags().StringSlice("pull-secret", nil, "imagePullSecret to attach to the pod (repeatable)")
+	imagePullCheckCmd.Flags().String("service-account", "", "Service account to run the pod as, using its imagePullSecrets (default: the namespace's default)")
+	imagePullCheckCmd.Flags().String("node", "", "Pull on this node instead of wherever the pod is scheduled")
+	imagePullCheckCmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the pull")
+	imagePullCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	imagePullCmd.AddCommand(imagePullCheckCmd)
+	return imagePullCmd
+}
+
+func createNetworkCmd() *cobra.Command {
+	networkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "Check network policy compatibility and CIDR capacity",
+		Long:  "Checks whether NetworkPolicies and service mesh settings in a namespace allow the traffic a deployment needs.",
+	}
+	networkCheckCmd := &cobra.Command{
+		Use:   "check --namespace <namespace>",
+		Short: "Find NetworkPolicies that block required flows",
+		Long: `Evaluates the NetworkPolicies in the namespace, and in any namespace a flow reaches, against the
+flows a deployment needs and lists the policies in conflict with each blocked flow. Without --flows
+the flows are: any pod of the namespace to every Service port in it (or to any pod on any port when
+no Services exist yet), cluster DNS, and HTTPS egress outside the cluster. Also reports service mesh
+sidecar injection and STRICT mTLS, which change how pods connect.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			flowsPath, _ := cmd.Flags().GetString("flows")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			var flows []utils.NetworkFlow
+			if flowsPath != "" {
+				flows, err = utils.LoadNetworkFlows(flowsPath)
+			} else {
+				flows, err = kc.NamespaceServiceFlows(cmd.Context(), namespace)
+				flows = append(flows, utils.ClusterEgressFlows...)
+			}
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+
+			report, err := kc.CheckNetworkPolicies(cmd.Context(), namespace, flows)
+			if err != nil {
+				cmd.Printf("✗ Network policy check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderNetworkReport(cmd, report)
+			}
+			if blocked := report.Blocked(); len(blocked) > 0 {
+				return fmt.Errorf("%d of %d required flows are blocked by network policies in %s", len(blocked), len(report.Flows), namespace)
+			}
+			return nil
+		},
+	}
+	networkCheckCmd.Flags().StringP("namespace", "n", "", "Namespace the deployment runs in")
+	networkCheckCmd.MarkFlagRequired("namespace")
+	networkCheckCmd.Flags().String("flows", "", "YAML file listing the required flows (see examples/network-flows.yaml)")
+	networkCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	networkCmd.AddCommand(networkCheckCmd)
+	networkCmd.AddCommand(createCIDRCmd())
+	return networkCmd
+}
+
+// createCIDRCmd builds 'cluster network cidr check', the address headroom of the service and pod
+// ranges
+func createCIDRCmd() *cobra.Command {
+	cidrCmd := &cobra.Command{
+		Use:   "cidr",
+		Short: "Check service and pod CIDR capacity",
+		Long:  "Reports the cluster's service and pod CIDRs, the IP addresses left in them, and whether the cluster is dual-stack.",
+	}
+	cidrCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report CIDR sizes, IP headroom, and dual-stack",
+		Long: `Reads the service and pod CIDRs from ServiceCIDR objects, the OpenShift network config, kubeadm-config,
+or kube-proxy's config, whichever the cluster has. When none records the service CIDR, it is read
+from the API server's answer to a dry-run Service with an out-of-range IP. Then checks:
+
+  ip-family     IPv4, IPv6, or dual-stack; warns when services and pods differ
+  service-ips   ClusterIPs used out of each service CIDR
+  pod-ips       pod IPs left per ready node in its pod CIDR, or in the IPs its ENIs can hold
+                under the EKS VPC CNI (vpc-cni-ips)
+  eni-config    under VPC CNI custom networking, that every node has an ENIConfig giving
+                its pods a subnet, usually in a secondary VPC CIDR
+  pod-cidr      node ranges, or pod IPs, left in each cluster pod CIDR
+
+Fails when a range is exhausted and warns below 20% free.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			report, err := kc.CheckCIDRCapacity(cmd.Context())
+			if err != nil {
+				cmd.Printf("✗ CIDR capacity check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+				for _, r := range report.Results {
+					if r.Status == utils.CheckFail {
+						return fmt.Errorf("CIDR capacity check failed: %s: %s", r.Name, r.Message)
+					}
+				}
+				return nil
+			}
+			cmd.Printf("Service CIDRs: %s\n", ipRangesLabel(report.ServiceCIDRs))
+			cmd.Printf("Pod CIDRs:     %s\n", ipRangesLabel(report.PodCIDRs))
+			cmd.Println()
+			return renderCheckResults(cmd, "CIDR capacity", report.Results, output)
+		},
+	}
+	cidrCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	cidrCmd.AddCommand(cidrCheckCmd)
+	return cidrCmd
+}
+
+// ipRangesLabel lists address ranges with where they were read from
+func ipRangesLabel(ranges []utils.IPRange) string {
+	if len(ranges) == 0 {
+		return "unknown"
+	}
+	var labels []string
+	for _, r := range ranges {
+		label := r.CIDR
+		if r.NodeMask > 0 {
+			label += fmt.Sprintf(", /%d per node", r.NodeMask)
+		}
+		labels = append(labels, fmt.Sprintf("%s (%s)", label, r.Source))
+	}
+	return strings.Join(labels, "; ")
+}
+
+func createHACmd() *cobra.Command {
+	haCmd := &cobra.Command{
+		Use:   "ha",
+		Short: "Check high availability readiness",
+		Long:  "Checks whether the deployment can tolerate a node drain, for production go/no-go decisions.",
+	}
+	haCheckCmd := &cobra.Command{
+		Use:   "check --namespace <namespace>",
+		Short: "Check that a node drain can be tolerated",
+		Long: `Checks that a node drain does not take the deployment down:
+  - at least two ready, schedulable nodes of each instance type the deployment needs (those given
+    with --instance-type and those the namespace's pods run on)
+  - Deployments and StatefulSets run more than one replica, on more than one node, with a
+    PodDisruptionBudget that lets a drain evict at least one pod
+  - the control plane is managed or has an odd number of nodes, more than one
+Prints a GO, GO with warnings, or NO-GO verdict and exits non-zero on NO-GO.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			instanceTypes, _ := cmd.Flags().GetStringSlice("instance-type")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			result, err := kc.CheckHAReadiness(cmd.Context(), namespace, instanceTypes)
+			if err != nil {
+				cmd.Printf("✗ HA readiness check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				cmd.Printf("  %-14s %-40s %s\n", "Area", "Subject", "Result")
+				cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+				for _, f := range result.Findings {
+					cmd.Printf("%s\n", statusMessage(f.Status, fmt.Sprintf("%-14s %-40s %s", f.Area, f.Subject, f.Message)))
+				}
+				cmd.Println()
+				cmd.Println(statusMessage(result.Status, "Production readiness: "+result.Verdict()))
+			}
+			if result.Status == utils.CheckFail {
+				return fmt.Errorf("namespace %s cannot tolerate a node drain", namespace)
+			}
+			return nil
+		},
+	}
+	haCheckCmd.Flags().StringP("namespace", "n", "", "Namespace the deployment runs in")
+	haCheckCmd.MarkFlagRequired("namespace")
+	haCheckCmd.Flags().StringSlice("instance-type", nil, "Instance type the deployment needs, e.g. a GPU node type (repeatable)")
+	haCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	haCmd.AddCommand(haCheckCmd)
+	return haCmd
+}
+
+// createAdmissionCmd builds 'cluster admission check', which checks that pod security admission
+// lets the deployment's pods in: PSA levels on Kubernetes, SCCs on OpenShift
+func createAdmissionCmd() *cobra.Command {
+	admissionCmd := &cobra.Command{
+		Use:   "admission",
+		Short: "Check pod security admission",
+		Long:  "Checks the Pod Security Admission level of a namespace, or on OpenShift the SecurityContextConstraints its pods may use.",
+	}
+	admissionCheckCmd := &cobra.Command{
+		Use:   "check --namespace <namespace>",
+		Short: "Check that pod security admission admits the deployment's pods",
+		Long: `On Kubernetes, reports the pod-security.kubernetes.io levels of the namespace and warns when
+restricted is enforced, which rejects pods that don't run as non-root with all capabilities
+dropped and a seccomp profile.
+
+On OpenShift (detected, or forced with --platform openshift), Pod Security Admission is
+governed by SecurityContextConstraints instead. Lists the SCCs --service-account may use, through
+the SCC's users and groups or the RBAC use verb. Fails when it may use none, and warns when it
+may only use restricted-v2, which runs pods as an arbitrary UID from the namespace's range.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			serviceAccount, _ := cmd.Flags().GetString("service-account")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			result, err := kc.CheckPodAdmission(cmd.Context(), namespace, serviceAccount)
+			if err != nil {
+				cmd.Printf("✗ Pod admission check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				cmd.Printf("Platform: %s\n", result.Platform)
+				if result.Platform == utils.PlatformOpenShift {
+					cmd.Printf("ServiceAccount: %s\n", result.ServiceAccount)
+					if result.UIDRange != "" {
+						cmd.Printf("UID range: %s\n", result.UIDRange)
+					}
+				} else {
+					cmd.Printf("Pod Security: enforce=%s audit=%s warn=%s\n", dashIfEmpty(result.Enforce), dashIfEmpty(result.Audit), dashIfEmpty(result.Warn))
+				}
+				cmd.Println(statusMessage(result.Status, "Pod admission: "+result.Message))
+			}
+			if result.Status == utils.CheckFail {
+				return fmt.Errorf("pods in %s will not be admitted", namespace)
+			}
+			return nil
+		},
+	}
+	admissionCheckCmd.Flags().StringP("namespace", "n", "", "Namespace the deployment runs in")
+	admissionCheckCmd.MarkFlagRequired("namespace")
+	admissionCheckCmd.Flags().String("service-account", "default", "ServiceAccount the deployment's pods run as, for the SCC check on OpenShift")
+	admissionCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	admissionCmd.AddCommand(admissionCheckCmd)
+	return admissionCmd
+}
+
+// createPodsCmd builds 'cluster pods check', a health summary of the Dynamo namespace's pods
+func createPodsCmd() *cobra.Command {
+	podsCmd := &cobra.Command{
+		Use:   "pods",
+		Short: "Check pod health in a namespace",
+		Long:  "Summarizes crash loops, image pull failures, Pending pods, OOMKills, and missing limits in the Dynamo namespace.",
+	}
+	podsCheckCmd := &cobra.Command{
+		Use:   "check --namespace <namespace>",
+		Short: "Summarize unhealthy pods and containers",
+		Long: `Reads the pods of the namespace and reports:
+
+  crash-loop   containers in CrashLoopBackOff
+  image-pull   containers in ImagePullBackOff, ErrImagePull, or InvalidImageName
+  pending      Pending pods, with the scheduler's reason or what their containers wait on
+  oom-killed   containers OOMKilled within --since
+  no-limits    containers without a cpu or memory limit
+
+Back-offs and pods Pending for more than 5 minutes fail the check, and the command exits non-zero.
+OOMKills, missing limits, and recently created Pending pods are warnings.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			since, _ := cmd.Flags().GetDuration("since")
+			output, _ := cmd.Flags().GetString("output")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			report, err := kc.CheckPodHealth(cmd.Context(), namespace, since)
+			if err != nil {
+				cmd.Printf("✗ Pod health check failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderPodHealth(cmd, report)
+			}
+			if report.Failed() {
+				return fmt.Errorf("unhealthy pods in %s", namespace)
+			}
+			return nil
+		},
+	}
+	podsCheckCmd.Flags().StringP("namespace", "n", "", "Namespace Dynamo runs in")
+	podsCheckCmd.MarkFlagRequired("namespace")
+	podsCheckCmd.Flags().Duration("since", 24*time.Hour, "Report OOMKills newer than this duration")
+	podsCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	podsCmd.AddCommand(podsCheckCmd)
+	return podsCmd
+}
+
+// createProviderCmd builds 'cluster provider check', the check pack of the managed Kubernetes
+// offering the cluster runs on
+func createProviderCmd() *cobra.Command {
+	providerCmd := &cobra.Command{
+		Use:   "provider",
+		Short: "Check managed Kubernetes service specifics",
+		Long:  "Runs the checks specific to EKS, AKS, or GKE, picked from the cluster's nodes.",
+	}
+	providerCheckCmd := &cobra.Command{
+		Use:   "check [--provider eks|aks|gke] [--namespace <namespace>]",
+		Short: "Run the EKS, AKS, or GKE check pack",
+		Long: `Detects the managed offering from the nodes' provider IDs, as cluster env does, and runs its checks:
+
+  EKS  irsa (pod identity webhook or EKS Pod Identity agent), vpc-cni-ips (pod IP headroom
+       per node under the aws-node VPC CNI), ebs-csi (EBS CSI driver add-on and controller)
+  AKS  managed-identity (workload identity webhook), agic (Application Gateway Ingress Controller)
+  GKE  workload-identity (GKE metadata server on every node pool), gpu-driver (a driver installer
+       for GPU nodes)
+
+With --namespace, the identity checks also count the namespace's ServiceAccounts that carry an
+IAM role or managed identity annotation. Other platforms have no check pack and pass.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, _ := cmd.Flags().GetString("provider")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			output, _ := cmd.Flags().GetString("output")
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			provider, results, err := kc.RunProviderChecks(cmd.Context(), provider, namespace)
+			if err != nil {
+				cmd.Printf("✗ Provider checks failed: %v\n", err)
+				return err
+			}
+			if results == nil {
+				cmd.Printf("No check pack for %s\n", provider)
+				return nil
+			}
+			if output != "json" {
+				cmd.Printf("Provider: %s\n", provider)
+			}
+			return renderCheckResults(cmd, strings.ToUpper(provider), results, output)
+		},
+	}
+	providerCheckCmd.Flags().String("provider", utils.PlatformAuto, "Check pack to run: auto, eks, aks, or gke")
+	providerCheckCmd.Flags().StringP("namespace", "n", "", "Namespace whose ServiceAccounts are checked for cloud identity annotations")
+	providerCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	providerCmd.AddCommand(providerCheckCmd)
+	return providerCmd
+}
+
+func createClockCmd() *cobra.Command {
+	clockCmd := &cobra.Command{
+		Use:   "clock",
+		Short: "Check node time synchronization",
+		Long:  "Checks that node clocks agree with the API server; skewed clocks break JWT and license validation.",
+	}
+	clockCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check node clock skew",
+		Long: `Estimates each node's clock offset from the API server using the kubelet's Lease heartbeat,
+which the kubelet stamps with its own clock. A heartbeat in the API server's future means the node is
+ahead; one older than the renew interval on a Ready node means it is behind. The estimate is good to
+about a second plus the renew interval (10s by default), so skew is reported as a lower bound. Fails
+when any node is off by more than --max-skew.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			maxSkew, _ := cmd.Flags().GetDuration("max-skew")
+			output, _ := cmd.Flags().GetString("output")
+			if maxSkew <= 0 {
+				return fmt.Errorf("--max-skew must be positive")
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			result, err := kc.ListNodeClockSkew(cmd.Context(), maxSkew)
+			if err != nil {
+				cmd.Printf("✗ Clock skew check failed: %v\n", err)
+				return err
+			}
+			summary, skewErr := utils.SummarizeClockSkew(result)
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				cmd.Printf("API server time: %s (this machine is %s off)\n", result.ServerTime.UTC().Format(time.RFC3339), result.LocalSkew)
+				cmd.Println()
+				cmd.Printf("  %-40s %-14s %s\n", "Node", "Heartbeat Lag", "Result")
+				cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+				for _, n := range result.Nodes {
+					lag, message := "-", n.Message
+					if n.RenewInterval > 0 {
+						lag = n.Lag.Round(time.Second).String()
+					}
+					if message == "" {
+						message = fmt.Sprintf("within %s", maxSkew)
+					}
+					cmd.Println(statusMessage(n.Status, fmt.Sprintf("%-40s %-14s %s", n.Name, lag, message)))
+				}
+				cmd.Println()
+				if skewErr != nil {
+					cmd.Printf("✗ %s\n", summary)
+				} else {
+					cmd.Printf("✓ %s\n", summary)
+				}
+			}
+			return skewErr
+		},
+	}
+	clockCheckCmd.Flags().Duration("max-skew", utils.DefaultMaxClockSkew, "Largest tolerated offset between a node's clock and the API server's")
+	clockCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	clockCmd.AddCommand(clockCheckCmd)
+	return clockCmd
+}
+
+// createFitCmd builds 'cluster fit', which dry-runs scheduling of a release sizing profile
+func createFitCmd() *cobra.Command {
+	fitCmd := &cobra.Command{
+		Use:   "fit --profile <sizing.yaml>",
+		Short: "Simulate scheduling a release sizing profile",
+		Long: `Bin-packs the per-component resource profile of a release tier (see examples/sizing/medium.yaml)
+onto the free capacity of the cluster's ready, schedulable nodes, respecting each component's node
+selector, tolerations, and GPU type. Nothing is created. Reports where each component's replicas
+would land and, for components that don't fit, why each node rejects them. Exits non-zero when any
+component does not fit.
+
+--terraform-out writes the missing capacity as a Terraform variable file for the infra team's
+node group module: eks_managed_node_groups of terraform-aws-modules/eks, or node_pools of
+Azure/aks with --terraform-module aks. Each group adds nodes shaped like the existing pool that
+best holds the unplaced replicas, with its instance type, taints, and disk size (or --disk-size).
+The file is HCL, or JSON when its name ends in .json, e.g. capacity.auto.tfvars.json.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profilePath, _ := cmd.Flags().GetString("profile")
+			output, _ := cmd.Flags().GetString("output")
+			terraformOut, _ := cmd.Flags().GetString("terraform-out")
+			terraformModule, _ := cmd.Flags().GetString("terraform-module")
+			diskSize, _ := cmd.Flags().GetInt("disk-size")
+
+			if terraformOut != "" && terraformModule != utils.TerraformModuleEKS && terraformModule != utils.TerraformModuleAKS {
+				return fmt.Errorf("--terraform-module must be %s or %s", utils.TerraformModuleEKS, utils.TerraformModuleAKS)
+			}
+
+			profile, err := utils.LoadSizingProfile(profilePath)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			nodes, err := kc.ListNodeCapacities(cmd.Context())
+			if err != nil {
+				cmd.Printf("✗ Scheduling simulation failed: %v\n", err)
+				return err
+			}
+			result, err := utils.SimulateSizingFit(nodes, *profile)
+			if err != nil {
+				cmd.Printf("✗ Scheduling simulation failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderSizingFit(cmd, profile, result)
+			}
+			if terraformOut != "" {
+				if err := writeCapacityTerraform(cmd, nodes, profile, result, terraformOut, terraformModule, diskSize); err != nil {
+					cmd.Printf("✗ %v\n", err)
+					return err
+				}
+			}
+			if !result.Fits {
+				return fmt.Errorf("sizing profile %s does not fit on the cluster", result.Profile)
+			}
+			return nil
+		},
+	}
+	fitCmd.Flags().String("profile", "", "Path to the release sizing profile (YAML or JSON)")
+	fitCmd.MarkFlagRequired("profile")
+	fitCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	fitCmd.Flags().String("terraform-out", "", "Write the missing capacity as a Terraform variable file (.tfvars, or .tfvars.json for JSON)")
+	fitCmd.Flags().String("terraform-module", utils.TerraformModuleEKS, "Node group variable to write: eks (eks_managed_node_groups) or aks (node_pools)")
+	fitCmd.Flags().Int("disk-size", 0, "Node disk size in GB for --terraform-out (default: that of the existing pool)")
+	return fitCmd
+}
+
+// writeCapacityTerraform writes the node groups a sizing profile is missing to a Terraform
+// variable file and notes the components whose node type has to be chosen by hand
+func writeCapacityTerraform(cmd *cobra.Command, nodes []utils.NodeCapacity, profile *utils.SizingProfile, result *utils.SizingFitResult, path, module string, diskSize int) error {
+	gap, err := utils.PlanCapacityGap(nodes, *profile, result, diskSize)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteTerraformVars(path, module, gap); err != nil {
+		return err
+	}
+	cmd.Println()
+	if len(gap.NodeGroups) == 0 && len(gap.Unsized) == 0 {
+		cmd.Printf("✓ No capacity missing; wrote an empty node group variable to %s\n", path)
+		return nil
+	}
+	cmd.Printf("Wrote %d node group(s) to %s:\n", len(gap.NodeGroups), path)
+	for _, g := range gap.NodeGroups {
+		cmd.Printf("  %-30s %d x %s, %d GB disk (%s)\n", g.Name, g.Count, g.InstanceType, g.DiskSizeGB, strings.Join(g.Components, ", "))
+	}
+	if len(gap.Unsized) > 0 {
+		cmd.Printf("⚠ No existing pool can hold %s; add a node group for them by hand\n", strings.Join(gap.Unsized, ", "))
+	}
+	return nil
+}
+
+// createNodeRotateCmd builds 'cluster node rotate', which moves the pods off a node before it is
+// replaced
+func createNodeRotateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate <node>",
+		Short: "Drain a node safely before replacing it",
+		Long: `Prepares a node, typically a GPU node due for an AMI rotation, to be replaced:
+
+  1. Lists the pods a drain would evict, GPU (model) pods first. DaemonSet and static pods stay.
+  2. Checks the other ready nodes have room for them, with the same bin packing as cluster fit.
+     GPU pods only count nodes with the same GPU product. Pods not managed by a controller, and
+     PodDisruptionBudgets that allow no disruptions, fail the check.
+  3. Cordons the node and evicts the pods through the Eviction API, retrying evictions a
+     PodDisruptionBudget refuses until --drain-timeout.
+  4. Waits until each controller has as many ready pods on other nodes as it had before.
+
+Any failed check stops before the node is touched; --force continues anyway. Use --dry-run to
+only run the checks. The node is left cordoned and empty, ready to be terminated.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodeName := args[0]
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			force, _ := cmd.Flags().GetBool("force")
+			drainTimeout, _ := cmd.Flags().GetDuration("drain-timeout")
+			rescheduleTimeout, _ := cmd.Flags().GetDuration("reschedule-timeout")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			plan, err := kc.PlanNodeRotation(ctx, nodeName)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			renderNodeRotationPlan(cmd, plan)
+			if plan.Failed() && !force {
+				return fmt.Errorf("node %s cannot be rotated safely; fix the failed checks or rerun with --force", nodeName)
+			}
+			if dryRun {
+				return nil
+			}
+			if err := confirm(cmd, fmt.Sprintf("cordon %s and evict %d pod(s)", nodeName, len(plan.Pods))); err != nil {
+				return err
+			}
+
+			before, err := kc.ReadyPodsByOwner(ctx, plan)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			if err := kc.CordonNode(ctx, nodeName); err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			cmd.Printf("✓ Cordoned %s\n", nodeName)
+
+			blocked := map[string]bool{}
+			err = kc.DrainNode(ctx, plan, utils.NodeDrainOptions{
+				Timeout:  drainTimeout,
+				Interval: 5 * time.Second,
+				OnEvict: func(p utils.DisplacedPod, err error) {
+					name := p.Namespace + "/" + p.Name
+					if err == nil {
+						cmd.Printf("✓ Evicted %s\n", name)
+					} else if !blocked[name] {
+						blocked[name] = true
+						cmd.Printf("! Eviction of %s refused (PodDisruptionBudget %s); retrying\n", name, dashIfEmpty(p.PDB))
+					}
+				},
+			})
+			if err != nil {
+				cmd.Printf("✗ Drain failed: %v\n", err)
+				cmd.Printf("! %s is still cordoned; uncordon it with kubectl uncordon %s to put it back in service\n", nodeName, nodeName)
+				return err
+			}
+			cmd.Printf("✓ Drained %s\n", nodeName)
+
+			err = kc.WaitForRescheduled(ctx, plan, before, rescheduleTimeout, 5*time.Second, func(o utils.RescheduledOwner) {
+				cmd.Printf("✓ %s/%s has %d ready pod(s) on %s\n", o.Namespace, o.Owner, o.Ready, strings.Join(o.Nodes, ", "))
+			})
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			cmd.Printf("✓ %s is cordoned and drained; it can be replaced\n", nodeName)
+			return nil
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "Only check what the drain would displace and whether it fits elsewhere")
+	cmd.Flags().Bool("force", false, "Rotate the node even when checks fail")
+	cmd.Flags().Duration("drain-timeout", 10*time.Minute, "How long to keep evicting pods, including retries blocked by PodDisruptionBudgets")
+	cmd.Flags().Duration("reschedule-timeout", 15*time.Minute, "How long to wait for the evicted pods' replacements to be ready")
+	addYesFlag(cmd)
+	return cmd
+}
+
+// renderNodeRotationPlan prints the pods a drain displaces and the rotation checks
+func renderNodeRotationPlan(cmd *cobra.Command, plan *utils.NodeRotationPlan) {
+	cmd.Printf("Pods on %s\n", plan.Node)
+	if len(plan.Pods) > 0 {
+		cmd.Printf("  %-48s %-40s %-8s %-10s %-4s %s\n", "Pod", "Owner", "CPU", "Memory", "GPU", "PDB")
+		for _, p := range plan.Pods {
+			pdb := dashIfEmpty(p.PDB)
+			if p.Blocked {
+				pdb += " (blocking)"
+			}
+			cmd.Printf("  %-48s %-40s %-8s %-10s %-4d %s\n", p.Namespace+"/"+p.Name, dashIfEmpty(p.Owner), dashIfEmpty(p.CPU), dashIfEmpty(p.Memory), p.GPU, pdb)
+		}
+	}
+	cmd.Println()
+	for _, r := range plan.Checks {
+		printCheckResult(cmd, r)
+	}
+}
+
+// createOperatorsCmd builds 'cluster operators check', which verifies the operators the charts need
+func createOperatorsCmd() *cobra.Command {
+	operatorsCmd := &cobra.Command{
+		Use:   "operators",
+		Short: "Check required operators",
+		Long:  "Checks that the CRDs and operators the Dynamo charts depend on are installed at supported versions.",
+	}
+	operatorsCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check operator CRDs and versions",
+		Long: `Checks each operator the release depends on (cert-manager, Prometheus Operator, KServe, and KubeRay
+by default): its CRDs must exist and serve the API version the charts use, and its version must be in the
+required range. The version is read from the CRD labels, or from the tag of the operator's controller
+image. Reports which operators must be installed or upgraded and exits non-zero when a required one
+does; missing optional operators are warnings. Use --config to check a different set of operators.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			output, _ := cmd.Flags().GetString("output")
+
+			requirements := utils.DefaultOperatorRequirements
+			if configPath != "" {
+				loaded, err := utils.LoadOperatorRequirements(configPath)
+				if err != nil {
+					cmd.Printf("✗ %v\n", err)
+					return err
+				}
+				requirements = loaded
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			statuses, err := kc.CheckOperators(cmd.Context(), requirements)
+			if err != nil {
+				cmd.Printf("✗ Operator check failed: %v\n", err)
+				return err
+			}
+			summary, opErr := utils.SummarizeOperators(statuses)
+
+			if output == "json" {
+				data, err := json.MarshalIndent(statuses, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+				return opErr
+			}
+
+			cmd.Printf("  %-22s %-14s %-18s %-10s %s\n", "Operator", "Installed", "Required", "Action", "Result")
+			cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+			for _, s := range statuses {
+				installed, required, action := s.Installed, s.Required, s.Action
+				if installed == "" {
+					installed = "-"
+				}
+				if required == "" {
+					required = "any"
+				}
+				if action == "" {
+					action = "-"
+				}
+				cmd.Println(statusMessage(s.Status, fmt.Sprintf("%-22s %-14s %-18s %-10s %s", s.Name, installed, required, action, s.Message)))
+			}
+			cmd.Println()
+			if opErr != nil {
+				cmd.Printf("✗ %s\n", summary)
+			} else {
+				cmd.Printf("✓ %s\n", summary)
+			}
+			return opErr
+		},
+	}
+	operatorsCheckCmd.Flags().String("config", "", "YAML file listing the operators, CRDs, and version ranges to check (see examples/operators.yaml)")
+	operatorsCheckCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	operatorsCmd.AddCommand(operatorsCheckCmd)
+	return operatorsCmd
+}
+
+// renderSizingFit prints each component's placement and why unplaced replicas don't fit
+func renderSizingFit(cmd *cobra.Command, profile *utils.SizingProfile, result *utils.SizingFitResult) {
+	cmd.Printf("Sizing profile: %s (%d components, %d ready nodes)\n", result.Profile, len(result.Components), result.Nodes)
+	cmd.Println()
+	cmd.Printf("  %-30s %-18s %-10s %s\n", "Component", "CPU/Mem/GPU", "Placed", "Node pools")
+	cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+	for i, c := range result.Components {
+		p := profile.Components[i]
+		var pools []string
+		for _, pl := range c.Placements {
+			pools = append(pools, fmt.Sprintf("%s (%d)", pl.Pool, pl.Replicas))
+		}
+		line := fmt.Sprintf("%-30s %-18s %-10s %s", c.Component, joinTriple(p.CPU, p.Memory, fmt.Sprintf("%d", p.GPU)),
+			fmt.Sprintf("%d/%d", c.Placed, c.Replicas), strings.Join(pools, ", "))
+		if c.Fits {
+			cmd.Printf("✓ %s\n", line)
+			continue
+		}
+		cmd.Printf("✗ %s\n", line)
+		for _, reason := range c.Reasons {
+			cmd.Printf("    %s\n", reason)
+		}
+	}
+	cmd.Println()
+
+	failed := 0
+	for _, c := range result.Components {
+		if !c.Fits {
+			failed++
+		}
+	}
+	if failed == 0 {
+		cmd.Printf("✓ All %d components fit on existing capacity\n", len(result.Components))
+	} else {
+		cmd.Printf("✗ %d of %d components do not fit\n", failed, len(result.Components))
+	}
+}
+
+// renderNetworkReport prints each flow's verdict and the mesh findings
+func renderNetworkReport(cmd *cobra.Command, report *utils.NetworkPolicyReport) {
+	if len(report.Policies) == 0 {
+		cmd.Printf("No NetworkPolicies in %s\n", report.Namespace)
+	} else {
+		cmd.Printf("NetworkPolicies in %s: %s\n", report.Namespace, strings.Join(report.Policies, ", "))
+	}
+	cmd.Println()
+	cmd.Printf("%-2s %-32s %-45s %-10s %s\n", "", "Flow", "Destination", "Port", "Blocked By")
+	cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+	for _, f := range report.Flows {
+		marker, blockedBy := "✓", "-"
+		if !f.Allowed {
+			marker = "✗"
+			blockedBy = fmt.Sprintf("%s (%s)", strings.Join(f.BlockedBy, ", "), f.Side)
+		}
+		cmd.Printf("%-2s %-32s %-45s %-10s %s\n", marker, f.Flow.Name, networkEndpointLabel(f.Flow.To), networkPortLabel(f.Flow), blockedBy)
+	}
+	if len(report.Mesh) > 0 {
+		cmd.Println()
+	}
+	for _, m := range report.Mesh {
+		cmd.Printf("! %s\n", m)
+	}
+}
+
+// networkEndpointLabel describes a flow endpoint in one column
+func networkEndpointLabel(ep utils.NetworkEndpoint) string {
+	if ep.CIDR != "" {
+		return ep.CIDR
+	}
+	var pairs []string
+	for k, v := range ep.Labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	selector := strings.Join(pairs, ",")
+	if selector == "" {
+		selector = "any pod"
+	}
+	if ep.Namespace != "" {
+		return ep.Namespace + "/" + selector
+	}
+	return selector
+}
+
+// networkPortLabel renders a flow's port and protocol
+func networkPortLabel(f utils.NetworkFlow) string {
+	protocol := f.Protocol
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	if f.Port == 0 {
+		return "any/" + protocol
+	}
+	return fmt.Sprintf("%d/%s", f.Port, protocol)
+}
+
+// renderNodeDiskUsage prints ephemeral storage and filesystem space per node
+func renderNodeDiskUsage(cmd *cobra.Command, usages []utils.NodeDiskUsage) {
+	if len(usages) == 0 {
+		cmd.Println("No nodes found")
+		return
+	}
+	cmd.Printf("%-2s %-40s %-24s %-20s %-20s %s\n", "", "Node", "Ephemeral Req/Alloc", "NodeFs Free", "ImageFs Free", "Problems")
+	cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+	for _, u := range usages {
+		marker := "✓"
+		if len(u.Problems) > 0 {
+			marker = "!"
+		}
+		ephemeral := fmt.Sprintf("%s/%s", formatGi(u.EphemeralRequests), formatGi(u.EphemeralAllocatable))
+		if u.EphemeralAllocatable > 0 {
+			ephemeral += fmt.Sprintf(" (%.0f%%)", u.EphemeralRequestsPercent)
+		}
+		nodeFs, imageFs := "-", "-"
+		if u.StatsAvailable {
+			nodeFs = fmt.Sprintf("%s/%s", formatGi(u.NodeFsAvailable), formatGi(u.NodeFsCapacity))
+			imageFs = fmt.Sprintf("%s/%s", formatGi(u.ImageFsAvailable), formatGi(u.ImageFsCapacity))
+		}
+		problems := strings.Join(u.Problems, ", ")
+		if problems == "" {
+			problems = "-"
+		}
+		cmd.Printf("%-2s %-40s %-24s %-20s %-20s %s\n", marker, u.Name, ephemeral, nodeFs, imageFs, problems)
+	}
+}
+
+// createHistoryCmd lists the check runs saved under ~/.dynactl/history
+func createHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history",
+		Aliases: []string{"hist"},
+		Short:   "List saved cluster check results",
+		Long:    "Lists the results saved by `cluster all check`, `cluster check`, and `cluster compare`, oldest first. Use an ID with `cluster compare --against` to see what changed since.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, _ := cmd.Flags().GetString("output")
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+			records, err := utils.ListCheckHistory()
+			if err != nil {
+				return err
+			}
+			if records == nil {
+				records = []utils.HistoryRecord{}
+			}
+			if len(records) == 0 && output.IsTabular(outputFormat) {
+				cmd.Println("No saved check results yet; run `dynactl cluster all check` to record one")
+				return nil
+			}
+
+			table := &output.Table{
+				Columns: []output.Column{
+					{Header: "ID", CSV: "id"},
+					{Header: "TIME", CSV: "time"},
+					{Header: "CLUSTER", CSV: "cluster", MaxWidth: 50},
+					{Header: "CHECKS", CSV: "checks"},
+					{Header: "FAILED", CSV: "failed"},
+					{Header: "WARNINGS", CSV: "warnings"},
+					{Header: "NODES", CSV: "nodes"},
+				},
+				Data: records,
+			}
+			for _, r := range records {
+				warnings := 0
+				for _, result := range r.Results {
+					if result.Status == utils.CheckWarn {
+						warnings++
+					}
+				}
+				nodes := ""
+				if r.Capacity != nil {
+					nodes = fmt.Sprintf("%d/%d", r.Capacity.ReadyNodes, r.Capacity.TotalNodes)
+				}
+				table.AddRow(r.ID, r.Time.Local().Format("2006-01-02 15:04"), r.Cluster, fmt.Sprintf("%d", len(r.Results)),
+					fmt.Sprintf("%d", len(r.Failures)), fmt.Sprintf("%d", warnings), nodes)
+			}
+			return renderer.Render(cmd.OutOrStdout(), table)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+	return cmd
+}
+
+// createCompareCmd diffs a saved check run against the current cluster or another saved run
+func createCompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare --against <timestamp>",
+		Short: "Show what changed since a saved check run",
+		Long:  "Compares check results and node capacity from a saved run (see `cluster history`) with a fresh run against the current cluster, or with another saved run given by --to. The timestamp may be a history ID, a prefix of one such as 20260301, or an RFC 3339 time.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			against, _ := cmd.Flags().GetString("against")
+			to, _ := cmd.Flags().GetString("to")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			noHistory, _ := cmd.Flags().GetBool("no-history")
+			outputFormat, _ := cmd.Flags().GetString("output")
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			records, err := utils.ListCheckHistory()
+			if err != nil {
+				return err
+			}
+			before, err := utils.FindCheckHistory(records, against)
+			if err != nil {
+				return err
+			}
+
+			var after utils.HistoryRecord
+			afterLabel := "current cluster"
+			if to != "" {
+				found, err := utils.FindCheckHistory(records, to)
+				if err != nil {
+					return err
+				}
+				after = *found
+				afterLabel = after.ID
+			} else {
+				kc, err := kubeChecker(cmd)
+				if err != nil {
+					cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+					return err
+				}
+				after = kc.RunSurvey(cmd.Context(), namespace)
+				if !noHistory {
+					saveCheckHistory(after)
+				}
+			}
+
+			changes := utils.CompareCheckHistory(*before, after)
+			if changes == nil {
+				changes = []utils.CheckChange{}
+			}
+			if output.IsTabular(outputFormat) {
+				cmd.Printf("Comparing %s (%s) with %s\n", before.ID, before.Time.Local().Format("2006-01-02 15:04"), afterLabel)
+				if before.Cluster != after.Cluster {
+					cmd.Printf("! Runs are from different clusters: %s and %s\n", before.Cluster, after.Cluster)
+				}
+				if len(changes) == 0 {
+					cmd.Println("✓ No changes")
+					return nil
+				}
+			}
+
+			table := &output.Table{
+				Columns: []output.Column{
+					{Header: "CHECK", CSV: "check"},
+					{Header: "BEFORE", CSV: "before", MaxWidth: 60},
+					{Header: "AFTER", CSV: "after", MaxWidth: 60},
+				},
+				Data: changes,
+			}
+			for _, c := range changes {
+				table.AddRow(c.Name, statusMessage(c.BeforeStatus, c.Before), statusMessage(c.AfterStatus, c.After))
+			}
+			return renderer.Render(cmd.OutOrStdout(), table)
+		},
+	}
+	cmd.Flags().String("against", "", "Saved run to compare with (history ID, ID prefix, or RFC 3339 time)")
+	_ = cmd.MarkFlagRequired("against")
+	cmd.Flags().String("to", "", "Compare with this saved run instead of the current cluster")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace for namespace-scoped checks (permissions, certs) in the fresh run")
+	cmd.Flags().Bool("no-history", false, "Do not save the fresh run to ~/.dynactl/history")
+	cmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+	return cmd
+}
+
+// saveCheckHistory records a run, warning instead of failing the check when it cannot be saved
+func saveCheckHistory(record utils.HistoryRecord) {
+	path, err := utils.SaveCheckHistory(record)
+	if err != nil {
+		utils.LogWarning("Failed to save check results: %v", err)
+		return
+	}
+	utils.LogInfo("Saved check results to %s", path)
+}
+
+// checkResult records the outcome of a check, using failStatus when it returned an error and the
+// error text when it returned no message
+func checkResult(name, message string, err error, failStatus string) utils.CheckResult {
+	if err == nil {
+		return utils.CheckResult{Name: name, Status: utils.CheckPass, Message: message}
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return utils.CheckResult{Name: name, Status: failStatus, Message: message}
+}
+
+// kubeChecker returns the KubernetesChecker shared by everything the command runs, connecting
+// on first use
+func kubeChecker(cmd *cobra.Command) (*utils.KubernetesChecker, error) {
+	applyEvidenceDir(cmd)
+	return utils.KubeClientsFrom(cmd.Context()).Checker(cmd.Context())
+}
+
+// applyEvidenceDir makes checkers created for the command save API responses when the cluster
+// commands' --evidence-dir is set
+func applyEvidenceDir(cmd *cobra.Command) {
+	if dir, err := cmd.Flags().GetString("evidence-dir"); err == nil {
+		utils.SetEvidenceDir(dir)
+	}
+}
+
+// statusMessage prefixes a check message with its status glyph
+func statusMessage(status, message string) string {
+	switch status {
+	case utils.CheckPass:
+		return "✓ " + message
+	case utils.CheckWarn:
+		return "! " + message
+	case utils.CheckFail:
+		return "✗ " + message
+	}
+	return message
+}
+
+// renderEventTriage prints issues grouped by workload, most failures first
+func renderEventTriage(cmd *cobra.Command, namespace string, since time.Duration, groups []utils.WorkloadIssues) {
+	cmd.Printf("Namespace: %s (last %s)\n", namespace, since)
+	if len(groups) == 0 {
+		cmd.Println("✓ No events found")
+		return
+	}
+
+	total := 0
+	for _, g := range groups {
+		total += g.Failures
+		marker := "✓"
+		if g.Failures > 0 {
+			marker = "✗"
+		}
+		cmd.Println()
+		cmd.Printf("%s %s (%d failures)\n", marker, g.Workload, g.Failures)
+		for _, issue := range g.Issues {
+			cmd.Printf("    %-8s %-12s %-22s x%-4d %s\n",
+				issue.LastSeen.Format("15:04:05"),
+				issue.Category,
+				issue.Reason,
+				issue.Count,
+				truncateMessage(issue.Message, 100),
+			)
+		}
+	}
+	cmd.Println()
+	cmd.Printf("%d failures across %d workloads\n", total, len(groups))
+}
+
+// renderPodHealth prints the pod health issues, failures first, and a count per category
+func renderPodHealth(cmd *cobra.Command, report *utils.PodHealthReport) {
+	cmd.Printf("Pods in %s: %d (%d healthy)\n", report.Namespace, report.Pods, report.Healthy)
+	if len(report.Issues) == 0 {
+		cmd.Println("✓ No issues found")
+		return
+	}
+	cmd.Println()
+	for _, issue := range report.Issues {
+		marker := "!"
+		if issue.Status == utils.CheckFail {
+			marker = "✗"
+		}
+		cmd.Printf("%s %-11s %-40s %s\n", marker, issue.Category, strings.TrimPrefix(issue.Object, "Pod/"), truncateMessage(issue.Message, 100))
+	}
+	counts := report.Counts()
+	var summary []string
+	for _, category := range []string{utils.IssueCrashLoop, utils.IssueImagePull, utils.IssuePending, utils.IssueOOMKilled, utils.IssueNoLimits} {
+		if counts[category] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[category], category))
+		}
+	}
+	cmd.Println()
+	cmd.Println(strings.Join(summary, ", "))
+}
+
+// renderCheckResults prints pass/warn/fail results and returns an error if any step failed
+func renderCheckResults(cmd *cobra.Command, title string, results []utils.CheckResult, output string) error {
+	failed := 0
+	for _, r := range results {
+		if r.Status == utils.CheckFail {
+			failed++
+		}
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+			return err
+		}
+		cmd.Println(string(data))
+	} else {
+		for _, r := range results {
+			marker := "✓"
+			switch r.Status {
+			case utils.CheckFail:
+				marker = "✗"
+			case utils.CheckWarn:
+				marker = "!"
+			}
+			cmd.Printf("%s %-16s %s\n", marker, r.Name, r.Message)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s validation failed: %d checks failed", title, failed)
+	}
+	return nil
+}
+
+// renderCertificates prints certificate expiry status, most urgent first
+func renderCertificates(cmd *cobra.Command, certs []utils.CertificateStatus) {
+	if len(certs) == 0 {
+		cmd.Println("No TLS certificates found")
+		return
+	}
+
+	cmd.Printf("%-10s %-45s %-12s %-10s %s\n", "Status", "Certificate", "Expires", "Days left", "Details")
+	cmd.Println("----------------------------------------------------------------------------------------------")
+	for _, c := range certs {
+		expires, daysLeft := "-", "-"
+		if c.NotAfter != nil {
+			expires = c.NotAfter.Format("2006-01-02")
+			daysLeft = fmt.Sprintf("%d", c.DaysLeft)
+		}
+		details := c.Message
+		if details == "" {
+			details = strings.Join(c.DNSNames, ",")
+		}
+		if len(c.UsedBy) > 0 {
+			details = strings.TrimSpace(details + " used by " + strings.Join(c.UsedBy, ","))
+		}
+		cmd.Printf("%-10s %-45s %-12s %-10s %s\n",
+			strings.ToUpper(c.Status),
+			c.Source+"/"+c.Name,
+			expires,
+			daysLeft,
+			truncateMessage(details, 80),
+		)
+	}
+}
+
+// summarizeCertificates returns a one-line verdict and an error when any certificate needs attention
+func summarizeCertificates(certs []utils.CertificateStatus, days int) (string, error) {
+	var problems []string
+	for _, c := range certs {
+		if c.Status != utils.CertStatusOK {
+			problems = append(problems, fmt.Sprintf("%s/%s (%s)", c.Source, c.Name, c.Status))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Sprintf("%d of %d certificates need attention: %s", len(problems), len(certs), strings.Join(problems, ", ")),
+			fmt.Errorf("certificates expired, expiring within %d days, or invalid", days)
+	}
+	return fmt.Sprintf("%d certificates valid for more than %d days", len(certs), days), nil
+}
+
+// addStorageThresholdFlags adds the storage capacity thresholds to a check command
+func addStorageThresholdFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("warn-threshold", utils.DefaultStorageThresholds.Warn, "Warn when a PVC is more than this percent full")
+	cmd.Flags().Float64("fail-threshold", utils.DefaultStorageThresholds.Fail, "Fail when a PVC is more than this percent full")
+}
+
+// storageThresholds reads and validates the storage capacity threshold flags
+func storageThresholds(cmd *cobra.Command) (utils.StorageThresholds, error) {
+	warn, _ := cmd.Flags().GetFloat64("warn-threshold")
+	fail, _ := cmd.Flags().GetFloat64("fail-threshold")
+	thresholds := utils.StorageThresholds{Warn: warn, Fail: fail}
+	if err := thresholds.Validate(); err != nil {
+		return thresholds, fmt.Errorf("invalid --warn-threshold/--fail-threshold: %w", err)
+	}
+	return thresholds, nil
+}
+
+// renderStorageClasses prints the per-StorageClass breakdown of a storage capacity check. Used
+// comes from volume stats of mounted claims; Bound and Unbound are PV capacity, which is
+// allocated rather than used.
+func renderStorageClasses(cmd *cobra.Command, result *utils.StorageCapacityResult) {
+	if len(result.Classes) == 0 {
+		return
+	}
+	cmd.Printf("%-2s %-24s %-10s %-8s %-12s %-12s %-8s %-12s %s\n", "", "StorageClass", "Provision", "PVCs", "Used", "Capacity", "Use%", "Bound", "Unbound")
+	cmd.Println("----------------------------------------------------------------------------------------------------------")
+	for _, c := range result.Classes {
+		provision := "static"
+		unbound := formatGi(c.UnboundBytes)
+		if c.Dynamic {
+			provision = "dynamic"
+			unbound = "on demand"
+		}
+		used, capacity, percent := "-", "-", "-"
+		if c.MountedPVCs > 0 {
+			used = formatGi(c.UsedBytes)
+			capacity = formatGi(c.CapacityBytes)
+			percent = fmt.Sprintf("%.1f%%", c.UsedPercent)
+		}
+		name := c.StorageClass
+		if name == "" {
+			name = "(none)"
+		}
+		cmd.Printf("%-2s %-24s %-10s %-8s %-12s %-12s %-8s %-12s %s\n",
+			strings.TrimSpace(statusMessage(c.Status, "")),
+			name,
+			provision,
+			fmt.Sprintf("%d/%d", c.MountedPVCs, c.PVCs),
+			used,
+			capacity,
+			percent,
+			formatGi(c.BoundBytes),
+			unbound,
+		)
+	}
+}
+
+// renderPVCUsage prints real filesystem usage per PVC, flagging claims above the thresholds
+func renderPVCUsage(cmd *cobra.Command, usages []utils.PVCUsage, thresholds utils.StorageThresholds) {
+	if len(usages) == 0 {
+		cmd.Println("No PersistentVolumeClaims found")
+		return
+	}
+
+	cmd.Printf("%-2s %-50s %-36s %-12s %-12s %s\n", "", "PVC", "Component", "Used", "Capacity", "Use%")
+	cmd.Println("--------------------------------------------------------------------------------------------------------------------------")
+	for _, u := range usages {
+		marker := "✓"
+		used, percent := "-", "not mounted"
+		if u.Mounted {
+			used = formatGi(u.UsedBytes)
+			percent = fmt.Sprintf("%.1f%%", u.UsedPercent)
+			if u.UsedPercent > thresholds.Fail {
+				marker = "✗"
+			} else if u.UsedPercent > thresholds.Warn {
+				marker = "!"
+			}
+		}
+		cmd.Printf("%-2s %-50s %-36s %-12s %-12s %s\n",
+			marker,
+			u.Namespace+"/"+u.Name,
+			u.Component,
+			used,
+			formatGi(u.CapacityBytes),
+			percent,
+		)
+	}
+}
+
+// truncateMessage shortens a single-line message for table output
+func truncateMessage(msg string, max int) string {
+	msg = strings.Join(strings.Fields(msg), " ")
+	if len(msg) <= max {
+		return msg
+	}
+	return msg[:max-3] + "..."
+}
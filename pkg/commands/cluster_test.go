@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodsCheckCommand(t *testing.T) {
+	objects := append(kubetest.Cluster(), kubetest.Pod(kubetest.Namespace, "guard-worker-1", "gpu-1", kubetest.Waiting("ImagePullBackOff")))
+	kc := utils.NewKubernetesCheckerForClients(kubetest.NewClientset(objects...), kubetest.NewDynamicClient(nil))
+
+	rootCmd := &cobra.Command{SilenceUsage: true}
+	AddClusterCommands(rootCmd)
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"cluster", "pods", "check", "-n", kubetest.Namespace, "-o", "json"})
+	err := rootCmd.ExecuteContext(utils.WithKubeClients(context.Background(), utils.NewKubeClientsFor(kc)))
+	require.Error(t, err, "an image pull back-off should fail the check")
+
+	var report utils.PodHealthReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 6, report.Pods)
+	assert.Equal(t, 5, report.Healthy)
+	if assert.Len(t, report.Issues, 1) {
+		assert.Equal(t, utils.IssueImagePull, report.Issues[0].Category)
+		assert.Equal(t, "Pod/guard-worker-1/main", report.Issues[0].Object)
+	}
+}
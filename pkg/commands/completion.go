@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/output"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completionTimeout bounds cluster lookups made while completing, so a slow or unreachable
+// cluster does not hang the shell
+const completionTimeout = 5 * time.Second
+
+// RegisterCompletions adds dynamic completions to every command under root: namespaces from the
+// cluster for --namespace, stored registries for --target-registry and `registry login`/`logout`, and the
+// accepted values for --output, --sort-by, --checks, and --components, Dynamo service names for
+// `guard port-forward`, and saved backups for `backup restore`.
+func RegisterCompletions(root *cobra.Command) {
+	for _, cmd := range root.Commands() {
+		registerFlagCompletions(cmd)
+		RegisterCompletions(cmd)
+	}
+}
+
+func registerFlagCompletions(cmd *cobra.Command) {
+	cmd.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		var fn cobra.CompletionFunc
+		switch flag.Name {
+		case "namespace", "namespaces":
+			fn = completeNamespaces
+		case "target-registry":
+			fn = completeRegistries
+		case "output":
+			if cmd.Name() == "release-notes" {
+				fn = cobra.FixedCompletions(releaseNotesFormats, cobra.ShellCompDirectiveNoFileComp)
+				break
+			}
+			fn = completeOutputFormats(flag.Usage)
+		case "sort-by":
+			fn = cobra.FixedCompletions(utils.NodeSortKeys, cobra.ShellCompDirectiveNoFileComp)
+		case "checks":
+			fn = cobra.FixedCompletions(utils.AvailablePeriodicChecks, cobra.ShellCompDirectiveNoFileComp)
+		case "profile":
+			if cmd.Name() != "check" || !cmd.HasParent() || cmd.Parent().Name() != "cluster" {
+				return
+			}
+			fn = cobra.FixedCompletions(utils.CheckProfileNames(), cobra.ShellCompDirectiveNoFileComp)
+		case "compression":
+			fn = cobra.FixedCompletions(utils.Compressions, cobra.ShellCompDirectiveNoFileComp)
+		case "symlinks":
+			fn = cobra.FixedCompletions(utils.SymlinkPolicies, cobra.ShellCompDirectiveNoFileComp)
+		case "components":
+			fn = cobra.FixedCompletions(utils.BackupComponents, cobra.ShellCompDirectiveNoFileComp)
+		default:
+			return
+		}
+		_ = cmd.RegisterFlagCompletionFunc(flag.Name, fn)
+	})
+
+	if (cmd.Name() == "login" || cmd.Name() == "logout") && cmd.HasParent() && cmd.Parent().Name() == "registry" {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeRegistries(cmd, args, toComplete)
+		}
+	}
+	if cmd.Name() == "port-forward" && cmd.HasParent() && cmd.Parent().Name() == "guard" {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeDynamoServices(cmd, args, toComplete)
+		}
+	}
+	if cmd.Name() == "restore" && cmd.HasParent() && cmd.Parent().Name() == "backup" {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeBackups(cmd, args, toComplete)
+		}
+	}
+}
+
+// completeNamespaces lists namespaces from the current cluster
+func completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kc, err := kubeChecker(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	namespaces, err := kc.ListNamespaces(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return namespaces, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDynamoServices offers the well-known Dynamo services with their descriptions, plus the
+// other services in --namespace when it is set and the cluster is reachable
+func completeDynamoServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	known := map[string]bool{}
+	completions := make([]string, 0, len(utils.DynamoServices))
+	for _, s := range utils.DynamoServices {
+		known[s.Name] = true
+		completions = append(completions, s.Name+"\t"+s.Description)
+	}
+
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if namespace == "" {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	kc, err := kubeChecker(cmd)
+	if err != nil {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	services, err := kc.ListServices(ctx, namespace)
+	if err != nil {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	for _, name := range services {
+		if !known[name] {
+			completions = append(completions, name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBackups lists the backups in the backup directory with when they were taken
+func completeBackups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := backupDir(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	backups, err := utils.ListBackups(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(backups))
+	for _, b := range backups {
+		names = append(names, b.Name+"\t"+b.Namespace+", "+b.Created.Local().Format("2006-01-02 15:04"))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRegistries lists registries from the dynactl credential store
+func completeRegistries(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	stored, err := utils.ListRegistryCredentials()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	registries := make([]string, 0, len(stored))
+	for _, r := range stored {
+		registries = append(registries, r.Registry)
+	}
+	return registries, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOutputFormats offers every format for commands using a Renderer, plus xlsx for those
+// that can write a workbook, and table or json for the rest
+func completeOutputFormats(usage string) cobra.CompletionFunc {
+	formats := []string{output.FormatTable, output.FormatJSON}
+	switch usage {
+	case output.FlagUsage:
+		formats = output.Formats
+	case output.WorkbookFlagUsage:
+		formats = append(append([]string{}, output.Formats...), output.FormatXLSX)
+	}
+	return cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp)
+}
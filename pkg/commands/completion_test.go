@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCompletions(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddClusterCommands(rootCmd)
+	AddRegistryCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	complete := func(args ...string) string {
+		buf := new(bytes.Buffer)
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+		assert.NoError(t, rootCmd.Execute())
+		return buf.String()
+	}
+
+	out := complete("cluster", "node", "check", "-o", "")
+	for _, format := range []string{"table", "wide", "json", "yaml", "csv"} {
+		assert.Contains(t, out, format)
+	}
+	assert.NotContains(t, complete("cluster", "events", "-o", ""), "yaml", "table-or-json commands should only offer table and json")
+	assert.Contains(t, complete("cl", "nodes", "check", "--sort-by", ""), "gpu", "aliases should resolve and --sort-by should complete")
+	assert.Contains(t, complete("cluster", "check", "--checks", ""), "storage")
+	assert.Contains(t, complete("cluster", "check", "--profile", ""), "enterprise")
+	assert.NotContains(t, complete("cluster", "fit", "--profile", ""), "enterprise", "cluster fit takes a profile file, not a check profile")
+}
+
+func TestRegistryLoginCompletesStoredRegistries(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddRegistryCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	loginCmd := findSubcommand(findSubcommand(rootCmd, "registry"), "login")
+	assert.NotNil(t, loginCmd.ValidArgsFunction, "registry login should complete stored registries")
+}
+
+func TestToFlagCompletion(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddArtifactsCommands(rootCmd)
+	AddUpgradeCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	promoteCmd := findSubcommand(findSubcommand(rootCmd, "artifacts"), "promote")
+	_, ok := promoteCmd.GetFlagCompletionFunc("to")
+	assert.True(t, ok, "artifacts promote --to should complete stored registries")
+
+	precheckCmd := findSubcommand(findSubcommand(rootCmd, "upgrade"), "precheck")
+	_, ok = precheckCmd.GetFlagCompletionFunc("to")
+	assert.False(t, ok, "upgrade precheck --to takes a manifest file")
+}
+
+func TestGuardPortForwardCompletesDynamoServices(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddGuardCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "guard", "port-forward", ""})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "dynamoai-api\tPlatform API")
+	assert.Contains(t, buf.String(), "dynamoai-ui\tWeb UI")
+}
+
+func TestBackupCompletions(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddBackupCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	restoreCmd := findSubcommand(findSubcommand(rootCmd, "backup"), "restore")
+	assert.NotNil(t, restoreCmd.ValidArgsFunction, "backup restore should complete saved backups")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "backup", "create", "--components", ""})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "pvc-snapshots")
+}
+
+func TestReleaseNotesOutputCompletion(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddArtifactsCommands(rootCmd)
+	RegisterCompletions(rootCmd)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "artifacts", "release-notes", "-o", ""})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "markdown")
+	assert.NotContains(t, buf.String(), "table")
+}
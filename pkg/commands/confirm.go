@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addYesFlag registers the --yes flag every destructive command accepts
+func addYesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt (required when stdin is not a terminal)")
+}
+
+// confirm asks before a destructive action. --yes skips the prompt; without it, a stdin that is
+// not a terminal aborts instead of blocking or reading an answer meant for something else.
+// Anything other than y or yes declines.
+func confirm(cmd *cobra.Command, action string) error {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return nil
+	}
+	in := cmd.InOrStdin()
+	if !isInteractive(in) {
+		return fmt.Errorf("refusing to %s without confirmation: stdin is not a terminal (rerun with --yes)", action)
+	}
+
+	cmd.Printf("This will %s. Continue? [y/N]: ", action)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("aborted: did not %s", action)
+}
+
+// isInteractive reports whether a reader is a terminal. Readers other than files, such as a
+// command's input set in tests, are treated as interactive.
+func isInteractive(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
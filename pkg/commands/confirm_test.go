@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func newConfirmCmd(input string) *cobra.Command {
+	cmd := &cobra.Command{Use: "prune"}
+	addYesFlag(cmd)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(new(bytes.Buffer))
+	return cmd
+}
+
+func TestConfirm(t *testing.T) {
+	assert.NoError(t, confirm(newConfirmCmd("y\n"), "delete things"))
+	assert.NoError(t, confirm(newConfirmCmd("YES\n"), "delete things"))
+
+	err := confirm(newConfirmCmd("\n"), "delete things")
+	assert.ErrorContains(t, err, "aborted")
+	assert.Error(t, confirm(newConfirmCmd(""), "delete things"), "EOF should decline")
+
+	cmd := newConfirmCmd("")
+	assert.NoError(t, cmd.Flags().Set("yes", "true"))
+	assert.NoError(t, confirm(cmd, "delete things"), "--yes skips the prompt")
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	w.Close()
+	cmd = newConfirmCmd("")
+	cmd.SetIn(r)
+	assert.ErrorContains(t, confirm(cmd, "delete things"), "--yes", "non-terminal stdin must abort without --yes")
+}
+
+func TestRegistryLogout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DYNACTL_AUDIT_LOG", os.DevNull)
+
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddRegistryCommands(rootCmd)
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+
+	rootCmd.SetArgs([]string{"registry", "login", "logout.example", "-u", "robot", "-p", "secret"})
+	assert.NoError(t, rootCmd.Execute())
+
+	rootCmd.SetIn(strings.NewReader("n\n"))
+	rootCmd.SetArgs([]string{"registry", "logout", "logout.example"})
+	assert.ErrorContains(t, rootCmd.Execute(), "aborted")
+
+	rootCmd.SetArgs([]string{"registry", "logout", "logout.example", "--yes"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, out.String(), "Removed credentials for logout.example")
+
+	out.Reset()
+	rootCmd.SetArgs([]string{"registry", "list", "-o", "json"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.NotContains(t, out.String(), "logout.example")
+}
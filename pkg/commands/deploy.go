@@ -0,0 +1,405 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// AddDeployCommands registers deployment related commands with the root command.
+func AddDeployCommands(rootCmd *cobra.Command) {
+	deployCmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Install release resources into the cluster",
+		Long:  "Commands that install the resources a Dynamo AI release needs in the cluster, such as its license.",
+	}
+
+	licenseCmd := &cobra.Command{
+		Use:   "license",
+		Short: "Manage the Dynamo AI license",
+	}
+	licenseCmd.AddCommand(createLicenseApplyCmd())
+
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Pause background Dynamo AI workloads, e.g. during database maintenance",
+	}
+	maintenanceCmd.AddCommand(createMaintenanceOnCmd())
+	maintenanceCmd.AddCommand(createMaintenanceOffCmd())
+
+	deployCmd.AddCommand(licenseCmd)
+	deployCmd.AddCommand(maintenanceCmd)
+	deployCmd.AddCommand(createPrewarmCmd())
+	rootCmd.AddCommand(deployCmd)
+}
+
+func createLicenseApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Create or update the license Secret and verify the application accepts it",
+		Long: `Creates the license Secret in the namespace, or replaces the license in an existing one, from
+the license pulled with the release (or --file). It then port-forwards to the application and
+polls its license status endpoint until the license is accepted. --expiry records when the
+license expires on the Secret, so that cluster check can warn before it does.
+
+With --export-dir the Secret is written to the directory for a GitOps repository instead of
+applied, and nothing is verified. Encrypt it (e.g. with SOPS or Sealed Secrets) before committing.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			file, _ := cmd.Flags().GetString("file")
+			dir, _ := cmd.Flags().GetString("dir")
+			secretName, _ := cmd.Flags().GetString("secret-name")
+			key, _ := cmd.Flags().GetString("key")
+			skipVerify, _ := cmd.Flags().GetBool("skip-verify")
+			service, _ := cmd.Flags().GetString("verify-service")
+			port, _ := cmd.Flags().GetInt32("verify-port")
+			path, _ := cmd.Flags().GetString("verify-path")
+			timeout, _ := cmd.Flags().GetDuration("verify-timeout")
+			exportDir, _ := cmd.Flags().GetString("export-dir")
+			expiry, _ := cmd.Flags().GetString("expiry")
+
+			if expiry != "" {
+				if _, err := utils.ParseLicenseExpiry(expiry); err != nil {
+					return err
+				}
+			}
+			if file == "" {
+				found, err := utils.FindLicenseFile(dir)
+				if err != nil {
+					return err
+				}
+				file = found
+			}
+			license, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read license: %w", err)
+			}
+			if len(license) == 0 {
+				return fmt.Errorf("license file %s is empty", file)
+			}
+			if exportDir != "" {
+				files, err := utils.ExportResources(exportDir, utils.LicenseSecret(namespace, secretName, key, license, expiry))
+				if err != nil {
+					return err
+				}
+				printExported(cmd, exportDir, files)
+				cmd.Printf("! %s holds the license in plain text; encrypt it before committing\n", files[0])
+				return nil
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			created, err := kc.ApplyLicenseSecret(ctx, namespace, secretName, key, license, expiry)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			if created {
+				cmd.Printf("✓ Created secret %s/%s from %s\n", namespace, secretName, file)
+			} else {
+				cmd.Printf("✓ Updated secret %s/%s from %s\n", namespace, secretName, file)
+			}
+
+			if skipVerify {
+				cmd.Printf("! Skipped license verification\n")
+				return nil
+			}
+			cmd.Printf("Waiting up to %s for %s to accept the license...\n", timeout, service)
+			err = kc.VerifyLicense(ctx, namespace, utils.LicenseVerifyOptions{
+				Service:  service,
+				Port:     port,
+				Path:     path,
+				Timeout:  timeout,
+				Interval: 5 * time.Second,
+			})
+			if err != nil {
+				cmd.Printf("✗ License verification failed: %v\n", err)
+				return err
+			}
+			cmd.Printf("✓ %s accepted the license\n", service)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = cmd.MarkFlagRequired("namespace")
+	cmd.Flags().String("file", "", "License file to apply (defaults to the license pulled into --dir)")
+	cmd.Flags().String("dir", "./artifacts", "Artifacts directory the release was pulled into")
+	cmd.Flags().String("secret-name", utils.DefaultLicenseSecret, "Name of the license Secret")
+	cmd.Flags().String("key", utils.DefaultLicenseSecretKey, "Secret key holding the license")
+	cmd.Flags().String("expiry", "", "When the license expires (the manifest's license_expiry), recorded on the Secret for cluster check")
+	cmd.Flags().Bool("skip-verify", false, "Do not wait for the application to accept the license")
+	cmd.Flags().String("verify-service", utils.DefaultLicenseService, "Service reporting the license status")
+	cmd.Flags().Int32("verify-port", 0, "Service port of the status endpoint (defaults to the first port)")
+	cmd.Flags().String("verify-path", utils.DefaultLicenseStatusPath, "Path of the license status endpoint")
+	cmd.Flags().Duration("verify-timeout", 2*time.Minute, "How long to wait for the application to accept the license")
+	addExportDirFlag(cmd)
+
+	return cmd
+}
+
+func createPrewarmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prewarm",
+		Short: "Pre-pull a release's images onto nodes before the upgrade window",
+		Long: `Runs a DaemonSet on the nodes matching --node-selector that pulls the release's images, so the
+rollout does not wait on multi-gigabyte image pulls. Each image runs as a container that only
+sleeps (with a busybox copied in from --tools-image), so images without a shell work too and
+nothing in them runs.
+
+Images are pulled by their manifest references, or from --target-registry when the release was
+mirrored, applying the manifest's target overrides. --image keeps only the images whose reference
+contains one of the given strings, such as the model servers. Once every node has pulled every
+image, or --timeout passes, the DaemonSet is deleted. With --dry-run the DaemonSet is printed
+as YAML instead of applied.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			nodeSelector, _ := cmd.Flags().GetStringToString("node-selector")
+			targetRegistry, _ := cmd.Flags().GetString("target-registry")
+			filters, _ := cmd.Flags().GetStringSlice("image")
+			pullSecrets, _ := cmd.Flags().GetStringSlice("pull-secret")
+			toolsImage, _ := cmd.Flags().GetString("tools-image")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			manifest, err := utils.LoadManifest(file)
+			if err != nil {
+				return fmt.Errorf("failed to load manifest: %v", err)
+			}
+			opts := utils.ImagePrewarmOptions{
+				Namespace:    namespace,
+				Images:       utils.PrewarmImageRefs(manifest, targetRegistry, filters),
+				NodeSelector: nodeSelector,
+				PullSecrets:  pullSecrets,
+				ToolsImage:   toolsImage,
+				Timeout:      timeout,
+			}
+			if len(opts.Images) == 0 {
+				return fmt.Errorf("no images in %s match --image %v", file, filters)
+			}
+			if dryRun {
+				data, err := utils.RenderImagePrewarm(opts)
+				if err != nil {
+					return err
+				}
+				cmd.Print(string(data))
+				return nil
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			cmd.Printf("=== Pre-pulling %d images through DaemonSet in %s ===\n", len(opts.Images), namespace)
+			opts.OnNode = func(r utils.PrewarmNodeResult) {
+				cmd.Printf("✓ %s: pulled %d images\n", r.Node, r.Pulled)
+			}
+			results, err := kc.PrewarmImages(ctx, opts)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+
+			pending := 0
+			for _, r := range results {
+				if r.Done() {
+					continue
+				}
+				pending++
+				cmd.Printf("✗ %s: pulled %d of %d images\n", r.Node, r.Pulled, r.Total)
+				for _, image := range sortedImageKeys(r.Failed) {
+					cmd.Printf("    %s: %s\n", image, r.Failed[image])
+				}
+			}
+			if pending > 0 {
+				return fmt.Errorf("%d of %d nodes did not pull every image within %s", pending, len(results), timeout)
+			}
+			cmd.Printf("✓ %d nodes have the release's images; removed the prewarm DaemonSet\n", len(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("file", "", "Path to the manifest JSON file")
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace for the prewarm DaemonSet; it must hold the pull secrets (required)")
+	_ = cmd.MarkFlagRequired("namespace")
+	cmd.Flags().StringToString("node-selector", nil, "Only pre-pull onto nodes with these labels (e.g. nvidia.com/gpu.present=true)")
+	cmd.Flags().String("target-registry", "", "Registry the release was mirrored to (defaults to the manifest's references)")
+	cmd.Flags().StringSlice("image", nil, "Only pre-pull images whose reference contains this string (repeatable)")
+	cmd.Flags().StringSlice("pull-secret", nil, "imagePullSecret to attach to the pods (repeatable)")
+	cmd.Flags().String("tools-image", utils.DefaultLoaderImage, "Image providing /bin/busybox for the prewarm containers")
+	cmd.Flags().Duration("timeout", utils.DefaultPrewarmTimeout, "How long the nodes have to pull every image")
+	cmd.Flags().Bool("dry-run", false, "Print the DaemonSet as YAML instead of applying it")
+
+	return cmd
+}
+
+// sortedImageKeys orders a node's pull failures by image
+func sortedImageKeys(failed map[string]string) []string {
+	images := make([]string, 0, len(failed))
+	for image := range failed {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+func createMaintenanceOnCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "on",
+		Short: "Scale down the ingestion and worker components",
+		Long: `Scales the Deployments and StatefulSets matching --selector (by default the ingestion and worker
+components) to zero and waits for their pods to exit. Each workload's replica count is kept in the
+dynactl.dynamo.ai/maintenance-replicas annotation for maintenance off. With --banner, the message
+is posted to the platform API first so users see it before the workers stop. Running it again is
+safe: workloads already scaled down keep the count recorded the first time. --dry-run prints the
+banner post and scale-downs it would make as a plan, changing nothing.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := maintenanceOptions(cmd)
+			opts.Selector, _ = cmd.Flags().GetString("selector")
+			opts.Banner, _ = cmd.Flags().GetString("banner")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			if !dryRun {
+				if err := confirm(cmd, fmt.Sprintf("scale down the workloads in %s matching %q", opts.Namespace, opts.Selector)); err != nil {
+					return err
+				}
+			}
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			if dryRun {
+				plan, err := kc.PlanMaintenance(ctx, opts)
+				if err != nil {
+					cmd.Printf("✗ %v\n", err)
+					return err
+				}
+				return renderPlan(cmd, plan)
+			}
+
+			result, err := kc.EnableMaintenance(ctx, opts)
+			if result != nil {
+				if opts.Banner != "" {
+					cmd.Printf("✓ Posted maintenance banner %q\n", opts.Banner)
+				}
+				for _, w := range result.Workloads {
+					cmd.Printf("✓ Scaled %s/%s from %d to 0\n", w.Kind, w.Name, w.Replicas)
+				}
+				for _, name := range result.Skipped {
+					cmd.Printf("! Left %s alone: it already has no replicas\n", name)
+				}
+			}
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			cmd.Printf("✓ Maintenance mode on in %s since %s; turn it off with deploy maintenance off -n %s\n", opts.Namespace, result.Since.Format(time.RFC3339), opts.Namespace)
+			return nil
+		},
+	}
+
+	addMaintenanceFlags(cmd)
+	cmd.Flags().String("selector", utils.DefaultMaintenanceSelector, "Label selector of the Deployments and StatefulSets to scale down")
+	cmd.Flags().String("banner", "", "Maintenance banner message to show users")
+	addPlanFlags(cmd, "Print the plan of what maintenance on would change instead of changing it")
+	addYesFlag(cmd)
+	return cmd
+}
+
+func createMaintenanceOffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "off",
+		Short: "Restore the workloads maintenance mode scaled down",
+		Long: `Scales every workload carrying the dynactl.dynamo.ai/maintenance-replicas annotation back to its
+recorded replica count and waits for it to be ready, then clears the banner maintenance on posted.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := maintenanceOptions(cmd)
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			result, err := kc.DisableMaintenance(ctx, opts)
+			if result != nil {
+				for _, w := range result.Workloads {
+					cmd.Printf("✓ Scaled %s/%s back to %d\n", w.Kind, w.Name, w.Replicas)
+				}
+			}
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			if result.Banner != "" {
+				cmd.Println("✓ Cleared the maintenance banner")
+			}
+			if result.Since.IsZero() {
+				cmd.Printf("✓ Maintenance mode off in %s\n", opts.Namespace)
+			} else {
+				cmd.Printf("✓ Maintenance mode off in %s after %s\n", opts.Namespace, time.Since(result.Since).Round(time.Second))
+			}
+			return nil
+		},
+	}
+
+	addMaintenanceFlags(cmd)
+	return cmd
+}
+
+// addMaintenanceFlags registers the flags maintenance on and off share
+func addMaintenanceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = cmd.MarkFlagRequired("namespace")
+	cmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the workloads to scale; 0 does not wait")
+	cmd.Flags().String("banner-service", utils.DefaultLicenseService, "Service of the platform API the banner is posted to")
+	cmd.Flags().Int32("banner-port", 0, "Service port of the banner endpoint (defaults to the first port)")
+	cmd.Flags().String("banner-path", utils.DefaultBannerPath, "Path of the banner endpoint")
+}
+
+// maintenanceOptions reads the flags maintenance on and off share
+func maintenanceOptions(cmd *cobra.Command) utils.MaintenanceOptions {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	service, _ := cmd.Flags().GetString("banner-service")
+	port, _ := cmd.Flags().GetInt32("banner-port")
+	path, _ := cmd.Flags().GetString("banner-path")
+	return utils.MaintenanceOptions{
+		Namespace:     namespace,
+		Timeout:       timeout,
+		BannerService: service,
+		BannerPort:    port,
+		BannerPath:    path,
+	}
+}
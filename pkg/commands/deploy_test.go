@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceCommands(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddDeployCommands(rootCmd)
+
+	deployCmd := findSubcommand(rootCmd, "deploy")
+	maintenanceCmd := findSubcommand(deployCmd, "maintenance")
+	assert.NotNil(t, maintenanceCmd, "maintenance command should exist")
+
+	onCmd := findSubcommand(maintenanceCmd, "on")
+	assert.NotNil(t, onCmd, "on command should exist")
+	assert.Equal(t, audited, onCmd.Annotations)
+	assert.Equal(t, utils.DefaultMaintenanceSelector, onCmd.Flags().Lookup("selector").DefValue)
+	assert.NotNil(t, onCmd.Flags().Lookup("yes"), "yes flag should exist")
+
+	offCmd := findSubcommand(maintenanceCmd, "off")
+	assert.NotNil(t, offCmd, "off command should exist")
+	assert.Equal(t, audited, offCmd.Annotations)
+}
+
+func TestMaintenanceOnDeclined(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddDeployCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetIn(strings.NewReader("n\n"))
+	rootCmd.SetArgs([]string{"deploy", "maintenance", "on", "-n", "dynamo"})
+	err := rootCmd.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "aborted")
+	}
+	assert.Contains(t, buf.String(), `scale down the workloads in dynamo matching "app.kubernetes.io/component in (ingestion,worker)"`)
+}
+
+func TestPrewarmDryRun(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	data := `{"release_version": "3.2.0", "images": ["oci://registry.dynamo.ai/dynamoai/api:3.2.0", "oci://registry.dynamo.ai/dynamoai/vllm-server:3.2.0"]}`
+	if err := os.WriteFile(manifest, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{}
+	AddDeployCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"deploy", "prewarm", "--file", manifest, "-n", "dynamo", "--node-selector", "gpu=true",
+		"--target-registry", "harbor.example.com/dynamo", "--image", "vllm", "--dry-run"})
+	assert.NoError(t, rootCmd.Execute())
+
+	out := buf.String()
+	assert.Contains(t, out, "kind: DaemonSet")
+	assert.Contains(t, out, "image: harbor.example.com/dynamo/dynamoai/vllm-server:3.2.0")
+	assert.NotContains(t, out, "dynamoai/api")
+	assert.Contains(t, out, "gpu: \"true\"")
+
+	rootCmd = &cobra.Command{}
+	AddDeployCommands(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"deploy", "prewarm", "--file", manifest, "-n", "dynamo", "--image", "missing", "--dry-run"})
+	if err := rootCmd.Execute(); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no images")
+	}
+}
+
+func TestLicenseApplyExportDir(t *testing.T) {
+	license := filepath.Join(t.TempDir(), "customer.lic")
+	if err := os.WriteFile(license, []byte("license-data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exportDir := t.TempDir()
+
+	rootCmd := &cobra.Command{}
+	AddDeployCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"deploy", "license", "apply", "-n", "dynamo", "--file", license, "--export-dir", exportDir})
+	assert.NoError(t, rootCmd.Execute())
+
+	assert.FileExists(t, filepath.Join(exportDir, "dynamo-secret-"+utils.DefaultLicenseSecret+".yaml"))
+	assert.FileExists(t, filepath.Join(exportDir, "kustomization.yaml"))
+	assert.Contains(t, buf.String(), "encrypt it before committing")
+}
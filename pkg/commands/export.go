@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// addExportDirFlag registers --export-dir on commands that can write their resources for GitOps
+// instead of applying them
+func addExportDirFlag(cmd *cobra.Command) {
+	cmd.Flags().String("export-dir", "", "Write the resources as YAML with a kustomization.yaml into this directory instead of applying them")
+}
+
+// printExported lists the files an export wrote
+func printExported(cmd *cobra.Command, dir string, files []string) {
+	for _, f := range files {
+		cmd.Printf("✓ Wrote %s\n", filepath.Join(dir, f))
+	}
+	cmd.Printf("✓ Listed %d resource(s) in %s; commit the directory to your GitOps repository\n", len(files), filepath.Join(dir, "kustomization.yaml"))
+}
@@ -1,167 +1,1786 @@
 package commands
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/output"
 	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
 )
 
-// AddGuardCommands adds the guard commands to the root command
-func AddGuardCommands(rootCmd *cobra.Command) {
-	guardCmd := &cobra.Command{
-		Use:   "guard",
-		Short: "Guard-related utilities",
-		Long:  "Security and resource visibility utilities for Dynamo Guard.",
+// AddGuardCommands adds the guard commands to the root command
+func AddGuardCommands(rootCmd *cobra.Command) {
+	guardCmd := &cobra.Command{
+		Use:   "guard",
+		Short: "Guard-related utilities",
+		Long:  "Security and resource visibility utilities for Dynamo Guard.",
+	}
+
+	modelsCmd := &cobra.Command{
+		Use:     "models",
+		Aliases: []string{"model", "m"},
+		Short:   "Model-related utilities",
+		Long:    "Utilities for working with model serving components.",
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List model workloads and their resource requests/limits",
+		Long:    "Lists Deployments, StatefulSets, DaemonSets, and model-serving resources (KServe InferenceServices, RayServices) in the given namespace with CPU, memory, and GPU requests/limits per container. With --namespaces or --all-namespaces the listing is grouped per namespace and ends with a total across namespaces.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			namespaces, _ := cmd.Flags().GetStringSlice("namespaces")
+			allNamespaces, _ := cmd.Flags().GetBool("all-namespaces")
+			outputFormat, _ := cmd.Flags().GetString("output")
+			perPod, _ := cmd.Flags().GetBool("per-pod")
+			perContainer, _ := cmd.Flags().GetBool("containers")
+			opts := workloadListOptions{}
+			opts.useReady, _ = cmd.Flags().GetBool("use-ready")
+			pricing, err := resolvePricing(cmd)
+			if err != nil {
+				return err
+			}
+			opts.pricing = pricing
+
+			if perPod && perContainer {
+				return fmt.Errorf("--per-pod and --containers cannot be used together")
+			}
+			outputFile, err := workbookOutputFile(cmd, outputFormat)
+			if err != nil {
+				return err
+			}
+			if outputFile != "" && (perPod || perContainer) {
+				return fmt.Errorf("--output xlsx lists workloads; it cannot be combined with --per-pod or --containers")
+			}
+			scopes := 0
+			for _, set := range []bool{namespace != "", len(namespaces) > 0, allNamespaces} {
+				if set {
+					scopes++
+				}
+			}
+			if scopes != 1 {
+				return fmt.Errorf("exactly one of --namespace, --namespaces, or --all-namespaces is required")
+			}
+			multi := namespace == ""
+			if multi && (perPod || perContainer) {
+				return fmt.Errorf("--per-pod and --containers list a single --namespace")
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			selector, include, exclude, err := resolveModelFilters(cmd)
+			if err != nil {
+				return err
+			}
+
+			kindValues, _ := cmd.Flags().GetStringSlice("kinds")
+			kinds, err := utils.ParseWorkloadKinds(kindValues)
+			if err != nil {
+				return err
+			}
+
+			if outputFile == "" {
+				if _, err := output.NewRenderer(outputFormat); err != nil {
+					return err
+				}
+			}
+
+			if multi {
+				if allNamespaces {
+					if namespaces, err = kc.ListNamespaces(cmd.Context()); err != nil {
+						cmd.Printf("✗ Failed to list namespaces: %v\n", err)
+						return err
+					}
+				}
+				var groups []namespaceWorkloads
+				for _, ns := range namespaces {
+					summaries, err := kc.ListWorkloadResourceSummaries(cmd.Context(), ns, selector, kinds)
+					if err != nil {
+						// With --all-namespaces, namespaces the caller cannot read are skipped
+						if allNamespaces {
+							utils.LogWarning("Skipping namespace %s: %v", ns, err)
+							continue
+						}
+						cmd.Printf("✗ Failed to list workloads in %s: %v\n", ns, err)
+						return err
+					}
+					filtered := utils.FilterDeploymentSummaries(summaries, include, exclude)
+					if len(filtered) == 0 && allNamespaces {
+						continue
+					}
+					groups = append(groups, namespaceWorkloads{Namespace: ns, Workloads: filtered})
+				}
+				if len(groups) == 0 && output.IsTabular(outputFormat) {
+					cmd.Println("No deployments found in any namespace")
+					return nil
+				}
+				if outputFile != "" {
+					return writeWorkloadWorkbook(cmd, kc, outputFile, groups, opts)
+				}
+				return renderMultiNamespaceWorkloads(cmd, groups, outputFormat, opts)
+			}
+
+			summaries, err := kc.ListWorkloadResourceSummaries(cmd.Context(), namespace, selector, kinds)
+			if err != nil {
+				cmd.Printf("✗ Failed to list workloads: %v\n", err)
+				return err
+			}
+
+			filtered := utils.FilterDeploymentSummaries(summaries, include, exclude)
+
+			if len(filtered) == 0 && output.IsTabular(outputFormat) {
+				cmd.Printf("No deployments found in namespace %s\n", namespace)
+				return nil
+			}
+
+			if outputFile != "" {
+				return writeWorkloadWorkbook(cmd, kc, outputFile, []namespaceWorkloads{{Namespace: namespace, Workloads: filtered}}, opts)
+			}
+
+			if perPod {
+				pods, err := kc.ListDeploymentPodSummaries(cmd.Context(), namespace, filtered)
+				if err != nil {
+					cmd.Printf("✗ Failed to list pods: %v\n", err)
+					return err
+				}
+				return renderPodSummaries(cmd, namespace, pods, outputFormat)
+			}
+
+			if perContainer {
+				return renderContainerSummaries(cmd, namespace, filtered, outputFormat)
+			}
+
+			return renderWorkloadSummaries(cmd, namespace, filtered, outputFormat, opts)
+		},
+	}
+
+	listCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	listCmd.Flags().StringSlice("namespaces", nil, "List several namespaces, grouped per namespace with a grand total (comma-separated)")
+	listCmd.Flags().BoolP("all-namespaces", "A", false, "List every namespace that has matching workloads, grouped per namespace with a grand total")
+	addWorkbookFlags(listCmd)
+	listCmd.Flags().Bool("per-pod", false, "Show pod-level status (node, instance type, phase, restarts, age)")
+	listCmd.Flags().Bool("containers", false, "Show per-container resource requests/limits")
+	listCmd.Flags().Bool("use-ready", false, "Compute totals from ready replicas instead of desired replicas")
+	addCostFlags(listCmd)
+	listCmd.Flags().StringP("selector", "l", "", "Label selector for model deployments (e.g. app.kubernetes.io/component=model-server)")
+	listCmd.Flags().StringSlice("include", nil, "Only list these deployments (comma-separated, glob patterns allowed)")
+	listCmd.Flags().StringSlice("exclude", nil, "Skip these deployments (comma-separated, glob patterns allowed)")
+	listCmd.Flags().StringSlice("kinds", nil, "Workload kinds to include: deployment, statefulset, daemonset, inferenceservice, rayservice (default all)")
+
+	logsCmd := &cobra.Command{
+		Use:   "logs <deployment>",
+		Short: "Tail logs from all pods of a model deployment",
+		Long:  "Streams logs from every pod of the given deployment concurrently, prefixing each line with the pod name.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			container, _ := cmd.Flags().GetString("container")
+			since, _ := cmd.Flags().GetDuration("since")
+			tail, _ := cmd.Flags().GetInt64("tail")
+			follow, _ := cmd.Flags().GetBool("follow")
+			grep, _ := cmd.Flags().GetString("grep")
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			return kc.TailDeploymentLogs(ctx, namespace, args[0], utils.LogTailOptions{
+				Container: container,
+				Since:     since,
+				TailLines: tail,
+				Follow:    follow,
+				Grep:      grep,
+			}, cmd.OutOrStdout())
+		},
+	}
+
+	logsCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = logsCmd.MarkFlagRequired("namespace")
+	logsCmd.Flags().StringP("container", "c", "", "Only show logs from this container")
+	logsCmd.Flags().Duration("since", 0, "Only return logs newer than a relative duration like 5s, 2m, or 3h")
+	logsCmd.Flags().Int64("tail", -1, "Lines of recent log to show per container (-1 shows all)")
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep streaming new log lines")
+	logsCmd.Flags().String("grep", "", "Only show lines matching this regular expression")
+
+	benchmarkCmd := &cobra.Command{
+		Use:   "benchmark <service>",
+		Short: "Run a latency smoke test against a model service",
+		Long:  "Port-forwards to a ready pod behind the given service and sends a small configurable load to its inference endpoint, reporting p50/p95 latency and error rate. Use it to validate sizing right after install.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			port, _ := cmd.Flags().GetInt32("port")
+			output, _ := cmd.Flags().GetString("output")
+
+			req, path, err := requestFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			pf, err := kc.PortForwardService(ctx, namespace, args[0], port, 0)
+			if err != nil {
+				cmd.Printf("✗ Failed to port-forward to %s: %v\n", args[0], err)
+				return err
+			}
+			defer pf.Close()
+
+			req.URL = fmt.Sprintf("http://127.0.0.1:%d%s", pf.LocalPort, path)
+			utils.LogInfo("Benchmarking %s via pod %s (%d requests, concurrency %d)", args[0], pf.Pod, req.Requests, req.Concurrency)
+
+			result, err := utils.RunBenchmark(ctx, req)
+			if err != nil {
+				cmd.Printf("✗ Benchmark failed: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderBenchmarkResult(cmd, args[0], path, result)
+			}
+
+			if result.Errors == result.Requests {
+				return fmt.Errorf("all %d requests failed", result.Requests)
+			}
+			return nil
+		},
+	}
+
+	benchmarkCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = benchmarkCmd.MarkFlagRequired("namespace")
+	addRequestFlags(benchmarkCmd, 50, 5, "Total number of requests to send")
+	benchmarkCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	planCmd := &cobra.Command{
+		Use:   "plan --add-model <profile.yaml>",
+		Short: "Check whether a proposed model fits on the cluster",
+		Long:  "Evaluates a proposed model's per-replica resource profile against current node allocatable and requests, reporting which node pools it would land on and what additional capacity is needed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profilePath, _ := cmd.Flags().GetString("add-model")
+			output, _ := cmd.Flags().GetString("output")
+
+			profile, err := utils.LoadModelProfile(profilePath)
+			if err != nil {
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			plan, err := kc.PlanModelPlacement(cmd.Context(), *profile)
+			if err != nil {
+				cmd.Printf("✗ Failed to evaluate capacity: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(plan, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+				return nil
+			}
+
+			renderPlacementPlan(cmd, profile, plan)
+			return nil
+		},
+	}
+
+	planCmd.Flags().String("add-model", "", "Path to the proposed model's resource profile (YAML or JSON)")
+	_ = planCmd.MarkFlagRequired("add-model")
+	planCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	auditCmd := &cobra.Command{
+		Use:   "audit --namespace <namespace>",
+		Short: "Audit model workloads against best practices",
+		Long:  "Checks Deployments and StatefulSets for missing probes, absent resource limits, missing PodDisruptionBudgets, single-replica services, and GPU workloads without placement constraints.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			selector, _ := cmd.Flags().GetString("selector")
+			output, _ := cmd.Flags().GetString("output")
+			failOn, _ := cmd.Flags().GetString("fail-on")
+
+			if failOn != "none" && utils.SeverityRank(failOn) == 0 {
+				return fmt.Errorf("--fail-on must be one of: high, medium, low, none")
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			findings, err := kc.AuditWorkloads(cmd.Context(), namespace, selector)
+			if err != nil {
+				cmd.Printf("✗ Failed to audit workloads: %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				if findings == nil {
+					findings = []utils.AuditFinding{}
+				}
+				data, err := json.MarshalIndent(findings, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderAuditFindings(cmd, namespace, findings)
+			}
+
+			if failOn == "none" {
+				return nil
+			}
+			threshold := utils.SeverityRank(failOn)
+			for _, f := range findings {
+				if utils.SeverityRank(f.Severity) >= threshold {
+					return fmt.Errorf("audit found issues at or above %s severity", failOn)
+				}
+			}
+			return nil
+		},
+	}
+
+	auditCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = auditCmd.MarkFlagRequired("namespace")
+	auditCmd.Flags().StringP("selector", "l", "", "Label selector for workloads to audit")
+	auditCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	auditCmd.Flags().String("fail-on", utils.SeverityHigh, "Exit non-zero when findings reach this severity: high, medium, low, or none")
+
+	autoscalingCmd := &cobra.Command{
+		Use:     "autoscaling",
+		Aliases: []string{"hpa"},
+		Short:   "Inspect autoscaling of Guard model workloads",
+	}
+
+	autoscalingListCmd := &cobra.Command{
+		Use:     "list --namespace <namespace>",
+		Aliases: []string{"ls"},
+		Short:   "List HPAs and KEDA ScaledObjects attached to model workloads",
+		Long:    "Shows HorizontalPodAutoscalers and KEDA ScaledObjects scaling Guard workloads with their replica bounds, current and target metrics, and recent scaling events. Warns when container requests or limits make utilization targets meaningless.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			output, _ := cmd.Flags().GetString("output")
+
+			_, include, exclude, err := resolveModelFilters(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to load config: %v\n", err)
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			autoscalers, err := kc.ListAutoscalers(cmd.Context(), namespace)
+			if err != nil {
+				cmd.Printf("✗ Failed to list autoscalers: %v\n", err)
+				return err
+			}
+			autoscalers = utils.FilterAutoscalers(autoscalers, include, exclude)
+
+			if output == "json" {
+				data, err := json.MarshalIndent(autoscalers, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+				return nil
+			}
+
+			renderAutoscalers(cmd, namespace, autoscalers)
+			return nil
+		},
+	}
+
+	autoscalingListCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = autoscalingListCmd.MarkFlagRequired("namespace")
+	autoscalingListCmd.Flags().StringSlice("include", nil, "Only show autoscalers targeting these workloads (glob patterns)")
+	autoscalingListCmd.Flags().StringSlice("exclude", nil, "Hide autoscalers targeting these workloads (glob patterns)")
+	autoscalingListCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	modelsCmd.AddCommand(listCmd)
+	modelsCmd.AddCommand(logsCmd)
+	modelsCmd.AddCommand(benchmarkCmd)
+	modelsCmd.AddCommand(createGuardModelsStageCmd())
+	modelsCmd.AddCommand(createGuardModelsWarmupCmd())
+	autoscalingCmd.AddCommand(autoscalingListCmd)
+	guardCmd.AddCommand(planCmd)
+	guardCmd.AddCommand(auditCmd)
+	guardCmd.AddCommand(autoscalingCmd)
+	guardCmd.AddCommand(modelsCmd)
+	guardCmd.AddCommand(createGuardConfigCmd())
+	guardCmd.AddCommand(createGuardDepsCmd())
+	guardCmd.AddCommand(createGuardPortForwardCmd())
+	rootCmd.AddCommand(guardCmd)
+}
+
+// createGuardConfigCmd builds `guard config show`
+func createGuardConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect Guard configuration",
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show --namespace <namespace>",
+		Short: "Show Guard model routing, rate limits, and policy settings",
+		Long:  "Reads the ConfigMaps and custom resources that configure Guard and lists their settings grouped into routing, rate limits, and policies. YAML and JSON values are expanded into one setting per key. With --diff only settings that differ from the release defaults are shown.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			outputFormat, _ := cmd.Flags().GetString("output")
+			configMaps, _ := cmd.Flags().GetStringSlice("configmaps")
+			selector, _ := cmd.Flags().GetString("selector")
+			resourceValues, _ := cmd.Flags().GetStringSlice("resource")
+			diff, _ := cmd.Flags().GetBool("diff")
+			defaultsPath, _ := cmd.Flags().GetString("defaults")
+			snapshot, _ := cmd.Flags().GetBool("snapshot")
+
+			if diff && snapshot {
+				return fmt.Errorf("--diff and --snapshot cannot be used together")
+			}
+			if _, err := output.NewRenderer(outputFormat); err != nil {
+				return err
+			}
+			opts := utils.GuardConfigOptions{ConfigMaps: configMaps, Selector: selector}
+			for _, value := range resourceValues {
+				gvr, err := utils.ParseGuardConfigResource(value)
+				if err != nil {
+					return err
+				}
+				opts.Resources = append(opts.Resources, gvr)
+			}
+
+			var defaults utils.GuardConfigSnapshot
+			if diff {
+				if defaultsPath == "" {
+					cfg, err := utils.LoadConfig()
+					if err != nil {
+						return err
+					}
+					defaultsPath = cfg.Guard.ConfigDefaults
+				}
+				if defaultsPath == "" {
+					return fmt.Errorf("--diff needs the release defaults: pass --defaults <file>, written by `guard config show --snapshot` on a fresh install")
+				}
+				var err error
+				if defaults, err = utils.LoadGuardConfigSnapshot(defaultsPath); err != nil {
+					return err
+				}
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+			entries, err := kc.GetGuardConfig(cmd.Context(), namespace, opts)
+			if err != nil {
+				cmd.Printf("✗ Failed to read Guard configuration: %v\n", err)
+				return err
+			}
+
+			if snapshot {
+				data, err := yaml.Marshal(utils.GuardConfigSnapshotOf(entries))
+				if err != nil {
+					return fmt.Errorf("failed to marshal YAML: %w", err)
+				}
+				cmd.Print(string(data))
+				return nil
+			}
+			if diff {
+				return renderGuardConfigDiff(cmd, namespace, utils.DiffGuardConfig(entries, defaults), outputFormat)
+			}
+			return renderGuardConfig(cmd, namespace, entries, outputFormat)
+		},
+	}
+
+	showCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = showCmd.MarkFlagRequired("namespace")
+	showCmd.Flags().StringSlice("configmaps", nil, "ConfigMaps to read (comma-separated, glob patterns allowed; default "+strings.Join(utils.DefaultGuardConfigMaps, ",")+")")
+	showCmd.Flags().StringP("selector", "l", "", "Only read ConfigMaps and custom resources matching this label selector")
+	showCmd.Flags().StringSlice("resource", nil, "Also read the spec of these custom resources, as resource.group/version (repeatable)")
+	showCmd.Flags().Bool("diff", false, "Only show settings that differ from the release defaults")
+	showCmd.Flags().String("defaults", "", "Release defaults for --diff, as written by --snapshot (default guard.config_defaults from the config file)")
+	showCmd.Flags().Bool("snapshot", false, "Print the settings as YAML in the --defaults format")
+	showCmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+
+	configCmd.AddCommand(showCmd)
+	return configCmd
+}
+
+// guardConfigSectionTitles head each section of the table output
+var guardConfigSectionTitles = map[string]string{
+	utils.GuardConfigRouting:    "MODEL ROUTING",
+	utils.GuardConfigRateLimits: "RATE LIMITS",
+	utils.GuardConfigPolicies:   "POLICIES",
+	utils.GuardConfigOther:      "OTHER SETTINGS",
+}
+
+// renderGuardConfig prints Guard settings. Table output has one table per section; other formats
+// list every setting with its section.
+func renderGuardConfig(cmd *cobra.Command, namespace string, entries []utils.GuardConfigEntry, outputFormat string) error {
+	if entries == nil {
+		entries = []utils.GuardConfigEntry{}
+	}
+	if !output.IsTabular(outputFormat) {
+		table := &output.Table{
+			Columns: []output.Column{{Header: "SECTION", CSV: "section"}, {Header: "KEY", CSV: "key"}, {Header: "VALUE", CSV: "value"}, {Header: "SOURCE", CSV: "source"}},
+			Data:    entries,
+		}
+		for _, e := range entries {
+			table.AddRow(e.Section, e.Key, e.Value, e.Source)
+		}
+		return output.Render(cmd.OutOrStdout(), outputFormat, table)
+	}
+
+	cmd.Printf("Namespace: %s\n", namespace)
+	if len(entries) == 0 {
+		cmd.Println("No Guard configuration found; pass --configmaps or --selector to name the ConfigMaps to read")
+		return nil
+	}
+	cmd.Printf("Sources: %s\n", strings.Join(guardConfigSources(entries), ", "))
+	for _, section := range utils.GuardConfigSections {
+		table := &output.Table{Columns: []output.Column{{Header: "KEY"}, {Header: "VALUE", MaxWidth: 60}, {Header: "SOURCE"}}}
+		for _, e := range entries {
+			if e.Section == section {
+				table.AddRow(e.Key, e.Value, e.Source)
+			}
+		}
+		if len(table.Rows) == 0 {
+			continue
+		}
+		cmd.Printf("\n%s\n", guardConfigSectionTitles[section])
+		if err := output.Render(cmd.OutOrStdout(), outputFormat, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderGuardConfigDiff prints the settings that differ from the release defaults
+func renderGuardConfigDiff(cmd *cobra.Command, namespace string, diffs []utils.GuardConfigDiff, outputFormat string) error {
+	if diffs == nil {
+		diffs = []utils.GuardConfigDiff{}
+	}
+	if !output.IsTabular(outputFormat) {
+		table := &output.Table{
+			Columns: []output.Column{{Header: "SECTION", CSV: "section"}, {Header: "STATUS", CSV: "status"}, {Header: "KEY", CSV: "key"},
+				{Header: "VALUE", CSV: "value"}, {Header: "DEFAULT", CSV: "default"}, {Header: "SOURCE", CSV: "source"}},
+			Data: diffs,
+		}
+		for _, d := range diffs {
+			table.AddRow(d.Section, d.Status, d.Key, d.Value, d.Default, d.Source)
+		}
+		return output.Render(cmd.OutOrStdout(), outputFormat, table)
+	}
+
+	cmd.Printf("Namespace: %s\n", namespace)
+	if len(diffs) == 0 {
+		cmd.Println("✓ Guard configuration matches the release defaults")
+		return nil
+	}
+	counts := map[string]int{}
+	for _, section := range utils.GuardConfigSections {
+		table := &output.Table{Columns: []output.Column{{Header: "STATUS"}, {Header: "KEY"}, {Header: "VALUE", MaxWidth: 40}, {Header: "DEFAULT", MaxWidth: 40}, {Header: "SOURCE"}}}
+		for _, d := range diffs {
+			if d.Section == section {
+				table.AddRow(d.Status, d.Key, d.Value, d.Default, d.Source)
+				counts[d.Status]++
+			}
+		}
+		if len(table.Rows) == 0 {
+			continue
+		}
+		cmd.Printf("\n%s\n", guardConfigSectionTitles[section])
+		if err := output.Render(cmd.OutOrStdout(), outputFormat, table); err != nil {
+			return err
+		}
+	}
+	cmd.Printf("\n! %d setting(s) differ from the release defaults (%d changed, %d added, %d missing)\n",
+		len(diffs), counts[utils.GuardConfigChanged], counts[utils.GuardConfigAdded], counts[utils.GuardConfigMissing])
+	return nil
+}
+
+// guardConfigSources lists the objects settings were read from
+func guardConfigSources(entries []utils.GuardConfigEntry) []string {
+	seen := map[string]bool{}
+	var sources []string
+	for _, e := range entries {
+		if !seen[e.Source] {
+			seen[e.Source] = true
+			sources = append(sources, e.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// createGuardDepsCmd builds `guard deps check`
+func createGuardDepsCmd() *cobra.Command {
+	depsCmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Check Guard's Secrets and external connections",
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   "check --namespace <namespace> --config <guard-deps.yaml>",
+		Short: "Verify Guard's Secrets and the connections made with them",
+		Long: "Checks that the Secrets Guard needs (database credentials, object store keys, API tokens) exist and their keys are non-empty, " +
+			"then starts a short-lived probe pod that connects to each dependency using those Secrets. Results are grouped by integration " +
+			"so a broken one can be pinpointed after credentials are rotated. Connections whose Secret is missing or empty are not probed.",
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			configPath, _ := cmd.Flags().GetString("config")
+			image, _ := cmd.Flags().GetString("image")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			outputFormat, _ := cmd.Flags().GetString("output")
+
+			if _, err := output.NewRenderer(outputFormat); err != nil {
+				return err
+			}
+			if configPath == "" {
+				cfg, err := utils.LoadConfig()
+				if err != nil {
+					return err
+				}
+				configPath = cfg.Guard.DepsConfig
+			}
+			if configPath == "" {
+				return fmt.Errorf("pass --config <guard-deps.yaml> or set guard.deps_config in the config file")
+			}
+			cfg, err := utils.LoadGuardDepsConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if image != "" {
+				cfg.Image = image
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+			statuses, err := kc.CheckGuardDependencies(cmd.Context(), namespace, cfg, timeout)
+			if err != nil {
+				cmd.Printf("✗ Dependency probe failed: %v\n", err)
+				return err
+			}
+			return renderGuardDeps(cmd, namespace, statuses, outputFormat)
+		},
+	}
+
+	checkCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace Guard runs in")
+	_ = checkCmd.MarkFlagRequired("namespace")
+	checkCmd.Flags().String("config", "", "Path to the Guard dependency config (default guard.deps_config from the config file)")
+	checkCmd.Flags().String("image", "", "Probe pod image (overrides the config; default "+utils.DefaultProbeImage+")")
+	checkCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the probe pod to finish")
+	checkCmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+
+	depsCmd.AddCommand(checkCmd)
+	return depsCmd
+}
+
+// renderGuardDeps prints one row per Secret key and connection, then a line per integration, and
+// fails when any integration is unhealthy
+func renderGuardDeps(cmd *cobra.Command, namespace string, statuses []utils.GuardIntegrationStatus, outputFormat string) error {
+	table := &output.Table{
+		Columns: []output.Column{{Header: "INTEGRATION", CSV: "integration"}, {Header: "CHECK", CSV: "check"}, {Header: "NAME", CSV: "name"},
+			{Header: "TARGET", CSV: "target", Wide: true}, {Header: "STATUS", CSV: "status"}, {Header: "MESSAGE", CSV: "message", MaxWidth: 80}},
+		Data: statuses,
+	}
+	var failing []string
+	for _, st := range statuses {
+		for _, s := range st.Secrets {
+			name := s.Secret
+			if s.Key != "" {
+				name += "/" + s.Key
+			}
+			table.AddRow(st.Integration, "secret", name, "", s.Status, s.Message)
+		}
+		for _, c := range st.Connections {
+			table.AddRow(st.Integration, "connection", c.Name, c.Target, c.Status, c.Message)
+		}
+		if !st.Healthy {
+			failing = append(failing, st.Integration)
+		}
+	}
+
+	if !output.IsTabular(outputFormat) {
+		if err := output.Render(cmd.OutOrStdout(), outputFormat, table); err != nil {
+			return err
+		}
+	} else {
+		cmd.Printf("Namespace: %s\n\n", namespace)
+		if err := output.Render(cmd.OutOrStdout(), outputFormat, table); err != nil {
+			return err
+		}
+		cmd.Println()
+		for _, st := range statuses {
+			if st.Healthy {
+				cmd.Printf("✓ %s\n", st.Integration)
+				continue
+			}
+			cmd.Printf("✗ %s: %s\n", st.Integration, guardDepsFailure(st))
+		}
+	}
+
+	if len(failing) > 0 {
+		return fmt.Errorf("%d of %d integrations failing: %s", len(failing), len(statuses), strings.Join(failing, ", "))
+	}
+	return nil
+}
+
+// guardDepsFailure describes the first failed check of an integration
+func guardDepsFailure(st utils.GuardIntegrationStatus) string {
+	for _, s := range st.Secrets {
+		if s.Status == utils.SecretOK {
+			continue
+		}
+		if s.Key == "" {
+			return fmt.Sprintf("Secret %s is %s", s.Secret, s.Status)
+		}
+		return fmt.Sprintf("Secret %s key %s is %s", s.Secret, s.Key, s.Status)
+	}
+	for _, c := range st.Connections {
+		if c.Status != utils.DependencyOK {
+			return fmt.Sprintf("%s %s: %s", c.Name, c.Status, truncateMessage(c.Message, 100))
+		}
+	}
+	return "unhealthy"
+}
+
+// warmupRetryInterval is how often `guard models warmup` retries while the service has no ready pods
+const warmupRetryInterval = 5 * time.Second
+
+// createGuardModelsWarmupCmd builds `guard models warmup`
+func createGuardModelsWarmupCmd() *cobra.Command {
+	warmupCmd := &cobra.Command{
+		Use:   "warmup <service> --namespace <namespace> --threshold <latency>",
+		Short: "Warm up a model service and wait until its latency is stable",
+		Long: "Port-forwards to a ready pod behind the service and sends rounds of warm-up requests until the p95 latency of " +
+			"--stable-rounds consecutive rounds is at or under --threshold with an acceptable error rate. Exits non-zero if that does " +
+			"not happen within --max-wait, so it can gate traffic cutover after a deploy, upgrade, or `guard models stage`.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			port, _ := cmd.Flags().GetInt32("port")
+			threshold, _ := cmd.Flags().GetDuration("threshold")
+			stableRounds, _ := cmd.Flags().GetInt("stable-rounds")
+			maxErrorRate, _ := cmd.Flags().GetFloat64("max-error-rate")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			maxWait, _ := cmd.Flags().GetDuration("max-wait")
+			output, _ := cmd.Flags().GetString("output")
+
+			if threshold <= 0 {
+				return fmt.Errorf("--threshold must be greater than zero")
+			}
+			if stableRounds <= 0 {
+				return fmt.Errorf("--stable-rounds must be greater than zero")
+			}
+			if maxErrorRate < 0 || maxErrorRate > 1 {
+				return fmt.Errorf("--max-error-rate must be between 0 and 1")
+			}
+			req, path, err := requestFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			// Right after a deploy the service may have no ready pods yet
+			start := time.Now()
+			var pf *utils.PortForward
+			for {
+				pf, err = kc.PortForwardService(ctx, namespace, args[0], port, 0)
+				if err == nil {
+					break
+				}
+				if ctx.Err() != nil || time.Since(start)+warmupRetryInterval > maxWait {
+					cmd.Printf("✗ Failed to port-forward to %s: %v\n", args[0], err)
+					return err
+				}
+				utils.LogInfo("Waiting for %s: %v", args[0], err)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(warmupRetryInterval):
+				}
+			}
+			defer pf.Close()
+
+			req.URL = fmt.Sprintf("http://127.0.0.1:%d%s", pf.LocalPort, path)
+			opts := utils.WarmupOptions{
+				Request:      req,
+				Threshold:    threshold,
+				StableRounds: stableRounds,
+				MaxErrorRate: maxErrorRate,
+				Interval:     interval,
+				MaxWait:      max(maxWait-time.Since(start), time.Millisecond),
+			}
+			if output != "json" {
+				cmd.Printf("Warming up %s%s via pod %s: %d request(s) per round, p95 at or under %s for %d consecutive round(s)\n\n",
+					args[0], path, pf.Pod, req.Requests, threshold, stableRounds)
+				cmd.Printf("%-6s %-10s %-10s %-8s %s\n", "ROUND", "P50", "P95", "ERRORS", "STABLE")
+				opts.OnRound = func(r utils.WarmupRound) {
+					stable := "no"
+					if r.Stable {
+						stable = "yes"
+					}
+					cmd.Printf("%-6d %-10s %-10s %-8d %s\n", r.Round, r.P50.Round(time.Millisecond), r.P95.Round(time.Millisecond), r.Errors, stable)
+				}
+			}
+
+			result, err := utils.RunWarmup(ctx, opts)
+			if err != nil {
+				cmd.Printf("✗ Warm-up failed: %v\n", err)
+				return err
+			}
+			result.Duration = time.Since(start)
+
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderWarmupResult(cmd, args[0], result)
+			}
+			if !result.Ready {
+				return fmt.Errorf("%s is not ready within %s: %s", args[0], maxWait, result.Reason)
+			}
+			return nil
+		},
+	}
+
+	warmupCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = warmupCmd.MarkFlagRequired("namespace")
+	addRequestFlags(warmupCmd, 10, 2, "Number of requests to send per round")
+	warmupCmd.Flags().Duration("threshold", 0, "p95 latency a round must stay at or under, e.g. 500ms")
+	_ = warmupCmd.MarkFlagRequired("threshold")
+	warmupCmd.Flags().Int("stable-rounds", 3, "Consecutive rounds under the threshold required before the service is ready")
+	warmupCmd.Flags().Float64("max-error-rate", 0, "Highest error rate (0-1) a round may have and still count as stable")
+	warmupCmd.Flags().Duration("interval", 2*time.Second, "Pause between rounds")
+	warmupCmd.Flags().Duration("max-wait", 10*time.Minute, "Give up if the service is not ready within this long, including waiting for ready pods")
+	warmupCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	return warmupCmd
+}
+
+// renderWarmupResult prints whether the service became ready
+func renderWarmupResult(cmd *cobra.Command, service string, r *utils.WarmupResult) {
+	cmd.Println()
+	if !r.Ready {
+		cmd.Printf("✗ %s is not ready after %d round(s) in %s: %s\n", service, len(r.Rounds), r.Duration.Round(time.Second), r.Reason)
+		return
 	}
+	last := r.Rounds[len(r.Rounds)-1]
+	cmd.Printf("✓ %s is ready after %d round(s) in %s (last p95 %s, threshold %s)\n",
+		service, len(r.Rounds), r.Duration.Round(time.Second), last.P95.Round(time.Millisecond), r.Threshold)
+}
 
-	modelsCmd := &cobra.Command{
-		Use:   "models",
-		Short: "Model-related utilities",
-		Long:  "Utilities for working with model serving components.",
+// addRequestFlags registers the flags describing requests sent to a model service through a
+// port-forward, shared by `guard models benchmark` and `guard models warmup`
+func addRequestFlags(cmd *cobra.Command, requests, concurrency int, requestsUsage string) {
+	cmd.Flags().Int32("port", 0, "Service port to target (default: the service's first port)")
+	cmd.Flags().String("path", "/", "Inference endpoint path")
+	cmd.Flags().StringP("method", "X", "GET", "HTTP method (defaults to POST when a body is given)")
+	cmd.Flags().String("body", "", "JSON request body")
+	cmd.Flags().String("body-file", "", "Read the JSON request body from a file")
+	cmd.Flags().StringToStringP("header", "H", nil, "Extra request headers (key=value, repeatable)")
+	cmd.Flags().IntP("requests", "N", requests, requestsUsage)
+	cmd.Flags().IntP("concurrency", "C", concurrency, "Number of concurrent requests")
+	cmd.Flags().Duration("timeout", 60*time.Second, "Per-request timeout")
+}
+
+// requestFromFlags reads the flags added by addRequestFlags. The URL is left for the caller to
+// fill in once the port-forward is up; the endpoint path is returned with a leading slash.
+func requestFromFlags(cmd *cobra.Command) (utils.BenchmarkOptions, string, error) {
+	path, _ := cmd.Flags().GetString("path")
+	method, _ := cmd.Flags().GetString("method")
+	body, _ := cmd.Flags().GetString("body")
+	bodyFile, _ := cmd.Flags().GetString("body-file")
+	headers, _ := cmd.Flags().GetStringToString("header")
+	requests, _ := cmd.Flags().GetInt("requests")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if requests <= 0 || concurrency <= 0 {
+		return utils.BenchmarkOptions{}, "", fmt.Errorf("--requests and --concurrency must be greater than zero")
+	}
+	if body != "" && bodyFile != "" {
+		return utils.BenchmarkOptions{}, "", fmt.Errorf("--body and --body-file cannot be used together")
+	}
+	payload := []byte(body)
+	if bodyFile != "" {
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return utils.BenchmarkOptions{}, "", fmt.Errorf("failed to read body file: %w", err)
+		}
+		payload = data
 	}
+	if !cmd.Flags().Changed("method") && len(payload) > 0 {
+		method = "POST"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return utils.BenchmarkOptions{
+		Method:      method,
+		Body:        payload,
+		Headers:     headers,
+		Requests:    requests,
+		Concurrency: concurrency,
+		Timeout:     timeout,
+	}, path, nil
+}
 
-	listCmd := &cobra.Command{
-		Use:   "list",
-		Short: "List deployments and their resource requests/limits",
-		Long:  "Lists deployments in the given namespace with CPU, memory, and GPU requests/limits per container.",
+// createGuardModelsStageCmd builds `guard models stage`
+func createGuardModelsStageCmd() *cobra.Command {
+	stageCmd := &cobra.Command{
+		Use:   "stage --model <name> --pvc <claim> --namespace <namespace>",
+		Short: "Copy a pulled model artifact into a PVC",
+		Long: "Copies a model pulled with `artifacts pull --models` into a PersistentVolumeClaim. A temporary pod mounts the claim and the " +
+			"files are streamed into it as a tar archive, with progress reported as they are sent. The pod is deleted afterwards.",
+		Annotations: audited,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			namespace, _ := cmd.Flags().GetString("namespace")
-			output, _ := cmd.Flags().GetString("output")
+			model, _ := cmd.Flags().GetString("model")
+			pvc, _ := cmd.Flags().GetString("pvc")
+			artifactsDir, _ := cmd.Flags().GetString("artifacts-dir")
+			target, _ := cmd.Flags().GetString("path")
+			image, _ := cmd.Flags().GetString("image")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			overwrite, _ := cmd.Flags().GetBool("overwrite")
 
-			kc, err := utils.NewKubernetesChecker()
+			source, err := utils.ResolveModelArtifact(artifactsDir, model)
 			if err != nil {
-				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
+			if target == "" {
+				target = strings.TrimSuffix(filepath.Base(model), ".tar")
+			}
+			if overwrite {
+				if err := confirm(cmd, fmt.Sprintf("replace the contents of %s in PVC %s/%s", target, namespace, pvc)); err != nil {
+					return err
+				}
+			}
 
-			summaries, err := kc.ListDeploymentResourceSummaries(namespace)
+			kc, err := kubeChecker(cmd)
 			if err != nil {
-				cmd.Printf("✗ Failed to list deployments: %v\n", err)
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
 				return err
 			}
 
-			// Exclude specific deployments by name
-			exclude := map[string]struct{}{
-				"dynamoai-data-processing": {},
-				"dynamoai-moderation":      {},
-				"dynamoai-off-topic":       {},
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			cmd.Printf("Staging %s into PVC %s/%s at /%s\n", source, namespace, pvc, strings.TrimPrefix(target, "/"))
+			result, err := kc.StageModel(ctx, utils.ModelStageOptions{
+				Namespace: namespace,
+				PVC:       pvc,
+				Source:    source,
+				TargetDir: target,
+				Image:     image,
+				Overwrite: overwrite,
+				Timeout:   timeout,
+				Progress: func(sent, total int64) {
+					cmd.Printf("  %s / %s (%d%%)\n", utils.FormatBytes(sent), utils.FormatBytes(total), percentOf(sent, total))
+				},
+			})
+			if err != nil {
+				cmd.Printf("✗ Failed to stage model: %v\n", err)
+				return err
 			}
+			cmd.Printf("✓ Copied %d file(s), %s, to %s:/%s in %s\n", result.Files, utils.FormatBytes(result.Bytes), pvc, result.Target,
+				result.Duration.Round(time.Second))
+			return nil
+		},
+	}
 
-			filtered := make([]utils.DeploymentResourceSummary, 0, len(summaries))
-			for _, s := range summaries {
-				if _, skip := exclude[s.Name]; skip {
-					continue
-				}
-				filtered = append(filtered, s)
+	stageCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace of the PVC")
+	_ = stageCmd.MarkFlagRequired("namespace")
+	stageCmd.Flags().String("model", "", "Model name as pulled into --artifacts-dir, or the path to a pulled model artifact")
+	_ = stageCmd.MarkFlagRequired("model")
+	stageCmd.Flags().String("pvc", "", "PersistentVolumeClaim to copy the model into")
+	_ = stageCmd.MarkFlagRequired("pvc")
+	stageCmd.Flags().String("artifacts-dir", "./artifacts", "Directory models were pulled into")
+	stageCmd.Flags().String("path", "", "Directory inside the PVC to copy the model into (default: the model name)")
+	stageCmd.Flags().String("image", utils.DefaultLoaderImage, "Staging pod image; it needs sh and tar")
+	stageCmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the staging pod to start")
+	stageCmd.Flags().Bool("overwrite", false, "Replace the contents of a non-empty target directory")
+	addYesFlag(stageCmd)
+	return stageCmd
+}
+
+// percentOf is done as a whole percentage of total
+func percentOf(done, total int64) int64 {
+	if total <= 0 {
+		return 100
+	}
+	return done * 100 / total
+}
+
+// Reconnect delays for `guard port-forward`, doubling after each failed attempt
+const (
+	portForwardMinDelay = time.Second
+	portForwardMaxDelay = 30 * time.Second
+)
+
+// createGuardPortForwardCmd builds `guard port-forward`
+func createGuardPortForwardCmd() *cobra.Command {
+	portForwardCmd := &cobra.Command{
+		Use:   "port-forward <service> --namespace <namespace>",
+		Short: "Forward a local port to a Dynamo service",
+		Long: "Forwards a local port to a ready pod behind the service until interrupted. Well-known Dynamo services get a fixed local port " +
+			"and shell completion; other services get a free port unless --local-port is given. When the pod goes away or the connection " +
+			"drops, the port-forward reconnects to a ready pod on the same local port.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			port, _ := cmd.Flags().GetInt32("port")
+			localPort, _ := cmd.Flags().GetUint16("local-port")
+			reconnect, _ := cmd.Flags().GetBool("reconnect")
+
+			service := args[0]
+			if !cmd.Flags().Changed("local-port") {
+				localPort = defaultLocalPort(service)
 			}
 
-			if len(filtered) == 0 {
-				if output == "json" {
-					cmd.Println("[]")
-					return nil
-				}
-				cmd.Printf("No deployments found in namespace %s\n", namespace)
-				return nil
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
 			}
 
-			if output == "json" {
-				data, err := json.MarshalIndent(filtered, "", "  ")
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			connected := false
+			delay := portForwardMinDelay
+			for {
+				pf, err := kc.PortForwardService(ctx, namespace, service, port, localPort)
 				if err != nil {
-					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
-					return err
+					if ctx.Err() != nil {
+						return nil
+					}
+					if !connected || !reconnect {
+						cmd.Printf("✗ Failed to port-forward to %s: %v\n", service, err)
+						return err
+					}
+					cmd.Printf("! Reconnect failed: %v; retrying in %s\n", err, delay)
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-time.After(delay):
+					}
+					delay = min(delay*2, portForwardMaxDelay)
+					continue
 				}
-				cmd.Println(string(data))
-				return nil
-			}
 
-			if output == "csv" {
-				writer := csv.NewWriter(cmd.OutOrStdout())
-				_ = writer.Write([]string{
-					"namespace",
-					"deployment",
-					"pods",
-					"requests_cpu",
-					"requests_memory",
-					"requests_gpu",
-					"limits_cpu",
-					"limits_memory",
-					"limits_gpu",
-				})
-				var totalPods int64
-				for _, d := range filtered {
-					reqCPU, reqMem, reqGPU, limCPU, limMem, limGPU := aggregateContainerResources(d.Containers)
-					totalPods += int64(d.Pods)
-					_ = writer.Write([]string{
-						namespace,
-						d.Name,
-						fmt.Sprintf("%d", d.Pods),
-						reqCPU,
-						reqMem,
-						reqGPU,
-						limCPU,
-						limMem,
-						limGPU,
-					})
+				// Keep the same local port across reconnects so open browser tabs and scripts keep working
+				localPort = pf.LocalPort
+				delay = portForwardMinDelay
+				if !connected {
+					cmd.Printf("✓ Forwarding http://127.0.0.1:%d -> %s/%s (pod %s, port %d)\n", pf.LocalPort, namespace, service, pf.Pod, pf.RemotePort)
+					cmd.Println("Press Ctrl+C to stop")
+				} else {
+					cmd.Printf("✓ Reconnected to pod %s\n", pf.Pod)
 				}
-				// Totals row (aggregate across deployments, multiplied by pods)
-				totals := computeTotals(filtered)
-				_ = writer.Write([]string{
-					namespace,
-					"TOTAL",
-					fmt.Sprintf("%d", totalPods),
-					formatCPUCores(totals.requestsCPUMilliCores),
-					formatGi(totals.requestsMemoryBytes),
-					fmt.Sprintf("%d", totals.requestsGPUs),
-					formatCPUCores(totals.limitsCPUMilliCores),
-					formatGi(totals.limitsMemoryBytes),
-					fmt.Sprintf("%d", totals.limitsGPUs),
-				})
-				writer.Flush()
-				if err := writer.Error(); err != nil {
-					cmd.Printf("✗ Failed to write CSV: %v\n", err)
-					return err
+				connected = true
+
+				select {
+				case <-ctx.Done():
+					pf.Close()
+					return nil
+				case <-pf.Done():
 				}
-				return nil
+				pf.Close()
+				if !reconnect {
+					return fmt.Errorf("port-forward to pod %s ended: %v", pf.Pod, pf.Err())
+				}
+				cmd.Printf("! Lost connection to pod %s (%v); reconnecting\n", pf.Pod, pf.Err())
 			}
+		},
+	}
+
+	portForwardCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
+	_ = portForwardCmd.MarkFlagRequired("namespace")
+	portForwardCmd.Flags().Int32("port", 0, "Service port to forward to (default: the service's first port)")
+	portForwardCmd.Flags().Uint16("local-port", 0, "Local port to listen on (default: the service's well-known port, or a free port)")
+	portForwardCmd.Flags().Bool("reconnect", true, "Reconnect when the pod goes away or the connection drops")
+	return portForwardCmd
+}
+
+// defaultLocalPort is the well-known local port of a Dynamo service, or 0 for a free port
+func defaultLocalPort(service string) uint16 {
+	if s, ok := utils.LookupDynamoService(service); ok {
+		return s.LocalPort
+	}
+	return 0
+}
+
+// resolveModelFilters merges the selector/include/exclude flags with config file defaults.
+// Flags always win; the built-in exclusion list applies only when no selector, include or
+// exclude list is set, so asking for an excluded deployment by name still finds it.
+func resolveModelFilters(cmd *cobra.Command) (string, []string, []string, error) {
+	cfg, err := utils.LoadConfig()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	selector := cfg.Guard.ModelSelector
+	if cmd.Flags().Changed("selector") {
+		selector, _ = cmd.Flags().GetString("selector")
+	}
+
+	include := cfg.Guard.IncludeDeployments
+	if cmd.Flags().Changed("include") {
+		include, _ = cmd.Flags().GetStringSlice("include")
+	}
+
+	exclude := cfg.Guard.ExcludeDeployments
+	if cmd.Flags().Changed("exclude") {
+		exclude, _ = cmd.Flags().GetStringSlice("exclude")
+	} else if exclude == nil && selector == "" && len(include) == 0 {
+		exclude = utils.DefaultGuardExcludedDeployments
+	}
+
+	utils.LogDebug("Model filters: selector=%q include=%v exclude=%v", selector, include, exclude)
+	return selector, include, exclude, nil
+}
+
+// addCostFlags adds --show-cost and --pricing to a resource report
+func addCostFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("show-cost", false, "Add approximate monthly cost at on-demand prices")
+	cmd.Flags().String("pricing", "", "Pricing file with instance type prices and unit rates (implies --show-cost)")
+}
+
+// addWorkbookFlags adds -o with xlsx support and --output-file to a resource report
+func addWorkbookFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "table", output.WorkbookFlagUsage)
+	cmd.Flags().String("output-file", "", "Write the xlsx workbook to this file")
+}
+
+// workbookOutputFile returns the --output-file path, which -o xlsx requires and other formats
+// do not use
+func workbookOutputFile(cmd *cobra.Command, outputFormat string) (string, error) {
+	path, _ := cmd.Flags().GetString("output-file")
+	if outputFormat == output.FormatXLSX && path == "" {
+		return "", fmt.Errorf("--output xlsx requires --output-file")
+	}
+	if outputFormat != output.FormatXLSX && path != "" {
+		return "", fmt.Errorf("--output-file is only used with --output xlsx")
+	}
+	return path, nil
+}
+
+// writeWorkbook writes the sheets to an xlsx file
+func writeWorkbook(cmd *cobra.Command, path string, sheets []output.Sheet) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	if err := output.WriteWorkbook(f, sheets); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	cmd.Printf("✓ Wrote %s (%d sheets)\n", path, len(sheets))
+	return nil
+}
+
+// resolvePricing returns the prices for --show-cost, or nil when costs were not requested.
+// --pricing wins over cluster.pricing_file in the config file; without either the built-in
+// on-demand tables are used.
+func resolvePricing(cmd *cobra.Command) (*utils.Pricing, error) {
+	showCost, _ := cmd.Flags().GetBool("show-cost")
+	path, _ := cmd.Flags().GetString("pricing")
+	if !showCost && path == "" {
+		return nil, nil
+	}
+	if path == "" {
+		cfg, err := utils.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		path = cfg.Cluster.PricingFile
+	}
+	if path == "" {
+		pricing := utils.DefaultPricing
+		return &pricing, nil
+	}
+	return utils.LoadPricing(path)
+}
+
+// renderBenchmarkResult prints latency percentiles and the error rate of a benchmark run
+func renderBenchmarkResult(cmd *cobra.Command, service, path string, r *utils.BenchmarkResult) {
+	cmd.Printf("Service: %s%s\n", service, path)
+	cmd.Printf("Requests: %d (concurrency %d) in %s, %.1f req/s\n", r.Requests, r.Concurrency, r.Duration.Round(time.Millisecond), r.RequestsPerSecond)
+	cmd.Println()
+	cmd.Printf("%-10s %-10s %-10s %-10s %s\n", "p50", "p95", "Mean", "Max", "Errors")
+	cmd.Println("------------------------------------------------------------")
+	cmd.Printf("%-10s %-10s %-10s %-10s %d (%.1f%%)\n",
+		r.P50.Round(time.Millisecond),
+		r.P95.Round(time.Millisecond),
+		r.Mean.Round(time.Millisecond),
+		r.Max.Round(time.Millisecond),
+		r.Errors,
+		r.ErrorRate*100,
+	)
+	cmd.Println()
+
+	switch {
+	case r.Errors == 0:
+		cmd.Println("✓ All requests succeeded")
+	case r.Errors == r.Requests:
+		cmd.Printf("✗ All requests failed (first error: %s)\n", r.FirstError)
+	default:
+		cmd.Printf("! %d requests failed (first error: %s)\n", r.Errors, r.FirstError)
+	}
+}
+
+// renderAutoscalers prints each autoscaler with its metrics, recent events, and warnings
+func renderAutoscalers(cmd *cobra.Command, namespace string, autoscalers []utils.AutoscalerSummary) {
+	cmd.Printf("Namespace: %s\n", namespace)
+	if len(autoscalers) == 0 {
+		cmd.Println("No HPAs or ScaledObjects found for model workloads")
+		return
+	}
+
+	cmd.Printf("%-40s %-24s %-10s %-10s %s\n", "Target", "Autoscaler", "Min/Max", "Replicas", "Metrics (current/target)")
+	cmd.Println("----------------------------------------------------------------------------------------------")
+	for _, a := range autoscalers {
+		var metrics []string
+		for _, m := range a.Metrics {
+			metrics = append(metrics, fmt.Sprintf("%s %s/%s", m.Name, m.Current, m.Target))
+		}
+		name := "hpa/" + a.Name
+		if a.Kind == "ScaledObject" {
+			name = "so/" + a.Name
+		}
+		cmd.Printf("%-40s %-24s %-10s %-10s %s\n",
+			a.Target,
+			name,
+			fmt.Sprintf("%d/%d", a.MinReplicas, a.MaxReplicas),
+			fmt.Sprintf("%d->%d", a.CurrentReplicas, a.DesiredReplicas),
+			strings.Join(metrics, ", "),
+		)
+		for _, e := range a.Events {
+			cmd.Printf("    event: %s\n", e)
+		}
+		for _, w := range a.Warnings {
+			cmd.Printf("    ! %s\n", w)
+		}
+	}
+}
+
+// renderAuditFindings prints findings grouped by severity
+func renderAuditFindings(cmd *cobra.Command, namespace string, findings []utils.AuditFinding) {
+	cmd.Printf("Namespace: %s\n", namespace)
+	if len(findings) == 0 {
+		cmd.Println("✓ No best-practice issues found")
+		return
+	}
+
+	counts := map[string]int{}
+	cmd.Printf("%-8s %-40s %-22s %s\n", "Severity", "Workload", "Check", "Finding")
+	cmd.Println("----------------------------------------------------------------------------------------------")
+	for _, f := range findings {
+		counts[f.Severity]++
+		cmd.Printf("%-8s %-40s %-22s %s\n",
+			strings.ToUpper(f.Severity),
+			workloadLabel(utils.DeploymentResourceSummary{Kind: f.Kind, Name: f.Workload}),
+			f.Check,
+			f.Message,
+		)
+	}
+	cmd.Println()
+	cmd.Printf("Findings: %d high, %d medium, %d low\n", counts[utils.SeverityHigh], counts[utils.SeverityMedium], counts[utils.SeverityLow])
+}
+
+// renderPlacementPlan prints a human-readable capacity verdict for a proposed model
+func renderPlacementPlan(cmd *cobra.Command, profile *utils.ModelProfile, plan *utils.PlacementPlan) {
+	cmd.Printf("Model: %s (%d replicas, per replica cpu/mem/gpu: %s)\n",
+		plan.Model, plan.Replicas, joinTriple(profile.CPU, profile.Memory, fmt.Sprintf("%d", profile.GPU)))
+	cmd.Printf("Eligible nodes: %d\n", plan.EligibleNodes)
+	cmd.Println()
 
-			// Header
-			cmd.Printf("Namespace: %s\n", namespace)
-			cmd.Println("Deployment (pods)                             Requests (cpu/mem/gpu)         Limits (cpu/mem/gpu)")
-			cmd.Println("----------------------------------------------------------------------------------------------")
+	if len(plan.Placements) > 0 {
+		cmd.Printf("%-30s %-10s %s\n", "Node pool", "Replicas", "Nodes")
+		cmd.Println("----------------------------------------------------------------------------------------------")
+		for _, p := range plan.Placements {
+			cmd.Printf("%-30s %-10d %s\n", p.Pool, p.Replicas, strings.Join(p.Nodes, ", "))
+		}
+		cmd.Println()
+	}
 
-			for _, d := range filtered {
-				reqCPU, reqMem, reqGPU, limCPU, limMem, limGPU := aggregateContainerResources(d.Containers)
-				label := fmt.Sprintf("%s (%d)", d.Name, d.Pods)
-				cmd.Printf("%-40s %-28s %-28s\n",
-					label,
-					joinTriple(reqCPU, reqMem, reqGPU),
-					joinTriple(limCPU, limMem, limGPU),
-				)
+	if a := plan.Autoscaling; a != nil && len(a.Groups) > 0 {
+		cmd.Printf("%-20s %-40s %-20s %s\n", "Autoscaler", "Group", "Node pool", "Capacity")
+		cmd.Println("----------------------------------------------------------------------------------------------")
+		for _, g := range a.Groups {
+			pool := g.Pool
+			if pool == "" {
+				pool = "-"
 			}
+			cmd.Printf("%-20s %-40s %-20s %s\n", g.Autoscaler, g.Name, pool, g.Capacity())
+		}
+		cmd.Println()
+	}
+	if a := plan.Autoscaling; a != nil {
+		for _, w := range a.Warnings {
+			cmd.Printf("! %s\n", w)
+		}
+	}
+
+	if plan.Fits {
+		cmd.Printf("✓ All %d replicas fit on existing capacity\n", plan.Replicas)
+		return
+	}
+	if plan.FitsAfterScaleUp {
+		cmd.Printf("! %d of %d replicas need the autoscaler to add capacity\n", plan.Unplaced, plan.Replicas)
+		renderScaleUp(cmd, plan.ScaleUp)
+		return
+	}
 
-			// Totals across all deployments (requests and limits) accounting for pod replicas
-			totals := computeTotals(filtered)
-			cmd.Println("----------------------------------------------------------------------------------------------")
-			cmd.Printf("%-40s %-28s %-28s\n",
-				"TOTAL (all deployments)",
-				joinTriple(formatCPUCores(totals.requestsCPUMilliCores), formatGi(totals.requestsMemoryBytes), fmt.Sprintf("%d", totals.requestsGPUs)),
-				joinTriple(formatCPUCores(totals.limitsCPUMilliCores), formatGi(totals.limitsMemoryBytes), fmt.Sprintf("%d", totals.limitsGPUs)),
-			)
+	cmd.Printf("✗ %d of %d replicas do not fit\n", plan.UnplacedAfterScaleUp, plan.Replicas)
+	renderScaleUp(cmd, plan.ScaleUp)
+	if plan.IneligibleReason != "" {
+		cmd.Printf("  Reason: %s\n", plan.IneligibleReason)
+	}
+	cmd.Printf("  Additional capacity needed: %.1f cores, %.1f GB memory, %d GPUs\n",
+		plan.AdditionalCPU, plan.AdditionalMemGB, plan.AdditionalGPU)
+	if plan.SuggestedPool != "" {
+		cmd.Printf("  Suggestion: add %d node(s) to pool %s\n", plan.SuggestedNodes, plan.SuggestedPool)
+	}
+}
 
-			return nil
+// renderScaleUp lists the replicas each autoscaler group takes
+func renderScaleUp(cmd *cobra.Command, scaleUp []utils.ScaleUpPlacement) {
+	for _, p := range scaleUp {
+		if p.NewNodes > 0 {
+			cmd.Printf("  %s grows %s by %d node(s) for %d replica(s)\n", p.Autoscaler, p.Group, p.NewNodes, p.Replicas)
+		} else {
+			cmd.Printf("  %s provisions nodes in %s for %d replica(s)\n", p.Autoscaler, p.Group, p.Replicas)
+		}
+	}
+}
+
+// workloadLabel names a workload for table output; Deployments keep their bare name
+func workloadLabel(d utils.DeploymentResourceSummary) string {
+	if d.Kind == "" || d.Kind == utils.WorkloadKindDeployment {
+		return d.Name
+	}
+	return strings.ToLower(d.Kind) + "/" + d.Name
+}
+
+// workloadResourceColumns are the per-workload request and limit columns shared by the list views
+var workloadResourceColumns = []output.Column{
+	{Header: "CPU REQ", CSV: "requests_cpu"},
+	{Header: "MEM REQ", CSV: "requests_memory"},
+	{Header: "GPU REQ", CSV: "requests_gpu"},
+	{Header: "CPU LIMIT", CSV: "limits_cpu"},
+	{Header: "MEM LIMIT", CSV: "limits_memory"},
+	{Header: "GPU LIMIT", CSV: "limits_gpu"},
+}
+
+// acceleratorsColumn lists accelerators other than nvidia.com/gpu, e.g. amd.com/gpu=1
+var acceleratorsColumn = output.Column{Header: "ACCELERATORS", CSV: "accelerators", MaxWidth: 40}
+
+// renderWorkloadSummaries prints one row per workload plus a totals row accounting for replicas.
+// Totals multiply by desired replicas, or by ready replicas when useReady is set.
+func renderWorkloadSummaries(cmd *cobra.Command, namespace string, deployments []utils.DeploymentResourceSummary, outputFormat string, opts workloadListOptions) error {
+	if deployments == nil {
+		deployments = []utils.DeploymentResourceSummary{}
+	}
+	priceWorkloads(deployments, opts)
+	table := &output.Table{Columns: workloadSummaryColumns(opts), Data: deployments}
+	addWorkloadRows(table, namespace, deployments, opts)
+
+	if output.IsTabular(outputFormat) {
+		cmd.Printf("Namespace: %s\n", namespace)
+	}
+	return output.Render(cmd.OutOrStdout(), outputFormat, table)
+}
+
+// workloadListOptions control how `guard models list` totals workloads
+type workloadListOptions struct {
+	// useReady multiplies resources by ready replicas instead of desired replicas
+	useReady bool
+	// pricing adds a monthly cost column when set
+	pricing *utils.Pricing
+}
+
+// costColumns hold the monthly cost of a workload's requests, shown with --show-cost
+func (o workloadListOptions) costColumns() []output.Column {
+	if o.pricing == nil {
+		return nil
+	}
+	return []output.Column{{Header: "COST/MO(" + o.pricing.Currency + ")", CSV: "monthly_cost"}}
+}
+
+// workloadSummaryColumns are the columns of the per-workload listing
+func workloadSummaryColumns(opts workloadListOptions) []output.Column {
+	columns := []output.Column{
+		{Header: "NAMESPACE", CSV: "namespace", Wide: true},
+		{Header: "WORKLOAD", CSV: "deployment"},
+	}
+	columns = append(columns, replicaColumns...)
+	columns = append(columns, workloadResourceColumns...)
+	columns = append(columns, output.Column{Header: "KIND", CSV: "kind"}, acceleratorsColumn)
+	return append(columns, opts.costColumns()...)
+}
+
+// replicaColumns are the desired, ready, and available replica counts of a workload
+var replicaColumns = []output.Column{
+	{Header: "DESIRED", CSV: "replicas"},
+	{Header: "READY", CSV: "ready_replicas"},
+	{Header: "AVAILABLE", CSV: "available_replicas", Wide: true},
+}
+
+// replicaCells formats a workload's replica counts for the replica columns
+func replicaCells(d utils.DeploymentResourceSummary) []string {
+	return []string{fmt.Sprintf("%d", d.Replicas), fmt.Sprintf("%d", d.ReadyReplicas), fmt.Sprintf("%d", d.AvailableReplicas)}
+}
+
+// priceWorkloads fills in the monthly cost of each workload's requests when costs are shown
+func priceWorkloads(deployments []utils.DeploymentResourceSummary, opts workloadListOptions) {
+	if opts.pricing == nil {
+		return
+	}
+	for i := range deployments {
+		deployments[i].MonthlyCost = computeTotals(deployments[i:i+1], opts).monthlyCost
+	}
+}
+
+// countedReplicas is the replica count resource totals multiply by
+func countedReplicas(d utils.DeploymentResourceSummary, useReady bool) int64 {
+	if useReady {
+		return int64(d.ReadyReplicas)
+	}
+	return int64(d.Replicas)
+}
+
+// totalLabel names the totals row, noting when it counts ready replicas
+func totalLabel(label string, useReady bool) string {
+	if useReady {
+		return label + " (ready)"
+	}
+	return label
+}
+
+// addWorkloadRows adds one row per workload and, when there are any, a TOTAL row
+func addWorkloadRows(table *output.Table, namespace string, deployments []utils.DeploymentResourceSummary, opts workloadListOptions) {
+	for _, d := range deployments {
+		reqCPU, reqMem, reqGPU, limCPU, limMem, limGPU := aggregateContainerResources(d.Containers)
+		row := append([]string{namespace, d.Name}, replicaCells(d)...)
+		row = append(row, reqCPU, reqMem, reqGPU, limCPU, limMem, limGPU, d.Kind,
+			utils.FormatAcceleratorCounts(workloadAccelerators(d.Containers, 1)))
+		if opts.pricing != nil {
+			row = append(row, utils.FormatCost(d.MonthlyCost, false))
+		}
+		table.AddRow(row...)
+	}
+	if len(deployments) > 0 {
+		// Totals across all workloads, multiplied by replicas
+		table.AddRow(computeTotals(deployments, opts).row(namespace, totalLabel("TOTAL", opts.useReady), true)...)
+	}
+}
+
+// row formats the totals as a table row led by two label cells, followed by the replica, resource,
+// and ACCELERATORS columns, with an empty KIND cell before ACCELERATORS when withKind is set
+func (t totalsAccumulator) row(first, second string, withKind bool) []string {
+	row := []string{first, second,
+		fmt.Sprintf("%d", t.replicas),
+		fmt.Sprintf("%d", t.readyReplicas),
+		fmt.Sprintf("%d", t.availableReplicas),
+		formatCPUCores(t.requestsCPUMilliCores),
+		formatGi(t.requestsMemoryBytes),
+		fmt.Sprintf("%d", t.requestsGPUs),
+		formatCPUCores(t.limitsCPUMilliCores),
+		formatGi(t.limitsMemoryBytes),
+		fmt.Sprintf("%d", t.limitsGPUs),
+	}
+	if withKind {
+		row = append(row, "")
+	}
+	row = append(row, utils.FormatAcceleratorCounts(t.accelerators))
+	if t.costed {
+		row = append(row, utils.FormatCost(t.monthlyCost, false))
+	}
+	return row
+}
+
+// namespaceWorkloads is one namespace's section of a multi-namespace listing
+type namespaceWorkloads struct {
+	Namespace string
+	Workloads []utils.DeploymentResourceSummary
+	Total     workloadTotals
+}
+
+// workloadTotals are resource totals in the units the tables print
+type workloadTotals struct {
+	Workloads         int
+	Replicas          int64
+	ReadyReplicas     int64
+	AvailableReplicas int64
+	// CountedFrom is the replica count the resource totals multiply by: desired or ready
+	CountedFrom    string
+	RequestsCPU    string
+	RequestsMemory string
+	RequestsGPU    int64
+	LimitsCPU      string
+	LimitsMemory   string
+	LimitsGPU      int64
+	Accelerators   map[string]int64 `json:",omitempty"`
+	MonthlyCost    float64          `json:",omitempty"`
+}
+
+// multiNamespaceReport is the json and yaml form of a multi-namespace listing
+type multiNamespaceReport struct {
+	Namespaces []namespaceWorkloads
+	Total      workloadTotals
+}
+
+func newWorkloadTotals(deployments []utils.DeploymentResourceSummary, opts workloadListOptions) workloadTotals {
+	t := computeTotals(deployments, opts)
+	countedFrom := "desired"
+	if opts.useReady {
+		countedFrom = "ready"
+	}
+	return workloadTotals{
+		Workloads:         len(deployments),
+		Replicas:          t.replicas,
+		ReadyReplicas:     t.readyReplicas,
+		AvailableReplicas: t.availableReplicas,
+		CountedFrom:       countedFrom,
+		RequestsCPU:       formatCPUCores(t.requestsCPUMilliCores),
+		RequestsMemory:    formatGi(t.requestsMemoryBytes),
+		RequestsGPU:       t.requestsGPUs,
+		LimitsCPU:         formatCPUCores(t.limitsCPUMilliCores),
+		LimitsMemory:      formatGi(t.limitsMemoryBytes),
+		LimitsGPU:         t.limitsGPUs,
+		Accelerators:      t.accelerators,
+		MonthlyCost:       t.monthlyCost,
+	}
+}
+
+// renderMultiNamespaceWorkloads prints each namespace's workloads followed by a per-namespace
+// summary with a grand total. CSV output is one table with every namespace's rows.
+func renderMultiNamespaceWorkloads(cmd *cobra.Command, groups []namespaceWorkloads, outputFormat string, opts workloadListOptions) error {
+	var all []utils.DeploymentResourceSummary
+	for i := range groups {
+		priceWorkloads(groups[i].Workloads, opts)
+		groups[i].Total = newWorkloadTotals(groups[i].Workloads, opts)
+		all = append(all, groups[i].Workloads...)
+	}
+	report := multiNamespaceReport{Namespaces: groups, Total: newWorkloadTotals(all, opts)}
+	if report.Namespaces == nil {
+		report.Namespaces = []namespaceWorkloads{}
+	}
+
+	if !output.IsTabular(outputFormat) {
+		return output.Render(cmd.OutOrStdout(), outputFormat, combinedWorkloadTable(groups, all, opts, report))
+	}
+
+	for _, g := range groups {
+		if err := renderWorkloadSummaries(cmd, g.Namespace, g.Workloads, outputFormat, opts); err != nil {
+			return err
+		}
+		cmd.Println()
+	}
+
+	cmd.Printf("Total across %d namespaces\n", len(groups))
+	return output.Render(cmd.OutOrStdout(), outputFormat, namespaceTotalsTable(groups, all, opts))
+}
+
+// combinedWorkloadTable lists the workloads of every namespace in one table, each namespace
+// followed by its TOTAL row, ending with a GRAND TOTAL row when there are several namespaces
+func combinedWorkloadTable(groups []namespaceWorkloads, all []utils.DeploymentResourceSummary, opts workloadListOptions, data interface{}) *output.Table {
+	table := &output.Table{Columns: workloadSummaryColumns(opts), Data: data}
+	for _, g := range groups {
+		addWorkloadRows(table, g.Namespace, g.Workloads, opts)
+	}
+	if len(groups) > 1 {
+		table.AddRow(computeTotals(all, opts).row("", totalLabel("GRAND TOTAL", opts.useReady), true)...)
+	}
+	return table
+}
+
+// namespaceTotalsTable has one totals row per namespace and a TOTAL row across them
+func namespaceTotalsTable(groups []namespaceWorkloads, all []utils.DeploymentResourceSummary, opts workloadListOptions) *output.Table {
+	columns := []output.Column{{Header: "NAMESPACE", CSV: "namespace"}, {Header: "WORKLOADS", CSV: "workloads"}}
+	columns = append(columns, replicaColumns...)
+	columns = append(columns, workloadResourceColumns...)
+	columns = append(columns, acceleratorsColumn)
+	table := &output.Table{Columns: append(columns, opts.costColumns()...)}
+	for _, g := range groups {
+		table.AddRow(computeTotals(g.Workloads, opts).row(g.Namespace, fmt.Sprintf("%d", len(g.Workloads)), false)...)
+	}
+	table.AddRow(computeTotals(all, opts).row(totalLabel("TOTAL", opts.useReady), fmt.Sprintf("%d", len(all)), false)...)
+	return table
+}
+
+// writeWorkloadWorkbook writes the workloads, per-namespace totals, and the cluster's node sheets
+// to an xlsx workbook. Node sheets are left out, with a warning, when nodes cannot be read.
+func writeWorkloadWorkbook(cmd *cobra.Command, kc *utils.KubernetesChecker, path string, groups []namespaceWorkloads, opts workloadListOptions) error {
+	var all []utils.DeploymentResourceSummary
+	for _, g := range groups {
+		priceWorkloads(g.Workloads, opts)
+		all = append(all, g.Workloads...)
+	}
+	sheets := []output.Sheet{{Name: "Workloads", Table: combinedWorkloadTable(groups, all, opts, nil)}}
+	if len(groups) > 1 {
+		sheets = append(sheets, output.Sheet{Name: "Namespaces", Table: namespaceTotalsTable(groups, all, opts)})
+	}
+
+	nodes, summary, err := kc.GatherNodeResources(cmd.Context(), "")
+	if err != nil {
+		utils.LogWarning("Leaving node sheets out of %s: %v", path, err)
+	} else {
+		if opts.pricing != nil {
+			utils.ApplyNodePricing(nodes, &summary, opts.pricing)
+		}
+		sheets = append(sheets, output.NodeWorkbookSheets(nodes, summary)...)
+	}
+	return writeWorkbook(cmd, path, sheets)
+}
+
+// containerResourceRow is a per-container view of a workload's resources
+type containerResourceRow struct {
+	Kind          string
+	Deployment    string
+	Replicas      int32
+	ReadyReplicas int32
+	utils.ContainerResourceSummary
+}
+
+// renderContainerSummaries prints one row per container of each deployment
+func renderContainerSummaries(cmd *cobra.Command, namespace string, deployments []utils.DeploymentResourceSummary, outputFormat string) error {
+	rows := make([]containerResourceRow, 0)
+	for _, d := range deployments {
+		for _, c := range d.Containers {
+			rows = append(rows, containerResourceRow{Kind: d.Kind, Deployment: d.Name, Replicas: d.Replicas, ReadyReplicas: d.ReadyReplicas, ContainerResourceSummary: c})
+		}
+	}
+
+	columns := []output.Column{
+		{Header: "NAMESPACE", CSV: "namespace", Wide: true},
+		{Header: "WORKLOAD", CSV: "deployment"},
+		{Header: "CONTAINER", CSV: "container"},
+		replicaColumns[0],
+		replicaColumns[1],
+	}
+	columns = append(columns, workloadResourceColumns...)
+	table := &output.Table{Columns: append(columns, acceleratorsColumn), Data: rows}
+	for _, r := range rows {
+		table.AddRow(namespace, r.Deployment, r.Name, fmt.Sprintf("%d", r.Replicas), fmt.Sprintf("%d", r.ReadyReplicas),
+			r.RequestsCPU, r.RequestsMemory, r.RequestsGPU, r.LimitsCPU, r.LimitsMemory, r.LimitsGPU,
+			utils.FormatAcceleratorCounts(r.Accelerators))
+	}
+
+	if output.IsTabular(outputFormat) {
+		cmd.Printf("Namespace: %s\n", namespace)
+	}
+	return output.Render(cmd.OutOrStdout(), outputFormat, table)
+}
+
+// renderPodSummaries prints pod-level placement and status for model deployments
+func renderPodSummaries(cmd *cobra.Command, namespace string, pods []utils.PodStatusSummary, outputFormat string) error {
+	if pods == nil {
+		pods = []utils.PodStatusSummary{}
+	}
+	table := &output.Table{
+		Columns: []output.Column{
+			{Header: "NAMESPACE", CSV: "namespace", Wide: true},
+			{Header: "WORKLOAD", CSV: "deployment", Wide: true},
+			{Header: "POD", CSV: "pod"},
+			{Header: "NODE", CSV: "node"},
+			{Header: "TYPE", CSV: "instance_type"},
+			{Header: "PHASE", CSV: "phase"},
+			{Header: "READY", CSV: "ready"},
+			{Header: "RESTARTS", CSV: "restarts"},
+			{Header: "AGE", CSV: "age"},
 		},
+		Data: pods,
+	}
+	for _, p := range pods {
+		table.AddRow(namespace, p.Deployment, p.Name, p.Node, p.InstanceType, p.Phase,
+			fmt.Sprintf("%t", p.Ready), fmt.Sprintf("%d", p.Restarts), formatAge(p.StartTime))
 	}
 
-	listCmd.Flags().StringP("namespace", "n", "", "Kubernetes namespace")
-	_ = listCmd.MarkFlagRequired("namespace")
-	listCmd.Flags().StringP("output", "o", "table", "Output format: table, json, or csv")
+	if output.IsTabular(outputFormat) {
+		cmd.Printf("Namespace: %s\n", namespace)
+		if len(pods) == 0 {
+			cmd.Println("No pods found for the selected deployments")
+			return nil
+		}
+	}
+	return output.Render(cmd.OutOrStdout(), outputFormat, table)
+}
 
-	modelsCmd.AddCommand(listCmd)
-	guardCmd.AddCommand(modelsCmd)
-	rootCmd.AddCommand(guardCmd)
+// formatAge renders the time since start in the short form used by kubectl
+func formatAge(start *time.Time) string {
+	if start == nil {
+		return "-"
+	}
+	return duration.HumanDuration(time.Since(*start))
 }
 
 // joinTriple joins cpu/memory/gpu strings into a compact display
@@ -216,18 +1835,30 @@ func aggregateContainerResources(containers []utils.ContainerResourceSummary) (s
 }
 
 type totalsAccumulator struct {
+	replicas              int64
+	readyReplicas         int64
+	availableReplicas     int64
 	requestsCPUMilliCores int64
 	requestsMemoryBytes   int64
 	requestsGPUs          int64
 	limitsCPUMilliCores   int64
 	limitsMemoryBytes     int64
 	limitsGPUs            int64
+	accelerators          map[string]int64
+	// monthlyCost prices the requests when costed is set
+	monthlyCost float64
+	costed      bool
 }
 
-func computeTotals(deployments []utils.DeploymentResourceSummary) totalsAccumulator {
+// computeTotals sums replicas and resources across workloads, multiplying resources by desired
+// replicas, or by ready replicas when useReady is set
+func computeTotals(deployments []utils.DeploymentResourceSummary, opts workloadListOptions) totalsAccumulator {
 	var t totalsAccumulator
 	for _, d := range deployments {
-		pods := int64(d.Pods)
+		t.replicas += int64(d.Replicas)
+		t.readyReplicas += int64(d.ReadyReplicas)
+		t.availableReplicas += int64(d.AvailableReplicas)
+		pods := countedReplicas(d, opts.useReady)
 		// per-deployment sums
 		var reqCPU, reqMem, reqGPU resource.Quantity
 		var limCPU, limMem, limGPU resource.Quantity
@@ -258,10 +1889,35 @@ func computeTotals(deployments []utils.DeploymentResourceSummary) totalsAccumula
 		t.limitsCPUMilliCores += limCPU.MilliValue() * pods
 		t.limitsMemoryBytes += limMem.Value() * pods
 		t.limitsGPUs += limGPU.Value() * pods
+		for name, count := range workloadAccelerators(d.Containers, pods) {
+			if t.accelerators == nil {
+				t.accelerators = map[string]int64{}
+			}
+			t.accelerators[name] += count
+		}
+	}
+	if opts.pricing != nil {
+		t.costed = true
+		t.monthlyCost = opts.pricing.ResourcesMonthlyCost(float64(t.requestsCPUMilliCores)/1000,
+			float64(t.requestsMemoryBytes)/(1<<30), t.requestsGPUs)
 	}
 	return t
 }
 
+// workloadAccelerators sums accelerator counts across containers, multiplied by pods
+func workloadAccelerators(containers []utils.ContainerResourceSummary, pods int64) map[string]int64 {
+	var counts map[string]int64
+	for _, c := range containers {
+		for name, count := range c.Accelerators {
+			if counts == nil {
+				counts = map[string]int64{}
+			}
+			counts[name] += count * pods
+		}
+	}
+	return counts
+}
+
 func formatCPUCores(milli int64) string {
 	// 1000m == 1 core
 	if milli%1000 == 0 {
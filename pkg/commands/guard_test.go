@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGuardCommands(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddGuardCommands(rootCmd)
+
+	guardCmd := findSubcommand(rootCmd, "guard")
+	assert.NotNil(t, guardCmd, "guard command should exist")
+
+	modelsCmd := findSubcommand(guardCmd, "models")
+	assert.NotNil(t, modelsCmd, "models command should exist")
+
+	listCmd := findSubcommand(modelsCmd, "list")
+	assert.NotNil(t, listCmd, "list command should exist")
+	assert.NotNil(t, listCmd.Flags().Lookup("per-pod"), "per-pod flag should exist")
+	assert.NotNil(t, listCmd.Flags().Lookup("containers"), "containers flag should exist")
+
+	logsCmd := findSubcommand(modelsCmd, "logs")
+	assert.NotNil(t, logsCmd, "logs command should exist")
+	assert.NotNil(t, logsCmd.Flags().Lookup("since"), "since flag should exist")
+	assert.NotNil(t, logsCmd.Flags().Lookup("grep"), "grep flag should exist")
+	assert.NotNil(t, logsCmd.Flags().Lookup("follow"), "follow flag should exist")
+}
+
+func TestGuardModelsListValidation(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddGuardCommands(rootCmd)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	rootCmd.SetArgs([]string{"guard", "models", "list", "-n", "dynamo", "--per-pod", "--containers"})
+	err := rootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--per-pod and --containers cannot be used together")
+}
+
+func TestGuardModelsListNamespaceScope(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"-n", "dynamo", "-A"},
+		{"-n", "dynamo", "--namespaces", "prod,staging"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddGuardCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"guard", "models", "list"}, args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", args) {
+			assert.Contains(t, err.Error(), "exactly one of --namespace, --namespaces, or --all-namespaces")
+		}
+	}
+
+	rootCmd := &cobra.Command{}
+	AddGuardCommands(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"guard", "models", "list", "-A", "--per-pod"})
+	err := rootCmd.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "--per-pod and --containers list a single --namespace")
+	}
+}
+
+func TestGuardModelsListDefaultExclusions(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	deployment := func(name, app string) *appsv1.Deployment {
+		labels := map[string]string{"app": app}
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kubetest.Namespace, Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+				},
+			},
+		}
+	}
+	kc := utils.NewKubernetesCheckerForClients(kubetest.NewClientset(
+		deployment("dynamoai-moderation", "guard"),
+		deployment("llama-3-8b", "model"),
+	), kubetest.NewDynamicClient(nil))
+
+	for _, tc := range []struct {
+		args []string
+		want []string
+		skip []string
+	}{
+		{args: nil, want: []string{"llama-3-8b"}, skip: []string{"dynamoai-moderation"}},
+		{args: []string{"--include", "dynamoai-moderation"}, want: []string{"dynamoai-moderation"}, skip: []string{"llama-3-8b"}},
+		{args: []string{"--selector", "app=guard"}, want: []string{"dynamoai-moderation"}, skip: []string{"llama-3-8b"}},
+	} {
+		rootCmd := &cobra.Command{SilenceUsage: true}
+		AddGuardCommands(rootCmd)
+		out := new(bytes.Buffer)
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"guard", "models", "list", "-n", kubetest.Namespace}, tc.args...))
+		err := rootCmd.ExecuteContext(utils.WithKubeClients(context.Background(), utils.NewKubeClientsFor(kc)))
+		if !assert.NoError(t, err, "args %v", tc.args) {
+			continue
+		}
+		for _, name := range tc.want {
+			assert.Contains(t, out.String(), name, "args %v", tc.args)
+		}
+		for _, name := range tc.skip {
+			assert.NotContains(t, out.String(), name, "args %v", tc.args)
+		}
+	}
+}
+
+func TestRenderMultiNamespaceWorkloads(t *testing.T) {
+	workload := func(name string, replicas int32, cpu, mem, gpu string) utils.DeploymentResourceSummary {
+		return utils.DeploymentResourceSummary{Kind: utils.WorkloadKindDeployment, Name: name, Replicas: replicas, ReadyReplicas: replicas, AvailableReplicas: replicas, Containers: []utils.ContainerResourceSummary{
+			{Name: "main", RequestsCPU: cpu, RequestsMemory: mem, RequestsGPU: gpu, LimitsCPU: cpu, LimitsMemory: mem, LimitsGPU: gpu},
+		}}
+	}
+	groups := func() []namespaceWorkloads {
+		return []namespaceWorkloads{
+			{Namespace: "prod", Workloads: []utils.DeploymentResourceSummary{workload("guard-api", 2, "500m", "1Gi", ""), workload("guard-worker", 1, "2", "8Gi", "1")}},
+			{Namespace: "staging", Workloads: []utils.DeploymentResourceSummary{workload("guard-worker", 1, "2", "8Gi", "1")}},
+		}
+	}
+
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	assert.NoError(t, renderMultiNamespaceWorkloads(cmd, groups(), "csv", workloadListOptions{}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 7, "header, 3 workloads, 2 namespace totals, and the grand total")
+	assert.Equal(t, "prod,TOTAL,3,3,3,3,10Gi,1,3,10Gi,1,,", lines[3])
+	assert.Equal(t, ",GRAND TOTAL,4,4,4,5,18Gi,2,5,18Gi,2,,", lines[6])
+
+	buf.Reset()
+	assert.NoError(t, renderMultiNamespaceWorkloads(cmd, groups(), "table", workloadListOptions{}))
+	out := buf.String()
+	assert.Contains(t, out, "Namespace: prod")
+	assert.Contains(t, out, "Namespace: staging")
+	assert.Contains(t, out, "Total across 2 namespaces")
+	assert.Regexp(t, `TOTAL\s+3\s+4\s+4\s+5\s+18Gi\s+2`, out)
+
+	buf.Reset()
+	assert.NoError(t, renderMultiNamespaceWorkloads(cmd, groups(), "json", workloadListOptions{}))
+	var report multiNamespaceReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Len(t, report.Namespaces, 2)
+	assert.Equal(t, int64(4), report.Total.Replicas)
+	assert.Equal(t, "desired", report.Total.CountedFrom)
+	assert.Equal(t, 1, report.Namespaces[1].Total.Workloads)
+}
+
+func TestRenderWorkloadSummariesUseReady(t *testing.T) {
+	// A rollout in progress and a deployment scaled to zero
+	deployments := []utils.DeploymentResourceSummary{
+		{Kind: utils.WorkloadKindDeployment, Name: "guard-api", Replicas: 3, ReadyReplicas: 1, AvailableReplicas: 1, Containers: []utils.ContainerResourceSummary{
+			{Name: "main", RequestsCPU: "1", RequestsMemory: "2Gi", LimitsCPU: "1", LimitsMemory: "2Gi"},
+		}},
+		{Kind: utils.WorkloadKindDeployment, Name: "guard-idle", Replicas: 0, Containers: []utils.ContainerResourceSummary{
+			{Name: "main", RequestsCPU: "4", RequestsMemory: "8Gi", LimitsCPU: "4", LimitsMemory: "8Gi"},
+		}},
+	}
+
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	assert.NoError(t, renderWorkloadSummaries(cmd, "prod", deployments, "csv", workloadListOptions{}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "prod,guard-idle,0,0,0,4,8Gi,0,4,8Gi,0,Deployment,", lines[2])
+	assert.Equal(t, "prod,TOTAL,3,1,1,3,6Gi,0,3,6Gi,0,,", lines[3])
+
+	buf.Reset()
+	assert.NoError(t, renderWorkloadSummaries(cmd, "prod", deployments, "csv", workloadListOptions{useReady: true}))
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "prod,TOTAL (ready),3,1,1,1,2Gi,0,1,2Gi,0,,", lines[3])
+}
+
+func TestRenderWorkloadSummariesShowCost(t *testing.T) {
+	deployments := []utils.DeploymentResourceSummary{
+		{Kind: utils.WorkloadKindDeployment, Name: "guard-worker", Replicas: 2, ReadyReplicas: 2, AvailableReplicas: 2, Containers: []utils.ContainerResourceSummary{
+			{Name: "main", RequestsCPU: "2", RequestsMemory: "10Gi", RequestsGPU: "1", LimitsCPU: "2", LimitsMemory: "10Gi", LimitsGPU: "1"},
+		}},
+	}
+	opts := workloadListOptions{pricing: &utils.Pricing{Currency: "USD", CPUCoreHour: 0.01, MemoryGBHour: 0.001, GPUHour: 1}}
+
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	assert.NoError(t, renderWorkloadSummaries(cmd, "prod", deployments, "csv", opts))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.True(t, strings.HasSuffix(lines[0], ",accelerators,monthly_cost"))
+	// 2 replicas x (2 cores x 0.01 + 10GB x 0.001 + 1 GPU x 1) x 730 hours
+	assert.True(t, strings.HasSuffix(lines[1], ",1503.80"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], ",1503.80"), lines[2])
+	assert.Equal(t, 1503.8, math.Round(deployments[0].MonthlyCost*100)/100)
+}
+
+func TestGuardModelsListOutputFile(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-o", "xlsx"}, "--output xlsx requires --output-file"},
+		{[]string{"-o", "csv", "--output-file", "report.xlsx"}, "--output-file is only used with --output xlsx"},
+		{[]string{"-o", "xlsx", "--output-file", "report.xlsx", "--per-pod"}, "cannot be combined with --per-pod or --containers"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddGuardCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"guard", "models", "list", "-n", "dynamo"}, tt.args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", tt.args) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestGuardConfigShowFlags(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--diff", "--snapshot"}, "--diff and --snapshot cannot be used together"},
+		{[]string{"--diff"}, "--diff needs the release defaults"},
+		{[]string{"--resource", "guardpolicies"}, "use resource.group/version"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddGuardCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"guard", "config", "show", "-n", "dynamo"}, tt.args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", tt.args) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestRenderGuardConfigDiff(t *testing.T) {
+	diffs := []utils.GuardConfigDiff{
+		{GuardConfigEntry: utils.GuardConfigEntry{Section: utils.GuardConfigRouting, Source: "ConfigMap/guard", Key: "routing.default_model", Value: "guard-70b"}, Default: "guard-8b", Status: utils.GuardConfigChanged},
+		{GuardConfigEntry: utils.GuardConfigEntry{Section: utils.GuardConfigPolicies, Source: "ConfigMap/guard", Key: "policies.pii.enabled"}, Default: "true", Status: utils.GuardConfigMissing},
+	}
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	assert.NoError(t, renderGuardConfigDiff(cmd, "prod", diffs, "table"))
+	out := buf.String()
+	assert.Contains(t, out, "MODEL ROUTING")
+	assert.Contains(t, out, "POLICIES")
+	assert.NotContains(t, out, "RATE LIMITS")
+	assert.Regexp(t, `changed\s+routing.default_model\s+guard-70b\s+guard-8b`, out)
+	assert.Contains(t, out, "2 setting(s) differ from the release defaults (1 changed, 0 added, 1 missing)")
+
+	buf.Reset()
+	assert.NoError(t, renderGuardConfigDiff(cmd, "prod", nil, "table"))
+	assert.Contains(t, buf.String(), "matches the release defaults")
+}
+
+func TestRenderGuardDeps(t *testing.T) {
+	statuses := []utils.GuardIntegrationStatus{
+		{Integration: "database", Healthy: true,
+			Secrets:     []utils.SecretCheckResult{{Integration: "database", Secret: "guard-db-credentials", Key: "password", Status: utils.SecretOK}},
+			Connections: []utils.DependencyResult{{Name: "postgres", Type: utils.DependencyPostgres, Target: "db:5432", Status: utils.DependencyOK, Message: "authenticated"}}},
+		{Integration: "model-api",
+			Secrets:     []utils.SecretCheckResult{{Integration: "model-api", Secret: "guard-api-tokens", Key: "openai-api-key", Status: utils.SecretEmpty, Message: "value is empty"}},
+			Connections: []utils.DependencyResult{{Name: "openai", Type: utils.DependencyHTTP, Status: utils.DependencySkipped, Message: "not probed"}}},
+	}
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+
+	err := renderGuardDeps(cmd, "guard", statuses, "table")
+	assert.EqualError(t, err, "1 of 2 integrations failing: model-api")
+	out := buf.String()
+	assert.Regexp(t, `database\s+secret\s+guard-db-credentials/password\s+ok`, out)
+	assert.Contains(t, out, "✓ database")
+	assert.Contains(t, out, "✗ model-api: Secret guard-api-tokens key openai-api-key is empty")
+
+	buf.Reset()
+	assert.NoError(t, renderGuardDeps(cmd, "guard", statuses[:1], "csv"))
+	assert.Contains(t, buf.String(), "database,connection,postgres,db:5432,ok,authenticated")
+}
+
+func TestGuardDepsCheckRequiresConfig(t *testing.T) {
+	t.Setenv("DYNACTL_CONFIG", t.TempDir()+"/config.yaml")
+	rootCmd := &cobra.Command{}
+	AddGuardCommands(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"guard", "deps", "check", "-n", "guard"})
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "guard.deps_config")
+}
+
+func TestDefaultLocalPort(t *testing.T) {
+	assert.Equal(t, uint16(8080), defaultLocalPort("dynamoai-api"))
+	assert.Equal(t, uint16(0), defaultLocalPort("my-model"), "unknown services should get a free port")
+}
+
+func TestGuardModelsStageOverwriteNeedsConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(dir+"/guard-8b-1.2.0.tar", []byte("x"), 0644))
+
+	rootCmd := &cobra.Command{}
+	AddGuardCommands(rootCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetIn(strings.NewReader(""))
+	rootCmd.SetArgs([]string{"guard", "models", "stage", "-n", "guard", "--pvc", "model-cache", "--model", "guard-8b", "--artifacts-dir", dir, "--overwrite"})
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "replace the contents of guard-8b in PVC guard/model-cache")
+}
+
+func TestGuardModelsWarmupValidation(t *testing.T) {
+	run := func(args ...string) error {
+		rootCmd := &cobra.Command{}
+		AddGuardCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"guard", "models", "warmup", "guard-worker", "-n", "prod"}, args...))
+		return rootCmd.Execute()
+	}
+	assert.ErrorContains(t, run(), `required flag(s) "threshold" not set`)
+	assert.ErrorContains(t, run("--threshold", "500ms", "--max-error-rate", "5"), "--max-error-rate must be between 0 and 1")
+	assert.ErrorContains(t, run("--threshold", "500ms", "--requests", "0"), "--requests and --concurrency must be greater than zero")
+}
+
+func TestRenderWarmupResult(t *testing.T) {
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	renderWarmupResult(cmd, "guard-worker", &utils.WarmupResult{
+		Ready:     true,
+		Threshold: 500 * time.Millisecond,
+		Rounds:    []utils.WarmupRound{{Round: 1, P95: 2 * time.Second}, {Round: 2, P95: 312 * time.Millisecond, Stable: true}},
+		Duration:  14 * time.Second,
+	})
+	assert.Contains(t, buf.String(), "✓ guard-worker is ready after 2 round(s) in 14s (last p95 312ms, threshold 500ms)")
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"github.com/dynamofl/dynactl/pkg/output"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// addPlanFlags registers the flags of commands whose --dry-run prints a plan
+func addPlanFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().Bool("dry-run", false, usage)
+	cmd.Flags().StringP("output", "o", output.FormatTable, "Format of the --dry-run plan: table, wide, json, yaml, or csv")
+}
+
+// renderPlan writes a dry-run plan in the -o format, with its totals under tables
+func renderPlan(cmd *cobra.Command, plan *utils.Plan) error {
+	format, _ := cmd.Flags().GetString("output")
+	if err := output.Render(cmd.OutOrStdout(), format, output.PlanTable(plan)); err != nil {
+		return err
+	}
+	if output.IsTabular(format) {
+		output.WritePlanSummary(cmd.OutOrStdout(), plan)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/dynamofl/dynactl/pkg/output"
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// AddPluginCommands registers the plugin commands with the root command.
+func AddPluginCommands(rootCmd *cobra.Command) {
+	pluginCmd := &cobra.Command{
+		Use:     "plugin",
+		Aliases: []string{"plugins"},
+		Short:   "Inspect dynactl plugins",
+		Long: `Any executable on PATH named dynactl-<name> can be run as "dynactl <name>".
+Dashes in the filename become nested commands, so dynactl-foo-bar runs as "dynactl foo bar".
+Built-in commands always take precedence over plugins with the same name.
+
+Plugins inherit the environment plus:
+  DYNACTL_BIN               path of the dynactl binary
+  DYNACTL_CONFIG            dynactl config file
+  DYNACTL_CREDENTIAL_STORE  registry credentials saved by "dynactl registry login"
+  DYNACTL_LOG_LEVEL         error, warning, info, or debug
+  DYNACTL_KUBECONFIG        kubeconfig files in load order
+  DYNACTL_KUBE_CONTEXT      current kubeconfig context`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List plugins found on PATH",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, _ := cmd.Flags().GetString("output")
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			plugins := utils.ListPlugins()
+			if plugins == nil {
+				plugins = []utils.Plugin{}
+			}
+			table := &output.Table{
+				Columns: []output.Column{
+					{Header: "NAME", CSV: "name"},
+					{Header: "PATH", CSV: "path"},
+					{Header: "STATUS", CSV: "status"},
+				},
+				Data: plugins,
+			}
+			for _, p := range plugins {
+				status := "ok"
+				if p.ShadowedBy != "" {
+					status = "shadowed by " + p.ShadowedBy
+				} else if shadowsBuiltin(rootCmd, p.Name) {
+					status = "ignored: conflicts with a built-in command"
+				}
+				table.AddRow(p.Name, p.Path, status)
+			}
+
+			if output.IsTabular(outputFormat) && len(plugins) == 0 {
+				cmd.Println("No plugins found on PATH (executables named " + utils.PluginPrefix + "<name>)")
+				return nil
+			}
+			return renderer.Render(cmd.OutOrStdout(), table)
+		},
+	}
+	listCmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+
+	pluginCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
+
+// DispatchPlugin runs the plugin named by args when they do not match a built-in command. It
+// reports whether a plugin handled the invocation. Root flags such as -v may come before the
+// plugin name; they are applied as for a built-in command, so the plugin's environment reflects
+// them.
+func DispatchPlugin(ctx context.Context, rootCmd *cobra.Command, args []string) (bool, error) {
+	rest, ok := skipRootFlags(rootCmd, args)
+	if !ok || len(rest) == 0 || shadowsBuiltin(rootCmd, rest[0]) {
+		return false, nil
+	}
+	path, pluginArgs, ok := utils.FindPlugin(rest)
+	if !ok {
+		return false, nil
+	}
+	if err := rootCmd.PersistentFlags().Parse(args[:len(args)-len(rest)]); err != nil {
+		return true, err
+	}
+	if rootCmd.PersistentPreRunE != nil {
+		rootCmd.SetContext(ctx)
+		if err := rootCmd.PersistentPreRunE(rootCmd, pluginArgs); err != nil {
+			return true, err
+		}
+	}
+	return true, utils.RunPlugin(ctx, path, pluginArgs)
+}
+
+// skipRootFlags returns the arguments after the root persistent flags leading args. The flags
+// are parsed into a throwaway set, so nothing is applied until a plugin is known to run; an
+// unknown flag, such as --help, leaves the invocation to cobra.
+func skipRootFlags(rootCmd *cobra.Command, args []string) ([]string, bool) {
+	flags := pflag.NewFlagSet(rootCmd.Name(), pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.SetInterspersed(false)
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		flags.AddFlag(&pflag.Flag{Name: f.Name, Shorthand: f.Shorthand, NoOptDefVal: f.NoOptDefVal, Value: discardValue(f.Value.Type())})
+	})
+	flags.SetNormalizeFunc(rootCmd.GlobalNormalizationFunc())
+	if err := flags.Parse(args); err != nil {
+		return nil, false
+	}
+	return flags.Args(), true
+}
+
+// discardValue accepts any value for a flag of the named type
+type discardValue string
+
+func (v discardValue) String() string   { return "" }
+func (v discardValue) Set(string) error { return nil }
+func (v discardValue) Type() string     { return string(v) }
+
+// shadowsBuiltin reports whether a plugin name, or its first segment, is a built-in command,
+// alias, or flag, in which case the plugin is never run
+func shadowsBuiltin(rootCmd *cobra.Command, name string) bool {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return true
+	}
+	first, _, _ := strings.Cut(name, "-")
+	switch first {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		for _, n := range []string{name, first} {
+			if c.Name() == n || c.HasAlias(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
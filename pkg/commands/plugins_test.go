@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are shell scripts")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"dynactl-cluster", "dynactl-backup"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\nexit 0\n"), 0o755))
+	}
+	t.Setenv("PATH", dir)
+
+	rootCmd := &cobra.Command{Use: "dynactl"}
+	AddClusterCommands(rootCmd)
+	AddPluginCommands(rootCmd)
+
+	handled, err := DispatchPlugin(context.Background(), rootCmd, []string{"cluster", "check"})
+	assert.False(t, handled, "built-in commands win over plugins")
+	assert.NoError(t, err)
+
+	handled, err = DispatchPlugin(context.Background(), rootCmd, []string{"cl"})
+	assert.False(t, handled, "aliases win over plugins")
+	assert.NoError(t, err)
+
+	handled, err = DispatchPlugin(context.Background(), rootCmd, []string{"backup", "--now"})
+	assert.True(t, handled)
+	assert.NoError(t, err)
+
+	handled, _ = DispatchPlugin(context.Background(), rootCmd, []string{"unknown"})
+	assert.False(t, handled)
+}
+
+func TestDispatchPluginRootFlags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are shell scripts")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" + `test "$DYNACTL_LOG_LEVEL" = debug -a "$1" = -v -a "$DYNACTL_PROFILE" = transfer || exit 4` + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "dynactl-hello"), []byte(script), 0o755))
+	t.Setenv("PATH", dir)
+	t.Setenv("DYNACTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("AWS_PROFILE", "transfer")
+	defer utils.SetLogLevel(0)
+
+	var verbose int
+	var timeout time.Duration
+	newRoot := func() *cobra.Command {
+		verbose, timeout = 0, 0
+		rootCmd := &cobra.Command{
+			Use: "dynactl",
+			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+				utils.SetLogLevel(verbose)
+				return nil
+			},
+		}
+		rootCmd.PersistentFlags().IntVarP(&verbose, "verbose", "v", 0, "")
+		rootCmd.PersistentFlags().DurationVar(&timeout, "request-timeout", 0, "")
+		AddPluginCommands(rootCmd)
+		return rootCmd
+	}
+
+	// Root flags before the name are applied; flags after it belong to the plugin
+	handled, err := DispatchPlugin(context.Background(), newRoot(), []string{"-v", "2", "--request-timeout", "5s", "hello", "-v"})
+	assert.True(t, handled)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, verbose)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	// Nothing is applied when the arguments are left to cobra
+	for _, args := range [][]string{{"-v", "2", "plugin", "list"}, {"--help"}, {"-v", "2", "missing"}, {"-v", "2"}} {
+		handled, _ = DispatchPlugin(context.Background(), newRoot(), args)
+		assert.False(t, handled, "%v", args)
+		assert.Zero(t, verbose, "%v", args)
+	}
+}
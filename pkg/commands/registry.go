@@ -3,9 +3,12 @@ package commands
 import (
 	"bufio"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/dynamofl/dynactl/pkg/output"
 	"github.com/dynamofl/dynactl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -13,16 +16,18 @@ import (
 // AddRegistryCommands registers registry related commands with the root command.
 func AddRegistryCommands(rootCmd *cobra.Command) {
 	registryCmd := &cobra.Command{
-		Use:   "registry",
-		Short: "Manage OCI registry credentials",
-		Long:  "Manage authentication credentials used when accessing OCI registries.",
+		Use:     "registry",
+		Aliases: []string{"reg"},
+		Short:   "Manage OCI registry credentials and serve pulled images",
+		Long:    "Manage authentication credentials used when accessing OCI registries, or serve pulled images as a local registry.",
 	}
 
 	loginCmd := &cobra.Command{
-		Use:   "login <registry>",
-		Short: "Store credentials for an OCI registry",
-		Long:  "Store credentials for the given OCI registry so dynactl can authenticate without relying on external CLIs.",
-		Args:  cobra.ExactArgs(1),
+		Use:         "login <registry>",
+		Short:       "Store credentials for an OCI registry",
+		Long:        "Store credentials for the given OCI registry so dynactl can authenticate without relying on external CLIs.",
+		Annotations: audited,
+		Args:        cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			registry := args[0]
 			username, _ := cmd.Flags().GetString("username")
@@ -74,6 +79,118 @@ func AddRegistryCommands(rootCmd *cobra.Command) {
 	loginCmd.Flags().String("identity-token", "", "Identity (refresh) token for registry authentication")
 	loginCmd.Flags().String("access-token", "", "Access token for registry authentication")
 
-	registryCmd.AddCommand(loginCmd)
+	logoutCmd := &cobra.Command{
+		Use:         "logout <registry>",
+		Short:       "Remove stored credentials for an OCI registry",
+		Long:        "Deletes the credentials for the given registry from the dynactl credential store. Credentials in Docker, Podman, or ORAS stores are not touched.",
+		Annotations: audited,
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry := args[0]
+			if _, ok, err := utils.GetRegistryCredential(registry); err != nil {
+				return err
+			} else if !ok {
+				cmd.Printf("No stored credentials for %s\n", registry)
+				return nil
+			}
+			if err := confirm(cmd, fmt.Sprintf("remove the stored credentials for %s", registry)); err != nil {
+				return err
+			}
+
+			if _, err := utils.RemoveRegistryCredential(registry); err != nil {
+				return err
+			}
+			cmd.Printf("✓ Removed credentials for %s\n", registry)
+			return nil
+		},
+	}
+	addYesFlag(logoutCmd)
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List registries with stored credentials",
+		Long:    "Lists the registries in the dynactl credential store with the username and kind of credential saved. Secrets are never printed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, _ := cmd.Flags().GetString("output")
+			renderer, err := output.NewRenderer(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			registries, err := utils.ListRegistryCredentials()
+			if err != nil {
+				return err
+			}
+			if len(registries) == 0 && output.IsTabular(outputFormat) {
+				cmd.Println("No stored registry credentials. Use `dynactl registry login` to add one.")
+				return nil
+			}
+
+			table := &output.Table{
+				Columns: []output.Column{
+					{Header: "REGISTRY", CSV: "registry"},
+					{Header: "USERNAME", CSV: "username"},
+					{Header: "AUTH", CSV: "auth_type"},
+				},
+				Data: registries,
+			}
+			for _, r := range registries {
+				table.AddRow(r.Registry, r.Username, r.AuthType)
+			}
+			return renderer.Render(cmd.OutOrStdout(), table)
+		},
+	}
+	listCmd.Flags().StringP("output", "o", "table", output.FlagUsage)
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve pulled images as a read-only registry",
+		Long: `Serves the image archives in a pulled artifacts directory over the OCI distribution API
+so a cluster inside the air gap can pull from this machine during installation. Images are
+served under their original repository path, e.g. <host>:5000/dynamoai/3.22.2/images/api:1.0.
+The registry is read-only and runs until interrupted.
+
+Without --tls-cert and --tls-key the registry speaks plain HTTP, so nodes must list it as an
+insecure registry (e.g. in containerd's hosts.toml or k3s registries.yaml).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := cmd.Flags().GetString("dir")
+			address, _ := cmd.Flags().GetString("address")
+			port, _ := cmd.Flags().GetInt("port")
+			tlsCert, _ := cmd.Flags().GetString("tls-cert")
+			tlsKey, _ := cmd.Flags().GetString("tls-key")
+
+			if (tlsCert == "") != (tlsKey == "") {
+				return fmt.Errorf("--tls-cert and --tls-key must be set together")
+			}
+			server, err := utils.NewRegistryServer(dir)
+			if err != nil {
+				return err
+			}
+			listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen on port %d: %w", port, err)
+			}
+
+			scheme := "http"
+			if tlsCert != "" {
+				scheme = "https"
+			}
+			refs := server.Repositories()
+			cmd.Printf("Serving %d images from %s at %s://%s\n", len(refs), dir, scheme, listener.Addr())
+			for _, ref := range refs {
+				cmd.Printf("  %s\n", ref)
+			}
+			cmd.Printf("Press Ctrl+C to stop\n")
+			return server.Serve(cmd.Context(), listener, tlsCert, tlsKey)
+		},
+	}
+	serveCmd.Flags().String("dir", "./artifacts", "Directory holding the pulled image archives")
+	serveCmd.Flags().String("address", "", "Address to listen on (default: all interfaces)")
+	serveCmd.Flags().Int("port", 5000, "Port to listen on")
+	serveCmd.Flags().String("tls-cert", "", "TLS certificate file; serves HTTPS when set with --tls-key")
+	serveCmd.Flags().String("tls-key", "", "TLS private key file")
+
+	registryCmd.AddCommand(loginCmd, logoutCmd, listCmd, serveCmd)
 	rootCmd.AddCommand(registryCmd)
 }
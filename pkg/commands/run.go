@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// AddRunCommands registers the run command with the root command.
+func AddRunCommands(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(createRunCmd())
+}
+
+func createRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run --in-cluster -n <namespace> --image <image> -- <command...>",
+		Short: "Run a dynactl command as a Kubernetes Job inside the cluster",
+		Long: `Runs a long dynactl command, such as artifacts mirror or backup create, as a Job in the
+namespace, close to the registry and the cluster, instead of on a laptop or bastion. The Job's
+output is streamed here as it runs; the command exits non-zero when the Job fails.
+
+The Job runs the dynactl on the PATH of --image as --service-account, which needs whatever
+permissions the command does. $HOME and the working directory are /work, an emptyDir unless
+--work-pvc keeps it on a PVC (e.g. a mirror --cache-dir to --resume from). Registry credentials
+come from --docker-config-secret, a kubernetes.io/dockerconfigjson Secret; --secret-mount
+mounts other Secrets, such as a CA bundle for --ca-file. The Job never retries, fails after
+--timeout, and is deleted once it finishes unless --keep is set.
+
+Everything after -- is the dynactl command line, e.g.:
+
+  dynactl run --in-cluster -n dynamo --image registry.example.com/dynactl:v0.2.3 \
+    --docker-config-secret registry-creds -- \
+    artifacts mirror --url artifacts.dynamo.ai/dynamoai/manifest:3.22.2 --target-registry harbor.example.com/dynamo
+
+With --dry-run the Job is printed as YAML instead of run.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inCluster, _ := cmd.Flags().GetBool("in-cluster")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			image, _ := cmd.Flags().GetString("image")
+			serviceAccount, _ := cmd.Flags().GetString("service-account")
+			dockerConfigSecret, _ := cmd.Flags().GetString("docker-config-secret")
+			secretMounts, _ := cmd.Flags().GetStringToString("secret-mount")
+			pullSecrets, _ := cmd.Flags().GetStringSlice("pull-secret")
+			cpu, _ := cmd.Flags().GetString("cpu")
+			memory, _ := cmd.Flags().GetString("memory")
+			workPVC, _ := cmd.Flags().GetString("work-pvc")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			keep, _ := cmd.Flags().GetBool("keep")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			if !inCluster {
+				return fmt.Errorf("--in-cluster is required; it is the only place run can run commands")
+			}
+			if cmd.ArgsLenAtDash() != 0 {
+				return fmt.Errorf("put the dynactl command to run after --, e.g. dynactl run --in-cluster -n %s --image <image> -- backup create -n %s", namespace, namespace)
+			}
+
+			job, err := utils.NewRunJob(utils.RunJobOptions{
+				Namespace:          namespace,
+				Image:              image,
+				ServiceAccount:     serviceAccount,
+				Args:               args,
+				DockerConfigSecret: dockerConfigSecret,
+				SecretMounts:       secretMounts,
+				PullSecrets:        pullSecrets,
+				CPU:                cpu,
+				Memory:             memory,
+				WorkPVC:            workPVC,
+				Timeout:            timeout,
+			})
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				data, err := utils.RenderRunJob(job)
+				if err != nil {
+					return err
+				}
+				cmd.Print(string(data))
+				return nil
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			cmd.Printf("=== Running dynactl %s in %s ===\n", job.Annotations["dynactl.dynamo.ai/command"], namespace)
+			result, err := kc.RunJob(ctx, job, keep, cmd.OutOrStdout())
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			if !result.Succeeded {
+				cmd.Printf("✗ Job %s failed after %s with exit code %d\n", result.Job, result.Duration, result.ExitCode)
+				if result.Message != "" {
+					cmd.Printf("    %s\n", result.Message)
+				}
+				return fmt.Errorf("in-cluster run of Job %s failed with exit code %d", result.Job, result.ExitCode)
+			}
+			cmd.Printf("✓ Job %s finished in %s\n", result.Job, result.Duration)
+			if keep {
+				cmd.Printf("Kept Job %s/%s; it is removed a day after finishing\n", namespace, result.Job)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("in-cluster", false, "Run the command as a Job in the cluster (required)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace to run the Job in (required)")
+	_ = cmd.MarkFlagRequired("namespace")
+	cmd.Flags().String("image", "", "Image with dynactl on its PATH to run the command with (required)")
+	_ = cmd.MarkFlagRequired("image")
+	cmd.Flags().String("service-account", "", "ServiceAccount the Job runs as (default: the namespace's default)")
+	cmd.Flags().String("docker-config-secret", "", "kubernetes.io/dockerconfigjson Secret holding the registry credentials the command uses")
+	cmd.Flags().StringToString("secret-mount", nil, "Mount a Secret at a path, as name=/path (repeatable)")
+	cmd.Flags().StringSlice("pull-secret", nil, "imagePullSecret for --image (repeatable)")
+	cmd.Flags().String("cpu", utils.DefaultRunJobCPU, "CPU request and limit of the Job")
+	cmd.Flags().String("memory", utils.DefaultRunJobMemory, "Memory request and limit of the Job")
+	cmd.Flags().String("work-pvc", "", "PVC to mount at /work instead of an emptyDir")
+	cmd.Flags().Duration("timeout", utils.DefaultRunJobTimeout, "How long the Job may run before it is failed")
+	cmd.Flags().Bool("keep", false, "Keep the Job after it finishes instead of deleting it")
+	cmd.Flags().Bool("dry-run", false, "Print the Job as YAML instead of running it")
+
+	return cmd
+}
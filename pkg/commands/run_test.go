@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInClusterDryRun(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddRunCommands(rootCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"run", "--in-cluster", "-n", "dynamo", "--image", "dynactl:v1", "--dry-run", "--", "backup", "create", "-n", "dynamo", "--components", "database"})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Contains(t, buf.String(), "kind: Job")
+	assert.Contains(t, buf.String(), "- --components")
+}
+
+func TestRunNeedsDashAndInCluster(t *testing.T) {
+	for _, args := range [][]string{
+		{"run", "-n", "dynamo", "--image", "dynactl:v1", "--", "backup", "list"},
+		{"run", "--in-cluster", "-n", "dynamo", "--image", "dynactl:v1", "backup", "list"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddRunCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(args)
+		assert.Error(t, rootCmd.Execute(), "%v should fail", args)
+	}
+}
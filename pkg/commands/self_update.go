@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// selfUpdateTimeout bounds the release lookup and download
+const selfUpdateTimeout = 5 * time.Minute
+
+// AddSelfUpdateCommands registers the self-update command with the root command.
+func AddSelfUpdateCommands(rootCmd *cobra.Command) {
+	selfUpdateCmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update dynactl to the latest release",
+		Long: `Download the latest dynactl release for this platform, verify its SHA-256 checksum
+against the release's checksums.txt, and replace the running binary.
+
+The stable channel only considers full releases; beta also includes prereleases. The default
+channel can be set with update.channel in ~/.dynactl/config.yaml.`,
+		Annotations: audited,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			channel, _ := cmd.Flags().GetString("channel")
+			checkOnly, _ := cmd.Flags().GetBool("check")
+			force, _ := cmd.Flags().GetBool("force")
+			if !cmd.Flags().Changed("channel") {
+				channel = utils.UpdateChannel()
+			}
+			current := strings.TrimPrefix(rootCmd.Version, "v")
+
+			ctx := cmd.Context()
+			client := &http.Client{Timeout: selfUpdateTimeout}
+			release, err := utils.LatestRelease(ctx, client, channel)
+			if err != nil {
+				return err
+			}
+
+			if !force && !utils.IsNewerVersion(current, release.Version()) {
+				cmd.Printf("✓ dynactl v%s is up to date (latest %s release: %s)\n", current, channel, release.Tag)
+				return nil
+			}
+			cmd.Printf("! dynactl %s is available on the %s channel (current v%s)\n", release.Tag, channel, current)
+			if checkOnly {
+				return nil
+			}
+
+			if err := confirm(cmd, fmt.Sprintf("replace dynactl v%s with %s", current, release.Tag)); err != nil {
+				return err
+			}
+
+			binary, err := utils.DownloadReleaseBinary(ctx, client, release)
+			if err != nil {
+				return fmt.Errorf("failed to download %s: %w", release.Tag, err)
+			}
+			path, err := utils.ReplaceExecutable(binary)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("✓ Updated %s to %s\n", path, release.Tag)
+			return nil
+		},
+	}
+	selfUpdateCmd.Flags().String("channel", utils.ChannelStable, fmt.Sprintf("Release channel to update from (%s)", strings.Join(utils.ReleaseChannels, ", ")))
+	selfUpdateCmd.Flags().Bool("check", false, "Only report whether a newer release is available")
+	selfUpdateCmd.Flags().Bool("force", false, "Reinstall even if the current version is the latest")
+	addYesFlag(selfUpdateCmd)
+	_ = selfUpdateCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(utils.ReleaseChannels, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// AddTelemetryCommands registers the telemetry commands with the root command.
+func AddTelemetryCommands(rootCmd *cobra.Command) {
+	telemetryCmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage opt-in usage reporting",
+		Long: `Usage reporting is off unless you turn it on. When on, dynactl reports the name of each
+command it runs, how long it took, whether it succeeded, and the dynactl version, OS, and
+architecture, tagged with a random install ID. Arguments, flag values, error messages, and
+cluster details are never sent.
+
+Events are queued in ~/.dynactl and sent in the background with a short timeout, so dynactl
+works the same offline. DYNACTL_TELEMETRY=0, DO_NOT_TRACK=1, or telemetry.disabled in
+~/.dynactl/config.yaml turn reporting off regardless of this setting; telemetry.endpoint
+changes where events are posted.`,
+	}
+
+	onCmd := &cobra.Command{
+		Use:   "on",
+		Short: "Turn usage reporting on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.SetTelemetry(true); err != nil {
+				return err
+			}
+			status := utils.GetTelemetryStatus()
+			if !status.Enabled {
+				fmt.Printf("! Telemetry is turned on but stays disabled by %s\n", status.Source)
+				return nil
+			}
+			fmt.Printf("✓ Telemetry enabled; events are sent to %s\n", status.Endpoint)
+			return nil
+		},
+	}
+
+	offCmd := &cobra.Command{
+		Use:   "off",
+		Short: "Turn usage reporting off and discard unsent events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.SetTelemetry(false); err != nil {
+				return err
+			}
+			fmt.Println("✓ Telemetry disabled")
+			return nil
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether usage reporting is on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status := utils.GetTelemetryStatus()
+			state := "disabled"
+			if status.Enabled {
+				state = "enabled"
+			}
+			fmt.Printf("Telemetry:  %s (%s)\n", state, status.Source)
+			fmt.Printf("Endpoint:   %s\n", status.Endpoint)
+			if status.InstallID != "" {
+				fmt.Printf("Install ID: %s\n", status.InstallID)
+			}
+			fmt.Printf("Queued:     %d event(s)\n", status.Queued)
+			return nil
+		},
+	}
+
+	telemetryCmd.AddCommand(onCmd, offCmd, statusCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
+
+// EnableTelemetry wraps every command so its name, duration, and result are queued for usage
+// reporting when the user has opted in. Call it once all commands are registered.
+func EnableTelemetry(root *cobra.Command) {
+	var walk func(*cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		switch cmd.Name() {
+		case "telemetry", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return
+		}
+		if cmd.RunE != nil {
+			cmd.RunE = telemetryRun(root, cmd.RunE)
+		}
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(root)
+}
+
+func telemetryRun(root *cobra.Command, run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		err := run(cmd, args)
+		utils.RecordTelemetry(cmd.CommandPath(), root.Version, time.Since(start), err == nil)
+		return err
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// AddUpgradeCommands registers upgrade related commands with the root command.
+func AddUpgradeCommands(rootCmd *cobra.Command) {
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Prepare Dynamo AI upgrades",
+		Long:  "Commands that check a Dynamo AI installation is ready to be upgraded to a new release.",
+	}
+
+	upgradeCmd.AddCommand(createUpgradePrecheckCmd())
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func createUpgradePrecheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "precheck",
+		Short: "Report whether a namespace is ready to upgrade to a release",
+		Long: `Cross-references what is installed in the namespace with the manifest of the target release
+(--to) and prints a single go/no-go report:
+
+  manifest          this dynactl supports the target manifest, and it is newer than what is installed
+  releases          the Helm releases are deployed (not failed or pending) and no chart goes backwards
+  artifacts         the charts, images, and models that are added, changed, or removed
+  resources         the target sizing profile (--profile) fits once the current pods are replaced
+  api-deprecations  the installed releases use no API versions the cluster has removed
+  license           the target manifest's license has not expired and the license Secret exists
+
+The installed release is read from the cluster with --from-current: the charts of the Helm
+releases and the images the namespace's pods run. Pass the manifest it was installed from with
+--from instead to also compare models. Exits non-zero on a no-go; warnings do not block.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			toPath, _ := cmd.Flags().GetString("to")
+			fromPath, _ := cmd.Flags().GetString("from")
+			fromCurrent, _ := cmd.Flags().GetBool("from-current")
+			profilePath, _ := cmd.Flags().GetString("profile")
+			licenseWarning, _ := cmd.Flags().GetDuration("license-warning")
+			output, _ := cmd.Flags().GetString("output")
+
+			if (fromPath == "") == !fromCurrent {
+				return fmt.Errorf("pass exactly one of --from <manifest> or --from-current")
+			}
+			opts := utils.UpgradePrecheckOptions{Namespace: namespace, LicenseWarning: licenseWarning}
+			var err error
+			if opts.Target, err = utils.LoadManifest(toPath); err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+			if fromPath != "" {
+				if opts.Current, err = utils.LoadManifest(fromPath); err != nil {
+					cmd.Printf("✗ %v\n", err)
+					return err
+				}
+			}
+			if profilePath != "" {
+				if opts.Profile, err = utils.LoadSizingProfile(profilePath); err != nil {
+					cmd.Printf("✗ %v\n", err)
+					return err
+				}
+			}
+
+			kc, err := kubeChecker(cmd)
+			if err != nil {
+				cmd.Printf("✗ Failed to connect to Kubernetes cluster: %v\n", err)
+				return err
+			}
+			report, err := kc.UpgradePrecheck(cmd.Context(), opts)
+			if err != nil {
+				cmd.Printf("✗ %v\n", err)
+				return err
+			}
+
+			if output == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					cmd.Printf("✗ Failed to marshal JSON: %v\n", err)
+					return err
+				}
+				cmd.Println(string(data))
+			} else {
+				renderUpgradePrecheck(cmd, report)
+			}
+			if !report.Go {
+				return fmt.Errorf("upgrade of %s to %s is a no-go", namespace, report.To)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the Dynamo AI installation (required)")
+	cmd.Flags().String("to", "", "Manifest JSON file of the release to upgrade to (required)")
+	cmd.Flags().String("from", "", "Manifest JSON file of the installed release")
+	cmd.Flags().Bool("from-current", false, "Read the installed release from the cluster")
+	cmd.Flags().String("profile", "", "Sizing profile of the target release (YAML or JSON), as for cluster fit")
+	cmd.Flags().Duration("license-warning", utils.DefaultLicenseExpiryWarning, "Warn when the license expires within this long")
+	cmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	cmd.MarkFlagRequired("namespace")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// renderUpgradePrecheck prints the release and artifact changes, then the checks and the verdict
+func renderUpgradePrecheck(cmd *cobra.Command, report *utils.UpgradePrecheckReport) {
+	from := report.From
+	if from == "" {
+		from = "unknown"
+	}
+	cmd.Printf("Upgrade pre-check of %s: %s -> %s\n", report.Namespace, from, report.To)
+
+	cmd.Println()
+	cmd.Println("Releases")
+	cmd.Printf("  %-24s %-24s %-10s %-12s %-12s %s\n", "Release", "Chart", "Status", "From", "To", "Change")
+	for _, r := range report.Releases {
+		cmd.Printf("  %-24s %-24s %-10s %-12s %-12s %s\n", dashIfEmpty(r.Release), r.Chart, dashIfEmpty(r.Status), dashIfEmpty(r.From), dashIfEmpty(r.To), r.Change)
+	}
+
+	if len(report.Artifacts) > 0 {
+		cmd.Println()
+		cmd.Println("Artifact changes")
+		for _, a := range report.Artifacts {
+			switch a.Change {
+			case utils.ArtifactAdded:
+				cmd.Printf("  + %-6s %s:%s\n", a.Type, a.Name, a.To)
+			case utils.ArtifactRemoved:
+				cmd.Printf("  - %-6s %s:%s\n", a.Type, a.Name, a.From)
+			default:
+				cmd.Printf("  ~ %-6s %s: %s -> %s\n", a.Type, a.Name, a.From, a.To)
+			}
+		}
+	}
+
+	cmd.Println()
+	cmd.Println("Checks")
+	for _, r := range report.Checks {
+		printCheckResult(cmd, r)
+	}
+	cmd.Println()
+	if report.Go {
+		cmd.Printf("✓ GO: %s can be upgraded to %s\n", report.Namespace, report.To)
+	} else {
+		cmd.Println("✗ NO-GO: resolve the failed checks before scheduling the upgrade window")
+	}
+}
+
+// dashIfEmpty shows missing table values as -
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeCommands(t *testing.T) {
+	rootCmd := &cobra.Command{}
+	AddUpgradeCommands(rootCmd)
+
+	upgradeCmd := findSubcommand(rootCmd, "upgrade")
+	assert.NotNil(t, upgradeCmd, "upgrade command should exist")
+
+	precheckCmd := findSubcommand(upgradeCmd, "precheck")
+	assert.NotNil(t, precheckCmd, "precheck command should exist")
+	for _, name := range []string{"to", "from", "from-current", "profile", "output"} {
+		assert.NotNil(t, precheckCmd.Flags().Lookup(name), "%s flag should exist", name)
+	}
+}
+
+func TestUpgradePrecheckNeedsOneSource(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--from", "manifest-3.22.json", "--from-current"},
+	} {
+		rootCmd := &cobra.Command{}
+		AddUpgradeCommands(rootCmd)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"upgrade", "precheck", "-n", "dynamo", "--to", "manifest-3.23.json"}, args...))
+		err := rootCmd.Execute()
+		if assert.Error(t, err, "args %v", args) {
+			assert.Contains(t, err.Error(), "exactly one of --from")
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package kubetest
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Canned cluster layout: an EKS cluster with one general-purpose node per zone, a GPU node, and
+// the Dynamo deployment running in Namespace
+const (
+	Namespace       = "dynamo"
+	InstanceType    = "m5.2xlarge"
+	GPUInstanceType = "g5.2xlarge"
+	GPUResource     = corev1.ResourceName("nvidia.com/gpu")
+)
+
+// Zones are the zones of the canned general-purpose nodes
+var Zones = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+// Node returns a Ready m5.2xlarge node: 8 CPUs, 32Gi of memory, 58 pods, and 100Gi of ephemeral
+// storage allocatable
+func Node(name, zone string, opts ...func(*corev1.Node)) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"kubernetes.io/hostname":           name,
+				"node.kubernetes.io/instance-type": InstanceType,
+				"topology.kubernetes.io/zone":      zone,
+				"eks.amazonaws.com/nodegroup":      "general",
+			},
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: corev1.NodeSpec{ProviderID: fmt.Sprintf("aws:///%s/i-%s", zone, name)},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("8"),
+				corev1.ResourceMemory:           resource.MustParse("32Gi"),
+				corev1.ResourcePods:             resource.MustParse("58"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			NodeInfo: corev1.NodeSystemInfo{
+				KubeletVersion:          "v1.31.4-eks-aeac579",
+				ContainerRuntimeVersion: "containerd://1.7.25",
+			},
+		},
+	}
+	node.Status.Capacity = node.Status.Allocatable.DeepCopy()
+	for _, opt := range opts {
+		opt(node)
+	}
+	return node
+}
+
+// GPUNode returns a Ready g5.2xlarge node with the given number of NVIDIA A10G GPUs
+func GPUNode(name, zone string, gpus int64, opts ...func(*corev1.Node)) *corev1.Node {
+	node := Node(name, zone, func(n *corev1.Node) {
+		n.Labels["node.kubernetes.io/instance-type"] = GPUInstanceType
+		n.Labels["eks.amazonaws.com/nodegroup"] = "gpu"
+		n.Labels["nvidia.com/gpu.product"] = "NVIDIA-A10G"
+		n.Labels["nvidia.com/gpu.memory"] = "23028"
+		n.Status.Allocatable[GPUResource] = *resource.NewQuantity(gpus, resource.DecimalSI)
+		n.Status.Capacity[GPUResource] = *resource.NewQuantity(gpus, resource.DecimalSI)
+		n.Spec.Taints = []corev1.Taint{{Key: "nvidia.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}}
+	})
+	for _, opt := range opts {
+		opt(node)
+	}
+	return node
+}
+
+// NotReady marks a node NotReady
+func NotReady(node *corev1.Node) {
+	node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Reason: "KubeletNotReady"}}
+}
+
+// Cordoned marks a node unschedulable
+func Cordoned(node *corev1.Node) {
+	node.Spec.Unschedulable = true
+}
+
+// Pod returns a Running, Ready pod on the node with one container, "main", requesting and
+// limited to 500m CPU and 1Gi of memory. An empty node leaves it unscheduled.
+func Pod(namespace, name, node string, opts ...func(*corev1.Pod)) *corev1.Pod {
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: metav1.Now()},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name:      "main",
+				Image:     "registry.example.com/dynamo/" + name + ":1.0.0",
+				Resources: corev1.ResourceRequirements{Requests: resources, Limits: resources.DeepCopy()},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "main",
+				Ready: true,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	for _, opt := range opts {
+		opt(pod)
+	}
+	return pod
+}
+
+// Requests sets the main container's requests and limits
+func Requests(cpu, memory string) func(*corev1.Pod) {
+	return func(pod *corev1.Pod) {
+		resources := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+		pod.Spec.Containers[0].Resources = corev1.ResourceRequirements{Requests: resources, Limits: resources.DeepCopy()}
+	}
+}
+
+// GPUs adds GPU requests and limits to the main container and tolerates the GPU node taint
+func GPUs(n int64) func(*corev1.Pod) {
+	return func(pod *corev1.Pod) {
+		gpus := *resource.NewQuantity(n, resource.DecimalSI)
+		pod.Spec.Containers[0].Resources.Requests[GPUResource] = gpus
+		pod.Spec.Containers[0].Resources.Limits[GPUResource] = gpus
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule})
+	}
+}
+
+// Waiting leaves the main container waiting for the reason, e.g. CrashLoopBackOff, and the pod
+// not ready
+func Waiting(reason string) func(*corev1.Pod) {
+	return func(pod *corev1.Pod) {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}
+		pod.Status.ContainerStatuses[0].Ready = false
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}
+	}
+}
+
+// Unschedulable leaves the pod Pending without a node, with the scheduler's message
+func Unschedulable(message string) func(*corev1.Pod) {
+	return func(pod *corev1.Pod) {
+		pod.Spec.NodeName = ""
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, Message: message,
+			}},
+		}
+	}
+}
+
+// Succeeded marks the pod completed
+func Succeeded(pod *corev1.Pod) {
+	pod.Status.Phase = corev1.PodSucceeded
+	pod.Status.Conditions = nil
+	pod.Status.ContainerStatuses[0].Ready = false
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}
+}
+
+// Cluster returns the canned cluster: nodes general-1 to general-3, one per zone, and gpu-1 with
+// one GPU; in Namespace, two dynamoai-api replicas, dynamoai-ui, and guard-worker on the GPU node,
+// plus a completed migration job; and CoreDNS in kube-system. Options apply to every node.
+func Cluster(opts ...func(*corev1.Node)) []runtime.Object {
+	var objects []runtime.Object
+	for i, zone := range Zones {
+		objects = append(objects, Node(fmt.Sprintf("general-%d", i+1), zone, opts...))
+	}
+	objects = append(objects,
+		GPUNode("gpu-1", Zones[0], 1, opts...),
+		Pod(Namespace, "dynamoai-api-0", "general-1", Requests("1", "2Gi")),
+		Pod(Namespace, "dynamoai-api-1", "general-2", Requests("1", "2Gi")),
+		Pod(Namespace, "dynamoai-ui-0", "general-3"),
+		Pod(Namespace, "guard-worker-0", "gpu-1", Requests("4", "16Gi"), GPUs(1)),
+		Pod(Namespace, "db-migrate", "general-1", Succeeded),
+		Pod("kube-system", "coredns-0", "general-2", Requests("100m", "70Mi")),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: Namespace, Labels: map[string]string{"kubernetes.io/metadata.name": Namespace}}},
+	)
+	return objects
+}
@@ -0,0 +1,120 @@
+// Package kubetest builds fake clusters for tests of the Kubernetes checks: fake clientsets that
+// behave like an API server where the checks depend on it, and canned nodes and pods.
+package kubetest
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// APIResources is what the fake clientsets' discovery serves: the built-in API groups the checks
+// read. Tests of checks that look for other groups, such as OpenShift's, append to a copy.
+var APIResources = []*metav1.APIResourceList{
+	{GroupVersion: "v1", APIResources: []metav1.APIResource{
+		{Name: "nodes", Kind: "Node"},
+		{Name: "pods", Namespaced: true, Kind: "Pod"},
+		{Name: "services", Namespaced: true, Kind: "Service"},
+		{Name: "configmaps", Namespaced: true, Kind: "ConfigMap"},
+		{Name: "namespaces", Kind: "Namespace"},
+		{Name: "persistentvolumeclaims", Namespaced: true, Kind: "PersistentVolumeClaim"},
+		{Name: "serviceaccounts", Namespaced: true, Kind: "ServiceAccount"},
+	}},
+	{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+		{Name: "deployments", Namespaced: true, Kind: "Deployment"},
+		{Name: "daemonsets", Namespaced: true, Kind: "DaemonSet"},
+		{Name: "statefulsets", Namespaced: true, Kind: "StatefulSet"},
+	}},
+	{GroupVersion: "storage.k8s.io/v1", APIResources: []metav1.APIResource{
+		{Name: "storageclasses", Kind: "StorageClass"},
+		{Name: "csidrivers", Kind: "CSIDriver"},
+	}},
+	{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{
+		{Name: "networkpolicies", Namespaced: true, Kind: "NetworkPolicy"},
+		{Name: "ingressclasses", Kind: "IngressClass"},
+	}},
+}
+
+// NewClientset returns a fake clientset serving the objects. Unlike the plain fake, it applies
+// field selectors to pod lists, as the checks rely on them to skip terminated pods.
+func NewClientset(objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewClientset(objects...)
+	clientset.Resources = APIResources
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		selector := action.(k8stesting.ListAction).GetListRestrictions().Fields
+		if selector == nil || selector.Empty() {
+			return false, nil, nil
+		}
+		obj, err := clientset.Tracker().List(corev1.SchemeGroupVersion.WithResource("pods"), corev1.SchemeGroupVersion.WithKind("Pod"), action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		list := obj.(*corev1.PodList)
+		matched := list.Items[:0]
+		for _, pod := range list.Items {
+			if selector.Matches(podFields(&pod)) {
+				matched = append(matched, pod)
+			}
+		}
+		list.Items = matched
+		return true, list, nil
+	})
+	return clientset
+}
+
+// podFields are the pod fields the API server supports in field selectors
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":      pod.Name,
+		"metadata.namespace": pod.Namespace,
+		"spec.nodeName":      pod.Spec.NodeName,
+		"status.phase":       string(pod.Status.Phase),
+	}
+}
+
+// NewDynamicClient returns a fake dynamic client serving the objects, given the list kind of each
+// resource it serves. Lists of any other resource fail with NotFound, as on a cluster without
+// their CRD, where the plain fake panics.
+func NewDynamicClient(listKinds map[schema.GroupVersionResource]string, objects ...runtime.Object) dynamic.Interface {
+	return &dynamicClient{
+		FakeDynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+		listKinds:         listKinds,
+	}
+}
+
+type dynamicClient struct {
+	*dynamicfake.FakeDynamicClient
+	listKinds map[schema.GroupVersionResource]string
+}
+
+func (c *dynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resource := c.FakeDynamicClient.Resource(gvr)
+	if _, ok := c.listKinds[gvr]; ok {
+		return resource
+	}
+	return missingResource{NamespaceableResourceInterface: resource, gvr: gvr}
+}
+
+// missingResource is a resource whose CRD is not installed
+type missingResource struct {
+	dynamic.NamespaceableResourceInterface
+	gvr schema.GroupVersionResource
+}
+
+func (r missingResource) Namespace(string) dynamic.ResourceInterface {
+	return r
+}
+
+func (r missingResource) List(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return nil, apierrors.NewNotFound(r.gvr.GroupResource(), "")
+}
@@ -0,0 +1,306 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// NodeResources is the json/yaml document for node resource output
+type NodeResources struct {
+	Nodes   []utils.NodeResourceUsage
+	Summary utils.ClusterResourceSummary
+	// NotReady is filled in by node check --explain
+	NotReady []utils.NodeDiagnosis `json:",omitempty"`
+}
+
+// nodeNameWidth keeps long cloud node names from pushing the numbers off screen
+const nodeNameWidth = 40
+
+// NodeResourcesTable lays out per-node resource usage. Wide output adds absolute requests and
+// limits, zone, node software versions, and taints. Accelerators other than nvidia.com/gpu are listed last.
+func NodeResourcesTable(nodes []utils.NodeResourceUsage, summary utils.ClusterResourceSummary) *Table {
+	if nodes == nil {
+		nodes = []utils.NodeResourceUsage{}
+	}
+	t := &Table{
+		Columns: []Column{
+			{Header: "NAME", CSV: "Name", MaxWidth: nodeNameWidth},
+			{Header: "TYPE", CSV: "Type"},
+			{Header: "CPU", CSV: "CPU_Capacity_Cores"},
+			{Header: "MEM(GB)", CSV: "Memory_Capaclity_GB"},
+			{Header: "CPU %REQ", CSV: "CPU_Requests_%"},
+			{Header: "CPU %LIMIT", CSV: "CPU_Limits_%"},
+			{Header: "MEM %REQ", CSV: "Memory_Requests_%"},
+			{Header: "MEM %LIMIT", CSV: "Memory_Limits_%"},
+			{Header: "GPU ALLOC/TOTAL", CSV: "GPU_Alloc_Total"},
+			{Header: "GPU MODEL", CSV: "GPU_Model", MaxWidth: 24},
+			{Header: "GPU MEM(GB)", CSV: "GPU_Memory_GB"},
+			{Header: "MIG", CSV: "MIG_Profiles", MaxWidth: 30},
+			{Header: "CPU REQ", CSV: "CPU_Requests_Cores", Wide: true},
+			{Header: "CPU LIMIT", CSV: "CPU_Limits_Cores", Wide: true},
+			{Header: "MEM REQ(GB)", CSV: "Memory_Requests_GB", Wide: true},
+			{Header: "MEM LIMIT(GB)", CSV: "Memory_Limits_GB", Wide: true},
+			{Header: "POOL", CSV: "Node_Pool", Wide: true},
+			{Header: "ZONE", CSV: "Zone", Wide: true},
+			{Header: "KUBELET", CSV: "Kubelet_Version", Wide: true},
+			{Header: "RUNTIME", CSV: "Container_Runtime", Wide: true, MaxWidth: 30},
+			{Header: "OS IMAGE", CSV: "OS_Image", Wide: true, MaxWidth: 30},
+			{Header: "KERNEL", CSV: "Kernel_Version", Wide: true, MaxWidth: 30},
+			{Header: "TAINTS", CSV: "Taints", Wide: true, MaxWidth: 50},
+			{Header: "ACCELERATORS", CSV: "Accelerators", MaxWidth: 40},
+		},
+		Data: NodeResources{Nodes: nodes, Summary: summary},
+	}
+	for _, u := range nodes {
+		t.AddRow(
+			u.Name,
+			u.InstanceType,
+			fmt.Sprintf("%.2f", u.CPUAllocatable),
+			fmt.Sprintf("%.2f", u.MemoryAllocatable),
+			fmt.Sprintf("%.1f", u.CPURequestsPercent),
+			fmt.Sprintf("%.1f", u.CPULimitsPercent),
+			fmt.Sprintf("%.1f", u.MemoryRequestsPercent),
+			fmt.Sprintf("%.1f", u.MemoryLimitsPercent),
+			gpuAllocation(u),
+			u.GPUModel,
+			gpuMemory(u),
+			strings.Join(u.MIGProfiles, ","),
+			fmt.Sprintf("%.2f", u.CPURequests),
+			fmt.Sprintf("%.2f", u.CPULimits),
+			fmt.Sprintf("%.2f", u.MemoryRequests),
+			fmt.Sprintf("%.2f", u.MemoryLimits),
+			u.NodePool,
+			u.Zone,
+			u.KubeletVersion,
+			strings.TrimSpace(u.ContainerRuntime+" "+u.ContainerRuntimeVersion),
+			u.OSImage,
+			u.KernelVersion,
+			strings.Join(u.Taints, ","),
+			utils.FormatAccelerators(u.Accelerators),
+		)
+	}
+	return t
+}
+
+// AddNodeCostColumn appends the monthly cost of each node, filled in by utils.ApplyNodePricing,
+// to a NodeResourcesTable
+func AddNodeCostColumn(t *Table, nodes []utils.NodeResourceUsage, currency string) {
+	t.Columns = append(t.Columns, Column{Header: "COST/MO(" + currency + ")", CSV: "Monthly_Cost_" + currency})
+	for i, u := range nodes {
+		t.Rows[i] = append(t.Rows[i], utils.FormatCost(u.MonthlyCost, u.CostEstimated))
+	}
+}
+
+// RenderNodeResources writes per-node resource usage in the given format. Table and wide output
+// are followed by the cluster summary and any node version problems.
+func RenderNodeResources(w io.Writer, format string, nodes []utils.NodeResourceUsage, summary utils.ClusterResourceSummary) error {
+	if err := Render(w, format, NodeResourcesTable(nodes, summary)); err != nil {
+		return err
+	}
+	if IsTabular(format) {
+		WriteClusterSummary(w, summary)
+		WriteNodeVersionProblems(w, nodes)
+	}
+	return nil
+}
+
+// WriteNodeVersionProblems lists nodes whose kubelet, container runtime, or kernel is outside the
+// supported matrix or differs from the rest of their pool
+func WriteNodeVersionProblems(w io.Writer, nodes []utils.NodeResourceUsage) {
+	var flagged []utils.NodeResourceUsage
+	for _, u := range nodes {
+		if len(u.VersionProblems) > 0 {
+			flagged = append(flagged, u)
+		}
+	}
+	if len(flagged) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nNODE VERSION PROBLEMS:\n")
+	for _, u := range flagged {
+		for _, problem := range u.VersionProblems {
+			fmt.Fprintf(w, "! %s: %s\n", u.Name, problem)
+		}
+	}
+}
+
+// WriteClusterSummary prints the cluster-wide totals shown beneath the node table, followed by
+// the breakdown per instance type and node pool
+func WriteClusterSummary(w io.Writer, summary utils.ClusterResourceSummary) {
+	fmt.Fprintf(w, "\nCLUSTER SUMMARY:\n")
+	fmt.Fprintf(w, "CPU: %.1f cores available, %.1f cores allocatable (%.1f%% already requested)\n", summary.CPUAvailable, summary.CPUAllocatable, summary.CPURequestsPercent)
+	fmt.Fprintf(w, "Mem: %.1f GB available, %.1f GB allocatable (%.1f%% already requested)\n", summary.MemoryAvailable, summary.MemoryAllocatable, summary.MemoryRequestsPercent)
+	for _, a := range summary.Accelerators {
+		fmt.Fprintf(w, "%s: %d available, %d allocatable (%d already requested)\n", a.Resource, a.Allocatable-a.Requests, a.Allocatable, a.Requests)
+	}
+	if summary.Currency != "" {
+		fmt.Fprintf(w, "Cost: ~%.2f %s/month at on-demand prices\n", summary.MonthlyCost, summary.Currency)
+		if len(summary.EstimatedInstanceTypes) > 0 {
+			fmt.Fprintf(w, "  estimated from allocatable resources for %s (add them to a --pricing file)\n", strings.Join(summary.EstimatedInstanceTypes, ", "))
+		}
+	}
+
+	if len(summary.ByInstanceType) > 0 {
+		fmt.Fprintf(w, "\nBY INSTANCE TYPE:\n")
+		_ = tableRenderer{}.Render(w, CapacityGroupsTable("INSTANCE TYPE", summary.ByInstanceType))
+	}
+	if len(summary.ByNodePool) > 0 {
+		fmt.Fprintf(w, "\nBY NODE POOL:\n")
+		_ = tableRenderer{}.Render(w, CapacityGroupsTable("NODE POOL", summary.ByNodePool))
+	}
+}
+
+// ClusterSummaryTable lays out the cluster-wide totals as one metric per row, for workbooks
+func ClusterSummaryTable(summary utils.ClusterResourceSummary) *Table {
+	t := &Table{Columns: []Column{{Header: "METRIC", CSV: "Metric"}, {Header: "VALUE", CSV: "Value"}}, Data: summary}
+	t.AddRow("Nodes", fmt.Sprintf("%d", summary.TotalNodes))
+	t.AddRow("Ready nodes", fmt.Sprintf("%d", summary.ReadyNodes))
+	t.AddRow("CPU allocatable (cores)", fmt.Sprintf("%.2f", summary.CPUAllocatable))
+	t.AddRow("CPU requested (cores)", fmt.Sprintf("%.2f", summary.CPURequests))
+	t.AddRow("CPU available (cores)", fmt.Sprintf("%.2f", summary.CPUAvailable))
+	t.AddRow("CPU requested (%)", fmt.Sprintf("%.1f", summary.CPURequestsPercent))
+	t.AddRow("Memory allocatable (GB)", fmt.Sprintf("%.2f", summary.MemoryAllocatable))
+	t.AddRow("Memory requested (GB)", fmt.Sprintf("%.2f", summary.MemoryRequests))
+	t.AddRow("Memory available (GB)", fmt.Sprintf("%.2f", summary.MemoryAvailable))
+	t.AddRow("Memory requested (%)", fmt.Sprintf("%.1f", summary.MemoryRequestsPercent))
+	for _, a := range summary.Accelerators {
+		t.AddRow(a.Resource+" allocatable", fmt.Sprintf("%d", a.Allocatable))
+		t.AddRow(a.Resource+" requested", fmt.Sprintf("%d", a.Requests))
+	}
+	if summary.Currency != "" {
+		t.AddRow("Monthly cost ("+summary.Currency+")", fmt.Sprintf("%.2f", summary.MonthlyCost))
+		if len(summary.EstimatedInstanceTypes) > 0 {
+			t.AddRow("Cost estimated for", strings.Join(summary.EstimatedInstanceTypes, ", "))
+		}
+	}
+	return t
+}
+
+// NodeWorkbookSheets are the node, cluster summary, and capacity breakdown sheets of a workbook.
+// Nodes get a cost column when utils.ApplyNodePricing has been applied.
+func NodeWorkbookSheets(nodes []utils.NodeResourceUsage, summary utils.ClusterResourceSummary) []Sheet {
+	table := NodeResourcesTable(nodes, summary)
+	if summary.Currency != "" {
+		AddNodeCostColumn(table, nodes, summary.Currency)
+	}
+	sheets := []Sheet{
+		{Name: "Nodes", Table: table},
+		{Name: "Cluster Summary", Table: ClusterSummaryTable(summary)},
+	}
+	if len(summary.ByInstanceType) > 0 {
+		sheets = append(sheets, Sheet{Name: "By Instance Type", Table: CapacityGroupsTable("INSTANCE TYPE", summary.ByInstanceType)})
+	}
+	if len(summary.ByNodePool) > 0 {
+		sheets = append(sheets, Sheet{Name: "By Node Pool", Table: CapacityGroupsTable("NODE POOL", summary.ByNodePool)})
+	}
+	return sheets
+}
+
+// WriteNodeDiagnoses prints why each not-ready node is failing: its failing conditions with how
+// long they have held, followed by the node's recent events
+func WriteNodeDiagnoses(w io.Writer, diagnoses []utils.NodeDiagnosis) {
+	if len(diagnoses) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nNOT READY NODES:\n")
+	for _, d := range diagnoses {
+		fmt.Fprintf(w, "%s", d.Name)
+		if d.Unschedulable {
+			fmt.Fprintf(w, " (cordoned)")
+		}
+		fmt.Fprintln(w)
+
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, c := range d.Conditions {
+			reason := c.Reason
+			if reason == "" {
+				reason = "-"
+			}
+			fmt.Fprintf(tw, "  %s=%s\t%s\t%s\t%s\n", c.Type, c.Status, reason, since(c.LastTransitionTime), c.Message)
+		}
+		_ = tw.Flush()
+
+		if len(d.Events) == 0 {
+			fmt.Fprintf(w, "  No recent events\n")
+			continue
+		}
+		fmt.Fprintf(w, "  Events:\n")
+		tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, e := range d.Events {
+			fmt.Fprintf(tw, "    %s\t%s\tx%d\t%s\t%s\n", e.Type, e.Reason, e.Count, since(e.LastSeen), e.Message)
+		}
+		_ = tw.Flush()
+	}
+}
+
+// since renders the time elapsed since t the way kubectl does, e.g. "12m ago"
+func since(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return duration.HumanDuration(time.Since(t)) + " ago"
+}
+
+// CapacityGroupsTable lays out allocatable vs requested resources for groups of nodes
+func CapacityGroupsTable(groupHeader string, groups []utils.CapacityGroup) *Table {
+	t := &Table{
+		Columns: []Column{
+			{Header: groupHeader},
+			{Header: "NODES"},
+			{Header: "CPU ALLOC"},
+			{Header: "CPU REQ"},
+			{Header: "CPU %REQ"},
+			{Header: "MEM ALLOC(GB)"},
+			{Header: "MEM REQ(GB)"},
+			{Header: "MEM %REQ"},
+			{Header: "GPU REQ/ALLOC"},
+			{Header: "ACCELERATORS"},
+		},
+		Data: groups,
+	}
+	for _, g := range groups {
+		gpu := ""
+		if g.GPUAllocatable > 0 {
+			gpu = fmt.Sprintf("%d/%d", g.GPURequests, g.GPUAllocatable)
+		}
+		t.AddRow(
+			g.Name,
+			fmt.Sprintf("%d", g.Nodes),
+			fmt.Sprintf("%.1f", g.CPUAllocatable),
+			fmt.Sprintf("%.1f", g.CPURequests),
+			fmt.Sprintf("%.1f", g.CPURequestsPercent),
+			fmt.Sprintf("%.1f", g.MemoryAllocatable),
+			fmt.Sprintf("%.1f", g.MemoryRequests),
+			fmt.Sprintf("%.1f", g.MemoryRequestsPercent),
+			gpu,
+			utils.FormatAccelerators(g.Accelerators),
+		)
+	}
+	return t
+}
+
+// gpuMemory formats per-GPU memory with the physical GPU count, e.g. "4x22.5"
+func gpuMemory(u utils.NodeResourceUsage) string {
+	if u.GPUMemoryMiB == 0 {
+		return ""
+	}
+	gb := fmt.Sprintf("%.1f", float64(u.GPUMemoryMiB)/1024)
+	if u.GPUCount > 1 {
+		return fmt.Sprintf("%dx%s", u.GPUCount, gb)
+	}
+	return gb
+}
+
+// gpuAllocation formats requested/allocatable GPUs, or an empty string for CPU-only nodes
+func gpuAllocation(u utils.NodeResourceUsage) string {
+	if u.GPUAllocatable == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", u.GPURequests, u.GPUAllocatable)
+}
@@ -0,0 +1,152 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+)
+
+func testNodes() ([]utils.NodeResourceUsage, utils.ClusterResourceSummary) {
+	nodes := []utils.NodeResourceUsage{
+		{Name: "node-a", InstanceType: "m5.large", Zone: "us-east-1a", KubeletVersion: "v1.30.2",
+			ContainerRuntime: "containerd", ContainerRuntimeVersion: "1.7.11", OSImage: "Amazon Linux 2", KernelVersion: "5.10.210-201.852.amzn2.x86_64",
+			Taints: []string{"dedicated=ml:NoSchedule"}, CPUAllocatable: 2, MemoryAllocatable: 8, CPURequests: 1, CPURequestsPercent: 50},
+		{Name: "node-b", InstanceType: "g5.2xlarge", CPUAllocatable: 8, MemoryAllocatable: 32, GPUAllocatable: 1, GPURequests: 1,
+			GPUModel: "NVIDIA-A10G", GPUCount: 1, GPUMemoryMiB: 23028},
+	}
+	return nodes, utils.SummarizeNodeResources(nodes)
+}
+
+func TestRenderNodeResourcesTable(t *testing.T) {
+	nodes, summary := testNodes()
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, "table", nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "node-a") || !strings.Contains(out, "m5.large") {
+		t.Errorf("table output missing node row:\n%s", out)
+	}
+	if !strings.Contains(out, "NVIDIA-A10G") || !strings.Contains(out, "22.5") {
+		t.Errorf("table output missing GPU model and memory:\n%s", out)
+	}
+	if !strings.Contains(out, "1/1") {
+		t.Errorf("table output missing GPU allocation:\n%s", out)
+	}
+	if !strings.Contains(out, "BY INSTANCE TYPE:") || strings.Contains(out, "BY NODE POOL:") {
+		t.Errorf("table output should break down by instance type only when no pools are labelled:\n%s", out)
+	}
+	if !strings.Contains(out, "CLUSTER SUMMARY:") {
+		t.Errorf("table output missing cluster summary:\n%s", out)
+	}
+}
+
+func TestRenderNodeResourcesCSV(t *testing.T) {
+	nodes, summary := testNodes()
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, "csv", nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines:\n%s", len(lines), buf.String())
+	}
+	if lines[1] != "node-a,m5.large,2.00,8.00,50.0,0.0,0.0,0.0,,,,,1.00,0.00,0.00,0.00,,us-east-1a,v1.30.2,containerd 1.7.11,Amazon Linux 2,5.10.210-201.852.amzn2.x86_64,dedicated=ml:NoSchedule," {
+		t.Errorf("unexpected CSV row %q", lines[1])
+	}
+}
+
+func TestRenderNodeResourcesVersionProblems(t *testing.T) {
+	nodes, summary := testNodes()
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, "table", nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	if strings.Contains(buf.String(), "NODE VERSION PROBLEMS:") {
+		t.Errorf("table output lists version problems when there are none:\n%s", buf.String())
+	}
+
+	nodes[1].VersionProblems = []string{"kubelet v1.26.4 is outside the supported range >= 1.27.0"}
+	buf.Reset()
+	if err := RenderNodeResources(&buf, "table", nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "NODE VERSION PROBLEMS:\n! node-b: kubelet v1.26.4 is outside the supported range >= 1.27.0") {
+		t.Errorf("table output missing version problems:\n%s", buf.String())
+	}
+}
+
+func TestRenderNodeResourcesAccelerators(t *testing.T) {
+	nodes := []utils.NodeResourceUsage{
+		{Name: "node-c", InstanceType: "dl1.24xlarge", CPUAllocatable: 96, MemoryAllocatable: 768, Accelerators: []utils.AcceleratorUsage{
+			{Resource: "habana.ai/gaudi", Allocatable: 8, Requests: 2},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, "table", nodes, utils.SummarizeNodeResources(nodes)); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "habana.ai/gaudi 2/8") {
+		t.Errorf("table output missing accelerator usage:\n%s", out)
+	}
+	if !strings.Contains(out, "habana.ai/gaudi: 6 available, 8 allocatable (2 already requested)") {
+		t.Errorf("cluster summary missing accelerator totals:\n%s", out)
+	}
+}
+
+func TestRenderNodeResourcesJSON(t *testing.T) {
+	nodes, summary := testNodes()
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, "json", nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	var doc NodeResources
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(doc.Nodes) != 2 || doc.Summary.TotalNodes != 2 {
+		t.Errorf("unexpected JSON document: %+v", doc)
+	}
+}
+
+func TestRenderNodeResourcesWide(t *testing.T) {
+	nodes, summary := testNodes()
+	var buf bytes.Buffer
+	if err := RenderNodeResources(&buf, FormatWide, nodes, summary); err != nil {
+		t.Fatalf("RenderNodeResources returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "MEM LIMIT(GB)") || !strings.Contains(buf.String(), "us-east-1a") {
+		t.Errorf("wide output missing absolute request/limit columns:\n%s", buf.String())
+	}
+}
+
+func TestWriteNodeDiagnoses(t *testing.T) {
+	var buf bytes.Buffer
+	WriteNodeDiagnoses(&buf, []utils.NodeDiagnosis{{
+		Name:          "node-x",
+		Unschedulable: true,
+		Conditions: []utils.NodeConditionReport{
+			{Type: "Ready", Status: "Unknown", Reason: "NodeStatusUnknown", Message: "Kubelet stopped posting node status.", LastTransitionTime: time.Now().Add(-5 * time.Minute)},
+		},
+		Events: []utils.NodeEventReport{
+			{Type: "Normal", Reason: "NodeNotReady", Count: 2, Message: "Node node-x status is now: NodeNotReady", LastSeen: time.Now()},
+		},
+	}})
+	out := buf.String()
+	for _, want := range []string{"NOT READY NODES:", "node-x (cordoned)", "Ready=Unknown", "NodeStatusUnknown", "5m ago", "NodeNotReady", "x2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diagnosis output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	WriteNodeDiagnoses(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output without diagnoses, got %q", buf.String())
+	}
+}
@@ -0,0 +1,195 @@
+// Package output renders command results in the formats selected with -o.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats accepted by -o
+const (
+	FormatTable = "table"
+	FormatWide  = "wide"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatCSV   = "csv"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatTable, FormatWide, FormatJSON, FormatYAML, FormatCSV}
+
+// FlagUsage is the help text for the -o flag on commands that use a Renderer
+const FlagUsage = "Output format: table, wide, json, yaml, or csv"
+
+// Column describes one column of tabular output
+type Column struct {
+	// Header is shown above the column in table output
+	Header string
+	// CSV overrides the header in CSV output, keeping machine-readable names stable
+	CSV string
+	// Wide columns are only shown with -o wide and in CSV output
+	Wide bool
+	// MaxWidth cuts longer table cells short unless the table disables truncation; 0 means no limit
+	MaxWidth int
+}
+
+// Table is a command result prepared for rendering. Rows hold the formatted cells used by
+// table, wide, and csv output (empty cells show as "-" in tables); Data is the value serialized
+// for json and yaml.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+	Data    interface{}
+	// NoTruncate prints every table cell in full, ignoring column MaxWidth
+	NoTruncate bool
+}
+
+// AddRow appends a row of cells, one per column
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Renderer writes a Table in one output format
+type Renderer interface {
+	Render(w io.Writer, t *Table) error
+}
+
+// NewRenderer returns the renderer for an output format
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case FormatTable, "":
+		return tableRenderer{}, nil
+	case FormatWide:
+		return tableRenderer{wide: true}, nil
+	case FormatJSON:
+		return jsonRenderer{}, nil
+	case FormatYAML:
+		return yamlRenderer{}, nil
+	case FormatCSV:
+		return csvRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// IsTabular reports whether a format is meant for people reading a terminal, so commands know
+// when to print headings and summaries around the table
+func IsTabular(format string) bool {
+	return format == FormatTable || format == FormatWide || format == ""
+}
+
+// Render writes t to w in the given format
+func Render(w io.Writer, format string, t *Table) error {
+	r, err := NewRenderer(format)
+	if err != nil {
+		return err
+	}
+	return r.Render(w, t)
+}
+
+type tableRenderer struct {
+	wide bool
+}
+
+func (r tableRenderer) Render(w io.Writer, t *Table) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	var cells []string
+	for _, c := range t.Columns {
+		if r.wide || !c.Wide {
+			cells = append(cells, c.Header)
+		}
+	}
+	fmt.Fprintln(tw, strings.Join(cells, "\t"))
+
+	for _, row := range t.Rows {
+		cells = cells[:0]
+		for i, c := range t.Columns {
+			if r.wide || !c.Wide {
+				v := cell(row, i)
+				if v == "" {
+					v = "-"
+				}
+				if !t.NoTruncate {
+					v = truncate(v, c.MaxWidth)
+				}
+				cells = append(cells, v)
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+type csvRenderer struct{}
+
+func (csvRenderer) Render(w io.Writer, t *Table) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Header
+		if c.CSV != "" {
+			header[i] = c.CSV
+		}
+	}
+	_ = writer.Write(header)
+	for _, row := range t.Rows {
+		record := make([]string, len(t.Columns))
+		for i := range t.Columns {
+			record[i] = cell(row, i)
+		}
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, t *Table) error {
+	data, err := json.MarshalIndent(t.Data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+type yamlRenderer struct{}
+
+func (yamlRenderer) Render(w io.Writer, t *Table) error {
+	data, err := yaml.Marshal(t.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// truncate shortens s to at most width characters, marking the cut with "..."
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// cell returns the i'th cell of a row, or an empty string for short rows
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
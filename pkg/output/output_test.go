@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testTable() *Table {
+	t := &Table{
+		Columns: []Column{
+			{Header: "NAME", CSV: "name"},
+			{Header: "VALUE"},
+			{Header: "DETAIL", CSV: "detail", Wide: true},
+		},
+		Data: []map[string]string{{"name": "a-long-name"}, {"name": "b"}},
+	}
+	t.AddRow("a-long-name", "1", "first")
+	t.AddRow("b", "", "second")
+	return t
+}
+
+func TestTableRendererAlignsColumns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, testTable()); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", buf.String())
+	}
+	if strings.Index(lines[0], "VALUE") != strings.Index(lines[1], "1") {
+		t.Errorf("columns are not aligned:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "DETAIL") {
+		t.Errorf("wide column shown in table output:\n%s", buf.String())
+	}
+	if !strings.HasSuffix(lines[2], "-") {
+		t.Errorf("empty cell should render as -, got %q", lines[2])
+	}
+}
+
+func TestWideRendererShowsWideColumns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatWide, testTable()); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DETAIL") || !strings.Contains(buf.String(), "second") {
+		t.Errorf("wide output missing wide column:\n%s", buf.String())
+	}
+}
+
+func TestCSVRendererUsesCSVHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatCSV, testTable()); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	want := "name,VALUE,detail\na-long-name,1,first\nb,,second\n"
+	if buf.String() != want {
+		t.Errorf("CSV output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStructuredRenderersUseData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatYAML, testTable()); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if buf.String() != "- name: a-long-name\n- name: b\n" {
+		t.Errorf("unexpected YAML output %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := Render(&buf, FormatJSON, testTable()); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"name": "a-long-name"`) {
+		t.Errorf("unexpected JSON output %q", buf.String())
+	}
+}
+
+func TestNewRendererRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewRenderer("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTableRendererTruncatesLongCells(t *testing.T) {
+	table := &Table{Columns: []Column{{Header: "NAME", MaxWidth: 8}}}
+	table.AddRow("ip-10-0-0-1.ec2.internal")
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, table); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "ip-10...\n") {
+		t.Errorf("expected truncated cell, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	table.NoTruncate = true
+	if err := Render(&buf, FormatTable, table); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "ip-10-0-0-1.ec2.internal") {
+		t.Errorf("NoTruncate should print the full cell, got:\n%s", buf.String())
+	}
+}
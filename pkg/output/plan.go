@@ -0,0 +1,50 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+)
+
+// PlanTable lays out the actions of a dry-run plan in order. json and yaml output serialize the
+// whole plan, totals included, for change review submissions.
+func PlanTable(plan *utils.Plan) *Table {
+	t := &Table{
+		Columns: []Column{
+			{Header: "STEP", CSV: "Step"},
+			{Header: "ACTION", CSV: "Action"},
+			{Header: "KIND", CSV: "Kind"},
+			{Header: "SOURCE", CSV: "Source", MaxWidth: 60},
+			{Header: "DESTINATION", CSV: "Destination", MaxWidth: 60},
+			{Header: "SIZE", CSV: "Size"},
+			{Header: "EST. DURATION", CSV: "Estimated_Duration"},
+			{Header: "NOTE", CSV: "Note", MaxWidth: 50},
+		},
+		Data: plan,
+	}
+	for _, a := range plan.Actions {
+		size, duration := "", ""
+		if a.SizeBytes > 0 {
+			size = utils.FormatBytes(a.SizeBytes)
+		}
+		if a.EstimatedDuration > 0 {
+			duration = a.EstimatedDuration.String()
+		}
+		t.AddRow(strconv.Itoa(a.Step), a.Action, a.Kind, a.Source, a.Destination, size, duration, a.Note)
+	}
+	return t
+}
+
+// WritePlanSummary prints a plan's totals under its table
+func WritePlanSummary(w io.Writer, plan *utils.Plan) {
+	fmt.Fprintf(w, "\n%d step(s)", len(plan.Actions))
+	if plan.TotalBytes > 0 {
+		fmt.Fprintf(w, ", %s to transfer", utils.FormatBytes(plan.TotalBytes))
+	}
+	if plan.TotalEstimatedDuration > 0 {
+		fmt.Fprintf(w, ", about %s at %s/s", plan.TotalEstimatedDuration, utils.FormatBytes(plan.TransferRate))
+	}
+	fmt.Fprintln(w)
+}
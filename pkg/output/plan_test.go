@@ -0,0 +1,46 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+)
+
+func testPlan() *utils.Plan {
+	plan := utils.NewPlan("artifacts mirror", 25<<20)
+	plan.Add(utils.PlanAction{Action: utils.PlanActionPull, Kind: "container_image", Source: "artifacts.dynamo.ai/dynamoai/api:3.22.2", Destination: "/cache/api.tar", SizeBytes: 250 << 20})
+	plan.Add(utils.PlanAction{Action: utils.PlanActionSkip, Kind: "container_image", Source: "artifacts.dynamo.ai/dynamoai/ui:3.22.2", Note: "already in the cache"})
+	return plan
+}
+
+func TestRenderPlanTable(t *testing.T) {
+	plan := testPlan()
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, PlanTable(plan)); err != nil {
+		t.Fatal(err)
+	}
+	WritePlanSummary(&buf, plan)
+	out := buf.String()
+	for _, want := range []string{"STEP", "pull", "250.00 MB", "10s", "already in the cache", "2 step(s), 250.00 MB to transfer, about 10s at 25.00 MB/s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderPlanJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatJSON, PlanTable(testPlan())); err != nil {
+		t.Fatal(err)
+	}
+	var got utils.Plan
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Operation != "artifacts mirror" || len(got.Actions) != 2 || got.TotalBytes != 250<<20 {
+		t.Errorf("unexpected plan %+v", got)
+	}
+}
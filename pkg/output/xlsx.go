@@ -0,0 +1,243 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatXLSX writes a multi-sheet workbook to --output-file instead of rendering to the terminal
+const FormatXLSX = "xlsx"
+
+// WorkbookFlagUsage is the help text for the -o flag on commands that can also write a workbook
+const WorkbookFlagUsage = "Output format: table, wide, json, yaml, csv, or xlsx (requires --output-file)"
+
+// Sheet is one worksheet of a workbook
+type Sheet struct {
+	Name  string
+	Table *Table
+}
+
+// maxSheetNameLength is the longest worksheet name spreadsheet applications accept
+const maxSheetNameLength = 31
+
+// maxColumnWidth caps the width given to long cells such as taints, in characters
+const maxColumnWidth = 60
+
+// numericCell matches cells written as numbers rather than text. Quantities like 10Gi and
+// estimates like ~12.50 stay text so they read the same as in table output.
+var numericCell = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// WriteWorkbook writes the sheets as an xlsx workbook. Like CSV output every column is included,
+// headed by its CSV name; the header row is bold and frozen.
+func WriteWorkbook(w io.Writer, sheets []Sheet) error {
+	if len(sheets) == 0 {
+		return fmt.Errorf("workbook has no sheets")
+	}
+
+	names := make([]string, len(sheets))
+	used := map[string]bool{}
+	for i, s := range sheets {
+		if s.Table == nil {
+			return fmt.Errorf("sheet %q has no table", s.Name)
+		}
+		names[i] = sheetName(s.Name, i, used)
+	}
+
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", contentTypesXML(len(sheets))},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML(names)},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML(len(sheets))},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, f := range files {
+		if err := writeZipFile(zw, f.name, []byte(f.body)); err != nil {
+			return err
+		}
+	}
+	for i, s := range sheets {
+		if err := writeZipFile(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheetXML(s.Table)); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, body []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	if _, err := f.Write(body); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
+
+// sheetName makes a worksheet name valid and unique: no []:*?/\ characters and at most 31 long
+func sheetName(name string, index int, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", index+1)
+	}
+	if utf8.RuneCountInString(name) > maxSheetNameLength {
+		name = string([]rune(name)[:maxSheetNameLength])
+	}
+	base := name
+	for n := 2; used[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		runes := []rune(base)
+		if len(runes)+len(suffix) > maxSheetNameLength {
+			runes = runes[:maxSheetNameLength-len(suffix)]
+		}
+		name = string(runes) + suffix
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// columnName converts a zero-based column index to a spreadsheet column letter: A, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func worksheetXML(t *Table) []byte {
+	header := make([]string, len(t.Columns))
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Header
+		if c.CSV != "" {
+			header[i] = c.CSV
+		}
+		widths[i] = utf8.RuneCountInString(header[i])
+	}
+	for _, row := range t.Rows {
+		for i := range t.Columns {
+			if n := utf8.RuneCountInString(cell(row, i)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(t.Columns) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, min(width, maxColumnWidth)+2)
+		}
+		b.WriteString("</cols>")
+	}
+	b.WriteString("<sheetData>")
+	writeRow(&b, 1, header, true)
+	for r, row := range t.Rows {
+		cells := make([]string, len(t.Columns))
+		for i := range t.Columns {
+			cells[i] = cell(row, i)
+		}
+		writeRow(&b, r+2, cells, false)
+	}
+	b.WriteString("</sheetData></worksheet>")
+	return b.Bytes()
+}
+
+func writeRow(b *bytes.Buffer, number int, cells []string, bold bool) {
+	fmt.Fprintf(b, `<row r="%d">`, number)
+	for i, v := range cells {
+		if v == "" {
+			continue
+		}
+		ref := fmt.Sprintf("%s%d", columnName(i), number)
+		if !bold && numericCell.MatchString(v) {
+			fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, v)
+			continue
+		}
+		style := ""
+		if bold {
+			style = ` s="1"`
+		}
+		fmt.Fprintf(b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, style)
+		_ = xml.EscapeText(b, []byte(v))
+		b.WriteString("</t></is></c>")
+	}
+	b.WriteString("</row>")
+}
+
+func contentTypesXML(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func workbookXML(names []string) string {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range names {
+		b.WriteString(`<sheet name="`)
+		_ = xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+// workbookRelsXML links the sheets as rId1..rIdN and the styles after them
+func workbookRelsXML(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// stylesXML defines the default cell style and a bold one (s="1") for header rows
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
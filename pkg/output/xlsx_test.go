@@ -0,0 +1,106 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/utils"
+)
+
+// readWorkbook returns the contents of every file in an xlsx workbook
+func readWorkbook(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("workbook is not a zip archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(body)
+	}
+	return files
+}
+
+func TestWriteWorkbook(t *testing.T) {
+	table := testTable()
+	table.AddRow("c<&>", "10Gi", "~1.50")
+	var buf bytes.Buffer
+	if err := WriteWorkbook(&buf, []Sheet{{Name: "Nodes", Table: table}, {Name: "Nodes", Table: testTable()}, {Name: "a/b:c"}}); err == nil {
+		t.Fatal("Expected an error for a sheet without a table")
+	}
+
+	buf.Reset()
+	if err := WriteWorkbook(&buf, []Sheet{{Name: "Nodes", Table: table}, {Name: "nodes", Table: testTable()}, {Name: "a/b:c", Table: &Table{}}}); err != nil {
+		t.Fatalf("WriteWorkbook returned error: %v", err)
+	}
+	files := readWorkbook(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet3.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+
+	workbook := files["xl/workbook.xml"]
+	for _, name := range []string{`name="Nodes"`, `name="nodes (2)"`, `name="a-b-c"`} {
+		if !strings.Contains(workbook, name) {
+			t.Errorf("workbook.xml is missing sheet %s:\n%s", name, workbook)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">name</t></is></c>`,
+		`<c r="C1" t="inlineStr" s="1"><is><t xml:space="preserve">detail</t></is></c>`,
+		`<c r="B2"><v>1</v></c>`,
+		`<c r="A4" t="inlineStr"><is><t xml:space="preserve">c&lt;&amp;&gt;</t></is></c>`,
+		`<c r="B4" t="inlineStr"><is><t xml:space="preserve">10Gi</t></is></c>`,
+		`<c r="C4" t="inlineStr"><is><t xml:space="preserve">~1.50</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet1.xml is missing %s", want)
+		}
+	}
+	if strings.Contains(sheet, `r="B3"`) {
+		t.Error("empty cells should be left out")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestNodeWorkbookSheets(t *testing.T) {
+	nodes := []utils.NodeResourceUsage{{Name: "gpu-1", InstanceType: "g5.2xlarge", MonthlyCost: 884.76}}
+	summary := utils.ClusterResourceSummary{TotalNodes: 1, ReadyNodes: 1, Currency: "USD", MonthlyCost: 884.76,
+		ByInstanceType: []utils.CapacityGroup{{Name: "g5.2xlarge", Nodes: 1}}}
+
+	sheets := NodeWorkbookSheets(nodes, summary)
+	var names []string
+	for _, s := range sheets {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "Nodes,Cluster Summary,By Instance Type" {
+		t.Errorf("Unexpected sheets %v", names)
+	}
+	nodeTable := sheets[0].Table
+	if last := nodeTable.Columns[len(nodeTable.Columns)-1]; last.CSV != "Monthly_Cost_USD" || nodeTable.Rows[0][len(nodeTable.Columns)-1] != "884.76" {
+		t.Errorf("Expected a cost column on the nodes sheet, got %+v", last)
+	}
+	summaryRows := sheets[1].Table.Rows
+	if row := summaryRows[len(summaryRows)-1]; row[0] != "Monthly cost (USD)" || row[1] != "884.76" {
+		t.Errorf("Expected the monthly cost last in the summary, got %v", row)
+	}
+}
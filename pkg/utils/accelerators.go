@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gpuResource is the NVIDIA device plugin resource reported in the GPU columns
+const gpuResource = corev1.ResourceName("nvidia.com/gpu")
+
+// DefaultAcceleratorResources are the extended resources counted as accelerators when neither
+// --accelerator-resource nor the config file names any. Glob patterns are allowed.
+var DefaultAcceleratorResources = []string{
+	"nvidia.com/gpu",
+	"nvidia.com/mig-*",
+	"amd.com/gpu",
+	"habana.ai/gaudi",
+	"intel.com/gpu",
+	"gpu.intel.com/i915",
+}
+
+// acceleratorResources holds the patterns in effect for this invocation
+var acceleratorResources = DefaultAcceleratorResources
+
+// SetAcceleratorResources sets the resource names (glob patterns allowed) aggregated as
+// accelerators. An empty list restores the defaults.
+func SetAcceleratorResources(names []string) {
+	if len(names) == 0 {
+		names = DefaultAcceleratorResources
+	}
+	acceleratorResources = names
+}
+
+// AcceleratorResources returns the resource name patterns counted as accelerators
+func AcceleratorResources() []string {
+	return acceleratorResources
+}
+
+// isAcceleratorResource reports whether a resource name matches a configured accelerator pattern
+func isAcceleratorResource(name corev1.ResourceName) bool {
+	for _, pattern := range acceleratorResources {
+		if ok, _ := path.Match(pattern, string(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceleratorUsage is the allocatable and requested count of one accelerator resource
+type AcceleratorUsage struct {
+	Resource    string
+	Allocatable int64
+	Requests    int64
+	Limits      int64
+}
+
+// addAccelerators merges src into dst, summing usage of the same resource, and keeps the result
+// sorted by resource name
+func addAccelerators(dst, src []AcceleratorUsage) []AcceleratorUsage {
+	for _, a := range src {
+		found := false
+		for i := range dst {
+			if dst[i].Resource == a.Resource {
+				dst[i].Allocatable += a.Allocatable
+				dst[i].Requests += a.Requests
+				dst[i].Limits += a.Limits
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, a)
+		}
+	}
+	sort.Slice(dst, func(i, j int) bool { return dst[i].Resource < dst[j].Resource })
+	return dst
+}
+
+// nodeAccelerators returns the usage of every accelerator resource the node advertises or its
+// pods request
+func nodeAccelerators(allocatable corev1.ResourceList, requests, limits corev1.ResourceList) []AcceleratorUsage {
+	var usage []AcceleratorUsage
+	for name, qty := range allocatable {
+		if isAcceleratorResource(name) && !qty.IsZero() {
+			usage = addAccelerators(usage, []AcceleratorUsage{{Resource: string(name), Allocatable: qty.Value()}})
+		}
+	}
+	for name, qty := range requests {
+		if isAcceleratorResource(name) && !qty.IsZero() {
+			usage = addAccelerators(usage, []AcceleratorUsage{{Resource: string(name), Requests: qty.Value()}})
+		}
+	}
+	for name, qty := range limits {
+		if isAcceleratorResource(name) && !qty.IsZero() {
+			usage = addAccelerators(usage, []AcceleratorUsage{{Resource: string(name), Limits: qty.Value()}})
+		}
+	}
+	return usage
+}
+
+// FormatAccelerators renders requested/allocatable counts for accelerators other than
+// nvidia.com/gpu, which has its own columns, e.g. "amd.com/gpu 2/8"
+func FormatAccelerators(usage []AcceleratorUsage) string {
+	var parts []string
+	for _, a := range usage {
+		if a.Resource == string(gpuResource) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %d/%d", a.Resource, a.Requests, a.Allocatable))
+	}
+	return strings.Join(parts, ",")
+}
+
+// containerAccelerators returns the accelerator count per resource for a container, excluding
+// nvidia.com/gpu. Extended resources cannot be overcommitted, so the request equals the limit and
+// whichever is set is used.
+func containerAccelerators(c corev1.Container) map[string]int64 {
+	var counts map[string]int64
+	for _, list := range []corev1.ResourceList{c.Resources.Limits, c.Resources.Requests} {
+		for name, qty := range list {
+			if name == gpuResource || !isAcceleratorResource(name) || qty.IsZero() {
+				continue
+			}
+			if counts == nil {
+				counts = map[string]int64{}
+			}
+			if _, ok := counts[string(name)]; !ok {
+				counts[string(name)] = qty.Value()
+			}
+		}
+	}
+	return counts
+}
+
+// FormatAcceleratorCounts renders accelerator counts sorted by resource, e.g.
+// "amd.com/gpu=1,habana.ai/gaudi=2"
+func FormatAcceleratorCounts(counts map[string]int64) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, counts[name]))
+	}
+	return strings.Join(parts, ",")
+}
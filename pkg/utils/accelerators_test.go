@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestIsAcceleratorResource(t *testing.T) {
+	defer SetAcceleratorResources(nil)
+
+	for _, name := range []string{"nvidia.com/gpu", "nvidia.com/mig-1g.5gb", "amd.com/gpu", "habana.ai/gaudi"} {
+		if !isAcceleratorResource(corev1.ResourceName(name)) {
+			t.Errorf("expected %s to be an accelerator by default", name)
+		}
+	}
+	if isAcceleratorResource(corev1.ResourceCPU) {
+		t.Error("cpu should not be an accelerator")
+	}
+
+	SetAcceleratorResources([]string{"example.com/tpu-*"})
+	if !isAcceleratorResource("example.com/tpu-v5") || isAcceleratorResource("nvidia.com/gpu") {
+		t.Errorf("configured patterns not applied: %v", AcceleratorResources())
+	}
+}
+
+func TestNodeResourceUsageAccelerators(t *testing.T) {
+	node := &corev1.Node{Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("96"),
+		"habana.ai/gaudi":  resource.MustParse("8"),
+		"nvidia.com/gpu":   resource.MustParse("0"),
+	}}}
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{"habana.ai/gaudi": resource.MustParse("2")},
+				Limits:   corev1.ResourceList{"habana.ai/gaudi": resource.MustParse("2")},
+			},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	usage := nodeResourceUsage(node, []corev1.Pod{pod, pod})
+	if len(usage.Accelerators) != 1 {
+		t.Fatalf("expected only habana.ai/gaudi, got %+v", usage.Accelerators)
+	}
+	if a := usage.Accelerators[0]; a.Resource != "habana.ai/gaudi" || a.Allocatable != 8 || a.Requests != 4 || a.Limits != 4 {
+		t.Errorf("unexpected accelerator usage %+v", a)
+	}
+	if got := FormatAccelerators(usage.Accelerators); got != "habana.ai/gaudi 4/8" {
+		t.Errorf("FormatAccelerators = %q", got)
+	}
+
+	summary := SummarizeNodeResources([]NodeResourceUsage{*usage, *usage})
+	if len(summary.Accelerators) != 1 || summary.Accelerators[0].Allocatable != 16 {
+		t.Errorf("unexpected summary accelerators %+v", summary.Accelerators)
+	}
+}
+
+func TestContainerAccelerators(t *testing.T) {
+	c := corev1.Container{Resources: corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			"amd.com/gpu":    resource.MustParse("1"),
+			"nvidia.com/gpu": resource.MustParse("1"),
+		},
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}}
+	counts := containerAccelerators(c)
+	if got := FormatAcceleratorCounts(counts); got != "amd.com/gpu=1" {
+		t.Errorf("containerAccelerators = %q, want amd.com/gpu=1", got)
+	}
+}
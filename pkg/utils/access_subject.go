@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessSubject is who a permission check asks about. The zero value is the caller; otherwise
+// the API server answers for User and Groups as if they had made the request, the way
+// `kubectl auth can-i --as` does.
+type AccessSubject struct {
+	User   string
+	Groups []string
+}
+
+// NewAccessSubject builds the subject of --as, --as-group, and --as-serviceaccount <ns>/<name>.
+// A ServiceAccount gets the groups the API server adds to its tokens, so bindings to
+// system:serviceaccounts:<ns> are taken into account.
+func NewAccessSubject(user string, groups []string, serviceAccount string) (AccessSubject, error) {
+	subject := AccessSubject{User: user, Groups: groups}
+	if serviceAccount != "" {
+		if user != "" {
+			return AccessSubject{}, fmt.Errorf("--as and --as-serviceaccount are mutually exclusive")
+		}
+		namespace, name, ok := strings.Cut(serviceAccount, "/")
+		if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+			return AccessSubject{}, fmt.Errorf("invalid ServiceAccount %q, expected <namespace>/<name>", serviceAccount)
+		}
+		subject.User = "system:serviceaccount:" + namespace + ":" + name
+		subject.Groups = append([]string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}, groups...)
+	}
+	if subject.User == "" && len(subject.Groups) > 0 {
+		return AccessSubject{}, fmt.Errorf("--as-group requires --as or --as-serviceaccount")
+	}
+	return subject, nil
+}
+
+// IsSelf reports whether the subject is the caller
+func (s AccessSubject) IsSelf() bool {
+	return s.User == ""
+}
+
+func (s AccessSubject) String() string {
+	if s.IsSelf() {
+		return "current user"
+	}
+	return s.User
+}
+
+// reviewAccess asks the API server whether the subject may perform the action, with a
+// SelfSubjectAccessReview for the caller and a SubjectAccessReview for anyone else
+func (kc *KubernetesChecker) reviewAccess(ctx context.Context, subject AccessSubject, attrs authorizationv1.ResourceAttributes) (authorizationv1.SubjectAccessReviewStatus, error) {
+	if subject.IsSelf() {
+		ssar := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+		}
+		resp, err := kc.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, metav1.CreateOptions{})
+		if err != nil {
+			return authorizationv1.SubjectAccessReviewStatus{}, err
+		}
+		return resp.Status, nil
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attrs,
+			User:               subject.User,
+			Groups:             subject.Groups,
+		},
+	}
+	resp, err := kc.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+	if apierrors.IsForbidden(err) {
+		return authorizationv1.SubjectAccessReviewStatus{}, fmt.Errorf("checking another subject's permissions requires create on subjectaccessreviews.authorization.k8s.io: %v", err)
+	}
+	if err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, err
+	}
+	return resp.Status, nil
+}
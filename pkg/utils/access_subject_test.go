@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewAccessSubject(t *testing.T) {
+	subject, err := NewAccessSubject("", nil, "")
+	if err != nil || !subject.IsSelf() {
+		t.Errorf("Expected no flags to check the current user, got %+v (%v)", subject, err)
+	}
+
+	subject, err = NewAccessSubject("", []string{"deployers"}, "dynamo/argocd-deployer")
+	if err != nil {
+		t.Fatalf("NewAccessSubject returned error: %v", err)
+	}
+	if subject.User != "system:serviceaccount:dynamo:argocd-deployer" {
+		t.Errorf("Unexpected ServiceAccount user %q", subject.User)
+	}
+	want := []string{"system:serviceaccounts", "system:serviceaccounts:dynamo", "system:authenticated", "deployers"}
+	if !reflect.DeepEqual(subject.Groups, want) {
+		t.Errorf("Expected groups %v, got %v", want, subject.Groups)
+	}
+
+	subject, err = NewAccessSubject("jane", []string{"ops"}, "")
+	if err != nil || subject.User != "jane" || len(subject.Groups) != 1 {
+		t.Errorf("Unexpected user subject %+v (%v)", subject, err)
+	}
+
+	for _, tc := range []struct {
+		user, sa string
+		groups   []string
+	}{
+		{user: "jane", sa: "dynamo/deployer"},
+		{sa: "deployer"},
+		{sa: "dynamo/"},
+		{sa: "a/b/c"},
+		{groups: []string{"ops"}},
+	} {
+		if _, err := NewAccessSubject(tc.user, tc.groups, tc.sa); err == nil {
+			t.Errorf("Expected an error for --as %q --as-group %v --as-serviceaccount %q", tc.user, tc.groups, tc.sa)
+		}
+	}
+}
@@ -20,7 +20,7 @@ import (
 )
 
 // pullContainerImage pulls a container image using go-containerregistry
-func pullContainerImage(component Component, outputDir string) error {
+func pullContainerImage(component Component, outputDir string, opts RegistryClientOptions) error {
 	var reference string
 	if component.Tag != "" {
 		reference = fmt.Sprintf("%s:%s", component.URI, component.Tag)
@@ -50,14 +50,23 @@ func pullContainerImage(component Component, outputDir string) error {
 		return fmt.Errorf("failed to parse image reference: %v", err)
 	}
 
+	httpClient, err := opts.HTTPClient()
+	if err != nil {
+		return err
+	}
+	craneOpts := []crane.Option{crane.WithTransport(httpClient.Transport)}
+	if opts.PlainHTTP {
+		craneOpts = append(craneOpts, crane.Insecure)
+	}
+
 	LogInfo("  Downloading image layers...")
-	img, err := crane.Pull(reference)
+	img, err := crane.Pull(reference, craneOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to pull container image: %v", err)
 	}
 
 	// Save the image as a tar file in the outputDir
-	tarPath := filepath.Join(outputDir, fmt.Sprintf("%s.tar", component.Name))
+	tarPath := LongPath(filepath.Join(outputDir, SafeFileName(component.Name)+".tar"))
 	LogInfo("  Saving image to: %s", tarPath)
 
 	if err := crane.Save(img, ref.String(), tarPath); err != nil {
@@ -74,32 +83,11 @@ func pullContainerImage(component Component, outputDir string) error {
 }
 
 // pullHelmChart pulls a Helm chart using Helm Go library
-func pullHelmChart(component Component, outputDir string) error {
-	// Extract the chart name from the HarborPath
-	// HarborPath format: "oci://artifacts.dynamo.ai/dynamoai/3.22.2/charts/dynamoai-base-1.1.2.tgz"
-	// We need: "oci://artifacts.dynamo.ai/dynamoai/3.22.2/charts/dynamoai-base"
-
-	// Remove the .tgz extension first
-	basePath := strings.TrimSuffix(component.URI, ".tgz")
-
-	dirPath := path.Dir(basePath)
-	fileBase := path.Base(basePath)
-
-	if strings.HasSuffix(fileBase, "-"+component.Tag) {
-		fileBase = strings.TrimSuffix(fileBase, "-"+component.Tag)
-	}
-
-	repoPath := dirPath
-	if dirPath == "." || dirPath == "" {
-		repoPath = fileBase
-	} else if path.Base(dirPath) != fileBase {
-		repoPath = path.Join(dirPath, fileBase)
-	}
-
-	if repoPath == "" {
-		return fmt.Errorf("invalid chart path: %s", component.URI)
+func pullHelmChart(component Component, outputDir string, opts RegistryClientOptions) error {
+	repoPath, err := helmChartRepository(component.URI, component.Tag)
+	if err != nil {
+		return err
 	}
-
 	chartRef := fmt.Sprintf("oci://%s", repoPath)
 
 	LogInfo("📊 Pulling Helm chart...")
@@ -107,17 +95,20 @@ func pullHelmChart(component Component, outputDir string) error {
 	LogInfo("  Version: %s", component.Tag)
 	LogInfo("  Downloading chart files...")
 
+	registryClient, err := newHelmRegistryClient(opts)
+	if err != nil {
+		return err
+	}
 	settings := cli.New()
 	chartDownloader := downloader.ChartDownloader{
-		Out:     os.Stdout,
-		Getters: getter.All(settings),
-		Options: []getter.Option{
-			getter.WithPassCredentialsAll(true),
-		},
+		Out:            os.Stdout,
+		Getters:        getter.All(settings),
+		Options:        helmGetterOptions(opts, registryClient),
+		RegistryClient: registryClient,
 	}
 
 	// Download the chart to outputDir
-	_, _, err := chartDownloader.DownloadTo(chartRef, component.Tag, outputDir)
+	_, _, err = chartDownloader.DownloadTo(chartRef, component.Tag, LongPath(outputDir))
 	if err != nil {
 		return fmt.Errorf("failed to download Helm chart: %v", err)
 	}
@@ -132,8 +123,37 @@ func pullHelmChart(component Component, outputDir string) error {
 	return nil
 }
 
+// helmChartRepository returns the OCI repository of a chart from its manifest path
+func helmChartRepository(harborPath, version string) (string, error) {
+	// Extract the chart name from the HarborPath
+	// HarborPath format: "oci://artifacts.dynamo.ai/dynamoai/3.22.2/charts/dynamoai-base-1.1.2.tgz"
+	// We need: "artifacts.dynamo.ai/dynamoai/3.22.2/charts/dynamoai-base"
+
+	// Remove the scheme and the .tgz extension first
+	basePath := strings.TrimSuffix(strings.TrimPrefix(harborPath, "oci://"), ".tgz")
+
+	dirPath := path.Dir(basePath)
+	fileBase := path.Base(basePath)
+
+	if strings.HasSuffix(fileBase, "-"+version) {
+		fileBase = strings.TrimSuffix(fileBase, "-"+version)
+	}
+
+	repoPath := dirPath
+	if dirPath == "." || dirPath == "" {
+		repoPath = fileBase
+	} else if path.Base(dirPath) != fileBase {
+		repoPath = path.Join(dirPath, fileBase)
+	}
+
+	if repoPath == "" {
+		return "", fmt.Errorf("invalid chart path: %s", harborPath)
+	}
+	return repoPath, nil
+}
+
 // pullOrasArtifact pulls a non-container artifact using ORAS Go library
-func pullOrasArtifact(component Component, outputDir string) error {
+func pullOrasArtifact(component Component, outputDir string, opts RegistryClientOptions) error {
 	uri := component.URI
 	if !strings.Contains(uri, "/") {
 		return fmt.Errorf("invalid URI format: %s", uri)
@@ -158,7 +178,8 @@ func pullOrasArtifact(component Component, outputDir string) error {
 	} else {
 		artifactPath = fmt.Sprintf("%s.tar", component.Name)
 	}
-	artifactFullPath := filepath.Join(outputDir, artifactPath)
+	// Digest references contain a colon, which Windows does not allow in file names
+	artifactFullPath := LongPath(filepath.Join(outputDir, SafeFileName(artifactPath)))
 
 	store, err := file.New(artifactFullPath)
 	if err != nil {
@@ -166,20 +187,9 @@ func pullOrasArtifact(component Component, outputDir string) error {
 	}
 	defer store.Close()
 
-	repo, err := remote.NewRepository(repoPart)
+	repo, err := newOrasRepository(repoPart, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create ORAS repository for '%s': %v", repoPart, err)
-	}
-
-	// Use credentials for authentication
-	repo.Client = &oras_auth.Client{
-		Credential: func(ctx context.Context, registry string) (oras_auth.Credential, error) {
-			cred, err := resolveRegistryCredential(registry)
-			if err != nil {
-				return oras_auth.Credential{}, err
-			}
-			return cred, nil
-		},
+		return err
 	}
 
 	_, err = oras.Copy(context.Background(), repo, refPart, store, "", oras.DefaultCopyOptions)
@@ -251,14 +261,14 @@ func PullManifestFromRegistry(reference, outputDir string) error {
 //
 //	"artifacts.dynamo.ai/dynamoai/models/foo@sha256:abcd" -> ("artifacts.dynamo.ai/dynamoai/models/foo", "sha256:abcd")
 func splitRepositoryAndReference(uri string) (repo, ref string) {
-	if i := strings.LastIndex(uri, ":"); i != -1 && !strings.Contains(uri[i+1:], "/") {
-		// Tag
+	if i := strings.LastIndex(uri, "@"); i != -1 {
+		// Digest, checked first as the digest itself contains a colon
 		repo = uri[:i]
 		ref = uri[i+1:]
 		return
 	}
-	if i := strings.LastIndex(uri, "@"); i != -1 {
-		// Digest
+	if i := strings.LastIndex(uri, ":"); i != -1 && !strings.Contains(uri[i+1:], "/") {
+		// Tag
 		repo = uri[:i]
 		ref = uri[i+1:]
 		return
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// artifactoryAPIPath is the JFrog Artifactory REST API root
+const artifactoryAPIPath = "/artifactory/api"
+
+// artifactoryRepository is the subset of an Artifactory repository configuration the preflight uses
+type artifactoryRepository struct {
+	Key                   string `json:"key"`
+	RClass                string `json:"rclass"`
+	PackageType           string `json:"packageType"`
+	DefaultDeploymentRepo string `json:"defaultDeploymentRepo"`
+}
+
+// artifactoryTarget validates Docker repositories in JFrog Artifactory. With the default
+// repository-path access method the repository key is the first path segment of each pushed
+// repository; with the subdomain method it is the first label of the host.
+type artifactoryTarget struct {
+	api      registryAPI
+	host     string
+	username string
+	// subdomainKey is set when the host itself names the repository
+	subdomainKey string
+}
+
+func newArtifactoryTarget(api registryAPI, host, username string) *artifactoryTarget {
+	return &artifactoryTarget{api: api.withPath(artifactoryAPIPath), host: host, username: username}
+}
+
+func (t *artifactoryTarget) Kind() string { return "Artifactory" }
+
+func (t *artifactoryTarget) detect(ctx context.Context) bool {
+	var info struct {
+		Version string `json:"version"`
+	}
+	status, err := t.api.do(ctx, http.MethodGet, "/system/version", nil, &info)
+	if err != nil || status != http.StatusOK || info.Version == "" {
+		LogDebug("Registry is not Artifactory (status %d): %v", status, err)
+		return false
+	}
+	LogDebug("Detected Artifactory %s", info.Version)
+	return true
+}
+
+func (t *artifactoryTarget) Prepare(ctx context.Context, repositories []string) error {
+	keys := map[string]int64{}
+	for _, repo := range repositories {
+		keys[firstPathSegment(repo)] = 0
+	}
+
+	// The subdomain access method puts the repository key in the host instead of the path
+	label, _, _ := strings.Cut(t.host, ".")
+	if repo, err := t.repository(ctx, label); err == nil && repo != nil && strings.EqualFold(repo.PackageType, "docker") {
+		t.subdomainKey = label
+		keys = map[string]int64{label: 0}
+	}
+
+	var problems []string
+	for _, key := range sortedKeys(keys) {
+		if key == "" {
+			problems = append(problems, "the target needs a repository key path, e.g. "+t.host+"/docker-local")
+			continue
+		}
+		repo, err := t.repository(ctx, key)
+		if err != nil {
+			return err
+		}
+		if repo == nil {
+			problems = append(problems, fmt.Sprintf("repository %s does not exist", key))
+			continue
+		}
+		if problem := checkArtifactoryRepository(repo); problem != "" {
+			problems = append(problems, problem)
+			continue
+		}
+		t.checkDeployPermission(ctx, key)
+	}
+
+	if t.subdomainKey == "" {
+		for _, repo := range repositories {
+			if !strings.Contains(repositoryPath(repo), "/") {
+				problems = append(problems, fmt.Sprintf("%s has no image path after the repository key %s", repo, firstPathSegment(repo)))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("artifactory target is not ready: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CheckCapacity accepts any size; Artifactory storage quotas are system wide, not per repository
+func (t *artifactoryTarget) CheckCapacity(ctx context.Context, sizes map[string]int64) error {
+	return nil
+}
+
+// TargetRepository lowercases the image path, which Artifactory requires for Docker repositories
+func (t *artifactoryTarget) TargetRepository(repository string) string {
+	host, path, ok := strings.Cut(repository, "/")
+	if !ok {
+		return repository
+	}
+	return host + "/" + strings.ToLower(path)
+}
+
+// repository returns the named repository, or nil when it does not exist
+func (t *artifactoryTarget) repository(ctx context.Context, key string) (*artifactoryRepository, error) {
+	var repo artifactoryRepository
+	status, err := t.api.do(ctx, http.MethodGet, "/repositories/"+url.PathEscape(key), nil, &repo)
+	if status == http.StatusNotFound || status == http.StatusBadRequest {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up Artifactory repository %s: %w", key, err)
+	}
+	return &repo, nil
+}
+
+// checkDeployPermission warns when the effective permissions show the account cannot deploy.
+// Reading them needs the Manage permission, so an unreadable answer is only logged.
+func (t *artifactoryTarget) checkDeployPermission(ctx context.Context, key string) {
+	var perms struct {
+		Principals struct {
+			Users map[string][]string `json:"users"`
+		} `json:"principals"`
+	}
+	if _, err := t.api.do(ctx, http.MethodGet, "/storage/"+url.PathEscape(key)+"?permissions", nil, &perms); err != nil {
+		LogDebug("Could not read permissions on %s: %v", key, err)
+		return
+	}
+	if actions, ok := perms.Principals.Users[t.username]; ok && !slices.Contains(actions, "w") {
+		LogWarning("Artifactory user %s does not have deploy permission on %s; pushes will be rejected", t.username, key)
+	}
+}
+
+// checkArtifactoryRepository explains why a repository cannot receive Docker pushes
+func checkArtifactoryRepository(repo *artifactoryRepository) string {
+	switch {
+	case !strings.EqualFold(repo.PackageType, "docker"):
+		return fmt.Sprintf("repository %s is a %s repository, not docker", repo.Key, repo.PackageType)
+	case repo.RClass == "remote":
+		return fmt.Sprintf("repository %s is a remote (proxy) repository and cannot be pushed to", repo.Key)
+	case repo.RClass == "virtual" && repo.DefaultDeploymentRepo == "":
+		return fmt.Sprintf("virtual repository %s has no default deployment repository", repo.Key)
+	}
+	return ""
+}
@@ -10,6 +10,7 @@ import (
 
 // ArtifactManifest represents the structure of the manifest file
 type ArtifactManifest struct {
+	SchemaVersion      int       `json:"schema_version,omitempty"`
 	CustomerID         string    `json:"customer_id"`
 	CustomerName       string    `json:"customer_name"`
 	ReleaseVersion     string    `json:"release_version"`
@@ -22,6 +23,16 @@ type ArtifactManifest struct {
 	Images             []string  `json:"images"` // Array of OCI URIs
 	Models             []string  `json:"models"` // Array of OCI URIs
 	Charts             []Chart   `json:"charts"`
+	Datasets           []Dataset `json:"datasets,omitempty"`
+	License            *License  `json:"license,omitempty"`
+	// Entitlements are the products the customer licensed
+	Entitlements []string `json:"entitlements,omitempty"`
+	// Products maps each licensable product to its artifacts; artifacts no product lists are
+	// shared by all products
+	Products map[string]Product `json:"products,omitempty"`
+	// TargetOverrides routes source repositories elsewhere when mirroring, keyed by source
+	// repository
+	TargetOverrides map[string]TargetOverride `json:"target_overrides,omitempty"`
 }
 
 // SPOC represents the Single Point of Contact
@@ -35,6 +46,8 @@ type Artifacts struct {
 	ChartsRoot string `json:"charts_root"`
 	ImagesRoot string `json:"images_root"`
 	ModelsRoot string `json:"models_root"`
+	// DatasetsRoot is optional; only releases that ship evaluation datasets set it
+	DatasetsRoot string `json:"datasets_root,omitempty"`
 }
 
 // Chart represents a Helm chart with additional metadata
@@ -48,6 +61,29 @@ type Chart struct {
 	SizeBytes  int64  `json:"size_bytes"`
 }
 
+// Dataset represents an evaluation dataset shipped as an OCI artifact
+type Dataset struct {
+	Name string `json:"name"`
+	// URI is the OCI reference of the dataset artifact, with a tag or digest
+	URI string `json:"uri"`
+	// SHA256 is the checksum of the dataset file, which is the artifact's layer digest
+	SHA256    string `json:"sha256"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	// TargetPath is where the dataset is placed, relative to the datasets directory; it
+	// defaults to the dataset name
+	TargetPath string `json:"target_path,omitempty"`
+}
+
+// Product lists the artifacts that belong to a licensable product
+type Product struct {
+	// Images and Models are URIs as listed in the manifest
+	Images []string `json:"images,omitempty"`
+	Models []string `json:"models,omitempty"`
+	// Charts and Datasets are names
+	Charts   []string `json:"charts,omitempty"`
+	Datasets []string `json:"datasets,omitempty"`
+}
+
 // Component represents a unified artifact component for processing
 type Component struct {
 	Name      string
@@ -56,6 +92,10 @@ type Component struct {
 	Tag       string
 	Digest    string
 	MediaType string
+	// SHA256 is the expected checksum of the artifact's content, when the manifest gives one
+	SHA256 string
+	// TargetPath places the artifact under the output directory
+	TargetPath string
 }
 
 // PullResult represents the result of pulling artifacts
@@ -65,6 +105,8 @@ type PullResult struct {
 	FailedCount    int
 	Duration       time.Duration
 	Errors         []string
+	// RateLimits are the rate limits the registries reported or enforced
+	RateLimits []RateLimitStatus
 }
 
 // PullOptions controls which artifact categories are processed.
@@ -72,21 +114,35 @@ type PullOptions struct {
 	IncludeImages bool
 	IncludeModels bool
 	IncludeCharts bool
+	// IncludeDatasets selects evaluation datasets
+	IncludeDatasets bool
+	// IncludeLicense selects the license file
+	IncludeLicense bool
+	// Registry controls how the source registry is reached
+	Registry RegistryClientOptions
+	// Journal, when set, records each pulled artifact and skips those an earlier run recorded
+	Journal *MirrorJournal
 }
 
 // NormalizePullOptions enables all artifact categories if none are explicitly selected.
 func NormalizePullOptions(opts PullOptions) PullOptions {
+	if opts.IncludeLicense {
+		// An explicit license selection keeps the other categories as given
+		return opts
+	}
+	opts.IncludeLicense = !opts.IncludeImages && !opts.IncludeModels && !opts.IncludeCharts && !opts.IncludeDatasets
 	normalized := MirrorOptions{
-		IncludeImages: opts.IncludeImages,
-		IncludeModels: opts.IncludeModels,
-		IncludeCharts: opts.IncludeCharts,
+		IncludeImages:   opts.IncludeImages,
+		IncludeModels:   opts.IncludeModels,
+		IncludeCharts:   opts.IncludeCharts,
+		IncludeDatasets: opts.IncludeDatasets,
 	}
 	normalized = NormalizeMirrorOptions(normalized)
-	return PullOptions{
-		IncludeImages: normalized.IncludeImages,
-		IncludeModels: normalized.IncludeModels,
-		IncludeCharts: normalized.IncludeCharts,
-	}
+	opts.IncludeImages = normalized.IncludeImages
+	opts.IncludeModels = normalized.IncludeModels
+	opts.IncludeCharts = normalized.IncludeCharts
+	opts.IncludeDatasets = normalized.IncludeDatasets
+	return opts
 }
 
 // LoadManifest loads and parses the manifest file
@@ -105,9 +161,24 @@ func LoadManifest(filename string) (*ArtifactManifest, error) {
 	return &manifest, nil
 }
 
+// WriteManifest writes a manifest as indented JSON
+func WriteManifest(filename string, manifest *ArtifactManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	if err := writeFileAtomic(filename, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write manifest file: %v", err)
+	}
+	return nil
+}
+
 // PullArtifacts pulls all artifacts specified in the manifest from Harbor
 func PullArtifacts(manifest *ArtifactManifest, outputDir string, options PullOptions) error {
 	options = NormalizePullOptions(options)
+	if options.Registry.RateLimits == nil {
+		options.Registry.RateLimits = NewRateLimitTracker()
+	}
 
 	components := convertManifestToComponents(manifest, options)
 
@@ -118,12 +189,20 @@ func PullArtifacts(manifest *ArtifactManifest, outputDir string, options PullOpt
 	// Display component breakdown
 	displayComponentBreakdown(components)
 
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
+	names := make([]string, len(components))
+	for i, component := range components {
+		names[i] = component.Name
+	}
+	if err := checkCaseCollisions("artifact names", names); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(LongPath(outputDir), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %v", err)
 	}
 
 	// Pull all artifacts and collect results
-	result := pullAllArtifacts(components, outputDir)
+	result := pullAllArtifacts(components, outputDir, options)
 
 	// Display summary
 	displayPullSummary(result)
@@ -140,33 +219,27 @@ func PullArtifacts(manifest *ArtifactManifest, outputDir string, options PullOpt
 func displayComponentBreakdown(components []Component) {
 	LogInfo("Components breakdown:")
 
-	imageCount := 0
-	modelCount := 0
-	chartCount := 0
+	counts := map[string]int{}
 	for _, comp := range components {
-		switch comp.Type {
-		case "containerImage":
-			imageCount++
-		case "mlModel":
-			modelCount++
-		case "helmChart":
-			chartCount++
-		}
+		counts[comp.Type]++
 	}
-
-	if imageCount > 0 {
-		LogInfo("  - Container Images: %d", imageCount)
+	for _, kind := range ArtifactKinds() {
+		if counts[kind.Type] > 0 {
+			LogInfo("  - %s: %d", kind.Label, counts[kind.Type])
+			delete(counts, kind.Type)
+		}
 	}
-	if modelCount > 0 {
-		LogInfo("  - ML Models: %d", modelCount)
+	other := 0
+	for _, n := range counts {
+		other += n
 	}
-	if chartCount > 0 {
-		LogInfo("  - Helm Charts: %d", chartCount)
+	if other > 0 {
+		LogInfo("  - Other Artifacts: %d", other)
 	}
 }
 
 // pullAllArtifacts pulls all artifacts and returns a summary
-func pullAllArtifacts(components []Component, outputDir string) PullResult {
+func pullAllArtifacts(components []Component, outputDir string, options PullOptions) PullResult {
 	startTime := time.Now()
 	result := PullResult{
 		TotalArtifacts: len(components),
@@ -178,8 +251,15 @@ func pullAllArtifacts(components []Component, outputDir string) PullResult {
 	for i, component := range components {
 		displayArtifactHeader(i+1, len(components), component)
 
+		source := componentReference(component)
+		if _, done := options.Journal.Completed(JournalStagePulled, source, ""); done {
+			LogInfo("⏭️  Already pulled by an earlier run; skipping %s", component.Name)
+			result.SuccessCount++
+			continue
+		}
+
 		artifactStartTime := time.Now()
-		if err := pullSingleArtifact(component, outputDir); err != nil {
+		if err := pullSingleArtifact(component, outputDir, options.Registry); err != nil {
 			LogError("❌ Failed to pull artifact %s: %v", component.Name, err)
 			result.FailedCount++
 			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", component.Name, err))
@@ -187,13 +267,25 @@ func pullAllArtifacts(components []Component, outputDir string) PullResult {
 			artifactDuration := time.Since(artifactStartTime)
 			LogInfo("✅ Successfully pulled %s in %v", component.Name, artifactDuration)
 			result.SuccessCount++
+			if err := options.Journal.Record(JournalEntry{Stage: JournalStagePulled, Type: component.Type, Source: source}); err != nil {
+				LogWarning("%v", err)
+			}
 		}
 	}
 
 	result.Duration = time.Since(startTime)
+	result.RateLimits = options.Registry.RateLimits.Statuses()
 	return result
 }
 
+// componentReference is the reference a component is pulled from
+func componentReference(component Component) string {
+	if component.Tag != "" {
+		return component.URI + ":" + component.Tag
+	}
+	return component.URI
+}
+
 // displayArtifactHeader displays the header for each artifact being pulled
 func displayArtifactHeader(current, total int, component Component) {
 	fmt.Println("------------------------------------------------------------")
@@ -216,6 +308,14 @@ func displayPullSummary(result PullResult) {
 	LogInfo("Total time: %v", result.Duration)
 	LogInfo("Successful: %d", result.SuccessCount)
 	LogInfo("Failed: %d", result.FailedCount)
+	for _, status := range result.RateLimits {
+		LogInfo("Rate limit %s", status)
+	}
+}
+
+// ManifestComponents returns the artifacts in a manifest selected by options
+func ManifestComponents(manifest *ArtifactManifest, options PullOptions) []Component {
+	return convertManifestToComponents(manifest, NormalizePullOptions(options))
 }
 
 // convertManifestToComponents converts the new manifest format to unified components
@@ -228,10 +328,10 @@ func convertManifestToComponents(manifest *ArtifactManifest, options PullOptions
 			uri := strings.TrimPrefix(imgURI, "oci://")
 			components = append(components, Component{
 				Name:      extractNameFromURI(uri),
-				Type:      "containerImage",
+				Type:      ArtifactTypeContainerImage,
 				URI:       uri,
 				Tag:       "",
-				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				MediaType: artifactMediaType(ArtifactTypeContainerImage),
 			})
 		}
 	}
@@ -242,10 +342,10 @@ func convertManifestToComponents(manifest *ArtifactManifest, options PullOptions
 			uri := strings.TrimPrefix(modelURI, "oci://")
 			components = append(components, Component{
 				Name:      extractNameFromURI(uri),
-				Type:      "mlModel",
+				Type:      ArtifactTypeMLModel,
 				URI:       uri,
 				Tag:       "",
-				MediaType: "application/vnd.dynamoai.model.v1+tar.gz",
+				MediaType: artifactMediaType(ArtifactTypeMLModel),
 			})
 		}
 	}
@@ -256,14 +356,44 @@ func convertManifestToComponents(manifest *ArtifactManifest, options PullOptions
 			uri := strings.TrimPrefix(chart.HarborPath, "oci://")
 			components = append(components, Component{
 				Name:      chart.Name,
-				Type:      "helmChart",
+				Type:      ArtifactTypeHelmChart,
 				URI:       uri,
 				Tag:       chart.Version,
-				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				MediaType: artifactMediaType(ArtifactTypeHelmChart),
 			})
 		}
 	}
 
+	// Convert datasets to components
+	if options.IncludeDatasets {
+		for _, dataset := range manifest.Datasets {
+			uri := strings.TrimPrefix(dataset.URI, "oci://")
+			name := dataset.Name
+			if name == "" {
+				name = extractNameFromURI(uri)
+			}
+			components = append(components, Component{
+				Name:       name,
+				Type:       ArtifactTypeDataset,
+				URI:        uri,
+				MediaType:  artifactMediaType(ArtifactTypeDataset),
+				SHA256:     strings.TrimPrefix(dataset.SHA256, "sha256:"),
+				TargetPath: dataset.TargetPath,
+			})
+		}
+	}
+
+	// The license is a single file stored next to the release
+	if options.IncludeLicense && manifest.License != nil && manifest.License.URI != "" {
+		components = append(components, Component{
+			Name:      ArtifactTypeLicense,
+			Type:      ArtifactTypeLicense,
+			URI:       strings.TrimPrefix(manifest.License.URI, "oci://"),
+			MediaType: artifactMediaType(ArtifactTypeLicense),
+			SHA256:    strings.TrimPrefix(manifest.License.SHA256, "sha256:"),
+		})
+	}
+
 	return components
 }
 
@@ -279,16 +409,9 @@ func extractNameFromURI(uri string) string {
 	return uri
 }
 
-// pullSingleArtifact pulls a single artifact from Harbor
-func pullSingleArtifact(component Component, outputDir string) error {
-	switch component.Type {
-	case "containerImage":
-		return pullContainerImage(component, outputDir)
-	case "helmChart":
-		return pullHelmChart(component, outputDir)
-	default:
-		return pullOrasArtifact(component, outputDir)
-	}
+// pullSingleArtifact pulls a single artifact from Harbor with the puller registered for its kind
+func pullSingleArtifact(component Component, outputDir string, registry RegistryClientOptions) error {
+	return pullerFor(component).Pull(component, outputDir, registry)
 }
 
 // CheckHarborLogin checks if the user is logged into Harbor
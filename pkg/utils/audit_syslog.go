@@ -0,0 +1,25 @@
+//go:build !windows && !plan9
+
+package utils
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// writeSyslog sends an audit line to the local syslog daemon under the dynactl tag
+func writeSyslog(message string, failed bool) error {
+	priority := syslog.LOG_AUTHPRIV | syslog.LOG_NOTICE
+	if failed {
+		priority = syslog.LOG_AUTHPRIV | syslog.LOG_WARNING
+	}
+	w, err := syslog.New(priority, "dynactl")
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer w.Close()
+	if failed {
+		return w.Warning(message)
+	}
+	return w.Notice(message)
+}
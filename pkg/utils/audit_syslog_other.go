@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package utils
+
+import "fmt"
+
+// writeSyslog is unavailable on platforms without a syslog daemon
+func writeSyslog(message string, failed bool) error {
+	return fmt.Errorf("syslog is not supported on this platform")
+}
@@ -0,0 +1,574 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// awsRefreshWindow is how long before expiry temporary credentials are fetched again
+const awsRefreshWindow = 5 * time.Minute
+
+// awsMetadataTimeout bounds calls to the instance metadata service, which does not answer at
+// all off EC2
+const awsMetadataTimeout = 2 * time.Second
+
+// awsCredentials is an access key pair with an optional session token. Expires is set for
+// temporary credentials.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+// expiring reports whether temporary credentials should be fetched again
+func (c awsCredentials) expiring() bool {
+	return !c.Expires.IsZero() && time.Until(c.Expires) < awsRefreshWindow
+}
+
+// errNoAWSCredentials marks a credential source that is not configured, so the chain moves on
+var errNoAWSCredentials = errors.New("no AWS credentials")
+
+// awsConfig holds the profiles from the shared AWS config and credentials files
+type awsConfig struct {
+	profile string
+	// explicit is set when the profile was named by AWS_PROFILE rather than defaulted
+	explicit    bool
+	profiles    map[string]map[string]string
+	ssoSessions map[string]map[string]string
+	client      *http.Client
+}
+
+// loadAWSConfig reads ~/.aws/config and ~/.aws/credentials, or the files named by
+// AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE. Missing files are not an error.
+func loadAWSConfig(client *http.Client) (*awsConfig, error) {
+	cfg := &awsConfig{
+		profile:     awsProfileName(),
+		explicit:    firstEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE") != "",
+		profiles:    map[string]map[string]string{},
+		ssoSessions: map[string]map[string]string{},
+		client:      client,
+	}
+
+	home, _ := os.UserHomeDir()
+	configPath := os.Getenv("AWS_CONFIG_FILE")
+	if configPath == "" {
+		configPath = filepath.Join(home, ".aws", "config")
+	}
+	credentialsPath := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsPath == "" {
+		credentialsPath = filepath.Join(home, ".aws", "credentials")
+	}
+
+	sections, err := readINISections(configPath)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range sections {
+		switch {
+		case name == "default":
+			cfg.mergeProfile(name, values)
+		case strings.HasPrefix(name, "profile "):
+			cfg.mergeProfile(strings.TrimSpace(strings.TrimPrefix(name, "profile ")), values)
+		case strings.HasPrefix(name, "sso-session "):
+			cfg.ssoSessions[strings.TrimSpace(strings.TrimPrefix(name, "sso-session "))] = values
+		}
+	}
+	// The credentials file wins over the config file for the same profile
+	sections, err = readINISections(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range sections {
+		cfg.mergeProfile(name, values)
+	}
+	return cfg, nil
+}
+
+// awsProfileName returns the AWS profile in use: AWS_PROFILE, AWS_DEFAULT_PROFILE, or default
+func awsProfileName() string {
+	if profile := firstEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+func (c *awsConfig) mergeProfile(name string, values map[string]string) {
+	profile := c.profiles[name]
+	if profile == nil {
+		profile = map[string]string{}
+		c.profiles[name] = profile
+	}
+	for key, value := range values {
+		profile[key] = value
+	}
+}
+
+// region returns AWS_REGION, AWS_DEFAULT_REGION, or the profile's region
+func (c *awsConfig) region() string {
+	if region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+	return c.profiles[c.profile]["region"]
+}
+
+// credentials walks the default credential chain: environment keys, the profile, a web
+// identity token (IRSA), the container credentials endpoint (ECS, EKS Pod Identity), and the
+// EC2 instance metadata service
+func (c *awsConfig) credentials(ctx context.Context) (awsCredentials, error) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	sources := []func(context.Context) (awsCredentials, error){
+		func(ctx context.Context) (awsCredentials, error) { return c.profileCredentials(ctx, c.profile, 0) },
+		c.webIdentityCredentials,
+		c.containerCredentials,
+		c.instanceCredentials,
+	}
+	for _, source := range sources {
+		creds, err := source(ctx)
+		if errors.Is(err, errNoAWSCredentials) {
+			continue
+		}
+		return creds, err
+	}
+	return awsCredentials{}, fmt.Errorf("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, configure a profile in ~/.aws, or run with an IAM role")
+}
+
+// profileCredentials resolves a profile's static keys, role, SSO login, or credential process
+func (c *awsConfig) profileCredentials(ctx context.Context, name string, depth int) (awsCredentials, error) {
+	profile, ok := c.profiles[name]
+	if !ok {
+		// A profile the user asked for must exist; the implicit default may not
+		if c.explicit || depth > 0 {
+			return awsCredentials{}, fmt.Errorf("AWS profile %s is not configured", name)
+		}
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	if depth > 5 {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: source_profile chain is too long", name)
+	}
+
+	static := awsCredentials{
+		AccessKeyID:     profile["aws_access_key_id"],
+		SecretAccessKey: profile["aws_secret_access_key"],
+		SessionToken:    profile["aws_session_token"],
+	}
+	switch {
+	case profile["role_arn"] != "":
+		return c.roleCredentials(ctx, name, profile, static, depth)
+	case profile["sso_account_id"] != "":
+		return c.ssoCredentials(ctx, name, profile)
+	case profile["credential_process"] != "":
+		return processCredentials(ctx, name, profile["credential_process"])
+	case static.AccessKeyID != "" && static.SecretAccessKey != "":
+		return static, nil
+	}
+	if c.explicit || depth > 0 {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s has no credentials", name)
+	}
+	return awsCredentials{}, errNoAWSCredentials
+}
+
+// roleCredentials assumes a profile's role_arn using its web identity token file, source
+// profile, or credential source
+func (c *awsConfig) roleCredentials(ctx context.Context, name string, profile map[string]string, static awsCredentials, depth int) (awsCredentials, error) {
+	session := profile["role_session_name"]
+	if session == "" {
+		session = fmt.Sprintf("dynactl-%d", time.Now().Unix())
+	}
+	if tokenFile := profile["web_identity_token_file"]; tokenFile != "" {
+		return c.assumeRoleWithWebIdentity(ctx, profile["role_arn"], session, tokenFile)
+	}
+
+	var base awsCredentials
+	var err error
+	switch source := profile["source_profile"]; {
+	case source == name:
+		// A profile may hold the keys that assume its own role
+		base = static
+	case source != "":
+		base, err = c.profileCredentials(ctx, source, depth+1)
+	default:
+		switch profile["credential_source"] {
+		case "Environment":
+			base = awsCredentials{AccessKeyID: os.Getenv("AWS_ACCESS_KEY_ID"), SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: os.Getenv("AWS_SESSION_TOKEN")}
+		case "EcsContainer":
+			base, err = c.containerCredentials(ctx)
+		case "Ec2InstanceMetadata":
+			base, err = c.instanceCredentials(ctx)
+		default:
+			return awsCredentials{}, fmt.Errorf("AWS profile %s has role_arn but no source_profile, credential_source, or web_identity_token_file", name)
+		}
+	}
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: %w", name, err)
+	}
+	if base.AccessKeyID == "" || base.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: no credentials to assume %s with", name, profile["role_arn"])
+	}
+
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {profile["role_arn"]},
+		"RoleSessionName": {session},
+	}
+	if externalID := profile["external_id"]; externalID != "" {
+		form.Set("ExternalId", externalID)
+	}
+	var resp struct {
+		Credentials stsCredentials `xml:"AssumeRoleResult>Credentials"`
+	}
+	if err := c.callSTS(ctx, form, &base, &resp); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to assume %s: %w", profile["role_arn"], err)
+	}
+	return resp.Credentials.awsCredentials(), nil
+}
+
+// webIdentityCredentials exchanges AWS_WEB_IDENTITY_TOKEN_FILE for AWS_ROLE_ARN, as IRSA on EKS
+// configures
+func (c *awsConfig) webIdentityCredentials(ctx context.Context) (awsCredentials, error) {
+	tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || role == "" {
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = fmt.Sprintf("dynactl-%d", time.Now().Unix())
+	}
+	return c.assumeRoleWithWebIdentity(ctx, role, session, tokenFile)
+}
+
+func (c *awsConfig) assumeRoleWithWebIdentity(ctx context.Context, role, session, tokenFile string) (awsCredentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	var resp struct {
+		Credentials stsCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := c.callSTS(ctx, form, nil, &resp); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to assume %s with a web identity: %w", role, err)
+	}
+	return resp.Credentials.awsCredentials(), nil
+}
+
+// stsCredentials is the Credentials element of an STS response
+type stsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+func (s stsCredentials) awsCredentials() awsCredentials {
+	return awsCredentials{AccessKeyID: s.AccessKeyID, SecretAccessKey: s.SecretAccessKey, SessionToken: s.SessionToken, Expires: s.Expiration}
+}
+
+// callSTS posts a query API request to STS, signing it when creds is set. The regional endpoint
+// is used when a region is configured; AWS_ENDPOINT_URL_STS overrides it.
+func (c *awsConfig) callSTS(ctx context.Context, form url.Values, creds *awsCredentials, out interface{}) error {
+	region := c.region()
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_STS")
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if region != "" {
+			endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", region)
+		}
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	target, err := url.Parse(endpoint)
+	if err != nil || target.Host == "" {
+		return fmt.Errorf("invalid STS endpoint %q", endpoint)
+	}
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Scheme+"://"+target.Host+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if creds != nil {
+		signAWSRequest(req, target.Host, "/", "", body, *creds, region, "sts", time.Now().UTC())
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+			return fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, e.Code, e.Message)
+		}
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return xml.Unmarshal(data, out)
+}
+
+// ssoCredentials exchanges the token cached by `aws sso login` for the profile's role
+func (c *awsConfig) ssoCredentials(ctx context.Context, name string, profile map[string]string) (awsCredentials, error) {
+	startURL, region, cacheKey := profile["sso_start_url"], profile["sso_region"], profile["sso_start_url"]
+	if sessionName := profile["sso_session"]; sessionName != "" {
+		session, ok := c.ssoSessions[sessionName]
+		if !ok {
+			return awsCredentials{}, fmt.Errorf("AWS profile %s names sso-session %s, which is not configured", name, sessionName)
+		}
+		startURL, region, cacheKey = session["sso_start_url"], session["sso_region"], sessionName
+	}
+	if startURL == "" || region == "" {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s needs sso_start_url and sso_region", name)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	sum := sha1.Sum([]byte(cacheKey))
+	data, err := os.ReadFile(filepath.Join(home, ".aws", "sso", "cache", hex.EncodeToString(sum[:])+".json"))
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: no SSO login found; run aws sso login --profile %s", name, name)
+	}
+	var cached struct {
+		AccessToken string    `json:"accessToken"`
+		ExpiresAt   time.Time `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: failed to parse cached SSO token: %w", name, err)
+	}
+	if cached.AccessToken == "" || time.Now().After(cached.ExpiresAt) {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: the SSO login has expired; run aws sso login --profile %s", name, name)
+	}
+
+	query := url.Values{"account_id": {profile["sso_account_id"]}, "role_name": {profile["sso_role_name"]}}
+	endpoint := fmt.Sprintf("https://portal.sso.%s.amazonaws.com/federation/credentials?%s", region, query.Encode())
+	var resp struct {
+		RoleCredentials struct {
+			AccessKeyID     string `json:"accessKeyId"`
+			SecretAccessKey string `json:"secretAccessKey"`
+			SessionToken    string `json:"sessionToken"`
+			Expiration      int64  `json:"expiration"`
+		} `json:"roleCredentials"`
+	}
+	if err := c.getJSON(ctx, endpoint, map[string]string{"x-amz-sso_bearer_token": cached.AccessToken}, &resp); err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: failed to get SSO role credentials: %w", name, err)
+	}
+	return awsCredentials{
+		AccessKeyID:     resp.RoleCredentials.AccessKeyID,
+		SecretAccessKey: resp.RoleCredentials.SecretAccessKey,
+		SessionToken:    resp.RoleCredentials.SessionToken,
+		Expires:         time.UnixMilli(resp.RoleCredentials.Expiration),
+	}, nil
+}
+
+// processCredentials runs a profile's credential_process and parses its JSON output
+func processCredentials(ctx context.Context, name, command string) (awsCredentials, error) {
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: credential_process failed: %w", name, err)
+	}
+	var result awsCredentialsDocument
+	if err := json.Unmarshal(out, &result); err != nil {
+		return awsCredentials{}, fmt.Errorf("AWS profile %s: failed to parse credential_process output: %w", name, err)
+	}
+	return result.awsCredentials(), nil
+}
+
+// awsCredentialsDocument is the JSON returned by credential processes, the container
+// credentials endpoint, and the instance metadata service
+type awsCredentialsDocument struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func (d awsCredentialsDocument) awsCredentials() awsCredentials {
+	creds := awsCredentials{AccessKeyID: d.AccessKeyID, SecretAccessKey: d.SecretAccessKey, SessionToken: d.SessionToken, Expires: d.Expiration}
+	if creds.SessionToken == "" {
+		creds.SessionToken = d.Token
+	}
+	return creds
+}
+
+// containerCredentials reads the endpoint ECS and EKS Pod Identity expose through
+// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI
+func (c *awsConfig) containerCredentials(ctx context.Context) (awsCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = "http://169.254.170.2" + relative
+	}
+	if endpoint == "" {
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	headers := map[string]string{}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		headers["Authorization"] = token
+	}
+	var doc awsCredentialsDocument
+	if err := c.getJSON(ctx, endpoint, headers, &doc); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get container credentials: %w", err)
+	}
+	return doc.awsCredentials(), nil
+}
+
+// instanceCredentials reads the instance profile's role from the EC2 instance metadata service
+// with an IMDSv2 session token. AWS_EC2_METADATA_DISABLED turns it off and
+// AWS_EC2_METADATA_SERVICE_ENDPOINT moves it.
+func (c *awsConfig) instanceCredentials(ctx context.Context) (awsCredentials, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+	ctx, cancel := context.WithTimeout(ctx, awsMetadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Not on EC2, or the hop limit keeps the token from reaching this container
+		LogDebug("EC2 instance metadata not reachable: %v", err)
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	token, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		LogDebug("EC2 instance metadata token request failed: HTTP %d", resp.StatusCode)
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": string(token)}
+
+	roleURL := endpoint + "/latest/meta-data/iam/security-credentials/"
+	role, err := c.getText(ctx, roleURL, headers)
+	if err != nil {
+		LogDebug("No instance profile role: %v", err)
+		return awsCredentials{}, errNoAWSCredentials
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	var doc awsCredentialsDocument
+	if err := c.getJSON(ctx, roleURL+role, headers, &doc); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get instance profile credentials: %w", err)
+	}
+	return doc.awsCredentials(), nil
+}
+
+func (c *awsConfig) getText(ctx context.Context, endpoint string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: HTTP %d", endpoint, resp.StatusCode)
+	}
+	return string(data), nil
+}
+
+func (c *awsConfig) getJSON(ctx context.Context, endpoint string, headers map[string]string, out interface{}) error {
+	data, err := c.getText(ctx, endpoint, headers)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), out)
+}
+
+// readINISections parses an AWS shared config or credentials file into sections of key/value
+// pairs. A missing file yields no sections.
+func readINISections(path string) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return sections, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var current map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			current = sections[name]
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		current[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return sections, scanner.Err()
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolateAWSEnv clears the AWS settings the credential chain reads and points HOME at an empty
+// directory
+func isolateAWSEnv(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_DEFAULT_PROFILE",
+		"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME", "AWS_ENDPOINT_URL_STS",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+		"AWS_EC2_METADATA_SERVICE_ENDPOINT",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	return home
+}
+
+func TestAWSCredentialsProfile(t *testing.T) {
+	home := isolateAWSEnv(t)
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	config := "[default]\nregion = us-east-1\n\n[profile transfer]\nregion = eu-west-1\n"
+	credentials := "[transfer]\naws_access_key_id = AKIDPROFILE\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(credentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default profile only sets a region, so the chain runs out of sources
+	cfg, err := loadAWSConfig(http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.credentials(context.Background()); err == nil || !strings.Contains(err.Error(), "no AWS credentials") {
+		t.Errorf("Expected no credentials from a region-only default profile, got %v", err)
+	}
+
+	t.Setenv("AWS_PROFILE", "transfer")
+	cfg, err = loadAWSConfig(http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "AKIDPROFILE" || cfg.region() != "eu-west-1" {
+		t.Errorf("Expected the transfer profile's keys and region, got %+v in %q", creds, cfg.region())
+	}
+
+	t.Setenv("AWS_PROFILE", "missing")
+	cfg, _ = loadAWSConfig(http.DefaultClient)
+	if _, err := cfg.credentials(context.Background()); err == nil || !strings.Contains(err.Error(), "missing is not configured") {
+		t.Errorf("Expected an unknown AWS_PROFILE to fail, got %v", err)
+	}
+}
+
+func TestAWSCredentialsWebIdentity(t *testing.T) {
+	home := isolateAWSEnv(t)
+	var form map[string]string
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = map[string]string{"Action": r.Form.Get("Action"), "RoleArn": r.Form.Get("RoleArn"), "WebIdentityToken": r.Form.Get("WebIdentityToken")}
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAWEB</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>2030-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+
+	tokenFile := filepath.Join(home, "token")
+	if err := os.WriteFile(tokenFile, []byte("eyJ.token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/transfer")
+	t.Setenv("AWS_ENDPOINT_URL_STS", sts.URL)
+
+	cfg, err := loadAWSConfig(sts.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form["Action"] != "AssumeRoleWithWebIdentity" || form["RoleArn"] != "arn:aws:iam::123456789012:role/transfer" || form["WebIdentityToken"] != "eyJ.token" {
+		t.Errorf("Unexpected STS request %v", form)
+	}
+	if creds.AccessKeyID != "ASIAWEB" || creds.SessionToken != "session" || !creds.Expires.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected web identity credentials %+v", creds)
+	}
+}
+
+func TestAWSCredentialsContainer(t *testing.T) {
+	isolateAWSEnv(t)
+	var auth string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"AccessKeyId":"ASIAPOD","SecretAccessKey":"secret","Token":"session","Expiration":"2030-01-01T00:00:00Z"}`))
+	}))
+	defer endpoint.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", endpoint.URL+"/v1/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "pod-identity-token")
+
+	cfg, err := loadAWSConfig(endpoint.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "pod-identity-token" || creds.AccessKeyID != "ASIAPOD" || creds.SessionToken != "session" {
+		t.Errorf("Unexpected container credentials %+v (authorization %q)", creds, auth)
+	}
+}
+
+func TestAWSCredentialsInstanceMetadata(t *testing.T) {
+	isolateAWSEnv(t)
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+			_, _ = w.Write([]byte("imds-token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("transfer-node"))
+		case "/latest/meta-data/iam/security-credentials/transfer-node":
+			_, _ = w.Write([]byte(`{"AccessKeyId":"ASIANODE","SecretAccessKey":"secret","Token":"session","Expiration":"2030-01-01T00:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer imds.Close()
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", imds.URL)
+
+	cfg, err := loadAWSConfig(imds.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "ASIANODE" || creds.SessionToken != "session" {
+		t.Errorf("Unexpected instance profile credentials %+v", creds)
+	}
+}
+
+func TestS3StoreRefreshesExpiringCredentials(t *testing.T) {
+	isolateAWSEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDNEW")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	cfg, err := loadAWSConfig(http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &s3Store{aws: cfg, creds: awsCredentials{AccessKeyID: "ASIAOLD", SecretAccessKey: "old", Expires: time.Now().Add(time.Minute)}}
+	creds, err := store.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "AKIDNEW" {
+		t.Errorf("Expected credentials about to expire to be fetched again, got %+v", creds)
+	}
+}
+
+func TestS3BucketRegion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/airgap-transfer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Amz-Bucket-Region", "ap-southeast-2")
+		w.Header().Set("Location", "https://airgap-transfer.s3.ap-southeast-2.amazonaws.com/")
+		w.WriteHeader(http.StatusMovedPermanently)
+	}))
+	defer srv.Close()
+
+	if region := s3BucketRegion(context.Background(), srv.Client(), srv.URL, "airgap-transfer"); region != "ap-southeast-2" {
+		t.Errorf("Expected ap-southeast-2, got %q", region)
+	}
+	if region := s3BucketRegion(context.Background(), srv.Client(), srv.URL, "other"); region != "" {
+		t.Errorf("Expected no region without the header, got %q", region)
+	}
+}
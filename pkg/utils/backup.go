@@ -0,0 +1,942 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+// BackupFormatVersion is the layout version written to backup.json. Readers refuse archives with
+// a newer version than they know.
+const BackupFormatVersion = 1
+
+// BackupExtension is the file extension of backup archives
+const BackupExtension = ".tar.gz"
+
+// backupMetadataFile is the first entry of every backup archive
+const backupMetadataFile = "backup.json"
+
+// backupDirName is the directory under ~/.dynactl backups are written to by default
+const backupDirName = "backups"
+
+// backupIDFormat names each backup after its UTC start time
+const backupIDFormat = "20060102T150405Z"
+
+// Backup components
+const (
+	BackupDatabase   = "database"
+	BackupConfigMaps = "configmaps"
+	BackupSecrets    = "secrets"
+	BackupHelm       = "helm-values"
+	BackupSnapshots  = "pvc-snapshots"
+)
+
+// BackupComponents lists every component, in the order they are backed up
+var BackupComponents = []string{BackupDatabase, BackupConfigMaps, BackupSecrets, BackupHelm, BackupSnapshots}
+
+// Backup component statuses
+const (
+	BackupOK      = "ok"
+	BackupSkipped = "skipped"
+	// BackupPending means the data is still being captured in the cluster, such as a volume
+	// snapshot that is not ready to use yet
+	BackupPending = "pending"
+)
+
+// Database backup methods
+const (
+	// DatabaseMethodExec runs pg_dump in the database pod and stores the dump in the archive
+	DatabaseMethodExec = "exec"
+	// DatabaseMethodCNPG asks the CloudNativePG operator to take a Backup, which it stores
+	// in its own object store
+	DatabaseMethodCNPG = "cnpg"
+)
+
+// DatabaseMethods lists the supported database backup methods
+var DatabaseMethods = []string{DatabaseMethodExec, DatabaseMethodCNPG}
+
+// DefaultDatabaseSelector finds the PostgreSQL pod for exec dumps
+const DefaultDatabaseSelector = "app.kubernetes.io/name=postgresql"
+
+// BackupRedactedKeysAnnotation lists the keys whose values were left out of an exported Secret
+const BackupRedactedKeysAnnotation = "dynactl.dynamo.ai/redacted-keys"
+
+// backupLabel marks cluster objects created for a backup with its ID
+const backupLabel = "dynactl.dynamo.ai/backup"
+
+var (
+	volumeSnapshotGVR        = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+	volumeSnapshotContentGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotcontents"}
+	cnpgBackupGVR            = schema.GroupVersionResource{Group: "postgresql.cnpg.io", Version: "v1", Resource: "backups"}
+)
+
+// BackupOptions configures a backup of a Dynamo namespace
+type BackupOptions struct {
+	Namespace string
+	// Dir is where the archive is written
+	Dir string
+	// Name overrides the archive name, which defaults to <namespace>-<UTC time>
+	Name string
+	// Components limits the backup; empty backs up everything
+	Components []string
+	Database   DatabaseBackupOptions
+	// IncludeSecrets stores Secret values; by default only their keys are kept
+	IncludeSecrets bool
+	// SnapshotClass is the VolumeSnapshotClass to use; empty uses the cluster default
+	SnapshotClass string
+	// SnapshotTimeout is how long to wait for snapshots to be ready; zero does not wait
+	SnapshotTimeout time.Duration
+	// DynactlVersion is recorded in the metadata
+	DynactlVersion string
+}
+
+// DatabaseBackupOptions selects how the database is backed up
+type DatabaseBackupOptions struct {
+	Method string
+	// Selector finds the database pod for exec dumps
+	Selector  string
+	Container string
+	// Name and User default to the pod's POSTGRES_DB and POSTGRES_USER
+	Name string
+	User string
+	// Cluster is the CloudNativePG Cluster to back up
+	Cluster string
+}
+
+// BackupMetadata describes a backup archive and is stored in it as backup.json
+type BackupMetadata struct {
+	FormatVersion  int       `json:"format_version"`
+	Name           string    `json:"name"`
+	Created        time.Time `json:"created"`
+	DynactlVersion string    `json:"dynactl_version,omitempty"`
+	// Server and KubernetesVersion describe the cluster the backup was taken from
+	Server            string            `json:"server,omitempty"`
+	KubernetesVersion string            `json:"kubernetes_version,omitempty"`
+	Namespace         string            `json:"namespace"`
+	Components        []BackupComponent `json:"components"`
+	// SecretsRedacted is true when Secret values were left out
+	SecretsRedacted bool                `json:"secrets_redacted"`
+	Database        *BackupDatabaseInfo `json:"database,omitempty"`
+	Releases        []BackupRelease     `json:"releases,omitempty"`
+	Snapshots       []BackupSnapshot    `json:"snapshots,omitempty"`
+
+	// Path and Size are filled in when reading an archive
+	Path string `json:"-"`
+	Size int64  `json:"-"`
+}
+
+// BackupComponent is the outcome of backing up one component
+type BackupComponent struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Message string   `json:"message,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	Bytes   int64    `json:"bytes,omitempty"`
+}
+
+// BackupDatabaseInfo records where the database backup came from
+type BackupDatabaseInfo struct {
+	Method string `json:"method"`
+	Pod    string `json:"pod,omitempty"`
+	Name   string `json:"name,omitempty"`
+	// File is the dump inside the archive, for exec backups
+	File string `json:"file,omitempty"`
+	// Cluster and OperatorBackup name the CloudNativePG Cluster and Backup, for cnpg backups
+	Cluster        string `json:"cluster,omitempty"`
+	OperatorBackup string `json:"operator_backup,omitempty"`
+}
+
+// BackupRelease is a Helm release whose values were saved
+type BackupRelease struct {
+	Name         string `json:"name"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chart_version"`
+	AppVersion   string `json:"app_version,omitempty"`
+	Revision     int    `json:"revision"`
+	File         string `json:"file"`
+	// RedactedKeys are the values left out because they looked like credentials, as dotted paths
+	RedactedKeys []string `json:"redacted_keys,omitempty"`
+}
+
+// BackupSnapshot is a VolumeSnapshot taken of a PVC, with what is needed to recreate the PVC from
+// it in another cluster
+type BackupSnapshot struct {
+	Name  string `json:"name"`
+	PVC   string `json:"pvc"`
+	Class string `json:"class,omitempty"`
+	Ready bool   `json:"ready"`
+	// StorageClass, Size and AccessModes are copied from the PVC
+	StorageClass string   `json:"storage_class,omitempty"`
+	Size         string   `json:"size,omitempty"`
+	AccessModes  []string `json:"access_modes,omitempty"`
+	// Driver and Handle identify the snapshot in the storage backend once it is ready
+	Driver string `json:"driver,omitempty"`
+	Handle string `json:"handle,omitempty"`
+}
+
+// DefaultBackupDir returns the directory backups are written to when none is configured
+func DefaultBackupDir() (string, error) {
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, backupDirName), nil
+}
+
+// ValidateBackupOptions checks the components and database method, filling in defaults
+func ValidateBackupOptions(opts *BackupOptions) error {
+	if opts.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if len(opts.Components) == 0 {
+		opts.Components = BackupComponents
+	}
+	for _, c := range opts.Components {
+		if !containsString(BackupComponents, c) {
+			return fmt.Errorf("unknown backup component %q (valid: %s)", c, strings.Join(BackupComponents, ", "))
+		}
+	}
+	if !containsString(opts.Components, BackupDatabase) {
+		return nil
+	}
+	if opts.Database.Method == "" {
+		opts.Database.Method = DatabaseMethodExec
+	}
+	switch opts.Database.Method {
+	case DatabaseMethodExec:
+		if opts.Database.Selector == "" {
+			opts.Database.Selector = DefaultDatabaseSelector
+		}
+	case DatabaseMethodCNPG:
+		if opts.Database.Cluster == "" {
+			return fmt.Errorf("the CloudNativePG cluster name is required for the %s method", DatabaseMethodCNPG)
+		}
+	default:
+		return fmt.Errorf("unknown database backup method %q (valid: %s)", opts.Database.Method, strings.Join(DatabaseMethods, ", "))
+	}
+	return nil
+}
+
+// CreateBackup backs up the requested components of a namespace into <dir>/<name>.tar.gz. Each
+// component is written to a staging directory first so backup.json, which records the outcome
+// of every component, can be the archive's first entry. A failed component aborts the backup
+// and no archive is left behind.
+func (kc *KubernetesChecker) CreateBackup(ctx context.Context, opts BackupOptions) (*BackupMetadata, error) {
+	if err := ValidateBackupOptions(&opts); err != nil {
+		return nil, err
+	}
+	created := time.Now().UTC()
+	id := created.Format(backupIDFormat)
+	if opts.Name == "" {
+		opts.Name = opts.Namespace + "-" + id
+	}
+	archive := filepath.Join(opts.Dir, opts.Name+BackupExtension)
+	if pathExists(archive) {
+		return nil, fmt.Errorf("backup %s already exists", archive)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(opts.Dir, "."+opts.Name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	kubeVersion, err := kc.CheckKubernetesVersion(ctx)
+	if err != nil {
+		LogWarning("Not recording the Kubernetes version: %v", err)
+	}
+	meta := &BackupMetadata{
+		FormatVersion:     BackupFormatVersion,
+		Name:              opts.Name,
+		Created:           created,
+		DynactlVersion:    opts.DynactlVersion,
+		Server:            kc.config.Host,
+		KubernetesVersion: kubeVersion,
+		Namespace:         opts.Namespace,
+		SecretsRedacted:   containsString(opts.Components, BackupSecrets) && !opts.IncludeSecrets,
+	}
+	w := &backupWriter{dir: staging}
+	for _, component := range BackupComponents {
+		if !containsString(opts.Components, component) {
+			continue
+		}
+		LogInfo("Backing up %s", component)
+		result, err := kc.backupComponent(ctx, component, id, opts, meta, w)
+		if err != nil {
+			return nil, fmt.Errorf("%s backup failed: %w", component, err)
+		}
+		meta.Components = append(meta.Components, result)
+	}
+
+	if err := writeBackupArchive(archive, staging, meta); err != nil {
+		return nil, err
+	}
+	meta.Path = archive
+	if info, err := os.Stat(archive); err == nil {
+		meta.Size = info.Size()
+	}
+	return meta, nil
+}
+
+// backupComponent backs up one component, writing its files through w
+func (kc *KubernetesChecker) backupComponent(ctx context.Context, component, id string, opts BackupOptions, meta *BackupMetadata, w *backupWriter) (BackupComponent, error) {
+	w.start()
+	result := BackupComponent{Name: component, Status: BackupOK}
+	var err error
+	switch component {
+	case BackupDatabase:
+		meta.Database, err = kc.backupDatabase(ctx, id, opts, w)
+		if err == nil && meta.Database.Method == DatabaseMethodCNPG {
+			result.Status = BackupPending
+			result.Message = fmt.Sprintf("CloudNativePG Backup %s started; the operator stores it", meta.Database.OperatorBackup)
+		}
+	case BackupConfigMaps:
+		err = kc.backupConfigMaps(ctx, opts.Namespace, w)
+	case BackupSecrets:
+		err = kc.backupSecrets(ctx, opts.Namespace, opts.IncludeSecrets, w)
+		if err == nil && !opts.IncludeSecrets {
+			result.Message = "values redacted"
+		}
+	case BackupHelm:
+		meta.Releases, err = kc.backupHelmValues(ctx, opts.Namespace, opts.IncludeSecrets, w)
+		if err == nil && len(meta.Releases) == 0 {
+			result.Status, result.Message = BackupSkipped, "no deployed Helm releases in the namespace"
+		}
+		redacted := 0
+		for _, rel := range meta.Releases {
+			redacted += len(rel.RedactedKeys)
+		}
+		if redacted > 0 {
+			result.Message = fmt.Sprintf("%d credential values redacted", redacted)
+		}
+	case BackupSnapshots:
+		meta.Snapshots, result.Status, result.Message, err = kc.backupSnapshots(ctx, id, opts)
+	}
+	result.Files, result.Bytes = w.files, w.bytes
+	return result, err
+}
+
+// backupWriter writes component files into the staging directory, tracking what each
+// component wrote
+type backupWriter struct {
+	dir   string
+	files []string
+	bytes int64
+}
+
+func (w *backupWriter) start() {
+	w.files, w.bytes = nil, 0
+}
+
+// create opens a file for writing at a slash-separated path in the archive
+func (w *backupWriter) create(name string, sensitive bool) (*os.File, error) {
+	path := filepath.Join(w.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(0o644)
+	if sensitive {
+		mode = 0o600
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return nil, err
+	}
+	w.files = append(w.files, name)
+	return f, nil
+}
+
+// writeYAML stores obj as YAML at a slash-separated path in the archive
+func (w *backupWriter) writeYAML(name string, obj any, sensitive bool) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	f, err := w.create(name, sensitive)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	w.bytes += int64(len(data))
+	return f.Close()
+}
+
+// backupDatabase dumps the database with pg_dump over exec, or starts a CloudNativePG Backup
+func (kc *KubernetesChecker) backupDatabase(ctx context.Context, id string, opts BackupOptions, w *backupWriter) (*BackupDatabaseInfo, error) {
+	db := opts.Database
+	if db.Method == DatabaseMethodCNPG {
+		name := strings.ToLower(db.Cluster + "-" + id)
+		backup := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "postgresql.cnpg.io/v1",
+			"kind":       "Backup",
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": opts.Namespace,
+				"labels":    map[string]any{backupLabel: id, "app.kubernetes.io/managed-by": "dynactl"},
+			},
+			"spec": map[string]any{"cluster": map[string]any{"name": db.Cluster}},
+		}}
+		if _, err := kc.dynamicClient.Resource(cnpgBackupGVR).Namespace(opts.Namespace).Create(ctx, backup, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("CloudNativePG is not installed in the cluster")
+			}
+			return nil, fmt.Errorf("failed to create CloudNativePG Backup: %v", err)
+		}
+		return &BackupDatabaseInfo{Method: db.Method, Cluster: db.Cluster, OperatorBackup: name}, nil
+	}
+
+	pods, err := kc.listPodsBySelector(ctx, opts.Namespace, db.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var pod *corev1.Pod
+	for i := range pods {
+		if isPodReady(&pods[i]) {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no ready database pod matches %s in %s (set the selector with --db-selector)", db.Selector, opts.Namespace)
+	}
+
+	file := "database/" + backupFileName(db.Name, "dump")
+	f, err := w.create(file, true)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var stderr bytes.Buffer
+	out := &countingWriter{w: f}
+	if err := kc.execStream(ctx, opts.Namespace, pod.Name, db.Container, []string{"sh", "-c", pgDumpScript(db)}, nil, out, &stderr); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("pg_dump in %s failed: %s", pod.Name, msg)
+		}
+		return nil, fmt.Errorf("pg_dump in %s failed: %v", pod.Name, err)
+	}
+	if out.n == 0 {
+		return nil, fmt.Errorf("pg_dump in %s produced no output", pod.Name)
+	}
+	w.bytes += out.n
+	return &BackupDatabaseInfo{Method: db.Method, Pod: pod.Name, Name: db.Name, File: file}, f.Close()
+}
+
+// pgPasswordEnv passes the password the postgres images are configured with to the client tools
+const pgPasswordEnv = `PGPASSWORD="${PGPASSWORD:-$POSTGRES_PASSWORD}" `
+
+// pgConnection returns the quoted user and database, falling back to the variables the postgres
+// images are configured with
+func pgConnection(db DatabaseBackupOptions) (string, string) {
+	user := `"${POSTGRES_USER:-postgres}"`
+	if db.User != "" {
+		user = shellQuote(db.User)
+	}
+	name := `"${POSTGRES_DB:-postgres}"`
+	if db.Name != "" {
+		name = shellQuote(db.Name)
+	}
+	return user, name
+}
+
+// pgDumpScript runs pg_dump in custom format, which pg_restore can restore selectively
+func pgDumpScript(db DatabaseBackupOptions) string {
+	user, name := pgConnection(db)
+	return pgPasswordEnv + "exec pg_dump --format=custom --no-owner -U " + user + " " + name
+}
+
+// backupFileName names a dump after its database, or "dynamo" when the pod's default is used
+func backupFileName(name, ext string) string {
+	if name == "" {
+		name = "dynamo"
+	}
+	return name + "." + ext
+}
+
+// backupConfigMaps exports each ConfigMap except the cluster CA bundle injected into every namespace
+func (kc *KubernetesChecker) backupConfigMaps(ctx context.Context, namespace string, w *backupWriter) error {
+	list, err := kc.clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ConfigMaps in %s: %v", namespace, err)
+	}
+	for _, cm := range list.Items {
+		if cm.Name == "kube-root-ca.crt" {
+			continue
+		}
+		cm.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+		cm.ObjectMeta = exportObjectMeta(cm.ObjectMeta)
+		if err := w.writeYAML("configmaps/"+cm.Name+".yaml", cm, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupSecrets exports each Secret, leaving out Helm release records and service account
+// tokens, which the cluster recreates
+func (kc *KubernetesChecker) backupSecrets(ctx context.Context, namespace string, includeValues bool, w *backupWriter) error {
+	list, err := kc.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list Secrets in %s: %v", namespace, err)
+	}
+	for _, secret := range list.Items {
+		if secret.Type == "helm.sh/release.v1" || secret.Type == corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		exported := exportSecret(secret, includeValues)
+		if err := w.writeYAML("secrets/"+secret.Name+".yaml", exported, includeValues); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportSecret strips server-set metadata and, unless values are included, replaces the data
+// with an annotation listing the keys so a restore can tell what has to be supplied again
+func exportSecret(secret corev1.Secret, includeValues bool) corev1.Secret {
+	secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	secret.ObjectMeta = exportObjectMeta(secret.ObjectMeta)
+	if includeValues {
+		return secret
+	}
+	keys := make([]string, 0, len(secret.Data)+len(secret.StringData))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	for k := range secret.StringData {
+		if _, ok := secret.Data[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[BackupRedactedKeysAnnotation] = strings.Join(keys, ",")
+	secret.Data, secret.StringData = nil, nil
+	return secret
+}
+
+// exportObjectMeta keeps what is needed to recreate an object in another cluster
+func exportObjectMeta(m metav1.ObjectMeta) metav1.ObjectMeta {
+	annotations := map[string]string{}
+	for k, v := range m.Annotations {
+		if k != corev1.LastAppliedConfigAnnotation {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	return metav1.ObjectMeta{Name: m.Name, Namespace: m.Namespace, Labels: m.Labels, Annotations: annotations}
+}
+
+// backupHelmValues saves the user-supplied values of the latest deployed revision of each release.
+// Unless includeSecrets is set, values that look like credentials are redacted as Secret values are.
+func (kc *KubernetesChecker) backupHelmValues(ctx context.Context, namespace string, includeSecrets bool, w *backupWriter) ([]BackupRelease, error) {
+	store := driver.NewSecrets(kc.clientset.CoreV1().Secrets(namespace))
+	releases, err := store.List(func(r *release.Release) bool {
+		return r.Info != nil && r.Info.Status == release.StatusDeployed
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Helm releases in %s: %v", namespace, err)
+	}
+
+	var saved []BackupRelease
+	for _, r := range latestReleases(releases) {
+		values := r.Config
+		if values == nil {
+			values = map[string]any{}
+		}
+		var redacted []string
+		if !includeSecrets {
+			redacted = redactHelmValues(values, "")
+		}
+		file := "helm/" + r.Name + "-values.yaml"
+		if err := w.writeYAML(file, values, true); err != nil {
+			return nil, err
+		}
+		entry := BackupRelease{Name: r.Name, Revision: r.Version, File: file, RedactedKeys: redacted}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			entry.Chart, entry.ChartVersion, entry.AppVersion = r.Chart.Metadata.Name, r.Chart.Metadata.Version, r.Chart.Metadata.AppVersion
+		}
+		saved = append(saved, entry)
+	}
+	return saved, nil
+}
+
+// helmCredentialKey matches Helm value keys that usually hold a credential
+var helmCredentialKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[-_]?key|access[-_]?key|private[-_]?key|credentials)`)
+
+// isHelmCredentialKey reports whether a values key holds a credential rather than naming the
+// Secret or Secret key one is read from (existingSecret, passwordSecretName, secretKeyRef)
+func isHelmCredentialKey(key string) bool {
+	lower := strings.ToLower(key)
+	if strings.HasPrefix(lower, "existing") || strings.HasSuffix(lower, "name") || strings.HasSuffix(lower, "ref") {
+		return false
+	}
+	return helmCredentialKey.MatchString(key)
+}
+
+// redactHelmValues replaces the scalar values under credential keys, and the value of env-style
+// {name, value} entries whose name is one, returning the dotted paths of what was redacted
+func redactHelmValues(values map[string]any, prefix string) []string {
+	var redacted []string
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		redacted = append(redacted, redactHelmValue(values, k, prefix+k)...)
+	}
+	if name, ok := values["name"].(string); ok && isHelmCredentialKey(name) {
+		if v, ok := values["value"]; ok && v != nil && v != "" && v != redactedValue {
+			values["value"] = redactedValue
+			redacted = append(redacted, prefix+"value")
+		}
+	}
+	return redacted
+}
+
+func redactHelmValue(parent map[string]any, key, path string) []string {
+	switch v := parent[key].(type) {
+	case map[string]any:
+		return redactHelmValues(v, path+".")
+	case []any:
+		var redacted []string
+		for i, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				redacted = append(redacted, redactHelmValues(m, fmt.Sprintf("%s[%d].", path, i))...)
+			}
+		}
+		return redacted
+	case nil, bool:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	if !isHelmCredentialKey(key) {
+		return nil
+	}
+	parent[key] = redactedValue
+	return []string{path}
+}
+
+// latestReleases keeps the highest revision of each release, sorted by name
+func latestReleases(releases []*release.Release) []*release.Release {
+	latest := map[string]*release.Release{}
+	for _, r := range releases {
+		if cur, ok := latest[r.Name]; !ok || r.Version > cur.Version {
+			latest[r.Name] = r
+		}
+	}
+	out := make([]*release.Release, 0, len(latest))
+	for _, r := range latest {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// backupSnapshots creates a VolumeSnapshot of each bound PVC and waits up to SnapshotTimeout for
+// them to be ready to use. Snapshots still being taken when the wait ends are reported as pending.
+func (kc *KubernetesChecker) backupSnapshots(ctx context.Context, id string, opts BackupOptions) ([]BackupSnapshot, string, string, error) {
+	pvcs, err := kc.clientset.CoreV1().PersistentVolumeClaims(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to list PVCs in %s: %v", opts.Namespace, err)
+	}
+
+	client := kc.dynamicClient.Resource(volumeSnapshotGVR).Namespace(opts.Namespace)
+	var snapshots []BackupSnapshot
+	for _, pvc := range pvcs.Items {
+		if pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		snap := BackupSnapshot{Name: snapshotName(pvc.Name, id), PVC: pvc.Name, Class: opts.SnapshotClass}
+		if pvc.Spec.StorageClassName != nil {
+			snap.StorageClass = *pvc.Spec.StorageClassName
+		}
+		if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			snap.Size = size.String()
+		}
+		for _, mode := range pvc.Spec.AccessModes {
+			snap.AccessModes = append(snap.AccessModes, string(mode))
+		}
+		if _, err := client.Create(ctx, volumeSnapshot(snap, opts.Namespace, id), metav1.CreateOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, BackupSkipped, "the VolumeSnapshot API is not installed in the cluster", nil
+			}
+			return nil, "", "", fmt.Errorf("failed to snapshot PVC %s: %v", pvc.Name, err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	if len(snapshots) == 0 {
+		return nil, BackupSkipped, "no bound PVCs in the namespace", nil
+	}
+	if opts.SnapshotTimeout <= 0 {
+		return snapshots, BackupPending, fmt.Sprintf("%d snapshots created; not waiting for them to be ready", len(snapshots)), nil
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, opts.SnapshotTimeout, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for i := range snapshots {
+			if snapshots[i].Ready {
+				continue
+			}
+			obj, err := client.Get(ctx, snapshots[i].Name, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("failed to read VolumeSnapshot %s: %v", snapshots[i].Name, err)
+			}
+			if msg, _, _ := unstructured.NestedString(obj.Object, "status", "error", "message"); msg != "" {
+				return false, fmt.Errorf("VolumeSnapshot %s failed: %s", snapshots[i].Name, msg)
+			}
+			snapshots[i].Ready, _, _ = unstructured.NestedBool(obj.Object, "status", "readyToUse")
+			if snapshots[i].Ready {
+				content, _, _ := unstructured.NestedString(obj.Object, "status", "boundVolumeSnapshotContentName")
+				snapshots[i].Driver, snapshots[i].Handle = kc.snapshotHandle(ctx, content)
+			}
+			done = done && snapshots[i].Ready
+		}
+		return done, nil
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return nil, "", "", err
+	}
+	ready := 0
+	for _, s := range snapshots {
+		if s.Ready {
+			ready++
+		}
+	}
+	if ready < len(snapshots) {
+		return snapshots, BackupPending, fmt.Sprintf("%d of %d snapshots ready", ready, len(snapshots)), nil
+	}
+	return snapshots, BackupOK, fmt.Sprintf("%d snapshots ready", len(snapshots)), nil
+}
+
+// snapshotHandle reads the CSI driver and storage backend handle of a snapshot from its
+// VolumeSnapshotContent. Without them the snapshot can only be restored in the cluster it was
+// taken in, so a failure to read them is only a warning.
+func (kc *KubernetesChecker) snapshotHandle(ctx context.Context, content string) (string, string) {
+	if content == "" {
+		return "", ""
+	}
+	obj, err := kc.dynamicClient.Resource(volumeSnapshotContentGVR).Get(ctx, content, metav1.GetOptions{})
+	if err != nil {
+		LogWarning("Could not read VolumeSnapshotContent %s; the snapshot can only be restored in this cluster: %v", content, err)
+		return "", ""
+	}
+	driver, _, _ := unstructured.NestedString(obj.Object, "spec", "driver")
+	handle, _, _ := unstructured.NestedString(obj.Object, "status", "snapshotHandle")
+	return driver, handle
+}
+
+// snapshotName names a PVC's snapshot after the PVC and the backup ID, within the 253 character
+// limit on object names
+func snapshotName(pvc, id string) string {
+	suffix := "-" + strings.ToLower(id)
+	if max := 253 - len(suffix); len(pvc) > max {
+		pvc = pvc[:max]
+	}
+	return pvc + suffix
+}
+
+func volumeSnapshot(snap BackupSnapshot, namespace, id string) *unstructured.Unstructured {
+	spec := map[string]any{"source": map[string]any{"persistentVolumeClaimName": snap.PVC}}
+	if snap.Class != "" {
+		spec["volumeSnapshotClassName"] = snap.Class
+	}
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]any{
+			"name":      snap.Name,
+			"namespace": namespace,
+			"labels":    map[string]any{backupLabel: id, "app.kubernetes.io/managed-by": "dynactl"},
+		},
+		"spec": spec,
+	}}
+}
+
+// writeBackupArchive writes backup.json followed by the staged files as a gzip-compressed tar.
+// The archive is written under a temporary name and renamed into place once complete.
+func writeBackupArchive(archive, staging string, meta *BackupMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup metadata: %w", err)
+	}
+	metaPath := filepath.Join(staging, backupMetadataFile)
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write backup metadata: %w", err)
+	}
+
+	files := []bundleSource{{Name: backupMetadataFile, localPath: metaPath, Size: int64(len(data))}}
+	for _, c := range meta.Components {
+		for _, name := range c.Files {
+			path := filepath.Join(staging, filepath.FromSlash(name))
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, bundleSource{Name: name, localPath: path, Size: info.Size()})
+		}
+	}
+
+	partial := archive + ".partial"
+	out, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	err = writeBundleArchive(out, files, ExportOptions{Compression: CompressionGzip}, &ExportResult{})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	return os.Rename(partial, archive)
+}
+
+// ReadBackupMetadata reads backup.json from a backup archive
+func ReadBackupMetadata(archive string) (*BackupMetadata, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a backup archive: %w", archive, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s has no %s", archive, backupMetadataFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		if hdr.Name != backupMetadataFile {
+			continue
+		}
+		var meta BackupMetadata
+		if err := json.NewDecoder(tr).Decode(&meta); err != nil {
+			return nil, fmt.Errorf("failed to parse %s in %s: %w", backupMetadataFile, archive, err)
+		}
+		if meta.FormatVersion > BackupFormatVersion {
+			return nil, fmt.Errorf("%s uses backup format %d; this dynactl reads up to %d, update dynactl", archive, meta.FormatVersion, BackupFormatVersion)
+		}
+		meta.Path = archive
+		if info, err := f.Stat(); err == nil {
+			meta.Size = info.Size()
+		}
+		return &meta, nil
+	}
+}
+
+// ResolveBackup finds a backup archive given its path or its name in dir
+func ResolveBackup(dir, backup string) (string, error) {
+	if pathExists(backup) {
+		return backup, nil
+	}
+	archive := filepath.Join(dir, strings.TrimSuffix(backup, BackupExtension)+BackupExtension)
+	if !pathExists(archive) {
+		return "", fmt.Errorf("no backup named %s in %s (see dynactl backup list)", backup, dir)
+	}
+	return archive, nil
+}
+
+// ListBackups returns the metadata of every backup archive in dir, oldest first. A missing
+// directory yields no backups.
+func ListBackups(dir string) ([]BackupMetadata, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []BackupMetadata
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), BackupExtension) {
+			continue
+		}
+		meta, err := ReadBackupMetadata(filepath.Join(dir, e.Name()))
+		if err != nil {
+			LogWarning("Skipping unreadable backup %s: %v", e.Name(), err)
+			continue
+		}
+		backups = append(backups, *meta)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Created.Before(backups[j].Created) })
+	return backups, nil
+}
+
+// backupsToPrune returns the backups of a namespace beyond the newest retain, oldest first.
+// backups must be sorted oldest first, as ListBackups returns them.
+func backupsToPrune(backups []BackupMetadata, namespace string, retain int) []BackupMetadata {
+	var matching []BackupMetadata
+	for _, b := range backups {
+		if b.Namespace == namespace {
+			matching = append(matching, b)
+		}
+	}
+	if retain <= 0 || len(matching) <= retain {
+		return nil
+	}
+	return matching[:len(matching)-retain]
+}
+
+// PruneBackups deletes the backups of a namespace in dir beyond the newest retain, along with the
+// VolumeSnapshots they took, and returns what was deleted
+func (kc *KubernetesChecker) PruneBackups(ctx context.Context, dir, namespace string, retain int) ([]BackupMetadata, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	prune := backupsToPrune(backups, namespace, retain)
+	for _, b := range prune {
+		for _, snap := range b.Snapshots {
+			err := kc.dynamicClient.Resource(volumeSnapshotGVR).Namespace(b.Namespace).Delete(ctx, snap.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to delete VolumeSnapshot %s of backup %s: %v", snap.Name, b.Name, err)
+			}
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return nil, fmt.Errorf("failed to delete backup %s: %w", b.Path, err)
+		}
+	}
+	return prune, nil
+}
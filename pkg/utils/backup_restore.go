@@ -0,0 +1,886 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+// Names of the restore validation checks and smoke checks, besides the component steps
+const (
+	RestoreCheckVersion        = "kubernetes-version"
+	RestoreCheckNamespace      = "namespace"
+	RestoreCheckStorageClasses = "storage-classes"
+	RestoreCheckPods           = "pods"
+)
+
+// DefaultDatabaseWait is how long a restore waits for the database pod before loading the dump
+const DefaultDatabaseWait = 10 * time.Minute
+
+// RestoreOptions configures restoring a backup archive
+type RestoreOptions struct {
+	// Namespace is where the backup is restored; empty uses the namespace it was taken from
+	Namespace string
+	// Components limits the restore; empty restores everything the backup captured
+	Components []string
+	// Database finds the pod to load the dump into; Name and User default to those of the backup
+	Database DatabaseBackupOptions
+	// DatabaseWait bounds waiting for a ready database pod
+	DatabaseWait time.Duration
+	// StorageClass, when set, replaces the StorageClass of every restored PVC
+	StorageClass string
+	// ValuesDir is where the saved Helm values are written for reinstalling the releases
+	ValuesDir string
+	// Force allows restoring into a namespace that already runs workloads
+	Force bool
+	// OnStep, when set, is called as each restore step finishes
+	OnStep func(CheckResult)
+}
+
+// RestoreResult lists the outcome of each restore step and of the smoke checks run afterwards
+type RestoreResult struct {
+	Steps []CheckResult
+	Smoke []CheckResult
+}
+
+// restoreDefaults fills in the target namespace and components from the backup, and rejects
+// components the backup does not hold
+func restoreDefaults(meta *BackupMetadata, opts *RestoreOptions) error {
+	if opts.Namespace == "" {
+		opts.Namespace = meta.Namespace
+	}
+	if opts.DatabaseWait <= 0 {
+		opts.DatabaseWait = DefaultDatabaseWait
+	}
+	if opts.Database.Selector == "" {
+		opts.Database.Selector = DefaultDatabaseSelector
+	}
+	if opts.Database.Name == "" && meta.Database != nil {
+		opts.Database.Name = meta.Database.Name
+	}
+
+	var captured []string
+	for _, c := range meta.Components {
+		if c.Status != BackupSkipped {
+			captured = append(captured, c.Name)
+		}
+	}
+	if len(opts.Components) == 0 {
+		opts.Components = captured
+		return nil
+	}
+	for _, c := range opts.Components {
+		if !containsString(BackupComponents, c) {
+			return fmt.Errorf("unknown backup component %q (valid: %s)", c, strings.Join(BackupComponents, ", "))
+		}
+		if !containsString(captured, c) {
+			return fmt.Errorf("backup %s does not hold %s", meta.Name, c)
+		}
+	}
+	return nil
+}
+
+// component returns the named component of a backup
+func (m *BackupMetadata) component(name string) *BackupComponent {
+	for i := range m.Components {
+		if m.Components[i].Name == name {
+			return &m.Components[i]
+		}
+	}
+	return nil
+}
+
+// componentObjects lists the object names a component stored as <component>/<name>.yaml
+func componentObjects(c *BackupComponent) []string {
+	if c == nil {
+		return nil
+	}
+	var names []string
+	for _, f := range c.Files {
+		if name, ok := strings.CutSuffix(path.Base(f), ".yaml"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ValidateRestore checks that the target cluster can take the backup before anything is changed:
+// the cluster is no older than the one backed up, the namespace is empty, the StorageClasses the
+// PVCs need exist, every snapshot can be reached, and notes what has to be done by hand.
+func (kc *KubernetesChecker) ValidateRestore(ctx context.Context, meta *BackupMetadata, opts RestoreOptions) ([]CheckResult, error) {
+	if err := restoreDefaults(meta, &opts); err != nil {
+		return nil, err
+	}
+
+	results := []CheckResult{kc.checkRestoreVersion(ctx, meta), kc.checkRestoreNamespace(ctx, opts)}
+	if containsString(opts.Components, BackupSnapshots) {
+		results = append(results, kc.checkRestoreStorageClasses(ctx, meta, opts), kc.checkRestoreSnapshots(ctx, meta, opts))
+	}
+	if containsString(opts.Components, BackupSecrets) && meta.SecretsRedacted {
+		results = append(results, kc.checkRedactedSecrets(ctx, meta, opts))
+	}
+	if containsString(opts.Components, BackupDatabase) && meta.Database != nil {
+		results = append(results, checkRestoreDatabase(meta, opts))
+	}
+	return results, nil
+}
+
+func (kc *KubernetesChecker) checkRestoreVersion(ctx context.Context, meta *BackupMetadata) CheckResult {
+	result := CheckResult{Name: RestoreCheckVersion}
+	current, err := kc.CheckKubernetesVersion(ctx)
+	if err != nil {
+		result.Status, result.Message = CheckFail, err.Error()
+		return result
+	}
+	result.Status, result.Message = compareRestoreVersions(meta.KubernetesVersion, current)
+	return result
+}
+
+// compareRestoreVersions fails when the target cluster runs an older minor version than the one
+// backed up, since resources saved from a newer cluster may use API fields the older one lacks
+func compareRestoreVersions(source, target string) (string, string) {
+	if source == "" {
+		return CheckWarn, fmt.Sprintf("%s; the backup does not record the version it was taken on", target)
+	}
+	src, err := parseNodeVersion(source)
+	if err != nil {
+		return CheckWarn, fmt.Sprintf("%s; cannot compare with %s: %v", target, source, err)
+	}
+	dst, err := parseNodeVersion(target)
+	if err != nil {
+		return CheckWarn, fmt.Sprintf("cannot compare %s with %s: %v", target, source, err)
+	}
+	if dst.Major() < src.Major() || (dst.Major() == src.Major() && dst.Minor() < src.Minor()) {
+		return CheckFail, fmt.Sprintf("%s is older than %s the backup was taken on", target, source)
+	}
+	return CheckPass, fmt.Sprintf("%s (backup taken on %s)", target, source)
+}
+
+// checkRestoreNamespace requires the namespace to be missing or free of workloads and PVCs, so a
+// restore never overwrites a running installation unless forced. Restoring only the database or
+// Helm values is meant for a reinstalled namespace, so it is allowed to be in use.
+func (kc *KubernetesChecker) checkRestoreNamespace(ctx context.Context, opts RestoreOptions) CheckResult {
+	result := CheckResult{Name: RestoreCheckNamespace, Status: CheckPass}
+	_, err := kc.clientset.CoreV1().Namespaces().Get(ctx, opts.Namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		result.Message = fmt.Sprintf("%s does not exist and will be created", opts.Namespace)
+		return result
+	}
+	if err != nil {
+		result.Status, result.Message = CheckFail, fmt.Sprintf("failed to read namespace %s: %v", opts.Namespace, err)
+		return result
+	}
+	if !restoresIntoNamespace(opts.Components) {
+		result.Message = fmt.Sprintf("%s exists; only %s restored", opts.Namespace, strings.Join(opts.Components, " and "))
+		return result
+	}
+
+	var found []string
+	count := func(kind string, list func() (int, error)) {
+		n, err := list()
+		if err != nil {
+			found = append(found, fmt.Sprintf("%s unknown (%v)", kind, err))
+		} else if n > 0 {
+			found = append(found, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	ns, opt := opts.Namespace, metav1.ListOptions{}
+	count("pods", func() (int, error) {
+		l, err := kc.clientset.CoreV1().Pods(ns).List(ctx, opt)
+		if err != nil {
+			return 0, err
+		}
+		return len(l.Items), nil
+	})
+	count("PVCs", func() (int, error) {
+		l, err := kc.clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, opt)
+		if err != nil {
+			return 0, err
+		}
+		return len(l.Items), nil
+	})
+	count("Deployments", func() (int, error) {
+		l, err := kc.clientset.AppsV1().Deployments(ns).List(ctx, opt)
+		if err != nil {
+			return 0, err
+		}
+		return len(l.Items), nil
+	})
+	count("StatefulSets", func() (int, error) {
+		l, err := kc.clientset.AppsV1().StatefulSets(ns).List(ctx, opt)
+		if err != nil {
+			return 0, err
+		}
+		return len(l.Items), nil
+	})
+
+	switch {
+	case len(found) == 0:
+		result.Message = fmt.Sprintf("%s exists and is empty", opts.Namespace)
+	case opts.Force:
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%s has %s; restoring anyway (--force), existing objects are updated", opts.Namespace, strings.Join(found, ", "))
+	default:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("%s has %s; restore into an empty namespace or rerun with --force", opts.Namespace, strings.Join(found, ", "))
+	}
+	return result
+}
+
+// restoresIntoNamespace reports whether the components create objects that would collide with a
+// running installation
+func restoresIntoNamespace(components []string) bool {
+	for _, c := range []string{BackupConfigMaps, BackupSecrets, BackupSnapshots} {
+		if containsString(components, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoredStorageClass is the StorageClass a snapshot's PVC is recreated with; empty means the
+// cluster default
+func restoredStorageClass(snap BackupSnapshot, opts RestoreOptions) string {
+	if opts.StorageClass != "" {
+		return opts.StorageClass
+	}
+	return snap.StorageClass
+}
+
+func (kc *KubernetesChecker) checkRestoreStorageClasses(ctx context.Context, meta *BackupMetadata, opts RestoreOptions) CheckResult {
+	result := CheckResult{Name: RestoreCheckStorageClasses, Status: CheckPass}
+	list, err := kc.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		result.Status, result.Message = CheckFail, fmt.Sprintf("failed to list StorageClasses: %v", err)
+		return result
+	}
+	available := map[string]bool{}
+	hasDefault := false
+	for _, sc := range list.Items {
+		available[sc.Name] = true
+		hasDefault = hasDefault || sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true"
+	}
+
+	var needed, missing []string
+	for _, snap := range meta.Snapshots {
+		class := restoredStorageClass(snap, opts)
+		label := class
+		if class == "" {
+			label = "(default)"
+		}
+		if containsString(needed, label) {
+			continue
+		}
+		needed = append(needed, label)
+		if (class == "" && !hasDefault) || (class != "" && !available[class]) {
+			missing = append(missing, label)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("missing %s; create them or map the PVCs onto one with --storage-class", strings.Join(missing, ", "))
+	case len(needed) == 0:
+		result.Message = "no PVCs to restore"
+	default:
+		result.Message = strings.Join(needed, ", ")
+	}
+	return result
+}
+
+// snapshotSource reports how a snapshot can be restored: from the VolumeSnapshot it was taken as,
+// when restoring into the same namespace of the same cluster, or from its storage backend handle
+func (kc *KubernetesChecker) snapshotSource(ctx context.Context, meta *BackupMetadata, snap BackupSnapshot, namespace string) (bool, error) {
+	if namespace == meta.Namespace {
+		_, err := kc.dynamicClient.Resource(volumeSnapshotGVR).Namespace(namespace).Get(ctx, snap.Name, metav1.GetOptions{})
+		if err == nil {
+			return true, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to read VolumeSnapshot %s: %v", snap.Name, err)
+		}
+	}
+	if snap.Handle == "" || snap.Driver == "" {
+		if !snap.Ready {
+			return false, fmt.Errorf("%s was not ready when the backup finished and is not in this cluster", snap.Name)
+		}
+		return false, fmt.Errorf("%s is not in this cluster and the backup has no storage handle for it", snap.Name)
+	}
+	if _, err := kc.clientset.StorageV1().CSIDrivers().Get(ctx, snap.Driver, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("%s needs CSI driver %s, which is not installed", snap.Name, snap.Driver)
+		}
+		return false, fmt.Errorf("failed to read CSI driver %s: %v", snap.Driver, err)
+	}
+	return false, nil
+}
+
+func (kc *KubernetesChecker) checkRestoreSnapshots(ctx context.Context, meta *BackupMetadata, opts RestoreOptions) CheckResult {
+	result := CheckResult{Name: BackupSnapshots, Status: CheckPass}
+	var problems []string
+	inCluster, fromHandle := 0, 0
+	for _, snap := range meta.Snapshots {
+		existing, err := kc.snapshotSource(ctx, meta, snap, opts.Namespace)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case existing:
+			inCluster++
+		default:
+			fromHandle++
+		}
+	}
+	if len(problems) > 0 {
+		result.Status, result.Message = CheckFail, strings.Join(problems, "; ")
+		return result
+	}
+	result.Message = fmt.Sprintf("%d PVCs from existing snapshots, %d from storage handles", inCluster, fromHandle)
+	return result
+}
+
+// checkRedactedSecrets warns about Secrets whose values were left out of the backup and that do
+// not exist in the target namespace yet; they have to be created by hand
+func (kc *KubernetesChecker) checkRedactedSecrets(ctx context.Context, meta *BackupMetadata, opts RestoreOptions) CheckResult {
+	result := CheckResult{Name: BackupSecrets, Status: CheckPass}
+	var missing []string
+	for _, name := range componentObjects(meta.component(BackupSecrets)) {
+		_, err := kc.clientset.CoreV1().Secrets(opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("values were redacted; create %s in %s with their values (keys are listed in the %s annotation)",
+			strings.Join(missing, ", "), opts.Namespace, BackupRedactedKeysAnnotation)
+		return result
+	}
+	result.Message = "values were redacted; every Secret already exists in " + opts.Namespace
+	return result
+}
+
+func checkRestoreDatabase(meta *BackupMetadata, opts RestoreOptions) CheckResult {
+	result := CheckResult{Name: BackupDatabase, Status: CheckPass}
+	if meta.Database.Method == DatabaseMethodCNPG {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("restore CloudNativePG Backup %s by bootstrapping a Cluster from it; dynactl does not load operator backups", meta.Database.OperatorBackup)
+		return result
+	}
+	result.Message = fmt.Sprintf("%s is loaded with pg_restore into the pod matching %s once it is ready", meta.Database.File, opts.Database.Selector)
+	return result
+}
+
+// RestoreBackup restores a backup archive in dependency order: the namespace, then ConfigMaps and
+// Secrets so workloads find their configuration, then PVCs from the snapshots so StatefulSets bind
+// to the restored data, then the Helm values to reinstall the releases with, and last the
+// database, which waits for the reinstalled database pod. Smoke checks run once every step is
+// done. Run ValidateRestore first.
+func (kc *KubernetesChecker) RestoreBackup(ctx context.Context, archive string, meta *BackupMetadata, opts RestoreOptions) (*RestoreResult, error) {
+	if err := restoreDefaults(meta, &opts); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "dynactl-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := ExtractArchive(archive, dir, ExtractOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", archive, err)
+	}
+
+	result := &RestoreResult{}
+	record := func(step CheckResult) {
+		result.Steps = append(result.Steps, step)
+		if opts.OnStep != nil {
+			opts.OnStep(step)
+		}
+	}
+
+	r := &restorer{kc: kc, dir: dir, meta: meta, opts: opts}
+	steps := []struct {
+		name string
+		run  func(context.Context) (CheckResult, error)
+	}{
+		{RestoreCheckNamespace, r.namespace},
+		{BackupConfigMaps, r.configMaps},
+		{BackupSecrets, r.secrets},
+		{BackupSnapshots, r.volumes},
+		{BackupHelm, r.helmValues},
+		{BackupDatabase, r.database},
+	}
+	for _, step := range steps {
+		if step.name != RestoreCheckNamespace && !containsString(opts.Components, step.name) {
+			continue
+		}
+		res, err := step.run(ctx)
+		if err != nil {
+			record(CheckResult{Name: step.name, Status: CheckFail, Message: err.Error()})
+			return result, fmt.Errorf("%s restore failed: %w", step.name, err)
+		}
+		record(res)
+	}
+	result.Smoke = r.smokeChecks(ctx)
+	return result, nil
+}
+
+// restorer carries the state shared by the restore steps
+type restorer struct {
+	kc   *KubernetesChecker
+	dir  string
+	meta *BackupMetadata
+	opts RestoreOptions
+	// pvcs and database record what was restored, for the smoke checks
+	pvcs       []string
+	dbRestored bool
+}
+
+func (r *restorer) namespace(ctx context.Context) (CheckResult, error) {
+	result := CheckResult{Name: RestoreCheckNamespace, Status: CheckPass}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: r.opts.Namespace}}
+	_, err := r.kc.clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		result.Message = r.opts.Namespace + " exists"
+	case err != nil:
+		return result, fmt.Errorf("failed to create namespace %s: %v", r.opts.Namespace, err)
+	default:
+		result.Message = "created " + r.opts.Namespace
+	}
+	return result, nil
+}
+
+// readObjects unmarshals each YAML file a component stored
+func readObjects[T any](r *restorer, component string) ([]T, error) {
+	c := r.meta.component(component)
+	if c == nil {
+		return nil, nil
+	}
+	var objects []T
+	for _, f := range c.Files {
+		data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(f)))
+		if err != nil {
+			return nil, err
+		}
+		var obj T
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func (r *restorer) configMaps(ctx context.Context) (CheckResult, error) {
+	configMaps, err := readObjects[corev1.ConfigMap](r, BackupConfigMaps)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	client := r.kc.clientset.CoreV1().ConfigMaps(r.opts.Namespace)
+	for _, cm := range configMaps {
+		cm.Namespace = r.opts.Namespace
+		_, err := client.Create(ctx, &cm, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			var existing *corev1.ConfigMap
+			if existing, err = client.Get(ctx, cm.Name, metav1.GetOptions{}); err == nil {
+				cm.ResourceVersion = existing.ResourceVersion
+				_, err = client.Update(ctx, &cm, metav1.UpdateOptions{})
+			}
+		}
+		if err != nil {
+			return CheckResult{}, fmt.Errorf("failed to restore ConfigMap %s: %v", cm.Name, err)
+		}
+	}
+	return CheckResult{Name: BackupConfigMaps, Status: CheckPass, Message: fmt.Sprintf("%d restored", len(configMaps))}, nil
+}
+
+// secrets restores the Secrets saved with their values. Redacted Secrets are left alone when they
+// exist and otherwise reported, since creating them without values would break the workloads.
+func (r *restorer) secrets(ctx context.Context) (CheckResult, error) {
+	secrets, err := readObjects[corev1.Secret](r, BackupSecrets)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	client := r.kc.clientset.CoreV1().Secrets(r.opts.Namespace)
+	restored := 0
+	var needValues []string
+	for _, secret := range secrets {
+		secret.Namespace = r.opts.Namespace
+		if _, redacted := secret.Annotations[BackupRedactedKeysAnnotation]; redacted {
+			if _, err := client.Get(ctx, secret.Name, metav1.GetOptions{}); err != nil {
+				needValues = append(needValues, secret.Name)
+			}
+			continue
+		}
+		_, err := client.Create(ctx, &secret, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			var existing *corev1.Secret
+			if existing, err = client.Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+				secret.ResourceVersion = existing.ResourceVersion
+				_, err = client.Update(ctx, &secret, metav1.UpdateOptions{})
+			}
+		}
+		if err != nil {
+			return CheckResult{}, fmt.Errorf("failed to restore Secret %s: %v", secret.Name, err)
+		}
+		restored++
+	}
+	result := CheckResult{Name: BackupSecrets, Status: CheckPass, Message: fmt.Sprintf("%d restored", restored)}
+	if len(needValues) > 0 {
+		result.Status = CheckWarn
+		result.Message += fmt.Sprintf("; create %s with their values", strings.Join(needValues, ", "))
+	}
+	return result, nil
+}
+
+// volumes recreates each PVC from its snapshot. Outside the namespace and cluster the snapshot was
+// taken in, the snapshot is first imported from its storage handle as a pre-provisioned
+// VolumeSnapshotContent that is retained when the VolumeSnapshot is deleted.
+func (r *restorer) volumes(ctx context.Context) (CheckResult, error) {
+	created, existing := 0, 0
+	for _, snap := range r.meta.Snapshots {
+		_, err := r.kc.clientset.CoreV1().PersistentVolumeClaims(r.opts.Namespace).Get(ctx, snap.PVC, metav1.GetOptions{})
+		if err == nil {
+			existing++
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return CheckResult{}, fmt.Errorf("failed to read PVC %s: %v", snap.PVC, err)
+		}
+
+		inCluster, err := r.kc.snapshotSource(ctx, r.meta, snap, r.opts.Namespace)
+		if err != nil {
+			return CheckResult{}, err
+		}
+		if !inCluster {
+			if err := r.kc.importSnapshot(ctx, snap, r.opts.Namespace); err != nil {
+				return CheckResult{}, err
+			}
+		}
+		pvc, err := restoredPVC(snap, r.opts)
+		if err != nil {
+			return CheckResult{}, err
+		}
+		if _, err := r.kc.clientset.CoreV1().PersistentVolumeClaims(r.opts.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+			return CheckResult{}, fmt.Errorf("failed to create PVC %s: %v", snap.PVC, err)
+		}
+		r.pvcs = append(r.pvcs, snap.PVC)
+		created++
+	}
+	result := CheckResult{Name: BackupSnapshots, Status: CheckPass, Message: fmt.Sprintf("%d PVCs created from snapshots", created)}
+	if existing > 0 {
+		result.Status = CheckWarn
+		result.Message += fmt.Sprintf("; %d already existed and were left as they are", existing)
+	}
+	return result, nil
+}
+
+// importSnapshot creates a VolumeSnapshotContent for a snapshot's storage handle and a
+// VolumeSnapshot bound to it in the namespace. Objects left by an earlier attempt are reused.
+func (kc *KubernetesChecker) importSnapshot(ctx context.Context, snap BackupSnapshot, namespace string) error {
+	contentName := restoredContentName(namespace, snap.Name)
+	content := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshotContent",
+		"metadata": map[string]any{
+			"name":   contentName,
+			"labels": map[string]any{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		"spec": map[string]any{
+			"deletionPolicy":    "Retain",
+			"driver":            snap.Driver,
+			"source":            map[string]any{"snapshotHandle": snap.Handle},
+			"volumeSnapshotRef": map[string]any{"name": snap.Name, "namespace": namespace},
+		},
+	}}
+	if _, err := kc.dynamicClient.Resource(volumeSnapshotContentGVR).Create(ctx, content, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to import snapshot %s: %v", snap.Name, err)
+	}
+
+	vs := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]any{
+			"name":      snap.Name,
+			"namespace": namespace,
+			"labels":    map[string]any{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		"spec": map[string]any{"source": map[string]any{"volumeSnapshotContentName": contentName}},
+	}}
+	if _, err := kc.dynamicClient.Resource(volumeSnapshotGVR).Namespace(namespace).Create(ctx, vs, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create VolumeSnapshot %s: %v", snap.Name, err)
+	}
+	return nil
+}
+
+// restoredContentName names an imported VolumeSnapshotContent, which is cluster scoped, after the
+// namespace and snapshot it is imported for
+func restoredContentName(namespace, snapshot string) string {
+	name := "dynactl-" + namespace + "-" + snapshot
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return name
+}
+
+// restoredPVC builds a PVC named and sized like the one backed up, provisioned from its snapshot
+func restoredPVC(snap BackupSnapshot, opts RestoreOptions) (*corev1.PersistentVolumeClaim, error) {
+	if snap.Size == "" {
+		return nil, fmt.Errorf("the backup does not record the size of PVC %s", snap.PVC)
+	}
+	size, err := resource.ParseQuantity(snap.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q for PVC %s: %v", snap.Size, snap.PVC, err)
+	}
+	modes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	if len(snap.AccessModes) > 0 {
+		modes = nil
+		for _, m := range snap.AccessModes {
+			modes = append(modes, corev1.PersistentVolumeAccessMode(m))
+		}
+	}
+	apiGroup := volumeSnapshotGVR.Group
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snap.PVC,
+			Namespace: opts.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: modes,
+			Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: size}},
+			DataSource:  &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: snap.Name},
+		},
+	}
+	if class := restoredStorageClass(snap, opts); class != "" {
+		pvc.Spec.StorageClassName = &class
+	}
+	return pvc, nil
+}
+
+// helmValues writes the saved values where they can be passed to helm, and says how
+func (r *restorer) helmValues(ctx context.Context) (CheckResult, error) {
+	result := CheckResult{Name: BackupHelm, Status: CheckPass}
+	if err := os.MkdirAll(r.opts.ValuesDir, 0o700); err != nil {
+		return result, fmt.Errorf("failed to create values directory: %w", err)
+	}
+	var commands, redacted []string
+	for _, rel := range r.meta.Releases {
+		data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(rel.File)))
+		if err != nil {
+			return result, err
+		}
+		dest := filepath.Join(r.opts.ValuesDir, path.Base(rel.File))
+		if err := os.WriteFile(dest, data, 0o600); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+		commands = append(commands, HelmInstallCommand(rel, r.opts.Namespace, dest))
+		if len(rel.RedactedKeys) > 0 {
+			redacted = append(redacted, fmt.Sprintf("%s: %s", dest, strings.Join(rel.RedactedKeys, ", ")))
+		}
+	}
+	result.Message = fmt.Sprintf("values of %d releases written to %s; reinstall them with:\n    %s", len(r.meta.Releases), r.opts.ValuesDir, strings.Join(commands, "\n    "))
+	if len(redacted) > 0 {
+		result.Status = CheckWarn
+		result.Message += fmt.Sprintf("\n  fill in the redacted values first:\n    %s", strings.Join(redacted, "\n    "))
+	}
+	return result, nil
+}
+
+// HelmInstallCommand is the helm command that reinstalls a release with its saved values
+func HelmInstallCommand(rel BackupRelease, namespace, valuesFile string) string {
+	return fmt.Sprintf("helm upgrade --install %s %s --version %s -n %s -f %s", rel.Name, rel.Chart, rel.ChartVersion, namespace, valuesFile)
+}
+
+// database waits for the reinstalled database pod and loads the dump into it with pg_restore,
+// dropping objects the new installation created first
+func (r *restorer) database(ctx context.Context) (CheckResult, error) {
+	result := CheckResult{Name: BackupDatabase, Status: CheckPass}
+	info := r.meta.Database
+	if info == nil {
+		return result, fmt.Errorf("the backup has no database information")
+	}
+	if info.Method == DatabaseMethodCNPG {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("not loaded; bootstrap a CloudNativePG Cluster from Backup %s", info.OperatorBackup)
+		return result, nil
+	}
+
+	LogInfo("Waiting up to %s for a ready database pod matching %s", r.opts.DatabaseWait, r.opts.Database.Selector)
+	pod, err := r.kc.waitForReadyPod(ctx, r.opts.Namespace, r.opts.Database.Selector, r.opts.DatabaseWait)
+	if err != nil {
+		return result, err
+	}
+	dump, err := os.Open(filepath.Join(r.dir, filepath.FromSlash(info.File)))
+	if err != nil {
+		return result, err
+	}
+	defer dump.Close()
+
+	var stderr bytes.Buffer
+	if err := r.kc.execStream(ctx, r.opts.Namespace, pod, r.opts.Database.Container, []string{"sh", "-c", pgRestoreScript(r.opts.Database)}, dump, nil, &stderr); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return result, fmt.Errorf("pg_restore in %s failed: %s", pod, msg)
+		}
+		return result, fmt.Errorf("pg_restore in %s failed: %v", pod, err)
+	}
+	r.dbRestored = true
+	result.Message = fmt.Sprintf("%s loaded into %s", info.File, pod)
+	return result, nil
+}
+
+// pgRestoreScript loads a custom-format dump from stdin, replacing existing objects
+func pgRestoreScript(db DatabaseBackupOptions) string {
+	user, name := pgConnection(db)
+	return pgPasswordEnv + "exec pg_restore --clean --if-exists --no-owner -U " + user + " -d " + name
+}
+
+// pgTableCountScript counts the tables outside the system schemas
+func pgTableCountScript(db DatabaseBackupOptions) string {
+	user, name := pgConnection(db)
+	return pgPasswordEnv + `exec psql -tA -U ` + user + " -d " + name +
+		` -c "SELECT count(*) FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema')"`
+}
+
+// waitForReadyPod returns the first ready pod matching selector, waiting up to timeout for one
+func (kc *KubernetesChecker) waitForReadyPod(ctx context.Context, namespace, selector string, timeout time.Duration) (string, error) {
+	var name string
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := kc.listPodsBySelector(ctx, namespace, selector)
+		if err != nil {
+			return false, err
+		}
+		for i := range pods {
+			if isPodReady(&pods[i]) {
+				name = pods[i].Name
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if wait.Interrupted(err) {
+		return "", fmt.Errorf("no ready pod matches %s in %s after %s; install the releases, then rerun with --components %s", selector, namespace, timeout, BackupDatabase)
+	}
+	return name, err
+}
+
+// smokeChecks checks the restored installation: the PVCs bind, the database holds tables, and
+// the pods in the namespace start
+func (r *restorer) smokeChecks(ctx context.Context) []CheckResult {
+	var results []CheckResult
+	if len(r.pvcs) > 0 {
+		results = append(results, r.checkRestoredPVCs(ctx))
+	}
+	if r.dbRestored {
+		results = append(results, r.checkRestoredDatabase(ctx))
+	}
+	return append(results, r.checkRestoredPods(ctx))
+}
+
+func (r *restorer) checkRestoredPVCs(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "pvcs", Status: CheckPass}
+	var pending []string
+	for _, name := range r.pvcs {
+		pvc, err := r.kc.clientset.CoreV1().PersistentVolumeClaims(r.opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			result.Status, result.Message = CheckFail, fmt.Sprintf("failed to read PVC %s: %v", name, err)
+			return result
+		}
+		if pvc.Status.Phase != corev1.ClaimBound {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		// Classes with WaitForFirstConsumer binding only provision once a pod uses the claim
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%s not bound yet; they bind once the releases' pods use them", strings.Join(pending, ", "))
+		return result
+	}
+	result.Message = fmt.Sprintf("%d bound", len(r.pvcs))
+	return result
+}
+
+func (r *restorer) checkRestoredDatabase(ctx context.Context) CheckResult {
+	result := CheckResult{Name: BackupDatabase}
+	pod, err := r.kc.waitForReadyPod(ctx, r.opts.Namespace, r.opts.Database.Selector, time.Second)
+	if err != nil {
+		result.Status, result.Message = CheckFail, err.Error()
+		return result
+	}
+	var stdout, stderr bytes.Buffer
+	if err := r.kc.execStream(ctx, r.opts.Namespace, pod, r.opts.Database.Container, []string{"sh", "-c", pgTableCountScript(r.opts.Database)}, nil, &stdout, &stderr); err != nil {
+		result.Status, result.Message = CheckFail, fmt.Sprintf("query failed: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+		return result
+	}
+	tables, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	switch {
+	case err != nil:
+		result.Status, result.Message = CheckFail, fmt.Sprintf("unexpected answer %q", strings.TrimSpace(stdout.String()))
+	case tables == 0:
+		result.Status, result.Message = CheckFail, "the restored database has no tables"
+	default:
+		result.Status, result.Message = CheckPass, fmt.Sprintf("%d tables", tables)
+	}
+	return result
+}
+
+// checkRestoredPods fails on pods that cannot start and warns about pods still starting
+func (r *restorer) checkRestoredPods(ctx context.Context) CheckResult {
+	result := CheckResult{Name: RestoreCheckPods}
+	pods, err := r.kc.clientset.CoreV1().Pods(r.opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		result.Status, result.Message = CheckFail, fmt.Sprintf("failed to list pods: %v", err)
+		return result
+	}
+	result.Status, result.Message = summarizeRestoredPods(pods.Items)
+	return result
+}
+
+// summarizeRestoredPods reports pods that are crash looping or cannot pull their image as
+// failures and pods that are not ready yet as a warning
+func summarizeRestoredPods(pods []corev1.Pod) (string, string) {
+	if len(pods) == 0 {
+		return CheckWarn, "no pods yet; install the releases with the restored values"
+	}
+	var broken, starting []string
+	ready := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		switch reason := podWaitingReason(pod); reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "CreateContainerConfigError":
+			broken = append(broken, pod.Name+" ("+reason+")")
+		default:
+			if isPodReady(pod) {
+				ready++
+			} else {
+				starting = append(starting, pod.Name)
+			}
+		}
+	}
+	sort.Strings(broken)
+	sort.Strings(starting)
+	switch {
+	case len(broken) > 0:
+		return CheckFail, strings.Join(broken, ", ")
+	case len(starting) > 0:
+		return CheckWarn, fmt.Sprintf("%d ready, %d starting: %s", ready, len(starting), strings.Join(starting, ", "))
+	}
+	return CheckPass, fmt.Sprintf("%d ready", ready)
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestoreDefaults(t *testing.T) {
+	meta := &BackupMetadata{
+		Name:      "pre-upgrade",
+		Namespace: "dynamo",
+		Database:  &BackupDatabaseInfo{Method: DatabaseMethodExec, Name: "dynamo"},
+		Components: []BackupComponent{
+			{Name: BackupDatabase, Status: BackupOK},
+			{Name: BackupConfigMaps, Status: BackupOK},
+			{Name: BackupHelm, Status: BackupSkipped},
+			{Name: BackupSnapshots, Status: BackupPending},
+		},
+	}
+
+	opts := RestoreOptions{}
+	if err := restoreDefaults(meta, &opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Namespace != "dynamo" || opts.Database.Name != "dynamo" || opts.Database.Selector != DefaultDatabaseSelector || opts.DatabaseWait != DefaultDatabaseWait {
+		t.Errorf("defaults not filled in: %+v", opts)
+	}
+	if strings.Join(opts.Components, ",") != "database,configmaps,pvc-snapshots" {
+		t.Errorf("expected skipped components left out, got %v", opts.Components)
+	}
+
+	for _, tc := range []struct {
+		components []string
+		want       string
+	}{
+		{[]string{BackupHelm}, "does not hold helm-values"},
+		{[]string{"volumes"}, `unknown backup component "volumes"`},
+	} {
+		opts := RestoreOptions{Components: tc.components}
+		if err := restoreDefaults(meta, &opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v", tc.want, err)
+		}
+	}
+}
+
+func TestCompareRestoreVersions(t *testing.T) {
+	cases := []struct {
+		source, target, want string
+	}{
+		{"v1.29.4", "v1.30.1", CheckPass},
+		{"v1.29.4", "v1.29.0-eks-1234", CheckPass},
+		{"v1.30.1", "v1.29.4", CheckFail},
+		{"", "v1.30.1", CheckWarn},
+		{"garbage", "v1.30.1", CheckWarn},
+	}
+	for _, tc := range cases {
+		if status, msg := compareRestoreVersions(tc.source, tc.target); status != tc.want {
+			t.Errorf("%s -> %s: expected %s, got %s (%s)", tc.source, tc.target, tc.want, status, msg)
+		}
+	}
+}
+
+func TestRestoresIntoNamespace(t *testing.T) {
+	if restoresIntoNamespace([]string{BackupDatabase, BackupHelm}) {
+		t.Error("database and Helm values only should not need an empty namespace")
+	}
+	if !restoresIntoNamespace([]string{BackupDatabase, BackupSecrets}) {
+		t.Error("Secrets should need an empty namespace")
+	}
+}
+
+func TestRestoredPVC(t *testing.T) {
+	snap := BackupSnapshot{Name: "data-postgres-0-20261017t090000z", PVC: "data-postgres-0", StorageClass: "gp3", Size: "50Gi", AccessModes: []string{"ReadWriteOnce"}}
+	pvc, err := restoredPVC(snap, RestoreOptions{Namespace: "dynamo-dr"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pvc.Name != "data-postgres-0" || pvc.Namespace != "dynamo-dr" || *pvc.Spec.StorageClassName != "gp3" {
+		t.Errorf("unexpected PVC %+v", pvc.ObjectMeta)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "50Gi" {
+		t.Errorf("unexpected size %s", size.String())
+	}
+	if ds := pvc.Spec.DataSource; ds == nil || ds.Kind != "VolumeSnapshot" || ds.Name != snap.Name || *ds.APIGroup != "snapshot.storage.k8s.io" {
+		t.Errorf("unexpected data source %+v", ds)
+	}
+
+	pvc, err = restoredPVC(snap, RestoreOptions{Namespace: "dynamo", StorageClass: "premium"})
+	if err != nil || *pvc.Spec.StorageClassName != "premium" {
+		t.Errorf("expected the StorageClass override, got %v (%v)", pvc, err)
+	}
+
+	snap.Size = ""
+	if _, err := restoredPVC(snap, RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "does not record the size") {
+		t.Errorf("expected a missing size error, got %v", err)
+	}
+}
+
+func TestRestoredContentName(t *testing.T) {
+	if got := restoredContentName("dynamo", "data-0-20261017t090000z"); got != "dynactl-dynamo-data-0-20261017t090000z" {
+		t.Errorf("unexpected name %s", got)
+	}
+	if got := restoredContentName("dynamo", strings.Repeat("a", 253)); len(got) != 253 {
+		t.Errorf("expected name cut to 253 characters, got %d", len(got))
+	}
+}
+
+func TestPgRestoreScript(t *testing.T) {
+	script := pgRestoreScript(DatabaseBackupOptions{Name: "dynamo"})
+	if !strings.Contains(script, "pg_restore --clean --if-exists --no-owner") || !strings.HasSuffix(script, `-U "${POSTGRES_USER:-postgres}" -d 'dynamo'`) {
+		t.Errorf("unexpected script %s", script)
+	}
+}
+
+func TestComponentObjects(t *testing.T) {
+	c := &BackupComponent{Files: []string{"secrets/db-credentials.yaml", "secrets/license.yaml"}}
+	if got := componentObjects(c); strings.Join(got, ",") != "db-credentials,license" {
+		t.Errorf("unexpected names %v", got)
+	}
+	if componentObjects(nil) != nil {
+		t.Error("expected no names for a missing component")
+	}
+}
+
+func TestSummarizeRestoredPods(t *testing.T) {
+	pod := func(name string, ready bool, waiting string) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		if waiting != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}}}}
+		}
+		return p
+	}
+
+	if status, _ := summarizeRestoredPods(nil); status != CheckWarn {
+		t.Errorf("expected a warning without pods, got %s", status)
+	}
+	if status, msg := summarizeRestoredPods([]corev1.Pod{pod("api", true, ""), pod("ui", true, "")}); status != CheckPass || msg != "2 ready" {
+		t.Errorf("unexpected %s: %s", status, msg)
+	}
+	if status, msg := summarizeRestoredPods([]corev1.Pod{pod("api", true, ""), pod("worker", false, "ContainerCreating")}); status != CheckWarn || !strings.Contains(msg, "worker") {
+		t.Errorf("unexpected %s: %s", status, msg)
+	}
+	if status, msg := summarizeRestoredPods([]corev1.Pod{pod("api", false, "CrashLoopBackOff"), pod("worker", false, "")}); status != CheckFail || msg != "api (CrashLoopBackOff)" {
+		t.Errorf("unexpected %s: %s", status, msg)
+	}
+}
+
+func TestResolveBackup(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "pre-upgrade"+BackupExtension)
+	if err := os.WriteFile(archive, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"pre-upgrade", "pre-upgrade.tar.gz", archive} {
+		if got, err := ResolveBackup(dir, ref); err != nil || got != archive {
+			t.Errorf("%s: expected %s, got %s (%v)", ref, archive, got, err)
+		}
+	}
+	if _, err := ResolveBackup(dir, "missing"); err == nil || !strings.Contains(err.Error(), "no backup named missing") {
+		t.Errorf("expected a missing backup error, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// backupScheduleName names the CronJob, its service account, RBAC, and PVC
+const backupScheduleName = "dynactl-backup"
+
+// backupScheduleDir is where the backup PVC is mounted in the CronJob's pods
+const backupScheduleDir = "/backups"
+
+// DefaultBackupStorageSize is the size of the PVC scheduled backups are kept on
+const DefaultBackupStorageSize = "50Gi"
+
+// BackupScheduleOptions configures in-cluster backups on a schedule
+type BackupScheduleOptions struct {
+	Namespace string
+	// Schedule is a standard five-field cron expression or a descriptor such as @daily
+	Schedule string
+	// Retain is how many backups of the namespace to keep; older ones are pruned after each run
+	Retain int
+	// Image is a container image with dynactl on its PATH
+	Image string
+	// StorageSize and StorageClass configure the PVC backups are written to
+	StorageSize  string
+	StorageClass string
+	// BackupArgs are extra `backup create` flags, such as --components
+	BackupArgs []string
+}
+
+// BackupSchedule holds the resources that run scheduled backups
+type BackupSchedule struct {
+	ServiceAccount     *corev1.ServiceAccount
+	Role               *rbacv1.Role
+	RoleBinding        *rbacv1.RoleBinding
+	ClusterRole        *rbacv1.ClusterRole
+	ClusterRoleBinding *rbacv1.ClusterRoleBinding
+	PVC                *corev1.PersistentVolumeClaim
+	CronJob            *batchv1.CronJob
+}
+
+var cronField = regexp.MustCompile(`^[0-9A-Za-z*?,/\-]+$`)
+
+var cronDescriptors = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+// ValidateCronSchedule checks a schedule has the shape the CronJob controller accepts; the API
+// server validates the values of each field
+func ValidateCronSchedule(schedule string) error {
+	if containsString(cronDescriptors, schedule) {
+		return nil
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return fmt.Errorf("invalid cron schedule %q: expected 5 fields (minute hour day month weekday) or one of %s", schedule, strings.Join(cronDescriptors, ", "))
+	}
+	for _, f := range fields {
+		if !cronField.MatchString(f) {
+			return fmt.Errorf("invalid cron schedule %q: unexpected field %q", schedule, f)
+		}
+	}
+	return nil
+}
+
+// NewBackupSchedule builds the service account, RBAC, PVC, and CronJob that run `dynactl backup
+// create --retain` in the namespace on a schedule
+func NewBackupSchedule(opts BackupScheduleOptions) (*BackupSchedule, error) {
+	if opts.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if opts.Image == "" {
+		return nil, fmt.Errorf("an image with dynactl is required")
+	}
+	if opts.Retain <= 0 {
+		return nil, fmt.Errorf("retain must be at least 1")
+	}
+	if err := ValidateCronSchedule(opts.Schedule); err != nil {
+		return nil, err
+	}
+	if opts.StorageSize == "" {
+		opts.StorageSize = DefaultBackupStorageSize
+	}
+	size, err := resource.ParseQuantity(opts.StorageSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage size %q: %v", opts.StorageSize, err)
+	}
+
+	ns := opts.Namespace
+	labels := map[string]string{"app.kubernetes.io/name": backupScheduleName, "app.kubernetes.io/managed-by": "dynactl"}
+	meta := metav1.ObjectMeta{Name: backupScheduleName, Namespace: ns, Labels: labels}
+	// The ClusterRole is cluster scoped, so it is named after the namespace it serves
+	clusterMeta := metav1.ObjectMeta{Name: backupScheduleName + "-" + ns, Labels: labels}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: backupScheduleName, Namespace: ns}
+
+	s := &BackupSchedule{
+		ServiceAccount: &corev1.ServiceAccount{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"}, ObjectMeta: meta},
+		Role: &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: meta,
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "configmaps", "secrets", "persistentvolumeclaims"}, Verbs: []string{"get", "list"}},
+				{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+				{APIGroups: []string{volumeSnapshotGVR.Group}, Resources: []string{volumeSnapshotGVR.Resource}, Verbs: []string{"create", "get", "list", "delete"}},
+				{APIGroups: []string{cnpgBackupGVR.Group}, Resources: []string{cnpgBackupGVR.Resource}, Verbs: []string{"create"}},
+			},
+		},
+		RoleBinding: &rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: meta,
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: backupScheduleName},
+		},
+		// Reading snapshot handles needs the cluster-scoped VolumeSnapshotContents
+		ClusterRole: &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: clusterMeta,
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{volumeSnapshotContentGVR.Group}, Resources: []string{volumeSnapshotContentGVR.Resource}, Verbs: []string{"get"}},
+			},
+		},
+		ClusterRoleBinding: &rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: clusterMeta,
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterMeta.Name},
+		},
+		PVC: &corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: meta,
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: size}},
+			},
+		},
+		CronJob: backupCronJob(opts, meta),
+	}
+	if opts.StorageClass != "" {
+		s.PVC.Spec.StorageClassName = &opts.StorageClass
+	}
+	return s, nil
+}
+
+// backupCronJob runs one backup at a time; a run still going when the next is due is not doubled up
+func backupCronJob(opts BackupScheduleOptions, meta metav1.ObjectMeta) *batchv1.CronJob {
+	args := []string{"backup", "create", "-n", opts.Namespace, "--dir", backupScheduleDir, "--retain", strconv.Itoa(opts.Retain)}
+	args = append(args, opts.BackupArgs...)
+	backoff := int32(1)
+	history := int32(3)
+	return &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: meta,
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   opts.Schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &history,
+			FailedJobsHistoryLimit:     &history,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoff,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: meta.Labels},
+						Spec: corev1.PodSpec{
+							ServiceAccountName: backupScheduleName,
+							RestartPolicy:      corev1.RestartPolicyNever,
+							Containers: []corev1.Container{{
+								Name:    "backup",
+								Image:   opts.Image,
+								Command: []string{"dynactl"},
+								Args:    args,
+								// dynactl keeps its audit log under $HOME, which is kept with the backups
+								Env:          []corev1.EnvVar{{Name: "HOME", Value: backupScheduleDir}},
+								VolumeMounts: []corev1.VolumeMount{{Name: "backups", MountPath: backupScheduleDir}},
+							}},
+							Volumes: []corev1.Volume{{
+								Name:         "backups",
+								VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: backupScheduleName}},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// objects lists the resources in the order they are applied
+func (s *BackupSchedule) objects() []runtime.Object {
+	return []runtime.Object{s.ServiceAccount, s.Role, s.RoleBinding, s.ClusterRole, s.ClusterRoleBinding, s.PVC, s.CronJob}
+}
+
+// Export writes the resources into a GitOps directory instead of applying them; see
+// ExportResources
+func (s *BackupSchedule) Export(dir string) ([]string, error) {
+	return ExportResources(dir, s.objects()...)
+}
+
+// Render returns the resources as a multi-document YAML manifest
+func (s *BackupSchedule) Render() ([]byte, error) {
+	var buf bytes.Buffer
+	for i, obj := range s.objects() {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// ApplyBackupSchedule creates the resources, or updates them when they exist, and describes each
+// change. An existing PVC is left as it is, since its size and class cannot simply be changed.
+func (kc *KubernetesChecker) ApplyBackupSchedule(ctx context.Context, s *BackupSchedule) ([]string, error) {
+	ns := s.CronJob.Namespace
+	var changes []string
+	note := func(kind, name string, created bool) {
+		verb := "updated"
+		if created {
+			verb = "created"
+		}
+		changes = append(changes, fmt.Sprintf("%s %s %s", verb, kind, name))
+	}
+
+	created, err := applyObject(ctx, s.ServiceAccount, kc.clientset.CoreV1().ServiceAccounts(ns))
+	if err != nil {
+		return changes, fmt.Errorf("failed to apply ServiceAccount: %v", err)
+	}
+	note("ServiceAccount", ns+"/"+s.ServiceAccount.Name, created)
+	if created, err = applyObject(ctx, s.Role, kc.clientset.RbacV1().Roles(ns)); err != nil {
+		return changes, fmt.Errorf("failed to apply Role: %v", err)
+	}
+	note("Role", ns+"/"+s.Role.Name, created)
+	if created, err = applyObject(ctx, s.RoleBinding, kc.clientset.RbacV1().RoleBindings(ns)); err != nil {
+		return changes, fmt.Errorf("failed to apply RoleBinding: %v", err)
+	}
+	note("RoleBinding", ns+"/"+s.RoleBinding.Name, created)
+	if created, err = applyObject(ctx, s.ClusterRole, kc.clientset.RbacV1().ClusterRoles()); err != nil {
+		return changes, fmt.Errorf("failed to apply ClusterRole: %v", err)
+	}
+	note("ClusterRole", s.ClusterRole.Name, created)
+	if created, err = applyObject(ctx, s.ClusterRoleBinding, kc.clientset.RbacV1().ClusterRoleBindings()); err != nil {
+		return changes, fmt.Errorf("failed to apply ClusterRoleBinding: %v", err)
+	}
+	note("ClusterRoleBinding", s.ClusterRoleBinding.Name, created)
+
+	_, err = kc.clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, s.PVC, metav1.CreateOptions{})
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		changes = append(changes, fmt.Sprintf("kept PersistentVolumeClaim %s/%s", ns, s.PVC.Name))
+	case err != nil:
+		return changes, fmt.Errorf("failed to create PersistentVolumeClaim: %v", err)
+	default:
+		note("PersistentVolumeClaim", ns+"/"+s.PVC.Name, true)
+	}
+
+	if created, err = applyObject(ctx, s.CronJob, kc.clientset.BatchV1().CronJobs(ns)); err != nil {
+		return changes, fmt.Errorf("failed to apply CronJob: %v", err)
+	}
+	note("CronJob", ns+"/"+s.CronJob.Name, created)
+	return changes, nil
+}
+
+// objectClient is the part of a typed client applyObject needs
+type objectClient[T metav1.Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+// applyObject creates obj, or replaces the existing object with it, and reports whether it was
+// created
+func applyObject[T metav1.Object](ctx context.Context, obj T, client objectClient[T]) (bool, error) {
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, obj, metav1.CreateOptions{})
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	return false, err
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateCronSchedule(t *testing.T) {
+	for _, schedule := range []string{"0 2 * * *", "*/15 0-6 * * MON-FRI", "@daily"} {
+		if err := ValidateCronSchedule(schedule); err != nil {
+			t.Errorf("expected %q to be valid, got %v", schedule, err)
+		}
+	}
+	for _, schedule := range []string{"", "0 2 * *", "0 2 * * * *", "@fortnightly", "0 2 * * $"} {
+		if err := ValidateCronSchedule(schedule); err == nil {
+			t.Errorf("expected %q to be rejected", schedule)
+		}
+	}
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 2, 0, 0, 0, time.UTC) }
+	backups := []BackupMetadata{
+		{Name: "dynamo-1", Namespace: "dynamo", Created: day(1)},
+		{Name: "staging-1", Namespace: "staging", Created: day(1)},
+		{Name: "dynamo-2", Namespace: "dynamo", Created: day(2)},
+		{Name: "dynamo-3", Namespace: "dynamo", Created: day(3)},
+	}
+	prune := backupsToPrune(backups, "dynamo", 2)
+	if len(prune) != 1 || prune[0].Name != "dynamo-1" {
+		t.Fatalf("unexpected backups to prune %+v", prune)
+	}
+	if prune := backupsToPrune(backups, "dynamo", 3); len(prune) != 0 {
+		t.Errorf("expected nothing to prune, got %+v", prune)
+	}
+	if prune := backupsToPrune(backups, "staging", 0); len(prune) != 0 {
+		t.Errorf("expected retain 0 to keep all, got %+v", prune)
+	}
+}
+
+func TestNewBackupSchedule(t *testing.T) {
+	opts := BackupScheduleOptions{
+		Namespace:  "dynamo",
+		Schedule:   "0 2 * * *",
+		Retain:     7,
+		Image:      "registry.example.com/dynactl:v1.4.0",
+		BackupArgs: []string{"--components=database,configmaps"},
+	}
+	s, err := NewBackupSchedule(opts)
+	if err != nil {
+		t.Fatalf("NewBackupSchedule failed: %v", err)
+	}
+	job := s.CronJob
+	if job.Spec.Schedule != "0 2 * * *" || job.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent {
+		t.Errorf("unexpected CronJob spec %+v", job.Spec)
+	}
+	pod := job.Spec.JobTemplate.Spec.Template.Spec
+	want := "backup create -n dynamo --dir /backups --retain 7 --components=database,configmaps"
+	if got := strings.Join(pod.Containers[0].Args, " "); got != want {
+		t.Errorf("unexpected args %q", got)
+	}
+	if pod.ServiceAccountName != backupScheduleName || pod.Volumes[0].PersistentVolumeClaim.ClaimName != s.PVC.Name {
+		t.Errorf("unexpected pod spec %+v", pod)
+	}
+	if s.PVC.Spec.Resources.Requests.Storage().String() != DefaultBackupStorageSize || s.PVC.Spec.StorageClassName != nil {
+		t.Errorf("unexpected PVC spec %+v", s.PVC.Spec)
+	}
+	if s.ClusterRole.Name != "dynactl-backup-dynamo" || s.ClusterRoleBinding.RoleRef.Name != s.ClusterRole.Name {
+		t.Errorf("unexpected cluster RBAC %s -> %s", s.ClusterRoleBinding.Name, s.ClusterRoleBinding.RoleRef.Name)
+	}
+
+	data, err := s.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	docs := strings.Split(string(data), "---\n")
+	if len(docs) != 7 {
+		t.Fatalf("expected 7 documents, got %d:\n%s", len(docs), data)
+	}
+	var rendered batchv1.CronJob
+	if err := yaml.Unmarshal([]byte(docs[6]), &rendered); err != nil || rendered.Kind != "CronJob" || rendered.Namespace != "dynamo" {
+		t.Errorf("unexpected CronJob document %q (%v)", docs[6], err)
+	}
+
+	cases := []struct {
+		change func(*BackupScheduleOptions)
+		want   string
+	}{
+		{func(o *BackupScheduleOptions) { o.Image = "" }, "image"},
+		{func(o *BackupScheduleOptions) { o.Retain = 0 }, "retain must be at least 1"},
+		{func(o *BackupScheduleOptions) { o.Schedule = "daily" }, "invalid cron schedule"},
+		{func(o *BackupScheduleOptions) { o.StorageSize = "lots" }, "invalid storage size"},
+	}
+	for _, tc := range cases {
+		o := opts
+		tc.change(&o)
+		if _, err := NewBackupSchedule(o); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v", tc.want, err)
+		}
+	}
+}
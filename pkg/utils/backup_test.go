@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateBackupOptions(t *testing.T) {
+	opts := BackupOptions{Namespace: "dynamo"}
+	if err := ValidateBackupOptions(&opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opts.Components) != len(BackupComponents) || opts.Database.Method != DatabaseMethodExec || opts.Database.Selector != DefaultDatabaseSelector {
+		t.Fatalf("defaults not filled in: %+v", opts)
+	}
+
+	cases := []struct {
+		opts BackupOptions
+		want string
+	}{
+		{BackupOptions{}, "namespace is required"},
+		{BackupOptions{Namespace: "dynamo", Components: []string{"volumes"}}, `unknown backup component "volumes"`},
+		{BackupOptions{Namespace: "dynamo", Database: DatabaseBackupOptions{Method: "velero"}}, `unknown database backup method "velero"`},
+		{BackupOptions{Namespace: "dynamo", Database: DatabaseBackupOptions{Method: DatabaseMethodCNPG}}, "cluster name is required"},
+	}
+	for _, tc := range cases {
+		if err := ValidateBackupOptions(&tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v", tc.want, err)
+		}
+	}
+
+	// The database method only matters when the database is backed up
+	opts = BackupOptions{Namespace: "dynamo", Components: []string{BackupConfigMaps}, Database: DatabaseBackupOptions{Method: "velero"}}
+	if err := ValidateBackupOptions(&opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPgDumpScript(t *testing.T) {
+	script := pgDumpScript(DatabaseBackupOptions{})
+	if !strings.Contains(script, `-U "${POSTGRES_USER:-postgres}" "${POSTGRES_DB:-postgres}"`) {
+		t.Errorf("expected pod defaults, got %s", script)
+	}
+	script = pgDumpScript(DatabaseBackupOptions{Name: "dynamo", User: "admin"})
+	if !strings.Contains(script, "--format=custom") || !strings.HasSuffix(script, "-U 'admin' 'dynamo'") {
+		t.Errorf("unexpected script %s", script)
+	}
+}
+
+func TestExportSecret(t *testing.T) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "db-credentials",
+			Namespace:       "dynamo",
+			ResourceVersion: "42",
+			UID:             "abc",
+			Annotations:     map[string]string{corev1.LastAppliedConfigAnnotation: "{}"},
+		},
+		Data: map[string][]byte{"password": []byte("s3cret"), "username": []byte("dynamo")},
+	}
+
+	redacted := exportSecret(secret, false)
+	if redacted.Data != nil || redacted.Annotations[BackupRedactedKeysAnnotation] != "password,username" {
+		t.Fatalf("expected values redacted, got %+v", redacted)
+	}
+	if redacted.ResourceVersion != "" || redacted.UID != "" || redacted.Kind != "Secret" {
+		t.Errorf("expected server metadata stripped, got %+v", redacted.ObjectMeta)
+	}
+	if _, ok := redacted.Annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		t.Error("expected last-applied annotation dropped")
+	}
+
+	kept := exportSecret(secret, true)
+	if string(kept.Data["password"]) != "s3cret" || kept.Annotations != nil {
+		t.Errorf("expected values kept, got %+v", kept)
+	}
+}
+
+func TestLatestReleases(t *testing.T) {
+	releases := []*release.Release{
+		{Name: "dynamoai", Version: 3},
+		{Name: "guard", Version: 1},
+		{Name: "dynamoai", Version: 5},
+		{Name: "dynamoai", Version: 4},
+	}
+	latest := latestReleases(releases)
+	if len(latest) != 2 || latest[0].Name != "dynamoai" || latest[0].Version != 5 || latest[1].Name != "guard" {
+		t.Fatalf("unexpected releases %+v", latest)
+	}
+}
+
+func TestRedactHelmValues(t *testing.T) {
+	var values map[string]any
+	err := yaml.Unmarshal([]byte(`
+replicaCount: 2
+postgresql:
+  auth:
+    username: dynamo
+    password: hunter2
+    existingSecret: db-credentials
+    passwordSecretName: db-credentials
+api:
+  apiKey: sk-123
+  tokenTTL: 3600
+  env:
+    - name: DB_PASSWORD
+      value: hunter2
+    - name: LOG_LEVEL
+      value: info
+oauth:
+  clientSecret: ""
+  enableTokens: true
+`), &values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redacted := redactHelmValues(values, "")
+	want := []string{"api.apiKey", "api.env[0].value", "api.tokenTTL", "postgresql.auth.password"}
+	if strings.Join(redacted, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v to be redacted, got %v", want, redacted)
+	}
+	auth := values["postgresql"].(map[string]any)["auth"].(map[string]any)
+	if auth["password"] != redactedValue || auth["username"] != "dynamo" || auth["existingSecret"] != "db-credentials" || auth["passwordSecretName"] != "db-credentials" {
+		t.Errorf("unexpected auth values %v", auth)
+	}
+	env := values["api"].(map[string]any)["env"].([]any)
+	if env[1].(map[string]any)["value"] != "info" {
+		t.Errorf("expected unrelated env values to be kept, got %v", env)
+	}
+}
+
+func TestSnapshotName(t *testing.T) {
+	if got := snapshotName("data-postgres-0", "20261017T090000Z"); got != "data-postgres-0-20261017t090000z" {
+		t.Errorf("unexpected name %s", got)
+	}
+	if got := snapshotName(strings.Repeat("a", 300), "20261017T090000Z"); len(got) != 253 {
+		t.Errorf("expected name cut to 253 characters, got %d", len(got))
+	}
+}
+
+func writeTestBackup(t *testing.T, dir string, meta *BackupMetadata, files map[string]string) string {
+	t.Helper()
+	staging := t.TempDir()
+	component := BackupComponent{Name: BackupConfigMaps, Status: BackupOK}
+	for name, content := range files {
+		path := filepath.Join(staging, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		component.Files = append(component.Files, name)
+	}
+	meta.Components = append(meta.Components, component)
+	archive := filepath.Join(dir, meta.Name+BackupExtension)
+	if err := writeBackupArchive(archive, staging, meta); err != nil {
+		t.Fatalf("writeBackupArchive failed: %v", err)
+	}
+	return archive
+}
+
+func TestBackupArchiveMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	backups, err := ListBackups(filepath.Join(dir, "missing"))
+	if err != nil || len(backups) != 0 {
+		t.Fatalf("expected no backups, got %v (%v)", backups, err)
+	}
+
+	later := &BackupMetadata{FormatVersion: BackupFormatVersion, Name: "dynamo-later", Namespace: "dynamo", Created: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)}
+	earlier := &BackupMetadata{FormatVersion: BackupFormatVersion, Name: "dynamo-earlier", Namespace: "dynamo", Created: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), SecretsRedacted: true}
+	archive := writeTestBackup(t, dir, later, map[string]string{"configmaps/app.yaml": "kind: ConfigMap\n"})
+	writeTestBackup(t, dir, earlier, nil)
+	if _, err := os.Stat(archive + ".partial"); !os.IsNotExist(err) {
+		t.Errorf("expected partial archive renamed, got %v", err)
+	}
+
+	// Entries that are not backups are skipped
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken"+BackupExtension), []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err = ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != "dynamo-earlier" || backups[1].Name != "dynamo-later" {
+		t.Fatalf("unexpected backups %+v", backups)
+	}
+	if backups[1].Path != archive || backups[1].Size == 0 || !backups[0].SecretsRedacted {
+		t.Errorf("unexpected metadata %+v", backups[1])
+	}
+	if files := backups[1].Components[0].Files; len(files) != 1 || files[0] != "configmaps/app.yaml" {
+		t.Errorf("unexpected files %v", files)
+	}
+
+	// Archives extract like any other dynactl bundle
+	extracted := t.TempDir()
+	if _, err := ExtractArchive(archive, extracted, ExtractOptions{}); err != nil {
+		t.Fatalf("ExtractArchive failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(extracted, backupMetadataFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta BackupMetadata
+	if err := json.Unmarshal(data, &meta); err != nil || meta.FormatVersion != BackupFormatVersion {
+		t.Errorf("unexpected backup.json %s (%v)", data, err)
+	}
+	if content, err := os.ReadFile(filepath.Join(extracted, "configmaps", "app.yaml")); err != nil || string(content) != "kind: ConfigMap\n" {
+		t.Errorf("unexpected ConfigMap %q (%v)", content, err)
+	}
+}
+
+func TestReadBackupMetadataRejectsNewerFormat(t *testing.T) {
+	archive := writeTestBackup(t, t.TempDir(), &BackupMetadata{FormatVersion: BackupFormatVersion + 1, Name: "future"}, nil)
+	if _, err := ReadBackupMetadata(archive); err == nil || !strings.Contains(err.Error(), "update dynactl") {
+		t.Errorf("expected a format version error, got %v", err)
+	}
+}
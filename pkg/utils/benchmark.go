@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchmarkOptions configures a latency smoke test against an HTTP endpoint
+type BenchmarkOptions struct {
+	URL         string
+	Method      string
+	Body        []byte
+	Headers     map[string]string
+	Requests    int
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// BenchmarkResult summarizes the latency and error rate of a benchmark run
+type BenchmarkResult struct {
+	URL               string
+	Requests          int
+	Concurrency       int
+	Errors            int
+	ErrorRate         float64
+	P50               time.Duration
+	P95               time.Duration
+	Max               time.Duration
+	Mean              time.Duration
+	Duration          time.Duration
+	RequestsPerSecond float64
+	// FirstError is the first failure seen, to help diagnose a failing endpoint
+	FirstError string `json:",omitempty"`
+}
+
+// RunBenchmark sends opts.Requests requests with opts.Concurrency workers and reports latency
+// percentiles. Responses with a status of 400 or above count as errors.
+func RunBenchmark(ctx context.Context, opts BenchmarkOptions) (*BenchmarkResult, error) {
+	if opts.Requests <= 0 {
+		return nil, fmt.Errorf("requests must be greater than zero")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Method == "" {
+		opts.Method = http.MethodGet
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	latencies := make([]time.Duration, 0, opts.Requests)
+	result := &BenchmarkResult{URL: opts.URL, Requests: opts.Requests, Concurrency: opts.Concurrency}
+
+	jobs := make(chan struct{}, opts.Requests)
+	for i := 0; i < opts.Requests; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				latency, err := benchmarkRequest(ctx, client, opts)
+				mu.Lock()
+				latencies = append(latencies, latency)
+				if err != nil {
+					result.Errors++
+					if result.FirstError == "" {
+						result.FirstError = err.Error()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	result.P50 = latencyPercentile(latencies, 50)
+	result.P95 = latencyPercentile(latencies, 95)
+	result.Max = latencies[len(latencies)-1]
+	result.Mean = total / time.Duration(len(latencies))
+	result.ErrorRate = float64(result.Errors) / float64(len(latencies))
+	if result.Duration > 0 {
+		result.RequestsPerSecond = float64(len(latencies)) / result.Duration.Seconds()
+	}
+	return result, nil
+}
+
+func benchmarkRequest(ctx context.Context, client *http.Client, opts BenchmarkOptions) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.URL, bytes.NewReader(opts.Body))
+	if err != nil {
+		return 0, err
+	}
+	if len(opts.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Since(start), err
+	}
+	// Latency includes reading the full response body, as a client would
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	latency := time.Since(start)
+
+	if resp.StatusCode >= 400 {
+		return latency, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return latency, nil
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted latencies
+func latencyPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
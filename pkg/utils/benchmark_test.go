@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 20; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	if got := latencyPercentile(latencies, 50); got != 10*time.Millisecond {
+		t.Errorf("p50 = %s, want 10ms", got)
+	}
+	if got := latencyPercentile(latencies, 95); got != 19*time.Millisecond {
+		t.Errorf("p95 = %s, want 19ms", got)
+	}
+	if got := latencyPercentile(nil, 95); got != 0 {
+		t.Errorf("empty p95 = %s, want 0", got)
+	}
+}
+
+func TestRunBenchmark(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fourth request fails
+		if atomic.AddInt32(&calls, 1)%4 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	result, err := RunBenchmark(context.Background(), BenchmarkOptions{
+		URL:         server.URL,
+		Method:      http.MethodPost,
+		Body:        []byte(`{"input":"hi"}`),
+		Requests:    20,
+		Concurrency: 4,
+		Timeout:     5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("RunBenchmark returned error: %v", err)
+	}
+	if result.Errors != 5 {
+		t.Errorf("Errors = %d, want 5", result.Errors)
+	}
+	if result.ErrorRate != 0.25 {
+		t.Errorf("ErrorRate = %f, want 0.25", result.ErrorRate)
+	}
+	if result.FirstError != "HTTP 503" {
+		t.Errorf("FirstError = %q, want HTTP 503", result.FirstError)
+	}
+	if result.P95 < result.P50 {
+		t.Errorf("p95 %s is lower than p50 %s", result.P95, result.P50)
+	}
+}
@@ -0,0 +1,721 @@
+package utils
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Bundle archive compression formats
+const (
+	CompressionZstd = "zstd"
+	CompressionGzip = "gzip"
+	CompressionNone = "none"
+)
+
+// Compressions lists the formats ExportArtifacts can write
+var Compressions = []string{CompressionZstd, CompressionGzip, CompressionNone}
+
+// gzipBlockSize is the input each gzip worker compresses into its own gzip member. It is fixed
+// rather than derived from the CPU count so every machine writes the same bytes.
+const gzipBlockSize = 4 << 20
+
+// ArchiveDigestSuffix names the detached file recording a bundle archive's SHA-256, in the
+// format sha256sum -c reads
+const ArchiveDigestSuffix = ".sha256"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ExportOptions configures how a bundle archive is compressed
+type ExportOptions struct {
+	Compression string
+	// Level is the compression level: 1-9 for gzip, 1-22 for zstd, 0 for the format's default
+	Level int
+}
+
+// ExportResult summarizes a written bundle archive
+type ExportResult struct {
+	Path  string
+	Files int
+	// Bytes is the size of the archived files before compression
+	Bytes int64
+	// Size is the size of the archive itself
+	Size int64
+	// Digest is the archive's sha256: digest, also written to Path + ArchiveDigestSuffix
+	Digest string
+}
+
+// ArchiveExtension returns the file extension for a bundle archive compressed with compression
+func ArchiveExtension(compression string) string {
+	switch compression {
+	case CompressionZstd:
+		return ".tar.zst"
+	case CompressionGzip:
+		return ".tar.gz"
+	}
+	return ".tar"
+}
+
+// ValidateExportOptions checks the compression format and that the level is in its range
+func ValidateExportOptions(opts ExportOptions) error {
+	switch opts.Compression {
+	case CompressionGzip:
+		if opts.Level < 0 || opts.Level > 9 {
+			return fmt.Errorf("gzip compression level must be between 1 and 9")
+		}
+	case CompressionZstd:
+		if opts.Level < 0 || opts.Level > 22 {
+			return fmt.Errorf("zstd compression level must be between 1 and 22")
+		}
+	case CompressionNone:
+		if opts.Level != 0 {
+			return fmt.Errorf("--compression-level cannot be used without compression")
+		}
+	default:
+		return fmt.Errorf("unsupported compression %q; use one of %s", opts.Compression, strings.Join(Compressions, ", "))
+	}
+	return nil
+}
+
+// ExportArtifacts writes every file under srcDir into a single tar archive at dest, compressed
+// on all CPUs with zstd or gzip. The archive is reproducible: files are added in sorted order
+// with fixed timestamps, owners, and normalized permissions, so identical inputs give
+// byte-identical archives. Its digest is recorded next to it in dest + ArchiveDigestSuffix.
+func ExportArtifacts(srcDir, dest string, opts ExportOptions) (*ExportResult, error) {
+	if err := ValidateExportOptions(opts); err != nil {
+		return nil, err
+	}
+	files, err := bundleSources(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", srcDir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to export in %s", srcDir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(LongPath(dest)), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(LongPath(dest)), "."+filepath.Base(dest)+".part-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	result := &ExportResult{Path: dest}
+	hash := sha256.New()
+	err = writeBundleArchive(io.MultiWriter(tmp, hash), files, opts, result)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	result.Size = info.Size()
+	if err := os.Rename(tmp.Name(), LongPath(dest)); err != nil {
+		return nil, err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	result.Digest = "sha256:" + sum
+	digestFile := fmt.Sprintf("%s  %s\n", sum, filepath.Base(dest))
+	if err := writeFileAtomic(LongPath(dest+ArchiveDigestSuffix), []byte(digestFile)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", dest+ArchiveDigestSuffix, err)
+	}
+	return result, nil
+}
+
+// VerifyArchiveDigest checks an archive against the digest file written next to it by
+// ExportArtifacts. It returns the verified digest, or "" when there is no digest file.
+func VerifyArchiveDigest(archive string) (string, error) {
+	data, err := os.ReadFile(LongPath(archive + ArchiveDigestSuffix))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return "", fmt.Errorf("malformed digest file %s", archive+ArchiveDigestSuffix)
+	}
+	want := strings.ToLower(fields[0])
+
+	f, err := os.Open(LongPath(archive))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", archive, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return "", fmt.Errorf("%s does not match its digest file: expected sha256:%s, got sha256:%s", archive, want, got)
+	}
+	return "sha256:" + want, nil
+}
+
+func writeBundleArchive(out io.Writer, files []bundleSource, opts ExportOptions, result *ExportResult) error {
+	compressed, err := newCompressWriter(out, opts)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(compressed)
+	for idx, file := range files {
+		LogInfo("📦 Adding %s (%d/%d, %s)", file.Name, idx+1, len(files), FormatBytes(file.Size))
+		if err := addFileToTar(tw, file); err != nil {
+			compressed.Close()
+			return err
+		}
+		result.Files++
+		result.Bytes += file.Size
+	}
+	if err := tw.Close(); err != nil {
+		compressed.Close()
+		return err
+	}
+	return compressed.Close()
+}
+
+func addFileToTar(tw *tar.Writer, file bundleSource) error {
+	f, err := os.Open(LongPath(file.localPath))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(bundleHeader(file.Name, info)); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file.localPath, err)
+	}
+	return nil
+}
+
+// bundleHeader builds a tar header that depends only on the file's name, size, and whether it
+// is executable, leaving out timestamps, owners, and the local umask
+func bundleHeader(name string, info os.FileInfo) *tar.Header {
+	mode := int64(0o644)
+	if info.Mode().Perm()&0o111 != 0 {
+		mode = 0o755
+	}
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     info.Size(),
+		Mode:     mode,
+		ModTime:  time.Unix(0, 0),
+	}
+}
+
+// newCompressWriter wraps out in the requested compression, using every CPU
+func newCompressWriter(out io.Writer, opts ExportOptions) (io.WriteCloser, error) {
+	switch opts.Compression {
+	case CompressionZstd:
+		level := zstd.SpeedDefault
+		if opts.Level != 0 {
+			level = zstd.EncoderLevelFromZstd(opts.Level)
+		}
+		return zstd.NewWriter(out, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(0)))
+	case CompressionGzip:
+		level := gzip.DefaultCompression
+		if opts.Level != 0 {
+			level = opts.Level
+		}
+		return newParallelGzipWriter(out, level, runtime.GOMAXPROCS(0)), nil
+	}
+	return nopWriteCloser{out}, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// parallelGzipWriter compresses fixed-size blocks concurrently, each as its own gzip member.
+// Concatenated members form a valid gzip stream that gunzip and tar read as one file.
+type parallelGzipWriter struct {
+	level   int
+	buf     []byte
+	pending chan chan gzipBlock
+	done    chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+type gzipBlock struct {
+	data []byte
+	err  error
+}
+
+func newParallelGzipWriter(out io.Writer, level, workers int) *parallelGzipWriter {
+	w := &parallelGzipWriter{
+		level:   level,
+		buf:     make([]byte, 0, gzipBlockSize),
+		pending: make(chan chan gzipBlock, workers),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		for block := range w.pending {
+			b := <-block
+			if b.err == nil && w.failed() == nil {
+				_, b.err = out.Write(b.data)
+			}
+			if b.err != nil {
+				w.fail(b.err)
+			}
+		}
+	}()
+	return w
+}
+
+func (w *parallelGzipWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if err := w.failed(); err != nil {
+			return written, err
+		}
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		if len(w.buf) == cap(w.buf) {
+			w.flushBlock()
+		}
+	}
+	return written, nil
+}
+
+// flushBlock hands the buffered block to a worker; it blocks once every worker is busy
+func (w *parallelGzipWriter) flushBlock() {
+	if len(w.buf) == 0 {
+		return
+	}
+	data := w.buf
+	w.buf = make([]byte, 0, gzipBlockSize)
+	result := make(chan gzipBlock, 1)
+	w.pending <- result
+	go func() {
+		var compressed bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&compressed, w.level)
+		if err == nil {
+			if _, err = zw.Write(data); err == nil {
+				err = zw.Close()
+			}
+		}
+		result <- gzipBlock{data: compressed.Bytes(), err: err}
+	}()
+}
+
+func (w *parallelGzipWriter) Close() error {
+	w.flushBlock()
+	close(w.pending)
+	<-w.done
+	return w.failed()
+}
+
+func (w *parallelGzipWriter) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *parallelGzipWriter) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// DetectCompression reports how an archive is compressed from its leading bytes
+func DetectCompression(r *bufio.Reader) string {
+	head, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(head, zstdMagic):
+		return CompressionZstd
+	case bytes.HasPrefix(head, gzipMagic):
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+// Symlink policies for ExtractArchive
+const (
+	// SymlinksSkip leaves symbolic and hard links out of the extraction
+	SymlinksSkip = "skip"
+	// SymlinksSafe creates links whose targets stay inside the destination and fails on others
+	SymlinksSafe = "safe"
+	// SymlinksFail fails the extraction at the first link
+	SymlinksFail = "fail"
+)
+
+// SymlinkPolicies lists the accepted symlink policies
+var SymlinkPolicies = []string{SymlinksSkip, SymlinksSafe, SymlinksFail}
+
+// Policies for files that already exist in the destination
+const (
+	ExistingFail      = "fail"
+	ExistingOverwrite = "overwrite"
+	ExistingSkip      = "skip"
+)
+
+// ExtractOptions controls how ExtractArchive treats links and existing files
+type ExtractOptions struct {
+	// Symlinks is SymlinksSkip (default), SymlinksSafe, or SymlinksFail
+	Symlinks string
+	// Existing is ExistingFail (default), ExistingOverwrite, or ExistingSkip
+	Existing string
+}
+
+// ExtractResult lists what ExtractArchive wrote and what it left alone
+type ExtractResult struct {
+	Files   []string
+	Links   []string
+	Skipped []string
+}
+
+// ExtractArchive unpacks a bundle archive into dest, detecting zstd, gzip, or no compression
+// from its contents rather than its name. Entries that would land outside dest, directly or
+// through a link, fail the extraction. Permissions and modification times are kept, and so is
+// ownership when running as root.
+func ExtractArchive(archive, dest string, opts ExtractOptions) (*ExtractResult, error) {
+	if opts.Symlinks == "" {
+		opts.Symlinks = SymlinksSkip
+	}
+	if opts.Existing == "" {
+		opts.Existing = ExistingFail
+	}
+	if !containsString(SymlinkPolicies, opts.Symlinks) {
+		return nil, fmt.Errorf("unsupported symlink policy %q; use one of %s", opts.Symlinks, strings.Join(SymlinkPolicies, ", "))
+	}
+
+	f, err := os.Open(LongPath(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 1<<20)
+	var r io.Reader = br
+	switch DetectCompression(br) {
+	case CompressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		defer zr.Close()
+		r = zr
+	case CompressionGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	root, err := extractRoot(dest)
+	if err != nil {
+		return nil, err
+	}
+	x := &extractor{root: root, opts: opts, result: &ExtractResult{}, seen: map[string]string{}}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return x.result, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		if err := x.extract(tr, hdr); err != nil {
+			return x.result, err
+		}
+	}
+	// Directory times are set last, since creating entries inside them changes them
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		_ = os.Chtimes(LongPath(x.dirs[i].path), x.dirs[i].modTime, x.dirs[i].modTime)
+	}
+	return x.result, nil
+}
+
+// extractRoot creates dest and returns its absolute path with symlinks resolved, so
+// containment checks compare like with like
+func extractRoot(dest string) (string, error) {
+	abs, err := filepath.Abs(dest)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(LongPath(abs), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+type extractor struct {
+	root   string
+	opts   ExtractOptions
+	result *ExtractResult
+	seen   map[string]string
+	dirs   []extractedDir
+}
+
+type extractedDir struct {
+	path    string
+	modTime time.Time
+}
+
+func (x *extractor) extract(r io.Reader, hdr *tar.Header) error {
+	name, err := entryName(hdr.Name)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return nil
+	}
+	key := strings.ToLower(name)
+	if other, ok := x.seen[key]; ok && other != name {
+		if err := checkCaseCollisions("archive entries", []string{other, name}); err != nil {
+			return err
+		}
+	}
+	x.seen[key] = name
+
+	target := filepath.Join(x.root, filepath.FromSlash(name))
+	if err := x.checkParents(target); err != nil {
+		return fmt.Errorf("archive entry %s: %w", name, err)
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(LongPath(target), 0o755); err != nil {
+			return err
+		}
+		_ = os.Chmod(LongPath(target), hdr.FileInfo().Mode().Perm()|0o700)
+		x.dirs = append(x.dirs, extractedDir{path: target, modTime: hdr.ModTime})
+		x.chown(target, hdr)
+	case tar.TypeReg:
+		if skip, err := x.handleExisting(name, target); skip || err != nil {
+			return err
+		}
+		if err := extractFile(r, target, hdr); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		x.chown(target, hdr)
+		x.result.Files = append(x.result.Files, name)
+	case tar.TypeSymlink, tar.TypeLink:
+		return x.extractLink(name, target, hdr)
+	default:
+		LogWarning("Skipping %s: unsupported archive entry type %q", name, hdr.Typeflag)
+		x.result.Skipped = append(x.result.Skipped, name)
+	}
+	return nil
+}
+
+// entryName validates an entry name, refusing absolute paths and names that climb out of the
+// destination instead of silently rewriting them
+func entryName(raw string) (string, error) {
+	name := strings.TrimSuffix(strings.ReplaceAll(raw, `\`, "/"), "/")
+	if name == "" || name == "." {
+		return "", nil
+	}
+	clean := path.Clean(name)
+	if path.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, "../") || filepath.VolumeName(filepath.FromSlash(clean)) != "" {
+		return "", fmt.Errorf("archive entry %q points outside the destination; refusing to extract", raw)
+	}
+	return strings.TrimPrefix(clean, "./"), nil
+}
+
+// checkParents makes sure no directory between the root and target is a link leading out of
+// the root, which would let a later entry be written anywhere on disk
+func (x *extractor) checkParents(target string) error {
+	rel, err := filepath.Rel(x.root, filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+	current := x.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(LongPath(current))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(current)
+		if err != nil || !withinDir(x.root, resolved) {
+			return fmt.Errorf("parent %s is a link outside the destination", current)
+		}
+	}
+	return nil
+}
+
+// handleExisting applies the overwrite policy, reporting whether the entry should be skipped
+func (x *extractor) handleExisting(name, target string) (bool, error) {
+	if _, err := os.Lstat(LongPath(target)); os.IsNotExist(err) {
+		return false, nil
+	}
+	switch x.opts.Existing {
+	case ExistingOverwrite:
+		return false, nil
+	case ExistingSkip:
+		LogDebug("Skipping %s: already exists", name)
+		x.result.Skipped = append(x.result.Skipped, name)
+		return true, nil
+	}
+	return false, fmt.Errorf("%s already exists; use --overwrite or --skip-existing", target)
+}
+
+func (x *extractor) extractLink(name, target string, hdr *tar.Header) error {
+	switch x.opts.Symlinks {
+	case SymlinksSkip:
+		LogWarning("Skipping link %s -> %s (use --symlinks safe to create links)", name, hdr.Linkname)
+		x.result.Skipped = append(x.result.Skipped, name)
+		return nil
+	case SymlinksFail:
+		return fmt.Errorf("archive contains link %s -> %s; refusing to extract (--symlinks fail)", name, hdr.Linkname)
+	}
+
+	// Symlink targets are relative to the link's directory, hard link targets to the root. Both
+	// are followed through the links already extracted, as the OS will: with d -> . in place,
+	// d/l -> ../outside is created in the root and leads out of it.
+	linkTarget := filepath.FromSlash(hdr.Linkname)
+	from := x.root
+	ok := !filepath.IsAbs(linkTarget)
+	if ok && hdr.Typeflag == tar.TypeSymlink {
+		rel, err := filepath.Rel(x.root, filepath.Dir(target))
+		if err != nil {
+			return err
+		}
+		from, ok = x.followPath(x.root, rel)
+	}
+	var resolved string
+	if ok {
+		resolved, ok = x.followPath(from, linkTarget)
+	}
+	if !ok {
+		return fmt.Errorf("link %s -> %s points outside the destination; refusing to extract", name, hdr.Linkname)
+	}
+	if skip, err := x.handleExisting(name, target); skip || err != nil {
+		return err
+	}
+	if err := os.MkdirAll(LongPath(filepath.Dir(target)), 0o755); err != nil {
+		return err
+	}
+	_ = os.Remove(LongPath(target))
+	var err error
+	if hdr.Typeflag == tar.TypeSymlink {
+		err = os.Symlink(linkTarget, LongPath(target))
+	} else {
+		err = os.Link(LongPath(resolved), LongPath(target))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create link %s: %w", name, err)
+	}
+	x.result.Links = append(x.result.Links, name)
+	return nil
+}
+
+// followPath walks rel from dir one element at a time, resolving links that already exist,
+// and reports whether every step stays within the root. Elements not created yet are taken as
+// written.
+func (x *extractor) followPath(dir, rel string) (string, bool) {
+	current := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, part)
+			if info, err := os.Lstat(LongPath(current)); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				resolved, err := filepath.EvalSymlinks(current)
+				if err != nil {
+					return "", false
+				}
+				current = resolved
+			}
+		}
+		if !withinDir(x.root, current) {
+			return "", false
+		}
+	}
+	return current, true
+}
+
+// chown restores the recorded owner, which only root can do; elsewhere files belong to the
+// extracting user
+func (x *extractor) chown(target string, hdr *tar.Header) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		return
+	}
+	if err := os.Lchown(LongPath(target), hdr.Uid, hdr.Gid); err != nil {
+		LogDebug("Failed to restore owner of %s: %v", target, err)
+	}
+}
+
+func withinDir(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// extractFile writes an entry next to its target and renames it into place, so an interrupted
+// extraction never leaves a truncated file and an existing link is replaced, not followed
+func extractFile(r io.Reader, target string, hdr *tar.Header) error {
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(LongPath(dir), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(LongPath(dir), "."+filepath.Base(target)+".part-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Chmod after writing so the umask does not strip recorded permissions
+	if err := os.Chmod(tmp.Name(), hdr.FileInfo().Mode().Perm()); err != nil {
+		return err
+	}
+	if !hdr.ModTime.IsZero() {
+		_ = os.Chtimes(tmp.Name(), hdr.ModTime, hdr.ModTime)
+	}
+	return os.Rename(tmp.Name(), LongPath(target))
+}
@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeBundleFixture(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	src := t.TempDir()
+	// Larger than one gzip block so the parallel writer emits several members
+	large := make([]byte, gzipBlockSize*2+12345)
+	rand.New(rand.NewSource(1)).Read(large)
+	files := map[string][]byte{
+		"artifacts-manifest.json":     []byte(`{"images":[]}`),
+		"api.tar":                     large,
+		"charts/dynamoai-1.0.0.tgz":   []byte("chart"),
+		"models/llama/weights.bin":    bytes.Repeat([]byte("w"), 100000),
+		"models/llama/tokenizer.json": []byte("{}"),
+	}
+	for name, data := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src, files
+}
+
+func TestExportAndExtractRoundTrip(t *testing.T) {
+	src, files := writeBundleFixture(t)
+
+	for _, compression := range Compressions {
+		t.Run(compression, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "bundle"+ArchiveExtension(compression))
+			level := 0
+			if compression != CompressionNone {
+				level = 3
+			}
+			result, err := ExportArtifacts(src, archive, ExportOptions{Compression: compression, Level: level})
+			if err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+			if result.Files != len(files) || result.Size == 0 {
+				t.Fatalf("unexpected result %+v", result)
+			}
+
+			f, err := os.Open(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			detected := DetectCompression(bufio.NewReader(f))
+			f.Close()
+			if detected != compression {
+				t.Fatalf("expected %s to be detected, got %s", compression, detected)
+			}
+
+			dest := t.TempDir()
+			extracted, err := ExtractArchive(archive, dest, ExtractOptions{})
+			if err != nil {
+				t.Fatalf("extract failed: %v", err)
+			}
+			if len(extracted.Files) != len(files) {
+				t.Fatalf("expected %d files, got %v", len(files), extracted.Files)
+			}
+			for name, want := range files {
+				got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%s differs after round trip", name)
+				}
+			}
+		})
+	}
+}
+
+func TestExportArtifactsIsReproducible(t *testing.T) {
+	src, _ := writeBundleFixture(t)
+
+	for _, compression := range Compressions {
+		t.Run(compression, func(t *testing.T) {
+			first := filepath.Join(t.TempDir(), "bundle"+ArchiveExtension(compression))
+			firstResult, err := ExportArtifacts(src, first, ExportOptions{Compression: compression})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Timestamps and the umask differ between machines and runs
+			later := time.Now().Add(time.Hour)
+			manifest := filepath.Join(src, "artifacts-manifest.json")
+			if err := os.Chtimes(manifest, later, later); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(manifest, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chmod(manifest, 0o644)
+
+			second := filepath.Join(t.TempDir(), "bundle"+ArchiveExtension(compression))
+			secondResult, err := ExportArtifacts(src, second, ExportOptions{Compression: compression})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, _ := os.ReadFile(first)
+			b, _ := os.ReadFile(second)
+			if !bytes.Equal(a, b) || firstResult.Digest != secondResult.Digest {
+				t.Fatalf("archives differ: %s vs %s", firstResult.Digest, secondResult.Digest)
+			}
+
+			digest, err := VerifyArchiveDigest(second)
+			if err != nil || digest != secondResult.Digest {
+				t.Fatalf("expected the digest file to verify %s, got %q, %v", secondResult.Digest, digest, err)
+			}
+			sums, _ := os.ReadFile(second + ArchiveDigestSuffix)
+			if !strings.HasSuffix(strings.TrimSpace(string(sums)), "  "+filepath.Base(second)) {
+				t.Fatalf("digest file is not in sha256sum format: %q", sums)
+			}
+		})
+	}
+}
+
+func TestVerifyArchiveDigestDetectsChanges(t *testing.T) {
+	src, _ := writeBundleFixture(t)
+	archive := filepath.Join(t.TempDir(), "bundle.tar")
+	if _, err := ExportArtifacts(src, archive, ExportOptions{Compression: CompressionNone}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(archive, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("tampered"))
+	f.Close()
+	if _, err := VerifyArchiveDigest(archive); err == nil {
+		t.Fatal("expected a modified archive to fail verification")
+	}
+
+	if err := os.Remove(archive + ArchiveDigestSuffix); err != nil {
+		t.Fatal(err)
+	}
+	if digest, err := VerifyArchiveDigest(archive); err != nil || digest != "" {
+		t.Fatalf("a missing digest file is not an error, got %q, %v", digest, err)
+	}
+}
+
+func TestParallelGzipIsReadableByStandardGzip(t *testing.T) {
+	data := bytes.Repeat([]byte("dynactl bundle "), gzipBlockSize/5)
+	var out bytes.Buffer
+	w := newParallelGzipWriter(&out, 6, 4)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("decompressed %d bytes, expected %d", len(got), len(data))
+	}
+}
+
+type tarEntry struct {
+	name     string
+	linkname string
+	typeflag byte
+	mode     int64
+	body     string
+}
+
+func writeTestArchive(t *testing.T, entries ...tarEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		if e.typeflag == 0 {
+			e.typeflag = tar.TypeReg
+		}
+		if e.mode == 0 {
+			e.mode = 0o644
+		}
+		hdr := &tar.Header{Name: e.name, Linkname: e.linkname, Typeflag: e.typeflag, Mode: e.mode, Size: int64(len(e.body))}
+		if e.typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "bundle.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExtractArchiveRejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"../escape.txt", "/etc/escape.txt", "models/../../escape.txt"} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			archive := writeTestArchive(t, tarEntry{name: name, body: "hi"})
+			if _, err := ExtractArchive(archive, filepath.Join(root, "out"), ExtractOptions{}); err == nil {
+				t.Fatal("expected the entry to be refused")
+			}
+			if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+				t.Fatalf("entry escaped the destination: %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveSymlinkPolicies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs extra privileges on Windows")
+	}
+	inside := []tarEntry{
+		{name: "models/llama/weights.bin", body: "weights"},
+		{name: "models/current", linkname: "llama", typeflag: tar.TypeSymlink},
+	}
+
+	dest := t.TempDir()
+	result, err := ExtractArchive(writeTestArchive(t, inside...), dest, ExtractOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Links) != 0 || len(result.Skipped) != 1 {
+		t.Fatalf("links must be skipped by default, got %+v", result)
+	}
+
+	dest = t.TempDir()
+	if _, err := ExtractArchive(writeTestArchive(t, inside...), dest, ExtractOptions{Symlinks: SymlinksSafe}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "models", "current", "weights.bin")); err != nil || string(data) != "weights" {
+		t.Fatalf("expected the link to be created: %v", err)
+	}
+
+	if _, err := ExtractArchive(writeTestArchive(t, inside...), t.TempDir(), ExtractOptions{Symlinks: SymlinksFail}); err == nil {
+		t.Fatal("expected --symlinks fail to refuse the archive")
+	}
+
+	for _, link := range []tarEntry{
+		{name: "etc", linkname: "/etc", typeflag: tar.TypeSymlink},
+		{name: "models/up", linkname: "../../..", typeflag: tar.TypeSymlink},
+		{name: "passwd", linkname: "../etc/passwd", typeflag: tar.TypeLink},
+	} {
+		if _, err := ExtractArchive(writeTestArchive(t, link), t.TempDir(), ExtractOptions{Symlinks: SymlinksSafe}); err == nil {
+			t.Fatalf("expected link %s -> %s to be refused", link.name, link.linkname)
+		}
+	}
+	// Each link looks harmless on its own, but d/l is created in the root through d -> .
+	for _, links := range [][]tarEntry{
+		{
+			{name: "d", linkname: ".", typeflag: tar.TypeSymlink},
+			{name: "d/l", linkname: "../outside", typeflag: tar.TypeSymlink},
+		},
+		{
+			{name: "d", linkname: ".", typeflag: tar.TypeSymlink},
+			{name: "l", linkname: "d/../outside", typeflag: tar.TypeSymlink},
+		},
+	} {
+		dest := filepath.Join(t.TempDir(), "dest")
+		if _, err := ExtractArchive(writeTestArchive(t, links...), dest, ExtractOptions{Symlinks: SymlinksSafe}); err == nil {
+			t.Errorf("expected link %s -> %s after %s -> %s to be refused", links[1].name, links[1].linkname, links[0].name, links[0].linkname)
+		}
+	}
+}
+
+func TestExtractArchiveRefusesWritingThroughExistingLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs extra privileges on Windows")
+	}
+	outside := t.TempDir()
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "models")); err != nil {
+		t.Fatal(err)
+	}
+	archive := writeTestArchive(t, tarEntry{name: "models/weights.bin", body: "weights"})
+	if _, err := ExtractArchive(archive, dest, ExtractOptions{Existing: ExistingOverwrite}); err == nil {
+		t.Fatal("expected the extraction to stop at the link leading outside")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "weights.bin")); !os.IsNotExist(err) {
+		t.Fatalf("file was written through the link: %v", err)
+	}
+}
+
+func TestExtractArchiveExistingFiles(t *testing.T) {
+	archive := writeTestArchive(t, tarEntry{name: "api.tar", body: "new"}, tarEntry{name: "run.sh", body: "#!/bin/sh", mode: 0o750})
+	dest := t.TempDir()
+	existing := filepath.Join(dest, "api.tar")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ExtractArchive(archive, dest, ExtractOptions{}); err == nil {
+		t.Fatal("existing files must not be overwritten by default")
+	}
+
+	result, err := ExtractArchive(archive, dest, ExtractOptions{Existing: ExistingSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "old" || len(result.Skipped) != 1 || len(result.Files) != 1 {
+		t.Fatalf("--skip-existing must keep existing files, got %q and %+v", data, result)
+	}
+
+	if _, err := ExtractArchive(archive, dest, ExtractOptions{Existing: ExistingOverwrite}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "new" {
+		t.Fatalf("--overwrite must replace existing files, got %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dest, "run.sh"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o750 {
+			t.Fatalf("expected permissions 0750 to be kept, got %o", info.Mode().Perm())
+		}
+	}
+}
+
+func TestValidateExportOptions(t *testing.T) {
+	for _, opts := range []ExportOptions{
+		{Compression: "brotli"},
+		{Compression: CompressionGzip, Level: 10},
+		{Compression: CompressionZstd, Level: 23},
+		{Compression: CompressionNone, Level: 3},
+	} {
+		if err := ValidateExportOptions(opts); err == nil {
+			t.Fatalf("expected %+v to be rejected", opts)
+		}
+	}
+}
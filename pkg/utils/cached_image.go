@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// maxCachedImageMetadata caps manifest.json and the config, the only entries read into memory
+const maxCachedImageMetadata = 16 << 20
+
+// archiveSpan locates one regular file inside an image archive
+type archiveSpan struct {
+	offset int64
+	size   int64
+}
+
+// cachedImage serves an image archive from the mirror cache. The archive is indexed in a single
+// pass and layers are streamed from their offsets, so memory use does not grow with layer size
+// and no layer is re-read to find the next one.
+type cachedImage struct {
+	file       *os.File
+	entries    map[string]archiveSpan
+	descriptor tarball.Descriptor
+	config     []byte
+
+	manifestOnce sync.Once
+	manifest     *v1.Manifest
+	manifestErr  error
+}
+
+// openCachedImage opens an image archive written by crane.Save. Archives with uncompressed
+// layers, as docker save writes, fall back to the tarball reader. Close the returned closer once
+// the image has been pushed.
+func openCachedImage(tarPath string) (v1.Image, io.Closer, error) {
+	file, err := os.Open(LongPath(tarPath))
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := indexCachedImage(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	compressed, err := img.layersCompressed()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !compressed {
+		file.Close()
+		LogDebug("%s has uncompressed layers; reading it with the tarball reader", tarPath)
+		fallback, err := tarball.ImageFromPath(tarPath, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fallback, io.NopCloser(nil), nil
+	}
+	image, err := partial.CompressedToImage(img)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return image, file, nil
+}
+
+// indexCachedImage records where each file in the archive starts and reads the image metadata
+func indexCachedImage(file *os.File) (*cachedImage, error) {
+	img := &cachedImage{file: file, entries: map[string]archiveSpan{}}
+	links := map[string]string{}
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to index image archive: %w", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			// Next leaves the file positioned at the start of this entry's data
+			offset, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			img.entries[hdr.Name] = archiveSpan{offset: offset, size: hdr.Size}
+		case tar.TypeSymlink, tar.TypeLink:
+			// docker save links layers shared between images
+			links[hdr.Name] = path.Join(path.Dir(hdr.Name), path.Clean(hdr.Linkname))
+		}
+	}
+	for name, target := range links {
+		if entry, ok := img.entries[target]; ok {
+			img.entries[name] = entry
+		}
+	}
+
+	data, err := img.readSmall("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	var manifest tarball.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest.json in image archive: %w", err)
+	}
+	if len(manifest) != 1 {
+		return nil, fmt.Errorf("image archive must contain exactly one image, found %d", len(manifest))
+	}
+	img.descriptor = manifest[0]
+	if img.config, err = img.readSmall(img.descriptor.Config); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// open returns a reader over one file in the archive; it does not need closing on its own
+func (i *cachedImage) open(name string) (*io.SectionReader, error) {
+	entry, ok := i.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in image archive", name)
+	}
+	return io.NewSectionReader(i.file, entry.offset, entry.size), nil
+}
+
+// readSmall reads a metadata file, refusing any larger than maxCachedImageMetadata
+func (i *cachedImage) readSmall(name string) ([]byte, error) {
+	r, err := i.open(name)
+	if err != nil {
+		return nil, err
+	}
+	if r.Size() > maxCachedImageMetadata {
+		return nil, fmt.Errorf("%s in image archive is %d bytes, larger than the %d byte limit", name, r.Size(), maxCachedImageMetadata)
+	}
+	return io.ReadAll(r)
+}
+
+// layersCompressed peeks at the gzip magic of the first layer
+func (i *cachedImage) layersCompressed() (bool, error) {
+	if len(i.descriptor.Layers) == 0 {
+		return false, errors.New("image archive has no layers")
+	}
+	r, err := i.open(i.descriptor.Layers[0])
+	if err != nil {
+		return false, err
+	}
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return false, nil
+	}
+	return magic[0] == 0x1f && magic[1] == 0x8b, nil
+}
+
+// RawConfigFile implements partial.CompressedImageCore
+func (i *cachedImage) RawConfigFile() ([]byte, error) {
+	return i.config, nil
+}
+
+// MediaType implements partial.CompressedImageCore
+func (i *cachedImage) MediaType() (types.MediaType, error) {
+	return types.DockerManifestSchema2, nil
+}
+
+// RawManifest implements partial.CompressedImageCore. The manifest is built the way the tarball
+// reader builds it, so the pushed digest does not depend on which reader was used.
+func (i *cachedImage) RawManifest() ([]byte, error) {
+	m, err := i.buildManifest()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func (i *cachedImage) buildManifest() (*v1.Manifest, error) {
+	i.manifestOnce.Do(func() {
+		cfgHash, cfgSize, err := v1.SHA256(bytes.NewReader(i.config))
+		if err != nil {
+			i.manifestErr = err
+			return
+		}
+		cfg, err := v1.ParseConfigFile(bytes.NewReader(i.config))
+		if err != nil {
+			i.manifestErr = fmt.Errorf("failed to parse image config: %w", err)
+			return
+		}
+		if len(cfg.RootFS.DiffIDs) != len(i.descriptor.Layers) {
+			i.manifestErr = fmt.Errorf("image config lists %d layers but the archive has %d", len(cfg.RootFS.DiffIDs), len(i.descriptor.Layers))
+			return
+		}
+		m := &v1.Manifest{
+			SchemaVersion: 2,
+			MediaType:     types.DockerManifestSchema2,
+			Config:        v1.Descriptor{MediaType: types.DockerConfigJSON, Size: cfgSize, Digest: cfgHash},
+		}
+		for idx, name := range i.descriptor.Layers {
+			if foreign, ok := i.descriptor.LayerSources[cfg.RootFS.DiffIDs[idx]]; ok {
+				m.Layers = append(m.Layers, foreign)
+				continue
+			}
+			r, err := i.open(name)
+			if err != nil {
+				i.manifestErr = err
+				return
+			}
+			// Hashing streams through a fixed buffer however large the layer is
+			sha, size, err := v1.SHA256(r)
+			if err != nil {
+				i.manifestErr = fmt.Errorf("failed to hash layer %s: %w", name, err)
+				return
+			}
+			m.Layers = append(m.Layers, v1.Descriptor{MediaType: types.DockerLayer, Size: size, Digest: sha})
+		}
+		i.manifest = m
+	})
+	return i.manifest, i.manifestErr
+}
+
+// LayerByDigest implements partial.CompressedImageCore
+func (i *cachedImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	m, err := i.buildManifest()
+	if err != nil {
+		return nil, err
+	}
+	for idx, desc := range m.Layers {
+		if desc.Digest == h {
+			return &cachedLayer{image: i, name: i.descriptor.Layers[idx], desc: desc}, nil
+		}
+	}
+	return nil, fmt.Errorf("blob %v not found in image archive", h)
+}
+
+// cachedLayer streams one compressed layer from the archive
+type cachedLayer struct {
+	image *cachedImage
+	name  string
+	desc  v1.Descriptor
+}
+
+// Digest implements partial.CompressedLayer
+func (l *cachedLayer) Digest() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+// Size implements partial.CompressedLayer
+func (l *cachedLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+// MediaType implements partial.CompressedLayer
+func (l *cachedLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
+
+// Compressed implements partial.CompressedLayer. Each call gets its own reader, so concurrent
+// uploads and retries of the same layer do not interfere.
+func (l *cachedLayer) Compressed() (io.ReadCloser, error) {
+	r, err := l.image.open(l.name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(r), nil
+}
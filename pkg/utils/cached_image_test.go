@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"io"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestOpenCachedImageMatchesTarballReader(t *testing.T) {
+	img, err := random.Image(1024, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarPath := filepath.Join(t.TempDir(), "api.tar")
+	if err := crane.Save(img, "artifacts.dynamo.ai/dynamoai/api:1", tarPath); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, closer, err := openCachedImage(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	reference, err := tarball.ImageFromPath(tarPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cached.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := reference.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected digest %s, got %s", want, got)
+	}
+
+	layers, err := cached.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 3 {
+		t.Fatalf("expected 3 layers, got %d", len(layers))
+	}
+	for _, layer := range layers {
+		rc, err := layer.Compressed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest, _, err := v1.SHA256(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := layer.Digest(); digest != want {
+			t.Fatalf("expected layer %s, read %s", want, digest)
+		}
+	}
+}
+
+func TestOpenCachedImageMemoryDoesNotGrowWithLayerSize(t *testing.T) {
+	const layerSize = 32 << 20
+	layer, err := random.Layer(layerSize, types.DockerLayer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarPath := filepath.Join(t.TempDir(), "runtime.tar")
+	if err := crane.Save(img, "artifacts.dynamo.ai/dynamoai/runtime:1", tarPath); err != nil {
+		t.Fatal(err)
+	}
+	layer, img = nil, nil
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	cached, closer, err := openCachedImage(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	if _, err := cached.Digest(); err != nil {
+		t.Fatal(err)
+	}
+	layers, err := cached.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range layers {
+		rc, err := l.Compressed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, rc); err != nil {
+			t.Fatal(err)
+		}
+		rc.Close()
+	}
+
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > layerSize/8 {
+		t.Fatalf("expected reading a %d byte layer to allocate a bounded amount, allocated %d bytes", layerSize, allocated)
+	}
+}
@@ -0,0 +1,376 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// nodePoolLabels are checked in order to find the node pool a node belongs to
+var nodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"karpenter.sh/nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"cloud.google.com/gke-nodepool",
+	"node-pool",
+}
+
+// ModelProfile describes the resources a proposed model deployment needs per replica
+type ModelProfile struct {
+	Name     string `json:"name"`
+	Replicas int    `json:"replicas"`
+	CPU      string `json:"cpu"`
+	Memory   string `json:"memory"`
+	GPU      int64  `json:"gpu"`
+	// GPUType restricts GPU replicas to nodes whose nvidia.com/gpu.product label contains it, e.g. A100
+	GPUType      string            `json:"gpu_type,omitempty"`
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations lists taint keys the model tolerates
+	Tolerations []string `json:"tolerations,omitempty"`
+}
+
+// NodeCapacity is the free and allocatable capacity of a schedulable node
+type NodeCapacity struct {
+	Name         string
+	Pool         string
+	InstanceType string
+	Labels       map[string]string `json:"-"`
+	TaintKeys    []string          `json:"-"`
+	// DiskBytes is the node's ephemeral storage capacity, roughly the size of its root disk
+	DiskBytes      int64 `json:"-"`
+	CPUAllocatable float64
+	MemAllocatable float64
+	GPUAllocatable int64
+	CPUFree        float64
+	MemFree        float64
+	GPUFree        int64
+}
+
+// PoolPlacement records how many replicas landed on a node pool
+type PoolPlacement struct {
+	Pool     string
+	Replicas int
+	Nodes    []string
+}
+
+// PlacementPlan is the result of evaluating a model profile against the cluster
+type PlacementPlan struct {
+	Model      string
+	Replicas   int
+	Placed     int
+	Fits       bool
+	Placements []PoolPlacement
+	Unplaced   int
+	// Additional capacity and the suggested pool cover the replicas that fit neither on current
+	// nodes nor on autoscaler headroom
+	AdditionalCPU    float64
+	AdditionalMemGB  float64
+	AdditionalGPU    int64
+	SuggestedPool    string
+	SuggestedNodes   int
+	EligibleNodes    int
+	IneligibleReason string `json:",omitempty"`
+	// Autoscaling is the node autoscaler configuration, nil when it could not be read
+	Autoscaling *AutoscalingInfo `json:",omitempty"`
+	// ScaleUp places replicas that do not fit on current nodes onto autoscaler headroom
+	ScaleUp              []ScaleUpPlacement `json:",omitempty"`
+	UnplacedAfterScaleUp int
+	FitsAfterScaleUp     bool
+}
+
+// LoadModelProfile reads a model resource profile from a YAML or JSON file
+func LoadModelProfile(path string) (*ModelProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model profile: %w", err)
+	}
+
+	var profile ModelProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse model profile %s: %w", path, err)
+	}
+	if profile.Replicas <= 0 {
+		profile.Replicas = 1
+	}
+	if profile.Name == "" {
+		profile.Name = "proposed-model"
+	}
+	return &profile, nil
+}
+
+// ListNodeCapacities returns free and allocatable capacity for every ready node
+func (kc *KubernetesChecker) ListNodeCapacities(ctx context.Context) ([]NodeCapacity, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	podsByNode, err := kc.listPodsByNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var capacities []NodeCapacity
+	for _, node := range nodes {
+		if !isNodeReady(&node) || node.Spec.Unschedulable {
+			continue
+		}
+		usage := nodeResourceUsage(&node, podsByNode[node.Name])
+
+		var taintKeys []string
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+				taintKeys = append(taintKeys, taint.Key)
+			}
+		}
+
+		capacities = append(capacities, NodeCapacity{
+			Name:           node.Name,
+			Pool:           nodePoolFromLabels(node.Labels),
+			InstanceType:   instanceTypeFromLabels(node.Labels),
+			Labels:         node.Labels,
+			TaintKeys:      taintKeys,
+			DiskBytes:      node.Status.Capacity.StorageEphemeral().Value(),
+			CPUAllocatable: usage.CPUAllocatable,
+			MemAllocatable: usage.MemoryAllocatable,
+			GPUAllocatable: usage.GPUAllocatable,
+			CPUFree:        usage.CPUAllocatable - usage.CPURequests,
+			MemFree:        usage.MemoryAllocatable - usage.MemoryRequests,
+			GPUFree:        usage.GPUAllocatable - usage.GPURequests,
+		})
+	}
+	return capacities, nil
+}
+
+// PlanModelPlacement evaluates whether a proposed model fits on the current cluster, and when it
+// does not, whether cluster-autoscaler or Karpenter can add the missing capacity
+func (kc *KubernetesChecker) PlanModelPlacement(ctx context.Context, profile ModelProfile) (*PlacementPlan, error) {
+	nodes, err := kc.ListNodeCapacities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := PlanPlacement(nodes, profile)
+	if err != nil {
+		return nil, err
+	}
+	autoscaling, err := kc.DetectAutoscaling(ctx, nodes)
+	if err != nil {
+		LogWarning("Failed to detect node autoscalers, judging current nodes only: %v", err)
+		plan.UnplacedAfterScaleUp, plan.FitsAfterScaleUp = plan.Unplaced, plan.Fits
+		return plan, nil
+	}
+	plan.Autoscaling = autoscaling
+	return plan, ApplyScaleUpHeadroom(plan, nodes, profile, autoscaling.Groups)
+}
+
+// PlanPlacement simulates placing each replica of the profile onto the given nodes, preferring
+// the node with the most remaining room, and reports any shortfall.
+func PlanPlacement(nodes []NodeCapacity, profile ModelProfile) (*PlacementPlan, error) {
+	cpu, mem, err := profileQuantities(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &PlacementPlan{Model: profile.Name, Replicas: profile.Replicas}
+
+	var eligible []*NodeCapacity
+	for i := range nodes {
+		if nodeEligible(&nodes[i], profile) {
+			n := nodes[i]
+			eligible = append(eligible, &n)
+		}
+	}
+	plan.EligibleNodes = len(eligible)
+	if len(eligible) == 0 {
+		plan.IneligibleReason = "no ready node matches the node selector, tolerations, and GPU requirement"
+	}
+
+	plan.Placements, plan.Placed = placeReplicas(eligible, profile.Replicas, cpu, mem, profile.GPU)
+	plan.Unplaced = profile.Replicas - plan.Placed
+
+	plan.Fits = plan.Unplaced == 0
+	if !plan.Fits {
+		plan.AdditionalCPU = cpu * float64(plan.Unplaced)
+		plan.AdditionalMemGB = mem * float64(plan.Unplaced)
+		plan.AdditionalGPU = profile.GPU * int64(plan.Unplaced)
+		plan.SuggestedPool, plan.SuggestedNodes = suggestNodePool(nodes, profile, cpu, mem, plan.Unplaced)
+	}
+
+	return plan, nil
+}
+
+// placeReplicas places replicas one at a time on the eligible node with the most remaining room,
+// consuming its free capacity, and returns the placements by pool and how many were placed
+func placeReplicas(eligible []*NodeCapacity, replicas int, cpu, mem float64, gpu int64) ([]PoolPlacement, int) {
+	placed := 0
+	placements := map[string]*PoolPlacement{}
+	for r := 0; r < replicas; r++ {
+		var best *NodeCapacity
+		for _, n := range eligible {
+			if n.CPUFree < cpu || n.MemFree < mem || n.GPUFree < gpu {
+				continue
+			}
+			if best == nil || placementScore(n) > placementScore(best) {
+				best = n
+			}
+		}
+		if best == nil {
+			continue
+		}
+		best.CPUFree -= cpu
+		best.MemFree -= mem
+		best.GPUFree -= gpu
+
+		p, ok := placements[best.Pool]
+		if !ok {
+			p = &PoolPlacement{Pool: best.Pool}
+			placements[best.Pool] = p
+		}
+		p.Replicas++
+		if !slices.Contains(p.Nodes, best.Name) {
+			p.Nodes = append(p.Nodes, best.Name)
+		}
+		placed++
+	}
+
+	var result []PoolPlacement
+	for _, p := range placements {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pool < result[j].Pool
+	})
+	return result, placed
+}
+
+// profileQuantities converts the profile's CPU and memory to cores and GB
+func profileQuantities(profile ModelProfile) (float64, float64, error) {
+	var cpu, mem float64
+	if profile.CPU != "" {
+		q, err := resource.ParseQuantity(profile.CPU)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid cpu %q: %v", profile.CPU, err)
+		}
+		cpu = float64(q.MilliValue()) / 1000.0
+	}
+	if profile.Memory != "" {
+		q, err := resource.ParseQuantity(profile.Memory)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid memory %q: %v", profile.Memory, err)
+		}
+		mem = float64(q.Value()) / (1024.0 * 1024.0 * 1024.0)
+	}
+	return cpu, mem, nil
+}
+
+// nodeEligible reports whether the profile's selector, tolerations, and GPU needs allow the node
+func nodeEligible(n *NodeCapacity, profile ModelProfile) bool {
+	return nodeIneligibility(n, profile) == ""
+}
+
+// nodeIneligibility explains why the profile cannot schedule on the node, in the words the
+// scheduler uses, or returns an empty string when it can
+func nodeIneligibility(n *NodeCapacity, profile ModelProfile) string {
+	for _, k := range sortedKeys(profile.NodeSelector) {
+		if n.Labels[k] != profile.NodeSelector[k] {
+			return "didn't match node selector"
+		}
+	}
+	for _, key := range n.TaintKeys {
+		if !slices.Contains(profile.Tolerations, key) {
+			return fmt.Sprintf("had untolerated taint %s", key)
+		}
+	}
+	if profile.GPU == 0 {
+		return ""
+	}
+	if n.GPUAllocatable == 0 {
+		return "had no GPUs"
+	}
+	if profile.GPUType != "" && !strings.Contains(strings.ToLower(n.Labels[gpuProductLabel]), strings.ToLower(profile.GPUType)) {
+		return fmt.Sprintf("didn't have GPU type %s", profile.GPUType)
+	}
+	return ""
+}
+
+// placementScore prefers nodes with the most free GPUs, then CPU, then memory
+func placementScore(n *NodeCapacity) float64 {
+	return float64(n.GPUFree)*1e6 + n.CPUFree*1e3 + n.MemFree
+}
+
+// suggestNodePool picks the eligible pool whose empty node holds the most replicas and returns
+// how many more of its nodes are needed for the unplaced replicas
+func suggestNodePool(nodes []NodeCapacity, profile ModelProfile, cpu, mem float64, unplaced int) (string, int) {
+	bestPool := ""
+	bestPerNode := 0
+	for i := range nodes {
+		n := nodes[i]
+		if !nodeEligible(&n, profile) {
+			continue
+		}
+		perNode := replicasPerNode(n, profile, cpu, mem)
+		if perNode > bestPerNode || (perNode == bestPerNode && perNode > 0 && n.Pool < bestPool) {
+			bestPool = n.Pool
+			bestPerNode = perNode
+		}
+	}
+	if bestPerNode == 0 {
+		return "", 0
+	}
+	return bestPool, int(math.Ceil(float64(unplaced) / float64(bestPerNode)))
+}
+
+// replicasPerNode returns how many replicas fit on an empty node of the same shape
+func replicasPerNode(n NodeCapacity, profile ModelProfile, cpu, mem float64) int {
+	fit := math.MaxInt32
+	if cpu > 0 {
+		fit = min(fit, int(n.CPUAllocatable/cpu))
+	}
+	if mem > 0 {
+		fit = min(fit, int(n.MemAllocatable/mem))
+	}
+	if profile.GPU > 0 {
+		fit = min(fit, int(n.GPUAllocatable/profile.GPU))
+	}
+	if fit == math.MaxInt32 {
+		return 0
+	}
+	return fit
+}
+
+// nodePoolFromLabels returns the node pool name, falling back to the instance type
+func nodePoolFromLabels(labels map[string]string) string {
+	if pool := nodePoolLabel(labels); pool != "" {
+		return pool
+	}
+	return instanceTypeFromLabels(labels)
+}
+
+// nodePoolLabel returns the node pool name from the well-known pool labels, or an empty string
+func nodePoolLabel(labels map[string]string) string {
+	for _, key := range nodePoolLabels {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// isNodeReady reports whether the node's Ready condition is true
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestPlanPlacement(t *testing.T) {
+	nodes := []NodeCapacity{
+		{Name: "cpu-1", Pool: "general", Labels: map[string]string{}, CPUAllocatable: 8, MemAllocatable: 32, CPUFree: 6, MemFree: 24},
+		{Name: "gpu-1", Pool: "gpu", Labels: map[string]string{"gpu": "true"}, TaintKeys: []string{"nvidia.com/gpu"},
+			CPUAllocatable: 32, MemAllocatable: 128, GPUAllocatable: 4, CPUFree: 20, MemFree: 100, GPUFree: 1},
+	}
+
+	profile := ModelProfile{Name: "guard", Replicas: 3, CPU: "4", Memory: "16Gi", GPU: 1, Tolerations: []string{"nvidia.com/gpu"}}
+	plan, err := PlanPlacement(nodes, profile)
+	if err != nil {
+		t.Fatalf("PlanPlacement returned error: %v", err)
+	}
+	if plan.Fits {
+		t.Fatal("Expected plan not to fit")
+	}
+	if plan.Placed != 1 || plan.Unplaced != 2 {
+		t.Errorf("Expected 1 placed and 2 unplaced, got %d/%d", plan.Placed, plan.Unplaced)
+	}
+	if plan.SuggestedPool != "gpu" || plan.SuggestedNodes != 1 {
+		t.Errorf("Expected suggestion of 1 gpu node, got %d %s", plan.SuggestedNodes, plan.SuggestedPool)
+	}
+	if plan.AdditionalGPU != 2 {
+		t.Errorf("Expected 2 additional GPUs, got %d", plan.AdditionalGPU)
+	}
+
+	// Without the toleration the tainted GPU node is not eligible
+	profile.Tolerations = nil
+	plan, err = PlanPlacement(nodes, profile)
+	if err != nil {
+		t.Fatalf("PlanPlacement returned error: %v", err)
+	}
+	if plan.EligibleNodes != 0 || plan.IneligibleReason == "" {
+		t.Errorf("Expected no eligible nodes, got %d", plan.EligibleNodes)
+	}
+
+	// CPU-only profile fits on the general pool
+	plan, err = PlanPlacement(nodes, ModelProfile{Name: "api", Replicas: 1, CPU: "2", Memory: "4Gi"})
+	if err != nil {
+		t.Fatalf("PlanPlacement returned error: %v", err)
+	}
+	if !plan.Fits || len(plan.Placements) != 1 || plan.Placements[0].Pool != "general" {
+		t.Errorf("Expected api to fit on general pool, got %+v", plan.Placements)
+	}
+}
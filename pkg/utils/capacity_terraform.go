@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Terraform modules whose node group variables a capacity gap can be written for
+const (
+	TerraformModuleEKS = "eks"
+	TerraformModuleAKS = "aks"
+)
+
+// DefaultTerraformDiskSizeGB is the node disk size used when the pool's nodes don't report one
+const DefaultTerraformDiskSizeGB = 100
+
+// CapacityNodeGroup is extra capacity to provision: more nodes shaped like an existing pool's
+type CapacityNodeGroup struct {
+	Name         string
+	Pool         string
+	InstanceType string
+	Count        int
+	DiskSizeGB   int
+	Labels       map[string]string `json:",omitempty"`
+	TaintKeys    []string          `json:",omitempty"`
+	Components   []string
+}
+
+// CapacityGap is the capacity a sizing profile is missing on the cluster, as node groups
+type CapacityGap struct {
+	Profile    string
+	NodeGroups []CapacityNodeGroup `json:",omitempty"`
+	// Unsized lists components no existing pool can hold, whose node type has to be picked by hand
+	Unsized []string `json:",omitempty"`
+}
+
+// PlanCapacityGap turns the components a sizing simulation could not place into node groups.
+// Each component's unplaced replicas go to the eligible pool whose empty node holds the most of
+// them; components sharing a pool add up their nodes, which errs on the side of one node too
+// many. Disk size is diskSizeGB when set, otherwise that of the pool's largest node.
+func PlanCapacityGap(nodes []NodeCapacity, profile SizingProfile, result *SizingFitResult, diskSizeGB int) (*CapacityGap, error) {
+	gap := &CapacityGap{Profile: profile.Name}
+	groups := map[string]*CapacityNodeGroup{}
+	for i, fit := range result.Components {
+		if fit.Fits {
+			continue
+		}
+		c := profile.Components[i]
+		cpu, mem, err := profileQuantities(c)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %v", c.Name, err)
+		}
+		pool, count := suggestNodePool(nodes, c, cpu, mem, fit.Replicas-fit.Placed)
+		if pool == "" {
+			gap.Unsized = append(gap.Unsized, c.Name)
+			continue
+		}
+		g, ok := groups[pool]
+		if !ok {
+			g = &CapacityNodeGroup{Name: "dynamo-" + pool, Pool: pool, DiskSizeGB: diskSizeGB, Labels: map[string]string{}}
+			for _, n := range nodes {
+				if n.Pool != pool {
+					continue
+				}
+				if g.InstanceType == "" || g.InstanceType == "unknown" {
+					g.InstanceType = n.InstanceType
+				}
+				for _, key := range n.TaintKeys {
+					if !containsString(g.TaintKeys, key) {
+						g.TaintKeys = append(g.TaintKeys, key)
+					}
+				}
+				if diskSizeGB == 0 {
+					g.DiskSizeGB = max(g.DiskSizeGB, diskGB(n.DiskBytes))
+				}
+			}
+			if g.DiskSizeGB == 0 {
+				g.DiskSizeGB = DefaultTerraformDiskSizeGB
+			}
+			sort.Strings(g.TaintKeys)
+			groups[pool] = g
+		}
+		g.Count += count
+		g.Components = append(g.Components, c.Name)
+		for k, v := range c.NodeSelector {
+			g.Labels[k] = v
+		}
+	}
+	for _, pool := range sortedKeys(groups) {
+		gap.NodeGroups = append(gap.NodeGroups, *groups[pool])
+	}
+	return gap, nil
+}
+
+// diskGB rounds a disk capacity up to the next 10 GiB, since the filesystem reports a little less
+// than the disk it sits on
+func diskGB(bytes int64) int {
+	if bytes <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(bytes)/(10<<30))) * 10
+}
+
+// TerraformVars lays out a capacity gap as the node group variable of a Terraform module:
+// eks_managed_node_groups of terraform-aws-modules/eks, or node_pools of Azure/aks
+func TerraformVars(gap *CapacityGap, module string) (map[string]any, error) {
+	groups := map[string]any{}
+	switch module {
+	case TerraformModuleEKS:
+		for _, g := range gap.NodeGroups {
+			taints := map[string]any{}
+			for _, key := range g.TaintKeys {
+				taints[key] = map[string]any{"key": key, "effect": "NO_SCHEDULE"}
+			}
+			groups[g.Name] = map[string]any{
+				"instance_types": []any{g.InstanceType},
+				"min_size":       g.Count,
+				"max_size":       g.Count,
+				"desired_size":   g.Count,
+				"disk_size":      g.DiskSizeGB,
+				"labels":         stringMap(g.Labels),
+				"taints":         taints,
+			}
+		}
+		return map[string]any{"eks_managed_node_groups": groups}, nil
+	case TerraformModuleAKS:
+		for _, g := range gap.NodeGroups {
+			taints := []any{}
+			for _, key := range g.TaintKeys {
+				taints = append(taints, key+":NoSchedule")
+			}
+			name := aksPoolName(g.Pool)
+			groups[name] = map[string]any{
+				"name":            name,
+				"vm_size":         g.InstanceType,
+				"node_count":      g.Count,
+				"os_disk_size_gb": g.DiskSizeGB,
+				"node_labels":     stringMap(g.Labels),
+				"node_taints":     taints,
+			}
+		}
+		return map[string]any{"node_pools": groups}, nil
+	default:
+		return nil, fmt.Errorf("unknown Terraform module %q, expected %s or %s", module, TerraformModuleEKS, TerraformModuleAKS)
+	}
+}
+
+// nonAlphanumeric matches what AKS does not allow in a node pool name
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+
+// aksPoolName derives a valid AKS node pool name, at most 12 lowercase alphanumerics starting
+// with a letter, from an existing pool's
+func aksPoolName(pool string) string {
+	name := "dyn" + nonAlphanumeric.ReplaceAllString(strings.ToLower(pool), "")
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	return name
+}
+
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// WriteTerraformVars writes a capacity gap as a Terraform variable file for module, in JSON when
+// path ends in .json (e.g. capacity.auto.tfvars.json) and in HCL otherwise
+func WriteTerraformVars(path, module string, gap *CapacityGap) error {
+	vars, err := TerraformVars(gap, module)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if strings.HasSuffix(path, ".json") {
+		data, err = json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# Capacity missing for sizing profile %s, generated by dynactl cluster fit\n", gap.Profile)
+		for _, k := range sortedKeys(vars) {
+			b.WriteString(k + " = ")
+			writeHCL(&b, vars[k], "")
+			b.WriteString("\n")
+		}
+		data = []byte(b.String())
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeHCL renders the maps, lists, strings and numbers of a variable value as HCL
+func writeHCL(b *strings.Builder, value any, indent string) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteString("{\n")
+		for _, k := range sortedKeys(v) {
+			b.WriteString(indent + "  " + hclKey(k) + " = ")
+			writeHCL(b, v[k], indent+"  ")
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []any:
+		b.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeHCL(b, item, indent)
+		}
+		b.WriteString("]")
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
+
+// hclIdentifier matches keys that need no quotes in HCL
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func hclKey(k string) string {
+	if hclIdentifier.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
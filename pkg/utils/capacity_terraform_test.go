@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanCapacityGap(t *testing.T) {
+	nodes := []NodeCapacity{
+		{Name: "cpu-1", Pool: "general", InstanceType: "m5.2xlarge", Labels: map[string]string{}, DiskBytes: 79 << 30,
+			CPUAllocatable: 8, MemAllocatable: 32, CPUFree: 2, MemFree: 8},
+		{Name: "gpu-1", Pool: "gpu", InstanceType: "p4d.24xlarge", Labels: map[string]string{"gpu": "true"}, TaintKeys: []string{"nvidia.com/gpu"},
+			CPUAllocatable: 32, MemAllocatable: 128, GPUAllocatable: 4, CPUFree: 20, MemFree: 100, GPUFree: 1},
+	}
+	profile := SizingProfile{Name: "medium", Components: []ModelProfile{
+		{Name: "guard", Replicas: 3, CPU: "4", Memory: "16Gi", GPU: 1, Tolerations: []string{"nvidia.com/gpu"}, NodeSelector: map[string]string{"gpu": "true"}},
+		{Name: "api", Replicas: 3, CPU: "2", Memory: "4Gi"},
+		{Name: "a10-model", Replicas: 1, CPU: "4", Memory: "16Gi", GPU: 1, GPUType: "A10"},
+	}}
+	result, err := SimulateSizingFit(nodes, profile)
+	if err != nil {
+		t.Fatalf("SimulateSizingFit returned error: %v", err)
+	}
+
+	gap, err := PlanCapacityGap(nodes, profile, result, 0)
+	if err != nil {
+		t.Fatalf("PlanCapacityGap returned error: %v", err)
+	}
+	if len(gap.NodeGroups) != 2 {
+		t.Fatalf("Expected 2 node groups, got %+v", gap.NodeGroups)
+	}
+	general, gpu := gap.NodeGroups[0], gap.NodeGroups[1]
+	if general.Name != "dynamo-general" || general.InstanceType != "m5.2xlarge" || general.Count != 1 || general.DiskSizeGB != 80 {
+		t.Errorf("Unexpected general group %+v", general)
+	}
+	if gpu.InstanceType != "p4d.24xlarge" || gpu.Count != 1 || gpu.DiskSizeGB != DefaultTerraformDiskSizeGB {
+		t.Errorf("Unexpected gpu group %+v", gpu)
+	}
+	if gpu.Labels["gpu"] != "true" || len(gpu.TaintKeys) != 1 {
+		t.Errorf("Expected gpu group to keep the node selector and taint, got %+v", gpu)
+	}
+	if len(gap.Unsized) != 1 || gap.Unsized[0] != "a10-model" {
+		t.Errorf("Expected a10-model to have no pool, got %v", gap.Unsized)
+	}
+
+	gap, err = PlanCapacityGap(nodes, profile, result, 250)
+	if err != nil {
+		t.Fatalf("PlanCapacityGap returned error: %v", err)
+	}
+	if gap.NodeGroups[0].DiskSizeGB != 250 || gap.NodeGroups[1].DiskSizeGB != 250 {
+		t.Errorf("Expected --disk-size to override the pools' disks, got %+v", gap.NodeGroups)
+	}
+}
+
+func TestWriteTerraformVars(t *testing.T) {
+	gap := &CapacityGap{Profile: "medium", NodeGroups: []CapacityNodeGroup{{
+		Name: "dynamo-gpu-pool", Pool: "gpu-pool", InstanceType: "Standard_NC24ads_A100_v4", Count: 2, DiskSizeGB: 200,
+		Labels: map[string]string{"nvidia.com/gpu.product": "A100"}, TaintKeys: []string{"nvidia.com/gpu"},
+	}}}
+	dir := t.TempDir()
+
+	hcl := filepath.Join(dir, "capacity.tfvars")
+	if err := WriteTerraformVars(hcl, TerraformModuleEKS, gap); err != nil {
+		t.Fatalf("WriteTerraformVars returned error: %v", err)
+	}
+	data, _ := os.ReadFile(hcl)
+	for _, want := range []string{
+		"eks_managed_node_groups = {",
+		`instance_types = ["Standard_NC24ads_A100_v4"]`,
+		"desired_size = 2",
+		"disk_size = 200",
+		`"nvidia.com/gpu.product" = "A100"`,
+		`effect = "NO_SCHEDULE"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected HCL to contain %q, got:\n%s", want, data)
+		}
+	}
+
+	js := filepath.Join(dir, "capacity.auto.tfvars.json")
+	if err := WriteTerraformVars(js, TerraformModuleAKS, gap); err != nil {
+		t.Fatalf("WriteTerraformVars returned error: %v", err)
+	}
+	data, _ = os.ReadFile(js)
+	var vars struct {
+		NodePools map[string]struct {
+			Name       string   `json:"name"`
+			VMSize     string   `json:"vm_size"`
+			NodeCount  int      `json:"node_count"`
+			NodeTaints []string `json:"node_taints"`
+		} `json:"node_pools"`
+	}
+	if err := json.Unmarshal(data, &vars); err != nil {
+		t.Fatalf("Failed to parse %s: %v", js, err)
+	}
+	pool, ok := vars.NodePools["dyngpupool"]
+	if !ok || pool.Name != "dyngpupool" || pool.VMSize != "Standard_NC24ads_A100_v4" || pool.NodeCount != 2 {
+		t.Errorf("Unexpected node_pools %+v", vars.NodePools)
+	}
+	if len(pool.NodeTaints) != 1 || pool.NodeTaints[0] != "nvidia.com/gpu:NoSchedule" {
+		t.Errorf("Unexpected node_taints %v", pool.NodeTaints)
+	}
+
+	if err := WriteTerraformVars(js, "gke", gap); err == nil {
+		t.Error("Expected an unknown module to be rejected")
+	}
+}
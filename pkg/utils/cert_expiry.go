@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Certificate check statuses
+const (
+	CertStatusOK       = "ok"
+	CertStatusExpiring = "expiring"
+	CertStatusExpired  = "expired"
+	CertStatusInvalid  = "invalid"
+	CertStatusMissing  = "missing"
+)
+
+var certificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// CertificateStatus is the expiry state of a TLS Secret or cert-manager Certificate
+type CertificateStatus struct {
+	Source   string
+	Name     string
+	Subject  string
+	DNSNames []string
+	NotAfter *time.Time
+	DaysLeft int
+	Status   string
+	// UsedBy lists the ingresses and routes that serve this certificate
+	UsedBy  []string
+	Message string `json:",omitempty"`
+}
+
+// CheckCertificateExpiry scans TLS Secrets, cert-manager Certificates, and the secrets referenced
+// by Ingresses (and on OpenShift, the certificates of Routes) in a namespace and reports
+// certificates that expire within the given number of days
+func (kc *KubernetesChecker) CheckCertificateExpiry(ctx context.Context, namespace string, days int) ([]CertificateStatus, error) {
+	now := time.Now()
+
+	secrets, err := kc.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TLS secrets in %s: %v", namespace, err)
+	}
+	ingresses, err := kc.clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses in %s: %v", namespace, err)
+	}
+
+	usedBy := map[string][]string{}
+	for _, ing := range ingresses.Items {
+		for _, tls := range ing.Spec.TLS {
+			if tls.SecretName != "" {
+				usedBy[tls.SecretName] = append(usedBy[tls.SecretName], "Ingress/"+ing.Name)
+			}
+		}
+	}
+
+	var results []CertificateStatus
+	// On OpenShift, Routes carry their certificate inline or reference a Secret
+	if kc.Platform() == PlatformOpenShift {
+		routes, _, err := kc.listCustomResources(ctx, routeGVR, namespace, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range routes {
+			if secret, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "externalCertificate", "name"); secret != "" {
+				usedBy[secret] = append(usedBy[secret], "Route/"+route.GetName())
+			}
+			certPEM, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "certificate")
+			if certPEM == "" {
+				// Served with the router's default certificate
+				continue
+			}
+			status := secretCertificateStatus([]byte(certPEM), now, days)
+			status.Source = "Route"
+			status.Name = route.GetName()
+			results = append(results, status)
+		}
+	}
+	seen := map[string]bool{}
+	for _, secret := range secrets.Items {
+		seen[secret.Name] = true
+		status := secretCertificateStatus(secret.Data[corev1.TLSCertKey], now, days)
+		status.Source = "Secret"
+		status.Name = secret.Name
+		status.UsedBy = usedBy[secret.Name]
+		results = append(results, status)
+	}
+
+	// Ingresses and Routes may reference secrets that were never created or are not of type TLS
+	for name, users := range usedBy {
+		if seen[name] {
+			continue
+		}
+		status := CertificateStatus{Source: "Secret", Name: name, UsedBy: users}
+		secret, err := kc.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			status.Status = CertStatusMissing
+			status.Message = "secret referenced by ingress or route not found"
+		} else {
+			status = secretCertificateStatus(secret.Data[corev1.TLSCertKey], now, days)
+			status.Source, status.Name, status.UsedBy = "Secret", name, users
+		}
+		results = append(results, status)
+	}
+
+	certs, installed, err := kc.listCustomResources(ctx, certificateGVR, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	if installed {
+		for _, cert := range certs {
+			results = append(results, certManagerCertificateStatus(cert, now, days))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		ri, rj := certStatusRank(results[i].Status), certStatusRank(results[j].Status)
+		if ri != rj {
+			return ri > rj
+		}
+		if results[i].DaysLeft != results[j].DaysLeft {
+			return results[i].DaysLeft < results[j].DaysLeft
+		}
+		return results[i].Source+results[i].Name < results[j].Source+results[j].Name
+	})
+	return results, nil
+}
+
+// secretCertificateStatus parses the leaf certificate from PEM data and evaluates its expiry
+func secretCertificateStatus(certPEM []byte, now time.Time, days int) CertificateStatus {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return CertificateStatus{Status: CertStatusInvalid, Message: "no PEM certificate in tls.crt"}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return CertificateStatus{Status: CertStatusInvalid, Message: fmt.Sprintf("failed to parse certificate: %v", err)}
+	}
+
+	notAfter := cert.NotAfter
+	status := expiryStatus(notAfter, now, days)
+	status.Subject = cert.Subject.CommonName
+	status.DNSNames = cert.DNSNames
+	return status
+}
+
+// certManagerCertificateStatus reads notAfter and readiness from a cert-manager Certificate
+func certManagerCertificateStatus(cert unstructured.Unstructured, now time.Time, days int) CertificateStatus {
+	dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	commonName, _, _ := unstructured.NestedString(cert.Object, "spec", "commonName")
+	notAfterStr, _, _ := unstructured.NestedString(cert.Object, "status", "notAfter")
+
+	var status CertificateStatus
+	if notAfter, err := time.Parse(time.RFC3339, notAfterStr); err == nil {
+		status = expiryStatus(notAfter, now, days)
+	} else {
+		status = CertificateStatus{Status: CertStatusInvalid, Message: "certificate has not been issued"}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		if cond["status"] != "True" {
+			msg, _ := cond["message"].(string)
+			if status.Status == CertStatusOK {
+				status.Status = CertStatusInvalid
+			}
+			status.Message = strings.TrimSpace("not ready: " + msg)
+		}
+	}
+
+	status.Source = "Certificate"
+	status.Name = cert.GetName()
+	status.Subject = commonName
+	status.DNSNames = dnsNames
+	return status
+}
+
+// expiryStatus classifies a certificate by the number of days until notAfter
+func expiryStatus(notAfter, now time.Time, days int) CertificateStatus {
+	left := int(notAfter.Sub(now).Hours() / 24)
+	status := CertificateStatus{NotAfter: &notAfter, DaysLeft: left, Status: CertStatusOK}
+	switch {
+	case !notAfter.After(now):
+		status.Status = CertStatusExpired
+	case notAfter.Before(now.AddDate(0, 0, days)):
+		status.Status = CertStatusExpiring
+	}
+	return status
+}
+
+func certStatusRank(status string) int {
+	switch status {
+	case CertStatusExpired, CertStatusMissing:
+		return 3
+	case CertStatusInvalid:
+		return 2
+	case CertStatusExpiring:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestSecretCertificateStatus(t *testing.T) {
+	now := time.Now()
+	certPEM := func(notAfter time.Time) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "guard.example.com"},
+			DNSNames:     []string{"guard.example.com"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"valid", certPEM(now.AddDate(0, 0, 90)), CertStatusOK},
+		{"expiring", certPEM(now.AddDate(0, 0, 10)), CertStatusExpiring},
+		{"expired", certPEM(now.Add(-time.Minute)), CertStatusExpired},
+		{"garbage", []byte("not a certificate"), CertStatusInvalid},
+	}
+
+	for _, tt := range tests {
+		status := secretCertificateStatus(tt.data, now, 30)
+		if status.Status != tt.expected {
+			t.Errorf("%s: status = %s, want %s", tt.name, status.Status, tt.expected)
+		}
+	}
+}
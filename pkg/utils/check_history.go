@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyDirName is the directory under ~/.dynactl holding saved check results
+const historyDirName = "history"
+
+// historyDirEnv overrides the location of the check history directory
+const historyDirEnv = "DYNACTL_HISTORY_DIR"
+
+// historyIDFormat names each saved run after its UTC start time
+const historyIDFormat = "20060102T150405Z"
+
+// Saving a run prunes the history to the latest historyMaxRecords runs of the last
+// historyMaxAge, so a `cluster check --daemon` on a short interval doesn't fill the disk
+const (
+	historyMaxRecords = 500
+	historyMaxAge     = 90 * 24 * time.Hour
+)
+
+// Names of the checks recorded by `cluster all check` in addition to the scheduled checks
+const (
+	CheckNamespacePermissions = "namespace-permissions"
+	CheckClusterPermissions   = "cluster-permissions"
+	CheckStorageClasses       = "storage-classes"
+	CheckNodeDisk             = "node-disk"
+	CheckClockSkew            = "clock-skew"
+	CheckOperators            = "operators"
+)
+
+// HistoryRecord is one saved run of the cluster checks. Capacity is present when node
+// resources were gathered.
+type HistoryRecord struct {
+	ID string `json:"-"`
+	CheckReport
+	Capacity *ClusterResourceSummary `json:",omitempty"`
+}
+
+// CheckChange is a difference in one check or capacity figure between two runs
+type CheckChange struct {
+	Name         string
+	BeforeStatus string `json:",omitempty"`
+	AfterStatus  string `json:",omitempty"`
+	Before       string
+	After        string
+}
+
+// HistoryDir returns the directory check results are saved to
+func HistoryDir() (string, error) {
+	if dir := os.Getenv(historyDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyDirName), nil
+}
+
+// SaveCheckHistory writes a run to the history directory and returns its path
+func SaveCheckHistory(record HistoryRecord) (string, error) {
+	dir, err := HistoryDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal check results: %w", err)
+	}
+	path := filepath.Join(dir, record.Time.UTC().Format(historyIDFormat)+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write check history: %w", err)
+	}
+	if err := pruneCheckHistory(dir, record.Time, historyMaxRecords, historyMaxAge); err != nil {
+		LogWarning("Failed to prune check history: %v", err)
+	}
+	return path, nil
+}
+
+// pruneCheckHistory removes saved runs older than maxAge before now and all but the latest
+// maxRecords. Run IDs sort in time order, so files are compared by name.
+func pruneCheckHistory(dir string, now time.Time, maxRecords int, maxAge time.Duration) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-maxAge).UTC().Format(historyIDFormat)
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for i, id := range ids {
+		if len(ids)-i <= maxRecords && id >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, id+".json")); err != nil {
+			return err
+		}
+		LogDebug("Pruned check history %s", id)
+	}
+	return nil
+}
+
+// ListCheckHistory returns every saved run, oldest first. A missing directory yields no runs.
+func ListCheckHistory() ([]HistoryRecord, error) {
+	dir, err := HistoryDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var records []HistoryRecord
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.Name(), err)
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			LogWarning("Skipping unreadable history file %s: %v", e.Name(), err)
+			continue
+		}
+		record.ID = id
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// FindCheckHistory returns the saved run matching a timestamp. The timestamp may be a run ID as
+// shown by `cluster history`, a prefix of one (e.g. 20260301 for the latest run that day), or an
+// RFC 3339 time.
+func FindCheckHistory(records []HistoryRecord, timestamp string) (*HistoryRecord, error) {
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		timestamp = t.UTC().Format(historyIDFormat)
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if strings.HasPrefix(records[i].ID, timestamp) {
+			return &records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no saved check results match %q (see dynactl cluster history)", timestamp)
+}
+
+// CompareCheckHistory lists the checks and capacity figures that differ between two runs. Checks
+// that only ran in one of them are left out, since runs of different commands cover different
+// checks.
+func CompareCheckHistory(before, after HistoryRecord) []CheckChange {
+	var changes []CheckChange
+
+	afterResults := map[string]CheckResult{}
+	for _, r := range after.Results {
+		afterResults[r.Name] = r
+	}
+	for _, b := range before.Results {
+		a, ok := afterResults[b.Name]
+		if !ok || (b.Status == a.Status && b.Message == a.Message) {
+			continue
+		}
+		changes = append(changes, CheckChange{Name: b.Name, BeforeStatus: b.Status, AfterStatus: a.Status, Before: b.Message, After: a.Message})
+	}
+
+	if before.Capacity != nil && after.Capacity != nil {
+		b, a := before.Capacity, after.Capacity
+		figure := func(name, before, after string) {
+			if before != after {
+				changes = append(changes, CheckChange{Name: name, Before: before, After: after})
+			}
+		}
+		figure("nodes", fmt.Sprintf("%d/%d ready", b.ReadyNodes, b.TotalNodes), fmt.Sprintf("%d/%d ready", a.ReadyNodes, a.TotalNodes))
+		figure("cpu", fmt.Sprintf("%.1f cores (%.1f%% requested)", b.CPUAllocatable, b.CPURequestsPercent), fmt.Sprintf("%.1f cores (%.1f%% requested)", a.CPUAllocatable, a.CPURequestsPercent))
+		figure("memory", fmt.Sprintf("%.1f GB (%.1f%% requested)", b.MemoryAllocatable, b.MemoryRequestsPercent), fmt.Sprintf("%.1f GB (%.1f%% requested)", a.MemoryAllocatable, a.MemoryRequestsPercent))
+		figure("gpu", fmt.Sprintf("%d/%d requested", b.GPURequests, b.GPUAllocatable), fmt.Sprintf("%d/%d requested", a.GPURequests, a.GPUAllocatable))
+	}
+	return changes
+}
+
+// RunSurvey runs the scheduled checks plus the permission checks and gathers node capacity, giving
+// a run that can be saved and compared with earlier ones
+func (kc *KubernetesChecker) RunSurvey(ctx context.Context, namespace string) HistoryRecord {
+	results := kc.RunPeriodicChecks(ctx, namespace, AllPeriodicChecks, DefaultPeriodicCheckOptions)
+	add := func(name, message string, err error) {
+		status := CheckPass
+		if err != nil {
+			status = CheckFail
+			if message == "" {
+				message = err.Error()
+			}
+		}
+		results = append(results, CheckResult{Name: name, Status: status, Message: message})
+	}
+	if namespace != "" {
+		msg, err := kc.CheckNamespaceRBAC(ctx, namespace, AccessSubject{})
+		add(CheckNamespacePermissions, msg, err)
+	}
+	msg, err := kc.CheckClusterRBAC(ctx, AccessSubject{})
+	add(CheckClusterPermissions, msg, err)
+
+	record := HistoryRecord{CheckReport: kc.NewCheckReport(results)}
+	if _, summary, err := kc.GatherNodeResources(ctx, ""); err != nil {
+		LogWarning("Node capacity not recorded: %v", err)
+	} else {
+		record.Capacity = &summary
+	}
+	return record
+}
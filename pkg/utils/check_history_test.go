@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckHistory(t *testing.T) {
+	t.Setenv(historyDirEnv, t.TempDir())
+
+	records, err := ListCheckHistory()
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected empty history, got %v (%v)", records, err)
+	}
+
+	first := HistoryRecord{
+		CheckReport: CheckReport{
+			Cluster: "https://cluster.example",
+			Time:    time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+			Results: []CheckResult{
+				{Name: PeriodicCheckVersion, Status: CheckPass, Message: "v1.29.4"},
+				{Name: CheckClusterPermissions, Status: CheckFail, Message: "missing list on nodes"},
+			},
+		},
+		Capacity: &ClusterResourceSummary{TotalNodes: 3, ReadyNodes: 3, CPUAllocatable: 12},
+	}
+	second := HistoryRecord{
+		CheckReport: CheckReport{
+			Cluster: "https://cluster.example",
+			Time:    time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC),
+			Results: []CheckResult{
+				{Name: PeriodicCheckVersion, Status: CheckPass, Message: "v1.30.1"},
+				{Name: CheckClusterPermissions, Status: CheckFail, Message: "missing list on nodes"},
+				{Name: PeriodicCheckStorage, Status: CheckPass, Message: "20% used"},
+			},
+		},
+		Capacity: &ClusterResourceSummary{TotalNodes: 4, ReadyNodes: 4, CPUAllocatable: 12},
+	}
+	for _, r := range []HistoryRecord{second, first} {
+		if _, err := SaveCheckHistory(r); err != nil {
+			t.Fatalf("SaveCheckHistory failed: %v", err)
+		}
+	}
+
+	records, err = ListCheckHistory()
+	if err != nil {
+		t.Fatalf("ListCheckHistory failed: %v", err)
+	}
+	if len(records) != 2 || records[0].ID != "20260301T090000Z" || records[1].ID != "20260315T090000Z" {
+		t.Fatalf("unexpected history %+v", records)
+	}
+
+	for _, ts := range []string{"20260301T090000Z", "20260301", "2026-03-01T09:00:00Z"} {
+		found, err := FindCheckHistory(records, ts)
+		if err != nil || found.ID != "20260301T090000Z" {
+			t.Errorf("FindCheckHistory(%q) = %v, %v", ts, found, err)
+		}
+	}
+	if found, _ := FindCheckHistory(records, "2026"); found == nil || found.ID != "20260315T090000Z" {
+		t.Errorf("a prefix should match the latest run, got %v", found)
+	}
+	if _, err := FindCheckHistory(records, "2025"); err == nil {
+		t.Error("expected an error for an unknown timestamp")
+	}
+
+	changes := CompareCheckHistory(records[0], records[1])
+	if len(changes) != 2 {
+		t.Fatalf("expected version and node changes, got %+v", changes)
+	}
+	if changes[0].Name != PeriodicCheckVersion || changes[0].Before != "v1.29.4" || changes[0].After != "v1.30.1" {
+		t.Errorf("unexpected version change %+v", changes[0])
+	}
+	if changes[1].Name != "nodes" || changes[1].Before != "3/3 ready" || changes[1].After != "4/4 ready" {
+		t.Errorf("unexpected node change %+v", changes[1])
+	}
+}
+
+func TestPruneCheckHistory(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	ids := []string{"20260601T000000Z", "20261001T000000Z", "20261010T000000Z", "20261016T000000Z", "20261017T120000Z"}
+	for _, id := range ids {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneCheckHistory(dir, now, 3, 30*24*time.Hour); err != nil {
+		t.Fatalf("pruneCheckHistory failed: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var kept []string
+	for _, e := range entries {
+		kept = append(kept, e.Name())
+	}
+	want := []string{"20261010T000000Z.json", "20261016T000000Z.json", "20261017T120000Z.json", "notes.txt"}
+	if strings.Join(kept, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v to be kept, got %v", want, kept)
+	}
+
+	// The age limit applies even below the record limit
+	if err := pruneCheckHistory(dir, now, 10, 3*24*time.Hour); err != nil {
+		t.Fatalf("pruneCheckHistory failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "20261010T000000Z.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the run from a week ago to be pruned, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CheckProfile is a named set of checks and thresholds for a deployment size. Advisory checks are
+// still run, but their failures are reported as warnings, so a proof of concept on a small cluster
+// does not fail preflight for requirements that only matter in production.
+type CheckProfile struct {
+	Name        string
+	Description string
+	Checks      []string
+	Advisory    []string
+	Options     PeriodicCheckOptions
+}
+
+// Check profiles selectable with `cluster check --profile`
+const (
+	CheckProfilePOC        = "poc"
+	CheckProfileStandard   = "standard"
+	CheckProfileEnterprise = "enterprise"
+)
+
+// CheckProfiles are the built-in profiles, from least to most strict
+var CheckProfiles = []CheckProfile{
+	{
+		Name:        CheckProfilePOC,
+		Description: "proof of concept on a small or shared cluster",
+		Checks:      []string{PeriodicCheckVersion, PeriodicCheckNodes, PeriodicCheckStorage, PeriodicCheckCerts, PeriodicCheckLicense, PeriodicCheckClock, PeriodicCheckOperators},
+		Advisory:    []string{PeriodicCheckStorage, PeriodicCheckCerts, PeriodicCheckLicense, PeriodicCheckClock, PeriodicCheckOperators},
+		Options: PeriodicCheckOptions{
+			Storage:        StorageThresholds{Warn: 90, Fail: 98},
+			CertExpiryDays: 7,
+			MaxClockSkew:   30 * time.Second,
+			MinImageFsFree: 10 << 30,
+			LicenseWarning: DefaultLicenseExpiryWarning,
+		},
+	},
+	{
+		Name:        CheckProfileStandard,
+		Description: "single production deployment",
+		Checks:      []string{PeriodicCheckVersion, PeriodicCheckNodes, PeriodicCheckStorage, PeriodicCheckCerts, PeriodicCheckLicense, PeriodicCheckClock, PeriodicCheckDisk, PeriodicCheckOperators},
+		Advisory:    []string{PeriodicCheckDisk},
+		Options:     DefaultPeriodicCheckOptions,
+	},
+	{
+		Name:        CheckProfileEnterprise,
+		Description: "highly available production deployment",
+		Checks:      AvailablePeriodicChecks,
+		Options: PeriodicCheckOptions{
+			Storage:        StorageThresholds{Warn: 75, Fail: 90},
+			CertExpiryDays: 30,
+			MaxClockSkew:   5 * time.Second,
+			MinImageFsFree: 50 << 30,
+			LicenseWarning: DefaultLicenseExpiryWarning,
+		},
+	},
+}
+
+// CheckProfileNames lists the built-in profile names
+func CheckProfileNames() []string {
+	names := make([]string, 0, len(CheckProfiles))
+	for _, p := range CheckProfiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LookupCheckProfile returns the built-in profile with the given name
+func LookupCheckProfile(name string) (*CheckProfile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range CheckProfiles {
+		if CheckProfiles[i].Name == name {
+			p := CheckProfiles[i]
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown check profile %q (valid: %s)", name, strings.Join(CheckProfileNames(), ", "))
+}
+
+// ApplyCheckProfile downgrades failures of the profile's advisory checks to warnings
+func ApplyCheckProfile(results []CheckResult, profile *CheckProfile) []CheckResult {
+	for i := range results {
+		r := &results[i]
+		if r.Status == CheckFail && slices.Contains(profile.Advisory, r.Name) {
+			r.Status = CheckWarn
+			r.Message = fmt.Sprintf("%s (advisory in the %s profile)", r.Message, profile.Name)
+		}
+	}
+	return results
+}
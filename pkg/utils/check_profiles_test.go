@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckProfilesAreValid(t *testing.T) {
+	for _, p := range CheckProfiles {
+		if _, err := ParsePeriodicChecks(p.Checks); err != nil {
+			t.Errorf("profile %s: %v", p.Name, err)
+		}
+		for _, name := range p.Advisory {
+			if !containsString(p.Checks, name) {
+				t.Errorf("profile %s: advisory check %s is not run", p.Name, name)
+			}
+		}
+		if err := p.Options.Storage.Validate(); err != nil {
+			t.Errorf("profile %s: %v", p.Name, err)
+		}
+	}
+
+	enterprise, err := LookupCheckProfile(" Enterprise ")
+	if err != nil || !containsString(enterprise.Checks, PeriodicCheckHA) || len(enterprise.Advisory) != 0 {
+		t.Errorf("Expected enterprise to make the ha check mandatory, got %+v, %v", enterprise, err)
+	}
+	poc, _ := LookupCheckProfile(CheckProfilePOC)
+	if containsString(poc.Checks, PeriodicCheckHA) {
+		t.Error("Expected the poc profile to skip the ha check")
+	}
+	if _, err := LookupCheckProfile("huge"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestApplyCheckProfile(t *testing.T) {
+	poc, _ := LookupCheckProfile(CheckProfilePOC)
+	results := ApplyCheckProfile([]CheckResult{
+		{Name: PeriodicCheckNodes, Status: CheckFail, Message: "1 of 1 nodes NotReady: node-1"},
+		{Name: PeriodicCheckStorage, Status: CheckFail, Message: "1 PVCs above 98% used"},
+		{Name: PeriodicCheckCerts, Status: CheckPass, Message: "2 certificates valid"},
+	}, poc)
+
+	want := []CheckResult{
+		{Name: PeriodicCheckNodes, Status: CheckFail, Message: "1 of 1 nodes NotReady: node-1"},
+		{Name: PeriodicCheckStorage, Status: CheckWarn, Message: "1 PVCs above 98% used (advisory in the poc profile)"},
+		{Name: PeriodicCheckCerts, Status: CheckPass, Message: "2 certificates valid"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("ApplyCheckProfile() = %+v, want %+v", results, want)
+	}
+}
@@ -0,0 +1,682 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Where the cluster's address ranges were read from, most authoritative first
+const (
+	CIDRSourceServiceCIDR = "ServiceCIDR"
+	CIDRSourceOpenShift   = "network.config.openshift.io"
+	CIDRSourceKubeadm     = "kubeadm-config"
+	CIDRSourceKubeProxy   = "kube-proxy"
+	CIDRSourceAPIServer   = "API server"
+)
+
+// cidrHeadroomWarnPercent is the share of a range's addresses below which the check warns
+const cidrHeadroomWarnPercent = 20
+
+const (
+	// eksENIConfigAnnotation names a node's ENIConfig under VPC CNI custom networking, unless
+	// ENI_CONFIG_LABEL_DEF names a label to read it from instead
+	eksENIConfigAnnotation = "k8s.amazonaws.com/eniConfig"
+	eksCustomNetworkingEnv = "AWS_VPC_K8S_CNI_CUSTOM_NETWORK_CFG"
+	eksENIConfigLabelEnv   = "ENI_CONFIG_LABEL_DEF"
+)
+
+var (
+	eniConfigGVR     = schema.GroupVersionResource{Group: "crd.k8s.amazonaws.com", Version: "v1alpha1", Resource: "eniconfigs"}
+	networkConfigGVR = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "networks"}
+)
+
+// serviceCIDRProbeIP is outside any sane service range, so a Service asking for it is rejected
+// with the range the API server allocates from
+const serviceCIDRProbeIP = "1.1.1.1"
+
+var serviceRangeError = regexp.MustCompile(`range of valid IPs is ([0-9a-fA-F:.,/]+[0-9a-fA-F])`)
+
+// IPRange is a service or pod address range of the cluster
+type IPRange struct {
+	CIDR   string `json:"cidr"`
+	Family string `json:"family"`
+	Source string `json:"source"`
+	// NodeMask is the prefix length of the range each node gets out of a pod range, when known
+	NodeMask int `json:"nodeMask,omitempty"`
+}
+
+// CIDRCapacityReport is the outcome of the CIDR capacity check
+type CIDRCapacityReport struct {
+	Provider     string
+	IPFamilies   []string
+	DualStack    bool
+	ServiceCIDRs []IPRange
+	PodCIDRs     []IPRange
+	// ENIConfigs maps the ENIConfigs of EKS VPC CNI custom networking to their subnets
+	ENIConfigs map[string]string `json:",omitempty"`
+	Results    []CheckResult
+}
+
+// cidrInventory is what the CIDR capacity checks look at, gathered once
+type cidrInventory struct {
+	nodes        []corev1.Node
+	podsByNode   map[string][]corev1.Pod
+	services     []corev1.Service
+	serviceCIDRs []IPRange
+	podCIDRs     []IPRange
+	// awsNode is the EKS VPC CNI DaemonSet, and eniConfigs its custom networking subnets
+	awsNode    *appsv1.DaemonSet
+	eniConfigs map[string]string
+}
+
+// CheckCIDRCapacity reports the cluster's service and pod ranges, how many addresses are left in
+// them, and whether the cluster is dual-stack. Ranges are read from ServiceCIDR objects, the
+// OpenShift network config, kubeadm-config, or kube-proxy's config, whichever the cluster has; the
+// service range falls back to what the API server reports for a rejected dry-run Service.
+func (kc *KubernetesChecker) CheckCIDRCapacity(ctx context.Context) (*CIDRCapacityReport, error) {
+	inv := &cidrInventory{}
+	var err error
+	if inv.nodes, err = kc.listNodes(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	if inv.podsByNode, err = kc.listPodsByNode(ctx); err != nil {
+		return nil, err
+	}
+	services, err := kc.clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+	inv.services = services.Items
+
+	openshift := kc.Platform() == PlatformOpenShift
+	if openshift {
+		inv.serviceCIDRs, inv.podCIDRs = kc.openShiftNetworkRanges(ctx)
+	}
+	if len(inv.serviceCIDRs) == 0 {
+		if inv.serviceCIDRs, err = kc.listServiceCIDRs(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if len(inv.serviceCIDRs) == 0 || len(inv.podCIDRs) == 0 {
+		serviceSubnet, podSubnet := kc.kubeadmNetworking(ctx)
+		if len(inv.serviceCIDRs) == 0 {
+			inv.serviceCIDRs = parseIPRanges(serviceSubnet, CIDRSourceKubeadm)
+		}
+		if len(inv.podCIDRs) == 0 {
+			inv.podCIDRs = parseIPRanges(podSubnet, CIDRSourceKubeadm)
+		}
+	}
+	if len(inv.podCIDRs) == 0 {
+		inv.podCIDRs = parseIPRanges(kc.kubeProxyClusterCIDR(ctx), CIDRSourceKubeProxy)
+	}
+	if len(inv.serviceCIDRs) == 0 {
+		inv.serviceCIDRs = parseIPRanges(kc.probeServiceCIDR(ctx), CIDRSourceAPIServer)
+	}
+
+	awsNode, err := kc.clientset.AppsV1().DaemonSets(kubeSystemNamespace).Get(ctx, eksVPCCNI, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		inv.awsNode = awsNode
+	case !apierrors.IsNotFound(err):
+		LogDebug("Skipping VPC CNI checks, %s DaemonSet not read: %v", eksVPCCNI, err)
+	}
+	if inv.awsNode != nil && daemonSetEnv(inv.awsNode, eksCustomNetworkingEnv) == "true" {
+		eniConfigs, installed, err := kc.listCustomResources(ctx, eniConfigGVR, "", "")
+		if err != nil {
+			return nil, err
+		}
+		inv.eniConfigs = map[string]string{}
+		if installed {
+			for _, ec := range eniConfigs {
+				subnet, _, _ := unstructured.NestedString(ec.Object, "spec", "subnet")
+				inv.eniConfigs[ec.GetName()] = subnet
+			}
+		}
+	}
+
+	report := evaluateCIDRCapacity(inv)
+	report.Provider = detectCloudProvider(inv.nodes, openshift)
+	return report, nil
+}
+
+// evaluateCIDRCapacity grades the address headroom of the gathered ranges
+func evaluateCIDRCapacity(inv *cidrInventory) *CIDRCapacityReport {
+	report := &CIDRCapacityReport{ServiceCIDRs: inv.serviceCIDRs, PodCIDRs: inv.podCIDRs, ENIConfigs: inv.eniConfigs}
+	for i := range report.PodCIDRs {
+		if report.PodCIDRs[i].NodeMask == 0 {
+			report.PodCIDRs[i].NodeMask = nodeMaskIn(inv.nodes, report.PodCIDRs[i].CIDR)
+		}
+	}
+
+	report.Results = append(report.Results, ipFamilyResult(inv, report))
+	report.Results = append(report.Results, serviceIPResults(inv.services, inv.serviceCIDRs)...)
+	switch {
+	case inv.awsNode != nil && !nodesHavePodCIDRs(inv.nodes):
+		report.Results = append(report.Results, podIPHeadroom(&providerInventory{nodes: inv.nodes, podsByNode: inv.podsByNode}, DefaultMinPodIPHeadroom))
+		if inv.eniConfigs != nil {
+			report.Results = append(report.Results, eniConfigResult(inv))
+		}
+	case nodesHavePodCIDRs(inv.nodes):
+		report.Results = append(report.Results, nodePodIPResult(inv))
+	}
+	for _, r := range report.PodCIDRs {
+		report.Results = append(report.Results, podRangeResult(inv, r))
+	}
+	return report
+}
+
+// ipFamilyResult reports the IP families of the service and pod ranges, and warns when services
+// and pods don't have the same ones: dual-stack Services need pod addresses of both families
+func ipFamilyResult(inv *cidrInventory, report *CIDRCapacityReport) CheckResult {
+	var serviceFamilies, podFamilies []string
+	for _, r := range inv.serviceCIDRs {
+		serviceFamilies = appendUnique(serviceFamilies, r.Family)
+	}
+	for _, r := range inv.podCIDRs {
+		podFamilies = appendUnique(podFamilies, r.Family)
+	}
+	for _, node := range inv.nodes {
+		for _, cidr := range nodePodCIDRs(&node) {
+			if family := cidrFamily(cidr); family != "" {
+				podFamilies = appendUnique(podFamilies, family)
+			}
+		}
+	}
+	if len(podFamilies) == 0 {
+		// Without pod ranges, e.g. under the VPC CNI, pods share the nodes' families
+		for _, node := range inv.nodes {
+			for _, addr := range node.Status.Addresses {
+				if family := ipFamily(net.ParseIP(addr.Address)); addr.Type == corev1.NodeInternalIP && family != "" {
+					podFamilies = appendUnique(podFamilies, family)
+				}
+			}
+		}
+	}
+	sort.Strings(serviceFamilies)
+	sort.Strings(podFamilies)
+	report.IPFamilies = uniqueSorted(append(append([]string{}, serviceFamilies...), podFamilies...))
+	report.DualStack = len(report.IPFamilies) > 1
+
+	result := CheckResult{Name: "ip-family", Status: CheckPass}
+	switch {
+	case len(report.IPFamilies) == 0:
+		result.Status = CheckWarn
+		result.Message = "IP families not detected"
+		return result
+	case report.DualStack:
+		result.Message = "dual-stack (" + strings.Join(report.IPFamilies, ", ") + ")"
+	default:
+		result.Message = report.IPFamilies[0] + " single-stack"
+	}
+	if len(serviceFamilies) > 0 && len(podFamilies) > 0 && strings.Join(serviceFamilies, ",") != strings.Join(podFamilies, ",") {
+		result.Status = CheckWarn
+		result.Message += fmt.Sprintf("; services are %s but pods are %s, so Services of the other family have no endpoints",
+			strings.Join(serviceFamilies, ", "), strings.Join(podFamilies, ", "))
+	}
+	return result
+}
+
+// serviceIPResults compares the ClusterIPs in use with the size of the service ranges, per family
+func serviceIPResults(services []corev1.Service, ranges []IPRange) []CheckResult {
+	if len(ranges) == 0 {
+		return []CheckResult{{Name: "service-ips", Status: CheckWarn,
+			Message: "service CIDR not found in ServiceCIDRs, kubeadm-config, or from the API server; service IP headroom unknown"}}
+	}
+	var results []CheckResult
+	for _, family := range []string{string(corev1.IPv4Protocol), string(corev1.IPv6Protocol)} {
+		var cidrs []string
+		var nets []*net.IPNet
+		var usable int64
+		for _, r := range ranges {
+			_, n, err := net.ParseCIDR(r.CIDR)
+			if err != nil || r.Family != family {
+				continue
+			}
+			cidrs = append(cidrs, r.CIDR)
+			nets = append(nets, n)
+			usable = addCapped(usable, usableAddresses(n))
+		}
+		if len(nets) == 0 {
+			continue
+		}
+		var used int64
+		for _, svc := range services {
+			for _, ip := range serviceClusterIPs(&svc) {
+				if parsed := net.ParseIP(ip); parsed != nil && ipFamily(parsed) == family && inAnyNet(nets, parsed) {
+					used++
+				}
+			}
+		}
+		free := usable - used
+		result := CheckResult{Name: "service-ips", Status: headroomStatus(free, usable),
+			Message: fmt.Sprintf("%d of %s %s service IPs used in %s", used, formatAddresses(usable), family, strings.Join(cidrs, ", "))}
+		if result.Status == CheckFail {
+			result.Message += "; new Services will be rejected"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// nodePodIPResult compares the pods on each ready node with the addresses of its pod range, and
+// warns about nodes that allow more pods than their range holds
+func nodePodIPResult(inv *cidrInventory) CheckResult {
+	var low, oversized []string
+	var free int64
+	for i := range inv.nodes {
+		node := &inv.nodes[i]
+		if !isNodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		cidrs := nodePodCIDRs(node)
+		if len(cidrs) == 0 {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidrs[0])
+		if err != nil {
+			continue
+		}
+		addresses := usableAddresses(n)
+		maxPods := node.Status.Allocatable.Pods().Value()
+		if maxPods > addresses {
+			oversized = append(oversized, fmt.Sprintf("%s (%d pods, %d IPs)", node.Name, maxPods, addresses))
+		}
+		used := int64(0)
+		for _, pod := range inv.podsByNode[node.Name] {
+			if !pod.Spec.HostNetwork {
+				used++
+			}
+		}
+		headroom := max(min(addresses, maxPods)-used, 0)
+		free = addCapped(free, headroom)
+		if headroom < DefaultMinPodIPHeadroom {
+			low = append(low, fmt.Sprintf("%s (%d left)", node.Name, headroom))
+		}
+	}
+	result := CheckResult{Name: "pod-ips", Status: CheckPass, Message: fmt.Sprintf("%s pod IPs free across ready nodes", formatAddresses(free))}
+	var problems []string
+	if len(low) > 0 {
+		problems = append(problems, fmt.Sprintf("%d node(s) with fewer than %d pod IPs left: %s", len(low), DefaultMinPodIPHeadroom, strings.Join(low, ", ")))
+	}
+	if len(oversized) > 0 {
+		problems = append(problems, "node(s) allowing more pods than their pod CIDR holds: "+strings.Join(oversized, ", "))
+	}
+	if len(problems) > 0 {
+		result.Status = CheckWarn
+		result.Message = strings.Join(problems, "; ") + "; new pods there stay ContainerCreating"
+	}
+	return result
+}
+
+// podRangeResult grades a cluster pod range: by the node ranges left in it when nodes get one,
+// since a node without a range never becomes ready, and otherwise by the pod IPs left in it
+func podRangeResult(inv *cidrInventory, r IPRange) CheckResult {
+	result := CheckResult{Name: "pod-cidr"}
+	_, n, err := net.ParseCIDR(r.CIDR)
+	if err != nil {
+		result.Status, result.Message = CheckWarn, fmt.Sprintf("invalid pod CIDR %q", r.CIDR)
+		return result
+	}
+	ones, _ := n.Mask.Size()
+	if r.NodeMask > ones {
+		total := int64(math.MaxInt64)
+		if r.NodeMask-ones < 63 {
+			total = int64(1) << (r.NodeMask - ones)
+		}
+		var used int64
+		for _, node := range inv.nodes {
+			cidrs := nodePodCIDRs(&node)
+			if len(cidrs) == 0 || inAnyCIDR(cidrs, n) {
+				used++
+			}
+		}
+		free := total - used
+		result.Status = headroomStatus(free, total)
+		result.Message = fmt.Sprintf("%d of %s /%d node ranges used in %s: room for %s more nodes",
+			used, formatAddresses(total), r.NodeMask, r.CIDR, formatAddresses(max(free, 0)))
+		if result.Status == CheckFail {
+			result.Message += "; new nodes get no pod range and stay NotReady"
+		}
+		return result
+	}
+
+	usable := usableAddresses(n)
+	var used int64
+	for _, pods := range inv.podsByNode {
+		for _, pod := range pods {
+			if ip := net.ParseIP(pod.Status.PodIP); !pod.Spec.HostNetwork && ip != nil && n.Contains(ip) {
+				used++
+			}
+		}
+	}
+	result.Status = headroomStatus(usable-used, usable)
+	result.Message = fmt.Sprintf("%d of %s pod IPs used in %s", used, formatAddresses(usable), r.CIDR)
+	return result
+}
+
+// eniConfigResult checks that, under VPC CNI custom networking, every node maps to an ENIConfig:
+// its pods get addresses from that ENIConfig's subnet, usually in a secondary VPC CIDR, and a node
+// without one gets none. Free addresses in those subnets can only be read from the AWS API.
+func eniConfigResult(inv *cidrInventory) CheckResult {
+	label := daemonSetEnv(inv.awsNode, eksENIConfigLabelEnv)
+	var missing []string
+	for _, node := range inv.nodes {
+		name := node.Annotations[eksENIConfigAnnotation]
+		if label != "" {
+			name = node.Labels[label]
+		}
+		if _, ok := inv.eniConfigs[name]; !ok {
+			missing = append(missing, node.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return CheckResult{Name: "eni-config", Status: CheckFail,
+			Message: fmt.Sprintf("custom networking is on but %d node(s) have no matching ENIConfig: %s; pods there get no IPs", len(missing), strings.Join(missing, ", "))}
+	}
+	var subnets []string
+	for _, name := range sortedKeys(inv.eniConfigs) {
+		subnet := inv.eniConfigs[name]
+		if subnet == "" {
+			subnet = "no subnet"
+		}
+		subnets = append(subnets, fmt.Sprintf("%s (%s)", name, subnet))
+	}
+	return CheckResult{Name: "eni-config", Status: CheckPass,
+		Message: "pods use the secondary subnets of ENIConfigs " + strings.Join(subnets, ", ") + "; check their free IPs with aws ec2 describe-subnets"}
+}
+
+// listServiceCIDRs lists the ServiceCIDR objects of Kubernetes 1.31 and later, networking/v1 from
+// 1.33 and v1beta1 before. Older clusters serve neither.
+func (kc *KubernetesChecker) listServiceCIDRs(ctx context.Context) ([]IPRange, error) {
+	var cidrs []string
+	list, err := kc.clientset.NetworkingV1().ServiceCIDRs().List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		beta, betaErr := kc.clientset.NetworkingV1beta1().ServiceCIDRs().List(ctx, metav1.ListOptions{})
+		if betaErr == nil {
+			for _, sc := range beta.Items {
+				cidrs = append(cidrs, sc.Spec.CIDRs...)
+			}
+		}
+		err = betaErr
+	} else if err == nil {
+		for _, sc := range list.Items {
+			cidrs = append(cidrs, sc.Spec.CIDRs...)
+		}
+	}
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		LogDebug("ServiceCIDRs not listed: %v", err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ServiceCIDRs: %v", err)
+	}
+	return parseIPRanges(strings.Join(cidrs, ","), CIDRSourceServiceCIDR), nil
+}
+
+// openShiftNetworkRanges reads the service and cluster networks of the OpenShift network config;
+// each cluster network's hostPrefix is the size of the range each node gets
+func (kc *KubernetesChecker) openShiftNetworkRanges(ctx context.Context) ([]IPRange, []IPRange) {
+	network, err := kc.dynamicClient.Resource(networkConfigGVR).Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		LogDebug("OpenShift network config not read: %v", err)
+		return nil, nil
+	}
+	serviceNetwork, _, _ := unstructured.NestedStringSlice(network.Object, "spec", "serviceNetwork")
+	services := parseIPRanges(strings.Join(serviceNetwork, ","), CIDRSourceOpenShift)
+	clusterNetwork, _, _ := unstructured.NestedSlice(network.Object, "spec", "clusterNetwork")
+	var pods []IPRange
+	for _, entry := range clusterNetwork {
+		m, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		cidr, _, _ := unstructured.NestedString(m, "cidr")
+		hostPrefix, _, _ := unstructured.NestedInt64(m, "hostPrefix")
+		for _, r := range parseIPRanges(cidr, CIDRSourceOpenShift) {
+			r.NodeMask = int(hostPrefix)
+			pods = append(pods, r)
+		}
+	}
+	return services, pods
+}
+
+// kubeadmNetworking reads the service and pod subnets kubeadm clusters record in kubeadm-config
+func (kc *KubernetesChecker) kubeadmNetworking(ctx context.Context) (string, string) {
+	var config struct {
+		Networking struct {
+			ServiceSubnet string `json:"serviceSubnet"`
+			PodSubnet     string `json:"podSubnet"`
+		} `json:"networking"`
+	}
+	data := kc.kubeSystemConfig(ctx, "kubeadm-config", "ClusterConfiguration")
+	if data == "" {
+		return "", ""
+	}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		LogDebug("kubeadm-config not parsed: %v", err)
+		return "", ""
+	}
+	return config.Networking.ServiceSubnet, config.Networking.PodSubnet
+}
+
+// kubeProxyClusterCIDR reads the pod range kube-proxy is configured with, when it is
+func (kc *KubernetesChecker) kubeProxyClusterCIDR(ctx context.Context) string {
+	var config struct {
+		ClusterCIDR string `json:"clusterCIDR"`
+	}
+	data := kc.kubeSystemConfig(ctx, "kube-proxy", "config.conf")
+	if data == "" {
+		return ""
+	}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		LogDebug("kube-proxy config not parsed: %v", err)
+		return ""
+	}
+	return config.ClusterCIDR
+}
+
+// kubeSystemConfig returns a key of a kube-system ConfigMap, or empty when it can't be read
+func (kc *KubernetesChecker) kubeSystemConfig(ctx context.Context, name, key string) string {
+	cm, err := kc.clientset.CoreV1().ConfigMaps(kubeSystemNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		LogDebug("ConfigMap %s/%s not read: %v", kubeSystemNamespace, name, err)
+		return ""
+	}
+	return cm.Data[key]
+}
+
+// probeServiceCIDR asks the API server for a Service with an IP outside any usual service range,
+// in dry-run, and reads the range from the rejection
+func (kc *KubernetesChecker) probeServiceCIDR(ctx context.Context) string {
+	probe := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "dynactl-cidr-probe-"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: serviceCIDRProbeIP,
+			Ports:     []corev1.ServicePort{{Port: 443}},
+		},
+	}
+	_, err := kc.clientset.CoreV1().Services(metav1.NamespaceDefault).Create(ctx, probe, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err == nil {
+		LogDebug("Service CIDR probe was accepted, range unknown")
+		return ""
+	}
+	match := serviceRangeError.FindStringSubmatch(err.Error())
+	if match == nil {
+		LogDebug("Service CIDR probe rejected without a range: %v", err)
+		return ""
+	}
+	return match[1]
+}
+
+// parseIPRanges parses a comma-separated list of CIDRs, skipping invalid ones
+func parseIPRanges(list, source string) []IPRange {
+	var ranges []IPRange
+	for _, cidr := range strings.Split(list, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			LogDebug("Skipping invalid CIDR %q from %s", cidr, source)
+			continue
+		}
+		ranges = append(ranges, IPRange{CIDR: n.String(), Family: ipFamily(n.IP), Source: source})
+	}
+	return ranges
+}
+
+// nodeMaskIn returns the prefix length of the node pod ranges inside a cluster pod range
+func nodeMaskIn(nodes []corev1.Node, cidr string) int {
+	_, cluster, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	for _, node := range nodes {
+		for _, c := range nodePodCIDRs(&node) {
+			if _, n, err := net.ParseCIDR(c); err == nil && cluster.Contains(n.IP) {
+				ones, _ := n.Mask.Size()
+				return ones
+			}
+		}
+	}
+	return 0
+}
+
+// nodePodCIDRs returns the pod ranges allocated to a node, primary family first
+func nodePodCIDRs(node *corev1.Node) []string {
+	if len(node.Spec.PodCIDRs) > 0 {
+		return node.Spec.PodCIDRs
+	}
+	if node.Spec.PodCIDR != "" {
+		return []string{node.Spec.PodCIDR}
+	}
+	return nil
+}
+
+func nodesHavePodCIDRs(nodes []corev1.Node) bool {
+	for i := range nodes {
+		if len(nodePodCIDRs(&nodes[i])) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceClusterIPs returns the cluster IPs a Service holds; headless Services hold none
+func serviceClusterIPs(svc *corev1.Service) []string {
+	ips := svc.Spec.ClusterIPs
+	if len(ips) == 0 && svc.Spec.ClusterIP != "" {
+		ips = []string{svc.Spec.ClusterIP}
+	}
+	if len(ips) > 0 && ips[0] == corev1.ClusterIPNone {
+		return nil
+	}
+	return ips
+}
+
+// usableAddresses is the size of a range less its network address and, for IPv4, its broadcast
+// address, capped at MaxInt64 for large IPv6 ranges
+func usableAddresses(n *net.IPNet) int64 {
+	ones, bits := n.Mask.Size()
+	if bits-ones >= 63 {
+		return math.MaxInt64
+	}
+	size := int64(1) << (bits - ones)
+	reserved := int64(1)
+	if bits == net.IPv4len*8 {
+		reserved = 2
+	}
+	return max(size-reserved, 0)
+}
+
+// headroomStatus fails when no addresses are left and warns below cidrHeadroomWarnPercent free
+func headroomStatus(free, total int64) string {
+	switch {
+	case free <= 0:
+		return CheckFail
+	case float64(free)*100 < float64(total)*cidrHeadroomWarnPercent:
+		return CheckWarn
+	default:
+		return CheckPass
+	}
+}
+
+// formatAddresses prints an address count, or "2^63+" once it is capped
+func formatAddresses(n int64) string {
+	if n == math.MaxInt64 {
+		return "2^63+"
+	}
+	return fmt.Sprint(n)
+}
+
+func addCapped(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func cidrFamily(cidr string) string {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ""
+	}
+	return ipFamily(n.IP)
+}
+
+func ipFamily(ip net.IP) string {
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return string(corev1.IPv4Protocol)
+	default:
+		return string(corev1.IPv6Protocol)
+	}
+}
+
+func inAnyNet(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// inAnyCIDR reports whether any of the CIDRs starts inside n
+func inAnyCIDR(cidrs []string, n *net.IPNet) bool {
+	for _, c := range cidrs {
+		if ip, _, err := net.ParseCIDR(c); err == nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// daemonSetEnv returns an environment variable set on a DaemonSet's first container
+func daemonSetEnv(ds *appsv1.DaemonSet, name string) string {
+	if len(ds.Spec.Template.Spec.Containers) == 0 {
+		return ""
+	}
+	for _, env := range ds.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
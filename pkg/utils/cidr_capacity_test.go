@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func cidrNode(name string, maxPods int64, podCIDRs ...string) corev1.Node {
+	node := providerNode(name, maxPods, nil)
+	node.Spec.PodCIDRs = podCIDRs
+	return node
+}
+
+func clusterIPService(ips ...string) corev1.Service {
+	return corev1.Service{Spec: corev1.ServiceSpec{ClusterIPs: ips}}
+}
+
+func TestParseIPRanges(t *testing.T) {
+	ranges := parseIPRanges("10.244.0.0/16, fd00:10:244::/56,bogus", CIDRSourceKubeadm)
+	if len(ranges) != 2 {
+		t.Fatalf("Expected 2 ranges, got %+v", ranges)
+	}
+	if ranges[0].Family != "IPv4" || ranges[1].Family != "IPv6" || ranges[1].Source != CIDRSourceKubeadm {
+		t.Errorf("Unexpected ranges: %+v", ranges)
+	}
+	if match := serviceRangeError.FindStringSubmatch(`spec.clusterIPs: Invalid value: []string{"1.1.1.1"}: failed to allocate IP 1.1.1.1: the provided IP (1.1.1.1) is not in the valid range. The range of valid IPs is 10.96.0.0/12`); match == nil || match[1] != "10.96.0.0/12" {
+		t.Errorf("Expected the service range from the API server error, got %v", match)
+	}
+}
+
+func TestEvaluateCIDRCapacity(t *testing.T) {
+	inv := &cidrInventory{
+		nodes: []corev1.Node{
+			cidrNode("node-1", 110, "10.244.0.0/24", "fd00:10:244::/64"),
+			cidrNode("node-2", 250, "10.244.1.0/25", "fd00:10:244:1::/64"),
+		},
+		podsByNode:   map[string][]corev1.Pod{"node-1": make([]corev1.Pod, 10)},
+		services:     []corev1.Service{clusterIPService("10.96.0.1"), clusterIPService("10.96.0.10", "fd00:10:96::a"), clusterIPService(corev1.ClusterIPNone)},
+		serviceCIDRs: parseIPRanges("10.96.0.0/28,fd00:10:96::/112", CIDRSourceServiceCIDR),
+		podCIDRs:     parseIPRanges("10.244.0.0/22", CIDRSourceKubeadm),
+	}
+	report := evaluateCIDRCapacity(inv)
+	if !report.DualStack || strings.Join(report.IPFamilies, ",") != "IPv4,IPv6" {
+		t.Errorf("Expected dual-stack, got %v", report.IPFamilies)
+	}
+	if report.PodCIDRs[0].NodeMask != 24 {
+		t.Errorf("Expected the node mask from the nodes' pod CIDRs, got %d", report.PodCIDRs[0].NodeMask)
+	}
+
+	byName := map[string][]CheckResult{}
+	for _, r := range report.Results {
+		byName[r.Name] = append(byName[r.Name], r)
+	}
+	// Services are dual-stack but the cluster pod range is IPv4 only; the nodes have both
+	if r := byName["ip-family"][0]; r.Status != CheckPass {
+		t.Errorf("Expected ip-family to pass, got %s: %s", r.Status, r.Message)
+	}
+	services := byName["service-ips"]
+	if len(services) != 2 {
+		t.Fatalf("Expected a service-ips result per family, got %+v", services)
+	}
+	if services[0].Status != CheckPass || !strings.Contains(services[0].Message, "2 of 14 IPv4") {
+		t.Errorf("Unexpected IPv4 service result: %s: %s", services[0].Status, services[0].Message)
+	}
+	if r := byName["pod-ips"][0]; r.Status != CheckWarn || !strings.Contains(r.Message, "node-2 (250 pods, 126 IPs)") {
+		t.Errorf("Expected node-2's /25 to be too small for 250 pods, got %s: %s", r.Status, r.Message)
+	}
+	if r := byName["pod-cidr"][0]; r.Status != CheckPass || !strings.Contains(r.Message, "2 of 4 /24 node ranges") {
+		t.Errorf("Unexpected pod-cidr result: %s: %s", r.Status, r.Message)
+	}
+
+	inv.nodes = append(inv.nodes, cidrNode("node-3", 110, "10.244.2.0/24"), cidrNode("node-4", 110, "10.244.3.0/24"))
+	for _, r := range evaluateCIDRCapacity(inv).Results {
+		if r.Name == "pod-cidr" && r.Status != CheckFail {
+			t.Errorf("Expected an exhausted pod CIDR to fail, got %s: %s", r.Status, r.Message)
+		}
+	}
+}
+
+func TestEvaluateCIDRCapacityVPCCNI(t *testing.T) {
+	node := providerNode("ip-10-0-1-1", 29, nil)
+	node.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.1.1"}}
+	node.Labels = map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}
+	inv := &cidrInventory{
+		nodes:        []corev1.Node{node},
+		serviceCIDRs: parseIPRanges("172.20.0.0/16", CIDRSourceAPIServer),
+		awsNode: &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: eksVPCCNI},
+			Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Env: []corev1.EnvVar{{Name: eksCustomNetworkingEnv, Value: "true"}, {Name: eksENIConfigLabelEnv, Value: "topology.kubernetes.io/zone"}},
+			}}}}},
+		},
+		eniConfigs: map[string]string{"us-east-1b": "subnet-0b"},
+	}
+	report := evaluateCIDRCapacity(inv)
+	if report.DualStack || strings.Join(report.IPFamilies, ",") != "IPv4" {
+		t.Errorf("Expected IPv4 single-stack from the node addresses, got %v", report.IPFamilies)
+	}
+	statuses := map[string]string{}
+	for _, r := range report.Results {
+		statuses[r.Name] = r.Status
+	}
+	if statuses["vpc-cni-ips"] != CheckPass {
+		t.Errorf("Expected vpc-cni-ips to pass, got %v", statuses)
+	}
+	if statuses["eni-config"] != CheckFail {
+		t.Errorf("Expected a node without an ENIConfig for its zone to fail, got %v", statuses)
+	}
+
+	inv.eniConfigs["us-east-1a"] = "subnet-0a"
+	for _, r := range evaluateCIDRCapacity(inv).Results {
+		if r.Name == "eni-config" && (r.Status != CheckPass || !strings.Contains(r.Message, "us-east-1a (subnet-0a)")) {
+			t.Errorf("Unexpected eni-config result: %s: %s", r.Status, r.Message)
+		}
+	}
+}
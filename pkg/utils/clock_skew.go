@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// DefaultMaxClockSkew is how far a node's clock may drift from the API server's before the check
+// fails; token and license validation commonly allows no more
+const DefaultMaxClockSkew = 10 * time.Second
+
+// nodeLeaseNamespace holds the Lease each kubelet renews as its heartbeat
+const nodeLeaseNamespace = "kube-node-lease"
+
+// NodeClockSkew is the estimated clock offset of one node from the API server
+type NodeClockSkew struct {
+	Name string
+	// Lag is how long before the API server's current time the kubelet last renewed its Lease,
+	// by the kubelet's own clock. A kubelet with a correct clock shows a lag between zero and
+	// the renew interval.
+	Lag           time.Duration
+	RenewInterval time.Duration
+	// Skew is the smallest offset consistent with the lag: positive when the node is ahead of the
+	// API server, negative when behind, zero when within the renew interval
+	Skew   time.Duration
+	Status string
+	// Message explains a warning or failure
+	Message string
+}
+
+// ClockSkewResult is the outcome of the clock skew check
+type ClockSkewResult struct {
+	ServerTime time.Time
+	// LocalSkew is this machine's clock offset from the API server, to the second
+	LocalSkew time.Duration
+	MaxSkew   time.Duration
+	Nodes     []NodeClockSkew
+}
+
+// Skewed returns the nodes whose clocks are off by more than the maximum
+func (r *ClockSkewResult) Skewed() []NodeClockSkew {
+	var skewed []NodeClockSkew
+	for _, n := range r.Nodes {
+		if n.Status == CheckFail {
+			skewed = append(skewed, n)
+		}
+	}
+	return skewed
+}
+
+// ListNodeClockSkew estimates each node's clock offset from the API server. Kubelets stamp their
+// Lease heartbeat with their own clock, so a renew time in the server's future means the node is
+// ahead, and one older than the renew interval on a Ready node means it is behind. The estimate is
+// accurate to about a second plus the renew interval, enough to catch skew that breaks token
+// validation without starting pods.
+func (kc *KubernetesChecker) ListNodeClockSkew(ctx context.Context, maxSkew time.Duration) (*ClockSkewResult, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	leases, err := kc.clientset.CoordinationV1().Leases(nodeLeaseNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node leases: %v", err)
+	}
+	localNow := time.Now()
+	serverNow, err := kc.apiServerTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byNode := make(map[string]*coordinationv1.Lease, len(leases.Items))
+	for i := range leases.Items {
+		byNode[leases.Items[i].Name] = &leases.Items[i]
+	}
+	result := &ClockSkewResult{ServerTime: serverNow, LocalSkew: localNow.Sub(serverNow).Round(time.Second), MaxSkew: maxSkew}
+	for i := range nodes {
+		result.Nodes = append(result.Nodes, nodeClockSkew(&nodes[i], byNode[nodes[i].Name], serverNow, maxSkew))
+	}
+	return result, nil
+}
+
+// apiServerTime reads the API server's clock from the Date header of a /version request
+func (kc *KubernetesChecker) apiServerTime(ctx context.Context) (time.Time, error) {
+	client, err := rest.HTTPClientFor(kc.config)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create API server client: %v", err)
+	}
+	endpoint, err := url.JoinPath(kc.config.Host, "version")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid API server host %q: %v", kc.config.Host, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query API server time: %v", err)
+	}
+	resp.Body.Close()
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("API server response has no usable Date header: %v", err)
+	}
+	return date, nil
+}
+
+// nodeClockSkew grades one node's Lease renew time against the server's clock. The Date header
+// is truncated to the second, so a second of slack is allowed on either side.
+func nodeClockSkew(node *corev1.Node, lease *coordinationv1.Lease, serverNow time.Time, maxSkew time.Duration) NodeClockSkew {
+	result := NodeClockSkew{Name: node.Name, Status: CheckPass}
+	if lease == nil || lease.Spec.RenewTime == nil {
+		result.Status, result.Message = CheckWarn, "no node Lease heartbeat; clock not checked"
+		return result
+	}
+	result.RenewInterval = 10 * time.Second
+	if lease.Spec.LeaseDurationSeconds != nil {
+		// Kubelets renew every quarter of the lease duration
+		result.RenewInterval = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second / 4
+	}
+	result.Lag = serverNow.Sub(lease.Spec.RenewTime.Time)
+	slack := time.Second
+
+	switch {
+	case result.Lag < -slack:
+		result.Skew = -result.Lag - slack
+	case result.Lag > result.RenewInterval+slack:
+		if !isNodeReady(node) {
+			// A NotReady kubelet has stopped renewing; its lag says nothing about its clock
+			result.Status, result.Message = CheckWarn, "node is not Ready; clock not checked"
+			return result
+		}
+		result.Skew = -(result.Lag - result.RenewInterval - slack)
+	}
+
+	if abs := max(result.Skew, -result.Skew); abs > maxSkew {
+		direction := "ahead of"
+		if result.Skew < 0 {
+			direction = "behind"
+		}
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("clock at least %s %s the API server", abs.Round(time.Second), direction)
+	}
+	return result
+}
+
+// CheckClockSkew returns a summary of node clock skew and an error when any node is off by more
+// than the maximum
+func (kc *KubernetesChecker) CheckClockSkew(ctx context.Context, maxSkew time.Duration) (string, error) {
+	result, err := kc.ListNodeClockSkew(ctx, maxSkew)
+	if err != nil {
+		return "", err
+	}
+	return SummarizeClockSkew(result)
+}
+
+// SummarizeClockSkew renders the clock skew result in one line
+func SummarizeClockSkew(result *ClockSkewResult) (string, error) {
+	if skewed := result.Skewed(); len(skewed) > 0 {
+		var names []string
+		for _, n := range skewed {
+			names = append(names, fmt.Sprintf("%s (%s)", n.Name, n.Message))
+		}
+		return fmt.Sprintf("%d of %d node clocks off by more than %s: %s", len(skewed), len(result.Nodes), result.MaxSkew, strings.Join(names, ", ")),
+			fmt.Errorf("node clocks are skewed; JWT and license validation may fail")
+	}
+	checked := 0
+	for _, n := range result.Nodes {
+		if n.Status == CheckPass {
+			checked++
+		}
+	}
+	return fmt.Sprintf("%d of %d node clocks within %s of the API server", checked, len(result.Nodes), result.MaxSkew), nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNodeLease(renew time.Time) *coordinationv1.Lease {
+	duration := int32(40)
+	renewTime := metav1.NewMicroTime(renew)
+	return &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{LeaseDurationSeconds: &duration, RenewTime: &renewTime}}
+}
+
+func TestNodeClockSkew(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ready := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+	}
+	notReady := ready.DeepCopy()
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		lease    *coordinationv1.Lease
+		status   string
+		skew     time.Duration
+		contains string
+	}{
+		{name: "fresh heartbeat", node: ready, lease: testNodeLease(now.Add(-4 * time.Second)), status: CheckPass},
+		{name: "date header truncation", node: ready, lease: testNodeLease(now.Add(800 * time.Millisecond)), status: CheckPass},
+		{name: "slightly ahead", node: ready, lease: testNodeLease(now.Add(6 * time.Second)), status: CheckPass, skew: 5 * time.Second},
+		{name: "ahead", node: ready, lease: testNodeLease(now.Add(2 * time.Minute)), status: CheckFail, skew: 119 * time.Second, contains: "ahead of"},
+		{name: "behind", node: ready, lease: testNodeLease(now.Add(-time.Minute)), status: CheckFail, skew: -49 * time.Second, contains: "behind"},
+		{name: "not ready", node: notReady, lease: testNodeLease(now.Add(-time.Minute)), status: CheckWarn, contains: "not Ready"},
+		{name: "no lease", node: ready, status: CheckWarn, contains: "no node Lease"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeClockSkew(tt.node, tt.lease, now, DefaultMaxClockSkew)
+			if got.Status != tt.status || got.Skew != tt.skew || !strings.Contains(got.Message, tt.contains) {
+				t.Errorf("nodeClockSkew() = %s skew %s %q, want %s skew %s containing %q", got.Status, got.Skew, got.Message, tt.status, tt.skew, tt.contains)
+			}
+		})
+	}
+}
+
+func TestSummarizeClockSkew(t *testing.T) {
+	result := &ClockSkewResult{MaxSkew: DefaultMaxClockSkew, Nodes: []NodeClockSkew{
+		{Name: "node-1", Status: CheckPass},
+		{Name: "node-2", Status: CheckFail, Message: "clock at least 1m0s behind the API server"},
+	}}
+	summary, err := SummarizeClockSkew(result)
+	if err == nil || !strings.Contains(summary, "node-2 (clock at least 1m0s behind") {
+		t.Errorf("SummarizeClockSkew() = %q, %v", summary, err)
+	}
+
+	result.Nodes = result.Nodes[:1]
+	if summary, err := SummarizeClockSkew(result); err != nil || summary != "1 of 1 node clocks within 10s of the API server" {
+		t.Errorf("SummarizeClockSkew() = %q, %v", summary, err)
+	}
+}
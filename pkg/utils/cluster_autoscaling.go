@@ -0,0 +1,373 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Node autoscalers that can add capacity beyond the current nodes
+const (
+	AutoscalerClusterAutoscaler = "cluster-autoscaler"
+	AutoscalerKarpenter         = "karpenter"
+)
+
+var (
+	karpenterNodePoolGVR        = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1", Resource: "nodepools"}
+	karpenterNodePoolBetaGVR    = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1beta1", Resource: "nodepools"}
+	karpenterProvisionerGVR     = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1alpha5", Resource: "provisioners"}
+	clusterAutoscalerStatusName = "cluster-autoscaler-status"
+)
+
+// ScalableNodeGroup is a node group or Karpenter NodePool an autoscaler can grow. Cluster
+// autoscaler groups are bounded by node count; Karpenter pools by resource limits, where a zero
+// limit means unlimited.
+type ScalableNodeGroup struct {
+	Autoscaler string
+	Name       string
+	// Pool is the node pool label value of the group's nodes, empty when no current node matches
+	Pool        string
+	MinSize     int `json:",omitempty"`
+	MaxSize     int `json:",omitempty"`
+	CurrentSize int `json:",omitempty"`
+	CPULimit    float64
+	MemLimitGB  float64
+	GPULimit    int64
+	CPUUsed     float64
+	MemUsedGB   float64
+	GPUUsed     int64
+	Labels      map[string]string `json:"-"`
+	TaintKeys   []string          `json:"-"`
+}
+
+// Capacity describes how far the group can grow
+func (g ScalableNodeGroup) Capacity() string {
+	if g.Autoscaler == AutoscalerClusterAutoscaler {
+		return fmt.Sprintf("%d/%d nodes (min %d)", g.CurrentSize, g.MaxSize, g.MinSize)
+	}
+	limit := func(used, max float64, unit string) string {
+		if max == 0 {
+			return fmt.Sprintf("%.0f%s/unlimited", used, unit)
+		}
+		return fmt.Sprintf("%.0f%s/%.0f%s", used, unit, max, unit)
+	}
+	return fmt.Sprintf("cpu %s, memory %s, gpu %s", limit(g.CPUUsed, g.CPULimit, ""), limit(g.MemUsedGB, g.MemLimitGB, "GB"), limit(float64(g.GPUUsed), float64(g.GPULimit), ""))
+}
+
+// AutoscalingInfo lists the node autoscalers found in the cluster and the groups they can grow
+type AutoscalingInfo struct {
+	ClusterAutoscaler bool
+	Karpenter         bool
+	Groups            []ScalableNodeGroup
+	// Warnings explain autoscaler configuration that could not be read
+	Warnings []string `json:",omitempty"`
+}
+
+// DetectAutoscaling finds cluster-autoscaler and Karpenter and reads each group's size bounds or
+// resource limits. Node groups found by cluster-autoscaler auto-discovery are only known from
+// its status ConfigMap. Nodes are used to tie groups to node pools.
+func (kc *KubernetesChecker) DetectAutoscaling(ctx context.Context, nodes []NodeCapacity) (*AutoscalingInfo, error) {
+	info := &AutoscalingInfo{}
+
+	deployments, err := kc.clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	for _, d := range deployments.Items {
+		if !strings.Contains(d.Name, AutoscalerClusterAutoscaler) {
+			continue
+		}
+		info.ClusterAutoscaler = true
+		groups := map[string]*ScalableNodeGroup{}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			for _, g := range clusterAutoscalerStaticGroups(append(append([]string{}, c.Command...), c.Args...)) {
+				groups[g.Name] = &g
+			}
+		}
+		status, err := kc.clientset.CoreV1().ConfigMaps(d.Namespace).Get(ctx, clusterAutoscalerStatusName, metav1.GetOptions{})
+		if err != nil {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("cluster-autoscaler status in %s unreadable: %v", d.Namespace, err))
+		} else {
+			for _, g := range parseClusterAutoscalerStatus(status.Data["status"]) {
+				groups[g.Name] = &g
+			}
+		}
+		for _, name := range sortedKeys(groups) {
+			info.Groups = append(info.Groups, *groups[name])
+		}
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{karpenterNodePoolGVR, karpenterNodePoolBetaGVR, karpenterProvisionerGVR} {
+		items, installed, err := kc.listCustomResources(ctx, gvr, "", "")
+		if err != nil {
+			info.Warnings = append(info.Warnings, err.Error())
+			continue
+		}
+		if !installed {
+			continue
+		}
+		info.Karpenter = true
+		for _, item := range items {
+			info.Groups = append(info.Groups, karpenterGroup(item, gvr.Resource == karpenterProvisionerGVR.Resource))
+		}
+		break
+	}
+
+	matchAutoscalingPools(info.Groups, nodes)
+	return info, nil
+}
+
+// clusterAutoscalerStaticGroups reads --nodes=min:max:name flags
+func clusterAutoscalerStaticGroups(args []string) []ScalableNodeGroup {
+	var groups []ScalableNodeGroup
+	for _, arg := range args {
+		value, ok := strings.CutPrefix(arg, "--nodes=")
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		minSize, err1 := strconv.Atoi(parts[0])
+		maxSize, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		groups = append(groups, ScalableNodeGroup{Autoscaler: AutoscalerClusterAutoscaler, Name: parts[2], MinSize: minSize, MaxSize: maxSize})
+	}
+	return groups
+}
+
+// legacyStatusGroup matches a node group in the text status cluster-autoscaler wrote before 1.30
+var legacyStatusGroup = regexp.MustCompile(`(?s)Name:\s+(\S+)\s+Health:.*?cloudProviderTarget=(\d+) \(minSize=(\d+), maxSize=(\d+)\)`)
+
+// parseClusterAutoscalerStatus reads the node groups from the cluster-autoscaler-status
+// ConfigMap, in either the YAML format of 1.30+ or the earlier text format
+func parseClusterAutoscalerStatus(status string) []ScalableNodeGroup {
+	var structured struct {
+		NodeGroups []struct {
+			Name   string `json:"name"`
+			Health struct {
+				CloudProviderTarget int `json:"cloudProviderTarget"`
+				MinSize             int `json:"minSize"`
+				MaxSize             int `json:"maxSize"`
+			} `json:"health"`
+		} `json:"nodeGroups"`
+	}
+	var groups []ScalableNodeGroup
+	if err := yaml.Unmarshal([]byte(status), &structured); err == nil && len(structured.NodeGroups) > 0 {
+		for _, g := range structured.NodeGroups {
+			groups = append(groups, ScalableNodeGroup{
+				Autoscaler:  AutoscalerClusterAutoscaler,
+				Name:        g.Name,
+				MinSize:     g.Health.MinSize,
+				MaxSize:     g.Health.MaxSize,
+				CurrentSize: g.Health.CloudProviderTarget,
+			})
+		}
+		return groups
+	}
+	for _, m := range legacyStatusGroup.FindAllStringSubmatch(status, -1) {
+		target, _ := strconv.Atoi(m[2])
+		minSize, _ := strconv.Atoi(m[3])
+		maxSize, _ := strconv.Atoi(m[4])
+		groups = append(groups, ScalableNodeGroup{Autoscaler: AutoscalerClusterAutoscaler, Name: m[1], MinSize: minSize, MaxSize: maxSize, CurrentSize: target})
+	}
+	return groups
+}
+
+// karpenterGroup reads a NodePool, or a v1alpha5 Provisioner, into a scalable group
+func karpenterGroup(item unstructured.Unstructured, provisioner bool) ScalableNodeGroup {
+	g := ScalableNodeGroup{Autoscaler: AutoscalerKarpenter, Name: item.GetName(), Pool: item.GetName()}
+	limitsPath := []string{"spec", "limits"}
+	labelsPath := []string{"spec", "template", "metadata", "labels"}
+	taintsPath := []string{"spec", "template", "spec", "taints"}
+	if provisioner {
+		limitsPath = []string{"spec", "limits", "resources"}
+		labelsPath = []string{"spec", "labels"}
+		taintsPath = []string{"spec", "taints"}
+	}
+	limits, _, _ := unstructured.NestedStringMap(item.Object, limitsPath...)
+	used, _, _ := unstructured.NestedStringMap(item.Object, "status", "resources")
+	g.CPULimit, g.MemLimitGB, g.GPULimit = karpenterResources(limits)
+	g.CPUUsed, g.MemUsedGB, g.GPUUsed = karpenterResources(used)
+	g.Labels, _, _ = unstructured.NestedStringMap(item.Object, labelsPath...)
+	taints, _, _ := unstructured.NestedSlice(item.Object, taintsPath...)
+	for _, t := range taints {
+		taint, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		effect, _ := taint["effect"].(string)
+		if key, _ := taint["key"].(string); key != "" && (effect == string(corev1.TaintEffectNoSchedule) || effect == string(corev1.TaintEffectNoExecute)) {
+			g.TaintKeys = append(g.TaintKeys, key)
+		}
+	}
+	return g
+}
+
+// karpenterResources converts Karpenter resource quantities to cores, GB, and GPUs
+func karpenterResources(list map[string]string) (float64, float64, int64) {
+	var cpu, mem float64
+	var gpu int64
+	if q, err := resource.ParseQuantity(list[string(corev1.ResourceCPU)]); err == nil {
+		cpu = float64(q.MilliValue()) / 1000.0
+	}
+	if q, err := resource.ParseQuantity(list[string(corev1.ResourceMemory)]); err == nil {
+		mem = float64(q.Value()) / (1024.0 * 1024.0 * 1024.0)
+	}
+	if q, err := resource.ParseQuantity(list[string(gpuResource)]); err == nil {
+		gpu = q.Value()
+	}
+	return cpu, mem, gpu
+}
+
+// matchAutoscalingPools ties cluster-autoscaler groups to the node pools of current nodes. Groups
+// are named after their cloud group, which embeds the pool name, e.g. EKS managed node group gpu
+// is backed by the auto scaling group eks-gpu-<id>.
+func matchAutoscalingPools(groups []ScalableNodeGroup, nodes []NodeCapacity) {
+	pools := map[string]int{}
+	for _, n := range nodes {
+		pools[n.Pool]++
+	}
+	names := sortedKeys(pools)
+	// Longer pool names first, so gpu-large is not claimed by gpu
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for i := range groups {
+		g := &groups[i]
+		if g.Autoscaler != AutoscalerClusterAutoscaler {
+			continue
+		}
+		for _, pool := range names {
+			if g.Name == pool || strings.HasPrefix(g.Name, "eks-"+pool+"-") || strings.Contains(g.Name, pool) {
+				g.Pool = pool
+				if g.CurrentSize == 0 {
+					g.CurrentSize = pools[pool]
+				}
+				break
+			}
+		}
+	}
+}
+
+// ScaleUpPlacement records replicas that fit once an autoscaler grows a group
+type ScaleUpPlacement struct {
+	Autoscaler string
+	Group      string
+	Replicas   int
+	// NewNodes is how many nodes cluster-autoscaler adds; Karpenter picks node sizes itself
+	NewNodes int `json:",omitempty"`
+}
+
+// ApplyScaleUpHeadroom places the replicas the current nodes cannot hold onto autoscaler
+// headroom. A cluster-autoscaler group grows by nodes shaped like its current ones, up to its
+// max size; a Karpenter pool grows until its resource limits. Groups whose labels or taints the
+// profile cannot use are skipped.
+func ApplyScaleUpHeadroom(plan *PlacementPlan, nodes []NodeCapacity, profile ModelProfile, groups []ScalableNodeGroup) error {
+	plan.UnplacedAfterScaleUp = plan.Unplaced
+	plan.FitsAfterScaleUp = plan.Fits
+	if plan.Fits || len(groups) == 0 {
+		return nil
+	}
+	cpu, mem, err := profileQuantities(profile)
+	if err != nil {
+		return err
+	}
+
+	remaining := plan.Unplaced
+	for _, g := range groups {
+		if remaining == 0 {
+			break
+		}
+		placed, newNodes := 0, 0
+		switch g.Autoscaler {
+		case AutoscalerClusterAutoscaler:
+			template := poolTemplate(nodes, g.Pool, profile)
+			if template == nil || g.MaxSize <= g.CurrentSize {
+				continue
+			}
+			perNode := replicasPerNode(*template, profile, cpu, mem)
+			if perNode == 0 {
+				continue
+			}
+			newNodes = min(g.MaxSize-g.CurrentSize, int(math.Ceil(float64(remaining)/float64(perNode))))
+			placed = min(remaining, newNodes*perNode)
+		case AutoscalerKarpenter:
+			if !nodeEligible(&NodeCapacity{Labels: g.Labels, TaintKeys: g.TaintKeys, GPUAllocatable: karpenterGPUs(g, nodes)}, profile) {
+				continue
+			}
+			placed = min(remaining, karpenterHeadroom(g, cpu, mem, profile.GPU))
+		}
+		if placed == 0 {
+			continue
+		}
+		remaining -= placed
+		plan.ScaleUp = append(plan.ScaleUp, ScaleUpPlacement{Autoscaler: g.Autoscaler, Group: g.Name, Replicas: placed, NewNodes: newNodes})
+	}
+	plan.UnplacedAfterScaleUp = remaining
+	plan.FitsAfterScaleUp = remaining == 0
+	// The shortfall is what neither the current nodes nor the autoscalers can hold
+	plan.AdditionalCPU = cpu * float64(remaining)
+	plan.AdditionalMemGB = mem * float64(remaining)
+	plan.AdditionalGPU = profile.GPU * int64(remaining)
+	plan.SuggestedPool, plan.SuggestedNodes = "", 0
+	if remaining > 0 {
+		plan.SuggestedPool, plan.SuggestedNodes = suggestNodePool(nodes, profile, cpu, mem, remaining)
+	}
+	return nil
+}
+
+// poolTemplate returns an eligible current node of the pool to model new nodes on
+func poolTemplate(nodes []NodeCapacity, pool string, profile ModelProfile) *NodeCapacity {
+	if pool == "" {
+		return nil
+	}
+	for i := range nodes {
+		if nodes[i].Pool == pool && nodeEligible(&nodes[i], profile) {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// karpenterGPUs reports whether a Karpenter pool can provision GPU nodes: it has a GPU limit or
+// already runs GPU nodes
+func karpenterGPUs(g ScalableNodeGroup, nodes []NodeCapacity) int64 {
+	if g.GPULimit > 0 {
+		return g.GPULimit
+	}
+	for _, n := range nodes {
+		if n.Pool == g.Name && n.GPUAllocatable > 0 {
+			return n.GPUAllocatable
+		}
+	}
+	return 0
+}
+
+// karpenterHeadroom is how many replicas fit within a pool's remaining limits; a pool without
+// limits is unbounded
+func karpenterHeadroom(g ScalableNodeGroup, cpu, mem float64, gpu int64) int {
+	fit := math.MaxInt32
+	if g.CPULimit > 0 && cpu > 0 {
+		fit = min(fit, int(math.Max(g.CPULimit-g.CPUUsed, 0)/cpu))
+	}
+	if g.MemLimitGB > 0 && mem > 0 {
+		fit = min(fit, int(math.Max(g.MemLimitGB-g.MemUsedGB, 0)/mem))
+	}
+	if g.GPULimit > 0 && gpu > 0 {
+		fit = min(fit, int(max(g.GPULimit-g.GPUUsed, 0)/gpu))
+	}
+	return fit
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestClusterAutoscalerStaticGroups(t *testing.T) {
+	groups := clusterAutoscalerStaticGroups([]string{"./cluster-autoscaler", "--cloud-provider=aws", "--nodes=1:10:eks-general-1a2b", "--nodes=bad", "--nodes=0:4:gpu-asg"})
+	want := []ScalableNodeGroup{
+		{Autoscaler: AutoscalerClusterAutoscaler, Name: "eks-general-1a2b", MinSize: 1, MaxSize: 10},
+		{Autoscaler: AutoscalerClusterAutoscaler, Name: "gpu-asg", MinSize: 0, MaxSize: 4},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("clusterAutoscalerStaticGroups() = %+v, want %+v", groups, want)
+	}
+}
+
+func TestParseClusterAutoscalerStatus(t *testing.T) {
+	legacy := `Cluster-autoscaler status at 2024-05-01 10:00:00.000000 +0000 UTC:
+Cluster-wide:
+  Health:      Healthy (ready=3 unready=0 notStarted=0 longNotStarted=0 registered=3 longUnregistered=0)
+NodeGroups:
+  Name:        eks-gpu-6ec7a1b2
+  Health:      Healthy (ready=1 unready=0 notStarted=0 longNotStarted=0 registered=1 longUnregistered=0 cloudProviderTarget=1 (minSize=0, maxSize=4))
+  ScaleUp:     NoActivity (ready=1 cloudProviderTarget=1)
+`
+	structured := `time: 2024-05-01 10:00:00.000000 +0000 UTC
+autoscalerStatus: Running
+nodeGroups:
+- name: eks-gpu-6ec7a1b2
+  health:
+    status: Healthy
+    cloudProviderTarget: 1
+    minSize: 0
+    maxSize: 4
+`
+	want := []ScalableNodeGroup{{Autoscaler: AutoscalerClusterAutoscaler, Name: "eks-gpu-6ec7a1b2", MinSize: 0, MaxSize: 4, CurrentSize: 1}}
+	for name, status := range map[string]string{"legacy": legacy, "structured": structured} {
+		if got := parseClusterAutoscalerStatus(status); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: parseClusterAutoscalerStatus() = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestKarpenterGroup(t *testing.T) {
+	pool := unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "gpu"},
+		"spec": map[string]interface{}{
+			"limits": map[string]interface{}{"cpu": "100", "memory": "400Gi", "nvidia.com/gpu": "8"},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"workload": "inference"}},
+				"spec": map[string]interface{}{"taints": []interface{}{
+					map[string]interface{}{"key": "nvidia.com/gpu", "effect": "NoSchedule"},
+					map[string]interface{}{"key": "spot", "effect": "PreferNoSchedule"},
+				}},
+			},
+		},
+		"status": map[string]interface{}{"resources": map[string]interface{}{"cpu": "32", "memory": "128Gi", "nvidia.com/gpu": "4"}},
+	}}
+	g := karpenterGroup(pool, false)
+	if g.CPULimit != 100 || g.MemLimitGB != 400 || g.GPULimit != 8 || g.CPUUsed != 32 || g.MemUsedGB != 128 || g.GPUUsed != 4 {
+		t.Errorf("karpenterGroup() limits/usage = %+v", g)
+	}
+	if g.Labels["workload"] != "inference" || !reflect.DeepEqual(g.TaintKeys, []string{"nvidia.com/gpu"}) {
+		t.Errorf("karpenterGroup() labels %v taints %v", g.Labels, g.TaintKeys)
+	}
+}
+
+func TestApplyScaleUpHeadroom(t *testing.T) {
+	nodes := []NodeCapacity{
+		{Name: "gpu-1", Pool: "gpu", Labels: map[string]string{}, CPUAllocatable: 32, MemAllocatable: 128, GPUAllocatable: 4, CPUFree: 4, MemFree: 16, GPUFree: 1},
+	}
+	profile := ModelProfile{Name: "guard", Replicas: 9, CPU: "4", Memory: "16Gi", GPU: 1}
+	groups := []ScalableNodeGroup{{Autoscaler: AutoscalerClusterAutoscaler, Name: "eks-gpu-6ec7a1b2", MinSize: 1, MaxSize: 2}}
+	matchAutoscalingPools(groups, nodes)
+	if groups[0].Pool != "gpu" || groups[0].CurrentSize != 1 {
+		t.Fatalf("matchAutoscalingPools() = %+v", groups[0])
+	}
+
+	plan, err := PlanPlacement(nodes, profile)
+	if err != nil {
+		t.Fatalf("PlanPlacement returned error: %v", err)
+	}
+	if err := ApplyScaleUpHeadroom(plan, nodes, profile, groups); err != nil {
+		t.Fatalf("ApplyScaleUpHeadroom returned error: %v", err)
+	}
+	// One replica fits now, one more node holds 4, so 4 are left over
+	if plan.FitsAfterScaleUp || plan.UnplacedAfterScaleUp != 4 || plan.AdditionalGPU != 4 {
+		t.Errorf("plan after scale-up: fits %v, unplaced %d, additional GPUs %d", plan.FitsAfterScaleUp, plan.UnplacedAfterScaleUp, plan.AdditionalGPU)
+	}
+	if want := []ScaleUpPlacement{{Autoscaler: AutoscalerClusterAutoscaler, Group: "eks-gpu-6ec7a1b2", Replicas: 4, NewNodes: 1}}; !reflect.DeepEqual(plan.ScaleUp, want) {
+		t.Errorf("ScaleUp = %+v, want %+v", plan.ScaleUp, want)
+	}
+
+	// A Karpenter pool with GPU limits takes the rest
+	karpenter := ScalableNodeGroup{Autoscaler: AutoscalerKarpenter, Name: "gpu-spot", GPULimit: 16, GPUUsed: 8, CPULimit: 200}
+	plan, _ = PlanPlacement(nodes, profile)
+	if err := ApplyScaleUpHeadroom(plan, nodes, profile, append(groups, karpenter)); err != nil {
+		t.Fatalf("ApplyScaleUpHeadroom returned error: %v", err)
+	}
+	if !plan.FitsAfterScaleUp || plan.AdditionalGPU != 0 || len(plan.ScaleUp) != 2 || plan.ScaleUp[1].Replicas != 4 {
+		t.Errorf("plan with Karpenter: fits %v, scale-up %+v", plan.FitsAfterScaleUp, plan.ScaleUp)
+	}
+
+	// A tainted pool the profile does not tolerate adds nothing
+	karpenter.TaintKeys = []string{"dedicated"}
+	plan, _ = PlanPlacement(nodes, profile)
+	if err := ApplyScaleUpHeadroom(plan, nodes, profile, []ScalableNodeGroup{karpenter}); err != nil {
+		t.Fatalf("ApplyScaleUpHeadroom returned error: %v", err)
+	}
+	if len(plan.ScaleUp) != 0 || plan.UnplacedAfterScaleUp != 8 {
+		t.Errorf("tainted pool should not be used, got %+v", plan.ScaleUp)
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Triage issue categories
+const (
+	IssueOOMKilled  = "oom-killed"
+	IssueImagePull  = "image-pull"
+	IssueScheduling = "scheduling"
+	IssueCrashLoop  = "crash-loop"
+	IssueProbe      = "probe"
+	IssueWarning    = "warning"
+	IssueNormal     = "normal"
+)
+
+// TriageIssue is a single event or pod condition attributed to a workload
+type TriageIssue struct {
+	Category string
+	Reason   string
+	Object   string
+	Count    int32
+	LastSeen time.Time
+	Message  string
+}
+
+// WorkloadIssues groups triage issues by the workload that owns the affected objects
+type WorkloadIssues struct {
+	Workload string
+	Failures int
+	Issues   []TriageIssue
+}
+
+// TriageNamespaceEvents collects events newer than since in a namespace, plus OOMKills and image
+// pull and crash-loop states read from pod statuses, and groups them by owning workload. With
+// failedOnly, Normal events are dropped.
+func (kc *KubernetesChecker) TriageNamespaceEvents(ctx context.Context, namespace string, since time.Duration, failedOnly bool) ([]WorkloadIssues, error) {
+	cutoff := time.Now().Add(-since)
+
+	events, err := kc.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events in %s: %v", namespace, err)
+	}
+	pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %v", namespace, err)
+	}
+	replicaSets, err := kc.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets in %s: %v", namespace, err)
+	}
+
+	rsOwners := map[string]string{}
+	for _, rs := range replicaSets.Items {
+		rsOwners[rs.Name] = ownerWorkload(rs.OwnerReferences, nil, "ReplicaSet/"+rs.Name)
+	}
+	podOwners := map[string]string{}
+	for _, pod := range pods.Items {
+		podOwners[pod.Name] = ownerWorkload(pod.OwnerReferences, rsOwners, "Pod/"+pod.Name)
+	}
+
+	groups := map[string]*WorkloadIssues{}
+	add := func(workload string, issue TriageIssue) {
+		g, ok := groups[workload]
+		if !ok {
+			g = &WorkloadIssues{Workload: workload}
+			groups[workload] = g
+		}
+		if issue.Category != IssueNormal {
+			g.Failures++
+		}
+		g.Issues = append(g.Issues, issue)
+	}
+
+	for _, e := range events.Items {
+		last := eventTime(e)
+		if last.Before(cutoff) {
+			continue
+		}
+		category := categorizeEvent(e)
+		if failedOnly && category == IssueNormal {
+			continue
+		}
+		count := e.Count
+		if count == 0 {
+			count = 1
+		}
+
+		obj := e.InvolvedObject
+		workload := obj.Kind + "/" + obj.Name
+		switch obj.Kind {
+		case "Pod":
+			if owner, ok := podOwners[obj.Name]; ok {
+				workload = owner
+			}
+		case "ReplicaSet":
+			if owner, ok := rsOwners[obj.Name]; ok {
+				workload = owner
+			}
+		}
+
+		add(workload, TriageIssue{
+			Category: category,
+			Reason:   e.Reason,
+			Object:   obj.Kind + "/" + obj.Name,
+			Count:    count,
+			LastSeen: last,
+			Message:  strings.TrimSpace(e.Message),
+		})
+	}
+
+	// OOMKills and image pull back-offs are not always recorded as events, so read them from
+	// pod statuses as well
+	for _, pod := range pods.Items {
+		for _, issue := range podStatusIssues(pod, cutoff) {
+			add(podOwners[pod.Name], issue)
+		}
+	}
+
+	result := make([]WorkloadIssues, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.Issues, func(i, j int) bool {
+			return g.Issues[i].LastSeen.After(g.Issues[j].LastSeen)
+		})
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Failures != result[j].Failures {
+			return result[i].Failures > result[j].Failures
+		}
+		return result[i].Workload < result[j].Workload
+	})
+	return result, nil
+}
+
+// categorizeEvent maps an event reason to a triage category
+func categorizeEvent(e corev1.Event) string {
+	reason := e.Reason
+	switch {
+	case reason == "OOMKilling" || strings.Contains(e.Message, "OOMKilled"):
+		return IssueOOMKilled
+	case reason == "FailedScheduling" || reason == "NotTriggerScaleUp":
+		return IssueScheduling
+	case reason == "ErrImagePull" || reason == "ImagePullBackOff" ||
+		(reason == "Failed" && strings.Contains(e.Message, "image")) ||
+		(reason == "BackOff" && strings.Contains(e.Message, "pulling image")):
+		return IssueImagePull
+	case reason == "BackOff" || reason == "CrashLoopBackOff":
+		return IssueCrashLoop
+	case reason == "Unhealthy" || reason == "ProbeWarning":
+		return IssueProbe
+	case e.Type == corev1.EventTypeWarning:
+		return IssueWarning
+	default:
+		return IssueNormal
+	}
+}
+
+// podStatusIssues reports OOMKilled terminations after cutoff and containers currently stuck
+// pulling images or crash-looping
+func podStatusIssues(pod corev1.Pod, cutoff time.Time) []TriageIssue {
+	var issues []TriageIssue
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		object := "Pod/" + pod.Name + "/" + cs.Name
+		if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" && t.FinishedAt.Time.After(cutoff) {
+			issues = append(issues, TriageIssue{
+				Category: IssueOOMKilled,
+				Reason:   "OOMKilled",
+				Object:   object,
+				Count:    cs.RestartCount,
+				LastSeen: t.FinishedAt.Time,
+				Message:  fmt.Sprintf("container %s was OOMKilled (restarts: %d)", cs.Name, cs.RestartCount),
+			})
+		}
+		if w := cs.State.Waiting; w != nil {
+			var category string
+			switch w.Reason {
+			case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+				category = IssueImagePull
+			case "CrashLoopBackOff":
+				category = IssueCrashLoop
+			default:
+				continue
+			}
+			issues = append(issues, TriageIssue{
+				Category: category,
+				Reason:   w.Reason,
+				Object:   object,
+				Count:    1,
+				LastSeen: time.Now(),
+				Message:  strings.TrimSpace(w.Message),
+			})
+		}
+	}
+	return issues
+}
+
+// ownerWorkload resolves the top-level workload for an object from its owner references, mapping
+// ReplicaSets to their Deployments through rsOwners
+func ownerWorkload(refs []metav1.OwnerReference, rsOwners map[string]string, fallback string) string {
+	for _, ref := range refs {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.Kind == "ReplicaSet" {
+			if owner, ok := rsOwners[ref.Name]; ok {
+				return owner
+			}
+		}
+		return ref.Kind + "/" + ref.Name
+	}
+	return fallback
+}
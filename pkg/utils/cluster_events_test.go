@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCategorizeEvent(t *testing.T) {
+	tests := []struct {
+		event corev1.Event
+		want  string
+	}{
+		{corev1.Event{Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available"}, IssueScheduling},
+		{corev1.Event{Type: "Warning", Reason: "Failed", Message: "Failed to pull image \"guard:1.2\""}, IssueImagePull},
+		{corev1.Event{Type: "Warning", Reason: "BackOff", Message: "Back-off pulling image \"guard:1.2\""}, IssueImagePull},
+		{corev1.Event{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container"}, IssueCrashLoop},
+		{corev1.Event{Type: "Warning", Reason: "Unhealthy", Message: "Readiness probe failed"}, IssueProbe},
+		{corev1.Event{Type: "Warning", Reason: "FailedMount", Message: "timed out waiting for volume"}, IssueWarning},
+		{corev1.Event{Type: "Normal", Reason: "Scheduled", Message: "Successfully assigned"}, IssueNormal},
+	}
+
+	for _, tt := range tests {
+		if got := categorizeEvent(tt.event); got != tt.want {
+			t.Errorf("categorizeEvent(%s: %s) = %s, want %s", tt.event.Reason, tt.event.Message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"sigs.k8s.io/yaml"
+)
+
+// configFileName is the filename of the optional dynactl defaults file.
+const configFileName = "config.yaml"
+
+// configPathEnv overrides the location of the dynactl config file.
+const configPathEnv = "DYNACTL_CONFIG"
+
+// DefaultGuardExcludedDeployments are skipped by `guard models list` when no other
+// exclusions are configured; they are not model-serving components.
+var DefaultGuardExcludedDeployments = []string{
+	"dynamoai-data-processing",
+	"dynamoai-moderation",
+	"dynamoai-off-topic",
+}
+
+// DynactlConfig holds user defaults read from the dynactl config file.
+type DynactlConfig struct {
+	Guard     GuardConfig     `json:"guard"`
+	Cluster   ClusterConfig   `json:"cluster"`
+	Update    UpdateConfig    `json:"update"`
+	Audit     AuditConfig     `json:"audit"`
+	Hooks     HooksConfig     `json:"hooks"`
+	Telemetry TelemetryConfig `json:"telemetry"`
+	Backup    BackupConfig    `json:"backup"`
+}
+
+// BackupConfig holds defaults for the backup commands.
+type BackupConfig struct {
+	// Dir is where backups are written and listed (default ~/.dynactl/backups).
+	Dir string `json:"dir,omitempty"`
+}
+
+// TelemetryConfig controls opt-in usage reporting; see dynactl telemetry.
+type TelemetryConfig struct {
+	// Endpoint overrides where usage events are posted.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Disabled turns telemetry off for everyone using this config file, even if they opted in.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// HooksConfig lists the customer gates artifacts pass through.
+type HooksConfig struct {
+	// PrePush hooks approve each artifact before it is pushed into a target registry.
+	PrePush []ArtifactHook `json:"pre_push,omitempty"`
+}
+
+// AuditConfig controls where mutating operations are recorded.
+type AuditConfig struct {
+	// Path overrides the audit log location (default ~/.dynactl/audit.log).
+	Path string `json:"path,omitempty"`
+	// Syslog also sends each entry to the local syslog daemon.
+	Syslog bool `json:"syslog,omitempty"`
+	// Webhook also posts each entry as JSON to this URL.
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// UpdateConfig controls self-update and the new version notice.
+type UpdateConfig struct {
+	// Channel is the release channel to follow: stable (default) or beta.
+	Channel string `json:"channel,omitempty"`
+	// DisableCheck turns off the new version notice shown after commands.
+	DisableCheck bool `json:"disable_check,omitempty"`
+}
+
+// ClusterConfig holds defaults for the cluster checks.
+type ClusterConfig struct {
+	// AcceleratorResources lists extended resources (glob patterns allowed) totalled as
+	// accelerators; empty uses DefaultAcceleratorResources.
+	AcceleratorResources []string `json:"accelerator_resources,omitempty"`
+	// PricingFile is the default --pricing file used with --show-cost.
+	PricingFile string `json:"pricing_file,omitempty"`
+}
+
+// GuardConfig holds defaults for the guard commands.
+type GuardConfig struct {
+	// ModelSelector is a label selector used to find model-serving workloads.
+	ModelSelector string `json:"model_selector,omitempty"`
+	// IncludeDeployments, when set, limits listings to these names (glob patterns allowed).
+	IncludeDeployments []string `json:"include_deployments,omitempty"`
+	// ExcludeDeployments removes these names (glob patterns allowed) from listings.
+	ExcludeDeployments []string `json:"exclude_deployments,omitempty"`
+	// ConfigDefaults is the release defaults file `guard config show --diff` compares against.
+	ConfigDefaults string `json:"config_defaults,omitempty"`
+	// DepsConfig is the Secrets and connections file `guard deps check` reads.
+	DepsConfig string `json:"deps_config,omitempty"`
+}
+
+// LoadConfig reads the dynactl config file. A missing file yields an empty config.
+func LoadConfig() (*DynactlConfig, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &DynactlConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg DynactlConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// ConfigPath returns the location of the dynactl config file.
+func ConfigPath() (string, error) {
+	if path := os.Getenv(configPathEnv); path != "" {
+		return path, nil
+	}
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, configFileName)
+	if system := systemConfigPath(runtime.GOOS, os.Getenv("ProgramData")); system != "" && !pathExists(path) && pathExists(system) {
+		return system, nil
+	}
+	return path, nil
+}
+
+// dynactlHomeDir returns the directory dynactl uses for persisted state: ~/.dynactl, or
+// %APPDATA%\dynactl on Windows.
+func dynactlHomeDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+	}
+	return stateDir(runtime.GOOS, homeDir, os.Getenv("APPDATA"), pathExists), nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	t.Setenv(configPathEnv, path)
+
+	// Missing file yields an empty config
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error for missing config, got %v", err)
+	}
+	if cfg.Guard.ModelSelector != "" || cfg.Guard.ExcludeDeployments != nil {
+		t.Errorf("Expected empty config, got %+v", cfg)
+	}
+
+	content := `guard:
+  model_selector: app.kubernetes.io/component=model-server
+  exclude_deployments:
+    - dynamoai-*-processing
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Guard.ModelSelector != "app.kubernetes.io/component=model-server" {
+		t.Errorf("Unexpected model selector: %s", cfg.Guard.ModelSelector)
+	}
+	if len(cfg.Guard.ExcludeDeployments) != 1 || cfg.Guard.ExcludeDeployments[0] != "dynamoai-*-processing" {
+		t.Errorf("Unexpected exclusions: %v", cfg.Guard.ExcludeDeployments)
+	}
+}
+
+func TestFilterDeploymentSummaries(t *testing.T) {
+	summaries := []DeploymentResourceSummary{
+		{Name: "guard-api"},
+		{Name: "guard-worker"},
+		{Name: "dynamoai-data-processing"},
+		{Name: "dynamoai-moderation"},
+	}
+
+	filtered := FilterDeploymentSummaries(summaries, nil, DefaultGuardExcludedDeployments)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 deployments after default exclusions, got %d", len(filtered))
+	}
+
+	filtered = FilterDeploymentSummaries(summaries, []string{"guard-*"}, []string{"guard-api"})
+	if len(filtered) != 1 || filtered[0].Name != "guard-worker" {
+		t.Errorf("Expected only guard-worker, got %v", filtered)
+	}
+}
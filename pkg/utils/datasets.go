@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry/remote"
+	oras_auth "oras.land/oras-go/v2/registry/remote/auth"
+)
+
+const (
+	// DatasetArtifactType is the OCI artifact type of dataset manifests
+	DatasetArtifactType = "application/vnd.dynamoai.dataset.v1"
+	// DatasetLayerMediaType is the media type of each file in a dataset artifact
+	DatasetLayerMediaType = "application/vnd.dynamoai.dataset.layer.v1"
+	// DatasetsDir is the directory under the output directory that datasets are pulled into
+	DatasetsDir = "datasets"
+)
+
+// datasetDir is where a dataset component is placed under outputDir
+func datasetDir(outputDir string, component Component) (string, error) {
+	rel := component.TargetPath
+	if rel == "" {
+		rel = SafeFileName(component.Name)
+	}
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("dataset %s has target path %q outside the datasets directory", component.Name, component.TargetPath)
+	}
+	return filepath.Join(outputDir, DatasetsDir, rel), nil
+}
+
+// newOrasRepository returns a repository client that authenticates with dynactl's credential
+// resolution and honors the registry client options
+func newOrasRepository(reference string, opts RegistryClientOptions) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ORAS repository for '%s': %v", reference, err)
+	}
+	httpClient, err := opts.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	repo.PlainHTTP = opts.PlainHTTP
+	repo.Client = &oras_auth.Client{
+		Client: httpClient,
+		Cache:  oras_auth.NewCache(),
+		Credential: func(ctx context.Context, registry string) (oras_auth.Credential, error) {
+			return resolveRegistryCredential(registry)
+		},
+	}
+	return repo, nil
+}
+
+// isPlainHTTPRegistry reports whether image pushes to a repository's host go over HTTP, which
+// go-containerregistry does for localhost and private .local hosts; dataset pushes follow suit
+func isPlainHTTPRegistry(repository string) bool {
+	reg, err := name.NewRegistry(RegistryHost(repository))
+	return err == nil && reg.Scheme() == "http"
+}
+
+// pullDataset pulls a dataset artifact into its target directory and checks its checksum
+func pullDataset(component Component, outputDir string, opts RegistryClientOptions) error {
+	dir, err := datasetDir(outputDir, component)
+	if err != nil {
+		return err
+	}
+
+	LogInfo("🧪 Pulling dataset...")
+	layers, err := pullArtifactFiles(component, dir, opts)
+	if err != nil {
+		return err
+	}
+	if err := checkArtifactChecksum(component, layers); err != nil {
+		return err
+	}
+	LogInfo("  Dataset saved to: %s", dir)
+	return nil
+}
+
+// pullArtifactFiles pulls the files of an OCI artifact into dir and returns the descriptors
+// referenced by its manifest
+func pullArtifactFiles(component Component, dir string, opts RegistryClientOptions) ([]ocispec.Descriptor, error) {
+	repoPart, refPart := splitRepositoryAndReference(component.URI)
+	if repoPart == "" || refPart == "" {
+		return nil, fmt.Errorf("%s reference needs a tag or digest: %s", component.Name, component.URI)
+	}
+	LogInfo("  Repository: %s", repoPart)
+	LogInfo("  Reference: %s", refPart)
+	LogInfo("  Target: %s", dir)
+
+	if err := os.MkdirAll(LongPath(dir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	store, err := file.New(LongPath(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file store: %v", err)
+	}
+	defer store.Close()
+
+	repo, err := newOrasRepository(repoPart, opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	root, err := oras.Copy(ctx, repo, refPart, store, "", oras.DefaultCopyOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull %s from '%s': %v", component.Name, component.URI, err)
+	}
+	layers, err := content.Successors(ctx, store, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the manifest of %s: %w", component.URI, err)
+	}
+	return layers, nil
+}
+
+// checkArtifactChecksum confirms one of the artifact's files has the manifest's checksum. The
+// ORAS store verifies every blob against its digest, so a matching layer digest covers the content.
+func checkArtifactChecksum(component Component, layers []ocispec.Descriptor) error {
+	if component.SHA256 == "" {
+		LogWarning("%s has no sha256 in the manifest; its content was not checked", component.Name)
+		return nil
+	}
+	for _, layer := range layers {
+		if strings.EqualFold(layer.Digest.Encoded(), component.SHA256) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s does not contain a file with sha256 %s", component.Name, component.SHA256)
+}
+
+// mirrorDatasets pushes pulled datasets to the target registry as dataset artifacts. Datasets a
+// pre-push hook denies are skipped, and the mirror fails after listing all of them.
+func mirrorDatasets(components []Component, cacheDir, targetRegistry string, options MirrorOptions) error {
+	ctx := context.Background()
+	var denied []string
+	for idx, component := range components {
+		repoPart, tagOrDigest := splitRepositoryAndReference(component.URI)
+		if tagOrDigest == "" || strings.HasPrefix(tagOrDigest, "sha256:") {
+			// Repacking the files produces a new manifest digest, so only tags survive a mirror
+			return fmt.Errorf("dataset %s must be referenced by tag to be mirrored, not %q", component.Name, tagOrDigest)
+		}
+		dir, err := datasetDir(cacheDir, component)
+		if err != nil {
+			return err
+		}
+		targetRepo, targetTag := mirrorTarget(repoPart, tagOrDigest, targetRegistry, options.TargetOverrides)
+		if options.TargetRepository != nil {
+			targetRepo = options.TargetRepository(targetRepo)
+		}
+
+		targetRef := assembleTargetReference(targetRepo, targetTag)
+		LogInfo("📤 Pushing dataset %d/%d", idx+1, len(components))
+		LogInfo("  Source: %s", component.URI)
+		LogInfo("  Target: %s", targetRef)
+
+		if _, done := options.Journal.Completed(JournalStagePushed, component.URI, targetRef); done {
+			LogInfo("⏭️  Already pushed by an earlier run; skipping")
+			continue
+		}
+		if len(options.PrePushHooks) > 0 {
+			err := RunArtifactHooks(ctx, options.PrePushHooks, HookArtifact{
+				Stage:  HookStagePrePush,
+				Type:   ArtifactTypeDataset,
+				Source: component.URI,
+				Target: targetRef,
+				Path:   dir,
+			})
+			if err != nil {
+				LogError("⛔ %v", err)
+				denied = append(denied, component.URI)
+				continue
+			}
+		}
+		if err := pushDataset(ctx, component, dir, targetRepo, targetTag); err != nil {
+			return err
+		}
+		err = options.Journal.Record(JournalEntry{Stage: JournalStagePushed, Type: ArtifactTypeDataset, Source: component.URI, Target: targetRef})
+		if err != nil {
+			return err
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("pre-push hooks denied %d dataset(s): %s", len(denied), strings.Join(denied, ", "))
+	}
+	return nil
+}
+
+// pushDataset packs the files in dir as a dataset artifact and pushes it under tag
+func pushDataset(ctx context.Context, component Component, dir, targetRepo, tag string) error {
+	store, err := file.New(LongPath(dir))
+	if err != nil {
+		return fmt.Errorf("failed to create file store: %v", err)
+	}
+	defer store.Close()
+
+	entries, err := os.ReadDir(LongPath(dir))
+	if err != nil {
+		return fmt.Errorf("failed to read dataset %s from the cache: %w", component.Name, err)
+	}
+	var layers []ocispec.Descriptor
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		desc, err := store.Add(ctx, entry.Name(), DatasetLayerMediaType, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to add %s to dataset %s: %w", entry.Name(), component.Name, err)
+		}
+		layers = append(layers, desc)
+	}
+	if len(layers) == 0 {
+		return fmt.Errorf("dataset %s has no files in %s", component.Name, dir)
+	}
+	if err := checkArtifactChecksum(component, layers); err != nil {
+		return err
+	}
+
+	root, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, DatasetArtifactType, oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		return fmt.Errorf("failed to pack dataset %s: %w", component.Name, err)
+	}
+	if err := store.Tag(ctx, root, tag); err != nil {
+		return fmt.Errorf("failed to tag dataset %s: %w", component.Name, err)
+	}
+	repo, err := newOrasRepository(targetRepo, RegistryClientOptions{PlainHTTP: isPlainHTTPRegistry(targetRepo)})
+	if err != nil {
+		return err
+	}
+	if _, err := oras.Copy(ctx, store, tag, repo, tag, oras.DefaultCopyOptions); err != nil {
+		return fmt.Errorf("failed to push dataset %s to %s: %w", component.Name, targetRepo, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pushTestDataset publishes a one-file dataset artifact and returns its component
+func pushTestDataset(t *testing.T, host string, data []byte) Component {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "toxicity.jsonl"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	component := Component{
+		Name:   "toxicity-eval",
+		Type:   ArtifactTypeDataset,
+		URI:    host + "/dynamoai/datasets/toxicity-eval:2024.1",
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	if err := pushDataset(context.Background(), component, dir, host+"/dynamoai/datasets/toxicity-eval", "2024.1"); err != nil {
+		t.Fatal(err)
+	}
+	return component
+}
+
+func TestPullAndMirrorDataset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	data := []byte(`{"prompt":"hello","label":"safe"}` + "\n")
+	component := pushTestDataset(t, host, data)
+	component.TargetPath = "eval/toxicity"
+
+	cacheDir := t.TempDir()
+	if err := pullSingleArtifact(component, cacheDir, RegistryClientOptions{PlainHTTP: true}); err != nil {
+		t.Fatal(err)
+	}
+	pulled, err := os.ReadFile(filepath.Join(cacheDir, DatasetsDir, "eval", "toxicity", "toxicity.jsonl"))
+	if err != nil {
+		t.Fatalf("expected the dataset under its target path: %v", err)
+	}
+	if string(pulled) != string(data) {
+		t.Fatalf("unexpected dataset content %q", pulled)
+	}
+
+	manifest := &ArtifactManifest{Datasets: []Dataset{{
+		Name:       component.Name,
+		URI:        "oci://" + component.URI,
+		SHA256:     "sha256:" + component.SHA256,
+		TargetPath: component.TargetPath,
+	}}}
+	if err := MirrorArtifacts(manifest, cacheDir, host+"/mirror", MirrorOptions{IncludeDatasets: true}); err != nil {
+		t.Fatal(err)
+	}
+	mirrored := component
+	mirrored.URI = host + "/mirror/dynamoai/datasets/toxicity-eval:2024.1"
+	mirrored.TargetPath = ""
+	if err := pullDataset(mirrored, t.TempDir(), RegistryClientOptions{PlainHTTP: true}); err != nil {
+		t.Fatalf("expected the mirrored dataset to pull with the same checksum: %v", err)
+	}
+}
+
+func TestMirrorDatasetPrePushHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	component := pushTestDataset(t, host, []byte(`{"prompt":"hello","label":"safe"}`+"\n"))
+	cacheDir := t.TempDir()
+	if err := pullSingleArtifact(component, cacheDir, RegistryClientOptions{PlainHTTP: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var asked HookArtifact
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&asked)
+		w.Write([]byte(`{"allow": false, "reason": "unreviewed dataset"}`))
+	}))
+	defer gate.Close()
+
+	manifest := &ArtifactManifest{Datasets: []Dataset{{Name: component.Name, URI: "oci://" + component.URI, SHA256: component.SHA256}}}
+	options := MirrorOptions{IncludeDatasets: true, PrePushHooks: []ArtifactHook{{Name: "gate", URL: gate.URL}}}
+	err := MirrorArtifacts(manifest, cacheDir, host+"/mirror", options)
+	if err == nil || !strings.Contains(err.Error(), "pre-push hooks denied 1 dataset(s): "+component.URI) {
+		t.Fatalf("expected the denied dataset to fail the mirror, got %v", err)
+	}
+	if asked.Type != ArtifactTypeDataset || asked.Stage != HookStagePrePush || asked.Target != host+"/mirror/dynamoai/datasets/toxicity-eval:2024.1" || asked.Path == "" {
+		t.Errorf("unexpected artifact sent to the hook: %+v", asked)
+	}
+
+	mirrored := component
+	mirrored.URI = host + "/mirror/dynamoai/datasets/toxicity-eval:2024.1"
+	if err := pullDataset(mirrored, t.TempDir(), RegistryClientOptions{PlainHTTP: true}); err == nil {
+		t.Error("expected the denied dataset not to be pushed")
+	}
+}
+
+func TestPullDatasetRejectsChecksumMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	component := pushTestDataset(t, host, []byte("data"))
+	component.SHA256 = strings.Repeat("0", 64)
+
+	err := pullDataset(component, t.TempDir(), RegistryClientOptions{PlainHTTP: true})
+	if err == nil || !strings.Contains(err.Error(), "does not contain a file with sha256") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestDatasetDirStaysInsideOutputDir(t *testing.T) {
+	if _, err := datasetDir("out", Component{Name: "x", TargetPath: "../escape"}); err == nil {
+		t.Fatal("expected a target path outside the datasets directory to be rejected")
+	}
+	dir, err := datasetDir("out", Component{Name: "toxicity-eval"})
+	if err != nil || dir != filepath.Join("out", DatasetsDir, "toxicity-eval") {
+		t.Fatalf("unexpected default dataset directory %q, %v", dir, err)
+	}
+}
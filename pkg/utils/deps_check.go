@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultProbeImage ships psql alongside busybox nc and wget, which covers every probe type
+const DefaultProbeImage = "postgres:16-alpine"
+
+// Dependency types understood by the probe pod
+const (
+	DependencyPostgres = "postgres"
+	DependencyRedis    = "redis"
+	DependencyS3       = "s3"
+	DependencyHTTP     = "http"
+	DependencySMTP     = "smtp"
+	DependencyOIDC     = "oidc"
+	DependencyTCP      = "tcp"
+)
+
+// Dependency check statuses
+const (
+	DependencyOK          = "ok"
+	DependencyAuthFailed  = "auth-failed"
+	DependencyUnreachable = "unreachable"
+	DependencyUnknown     = "unknown"
+)
+
+// probeResultPrefix marks result lines in the probe pod's output
+const probeResultPrefix = "@@dynactl|"
+
+// DependencyConfig lists the external services a deployment needs, read from deps.yaml
+type DependencyConfig struct {
+	Namespace    string       `json:"namespace,omitempty"`
+	Image        string       `json:"image,omitempty"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Dependency is a single external service to probe from inside the cluster
+type Dependency struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Database string `json:"database,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// PasswordSecret reads the password from a Secret in the probe namespace instead
+	PasswordSecret *SecretKeyRef `json:"password_secret,omitempty"`
+	// UsernameSecret reads the username from a Secret in the probe namespace instead
+	UsernameSecret *SecretKeyRef `json:"username_secret,omitempty"`
+	// TokenSecret sends a bearer token from a Secret with HTTP probes; 401 and 403 responses then
+	// count as authentication failures
+	TokenSecret *SecretKeyRef `json:"token_secret,omitempty"`
+	// Integration groups dependencies and Secrets in `guard deps check` output
+	Integration string `json:"integration,omitempty"`
+}
+
+// secretRefs lists the Secret keys the dependency's probe reads
+func (d Dependency) secretRefs() []SecretKeyRef {
+	var refs []SecretKeyRef
+	for _, ref := range []*SecretKeyRef{d.UsernameSecret, d.PasswordSecret, d.TokenSecret} {
+		if ref != nil {
+			refs = append(refs, *ref)
+		}
+	}
+	return refs
+}
+
+// SecretKeyRef points at a key in a Secret
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// DependencyResult is the probe outcome for one dependency
+type DependencyResult struct {
+	Name    string
+	Type    string
+	Target  string
+	Status  string
+	Message string
+}
+
+// LoadDependencyConfig reads and validates a dependency config file
+func LoadDependencyConfig(path string) (*DependencyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependency config: %w", err)
+	}
+
+	var cfg DependencyConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse dependency config %s: %w", path, err)
+	}
+	if len(cfg.Dependencies) == 0 {
+		return nil, fmt.Errorf("no dependencies listed in %s", path)
+	}
+
+	if err := validateDependencies(cfg.Dependencies); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validateDependencies normalizes dependency types, names unnamed dependencies, and fills in
+// default ports
+func validateDependencies(deps []Dependency) error {
+	for i := range deps {
+		d := &deps[i]
+		d.Type = strings.ToLower(d.Type)
+		if d.Name == "" {
+			d.Name = fmt.Sprintf("%s-%d", d.Type, i)
+		}
+		switch d.Type {
+		case DependencyPostgres, DependencyRedis, DependencySMTP, DependencyTCP:
+			if d.Host == "" {
+				return fmt.Errorf("dependency %s: host is required", d.Name)
+			}
+			if d.Port == 0 {
+				d.Port = defaultDependencyPort(d.Type)
+			}
+			if d.Port == 0 {
+				return fmt.Errorf("dependency %s: port is required", d.Name)
+			}
+		case DependencyS3, DependencyHTTP, DependencyOIDC:
+			if d.URL == "" {
+				return fmt.Errorf("dependency %s: url is required", d.Name)
+			}
+		default:
+			return fmt.Errorf("dependency %s: unsupported type %q", d.Name, d.Type)
+		}
+	}
+	return nil
+}
+
+func defaultDependencyPort(depType string) int {
+	switch depType {
+	case DependencyPostgres:
+		return 5432
+	case DependencyRedis:
+		return 6379
+	case DependencySMTP:
+		return 587
+	}
+	return 0
+}
+
+// dependencyTarget describes where a dependency lives for display
+func dependencyTarget(d Dependency) string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf("%s:%d", d.Host, d.Port)
+}
+
+// CheckDependencies runs a short-lived probe pod in the namespace that tests reachability and,
+// where credentials are given, authentication to each dependency. The pod is always deleted.
+func (kc *KubernetesChecker) CheckDependencies(ctx context.Context, namespace string, cfg *DependencyConfig, timeout time.Duration) ([]DependencyResult, error) {
+	image := cfg.Image
+	if image == "" {
+		image = DefaultProbeImage
+	}
+
+	// Inline passwords reach the probe through a temporary Secret, so they are not stored in the
+	// pod spec, where anyone who can read pods would see them
+	var credentialsSecret string
+	if credentials := inlineCredentials(cfg.Dependencies); len(credentials) > 0 {
+		secret, err := kc.clientset.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "dynactl-deps-probe-",
+				Namespace:    namespace,
+				Labels:       map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+			},
+			Type:       corev1.SecretTypeOpaque,
+			StringData: credentials,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create probe credentials Secret in %s: %v", namespace, err)
+		}
+		credentialsSecret = secret.Name
+		defer func() {
+			if err := kc.clientset.CoreV1().Secrets(namespace).Delete(context.Background(), secret.Name, metav1.DeleteOptions{}); err != nil {
+				LogWarning("Failed to delete probe credentials Secret %s: %v", secret.Name, err)
+			}
+		}()
+	}
+
+	pod := buildProbePod(namespace, image, cfg.Dependencies, credentialsSecret)
+	created, err := kc.clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create probe pod in %s: %v", namespace, err)
+	}
+	LogInfo("Started probe pod %s/%s using image %s", namespace, created.Name, image)
+	defer func() {
+		if err := kc.clientset.CoreV1().Pods(namespace).Delete(context.Background(), created.Name, metav1.DeleteOptions{}); err != nil {
+			LogWarning("Failed to delete probe pod %s: %v", created.Name, err)
+		}
+	}()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		p, err := kc.clientset.CoreV1().Pods(namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get probe pod: %v", err)
+		}
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			break
+		}
+		if reason := podWaitingReason(p); reason == "ErrImagePull" || reason == "ImagePullBackOff" {
+			return nil, fmt.Errorf("probe pod cannot pull image %s (%s); set image in the config to a mirrored copy", image, reason)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("probe pod did not finish within %s (phase %s)", timeout, p.Status.Phase)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	logs, err := kc.clientset.CoreV1().Pods(namespace).GetLogs(created.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probe pod logs: %v", err)
+	}
+	return parseProbeOutput(string(logs), cfg.Dependencies), nil
+}
+
+// inlineCredentials maps the probe variables of passwords given inline in the config to their values
+func inlineCredentials(deps []Dependency) map[string]string {
+	credentials := map[string]string{}
+	for i, d := range deps {
+		if d.Password != "" && d.PasswordSecret == nil {
+			credentials[probePasswordVar(i)] = d.Password
+		}
+	}
+	return credentials
+}
+
+func probePasswordVar(i int) string {
+	return fmt.Sprintf("DEP_%d_PASSWORD", i)
+}
+
+// buildProbePod creates the spec for a one-shot pod that runs the probe script. Inline passwords
+// are read from credentialsSecret, keyed by their probe variable.
+func buildProbePod(namespace, image string, deps []Dependency, credentialsSecret string) *corev1.Pod {
+	var env []corev1.EnvVar
+	for i, d := range deps {
+		password := d.PasswordSecret
+		if password == nil && d.Password != "" {
+			password = &SecretKeyRef{Name: credentialsSecret, Key: probePasswordVar(i)}
+		}
+		env = append(env, probeEnv(fmt.Sprintf("DEP_%d_USERNAME", i), d.UsernameSecret)...)
+		env = append(env, probeEnv(probePasswordVar(i), password)...)
+		env = append(env, probeEnv(fmt.Sprintf("DEP_%d_TOKEN", i), d.TokenSecret)...)
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dynactl-deps-probe-",
+			Namespace:    namespace,
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    "probe",
+				Image:   image,
+				Command: []string{"/bin/sh", "-c", buildProbeScript(deps)},
+				Env:     env,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("50m"),
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("200m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// probeEnv sets a probe pod variable from a Secret key, if there is one
+func probeEnv(name string, ref *SecretKeyRef) []corev1.EnvVar {
+	if ref == nil {
+		return nil
+	}
+	return []corev1.EnvVar{{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+			Key:                  ref.Key,
+		}},
+	}}
+}
+
+// buildProbeScript generates a POSIX shell script that checks each dependency and prints one
+// result line per dependency
+func buildProbeScript(deps []Dependency) string {
+	var b strings.Builder
+	b.WriteString("r() { printf '" + probeResultPrefix + "%s|%s|%s\\n' \"$1\" \"$2\" \"$(echo \"$3\" | tr '\\n|' '  ')\"; }\n")
+
+	for i, d := range deps {
+		name := shellQuote(d.Name)
+		pw := fmt.Sprintf("\"$%s\"", probePasswordVar(i))
+		hasPassword := d.Password != "" || d.PasswordSecret != nil
+		hasUsername := d.Username != "" || d.UsernameSecret != nil
+		user := shellQuote(d.Username)
+		if d.UsernameSecret != nil {
+			user = fmt.Sprintf("\"$DEP_%d_USERNAME\"", i)
+		}
+		host, port := shellQuote(d.Host), fmt.Sprintf("%d", d.Port)
+		tcpCheck := func(okMessage string) string {
+			return fmt.Sprintf("if nc -z -w 5 %s %s; then r %s %s %s; else r %s %s 'connection failed'; fi\n",
+				host, port, name, DependencyOK, shellQuote(okMessage), name, DependencyUnreachable)
+		}
+
+		b.WriteString(fmt.Sprintf("# %s (%s)\n", d.Name, d.Type))
+		switch d.Type {
+		case DependencyPostgres:
+			if !hasUsername {
+				b.WriteString(tcpCheck("port reachable (no username; authentication not verified)"))
+				continue
+			}
+			db := d.Database
+			if db == "" {
+				db = "postgres"
+			}
+			conninfo := shellQuote(fmt.Sprintf("host=%s port=%d dbname=%s", d.Host, d.Port, db))
+			b.WriteString("if command -v psql >/dev/null 2>&1; then\n")
+			b.WriteString(fmt.Sprintf("  out=$(PGUSER=%s PGPASSWORD=%s PGCONNECT_TIMEOUT=5 psql %s -tAc 'select 1' 2>&1)\n", user, pw, conninfo))
+			b.WriteString(fmt.Sprintf("  if [ $? -eq 0 ]; then r %s %s 'authenticated'; ", name, DependencyOK))
+			b.WriteString(fmt.Sprintf("elif echo \"$out\" | grep -qi 'authentication failed\\|password\\|no pg_hba'; then r %s %s \"$out\"; ", name, DependencyAuthFailed))
+			b.WriteString(fmt.Sprintf("else r %s %s \"$out\"; fi\n", name, DependencyUnreachable))
+			b.WriteString("else\n  ")
+			b.WriteString(tcpCheck("port reachable (psql not in probe image; authentication not verified)"))
+			b.WriteString("fi\n")
+		case DependencyRedis:
+			send := "printf 'PING\\r\\n'"
+			switch {
+			case hasPassword && hasUsername:
+				send = fmt.Sprintf("printf 'AUTH %%s %%s\\r\\nPING\\r\\n' %s %s", user, pw)
+			case hasPassword:
+				send = fmt.Sprintf("printf 'AUTH %%s\\r\\nPING\\r\\n' %s", pw)
+			}
+			b.WriteString(fmt.Sprintf("out=$( (%s; sleep 1) | nc -w 5 %s %s 2>&1)\n", send, host, port))
+			b.WriteString(fmt.Sprintf("if echo \"$out\" | grep -q PONG; then r %s %s 'PING succeeded'; ", name, DependencyOK))
+			b.WriteString(fmt.Sprintf("elif echo \"$out\" | grep -q 'NOAUTH\\|WRONGPASS\\|invalid password\\|invalid username'; then r %s %s \"$out\"; ", name, DependencyAuthFailed))
+			b.WriteString(fmt.Sprintf("else r %s %s \"${out:-connection failed}\"; fi\n", name, DependencyUnreachable))
+		case DependencySMTP:
+			b.WriteString(fmt.Sprintf("out=$( (sleep 2; printf 'QUIT\\r\\n') | nc -w 5 %s %s 2>&1)\n", host, port))
+			b.WriteString(fmt.Sprintf("if echo \"$out\" | grep -q '^220'; then r %s %s \"$(echo \"$out\" | head -n 1)\"; ", name, DependencyOK))
+			b.WriteString(fmt.Sprintf("else r %s %s \"${out:-no SMTP banner}\"; fi\n", name, DependencyUnreachable))
+		case DependencyS3, DependencyHTTP:
+			header := ""
+			if d.TokenSecret != nil {
+				header = fmt.Sprintf("--header \"Authorization: Bearer $DEP_%d_TOKEN\" ", i)
+			}
+			b.WriteString(fmt.Sprintf("out=$(wget -S -O /dev/null -T 5 %s%s 2>&1)\n", header, shellQuote(d.URL)))
+			b.WriteString("code=$(echo \"$out\" | grep -o 'HTTP/[0-9.]* [0-9]*' | tail -n 1)\n")
+			b.WriteString(fmt.Sprintf("if [ -z \"$code\" ]; then r %s %s \"$out\"; ", name, DependencyUnreachable))
+			if d.TokenSecret != nil {
+				// With a token, a rejected request means the token is wrong rather than absent
+				b.WriteString(fmt.Sprintf("elif echo \"$code\" | grep -q ' 40[13]$'; then r %s %s \"token rejected: $code\"; ", name, DependencyAuthFailed))
+			}
+			// Any HTTP response proves reachability; unauthenticated S3 requests return 403
+			b.WriteString(fmt.Sprintf("else r %s %s \"endpoint responded: $code\"; fi\n", name, DependencyOK))
+		case DependencyOIDC:
+			discovery := strings.TrimSuffix(d.URL, "/") + "/.well-known/openid-configuration"
+			b.WriteString(fmt.Sprintf("out=$(wget -q -O - -T 5 %s 2>&1)\n", shellQuote(discovery)))
+			b.WriteString(fmt.Sprintf("if echo \"$out\" | grep -q '\"issuer\"'; then r %s %s 'discovery document served'; ", name, DependencyOK))
+			b.WriteString(fmt.Sprintf("else r %s %s \"$out\"; fi\n", name, DependencyUnreachable))
+		default:
+			b.WriteString(tcpCheck("port reachable"))
+		}
+	}
+	return b.String()
+}
+
+// parseProbeOutput turns probe result lines into results, marking dependencies without output
+// as unknown
+func parseProbeOutput(output string, deps []Dependency) []DependencyResult {
+	found := map[string]DependencyResult{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, probeResultPrefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, probeResultPrefix), "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		found[parts[0]] = DependencyResult{Status: parts[1], Message: strings.TrimSpace(parts[2])}
+	}
+
+	results := make([]DependencyResult, 0, len(deps))
+	for _, d := range deps {
+		r, ok := found[d.Name]
+		if !ok {
+			r = DependencyResult{Status: DependencyUnknown, Message: "no result from probe pod"}
+		}
+		r.Name = d.Name
+		r.Type = d.Type
+		r.Target = dependencyTarget(d)
+		results = append(results, r)
+	}
+	return results
+}
+
+// podWaitingReason returns the first waiting reason among the pod's containers
+func podWaitingReason(pod *corev1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil {
+			return cs.State.Waiting.Reason
+		}
+	}
+	return ""
+}
+
+// shellQuote wraps s in single quotes for safe use in a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLoadDependencyConfig(t *testing.T) {
+	cfg, err := LoadDependencyConfig("../../examples/deps.yaml")
+	if err != nil {
+		t.Fatalf("LoadDependencyConfig returned error: %v", err)
+	}
+	if len(cfg.Dependencies) != 5 {
+		t.Fatalf("expected 5 dependencies, got %d", len(cfg.Dependencies))
+	}
+	if redis := cfg.Dependencies[1]; redis.Port != 6379 {
+		t.Errorf("expected default redis port 6379, got %d", redis.Port)
+	}
+}
+
+func TestBuildProbeScript(t *testing.T) {
+	deps := []Dependency{
+		{Name: "postgres", Type: DependencyPostgres, Host: "db", Port: 5432, Username: "o'brien", Password: "x"},
+		{Name: "redis", Type: DependencyRedis, Host: "cache", Port: 6379, Password: "x"},
+		{Name: "bucket", Type: DependencyS3, URL: "https://s3.example.com/bucket"},
+		{Name: "smtp", Type: DependencySMTP, Host: "mail", Port: 587},
+		{Name: "sso", Type: DependencyOIDC, URL: "https://login.example.com/"},
+		{Name: "kafka", Type: DependencyTCP, Host: "kafka", Port: 9092},
+	}
+
+	script := buildProbeScript(deps)
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	if out, err := exec.Command(sh, "-n", "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("generated script is not valid shell: %v\n%s\n%s", err, out, script)
+	}
+}
+
+func TestParseProbeOutput(t *testing.T) {
+	deps := []Dependency{
+		{Name: "postgres", Type: DependencyPostgres, Host: "db", Port: 5432},
+		{Name: "sso", Type: DependencyOIDC, URL: "https://login.example.com"},
+	}
+	output := "some noise\n@@dynactl|postgres|auth-failed|FATAL: password authentication failed\n"
+
+	results := parseProbeOutput(output, deps)
+	if results[0].Status != DependencyAuthFailed || results[0].Target != "db:5432" {
+		t.Errorf("unexpected postgres result: %+v", results[0])
+	}
+	if results[1].Status != DependencyUnknown {
+		t.Errorf("expected sso to be unknown, got %s", results[1].Status)
+	}
+}
+
+// completeProbePods makes pods created through the fake clientset finish at once, as the probe
+// pod does, and names objects created with generateName. created sees every object created.
+func completeProbePods(clientset *fake.Clientset, created func(runtime.Object)) {
+	clientset.PrependReactor("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject()
+		meta := obj.(metav1.Object)
+		if meta.GetName() == "" {
+			meta.SetName(meta.GetGenerateName() + "abcde")
+		}
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pod.Status.Phase = corev1.PodSucceeded
+		}
+		created(obj.DeepCopyObject())
+		return false, nil, nil
+	})
+}
+
+func TestCheckDependenciesInlinePassword(t *testing.T) {
+	clientset := kubetest.NewClientset()
+	var probe *corev1.Pod
+	var credentials map[string]string
+	completeProbePods(clientset, func(obj runtime.Object) {
+		switch o := obj.(type) {
+		case *corev1.Pod:
+			probe = o
+		case *corev1.Secret:
+			credentials = o.StringData
+		}
+	})
+	kc := NewKubernetesCheckerForClients(clientset, kubetest.NewDynamicClient(nil))
+
+	cfg := &DependencyConfig{Dependencies: []Dependency{
+		{Name: "postgres", Type: DependencyPostgres, Host: "db", Port: 5432, Username: "dynamo", Password: "hunter2"},
+		{Name: "redis", Type: DependencyRedis, Host: "cache", Port: 6379, PasswordSecret: &SecretKeyRef{Name: "redis", Key: "password"}},
+	}}
+	if _, err := kc.CheckDependencies(context.Background(), kubetest.Namespace, cfg, time.Minute); err != nil {
+		t.Fatalf("CheckDependencies returned error: %v", err)
+	}
+
+	if credentials["DEP_0_PASSWORD"] != "hunter2" || len(credentials) != 1 {
+		t.Errorf("Expected only the inline password in the credentials Secret, got %v", credentials)
+	}
+	for _, env := range probe.Spec.Containers[0].Env {
+		if env.Value != "" {
+			t.Errorf("Expected no literal values in the probe pod env, got %s=%s", env.Name, env.Value)
+		}
+	}
+	if env := probe.Spec.Containers[0].Env; len(env) != 2 || env[0].ValueFrom.SecretKeyRef.Name != "dynactl-deps-probe-abcde" || env[1].ValueFrom.SecretKeyRef.Name != "redis" {
+		t.Errorf("Expected the passwords to come from the credentials Secret and the redis Secret, got %+v", env)
+	}
+
+	secrets, _ := clientset.CoreV1().Secrets(kubetest.Namespace).List(context.Background(), metav1.ListOptions{})
+	pods, _ := clientset.CoreV1().Pods(kubetest.Namespace).List(context.Background(), metav1.ListOptions{})
+	if len(secrets.Items) != 0 || len(pods.Items) != 0 {
+		t.Errorf("Expected the probe pod and credentials Secret to be deleted, got %d pods and %d Secrets", len(pods.Items), len(secrets.Items))
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DynamoService is a Dynamo AI service engineers commonly port-forward to
+type DynamoService struct {
+	Name        string
+	Description string
+	// LocalPort is the local port `guard port-forward` uses by default, so each service keeps a
+	// stable localhost address
+	LocalPort uint16
+}
+
+// DynamoServices lists the well-known Dynamo AI services, offered by shell completion
+var DynamoServices = []DynamoService{
+	{Name: DefaultLicenseService, Description: "Platform API", LocalPort: 8080},
+	{Name: "dynamoai-ui", Description: "Web UI", LocalPort: 3000},
+	{Name: "dynamoai-guard", Description: "Guard API", LocalPort: 8081},
+	{Name: "dynamoai-moderation", Description: "Moderation service", LocalPort: 8082},
+	{Name: "dynamoai-off-topic", Description: "Off-topic detection service", LocalPort: 8083},
+	{Name: "dynamoai-data-processing", Description: "Data processing service", LocalPort: 8084},
+	{Name: "dynamoai-keycloak", Description: "Keycloak SSO admin console", LocalPort: 8180},
+}
+
+// LookupDynamoService returns the well-known service with the given name
+func LookupDynamoService(name string) (DynamoService, bool) {
+	for _, s := range DynamoServices {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return DynamoService{}, false
+}
+
+// ListServices returns the names of the services in a namespace, sorted
+func (kc *KubernetesChecker) ListServices(ctx context.Context, namespace string) ([]string, error) {
+	services, err := kc.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services in %s: %v", namespace, err)
+	}
+
+	names := make([]string, 0, len(services.Items))
+	for _, svc := range services.Items {
+		names = append(names, svc.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
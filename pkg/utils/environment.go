@@ -0,0 +1,334 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const environmentFileName = "environment.json"
+
+// Cloud providers and distributions reported by the environment fingerprint
+const (
+	ProviderEKS       = "eks"
+	ProviderAKS       = "aks"
+	ProviderGKE       = "gke"
+	ProviderOpenShift = "openshift"
+	ProviderK3s       = "k3s"
+	ProviderRKE2      = "rke2"
+	ProviderOnPrem    = "on-prem"
+)
+
+// publicRegistries are probed from inside the cluster to decide whether it is air-gapped
+var publicRegistries = []string{
+	"registry-1.docker.io",
+	"ghcr.io",
+	"quay.io",
+	"public.ecr.aws",
+	"nvcr.io",
+}
+
+// cniDaemonSets maps well-known CNI DaemonSet names to the plugin they belong to
+var cniDaemonSets = map[string]string{
+	"aws-node":            "aws-vpc-cni",
+	"calico-node":         "calico",
+	"canal":               "canal",
+	"cilium":              "cilium",
+	"anetd":               "cilium",
+	"kube-flannel-ds":     "flannel",
+	"kube-flannel":        "flannel",
+	"weave-net":           "weave",
+	"antrea-agent":        "antrea",
+	"kube-ovn-cni":        "kube-ovn",
+	"azure-cns":           "azure-cni",
+	"azure-ip-masq-agent": "azure-cni",
+	"ovnkube-node":        "ovn-kubernetes",
+	"sdn":                 "openshift-sdn",
+}
+
+// ProxySettings are the proxy environment variables seen by dynactl, without credentials
+type ProxySettings struct {
+	HTTPProxy  string `json:"http_proxy,omitempty"`
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	NoProxy    string `json:"no_proxy,omitempty"`
+}
+
+// EnvironmentProfile fingerprints a cluster so later commands can pick sensible defaults
+type EnvironmentProfile struct {
+	GeneratedAt         time.Time       `json:"generated_at"`
+	KubernetesVersion   string          `json:"kubernetes_version"`
+	CloudProvider       string          `json:"cloud_provider"`
+	CNI                 []string        `json:"cni"`
+	IngressControllers  []string        `json:"ingress_controllers"`
+	StorageDrivers      []string        `json:"storage_drivers"`
+	DefaultStorageClass string          `json:"default_storage_class,omitempty"`
+	GPUNodes            int             `json:"gpu_nodes"`
+	GPUOperator         bool            `json:"gpu_operator"`
+	Proxy               ProxySettings   `json:"proxy"`
+	PublicRegistries    map[string]bool `json:"public_registries"`
+	// AirGapped is left out when the registry probe pod could not run
+	AirGapped *bool `json:"air_gapped,omitempty"`
+	// InternalRegistry is the host of OpenShift's built-in image registry
+	InternalRegistry string `json:"internal_registry,omitempty"`
+}
+
+// EnvironmentOptions controls the pod FingerprintEnvironment probes public registries from
+type EnvironmentOptions struct {
+	Namespace string
+	// Image is the probe pod image; empty uses DefaultProbeImage
+	Image string
+	// ProbeTimeout bounds how long the probe pod may take to finish
+	ProbeTimeout time.Duration
+}
+
+// FingerprintEnvironment inspects the cluster to build an environment profile. Registry
+// reachability is tested from a probe pod, since the machine running dynactl, such as a bastion,
+// may reach the internet when the cluster cannot.
+func (kc *KubernetesChecker) FingerprintEnvironment(ctx context.Context, opts EnvironmentOptions) (*EnvironmentProfile, error) {
+	profile := &EnvironmentProfile{
+		GeneratedAt: time.Now().UTC(),
+		Proxy: ProxySettings{
+			HTTPProxy:  stripURLCredentials(proxyEnv("HTTP_PROXY")),
+			HTTPSProxy: stripURLCredentials(proxyEnv("HTTPS_PROXY")),
+			NoProxy:    proxyEnv("NO_PROXY"),
+		},
+	}
+
+	version, err := kc.CheckKubernetesVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile.KubernetesVersion = version
+
+	nodes, err := kc.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	for _, node := range nodes.Items {
+		if len(nodeAccelerators(node.Status.Allocatable, nil, nil)) > 0 {
+			profile.GPUNodes++
+		}
+	}
+
+	groups, err := kc.discovery.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API groups: %v", err)
+	}
+	apiGroups := map[string]bool{}
+	for _, g := range groups.Groups {
+		apiGroups[g.Name] = true
+	}
+	profile.CloudProvider = detectCloudProvider(nodes.Items, isOpenShift(apiGroups))
+	if profile.CloudProvider == ProviderOpenShift {
+		if profile.InternalRegistry, err = kc.OpenShiftRegistryHost(ctx); err != nil {
+			LogWarning("Internal registry not recorded: %v", err)
+		}
+	}
+
+	daemonSets, err := kc.clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %v", err)
+	}
+	var dsNames []string
+	for _, ds := range daemonSets.Items {
+		dsNames = append(dsNames, ds.Name)
+		if strings.HasPrefix(ds.Name, "nvidia-device-plugin") && ds.Namespace == "gpu-operator" {
+			profile.GPUOperator = true
+		}
+	}
+	profile.CNI = detectCNI(dsNames)
+	profile.GPUOperator = profile.GPUOperator || apiGroups["nvidia.com"]
+
+	ingressClasses, err := kc.clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingress classes: %v", err)
+	}
+	for _, ic := range ingressClasses.Items {
+		profile.IngressControllers = appendUnique(profile.IngressControllers, ic.Spec.Controller)
+	}
+
+	csiDrivers, err := kc.clientset.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSI drivers: %v", err)
+	}
+	for _, d := range csiDrivers.Items {
+		profile.StorageDrivers = appendUnique(profile.StorageDrivers, d.Name)
+	}
+	storageClasses, err := kc.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list StorageClasses: %v", err)
+	}
+	for _, sc := range storageClasses.Items {
+		profile.StorageDrivers = appendUnique(profile.StorageDrivers, sc.Provisioner)
+		if sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+			profile.DefaultStorageClass = sc.Name
+		}
+	}
+	sort.Strings(profile.StorageDrivers)
+	sort.Strings(profile.IngressControllers)
+
+	if profile.PublicRegistries, err = kc.probePublicRegistries(ctx, opts); err != nil {
+		LogWarning("Public registry reachability not probed: %v", err)
+		return profile, nil
+	}
+	airGapped := true
+	for _, reachable := range profile.PublicRegistries {
+		if reachable {
+			airGapped = false
+		}
+	}
+	profile.AirGapped = &airGapped
+
+	return profile, nil
+}
+
+// detectCloudProvider infers the managed Kubernetes offering or distribution from node metadata
+func detectCloudProvider(nodes []corev1.Node, openshift bool) string {
+	if openshift {
+		return ProviderOpenShift
+	}
+	for _, node := range nodes {
+		providerID := node.Spec.ProviderID
+		kubelet := node.Status.NodeInfo.KubeletVersion
+		switch {
+		case strings.HasPrefix(providerID, "aws://"):
+			return ProviderEKS
+		case strings.HasPrefix(providerID, "azure://"):
+			return ProviderAKS
+		case strings.HasPrefix(providerID, "gce://"):
+			return ProviderGKE
+		case strings.Contains(kubelet, "+k3s"):
+			return ProviderK3s
+		case strings.Contains(kubelet, "+rke2"):
+			return ProviderRKE2
+		}
+	}
+	return ProviderOnPrem
+}
+
+// detectCNI maps DaemonSet names to the CNI plugins they belong to
+func detectCNI(daemonSets []string) []string {
+	var cni []string
+	for _, name := range daemonSets {
+		if plugin, ok := cniDaemonSets[name]; ok {
+			cni = appendUnique(cni, plugin)
+		}
+	}
+	sort.Strings(cni)
+	return cni
+}
+
+// probePublicRegistries checks from a probe pod whether each public registry answers over HTTPS
+func (kc *KubernetesChecker) probePublicRegistries(ctx context.Context, opts EnvironmentOptions) (map[string]bool, error) {
+	deps := make([]Dependency, 0, len(publicRegistries))
+	for _, registry := range publicRegistries {
+		// Any HTTP response (typically 401 from /v2/) means the registry is reachable
+		deps = append(deps, Dependency{Name: registry, Type: DependencyHTTP, URL: "https://" + registry + "/v2/"})
+	}
+	results, err := kc.CheckDependencies(ctx, opts.Namespace, &DependencyConfig{Image: opts.Image, Dependencies: deps}, opts.ProbeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[string]bool, len(results))
+	for _, r := range results {
+		if r.Status != DependencyOK {
+			LogDebug("Registry %s unreachable from the cluster: %s", r.Name, r.Message)
+		}
+		reachable[r.Name] = r.Status == DependencyOK
+	}
+	return reachable, nil
+}
+
+func proxyEnv(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+// stripURLCredentials drops the user:password part of a proxy URL, with or without a scheme, so
+// it is not saved with the profile
+func stripURLCredentials(value string) string {
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return value
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil && u.Host != "" {
+		u.User = nil
+		return u.String()
+	}
+	scheme := ""
+	if i := strings.Index(value, "://"); i >= 0 && i < at {
+		scheme = value[:i+3]
+	}
+	return scheme + value[at+1:]
+}
+
+func appendUnique(values []string, v string) []string {
+	if v == "" || slices.Contains(values, v) {
+		return values
+	}
+	return append(values, v)
+}
+
+// EnvironmentProfilePath returns where the saved environment profile lives
+func EnvironmentProfilePath() (string, error) {
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, environmentFileName), nil
+}
+
+// SaveEnvironmentProfile writes the profile to path, or the default location when path is empty
+func SaveEnvironmentProfile(profile *EnvironmentProfile, path string) (string, error) {
+	if path == "" {
+		var err error
+		if path, err = EnvironmentProfilePath(); err != nil {
+			return "", err
+		}
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal environment profile: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write environment profile: %w", err)
+	}
+	return path, nil
+}
+
+// LoadEnvironmentProfile reads a saved environment profile. It returns nil without error when
+// no profile has been saved yet.
+func LoadEnvironmentProfile() (*EnvironmentProfile, error) {
+	path, err := EnvironmentProfilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment profile: %w", err)
+	}
+
+	var profile EnvironmentProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse environment profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// evidenceDir is where --evidence-dir saves the API responses checks are based on; empty
+// disables it
+var evidenceDir string
+
+// SetEvidenceDir makes KubernetesCheckers created from now on save every API response under dir
+func SetEvidenceDir(dir string) {
+	evidenceDir = dir
+}
+
+// redactedValue replaces the values of Secrets in saved evidence
+const redactedValue = "<redacted>"
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// EvidenceEntry is one saved API exchange
+type EvidenceEntry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	Status int       `json:"status"`
+	// Request is the body sent, such as the attributes of an access review
+	Request json.RawMessage `json:"request,omitempty"`
+	// Response is the object the API server returned, with Secret values redacted
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// evidenceRecorder saves each API response it passes through to a file of its own, numbered in
+// the order they were received, so a verdict can be traced back to what the cluster reported
+type evidenceRecorder struct {
+	dir  string
+	next http.RoundTripper
+	seq  atomic.Int64
+}
+
+// newEvidenceRecorder creates a directory for this run under base, named after the current time
+func newEvidenceRecorder(base string) (*evidenceRecorder, error) {
+	dir := filepath.Join(base, time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create evidence directory: %v", err)
+	}
+	return &evidenceRecorder{dir: dir}, nil
+}
+
+// wrap is a rest.Config WrapTransport
+func (r *evidenceRecorder) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &evidenceRecorder{dir: r.dir, next: rt}
+}
+
+func (r *evidenceRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	// Watches and followed logs never end; what they stream is not evidence of a single verdict
+	if query.Get("watch") == "true" || query.Get("follow") == "true" {
+		return r.next.RoundTrip(req)
+	}
+
+	var sent []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sent = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	received, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(received))
+	if err != nil {
+		return resp, nil
+	}
+
+	entry := EvidenceEntry{
+		Time:     time.Now().UTC(),
+		Method:   req.Method,
+		URL:      req.URL.RequestURI(),
+		Status:   resp.StatusCode,
+		Request:  evidenceJSON(sent),
+		Response: evidenceJSON(redactSecrets(received)),
+	}
+	if err := r.save(entry); err != nil {
+		LogWarning("Evidence not saved for %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (r *evidenceRecorder) save(entry EvidenceEntry) error {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.Trim(strings.SplitN(entry.URL, "?", 2)[0], "/"), "_"), "_")
+	if len(name) > 120 {
+		name = name[:120]
+	}
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entry); err != nil {
+		return err
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%04d-%s-%s.json", r.seq.Add(1), entry.Method, name))
+	return os.WriteFile(path, data.Bytes(), 0o600)
+}
+
+// evidenceJSON keeps a body as JSON, or as a JSON string when it is not JSON
+func evidenceJSON(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// redactSecrets blanks the values of a Secret or of the Secrets in a list, keeping their keys
+func redactSecrets(body []byte) []byte {
+	if !bytes.Contains(body, []byte(`"Secret`)) {
+		return body
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return body
+	}
+	switch obj["kind"] {
+	case "Secret":
+		redactSecret(obj)
+	case "SecretList":
+		items, _ := obj["items"].([]any)
+		for _, item := range items {
+			if secret, ok := item.(map[string]any); ok {
+				redactSecret(secret)
+			}
+		}
+	default:
+		return body
+	}
+	var redacted bytes.Buffer
+	enc := json.NewEncoder(&redacted)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		return body
+	}
+	return bytes.TrimSpace(redacted.Bytes())
+}
+
+func redactSecret(secret map[string]any) {
+	for _, field := range []string{"data", "stringData"} {
+		if values, ok := secret[field].(map[string]any); ok {
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+	// kubectl apply keeps the whole Secret, values included, in this annotation
+	if metadata, ok := secret["metadata"].(map[string]any); ok {
+		if annotations, ok := metadata["annotations"].(map[string]any); ok {
+			if _, ok := annotations["kubectl.kubernetes.io/last-applied-configuration"]; ok {
+				annotations["kubectl.kubernetes.io/last-applied-configuration"] = redactedValue
+			}
+		}
+	}
+}
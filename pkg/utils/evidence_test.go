@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEvidenceRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/secrets"):
+			w.Write([]byte(`{"kind":"SecretList","items":[{"kind":"Secret","metadata":{"name":"tls"},"data":{"tls.key":"c2VjcmV0"}}]}`))
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(`{"kind":"SelfSubjectAccessReview","status":{"allowed":true},"echo":` + string(body) + `}`))
+		default:
+			w.Write([]byte(`{"kind":"NodeList","items":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	recorder, err := newEvidenceRecorder(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder.wrap(http.DefaultTransport)}
+	get := func(path string) {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get("/api/v1/nodes?labelSelector=gpu%3Dtrue")
+	get("/api/v1/nodes?watch=true")
+	get("/api/v1/namespaces/dynamo/secrets")
+	resp, err := client.Post(server.URL+"/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", "application/json", strings.NewReader(`{"spec":{"resourceAttributes":{"verb":"list"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The caller still reads the whole response
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), `"allowed":true`) {
+		t.Errorf("Expected the response body to be passed through, got %s", body)
+	}
+	resp.Body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(recorder.dir, "*.json"))
+	want := []string{
+		"0001-GET-api_v1_nodes.json",
+		"0002-GET-api_v1_namespaces_dynamo_secrets.json",
+		"0003-POST-apis_authorization.k8s.io_v1_selfsubjectaccessreviews.json",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, files)
+	}
+	entries := map[string]EvidenceEntry{}
+	for i, file := range files {
+		if filepath.Base(file) != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], filepath.Base(file))
+		}
+		data, _ := os.ReadFile(file)
+		var entry EvidenceEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatalf("Expected %s to hold an entry: %v", file, err)
+		}
+		entries[want[i]] = entry
+	}
+
+	if e := entries[want[0]]; e.URL != "/api/v1/nodes?labelSelector=gpu%3Dtrue" || e.Status != http.StatusOK {
+		t.Errorf("Expected the node list with its selector, got %s %d", e.URL, e.Status)
+	}
+	var secrets struct {
+		Items []struct {
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(entries[want[1]].Response, &secrets); err != nil || len(secrets.Items) != 1 || secrets.Items[0].Data["tls.key"] != redactedValue {
+		t.Errorf("Expected the secret value to be redacted, got %s", entries[want[1]].Response)
+	}
+	var review struct {
+		Spec struct {
+			ResourceAttributes struct {
+				Verb string `json:"verb"`
+			} `json:"resourceAttributes"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(entries[want[2]].Request, &review); err != nil || review.Spec.ResourceAttributes.Verb != "list" {
+		t.Errorf("Expected the access review request to be kept, got %s", entries[want[2]].Request)
+	}
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizationFile lists the exported resources so the directory can be applied with kustomize
+const kustomizationFile = "kustomization.yaml"
+
+// ExportResources writes each object as clean YAML to its own file in dir, named
+// <kind>-<name>.yaml (prefixed with the namespace for namespaced objects), for committing to a
+// GitOps repository instead of applying. The files are added to dir's kustomization.yaml, which
+// is created if needed; resources it already lists are kept, so several commands can export
+// into one directory. It returns the files written, relative to dir.
+func ExportResources(dir string, objects ...runtime.Object) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	var files []string
+	for _, obj := range objects {
+		data, kind, err := cleanResourceYAML(obj)
+		if err != nil {
+			return files, err
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return files, err
+		}
+		name := strings.ToLower(kind) + "-" + accessor.GetName() + ".yaml"
+		if ns := accessor.GetNamespace(); ns != "" {
+			name = ns + "-" + name
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return files, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		files = append(files, name)
+	}
+	return files, updateKustomization(dir, files)
+}
+
+// cleanResourceYAML renders an object with its apiVersion and kind, leaving out the status and
+// the empty creationTimestamps typed objects carry, which would only be noise in a repository
+func cleanResourceYAML(obj runtime.Object) ([]byte, string, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "" {
+		kinds, _, err := scheme.Scheme.ObjectKinds(obj)
+		if err != nil || len(kinds) == 0 {
+			return nil, "", fmt.Errorf("unknown resource type %T: %v", obj, err)
+		}
+		gvk = kinds[0]
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, "", err
+	}
+	content["apiVersion"], content["kind"] = gvk.GroupVersion().String(), gvk.Kind
+	delete(content, "status")
+	dropNullTimestamps(content)
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, gvk.Kind, nil
+}
+
+// dropNullTimestamps removes unset creationTimestamp fields, including those of pod templates
+func dropNullTimestamps(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		if ts, ok := v["creationTimestamp"]; ok && ts == nil {
+			delete(v, "creationTimestamp")
+		}
+		for _, child := range v {
+			dropNullTimestamps(child)
+		}
+	case []any:
+		for _, child := range v {
+			dropNullTimestamps(child)
+		}
+	}
+}
+
+// updateKustomization adds files to the resources of dir's kustomization.yaml, keeping
+// everything else it holds, such as patches added by hand
+func updateKustomization(dir string, files []string) error {
+	path := filepath.Join(dir, kustomizationFile)
+	k := map[string]any{"apiVersion": "kustomize.config.k8s.io/v1beta1", "kind": "Kustomization"}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &k); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var resources []string
+	if listed, ok := k["resources"].([]any); ok {
+		for _, r := range listed {
+			if s, ok := r.(string); ok {
+				resources = append(resources, s)
+			}
+		}
+	}
+	for _, f := range files {
+		if !containsString(resources, f) {
+			resources = append(resources, f)
+		}
+	}
+	sort.Strings(resources)
+	k["resources"] = resources
+	out, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportResources(t *testing.T) {
+	dir := t.TempDir()
+	kustomization := filepath.Join(dir, "kustomization.yaml")
+	existing := "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n- namespace.yaml\npatches:\n- path: patch.yaml\n"
+	if err := os.WriteFile(kustomization, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ExportResources(dir, LicenseSecret("dynamo", DefaultLicenseSecret, DefaultLicenseSecretKey, []byte("license"), ""))
+	if err != nil {
+		t.Fatalf("ExportResources failed: %v", err)
+	}
+	want := "dynamo-secret-" + DefaultLicenseSecret + ".yaml"
+	if len(files) != 1 || files[0] != want {
+		t.Fatalf("expected %s, got %v", want, files)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := string(data)
+	for _, expected := range []string{"apiVersion: v1", "kind: Secret", "namespace: dynamo"} {
+		if !strings.Contains(secret, expected) {
+			t.Errorf("expected %q in:\n%s", expected, secret)
+		}
+	}
+	if strings.Contains(secret, "creationTimestamp") || strings.Contains(secret, "status") {
+		t.Errorf("expected clean YAML, got:\n%s", secret)
+	}
+
+	// Exporting again keeps one entry per file and the hand-written patches
+	if _, err := ExportResources(dir, LicenseSecret("dynamo", DefaultLicenseSecret, DefaultLicenseSecretKey, []byte("renewed"), "")); err != nil {
+		t.Fatal(err)
+	}
+	var k struct {
+		Resources []string `json:"resources"`
+		Patches   []any    `json:"patches"`
+	}
+	data, _ = os.ReadFile(kustomization)
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(k.Resources, ",") != want+",namespace.yaml" || len(k.Patches) != 1 {
+		t.Errorf("unexpected kustomization:\n%s", data)
+	}
+}
+
+func TestBackupScheduleExport(t *testing.T) {
+	s, err := NewBackupSchedule(BackupScheduleOptions{Namespace: "dynamo", Schedule: "@daily", Retain: 7, Image: "dynactl:v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files, err := s.Export(dir)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(files) != 7 || files[3] != "clusterrole-dynactl-backup-dynamo.yaml" || files[6] != "dynamo-cronjob-dynactl-backup.yaml" {
+		t.Errorf("unexpected files %v", files)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "dynamo-cronjob-dynactl-backup.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "creationTimestamp") {
+		t.Errorf("expected pod template timestamps to be dropped:\n%s", data)
+	}
+}
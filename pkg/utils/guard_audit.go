@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Audit finding severities, from most to least important
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// AuditFinding is a single best-practice violation found on a workload
+type AuditFinding struct {
+	Severity string
+	Kind     string
+	Workload string
+	Check    string
+	Message  string
+}
+
+// SeverityRank orders severities so that higher values are more severe
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AuditWorkloads checks Deployments and StatefulSets in a namespace against Guard best practices
+// and returns findings ordered by severity
+func (kc *KubernetesChecker) AuditWorkloads(ctx context.Context, namespace, selector string) ([]AuditFinding, error) {
+	pdbList, err := kc.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodDisruptionBudgets in %s: %v", namespace, err)
+	}
+
+	deployments, err := kc.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments in %s: %v", namespace, err)
+	}
+	statefulSets, err := kc.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets in %s: %v", namespace, err)
+	}
+
+	var findings []AuditFinding
+	for _, d := range deployments.Items {
+		findings = append(findings, AuditPodTemplate(WorkloadKindDeployment, d.Name, replicasOrDefault(d.Spec.Replicas), d.Spec.Template, pdbList.Items)...)
+	}
+	for _, s := range statefulSets.Items {
+		findings = append(findings, AuditPodTemplate(WorkloadKindStatefulSet, s.Name, replicasOrDefault(s.Spec.Replicas), s.Spec.Template, pdbList.Items)...)
+	}
+
+	SortAuditFindings(findings)
+	return findings, nil
+}
+
+// AuditPodTemplate evaluates a single workload's pod template and replica count
+func AuditPodTemplate(kind, name string, replicas int32, tmpl corev1.PodTemplateSpec, pdbs []policyv1.PodDisruptionBudget) []AuditFinding {
+	var findings []AuditFinding
+	add := func(severity, check, format string, args ...interface{}) {
+		findings = append(findings, AuditFinding{
+			Severity: severity,
+			Kind:     kind,
+			Workload: name,
+			Check:    check,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	requestsGPU := false
+	for _, c := range tmpl.Spec.Containers {
+		if c.ReadinessProbe == nil {
+			add(SeverityMedium, "readiness-probe", "container %s has no readiness probe; traffic may reach it before the model is loaded", c.Name)
+		}
+		if c.LivenessProbe == nil {
+			add(SeverityLow, "liveness-probe", "container %s has no liveness probe; hung processes will not be restarted", c.Name)
+		}
+
+		var missing []string
+		if _, ok := c.Resources.Limits[corev1.ResourceCPU]; !ok {
+			missing = append(missing, "cpu")
+		}
+		if _, ok := c.Resources.Limits[corev1.ResourceMemory]; !ok {
+			missing = append(missing, "memory")
+		}
+		if len(missing) > 0 {
+			severity := SeverityMedium
+			if slices.Contains(missing, "memory") {
+				severity = SeverityHigh
+			}
+			add(severity, "resource-limits", "container %s has no %s limit", c.Name, strings.Join(missing, "/"))
+		}
+
+		for _, list := range []corev1.ResourceList{c.Resources.Requests, c.Resources.Limits} {
+			for name := range list {
+				if isAcceleratorResource(name) {
+					requestsGPU = true
+				}
+			}
+		}
+	}
+
+	if replicas == 1 {
+		add(SeverityMedium, "single-replica", "runs a single replica; any restart or node drain causes an outage")
+	}
+
+	if replicas > 0 && !hasMatchingPDB(tmpl.Labels, pdbs) {
+		add(SeverityLow, "pod-disruption-budget", "no PodDisruptionBudget covers its pods; node drains may evict all replicas at once")
+	}
+
+	if requestsGPU {
+		spec := tmpl.Spec
+		if len(spec.NodeSelector) == 0 && spec.Affinity == nil {
+			add(SeverityMedium, "gpu-placement", "requests GPUs without a nodeSelector or affinity; scheduling relies on GPU capacity alone")
+		}
+		if len(spec.Tolerations) == 0 {
+			add(SeverityLow, "gpu-tolerations", "requests GPUs but has no tolerations; it cannot land on tainted GPU node pools")
+		}
+	}
+
+	return findings
+}
+
+// SortAuditFindings orders findings by severity, then workload, then check
+func SortAuditFindings(findings []AuditFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		ri, rj := SeverityRank(findings[i].Severity), SeverityRank(findings[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		if findings[i].Workload != findings[j].Workload {
+			return findings[i].Workload < findings[j].Workload
+		}
+		return findings[i].Check < findings[j].Check
+	})
+}
+
+func hasMatchingPDB(podLabels map[string]string, pdbs []policyv1.PodDisruptionBudget) bool {
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSeverityRank(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     int
+	}{
+		{SeverityHigh, 3},
+		{SeverityMedium, 2},
+		{SeverityLow, 1},
+		{"critical", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := SeverityRank(tt.severity); got != tt.want {
+			t.Errorf("SeverityRank(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestSortAuditFindings(t *testing.T) {
+	findings := []AuditFinding{
+		{Severity: SeverityLow, Workload: "api", Check: "liveness-probe"},
+		{Severity: SeverityHigh, Workload: "worker", Check: "resource-limits"},
+		{Severity: SeverityMedium, Workload: "worker", Check: "single-replica"},
+		{Severity: SeverityMedium, Workload: "api", Check: "single-replica"},
+		{Severity: SeverityMedium, Workload: "api", Check: "readiness-probe"},
+	}
+	SortAuditFindings(findings)
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity+"/"+f.Workload+"/"+f.Check)
+	}
+	want := []string{
+		"high/worker/resource-limits",
+		"medium/api/readiness-probe",
+		"medium/api/single-replica",
+		"medium/worker/single-replica",
+		"low/api/liveness-probe",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortAuditFindings order:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestHasMatchingPDB(t *testing.T) {
+	pdb := func(selector *metav1.LabelSelector) policyv1.PodDisruptionBudget {
+		return policyv1.PodDisruptionBudget{Spec: policyv1.PodDisruptionBudgetSpec{Selector: selector}}
+	}
+	podLabels := map[string]string{"app": "guard", "tier": "worker"}
+
+	tests := []struct {
+		name string
+		pdbs []policyv1.PodDisruptionBudget
+		want bool
+	}{
+		{name: "no PDBs", want: false},
+		{name: "matching labels", pdbs: []policyv1.PodDisruptionBudget{pdb(&metav1.LabelSelector{MatchLabels: map[string]string{"app": "guard"}})}, want: true},
+		{name: "other app", pdbs: []policyv1.PodDisruptionBudget{pdb(&metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}})}, want: false},
+		{
+			name: "match expression",
+			pdbs: []policyv1.PodDisruptionBudget{pdb(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"worker", "gpu"}},
+			}})},
+			want: true,
+		},
+		// An empty selector matches every pod, which is almost never intended, so it is not counted
+		{name: "empty selector", pdbs: []policyv1.PodDisruptionBudget{pdb(&metav1.LabelSelector{})}, want: false},
+		{
+			name: "invalid selector",
+			pdbs: []policyv1.PodDisruptionBudget{pdb(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: "Bogus"},
+			}})},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		if got := hasMatchingPDB(podLabels, tt.pdbs); got != tt.want {
+			t.Errorf("%s: hasMatchingPDB = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuditPodTemplate(t *testing.T) {
+	probe := &corev1.Probe{}
+	limits := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	labels := map[string]string{"app": "guard"}
+	pdbs := []policyv1.PodDisruptionBudget{{Spec: policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}}}}
+	template := func(c corev1.Container, mutate func(*corev1.PodSpec)) corev1.PodTemplateSpec {
+		tmpl := corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{c}},
+		}
+		if mutate != nil {
+			mutate(&tmpl.Spec)
+		}
+		return tmpl
+	}
+	healthy := corev1.Container{Name: "main", ReadinessProbe: probe, LivenessProbe: probe, Resources: corev1.ResourceRequirements{Limits: limits}}
+	gpu := healthy
+	gpu.Resources = corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+		"nvidia.com/gpu":      resource.MustParse("1"),
+	}}
+
+	tests := []struct {
+		name      string
+		replicas  int32
+		container corev1.Container
+		mutate    func(*corev1.PodSpec)
+		pdbs      []policyv1.PodDisruptionBudget
+		want      map[string]string
+	}{
+		{name: "healthy", replicas: 2, container: healthy, pdbs: pdbs, want: map[string]string{}},
+		{
+			name:      "no probes or limits",
+			replicas:  2,
+			container: corev1.Container{Name: "main"},
+			pdbs:      pdbs,
+			want:      map[string]string{"readiness-probe": SeverityMedium, "liveness-probe": SeverityLow, "resource-limits": SeverityHigh},
+		},
+		{
+			name:      "cpu limit missing",
+			replicas:  2,
+			container: corev1.Container{Name: "main", ReadinessProbe: probe, LivenessProbe: probe, Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}},
+			pdbs:      pdbs,
+			want:      map[string]string{"resource-limits": SeverityMedium},
+		},
+		{
+			name:      "single replica without PDB",
+			replicas:  1,
+			container: healthy,
+			want:      map[string]string{"single-replica": SeverityMedium, "pod-disruption-budget": SeverityLow},
+		},
+		{name: "scaled to zero", replicas: 0, container: healthy, want: map[string]string{}},
+		{
+			name:      "GPU without placement",
+			replicas:  2,
+			container: gpu,
+			pdbs:      pdbs,
+			want:      map[string]string{"gpu-placement": SeverityMedium, "gpu-tolerations": SeverityLow},
+		},
+		{
+			name:      "GPU with placement",
+			replicas:  2,
+			container: gpu,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.NodeSelector = map[string]string{"nvidia.com/gpu.present": "true"}
+				spec.Tolerations = []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}
+			},
+			pdbs: pdbs,
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		findings := AuditPodTemplate(WorkloadKindDeployment, "guard", tt.replicas, template(tt.container, tt.mutate), tt.pdbs)
+		got := map[string]string{}
+		for _, f := range findings {
+			if f.Kind != WorkloadKindDeployment || f.Workload != "guard" || f.Message == "" {
+				t.Errorf("%s: finding not attributed to the workload: %+v", tt.name, f)
+			}
+			got[f.Check] = f.Severity
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: findings = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxScalingEvents caps the number of recent scaling events reported per autoscaler
+const maxScalingEvents = 3
+
+var scaledObjectGVR = schema.GroupVersionResource{Group: "keda.sh", Version: "v1alpha1", Resource: "scaledobjects"}
+
+// AutoscalerMetric is a single scaling metric with its current and target values
+type AutoscalerMetric struct {
+	Name    string
+	Current string
+	Target  string
+}
+
+// AutoscalerSummary describes an HPA or KEDA ScaledObject and the workload it scales
+type AutoscalerSummary struct {
+	Kind            string
+	Name            string
+	Target          string
+	MinReplicas     int32
+	MaxReplicas     int32
+	CurrentReplicas int32
+	DesiredReplicas int32
+	Metrics         []AutoscalerMetric
+	Events          []string
+	Warnings        []string
+}
+
+// ListAutoscalers returns HPAs and KEDA ScaledObjects in a namespace. HPAs generated by KEDA are
+// reported through their ScaledObject.
+func (kc *KubernetesChecker) ListAutoscalers(ctx context.Context, namespace string) ([]AutoscalerSummary, error) {
+	hpas, err := kc.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HorizontalPodAutoscalers in %s: %v", namespace, err)
+	}
+
+	kedaHPAs := map[string]autoscalingv2.HorizontalPodAutoscaler{}
+	var summaries []AutoscalerSummary
+	for _, hpa := range hpas.Items {
+		if owner := scaledObjectOwner(hpa.OwnerReferences); owner != "" {
+			kedaHPAs[owner] = hpa
+			continue
+		}
+		summaries = append(summaries, kc.summarizeHPA(ctx, namespace, hpa))
+	}
+
+	scaledObjects, _, err := kc.listCustomResources(ctx, scaledObjectGVR, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, so := range scaledObjects {
+		summary := summarizeScaledObject(so)
+		if hpa, ok := kedaHPAs[so.GetName()]; ok {
+			summary.CurrentReplicas = hpa.Status.CurrentReplicas
+			summary.DesiredReplicas = hpa.Status.DesiredReplicas
+			summary.Events = kc.recentScalingEvents(ctx, namespace, hpa.Name)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Target < summaries[j].Target
+	})
+	return summaries, nil
+}
+
+func (kc *KubernetesChecker) summarizeHPA(ctx context.Context, namespace string, hpa autoscalingv2.HorizontalPodAutoscaler) AutoscalerSummary {
+	ref := hpa.Spec.ScaleTargetRef
+	summary := AutoscalerSummary{
+		Kind:            "HorizontalPodAutoscaler",
+		Name:            hpa.Name,
+		Target:          ref.Kind + "/" + ref.Name,
+		MinReplicas:     replicasOrDefault(hpa.Spec.MinReplicas),
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+
+	var resourceTargets []corev1.ResourceName
+	for _, m := range hpa.Spec.Metrics {
+		metric := AutoscalerMetric{Name: metricSpecName(m), Target: metricTargetString(m), Current: "<unknown>"}
+		for _, cur := range hpa.Status.CurrentMetrics {
+			if metricStatusName(cur) == metric.Name {
+				metric.Current = metricStatusString(cur)
+			}
+		}
+		summary.Metrics = append(summary.Metrics, metric)
+
+		if m.Type == autoscalingv2.ResourceMetricSourceType && m.Resource != nil &&
+			m.Resource.Target.Type == autoscalingv2.UtilizationMetricType {
+			resourceTargets = append(resourceTargets, m.Resource.Name)
+		}
+	}
+
+	if summary.MinReplicas == summary.MaxReplicas {
+		summary.Warnings = append(summary.Warnings, "minReplicas equals maxReplicas; the autoscaler can never scale")
+	}
+
+	if len(resourceTargets) > 0 {
+		containers, err := kc.scaleTargetContainers(ctx, namespace, ref)
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("could not inspect scale target: %v", err))
+		} else {
+			summary.Warnings = append(summary.Warnings, utilizationWarnings(containers, resourceTargets)...)
+		}
+	}
+
+	summary.Events = kc.recentScalingEvents(ctx, namespace, hpa.Name)
+	return summary
+}
+
+// utilizationWarnings flags utilization targets that cannot be computed or are misleading given the
+// containers' requests and limits
+func utilizationWarnings(containers []corev1.Container, resources []corev1.ResourceName) []string {
+	var warnings []string
+	for _, res := range resources {
+		for _, c := range containers {
+			req, hasReq := c.Resources.Requests[res]
+			if !hasReq || req.IsZero() {
+				warnings = append(warnings, fmt.Sprintf("container %s has no %s request; %s utilization cannot be computed", c.Name, res, res))
+				continue
+			}
+			if lim, ok := c.Resources.Limits[res]; ok && lim.Cmp(req) > 0 {
+				ratio := float64(lim.MilliValue()) / float64(req.MilliValue())
+				if ratio >= 4 {
+					warnings = append(warnings, fmt.Sprintf("container %s %s limit is %.0fx its request; utilization above 100%% is normal and targets may never be reached", c.Name, res, ratio))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+func (kc *KubernetesChecker) scaleTargetContainers(ctx context.Context, namespace string, ref autoscalingv2.CrossVersionObjectReference) ([]corev1.Container, error) {
+	switch ref.Kind {
+	case "Deployment":
+		d, err := kc.clientset.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return d.Spec.Template.Spec.Containers, nil
+	case "StatefulSet":
+		s, err := kc.clientset.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return s.Spec.Template.Spec.Containers, nil
+	default:
+		return nil, fmt.Errorf("unsupported scale target kind %s", ref.Kind)
+	}
+}
+
+// recentScalingEvents returns the latest rescale events for an HPA, newest first
+func (kc *KubernetesChecker) recentScalingEvents(ctx context.Context, namespace, hpaName string) []string {
+	events, err := kc.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=HorizontalPodAutoscaler,involvedObject.name=" + hpaName,
+	})
+	if err != nil {
+		LogDebug("Failed to list events for HPA %s: %v", hpaName, err)
+		return nil
+	}
+
+	items := events.Items
+	sort.Slice(items, func(i, j int) bool {
+		return eventTime(items[i]).After(eventTime(items[j]))
+	})
+
+	var result []string
+	for _, e := range items {
+		if len(result) == maxScalingEvents {
+			break
+		}
+		result = append(result, fmt.Sprintf("%s %s: %s", eventTime(e).Format("2006-01-02 15:04:05"), e.Reason, e.Message))
+	}
+	return result
+}
+
+func summarizeScaledObject(so unstructured.Unstructured) AutoscalerSummary {
+	kind, _, _ := unstructured.NestedString(so.Object, "spec", "scaleTargetRef", "kind")
+	if kind == "" {
+		kind = "Deployment"
+	}
+	name, _, _ := unstructured.NestedString(so.Object, "spec", "scaleTargetRef", "name")
+	minReplicas, found, _ := unstructured.NestedInt64(so.Object, "spec", "minReplicaCount")
+	if !found {
+		minReplicas = 0
+	}
+	maxReplicas, found, _ := unstructured.NestedInt64(so.Object, "spec", "maxReplicaCount")
+	if !found {
+		maxReplicas = 100
+	}
+
+	summary := AutoscalerSummary{
+		Kind:        "ScaledObject",
+		Name:        so.GetName(),
+		Target:      kind + "/" + name,
+		MinReplicas: int32(minReplicas),
+		MaxReplicas: int32(maxReplicas),
+	}
+
+	triggers, _, _ := unstructured.NestedSlice(so.Object, "spec", "triggers")
+	for _, t := range triggers {
+		trigger, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		triggerType, _, _ := unstructured.NestedString(trigger, "type")
+		metadata, _, _ := unstructured.NestedStringMap(trigger, "metadata")
+		var parts []string
+		for _, key := range []string{"value", "threshold", "targetValue", "queueLength", "lagThreshold"} {
+			if v := metadata[key]; v != "" {
+				parts = append(parts, key+"="+v)
+			}
+		}
+		summary.Metrics = append(summary.Metrics, AutoscalerMetric{
+			Name:    triggerType,
+			Target:  strings.Join(parts, ","),
+			Current: "<see HPA>",
+		})
+	}
+
+	if summary.MinReplicas == summary.MaxReplicas {
+		summary.Warnings = append(summary.Warnings, "minReplicaCount equals maxReplicaCount; the autoscaler can never scale")
+	}
+	return summary
+}
+
+func scaledObjectOwner(refs []metav1.OwnerReference) string {
+	for _, ref := range refs {
+		if ref.Kind == "ScaledObject" {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+func metricSpecName(m autoscalingv2.MetricSpec) string {
+	switch {
+	case m.Resource != nil:
+		return string(m.Resource.Name)
+	case m.ContainerResource != nil:
+		return m.ContainerResource.Container + "/" + string(m.ContainerResource.Name)
+	case m.Pods != nil:
+		return m.Pods.Metric.Name
+	case m.Object != nil:
+		return m.Object.Metric.Name
+	case m.External != nil:
+		return m.External.Metric.Name
+	}
+	return string(m.Type)
+}
+
+func metricStatusName(m autoscalingv2.MetricStatus) string {
+	switch {
+	case m.Resource != nil:
+		return string(m.Resource.Name)
+	case m.ContainerResource != nil:
+		return m.ContainerResource.Container + "/" + string(m.ContainerResource.Name)
+	case m.Pods != nil:
+		return m.Pods.Metric.Name
+	case m.Object != nil:
+		return m.Object.Metric.Name
+	case m.External != nil:
+		return m.External.Metric.Name
+	}
+	return string(m.Type)
+}
+
+func metricTargetString(m autoscalingv2.MetricSpec) string {
+	var target autoscalingv2.MetricTarget
+	switch {
+	case m.Resource != nil:
+		target = m.Resource.Target
+	case m.ContainerResource != nil:
+		target = m.ContainerResource.Target
+	case m.Pods != nil:
+		target = m.Pods.Target
+	case m.Object != nil:
+		target = m.Object.Target
+	case m.External != nil:
+		target = m.External.Target
+	}
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return "-"
+}
+
+func metricStatusString(m autoscalingv2.MetricStatus) string {
+	var current autoscalingv2.MetricValueStatus
+	switch {
+	case m.Resource != nil:
+		current = m.Resource.Current
+	case m.ContainerResource != nil:
+		current = m.ContainerResource.Current
+	case m.Pods != nil:
+		current = m.Pods.Current
+	case m.Object != nil:
+		current = m.Object.Current
+	case m.External != nil:
+		current = m.External.Current
+	}
+	switch {
+	case current.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *current.AverageUtilization)
+	case current.AverageValue != nil:
+		return current.AverageValue.String()
+	case current.Value != nil:
+		return current.Value.String()
+	}
+	return "<unknown>"
+}
+
+// eventTime returns the most recent timestamp recorded on an event
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// FilterAutoscalers keeps autoscalers whose scale target name passes the include/exclude patterns
+func FilterAutoscalers(summaries []AutoscalerSummary, include, exclude []string) []AutoscalerSummary {
+	filtered := make([]AutoscalerSummary, 0, len(summaries))
+	for _, s := range summaries {
+		name := s.Target[strings.Index(s.Target, "/")+1:]
+		if len(include) > 0 && !matchesAnyPattern(name, include) {
+			continue
+		}
+		if matchesAnyPattern(name, exclude) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestUtilizationWarnings(t *testing.T) {
+	containers := []corev1.Container{
+		{
+			Name: "server",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			},
+		},
+		{Name: "sidecar"},
+	}
+
+	warnings := utilizationWarnings(containers, []corev1.ResourceName{corev1.ResourceCPU})
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], "server cpu limit is 8x") {
+		t.Errorf("unexpected limit warning: %s", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "sidecar has no cpu request") {
+		t.Errorf("unexpected request warning: %s", warnings[1])
+	}
+}
@@ -0,0 +1,338 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Sections Guard settings are grouped into, in display order
+const (
+	GuardConfigRouting    = "routing"
+	GuardConfigRateLimits = "rate-limits"
+	GuardConfigPolicies   = "policies"
+	GuardConfigOther      = "other"
+)
+
+// GuardConfigSections lists the sections in display order
+var GuardConfigSections = []string{GuardConfigRouting, GuardConfigRateLimits, GuardConfigPolicies, GuardConfigOther}
+
+// DefaultGuardConfigMaps are the ConfigMap name patterns read by `guard config show` when
+// neither --configmaps nor --selector is given
+var DefaultGuardConfigMaps = []string{"*guard*", "*model-routing*", "*rate-limit*", "*policy*", "*policies*"}
+
+// guardConfigKeywords classify a setting by words in its key. Rate limits are checked first since
+// their keys often mention the model or policy they limit.
+var guardConfigKeywords = []struct {
+	section string
+	words   []string
+}{
+	{GuardConfigRateLimits, []string{"rate", "limit", "qps", "rps", "rpm", "tpm", "burst", "quota", "throttl"}},
+	{GuardConfigRouting, []string{"rout", "model", "endpoint", "upstream", "backend", "fallback", "weight"}},
+	{GuardConfigPolicies, []string{"polic", "enable", "disable", "toggle", "feature", "guardrail", "block", "allow"}},
+}
+
+// secretKey matches keys whose values are credentials and are never printed
+var secretKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[-_]?key|credential|private[-_]?key)`)
+
+// redacted replaces the values of keys matching secretKey
+const redacted = "<redacted>"
+
+// GuardConfigEntry is one setting read from a Guard ConfigMap or custom resource
+type GuardConfigEntry struct {
+	Section string
+	// Source is Kind/name of the object the setting was read from
+	Source string
+	// Key is the ConfigMap data key, followed by the dotted path inside it when the value is YAML
+	// or JSON, e.g. config.yaml:routing.default_model
+	Key   string
+	Value string
+}
+
+// GuardConfigSnapshot maps each source to its settings. It is the format of --snapshot output
+// and of the --defaults file `guard config show --diff` compares against.
+type GuardConfigSnapshot map[string]map[string]string
+
+// Diff statuses of a Guard setting compared with the release defaults
+const (
+	GuardConfigChanged = "changed"
+	GuardConfigAdded   = "added"
+	GuardConfigMissing = "missing"
+)
+
+// GuardConfigDiff is a setting that differs from the release defaults
+type GuardConfigDiff struct {
+	GuardConfigEntry
+	Default string `json:",omitempty"`
+	Status  string
+}
+
+// GuardConfigOptions select the objects `guard config show` reads
+type GuardConfigOptions struct {
+	// ConfigMaps are name patterns; empty with no Selector uses DefaultGuardConfigMaps
+	ConfigMaps []string
+	Selector   string
+	// Resources are custom resources whose spec is read, e.g. guardpolicies.example.com/v1
+	Resources []schema.GroupVersionResource
+}
+
+// ParseGuardConfigResource parses a custom resource given as resource.group/version
+func ParseGuardConfigResource(value string) (schema.GroupVersionResource, error) {
+	groupResource, version, ok := strings.Cut(value, "/")
+	resource, group, hasGroup := strings.Cut(groupResource, ".")
+	if !ok || !hasGroup || resource == "" || group == "" || version == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q (use resource.group/version, e.g. guardpolicies.example.com/v1)", value)
+	}
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: resource}, nil
+}
+
+// GetGuardConfig reads the Guard settings in a namespace, sorted by section, source, and key
+func (kc *KubernetesChecker) GetGuardConfig(ctx context.Context, namespace string, opts GuardConfigOptions) ([]GuardConfigEntry, error) {
+	patterns := opts.ConfigMaps
+	if len(patterns) == 0 && opts.Selector == "" {
+		patterns = DefaultGuardConfigMaps
+	}
+	configMaps, err := kc.clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: opts.Selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps in %s: %v", namespace, err)
+	}
+
+	var entries []GuardConfigEntry
+	for _, cm := range configMaps.Items {
+		if len(patterns) > 0 && !matchesAnyPattern(cm.Name, patterns) {
+			continue
+		}
+		settings := map[string]string{}
+		for key, value := range cm.Data {
+			flattenConfigData(key, value, settings)
+		}
+		entries = append(entries, guardConfigEntries("ConfigMap/"+cm.Name, settings)...)
+	}
+
+	for _, gvr := range opts.Resources {
+		items, installed, err := kc.listCustomResources(ctx, gvr, namespace, opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if !installed {
+			LogWarning("%s is not installed in the cluster, skipping", gvr.GroupResource())
+			continue
+		}
+		for _, item := range items {
+			entries = append(entries, guardConfigEntries(item.GetKind()+"/"+item.GetName(), customResourceSettings(item))...)
+		}
+	}
+
+	SortGuardConfig(entries)
+	return entries, nil
+}
+
+// customResourceSettings flattens the spec of a custom resource
+func customResourceSettings(item unstructured.Unstructured) map[string]string {
+	settings := map[string]string{}
+	if spec, ok := item.Object["spec"]; ok {
+		flattenConfigValue("", spec, settings)
+	}
+	return settings
+}
+
+// guardConfigEntries classifies and redacts the settings of one source
+func guardConfigEntries(source string, settings map[string]string) []GuardConfigEntry {
+	entries := make([]GuardConfigEntry, 0, len(settings))
+	for key, value := range settings {
+		if secretKey.MatchString(key) {
+			value = redacted
+		}
+		entries = append(entries, GuardConfigEntry{Section: guardConfigSection(key), Source: source, Key: key, Value: value})
+	}
+	return entries
+}
+
+// guardConfigSection picks the section for a setting from the words in its key
+func guardConfigSection(key string) string {
+	key = strings.ToLower(key)
+	for _, k := range guardConfigKeywords {
+		for _, word := range k.words {
+			if strings.Contains(key, word) {
+				return k.section
+			}
+		}
+	}
+	return GuardConfigOther
+}
+
+// flattenConfigData adds a ConfigMap value to settings, expanding YAML and JSON documents into
+// one setting per leaf; anything else is kept as written
+func flattenConfigData(key, value string, settings map[string]string) {
+	var doc interface{}
+	if looksLikeDocument(key, value) && yaml.Unmarshal([]byte(value), &doc) == nil {
+		switch doc.(type) {
+		case map[string]interface{}, []interface{}:
+			flattenConfigValue(key+":", doc, settings)
+			return
+		}
+	}
+	settings[key] = value
+}
+
+// looksLikeDocument reports whether a ConfigMap value should be parsed as YAML or JSON: its key
+// has a YAML or JSON extension, or it spans lines or opens with a bracket. One-line text such as
+// "Welcome: to Guard" is otherwise valid YAML too.
+func looksLikeDocument(key, value string) bool {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	value = strings.TrimSpace(value)
+	return strings.Contains(value, "\n") || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")
+}
+
+// flattenConfigValue adds one setting per leaf of v, keyed by its dotted path under prefix.
+// Lists of scalars are kept together as a comma-separated value.
+func flattenConfigValue(prefix string, v interface{}, settings map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			settings[strings.TrimSuffix(prefix, ":")] = "{}"
+		}
+		for k, child := range t {
+			flattenConfigValue(joinConfigKey(prefix, k), child, settings)
+		}
+	case []interface{}:
+		if scalars, ok := scalarList(t); ok {
+			settings[strings.TrimSuffix(prefix, ":")] = strings.Join(scalars, ", ")
+			return
+		}
+		for i, item := range t {
+			flattenConfigValue(fmt.Sprintf("%s[%d]", strings.TrimSuffix(prefix, ":"), i), item, settings)
+		}
+	case nil:
+		settings[strings.TrimSuffix(prefix, ":")] = "null"
+	default:
+		settings[strings.TrimSuffix(prefix, ":")] = scalarString(t)
+	}
+}
+
+// scalarList formats a list whose items are all scalars, reporting false for nested lists
+func scalarList(items []interface{}) ([]string, bool) {
+	scalars := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, false
+		}
+		scalars = append(scalars, scalarString(item))
+	}
+	return scalars, true
+}
+
+// scalarString formats a YAML scalar; numbers decode as float64 and are printed without an exponent
+func scalarString(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// joinConfigKey appends a map key to a path; prefixes ending in ":" name a ConfigMap data key
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" || strings.HasSuffix(prefix, ":") {
+		return prefix + key
+	}
+	return prefix + "." + key
+}
+
+// SortGuardConfig orders entries by section, then source, then key
+func SortGuardConfig(entries []GuardConfigEntry) {
+	sort.Slice(entries, func(i, j int) bool { return guardConfigLess(entries[i], entries[j]) })
+}
+
+func guardConfigLess(a, b GuardConfigEntry) bool {
+	if a.Section != b.Section {
+		return slices.Index(GuardConfigSections, a.Section) < slices.Index(GuardConfigSections, b.Section)
+	}
+	if a.Source != b.Source {
+		return a.Source < b.Source
+	}
+	return a.Key < b.Key
+}
+
+// GuardConfigSnapshotOf converts entries to the snapshot format used for release defaults
+func GuardConfigSnapshotOf(entries []GuardConfigEntry) GuardConfigSnapshot {
+	snapshot := GuardConfigSnapshot{}
+	for _, e := range entries {
+		if snapshot[e.Source] == nil {
+			snapshot[e.Source] = map[string]string{}
+		}
+		snapshot[e.Source][e.Key] = e.Value
+	}
+	return snapshot
+}
+
+// LoadGuardConfigSnapshot reads release defaults written by `guard config show --snapshot`
+func LoadGuardConfigSnapshot(path string) (GuardConfigSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guard config defaults: %w", err)
+	}
+	var snapshot GuardConfigSnapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse guard config defaults %s: %w", path, err)
+	}
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("guard config defaults %s list no settings", path)
+	}
+	return snapshot, nil
+}
+
+// DiffGuardConfig compares settings against release defaults. Settings not in the defaults are
+// added; defaults of sources that were read but lack the setting are missing. Sources absent from
+// the cluster entirely are not reported, so defaults can cover optional components. Redacted
+// values are never compared.
+func DiffGuardConfig(entries []GuardConfigEntry, defaults GuardConfigSnapshot) []GuardConfigDiff {
+	var diffs []GuardConfigDiff
+	seen := map[string]map[string]bool{}
+	for _, e := range entries {
+		if seen[e.Source] == nil {
+			seen[e.Source] = map[string]bool{}
+		}
+		seen[e.Source][e.Key] = true
+
+		def, ok := defaults[e.Source][e.Key]
+		switch {
+		case !ok:
+			diffs = append(diffs, GuardConfigDiff{GuardConfigEntry: e, Status: GuardConfigAdded})
+		case e.Value != def && e.Value != redacted:
+			diffs = append(diffs, GuardConfigDiff{GuardConfigEntry: e, Default: def, Status: GuardConfigChanged})
+		}
+	}
+
+	for source, settings := range defaults {
+		if seen[source] == nil {
+			continue
+		}
+		for key, def := range settings {
+			if !seen[source][key] {
+				diffs = append(diffs, GuardConfigDiff{
+					GuardConfigEntry: GuardConfigEntry{Section: guardConfigSection(key), Source: source, Key: key},
+					Default:          def,
+					Status:           GuardConfigMissing,
+				})
+			}
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return guardConfigLess(diffs[i].GuardConfigEntry, diffs[j].GuardConfigEntry) })
+	return diffs
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFlattenConfigData(t *testing.T) {
+	settings := map[string]string{}
+	flattenConfigData("config.yaml", `
+routing:
+  default_model: guard-llama-8b
+  models: [guard-llama-8b, guard-small]
+  weights:
+    - model: guard-llama-8b
+      weight: 90
+limits:
+  max_tokens: 1000000
+policies: {}
+`, settings)
+	flattenConfigData("settings.json", `{"pii": {"enabled": true}, "threshold": 0.75}`, settings)
+	flattenConfigData("LOG_LEVEL", "info", settings)
+	flattenConfigData("banner.txt", "Welcome: to Guard", settings)
+
+	want := map[string]string{
+		"config.yaml:routing.default_model":     "guard-llama-8b",
+		"config.yaml:routing.models":            "guard-llama-8b, guard-small",
+		"config.yaml:routing.weights[0].model":  "guard-llama-8b",
+		"config.yaml:routing.weights[0].weight": "90",
+		"config.yaml:limits.max_tokens":         "1000000",
+		"config.yaml:policies":                  "{}",
+		"settings.json:pii.enabled":             "true",
+		"settings.json:threshold":               "0.75",
+		"LOG_LEVEL":                             "info",
+		"banner.txt":                            "Welcome: to Guard",
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("flattenConfigData() =\n%v\nwant\n%v", settings, want)
+	}
+}
+
+func TestGuardConfigEntries(t *testing.T) {
+	entries := guardConfigEntries("ConfigMap/guard", map[string]string{
+		"config.yaml:routing.default_model":          "guard-llama-8b",
+		"config.yaml:models.guard-llama-8b.rpm":      "600",
+		"config.yaml:policies.pii_detection.enabled": "true",
+		"config.yaml:upstream.api_key":               "sk-123",
+		"LOG_LEVEL":                                  "info",
+	})
+	SortGuardConfig(entries)
+
+	var got [][2]string
+	for _, e := range entries {
+		got = append(got, [2]string{e.Section, e.Key + "=" + e.Value})
+	}
+	want := [][2]string{
+		{GuardConfigRouting, "config.yaml:routing.default_model=guard-llama-8b"},
+		{GuardConfigRouting, "config.yaml:upstream.api_key=<redacted>"},
+		{GuardConfigRateLimits, "config.yaml:models.guard-llama-8b.rpm=600"},
+		{GuardConfigPolicies, "config.yaml:policies.pii_detection.enabled=true"},
+		{GuardConfigOther, "LOG_LEVEL=info"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("guardConfigEntries() = %v, want %v", got, want)
+	}
+}
+
+func TestParseGuardConfigResource(t *testing.T) {
+	gvr, err := ParseGuardConfigResource("guardpolicies.example.com/v1alpha1")
+	if err != nil {
+		t.Fatalf("ParseGuardConfigResource returned error: %v", err)
+	}
+	if want := (schema.GroupVersionResource{Group: "example.com", Version: "v1alpha1", Resource: "guardpolicies"}); gvr != want {
+		t.Errorf("ParseGuardConfigResource() = %v, want %v", gvr, want)
+	}
+	for _, value := range []string{"guardpolicies", "guardpolicies/v1", "guardpolicies.example.com"} {
+		if _, err := ParseGuardConfigResource(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestDiffGuardConfig(t *testing.T) {
+	defaults, err := LoadGuardConfigSnapshot("../../examples/guard-config-defaults.yaml")
+	if err != nil {
+		t.Fatalf("LoadGuardConfigSnapshot returned error: %v", err)
+	}
+	source := "ConfigMap/dynamoai-guard-config"
+	entries := []GuardConfigEntry{
+		{Section: GuardConfigRouting, Source: source, Key: "config.yaml:routing.default_model", Value: "guard-llama-70b"},
+		{Section: GuardConfigRouting, Source: source, Key: "config.yaml:routing.fallback_model", Value: "guard-small"},
+		{Section: GuardConfigRouting, Source: source, Key: "config.yaml:routing.timeout_seconds", Value: "30"},
+		{Section: GuardConfigPolicies, Source: source, Key: "config.yaml:policies.pii_detection.enabled", Value: "true"},
+		{Section: GuardConfigPolicies, Source: source, Key: "config.yaml:policies.toxicity.threshold", Value: "0.8"},
+		{Section: GuardConfigOther, Source: source, Key: "config.yaml:upstream.token", Value: redacted},
+	}
+
+	var got []string
+	for _, d := range DiffGuardConfig(entries, defaults) {
+		got = append(got, d.Status+" "+d.Key+" "+d.Value+" "+d.Default)
+	}
+	// The rate limits ConfigMap was not read at all, so its defaults are not reported missing
+	want := []string{
+		"changed config.yaml:routing.default_model guard-llama-70b guard-llama-8b",
+		"missing config.yaml:policies.jailbreak_detection.enabled  true",
+		"added config.yaml:upstream.token <redacted> ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffGuardConfig() = %q, want %q", got, want)
+	}
+}
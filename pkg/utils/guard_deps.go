@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Secret check statuses
+const (
+	SecretOK         = "ok"
+	SecretMissing    = "missing"
+	SecretKeyMissing = "key-missing"
+	SecretEmpty      = "empty"
+	SecretUnreadable = "unreadable"
+)
+
+// DependencySkipped marks a connection that was not probed because a Secret it reads is broken
+const DependencySkipped = "skipped"
+
+// GuardDepsConfig lists the Secrets Guard reads and the connections made with them, read from
+// guard-deps.yaml
+type GuardDepsConfig struct {
+	Image        string        `json:"image,omitempty"`
+	Secrets      []GuardSecret `json:"secrets,omitempty"`
+	Dependencies []Dependency  `json:"dependencies,omitempty"`
+}
+
+// GuardSecret is a Secret Guard needs, with the keys that must be present and non-empty
+type GuardSecret struct {
+	Integration string   `json:"integration,omitempty"`
+	Name        string   `json:"name"`
+	Keys        []string `json:"keys"`
+}
+
+// SecretCheckResult is the outcome for one Secret key. Key is empty when the whole Secret is
+// missing or unreadable.
+type SecretCheckResult struct {
+	Integration string `json:"integration"`
+	Secret      string `json:"secret"`
+	Key         string `json:"key,omitempty"`
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+}
+
+// GuardIntegrationStatus groups the Secret and connection checks for one integration, such as
+// the database or the object store
+type GuardIntegrationStatus struct {
+	Integration string              `json:"integration"`
+	Healthy     bool                `json:"healthy"`
+	Secrets     []SecretCheckResult `json:"secrets"`
+	Connections []DependencyResult  `json:"connections"`
+}
+
+// LoadGuardDepsConfig reads and validates a Guard dependency config file
+func LoadGuardDepsConfig(path string) (*GuardDepsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Guard dependency config: %w", err)
+	}
+
+	var cfg GuardDepsConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse Guard dependency config %s: %w", path, err)
+	}
+	if len(cfg.Secrets) == 0 && len(cfg.Dependencies) == 0 {
+		return nil, fmt.Errorf("no secrets or dependencies listed in %s", path)
+	}
+	for i, s := range cfg.Secrets {
+		if s.Name == "" {
+			return nil, fmt.Errorf("secret %d in %s: name is required", i+1, path)
+		}
+		if len(s.Keys) == 0 {
+			return nil, fmt.Errorf("secret %s: keys are required", s.Name)
+		}
+		if s.Integration == "" {
+			cfg.Secrets[i].Integration = s.Name
+		}
+	}
+	if err := validateDependencies(cfg.Dependencies); err != nil {
+		return nil, err
+	}
+	for i, d := range cfg.Dependencies {
+		if d.Integration == "" {
+			cfg.Dependencies[i].Integration = d.Name
+		}
+	}
+	return &cfg, nil
+}
+
+// guardSecretRequirements lists the listed Secrets plus those the dependencies read, merging keys
+// of the same Secret. A Secret is attributed to the first integration that needs it.
+func guardSecretRequirements(cfg *GuardDepsConfig) []GuardSecret {
+	var reqs []GuardSecret
+	index := map[string]int{}
+	add := func(integration, name string, keys ...string) {
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(reqs)
+			reqs = append(reqs, GuardSecret{Integration: integration, Name: name})
+			i = len(reqs) - 1
+		}
+		for _, key := range keys {
+			if !containsString(reqs[i].Keys, key) {
+				reqs[i].Keys = append(reqs[i].Keys, key)
+			}
+		}
+	}
+	for _, s := range cfg.Secrets {
+		add(s.Integration, s.Name, s.Keys...)
+	}
+	for _, d := range cfg.Dependencies {
+		for _, ref := range d.secretRefs() {
+			add(d.Integration, ref.Name, ref.Key)
+		}
+	}
+	return reqs
+}
+
+// checkSecretKeys reports on each required key of a Secret that was read successfully
+func checkSecretKeys(req GuardSecret, secret *corev1.Secret) []SecretCheckResult {
+	results := make([]SecretCheckResult, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		r := SecretCheckResult{Integration: req.Integration, Secret: req.Name, Key: key, Status: SecretOK}
+		value, ok := secret.Data[key]
+		switch {
+		case !ok:
+			r.Status, r.Message = SecretKeyMissing, "key not present in the Secret"
+		case len(bytes.TrimSpace(value)) == 0:
+			r.Status, r.Message = SecretEmpty, "value is empty"
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// CheckGuardSecrets verifies the Secrets exist in the namespace and their keys are non-empty.
+// Values are never returned.
+func (kc *KubernetesChecker) CheckGuardSecrets(ctx context.Context, namespace string, reqs []GuardSecret) []SecretCheckResult {
+	var results []SecretCheckResult
+	for _, req := range reqs {
+		secret, err := kc.clientset.CoreV1().Secrets(namespace).Get(ctx, req.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			results = append(results, SecretCheckResult{Integration: req.Integration, Secret: req.Name, Status: SecretMissing,
+				Message: fmt.Sprintf("Secret not found in %s", namespace)})
+		case err != nil:
+			results = append(results, SecretCheckResult{Integration: req.Integration, Secret: req.Name, Status: SecretUnreadable,
+				Message: err.Error()})
+		default:
+			results = append(results, checkSecretKeys(req, secret)...)
+		}
+	}
+	return results
+}
+
+// CheckGuardDependencies checks the Secrets Guard needs, then probes each connection from a pod in
+// the namespace. Connections that read a missing or empty Secret key are skipped rather than left
+// to fail the probe pod, so the broken Secret is reported instead.
+func (kc *KubernetesChecker) CheckGuardDependencies(ctx context.Context, namespace string, cfg *GuardDepsConfig, timeout time.Duration) ([]GuardIntegrationStatus, error) {
+	secrets := kc.CheckGuardSecrets(ctx, namespace, guardSecretRequirements(cfg))
+	probe, skipped := splitProbeableDependencies(cfg.Dependencies, secrets)
+
+	var connections []DependencyResult
+	if len(probe) > 0 {
+		var err error
+		connections, err = kc.CheckDependencies(ctx, namespace, &DependencyConfig{Image: cfg.Image, Dependencies: probe}, timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return groupGuardIntegrations(cfg, secrets, append(connections, skipped...)), nil
+}
+
+// splitProbeableDependencies separates dependencies whose Secret keys all checked out from those
+// that would fail to start the probe pod, which get a skipped result naming the broken key
+func splitProbeableDependencies(deps []Dependency, secrets []SecretCheckResult) ([]Dependency, []DependencyResult) {
+	// A result without a key covers the whole Secret
+	broken := map[SecretKeyRef]string{}
+	for _, s := range secrets {
+		if s.Status == SecretOK {
+			continue
+		}
+		ref := s.Secret
+		if s.Key != "" {
+			ref += "/" + s.Key
+		}
+		broken[SecretKeyRef{Name: s.Secret, Key: s.Key}] = fmt.Sprintf("%s is %s", ref, s.Status)
+	}
+
+	var probe []Dependency
+	var skipped []DependencyResult
+	for _, d := range deps {
+		reason := ""
+		for _, ref := range d.secretRefs() {
+			if msg, ok := broken[ref]; ok {
+				reason = msg
+			} else if msg, ok := broken[SecretKeyRef{Name: ref.Name}]; ok {
+				reason = msg
+			}
+			if reason != "" {
+				break
+			}
+		}
+		if reason == "" {
+			probe = append(probe, d)
+			continue
+		}
+		skipped = append(skipped, DependencyResult{Name: d.Name, Type: d.Type, Target: dependencyTarget(d), Status: DependencySkipped,
+			Message: "not probed: Secret " + reason})
+	}
+	return probe, skipped
+}
+
+// groupGuardIntegrations collects results by integration, in the order integrations first appear
+// in the config, and marks an integration healthy when every check passed
+func groupGuardIntegrations(cfg *GuardDepsConfig, secrets []SecretCheckResult, connections []DependencyResult) []GuardIntegrationStatus {
+	integrationOf := map[string]string{}
+	var order []string
+	seen := map[string]bool{}
+	note := func(integration string) {
+		if !seen[integration] {
+			seen[integration] = true
+			order = append(order, integration)
+		}
+	}
+	for _, s := range cfg.Secrets {
+		note(s.Integration)
+	}
+	for _, d := range cfg.Dependencies {
+		note(d.Integration)
+		integrationOf[d.Name] = d.Integration
+	}
+
+	byName := map[string]*GuardIntegrationStatus{}
+	statuses := make([]GuardIntegrationStatus, len(order))
+	for i, name := range order {
+		statuses[i] = GuardIntegrationStatus{Integration: name, Healthy: true, Secrets: []SecretCheckResult{}, Connections: []DependencyResult{}}
+		byName[name] = &statuses[i]
+	}
+	for _, s := range secrets {
+		st := byName[s.Integration]
+		st.Secrets = append(st.Secrets, s)
+		st.Healthy = st.Healthy && s.Status == SecretOK
+	}
+
+	position := map[string]int{}
+	for i, d := range cfg.Dependencies {
+		position[d.Name] = i
+	}
+	sort.SliceStable(connections, func(i, j int) bool { return position[connections[i].Name] < position[connections[j].Name] })
+	for _, c := range connections {
+		st := byName[integrationOf[c.Name]]
+		st.Connections = append(st.Connections, c)
+		st.Healthy = st.Healthy && c.Status == DependencyOK
+	}
+	return statuses
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os/exec"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestLoadGuardDepsConfig(t *testing.T) {
+	cfg, err := LoadGuardDepsConfig("../../examples/guard-deps.yaml")
+	if err != nil {
+		t.Fatalf("LoadGuardDepsConfig returned error: %v", err)
+	}
+	if len(cfg.Secrets) != 3 || len(cfg.Dependencies) != 4 {
+		t.Fatalf("expected 3 secrets and 4 dependencies, got %d and %d", len(cfg.Secrets), len(cfg.Dependencies))
+	}
+	if pg := cfg.Dependencies[0]; pg.Port != 5432 || pg.UsernameSecret == nil {
+		t.Errorf("unexpected postgres dependency: %+v", pg)
+	}
+
+	reqs := guardSecretRequirements(cfg)
+	if len(reqs) != 4 {
+		t.Fatalf("expected 4 required secrets, got %+v", reqs)
+	}
+	// Keys the dependencies read are merged into the listed Secret
+	if db := reqs[0]; db.Name != "guard-db-credentials" || len(db.Keys) != 3 {
+		t.Errorf("unexpected database secret requirement: %+v", db)
+	}
+	if redis := reqs[3]; redis.Name != "guard-redis" || redis.Integration != "cache" || redis.Keys[0] != "auth-token" {
+		t.Errorf("unexpected redis secret requirement: %+v", redis)
+	}
+}
+
+func TestCheckSecretKeys(t *testing.T) {
+	req := GuardSecret{Integration: "object-store", Name: "guard-object-store", Keys: []string{"access-key-id", "secret-access-key", "region"}}
+	secret := &corev1.Secret{Data: map[string][]byte{"access-key-id": []byte("AKIA"), "secret-access-key": []byte(" \n")}}
+
+	results := checkSecretKeys(req, secret)
+	want := []string{SecretOK, SecretEmpty, SecretKeyMissing}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: expected %s, got %s", r.Key, want[i], r.Status)
+		}
+	}
+}
+
+func TestSplitProbeableDependencies(t *testing.T) {
+	deps := []Dependency{
+		{Name: "postgres", Type: DependencyPostgres, Host: "db", Port: 5432, Integration: "database",
+			PasswordSecret: &SecretKeyRef{Name: "guard-db", Key: "password"}},
+		{Name: "redis", Type: DependencyRedis, Host: "cache", Port: 6379, Integration: "cache",
+			PasswordSecret: &SecretKeyRef{Name: "guard-redis", Key: "auth-token"}},
+		{Name: "openai", Type: DependencyHTTP, URL: "https://api.example.com", Integration: "model-api",
+			TokenSecret: &SecretKeyRef{Name: "guard-api-tokens", Key: "openai"}},
+	}
+	secrets := []SecretCheckResult{
+		{Integration: "database", Secret: "guard-db", Key: "password", Status: SecretOK},
+		{Integration: "cache", Secret: "guard-redis", Status: SecretMissing},
+		{Integration: "model-api", Secret: "guard-api-tokens", Key: "openai", Status: SecretEmpty},
+	}
+
+	probe, skipped := splitProbeableDependencies(deps, secrets)
+	if len(probe) != 1 || probe[0].Name != "postgres" {
+		t.Fatalf("expected only postgres to be probed, got %+v", probe)
+	}
+	if len(skipped) != 2 || skipped[0].Message != "not probed: Secret guard-redis is missing" ||
+		skipped[1].Message != "not probed: Secret guard-api-tokens/openai is empty" {
+		t.Errorf("unexpected skipped results: %+v", skipped)
+	}
+
+	cfg := &GuardDepsConfig{Dependencies: deps}
+	connections := append([]DependencyResult{{Name: "postgres", Status: DependencyOK}}, skipped...)
+	statuses := groupGuardIntegrations(cfg, secrets, connections)
+	if len(statuses) != 3 || !statuses[0].Healthy || statuses[1].Healthy || statuses[2].Healthy {
+		t.Fatalf("unexpected integration statuses: %+v", statuses)
+	}
+	if statuses[1].Integration != "cache" || len(statuses[1].Secrets) != 1 || len(statuses[1].Connections) != 1 {
+		t.Errorf("unexpected cache status: %+v", statuses[1])
+	}
+}
+
+func TestBuildProbeScriptWithSecrets(t *testing.T) {
+	deps := []Dependency{
+		{Name: "postgres", Type: DependencyPostgres, Host: "db", Port: 5432,
+			UsernameSecret: &SecretKeyRef{Name: "db", Key: "username"}, PasswordSecret: &SecretKeyRef{Name: "db", Key: "password"}},
+		{Name: "openai", Type: DependencyHTTP, URL: "https://api.example.com/v1/models", TokenSecret: &SecretKeyRef{Name: "tokens", Key: "openai"}},
+	}
+
+	pod := buildProbePod("guard", DefaultProbeImage, deps, "")
+	if env := pod.Spec.Containers[0].Env; len(env) != 3 || env[0].Name != "DEP_0_USERNAME" || env[2].Name != "DEP_1_TOKEN" {
+		t.Errorf("unexpected probe pod env: %+v", env)
+	}
+
+	script := buildProbeScript(deps)
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	if out, err := exec.Command(sh, "-n", "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("generated script is not valid shell: %v\n%s\n%s", err, out, script)
+	}
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogTailOptions controls how deployment logs are tailed
+type LogTailOptions struct {
+	Container string
+	Since     time.Duration
+	TailLines int64
+	Follow    bool
+	Grep      string
+}
+
+// ListDeploymentPods returns the pods selected by the given deployment, sorted by name
+func (kc *KubernetesChecker) ListDeploymentPods(ctx context.Context, namespace, deployment string) ([]corev1.Pod, error) {
+	dep, err := kc.clientset.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s in %s: %v", deployment, namespace, err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector on deployment %s: %v", deployment, err)
+	}
+
+	return kc.listPodsBySelector(ctx, namespace, selector.String())
+}
+
+// listPodsBySelector lists pods in a namespace matching a label selector, sorted by name
+func (kc *KubernetesChecker) listPodsBySelector(ctx context.Context, namespace, selector string) ([]corev1.Pod, error) {
+	pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods matching %q in %s: %v", selector, namespace, err)
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	return pods.Items, nil
+}
+
+// TailDeploymentLogs streams logs from every pod of a deployment concurrently, prefixing each
+// line with the pod (and container) it came from. It returns once all streams are closed or
+// the context is canceled.
+func (kc *KubernetesChecker) TailDeploymentLogs(ctx context.Context, namespace, deployment string, opts LogTailOptions, out io.Writer) error {
+	var grep *regexp.Regexp
+	if opts.Grep != "" {
+		re, err := regexp.Compile(opts.Grep)
+		if err != nil {
+			return fmt.Errorf("invalid --grep pattern: %v", err)
+		}
+		grep = re
+	}
+
+	pods, err := kc.ListDeploymentPods(ctx, namespace, deployment)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no pods found for deployment %s in %s", deployment, namespace)
+	}
+
+	type logTarget struct {
+		pod       string
+		container string
+	}
+	var targets []logTarget
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			if opts.Container != "" && c.Name != opts.Container {
+				continue
+			}
+			targets = append(targets, logTarget{pod: pod.Name, container: c.Name})
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("container %s not found in pods of deployment %s", opts.Container, deployment)
+	}
+
+	LogInfo("Tailing logs from %d containers across %d pods", len(targets), len(pods))
+
+	// Only show the container name in the prefix when it is ambiguous
+	multiContainer := len(targets) > len(pods)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]string, 0)
+
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t logTarget) {
+			defer wg.Done()
+
+			prefix := t.pod
+			if multiContainer {
+				prefix = t.pod + "/" + t.container
+			}
+
+			logOpts := &corev1.PodLogOptions{
+				Container: t.container,
+				Follow:    opts.Follow,
+			}
+			if opts.Since > 0 {
+				seconds := int64(opts.Since.Seconds())
+				logOpts.SinceSeconds = &seconds
+			}
+			if opts.TailLines >= 0 {
+				tail := opts.TailLines
+				logOpts.TailLines = &tail
+			}
+
+			stream, err := kc.streamClientset.CoreV1().Pods(namespace).GetLogs(t.pod, logOpts).Stream(ctx)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", prefix, err))
+				mu.Unlock()
+				return
+			}
+			defer stream.Close()
+
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if grep != nil && !grep.MatchString(line) {
+					continue
+				}
+				mu.Lock()
+				fmt.Fprintf(out, "[%s] %s\n", prefix, line)
+				mu.Unlock()
+			}
+			if err := scanner.Err(); err != nil && ctx.Err() == nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", prefix, err))
+				mu.Unlock()
+			}
+		}(t)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		for _, e := range errs {
+			LogWarning("Log stream failed for %s", e)
+		}
+		if len(errs) == len(targets) {
+			return fmt.Errorf("failed to stream logs from any pod of deployment %s", deployment)
+		}
+	}
+	return nil
+}
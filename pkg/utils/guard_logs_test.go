@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func guardWorkerFixtures() *KubernetesChecker {
+	labels := map[string]string{"app": "guard-worker"}
+	labeled := func(pod *corev1.Pod) { pod.Labels = labels }
+	sidecar := func(pod *corev1.Pod) {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "metrics"})
+	}
+	return fakeChecker(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubetest.Namespace, Name: "guard-worker"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		kubetest.Pod(kubetest.Namespace, "guard-worker-b", "gpu-1", labeled, sidecar),
+		kubetest.Pod(kubetest.Namespace, "guard-worker-a", "gpu-1", labeled, sidecar),
+		kubetest.Pod(kubetest.Namespace, "dynamoai-api-0", "general-1"),
+		kubetest.Pod("other", "guard-worker-c", "general-1", labeled),
+	)
+}
+
+func TestListDeploymentPods(t *testing.T) {
+	kc := guardWorkerFixtures()
+	pods, err := kc.ListDeploymentPods(context.Background(), kubetest.Namespace, "guard-worker")
+	if err != nil {
+		t.Fatalf("ListDeploymentPods returned error: %v", err)
+	}
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	if strings.Join(names, ",") != "guard-worker-a,guard-worker-b" {
+		t.Errorf("Expected the deployment's pods in its namespace, sorted, got %v", names)
+	}
+
+	if _, err := kc.ListDeploymentPods(context.Background(), kubetest.Namespace, "missing"); err == nil {
+		t.Error("Expected an error for a missing deployment")
+	}
+}
+
+func TestTailDeploymentLogs(t *testing.T) {
+	kc := guardWorkerFixtures()
+	var out bytes.Buffer
+	if err := kc.TailDeploymentLogs(context.Background(), kubetest.Namespace, "guard-worker", LogTailOptions{TailLines: 10}, &out); err != nil {
+		t.Fatalf("TailDeploymentLogs returned error: %v", err)
+	}
+	// The fake clientset answers every log request with "fake logs"
+	for _, prefix := range []string{"guard-worker-a/main", "guard-worker-a/metrics", "guard-worker-b/main", "guard-worker-b/metrics"} {
+		if !strings.Contains(out.String(), "["+prefix+"] fake logs\n") {
+			t.Errorf("Expected a line prefixed with %s, got:\n%s", prefix, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := kc.TailDeploymentLogs(context.Background(), kubetest.Namespace, "guard-worker", LogTailOptions{Container: "main", TailLines: -1}, &out); err != nil {
+		t.Fatalf("TailDeploymentLogs returned error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "[guard-worker-") || strings.Contains(out.String(), "/main]") {
+		t.Errorf("Expected one line per pod prefixed with the pod alone, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := kc.TailDeploymentLogs(context.Background(), kubetest.Namespace, "guard-worker", LogTailOptions{Grep: "^error"}, &out); err != nil || out.Len() != 0 {
+		t.Errorf("Expected --grep to drop every line, got %q (%v)", out.String(), err)
+	}
+	if err := kc.TailDeploymentLogs(context.Background(), kubetest.Namespace, "guard-worker", LogTailOptions{Container: "proxy"}, &out); err == nil {
+		t.Error("Expected an error for a container the pods do not have")
+	}
+}
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Areas of the HA readiness check
+const (
+	HAAreaNodes        = "nodes"
+	HAAreaWorkloads    = "workloads"
+	HAAreaControlPlane = "control-plane"
+)
+
+// HAFinding is one result of the HA readiness check
+type HAFinding struct {
+	Area    string
+	Subject string
+	Status  string
+	Message string
+}
+
+// HAReadinessResult says whether the deployment can ride out a node drain. A fail means a drain
+// would take something down; warnings are gaps to close before production.
+type HAReadinessResult struct {
+	Namespace string
+	Status    string
+	Findings  []HAFinding
+}
+
+// Verdict is the go/no-go guidance for the result
+func (r *HAReadinessResult) Verdict() string {
+	switch r.Status {
+	case CheckFail:
+		return "NO-GO"
+	case CheckWarn:
+		return "GO with warnings"
+	}
+	return "GO"
+}
+
+// CheckHAReadiness evaluates whether a node drain can be tolerated: at least two schedulable
+// nodes of each instance type the deployment needs, replicas and PodDisruptionBudgets on the
+// namespace's Deployments and StatefulSets, and control-plane redundancy. The required instance
+// types are those given plus the ones the namespace's pods run on.
+func (kc *KubernetesChecker) CheckHAReadiness(ctx context.Context, namespace string, instanceTypes []string) (*HAReadinessResult, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := kc.listPods(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %v", namespace, err)
+	}
+	pdbs, err := kc.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodDisruptionBudgets in %s: %v", namespace, err)
+	}
+	deployments, err := kc.listDeployments(ctx, namespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments in %s: %v", namespace, err)
+	}
+	statefulSets, err := kc.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets in %s: %v", namespace, err)
+	}
+
+	result := &HAReadinessResult{Namespace: namespace, Status: CheckPass}
+	result.Findings = append(result.Findings, nodeRedundancyFindings(nodes, pods, instanceTypes)...)
+	for _, d := range deployments {
+		result.Findings = append(result.Findings, workloadHAFindings(WorkloadKindDeployment, d.Name, replicasOrDefault(d.Spec.Replicas), d.Spec.Selector, pods, pdbs.Items)...)
+	}
+	for _, s := range statefulSets.Items {
+		result.Findings = append(result.Findings, workloadHAFindings(WorkloadKindStatefulSet, s.Name, replicasOrDefault(s.Spec.Replicas), s.Spec.Selector, pods, pdbs.Items)...)
+	}
+	if len(deployments)+len(statefulSets.Items) == 0 {
+		result.Findings = append(result.Findings, HAFinding{Area: HAAreaWorkloads, Subject: namespace, Status: CheckPass, Message: "no Deployments or StatefulSets yet; rerun after install to check replicas and PodDisruptionBudgets"})
+	}
+	result.Findings = append(result.Findings, controlPlaneFindings(nodes)...)
+
+	for _, f := range result.Findings {
+		result.Status = worseStatus(result.Status, f.Status)
+	}
+	return result, nil
+}
+
+// isControlPlaneNode reports whether a node carries a control-plane role label
+func isControlPlaneNode(node corev1.Node) bool {
+	_, cp := node.Labels["node-role.kubernetes.io/control-plane"]
+	_, master := node.Labels["node-role.kubernetes.io/master"]
+	return cp || master
+}
+
+// nodeRedundancyFindings counts ready, schedulable worker nodes per instance type. Each required
+// type needs two, so one can be drained while the other takes its pods. With no required types
+// known, any type with a single node is only a warning.
+func nodeRedundancyFindings(nodes []corev1.Node, pods []corev1.Pod, required []string) []HAFinding {
+	counts := map[string]int{}
+	typeOf := map[string]string{}
+	workers := 0
+	for _, node := range nodes {
+		instanceType := instanceTypeFromLabels(node.Labels)
+		typeOf[node.Name] = instanceType
+		if isControlPlaneNode(node) || node.Spec.Unschedulable || !isNodeReady(&node) {
+			continue
+		}
+		counts[instanceType]++
+		workers++
+	}
+
+	needed := map[string]bool{}
+	for _, t := range required {
+		needed[t] = true
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			// Finished job pods do not need their node type again
+			continue
+		}
+		if t, ok := typeOf[pod.Spec.NodeName]; ok {
+			needed[t] = true
+		}
+	}
+
+	var findings []HAFinding
+	if len(needed) == 0 {
+		if workers < 2 {
+			return []HAFinding{{Area: HAAreaNodes, Subject: "workers", Status: CheckFail, Message: fmt.Sprintf("%d ready schedulable worker node(s); a drain leaves nowhere to reschedule pods", workers)}}
+		}
+		for _, t := range sortedKeys(counts) {
+			status, message := CheckPass, fmt.Sprintf("%d ready schedulable nodes", counts[t])
+			if counts[t] < 2 {
+				status, message = CheckWarn, "only one ready schedulable node; workloads that need this type cannot move during a drain"
+			}
+			findings = append(findings, HAFinding{Area: HAAreaNodes, Subject: t, Status: status, Message: message})
+		}
+		return findings
+	}
+
+	for _, t := range sortedKeys(needed) {
+		switch n := counts[t]; {
+		case n == 0:
+			findings = append(findings, HAFinding{Area: HAAreaNodes, Subject: t, Status: CheckFail, Message: "required, but no ready schedulable nodes"})
+		case n == 1:
+			findings = append(findings, HAFinding{Area: HAAreaNodes, Subject: t, Status: CheckFail, Message: "required, but only one ready schedulable node; draining it leaves its pods unschedulable"})
+		default:
+			findings = append(findings, HAFinding{Area: HAAreaNodes, Subject: t, Status: CheckPass, Message: fmt.Sprintf("%d ready schedulable nodes", n)})
+		}
+	}
+	return findings
+}
+
+// workloadHAFindings checks that a workload has more than one replica, spread over more than one
+// node, with a PodDisruptionBudget that lets a drain evict at least one pod
+func workloadHAFindings(kind, name string, replicas int32, selector *metav1.LabelSelector, pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget) []HAFinding {
+	if replicas == 0 {
+		return nil
+	}
+	subject := fmt.Sprintf("%s/%s", strings.ToLower(kind), name)
+	var findings []HAFinding
+	add := func(status, format string, args ...interface{}) {
+		findings = append(findings, HAFinding{Area: HAAreaWorkloads, Subject: subject, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		podSelector = labels.Nothing()
+	}
+	var podLabels map[string]string
+	nodes := map[string]bool{}
+	for _, pod := range pods {
+		if !podSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		podLabels = pod.Labels
+		if pod.Spec.NodeName != "" && pod.DeletionTimestamp == nil {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	if replicas == 1 {
+		add(CheckWarn, "runs a single replica; a node drain causes an outage")
+	} else if len(nodes) == 1 {
+		add(CheckWarn, "all %d replicas run on node %s; draining it takes the workload down", replicas, sortedKeys(nodes)[0])
+	}
+
+	if podLabels == nil && selector != nil {
+		podLabels = selector.MatchLabels
+	}
+	var covering []policyv1.PodDisruptionBudget
+	for _, pdb := range pdbs {
+		if pdbSelects(pdb, podLabels) {
+			covering = append(covering, pdb)
+		}
+	}
+	switch {
+	case len(covering) == 0:
+		add(CheckWarn, "no PodDisruptionBudget; a drain may evict every replica at once")
+	default:
+		for _, pdb := range covering {
+			if pdb.Status.ExpectedPods > 0 && pdb.Status.DisruptionsAllowed == 0 {
+				add(CheckWarn, "PodDisruptionBudget %s allows no disruptions (%d of %d healthy, %d required); node drains will hang", pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods, pdb.Status.DesiredHealthy)
+			}
+		}
+	}
+	if len(findings) == 0 {
+		names := make([]string, 0, len(covering))
+		for _, pdb := range covering {
+			names = append(names, pdb.Name)
+		}
+		add(CheckPass, "%d replicas across %d nodes, PodDisruptionBudget %s", replicas, len(nodes), strings.Join(names, ", "))
+	}
+	return findings
+}
+
+// pdbSelects reports whether a PodDisruptionBudget covers pods with the given labels
+func pdbSelects(pdb policyv1.PodDisruptionBudget, podLabels map[string]string) bool {
+	if podLabels == nil {
+		return false
+	}
+	return hasMatchingPDB(podLabels, []policyv1.PodDisruptionBudget{pdb})
+}
+
+// controlPlaneFindings reports control-plane redundancy. Managed control planes do not appear as
+// nodes; the provider is named from the nodes' provider IDs.
+func controlPlaneFindings(nodes []corev1.Node) []HAFinding {
+	var total, ready int
+	provider := ""
+	for _, node := range nodes {
+		if provider == "" {
+			provider = managedControlPlane(node.Spec.ProviderID)
+		}
+		if !isControlPlaneNode(node) {
+			continue
+		}
+		total++
+		if isNodeReady(&node) {
+			ready++
+		}
+	}
+
+	finding := HAFinding{Area: HAAreaControlPlane, Subject: "control-plane"}
+	switch {
+	case total == 0 && provider != "":
+		finding.Status, finding.Message = CheckPass, fmt.Sprintf("managed by %s; its availability follows the provider's SLA", provider)
+	case total == 0:
+		finding.Status, finding.Message = CheckPass, "no control-plane nodes visible; the control plane is likely managed, confirm it is highly available"
+	case total == 1:
+		finding.Status, finding.Message = CheckWarn, "single control-plane node; the API server is down while it is drained or fails"
+	case total%2 == 0:
+		finding.Status, finding.Message = CheckWarn, fmt.Sprintf("%d control-plane nodes; an even count tolerates no more etcd member failures than %d, use an odd number", total, total-1)
+	case ready < total:
+		finding.Status, finding.Message = CheckWarn, fmt.Sprintf("%d of %d control-plane nodes ready", ready, total)
+	default:
+		finding.Status, finding.Message = CheckPass, fmt.Sprintf("%d control-plane nodes ready", total)
+	}
+	return []HAFinding{finding}
+}
+
+// managedControlPlane names the managed Kubernetes service behind a node's provider ID
+func managedControlPlane(providerID string) string {
+	switch {
+	case strings.HasPrefix(providerID, "aws://"):
+		return "EKS"
+	case strings.HasPrefix(providerID, "gce://"):
+		return "GKE"
+	case strings.HasPrefix(providerID, "azure://"):
+		return "AKS"
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testHANode(name, instanceType string, labels map[string]string) corev1.Node {
+	nodeLabels := map[string]string{"node.kubernetes.io/instance-type": instanceType}
+	for k, v := range labels {
+		nodeLabels[k] = v
+	}
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+	}
+}
+
+func testHAPod(name, node string, labels map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func findingStatuses(findings []HAFinding) map[string]string {
+	statuses := map[string]string{}
+	for _, f := range findings {
+		if existing, ok := statuses[f.Subject]; ok {
+			statuses[f.Subject] = worseStatus(existing, f.Status)
+		} else {
+			statuses[f.Subject] = f.Status
+		}
+	}
+	return statuses
+}
+
+func TestNodeRedundancyFindings(t *testing.T) {
+	cordoned := testHANode("gpu-2", "g5.2xlarge", nil)
+	cordoned.Spec.Unschedulable = true
+	nodes := []corev1.Node{
+		testHANode("cpu-1", "m5.xlarge", nil),
+		testHANode("cpu-2", "m5.xlarge", nil),
+		testHANode("gpu-1", "g5.2xlarge", nil),
+		cordoned,
+		testHANode("cp-1", "m5.large", map[string]string{"node-role.kubernetes.io/control-plane": ""}),
+	}
+	pods := []corev1.Pod{testHAPod("api", "cpu-1", nil), testHAPod("model", "gpu-1", nil)}
+
+	got := findingStatuses(nodeRedundancyFindings(nodes, pods, []string{"p4d.24xlarge"}))
+	want := map[string]string{"m5.xlarge": CheckPass, "g5.2xlarge": CheckFail, "p4d.24xlarge": CheckFail}
+	for subject, status := range want {
+		if got[subject] != status {
+			t.Errorf("%s: status = %q, want %q", subject, got[subject], status)
+		}
+	}
+	if _, ok := got["m5.large"]; ok {
+		t.Error("control-plane node types should not be required")
+	}
+
+	// Before install only single-node types are flagged, as warnings
+	got = findingStatuses(nodeRedundancyFindings(nodes, nil, nil))
+	if got["g5.2xlarge"] != CheckWarn || got["m5.xlarge"] != CheckPass {
+		t.Errorf("pre-install statuses = %v", got)
+	}
+	got = findingStatuses(nodeRedundancyFindings(nodes[:1], nil, nil))
+	if got["workers"] != CheckFail {
+		t.Errorf("single worker status = %v, want fail", got)
+	}
+}
+
+func TestWorkloadHAFindings(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "dynamoai-api"}
+	selector := &metav1.LabelSelector{MatchLabels: labels}
+	pdb := policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "dynamoai-api"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: selector},
+		Status:     policyv1.PodDisruptionBudgetStatus{ExpectedPods: 2, CurrentHealthy: 2, DesiredHealthy: 1, DisruptionsAllowed: 1},
+	}
+	blocking := pdb
+	blocking.Status.DesiredHealthy, blocking.Status.DisruptionsAllowed = 2, 0
+	spread := []corev1.Pod{testHAPod("api-a", "node-1", labels), testHAPod("api-b", "node-2", labels)}
+	packed := []corev1.Pod{testHAPod("api-a", "node-1", labels), testHAPod("api-b", "node-1", labels)}
+
+	tests := []struct {
+		name     string
+		replicas int32
+		pods     []corev1.Pod
+		pdbs     []policyv1.PodDisruptionBudget
+		status   string
+		contains string
+	}{
+		{"ready for a drain", 2, spread, []policyv1.PodDisruptionBudget{pdb}, CheckPass, "2 replicas across 2 nodes"},
+		{"single replica", 1, spread[:1], []policyv1.PodDisruptionBudget{pdb}, CheckWarn, "single replica"},
+		{"no PDB", 2, spread, nil, CheckWarn, "no PodDisruptionBudget"},
+		{"PDB blocks drains", 2, spread, []policyv1.PodDisruptionBudget{blocking}, CheckWarn, "allows no disruptions"},
+		{"replicas on one node", 2, packed, []policyv1.PodDisruptionBudget{pdb}, CheckWarn, "run on node node-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := workloadHAFindings(WorkloadKindDeployment, "dynamoai-api", tt.replicas, selector, tt.pods, tt.pdbs)
+			status := CheckPass
+			var messages []string
+			for _, f := range findings {
+				status = worseStatus(status, f.Status)
+				messages = append(messages, f.Message)
+			}
+			if status != tt.status || !strings.Contains(strings.Join(messages, "; "), tt.contains) {
+				t.Errorf("workloadHAFindings() = %s %v, want %s containing %q", status, messages, tt.status, tt.contains)
+			}
+		})
+	}
+
+	if findings := workloadHAFindings(WorkloadKindDeployment, "scaled-down", 0, selector, nil, nil); len(findings) != 0 {
+		t.Errorf("scaled-down workloads should be skipped, got %v", findings)
+	}
+}
+
+func TestControlPlaneFindings(t *testing.T) {
+	cp := map[string]string{"node-role.kubernetes.io/control-plane": ""}
+	managed := testHANode("worker", "m5.xlarge", nil)
+	managed.Spec.ProviderID = "aws:///us-east-1a/i-0123456789abcdef0"
+
+	tests := []struct {
+		name     string
+		nodes    []corev1.Node
+		status   string
+		contains string
+	}{
+		{"managed", []corev1.Node{managed}, CheckPass, "EKS"},
+		{"single", []corev1.Node{testHANode("cp-1", "", cp)}, CheckWarn, "single control-plane node"},
+		{"even", []corev1.Node{testHANode("cp-1", "", cp), testHANode("cp-2", "", cp)}, CheckWarn, "odd number"},
+		{"three", []corev1.Node{testHANode("cp-1", "", cp), testHANode("cp-2", "", cp), testHANode("cp-3", "", cp)}, CheckPass, "3 control-plane nodes ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := controlPlaneFindings(tt.nodes)[0]
+			if f.Status != tt.status || !strings.Contains(f.Message, tt.contains) {
+				t.Errorf("controlPlaneFindings() = %s %q, want %s containing %q", f.Status, f.Message, tt.status, tt.contains)
+			}
+		})
+	}
+}
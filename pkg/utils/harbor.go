@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// harborAPIPath is the Harbor v2 REST API root
+const harborAPIPath = "/api/v2.0"
+
+// HarborClient calls the REST API of a Harbor registry using the credentials dynactl pushes with
+type HarborClient struct {
+	registryAPI
+}
+
+// HarborProject is the subset of a Harbor project used by the mirror preflight
+type HarborProject struct {
+	ProjectID int64  `json:"project_id"`
+	Name      string `json:"name"`
+}
+
+// HarborQuota is a project's storage quota in bytes; a Hard of -1 means unlimited
+type HarborQuota struct {
+	Hard int64
+	Used int64
+}
+
+// HarborProjectOptions controls what the preflight may change on the registry
+type HarborProjectOptions struct {
+	// Create makes missing projects instead of failing
+	Create bool
+	// RetainLatest adds a retention policy keeping the N most recently pushed tags per
+	// repository to projects dynactl creates; 0 leaves retention unset
+	RetainLatest int
+}
+
+// IsHarbor reports whether the registry answers the Harbor system info endpoint
+func (h *HarborClient) IsHarbor(ctx context.Context) bool {
+	var info struct {
+		HarborVersion string `json:"harbor_version"`
+	}
+	status, err := h.do(ctx, http.MethodGet, "/systeminfo", nil, &info)
+	if err != nil || status != http.StatusOK || info.HarborVersion == "" {
+		LogDebug("Registry is not Harbor (status %d): %v", status, err)
+		return false
+	}
+	LogDebug("Detected Harbor %s", info.HarborVersion)
+	return true
+}
+
+// GetProject returns the named project, or nil when it does not exist
+func (h *HarborClient) GetProject(ctx context.Context, name string) (*HarborProject, error) {
+	var project HarborProject
+	status, err := h.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(name), nil, &project)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up Harbor project %s: %w", name, err)
+	}
+	return &project, nil
+}
+
+// CreateProject creates a private project without its own storage limit, optionally with a
+// retention policy
+func (h *HarborClient) CreateProject(ctx context.Context, name string, retainLatest int) (*HarborProject, error) {
+	body := map[string]interface{}{
+		"project_name":  name,
+		"metadata":      map[string]string{"public": "false"},
+		"storage_limit": -1,
+	}
+	if _, err := h.do(ctx, http.MethodPost, "/projects", body, nil); err != nil {
+		return nil, fmt.Errorf("failed to create Harbor project %s: %w", name, err)
+	}
+	project, err := h.GetProject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project %s was not found in Harbor after creating it", name)
+	}
+
+	if retainLatest > 0 {
+		if _, err := h.do(ctx, http.MethodPost, "/retentions", retentionPolicy(project.ProjectID, retainLatest), nil); err != nil {
+			return project, fmt.Errorf("created Harbor project %s but failed to set its retention policy: %w", name, err)
+		}
+	}
+	return project, nil
+}
+
+// ProjectQuota returns the storage quota of a project
+func (h *HarborClient) ProjectQuota(ctx context.Context, projectID int64) (*HarborQuota, error) {
+	var quotas []struct {
+		Hard map[string]int64 `json:"hard"`
+		Used map[string]int64 `json:"used"`
+	}
+	path := "/quotas?reference=project&reference_id=" + strconv.FormatInt(projectID, 10)
+	if _, err := h.do(ctx, http.MethodGet, path, nil, &quotas); err != nil {
+		return nil, fmt.Errorf("failed to read Harbor quota: %w", err)
+	}
+	if len(quotas) == 0 {
+		return &HarborQuota{Hard: -1}, nil
+	}
+	return &HarborQuota{Hard: quotas[0].Hard["storage"], Used: quotas[0].Used["storage"]}, nil
+}
+
+// EnsureHarborProjects checks that every project exists, creating missing ones when allowed, and
+// returns them by name
+func EnsureHarborProjects(ctx context.Context, h *HarborClient, names []string, opts HarborProjectOptions) (map[string]*HarborProject, error) {
+	projects := map[string]*HarborProject{}
+	var missing []string
+	for _, name := range names {
+		project, err := h.GetProject(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			if !opts.Create {
+				missing = append(missing, name)
+				continue
+			}
+			if project, err = h.CreateProject(ctx, name, opts.RetainLatest); err != nil {
+				return nil, err
+			}
+			LogInfo("Created Harbor project %s", name)
+		}
+		projects[name] = project
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("project(s) %s do not exist in Harbor; create them or rerun with --create-project", strings.Join(missing, ", "))
+	}
+	return projects, nil
+}
+
+// CheckHarborQuotas fails when a project's remaining storage quota is smaller than the bytes
+// about to be pushed into it. Harbor deduplicates layers, so this is a conservative estimate.
+func CheckHarborQuotas(ctx context.Context, h *HarborClient, projects map[string]*HarborProject, sizes map[string]int64) error {
+	var problems []string
+	for _, name := range sortedKeys(sizes) {
+		project, ok := projects[name]
+		if !ok {
+			continue
+		}
+		quota, err := h.ProjectQuota(ctx, project.ProjectID)
+		if err != nil {
+			return err
+		}
+		if quota.Hard < 0 {
+			LogDebug("Harbor project %s has no storage limit", name)
+			continue
+		}
+		free := quota.Hard - quota.Used
+		if sizes[name] > free {
+			problems = append(problems, fmt.Sprintf("%s needs %s but has %s free of %s",
+				name, FormatBytes(sizes[name]), FormatBytes(max(free, 0)), FormatBytes(quota.Hard)))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("not enough Harbor storage quota: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// harborRegistryTarget checks Harbor projects and quotas; the project is the first path
+// segment of each repository
+type harborRegistryTarget struct {
+	client   *HarborClient
+	opts     HarborProjectOptions
+	projects map[string]*HarborProject
+}
+
+func (t *harborRegistryTarget) Kind() string { return "Harbor" }
+
+func (t *harborRegistryTarget) Prepare(ctx context.Context, repositories []string) error {
+	names := map[string]int64{}
+	for _, repo := range repositories {
+		if project := firstPathSegment(repo); project != "" {
+			names[project] = 0
+		}
+	}
+	projects, err := EnsureHarborProjects(ctx, t.client, sortedKeys(names), t.opts)
+	if err != nil {
+		return err
+	}
+	t.projects = projects
+	return nil
+}
+
+func (t *harborRegistryTarget) CheckCapacity(ctx context.Context, sizes map[string]int64) error {
+	byProject := map[string]int64{}
+	for repo, size := range sizes {
+		byProject[firstPathSegment(repo)] += size
+	}
+	return CheckHarborQuotas(ctx, t.client, t.projects, byProject)
+}
+
+func (t *harborRegistryTarget) TargetRepository(repository string) string { return repository }
+
+// retentionPolicy keeps the latest n pushed tags of every repository in a project
+func retentionPolicy(projectID int64, n int) map[string]interface{} {
+	return map[string]interface{}{
+		"algorithm": "or",
+		"rules": []map[string]interface{}{{
+			"action":   "retain",
+			"template": "latestPushedK",
+			"params":   map[string]int{"latestPushedK": n},
+			"tag_selectors": []map[string]string{
+				{"kind": "doublestar", "decoration": "matches", "pattern": "**"},
+			},
+			"scope_selectors": map[string][]map[string]string{
+				"repository": {{"kind": "doublestar", "decoration": "repoMatches", "pattern": "**"}},
+			},
+		}},
+		"trigger": map[string]interface{}{"kind": "Schedule", "settings": map[string]string{"cron": "0 0 0 * * *"}},
+		"scope":   map[string]interface{}{"level": "project", "ref": projectID},
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHarbor serves enough of the Harbor API for the mirror preflight
+type fakeHarbor struct {
+	mu         sync.Mutex
+	projects   map[string]int64
+	quotas     map[int64][2]int64
+	retentions int
+}
+
+func (f *fakeHarbor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, harborAPIPath)
+	switch {
+	case path == "/systeminfo":
+		fmt.Fprint(w, `{"harbor_version":"v2.10.0"}`)
+	case strings.HasPrefix(path, "/projects/") && r.Method == http.MethodGet:
+		name := strings.TrimPrefix(path, "/projects/")
+		id, ok := f.projects[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(HarborProject{ProjectID: id, Name: name})
+	case path == "/projects" && r.Method == http.MethodPost:
+		var body struct {
+			Name string `json:"project_name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.projects[body.Name] = int64(len(f.projects) + 1)
+		w.WriteHeader(http.StatusCreated)
+	case path == "/quotas":
+		var id int64
+		fmt.Sscan(r.URL.Query().Get("reference_id"), &id)
+		q, ok := f.quotas[id]
+		if !ok {
+			q = [2]int64{-1, 0}
+		}
+		fmt.Fprintf(w, `[{"hard":{"storage":%d},"used":{"storage":%d}}]`, q[0], q[1])
+	case path == "/retentions" && r.Method == http.MethodPost:
+		f.retentions++
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestHarborPreflight(t *testing.T) {
+	harbor := &fakeHarbor{projects: map[string]int64{"dynamoai": 1}, quotas: map[int64][2]int64{1: {100 << 20, 90 << 20}}}
+	srv := httptest.NewServer(harbor)
+	defer srv.Close()
+	client := &HarborClient{registryAPI: registryAPI{baseURL: srv.URL + harborAPIPath, client: srv.Client()}}
+	ctx := context.Background()
+
+	if !client.IsHarbor(ctx) {
+		t.Fatal("expected the fake registry to be detected as Harbor")
+	}
+
+	images := []string{"artifacts.dynamo.ai/dynamoai/3.22.2/images/api:1.0", "artifacts.dynamo.ai/extras/tools:2.0"}
+	repos := MirrorTargetRepositories(images, "harbor.example.com", nil)
+	if strings.Join(repos, ",") != "harbor.example.com/dynamoai/3.22.2/images/api,harbor.example.com/extras/tools" {
+		t.Fatalf("unexpected repositories %v", repos)
+	}
+	if repos := MirrorTargetRepositories(images, "harbor.example.com/mirror", nil); firstPathSegment(repos[0]) != "mirror" || firstPathSegment(repos[1]) != "mirror" {
+		t.Fatalf("a target with a path should push into that project, got %v", repos)
+	}
+
+	target := &harborRegistryTarget{client: client}
+	if err := target.Prepare(ctx, repos); err == nil || !strings.Contains(err.Error(), "extras") {
+		t.Fatalf("expected a missing project error naming extras, got %v", err)
+	}
+	target.opts = HarborProjectOptions{Create: true, RetainLatest: 5}
+	if err := target.Prepare(ctx, repos); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if target.projects["extras"] == nil || harbor.retentions != 1 {
+		t.Fatalf("expected extras to be created with a retention policy, got %+v (%d retentions)", target.projects, harbor.retentions)
+	}
+
+	cache := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cache, "api.tar"), make([]byte, 20<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sizes := MirrorBundleSizes(images, cache, "harbor.example.com", nil)
+	if sizes["harbor.example.com/dynamoai/3.22.2/images/api"] != 20<<20 || sizes["harbor.example.com/extras/tools"] != 0 {
+		t.Fatalf("unexpected bundle sizes %v", sizes)
+	}
+	err := target.CheckCapacity(ctx, sizes)
+	if err == nil || !strings.Contains(err.Error(), "dynamoai needs 20.00 MB but has 10.00 MB free") {
+		t.Fatalf("expected a quota error, got %v", err)
+	}
+
+	harbor.quotas[1] = [2]int64{100 << 20, 10 << 20}
+	if err := target.CheckCapacity(ctx, sizes); err != nil {
+		t.Errorf("expected enough quota, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HookStagePrePush runs before an artifact is pushed into the target registry
+const HookStagePrePush = "pre-push"
+
+// Hook failure policies
+const (
+	HookOnErrorDeny  = "deny"
+	HookOnErrorAllow = "allow"
+)
+
+// defaultHookTimeout bounds a hook that sets no timeout; scans of large images can be slow
+const defaultHookTimeout = 10 * time.Minute
+
+// ArtifactHook is a customer gate every artifact passes through, either a command or an HTTP
+// endpoint. A command allows the artifact by exiting 0; an endpoint by answering 2xx without
+// "allow": false.
+type ArtifactHook struct {
+	Name string `json:"name"`
+	// Command is run with the artifact as JSON on stdin and in DYNACTL_ARTIFACT_* variables
+	Command []string `json:"command,omitempty"`
+	// URL receives the artifact as a JSON POST
+	URL string `json:"url,omitempty"`
+	// Timeout is a Go duration such as 5m (default 10m)
+	Timeout string `json:"timeout,omitempty"`
+	// OnError decides what happens when the hook cannot give an answer: deny (default) or allow
+	OnError string `json:"on_error,omitempty"`
+}
+
+// HookArtifact describes the artifact a hook is asked about
+type HookArtifact struct {
+	Stage  string `json:"stage"`
+	Type   string `json:"type"`
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// HookDeniedError reports an artifact a hook refused
+type HookDeniedError struct {
+	Hook     string
+	Artifact string
+	Reason   string
+}
+
+func (e *HookDeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("hook %s denied %s", e.Hook, e.Artifact)
+	}
+	return fmt.Sprintf("hook %s denied %s: %s", e.Hook, e.Artifact, e.Reason)
+}
+
+// ValidateHooks checks hook definitions from the config file
+func ValidateHooks(hooks []ArtifactHook) error {
+	for i, hook := range hooks {
+		label := hook.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		if (len(hook.Command) == 0) == (hook.URL == "") {
+			return fmt.Errorf("hook %s must set exactly one of command or url", label)
+		}
+		if hook.Timeout != "" {
+			if _, err := time.ParseDuration(hook.Timeout); err != nil {
+				return fmt.Errorf("hook %s has an invalid timeout: %w", label, err)
+			}
+		}
+		if hook.OnError != "" && hook.OnError != HookOnErrorDeny && hook.OnError != HookOnErrorAllow {
+			return fmt.Errorf("hook %s on_error must be %s or %s", label, HookOnErrorDeny, HookOnErrorAllow)
+		}
+	}
+	return nil
+}
+
+// RunArtifactHooks asks every hook about an artifact in order, stopping at the first denial
+func RunArtifactHooks(ctx context.Context, hooks []ArtifactHook, artifact HookArtifact) error {
+	for _, hook := range hooks {
+		allowed, reason, err := runHook(ctx, hook, artifact)
+		if err != nil {
+			if hook.OnError != HookOnErrorAllow {
+				return &HookDeniedError{Hook: hook.label(), Artifact: artifact.Source, Reason: "hook failed: " + err.Error()}
+			}
+			LogWarning("Hook %s failed for %s, allowing it (on_error: allow): %v", hook.label(), artifact.Source, err)
+			continue
+		}
+		if !allowed {
+			return &HookDeniedError{Hook: hook.label(), Artifact: artifact.Source, Reason: reason}
+		}
+		LogDebug("Hook %s allowed %s", hook.label(), artifact.Source)
+	}
+	return nil
+}
+
+func (h ArtifactHook) label() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.URL != "":
+		return h.URL
+	}
+	return h.Command[0]
+}
+
+// runHook returns the hook's verdict, or an error when it could not give one
+func runHook(ctx context.Context, hook ArtifactHook, artifact HookArtifact) (bool, string, error) {
+	timeout := defaultHookTimeout
+	if hook.Timeout != "" {
+		timeout, _ = time.ParseDuration(hook.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(artifact)
+	if err != nil {
+		return false, "", err
+	}
+	if hook.URL != "" {
+		return runURLHook(ctx, hook.URL, payload)
+	}
+	return runCommandHook(ctx, hook.Command, artifact, payload)
+}
+
+func runCommandHook(ctx context.Context, command []string, artifact HookArtifact, payload []byte) (bool, string, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"DYNACTL_HOOK_STAGE="+artifact.Stage,
+		"DYNACTL_ARTIFACT_TYPE="+artifact.Type,
+		"DYNACTL_ARTIFACT_SOURCE="+artifact.Source,
+		"DYNACTL_ARTIFACT_TARGET="+artifact.Target,
+		"DYNACTL_ARTIFACT_PATH="+artifact.Path,
+		"DYNACTL_ARTIFACT_DIGEST="+artifact.Digest,
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return false, "", fmt.Errorf("timed out")
+	case errors.As(err, &exitErr):
+		return false, lastLine(output.String()), nil
+	case err != nil:
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+func runURLHook(ctx context.Context, url string, payload []byte) (bool, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, "", fmt.Errorf("invalid hook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var verdict struct {
+		Allow  *bool  `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(body, &verdict)
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		if verdict.Reason == "" {
+			verdict.Reason = strings.TrimSpace(string(body))
+		}
+		return false, verdict.Reason, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	case verdict.Allow != nil && !*verdict.Allow:
+		return false, verdict.Reason, nil
+	}
+	return true, "", nil
+}
+
+// lastLine returns the last non-empty line of a hook's output, which usually holds the reason
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateHooks(t *testing.T) {
+	valid := []ArtifactHook{{Name: "scan", Command: []string{"scan"}, Timeout: "5m"}, {URL: "https://gate", OnError: HookOnErrorAllow}}
+	if err := ValidateHooks(valid); err != nil {
+		t.Errorf("expected valid hooks, got %v", err)
+	}
+	for _, bad := range []ArtifactHook{
+		{Name: "both", Command: []string{"scan"}, URL: "https://gate"},
+		{Name: "neither"},
+		{Name: "timeout", URL: "https://gate", Timeout: "soon"},
+		{Name: "policy", URL: "https://gate", OnError: "maybe"},
+	} {
+		if err := ValidateHooks([]ArtifactHook{bad}); err == nil || !strings.Contains(err.Error(), bad.Name) {
+			t.Errorf("expected hook %s to be rejected, got %v", bad.Name, err)
+		}
+	}
+}
+
+func TestURLHooks(t *testing.T) {
+	var received HookArtifact
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		switch r.URL.Path {
+		case "/allow":
+		case "/deny":
+			fmt.Fprint(w, `{"allow":false,"reason":"CVE-2024-1234 is critical"}`)
+		case "/forbidden":
+			http.Error(w, "blocked by policy", http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	artifact := HookArtifact{Stage: HookStagePrePush, Type: "containerImage", Source: "artifacts.dynamo.ai/api:1.0", Digest: "sha256:abc"}
+
+	if err := RunArtifactHooks(ctx, []ArtifactHook{{URL: srv.URL + "/allow"}}, artifact); err != nil {
+		t.Errorf("expected the artifact to be allowed, got %v", err)
+	}
+	if received.Digest != "sha256:abc" || received.Stage != HookStagePrePush {
+		t.Errorf("unexpected payload %+v", received)
+	}
+
+	cases := map[string]string{
+		"/deny":      "CVE-2024-1234 is critical",
+		"/forbidden": "blocked by policy",
+		"/down":      "hook failed: HTTP 502",
+	}
+	for path, want := range cases {
+		err := RunArtifactHooks(ctx, []ArtifactHook{{Name: "gate", URL: srv.URL + path}}, artifact)
+		var denied *HookDeniedError
+		if !errors.As(err, &denied) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected a denial containing %q, got %v", path, want, err)
+		}
+	}
+
+	if err := RunArtifactHooks(ctx, []ArtifactHook{{URL: srv.URL + "/down", OnError: HookOnErrorAllow}}, artifact); err != nil {
+		t.Errorf("expected on_error: allow to let the artifact through, got %v", err)
+	}
+}
+
+func TestCommandHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "scan.sh")
+	body := `#!/bin/sh
+grep -q '"stage":"pre-push"' || exit 2
+case "$DYNACTL_ARTIFACT_SOURCE" in
+  *bad*) echo "scanning $DYNACTL_ARTIFACT_DIGEST"; echo "2 critical vulnerabilities"; exit 1;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hooks := []ArtifactHook{{Name: "scan", Command: []string{script}}}
+	ctx := context.Background()
+
+	if err := RunArtifactHooks(ctx, hooks, HookArtifact{Stage: HookStagePrePush, Source: "registry/good:1.0"}); err != nil {
+		t.Errorf("expected the artifact to be allowed, got %v", err)
+	}
+	err := RunArtifactHooks(ctx, hooks, HookArtifact{Stage: HookStagePrePush, Source: "registry/bad:1.0", Digest: "sha256:abc"})
+	if err == nil || err.Error() != "hook scan denied registry/bad:1.0: 2 critical vulnerabilities" {
+		t.Errorf("unexpected denial %v", err)
+	}
+
+	slow := []ArtifactHook{{Name: "slow", Command: []string{"sleep", "5"}, Timeout: "50ms"}}
+	if err := RunArtifactHooks(ctx, slow, HookArtifact{Source: "registry/good:1.0"}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout denial, got %v", err)
+	}
+	missing := []ArtifactHook{{Command: []string{filepath.Join(dir, "missing")}, OnError: HookOnErrorAllow}}
+	if err := RunArtifactHooks(ctx, missing, HookArtifact{Source: "registry/good:1.0"}); err != nil {
+		t.Errorf("expected on_error: allow to let the artifact through, got %v", err)
+	}
+}
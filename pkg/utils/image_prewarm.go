@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// imagePrewarmName names the DaemonSet that pre-pulls release images
+	imagePrewarmName = "dynactl-image-prewarm"
+	// prewarmToolsDir is where the init container copies busybox for the image containers
+	prewarmToolsDir = "/dynactl"
+	// DefaultPrewarmTimeout bounds how long the nodes have to pull every image
+	DefaultPrewarmTimeout = 30 * time.Minute
+)
+
+// ImagePrewarmOptions configures pre-pulling images onto nodes
+type ImagePrewarmOptions struct {
+	Namespace    string
+	Images       []string
+	NodeSelector map[string]string
+	// PullSecrets are attached to the pods as imagePullSecrets
+	PullSecrets []string
+	// ToolsImage provides the static busybox the image containers run; it must have
+	// /bin/busybox
+	ToolsImage string
+	// Timeout bounds how long the nodes have to pull every image
+	Timeout time.Duration
+	// OnNode is called once for each node that has pulled every image
+	OnNode func(PrewarmNodeResult)
+}
+
+// PrewarmNodeResult is how far a node got pulling the images
+type PrewarmNodeResult struct {
+	Node   string
+	Pulled int
+	Total  int
+	// Failed maps images the node could not pull to the kubelet's error
+	Failed map[string]string `json:",omitempty"`
+}
+
+// Done reports whether the node has pulled every image
+func (r PrewarmNodeResult) Done() bool {
+	return r.Pulled == r.Total
+}
+
+// PrewarmImageRefs returns the references the cluster pulls the manifest's images by: rewritten
+// to the target registry, with the manifest's target overrides, when one is given. Filters keep
+// only images whose reference contains one of them.
+func PrewarmImageRefs(manifest *ArtifactManifest, targetRegistry string, filters []string) []string {
+	var refs []string
+	for _, imageRef := range manifest.Images {
+		ref := strings.TrimPrefix(imageRef, "oci://")
+		if len(filters) > 0 && !containsAny(ref, filters) {
+			continue
+		}
+		if targetRegistry != "" {
+			repoPart, tagOrDigest := splitRepositoryAndReference(ref)
+			repo, tag := mirrorTarget(repoPart, tagOrDigest, targetRegistry, manifest.TargetOverrides)
+			ref = repo
+			if tag != "" {
+				ref = assembleTargetReference(repo, tag)
+			}
+		}
+		if !containsString(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePrewarmDaemonSet builds the DaemonSet that pulls the images onto every selected node. Each
+// image runs as a container that only sleeps, using a static busybox an init container copies
+// into a shared volume, so images without a shell work too. The pull happens when the kubelet
+// starts the container; nothing in the images runs.
+func imagePrewarmDaemonSet(opts ImagePrewarmOptions) *appsv1.DaemonSet {
+	labels := map[string]string{"app.kubernetes.io/name": imagePrewarmName, "app.kubernetes.io/managed-by": "dynactl"}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1m"),
+		corev1.ResourceMemory: resource.MustParse("8Mi"),
+	}
+	tools := corev1.VolumeMount{Name: "tools", MountPath: prewarmToolsDir}
+
+	containers := make([]corev1.Container, 0, len(opts.Images))
+	for i, image := range opts.Images {
+		containers = append(containers, corev1.Container{
+			Name:            fmt.Sprintf("image-%d", i),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{prewarmToolsDir + "/busybox", "sleep", "2147483647"},
+			Resources:       corev1.ResourceRequirements{Requests: requests, Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Mi")}},
+			VolumeMounts:    []corev1.VolumeMount{tools},
+		})
+	}
+	var secrets []corev1.LocalObjectReference
+	for _, s := range opts.PullSecrets {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: s})
+	}
+	grace := int64(0)
+	automount := false
+
+	return &appsv1.DaemonSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: imagePrewarmName, Namespace: opts.Namespace, Labels: labels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": imagePrewarmName}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					NodeSelector: opts.NodeSelector,
+					// Pull onto tainted nodes too, such as GPU pools
+					Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					ImagePullSecrets:              secrets,
+					AutomountServiceAccountToken:  &automount,
+					TerminationGracePeriodSeconds: &grace,
+					InitContainers: []corev1.Container{{
+						Name:            "tools",
+						Image:           opts.ToolsImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"cp", "/bin/busybox", prewarmToolsDir + "/busybox"},
+						Resources:       corev1.ResourceRequirements{Requests: requests},
+						VolumeMounts:    []corev1.VolumeMount{tools},
+					}},
+					Containers: containers,
+					Volumes: []corev1.Volume{{
+						Name:         "tools",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// RenderImagePrewarm returns the prewarm DaemonSet as YAML, for applying it by hand
+func RenderImagePrewarm(opts ImagePrewarmOptions) ([]byte, error) {
+	return yaml.Marshal(imagePrewarmDaemonSet(opts))
+}
+
+// PrewarmImages creates the prewarm DaemonSet, waits until every selected node has pulled every
+// image or the timeout passes, and deletes the DaemonSet either way. It returns how far each node
+// got; nodes still pulling at the timeout are not done.
+func (kc *KubernetesChecker) PrewarmImages(ctx context.Context, opts ImagePrewarmOptions) ([]PrewarmNodeResult, error) {
+	if len(opts.Images) == 0 {
+		return nil, fmt.Errorf("no images to pre-pull")
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultPrewarmTimeout
+	}
+
+	daemonSets := kc.clientset.AppsV1().DaemonSets(opts.Namespace)
+	if _, err := daemonSets.Create(ctx, imagePrewarmDaemonSet(opts), metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create DaemonSet %s: %v", imagePrewarmName, err)
+	}
+	defer func() {
+		policy := metav1.DeletePropagationForeground
+		if err := daemonSets.Delete(context.WithoutCancel(ctx), imagePrewarmName, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+			LogWarning("Failed to delete DaemonSet %s/%s: %v", opts.Namespace, imagePrewarmName, err)
+		}
+	}()
+
+	reported := map[string]bool{}
+	var results []PrewarmNodeResult
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		ds, err := daemonSets.Get(ctx, imagePrewarmName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		pods, err := kc.listPodsBySelector(ctx, opts.Namespace, "app.kubernetes.io/name="+imagePrewarmName)
+		if err != nil {
+			return false, err
+		}
+		results = results[:0]
+		done := 0
+		for i := range pods {
+			result := prewarmProgress(&pods[i], opts.Images)
+			if result.Node == "" {
+				continue
+			}
+			results = append(results, result)
+			if result.Done() {
+				done++
+				if !reported[result.Node] && opts.OnNode != nil {
+					reported[result.Node] = true
+					opts.OnNode(result)
+				}
+			}
+		}
+		desired := int(ds.Status.DesiredNumberScheduled)
+		return desired > 0 && done == desired, nil
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return results, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no node matched the node selector, or the prewarm pods were never scheduled")
+	}
+	return results, ctx.Err()
+}
+
+// prewarmProgress counts the images a prewarm pod's node has pulled and records pull failures
+func prewarmProgress(pod *corev1.Pod, images []string) PrewarmNodeResult {
+	result := PrewarmNodeResult{Node: pod.Spec.NodeName, Total: len(images)}
+	for _, cs := range pod.Status.ContainerStatuses {
+		var i int
+		if _, err := fmt.Sscanf(cs.Name, "image-%d", &i); err != nil || i >= len(images) {
+			continue
+		}
+		pulled, reason, message := containerImagePulled(cs)
+		switch {
+		case pulled:
+			result.Pulled++
+		case reason != "":
+			if result.Failed == nil {
+				result.Failed = map[string]string{}
+			}
+			result.Failed[images[i]] = strings.TrimSpace(reason + ": " + message)
+		}
+	}
+	return result
+}
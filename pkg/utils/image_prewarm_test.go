@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPrewarmImageRefs(t *testing.T) {
+	manifest := &ArtifactManifest{
+		Images: []string{
+			"oci://registry.dynamo.ai/dynamoai/api:3.2.0",
+			"oci://registry.dynamo.ai/dynamoai/vllm-server@sha256:abc",
+			"oci://registry.dynamo.ai/dynamoai/ui:3.2.0",
+			"oci://docker.io/library/redis:7",
+		},
+		TargetOverrides: map[string]TargetOverride{"docker.io/library/redis": {Repository: "mirror/redis", Tag: "7-alpine"}},
+	}
+
+	refs := PrewarmImageRefs(manifest, "", nil)
+	if want := []string{"registry.dynamo.ai/dynamoai/api:3.2.0", "registry.dynamo.ai/dynamoai/vllm-server@sha256:abc", "registry.dynamo.ai/dynamoai/ui:3.2.0", "docker.io/library/redis:7"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("refs = %q, want %q", refs, want)
+	}
+
+	refs = PrewarmImageRefs(manifest, "harbor.example.com/dynamo", nil)
+	want := []string{
+		"harbor.example.com/dynamo/dynamoai/api:3.2.0",
+		"harbor.example.com/dynamo/dynamoai/vllm-server@sha256:abc",
+		"harbor.example.com/dynamo/dynamoai/ui:3.2.0",
+		"harbor.example.com/mirror/redis:7-alpine",
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("refs = %q, want %q", refs, want)
+	}
+
+	refs = PrewarmImageRefs(manifest, "harbor.example.com/dynamo", []string{"vllm", "api"})
+	if want := want[:2]; !reflect.DeepEqual(refs, want) {
+		t.Errorf("filtered refs = %q, want %q", refs, want)
+	}
+}
+
+func TestImagePrewarmDaemonSet(t *testing.T) {
+	ds := imagePrewarmDaemonSet(ImagePrewarmOptions{
+		Namespace:    "dynamo",
+		Images:       []string{"harbor.example.com/dynamo/api:3.2.0", "harbor.example.com/dynamo/vllm:3.2.0"},
+		NodeSelector: map[string]string{"gpu": "true"},
+		PullSecrets:  []string{"harbor"},
+		ToolsImage:   DefaultLoaderImage,
+	})
+	spec := ds.Spec.Template.Spec
+	if ds.Namespace != "dynamo" || spec.NodeSelector["gpu"] != "true" || spec.ImagePullSecrets[0].Name != "harbor" {
+		t.Errorf("Unexpected DaemonSet placement %+v", spec)
+	}
+	if len(spec.InitContainers) != 1 || spec.InitContainers[0].Image != DefaultLoaderImage {
+		t.Fatalf("Expected the tools init container, got %+v", spec.InitContainers)
+	}
+	if len(spec.Containers) != 2 {
+		t.Fatalf("Expected a container per image, got %d", len(spec.Containers))
+	}
+	vllm := spec.Containers[1]
+	if vllm.Name != "image-1" || vllm.Image != "harbor.example.com/dynamo/vllm:3.2.0" || vllm.Command[0] != "/dynactl/busybox" {
+		t.Errorf("Unexpected image container %+v", vllm)
+	}
+
+	data, err := RenderImagePrewarm(ImagePrewarmOptions{Namespace: "dynamo", Images: []string{"api:1"}, ToolsImage: DefaultLoaderImage})
+	if err != nil {
+		t.Fatalf("RenderImagePrewarm returned error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "apiVersion: apps/v1\nkind: DaemonSet\n") {
+		t.Errorf("Expected a DaemonSet manifest, got:\n%s", data)
+	}
+}
+
+func TestPrewarmProgress(t *testing.T) {
+	images := []string{"api:1", "vllm:1", "ui:1"}
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{NodeName: "gpu-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "image-0", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			{Name: "image-1", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}},
+			{Name: "image-2", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+		}},
+	}
+
+	result := prewarmProgress(pod, images)
+	if result.Node != "gpu-1" || result.Pulled != 1 || result.Total != 3 || result.Done() {
+		t.Errorf("Unexpected progress %+v", result)
+	}
+	if want := map[string]string{"vllm:1": "ImagePullBackOff: Back-off pulling image"}; !reflect.DeepEqual(result.Failed, want) {
+		t.Errorf("failed = %v, want %v", result.Failed, want)
+	}
+}
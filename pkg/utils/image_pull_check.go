@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImagePullCheckOptions selects what the pull check pod pulls and how
+type ImagePullCheckOptions struct {
+	Image     string
+	Namespace string
+	// PullSecrets are attached to the pod as imagePullSecrets
+	PullSecrets []string
+	// ServiceAccount runs the pod, picking up the pull secrets it carries
+	ServiceAccount string
+	// NodeName pins the pull to one node
+	NodeName string
+	Timeout  time.Duration
+}
+
+// ImagePullResult is what the cluster reported while pulling the image
+type ImagePullResult struct {
+	Image    string
+	Registry string
+	Pod      string
+	Node     string
+	Pulled   bool
+	Duration time.Duration
+	// Reason and Message are the kubelet's, e.g. ErrImagePull and the registry error
+	Reason  string
+	Message string
+	// Hint suggests what to fix when the pull failed
+	Hint string
+	// Warnings are problems found with the pull secrets before the pod started
+	Warnings []string
+}
+
+// Pull failure reasons reported on a container that is waiting for its image
+var imagePullFailureReasons = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"RegistryUnavailable": true,
+}
+
+// CheckImagePull starts a short-lived pod that pulls the image with the namespace's pull secrets
+// and reports whether the kubelet could pull it. The image is always pulled from the registry,
+// never taken from a node's cache. Whether the container can run afterwards does not matter.
+// The pod is always deleted.
+func (kc *KubernetesChecker) CheckImagePull(ctx context.Context, opts ImagePullCheckOptions) (*ImagePullResult, error) {
+	ref, err := name.ParseReference(opts.Image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %w", opts.Image, err)
+	}
+	result := &ImagePullResult{Image: opts.Image, Registry: ref.Context().RegistryStr()}
+	result.Warnings = kc.checkPullSecrets(ctx, opts, result.Registry)
+
+	pod := buildImagePullPod(opts)
+	created, err := kc.clientset.CoreV1().Pods(opts.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image pull pod in %s: %v", opts.Namespace, err)
+	}
+	result.Pod = created.Name
+	LogInfo("Started image pull pod %s/%s for %s", opts.Namespace, created.Name, opts.Image)
+	defer func() {
+		grace := int64(0)
+		if err := kc.clientset.CoreV1().Pods(opts.Namespace).Delete(context.Background(), created.Name, metav1.DeleteOptions{GracePeriodSeconds: &grace}); err != nil {
+			LogWarning("Failed to delete image pull pod %s: %v", created.Name, err)
+		}
+	}()
+
+	start := time.Now()
+	deadline := start.Add(opts.Timeout)
+	for {
+		p, err := kc.clientset.CoreV1().Pods(opts.Namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image pull pod: %v", err)
+		}
+		result.Node = p.Spec.NodeName
+		if pulled, reason, message := imagePullState(p); pulled || reason != "" {
+			result.Duration = time.Since(start)
+			result.Pulled = pulled
+			if !pulled {
+				result.Reason = reason
+				result.Message = message
+				if events := kc.imagePullEvents(ctx, opts.Namespace, created.Name); len(events) > 0 {
+					// The events carry the registry's own error, which the status often truncates
+					result.Message = events[len(events)-1]
+				}
+				result.Hint = imagePullHint(result.Message)
+			}
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			result.Duration = time.Since(start)
+			result.Reason = "Timeout"
+			result.Message = fmt.Sprintf("image was not pulled within %s (phase %s)", opts.Timeout, p.Status.Phase)
+			if p.Spec.NodeName == "" {
+				result.Message += "; the pod was never scheduled"
+			}
+			result.Hint = "large images can take longer; raise --timeout, or check the node's disk space with 'dynactl cluster disk check'"
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// buildImagePullPod creates the spec for a pod whose only job is to make the kubelet pull an image
+func buildImagePullPod(opts ImagePullCheckOptions) *corev1.Pod {
+	var secrets []corev1.LocalObjectReference
+	for _, s := range opts.PullSecrets {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: s})
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dynactl-imagepull-check-",
+			Namespace:    opts.Namespace,
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:      corev1.RestartPolicyNever,
+			ServiceAccountName: opts.ServiceAccount,
+			NodeName:           opts.NodeName,
+			ImagePullSecrets:   secrets,
+			// The image may not have a shell or may expect arguments; only the pull matters
+			Containers: []corev1.Container{{
+				Name:            "pull",
+				Image:           opts.Image,
+				ImagePullPolicy: corev1.PullAlways,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10m"),
+						corev1.ResourceMemory: resource.MustParse("16Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// imagePullState reports whether the pod's image has been pulled, or why the pull failed
+func imagePullState(pod *corev1.Pod) (bool, string, string) {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if pulled, reason, message := containerImagePulled(cs); pulled || reason != "" {
+			return pulled, reason, message
+		}
+	}
+	return false, "", ""
+}
+
+// containerImagePulled reports whether a container's image has been pulled, or why the pull
+// failed. A container that started, exited, or failed to start had its image pulled.
+func containerImagePulled(cs corev1.ContainerStatus) (bool, string, string) {
+	switch {
+	case cs.ImageID != "", cs.State.Running != nil, cs.State.Terminated != nil:
+		return true, "", ""
+	case cs.State.Waiting != nil && imagePullFailureReasons[cs.State.Waiting.Reason]:
+		return false, cs.State.Waiting.Reason, cs.State.Waiting.Message
+	case cs.State.Waiting != nil && cs.State.Waiting.Reason != "ContainerCreating" && cs.State.Waiting.Reason != "PodInitializing":
+		// CreateContainerError and the like happen after the pull
+		return true, "", ""
+	}
+	return false, "", ""
+}
+
+// imagePullEvents returns the Failed events of the pull pod, oldest first
+func (kc *KubernetesChecker) imagePullEvents(ctx context.Context, namespace, podName string) []string {
+	events, err := kc.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + podName + ",reason=Failed",
+	})
+	if err != nil {
+		LogDebug("Failed to list events for pod %s: %v", podName, err)
+		return nil
+	}
+	items := events.Items
+	sort.Slice(items, func(i, j int) bool { return eventTime(items[i]).Before(eventTime(items[j])) })
+	var messages []string
+	for _, e := range items {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+// imagePullHint maps a registry error to the likely fix
+func imagePullHint(message string) string {
+	m := strings.ToLower(message)
+	switch {
+	case strings.Contains(m, "x509"), strings.Contains(m, "certificate"), strings.Contains(m, "tls:"):
+		return "the node does not trust the registry's certificate; add the registry CA to the container runtime's trust store (e.g. containerd certs.d) on every node"
+	case strings.Contains(m, "unauthorized"), strings.Contains(m, "authentication required"), strings.Contains(m, "401"),
+		strings.Contains(m, "denied"), strings.Contains(m, "403"):
+		return "the registry rejected the credentials; check that an imagePullSecret for the registry host is set and still valid"
+	case strings.Contains(m, "not found"), strings.Contains(m, "manifest unknown"), strings.Contains(m, "404"):
+		return "the image or tag does not exist in the registry; check that it was mirrored"
+	case strings.Contains(m, "no such host"), strings.Contains(m, "server misbehaving"):
+		return "the node cannot resolve the registry host; check cluster DNS and the node's resolver"
+	case strings.Contains(m, "i/o timeout"), strings.Contains(m, "connection refused"), strings.Contains(m, "connection reset"),
+		strings.Contains(m, "proxy"), strings.Contains(m, "deadline exceeded"):
+		return "the node cannot reach the registry; check firewalls and the container runtime's HTTP(S)_PROXY and NO_PROXY settings"
+	case strings.Contains(m, "http response to https client"):
+		return "the registry serves plain HTTP; configure it as an insecure registry in the container runtime"
+	case strings.Contains(m, "toomanyrequests"), strings.Contains(m, "429"):
+		return "the registry is rate limiting pulls; mirror the image to a registry you control"
+	}
+	return ""
+}
+
+// checkPullSecrets reports pull secrets that are missing, of the wrong type, or hold no
+// credentials for the registry. It only warns: the service account or node may supply them.
+func (kc *KubernetesChecker) checkPullSecrets(ctx context.Context, opts ImagePullCheckOptions, registry string) []string {
+	var warnings []string
+	for _, secretName := range opts.PullSecrets {
+		secret, err := kc.clientset.CoreV1().Secrets(opts.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("pull secret %s: %v", secretName, err))
+			continue
+		}
+		hosts, err := pullSecretRegistries(secret)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("pull secret %s: %v", secretName, err))
+			continue
+		}
+		if !containsString(hosts, registry) {
+			warnings = append(warnings, fmt.Sprintf("pull secret %s has no credentials for %s (it covers %s)", secretName, registry, strings.Join(hosts, ", ")))
+		}
+	}
+	return warnings
+}
+
+// pullSecretRegistries lists the registry hosts a docker config Secret holds credentials for
+func pullSecretRegistries(secret *corev1.Secret) ([]string, error) {
+	var auths map[string]json.RawMessage
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var cfg struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &cfg); err != nil {
+			return nil, fmt.Errorf("unreadable %s: %v", corev1.DockerConfigJsonKey, err)
+		}
+		auths = cfg.Auths
+	case corev1.SecretTypeDockercfg:
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+			return nil, fmt.Errorf("unreadable %s: %v", corev1.DockerConfigKey, err)
+		}
+	default:
+		return nil, fmt.Errorf("type is %s, not %s", secret.Type, corev1.SecretTypeDockerConfigJson)
+	}
+
+	var hosts []string
+	for key := range auths {
+		host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+		host, _, _ = strings.Cut(host, "/")
+		if host == "docker.io" || host == "registry-1.docker.io" {
+			host = name.DefaultRegistry
+		}
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
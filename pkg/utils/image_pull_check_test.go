@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildImagePullPod(t *testing.T) {
+	pod := buildImagePullPod(ImagePullCheckOptions{
+		Image:       "registry.example.com/dynamoai/api:3.22.2",
+		Namespace:   "dynamo",
+		PullSecrets: []string{"mirror-creds"},
+		NodeName:    "gpu-1",
+	})
+	c := pod.Spec.Containers[0]
+	if c.Image != "registry.example.com/dynamoai/api:3.22.2" || c.ImagePullPolicy != corev1.PullAlways {
+		t.Fatalf("expected an always-pulled container, got %+v", c)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "mirror-creds" || pod.Spec.NodeName != "gpu-1" {
+		t.Fatalf("unexpected pod spec %+v", pod.Spec)
+	}
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("expected RestartPolicy Never, got %s", pod.Spec.RestartPolicy)
+	}
+}
+
+func TestImagePullState(t *testing.T) {
+	waiting := func(reason, message string) *corev1.Pod {
+		return &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+		}}}}
+	}
+	if pulled, reason, _ := imagePullState(waiting("ContainerCreating", "")); pulled || reason != "" {
+		t.Fatalf("expected a creating container to be undecided, got %v %q", pulled, reason)
+	}
+	if pulled, reason, message := imagePullState(waiting("ErrImagePull", "401 Unauthorized")); pulled || reason != "ErrImagePull" || message != "401 Unauthorized" {
+		t.Fatalf("expected a pull failure, got %v %q %q", pulled, reason, message)
+	}
+	// The image was pulled even though the container cannot be created
+	if pulled, _, _ := imagePullState(waiting("CreateContainerError", "exec: no such file")); !pulled {
+		t.Fatal("expected CreateContainerError to count as pulled")
+	}
+	terminated := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		ImageID: "registry.example.com/dynamoai/api@sha256:abc",
+		State:   corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+	}}}}
+	if pulled, _, _ := imagePullState(terminated); !pulled {
+		t.Fatal("expected a terminated container to count as pulled")
+	}
+}
+
+func TestImagePullHint(t *testing.T) {
+	for message, want := range map[string]string{
+		`failed to pull: tls: failed to verify certificate: x509: certificate signed by unknown authority`: "certificate",
+		`failed to authorize: failed to fetch anonymous token: 401 Unauthorized`:                           "credentials",
+		`registry.example.com/dynamoai/api:3.22.9: not found`:                                              "mirrored",
+		`dial tcp 10.0.0.5:443: i/o timeout`:                                                               "PROXY",
+		`lookup registry.example.com: no such host`:                                                        "DNS",
+	} {
+		if hint := imagePullHint(message); !strings.Contains(hint, want) {
+			t.Errorf("imagePullHint(%q) = %q, want it to mention %q", message, hint, want)
+		}
+	}
+	if hint := imagePullHint("something else"); hint != "" {
+		t.Errorf("expected no hint for an unknown error, got %q", hint)
+	}
+}
+
+func TestPullSecretRegistries(t *testing.T) {
+	secret := &corev1.Secret{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{},"registry.example.com":{}}}`)},
+	}
+	hosts, err := pullSecretRegistries(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(hosts, ",") != "index.docker.io,registry.example.com" {
+		t.Fatalf("unexpected hosts %v", hosts)
+	}
+	if _, err := pullSecretRegistries(&corev1.Secret{Type: corev1.SecretTypeOpaque}); err == nil {
+		t.Fatal("expected an Opaque secret to be rejected")
+	}
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// cacheSyncTimeout bounds the initial LIST of the informer caches, which never completes when
+// the caller may list but not watch
+const cacheSyncTimeout = 2 * time.Minute
+
+// listerCache serves node, pod, and deployment lists from shared informers, which keep a watch
+// open and update in place instead of listing everything again on every check
+type listerCache struct {
+	nodes       corelisters.NodeLister
+	pods        corelisters.PodLister
+	deployments appslisters.DeploymentLister
+	// stop ends the informers' watches
+	stop context.CancelFunc
+}
+
+// StartCache switches the checker's node, pod, and deployment lists to shared informers for the
+// life of ctx. Long-running modes such as `cluster check --daemon` call it once so that repeated
+// checks cost the API server one LIST and a watch per resource instead of full LISTs every run.
+// It returns once the caches have synced; on error the checker keeps listing from the API server.
+func (kc *KubernetesChecker) StartCache(ctx context.Context) error {
+	cacheCtx, stop := context.WithCancel(ctx)
+	// Watches must outlive the per-request timeout, and managed fields are dropped to keep the
+	// cluster-wide pod cache small
+	factory := informers.NewSharedInformerFactoryWithOptions(kc.streamClientset, 0, informers.WithTransform(stripManagedFields))
+	cache := &listerCache{
+		nodes:       factory.Core().V1().Nodes().Lister(),
+		pods:        factory.Core().V1().Pods().Lister(),
+		deployments: factory.Apps().V1().Deployments().Lister(),
+		stop:        stop,
+	}
+	factory.Start(cacheCtx.Done())
+
+	LogInfo("Waiting for node, pod, and deployment caches to sync...")
+	syncCtx, cancel := context.WithTimeout(cacheCtx, cacheSyncTimeout)
+	defer cancel()
+	var unsynced []string
+	for informerType, synced := range factory.WaitForCacheSync(syncCtx.Done()) {
+		if !synced {
+			unsynced = append(unsynced, informerType.String())
+		}
+	}
+	if len(unsynced) > 0 {
+		stop()
+		sort.Strings(unsynced)
+		return fmt.Errorf("caches did not sync within %s (list and watch permissions needed): %s", cacheSyncTimeout, strings.Join(unsynced, ", "))
+	}
+	kc.cache = cache
+	return nil
+}
+
+// stripManagedFields drops the managed fields of cached objects, which no check reads
+func stripManagedFields(obj any) (any, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return obj, nil
+}
+
+// listNodes lists the nodes matching a label selector (all nodes when empty), from the cache
+// once StartCache has run. Callers must not modify the nodes.
+func (kc *KubernetesChecker) listNodes(ctx context.Context, selector string) ([]corev1.Node, error) {
+	if kc.cache == nil {
+		nodes, err := kc.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		return nodes.Items, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := kc.cache.nodes.List(sel)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]corev1.Node, len(cached))
+	for i, n := range cached {
+		nodes[i] = *n
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+// listPods lists the pods of a namespace (all namespaces when empty), from the cache once
+// StartCache has run and in pages otherwise. Callers must not modify the pods.
+func (kc *KubernetesChecker) listPods(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+	if kc.cache == nil {
+		var pods []corev1.Pod
+		opts := metav1.ListOptions{Limit: podListPageSize}
+		for {
+			page, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			pods = append(pods, page.Items...)
+			if page.Continue == "" {
+				return pods, nil
+			}
+			opts.Continue = page.Continue
+		}
+	}
+	var cached []*corev1.Pod
+	var err error
+	if namespace == "" {
+		cached, err = kc.cache.pods.List(labels.Everything())
+	} else {
+		cached, err = kc.cache.pods.Pods(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, len(cached))
+	for i, p := range cached {
+		pods[i] = *p
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// listDeployments lists the deployments of a namespace matching a label selector, from the cache
+// once StartCache has run. Callers must not modify the deployments.
+func (kc *KubernetesChecker) listDeployments(ctx context.Context, namespace, selector string) ([]appsv1.Deployment, error) {
+	if kc.cache == nil {
+		deployments, err := kc.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		return deployments.Items, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	var cached []*appsv1.Deployment
+	if namespace == "" {
+		cached, err = kc.cache.deployments.List(sel)
+	} else {
+		cached, err = kc.cache.deployments.Deployments(namespace).List(sel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]appsv1.Deployment, len(cached))
+	for i, d := range cached {
+		deployments[i] = *d
+	}
+	sort.Slice(deployments, func(i, j int) bool { return deployments[i].Name < deployments[j].Name })
+	return deployments, nil
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCachedLists(t *testing.T) {
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	_ = nodes.Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"pool": "gpu"}}})
+	_ = nodes.Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cpu-1", Labels: map[string]string{"pool": "general"}}})
+	_ = pods.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: "api"}, Spec: corev1.PodSpec{NodeName: "cpu-1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}})
+	_ = pods.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: "migrate"}, Spec: corev1.PodSpec{NodeName: "cpu-1"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}})
+	_ = pods.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: "pending"}, Status: corev1.PodStatus{Phase: corev1.PodPending}})
+	_ = pods.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "dns"}, Spec: corev1.PodSpec{NodeName: "gpu-1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}})
+	_ = deployments.Add(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: "api", Labels: map[string]string{"app": "api"}}})
+	_ = deployments.Add(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "web"}})
+
+	kc := &KubernetesChecker{cache: &listerCache{
+		nodes:       corelisters.NewNodeLister(nodes),
+		pods:        corelisters.NewPodLister(pods),
+		deployments: appslisters.NewDeploymentLister(deployments),
+	}}
+	ctx := context.Background()
+
+	all, err := kc.listNodes(ctx, "")
+	if err != nil || len(all) != 2 || all[0].Name != "cpu-1" {
+		t.Errorf("Expected both nodes sorted by name, got %v (%v)", all, err)
+	}
+	gpu, err := kc.listNodes(ctx, "pool=gpu")
+	if err != nil || len(gpu) != 1 || gpu[0].Name != "gpu-1" {
+		t.Errorf("Expected the selector to pick gpu-1, got %v (%v)", gpu, err)
+	}
+
+	nsPods, err := kc.listPods(ctx, "dynamo")
+	if err != nil || len(nsPods) != 3 {
+		t.Errorf("Expected 3 pods in dynamo, got %d (%v)", len(nsPods), err)
+	}
+	byNode, err := kc.listPodsByNode(ctx)
+	if err != nil {
+		t.Fatalf("listPodsByNode returned error: %v", err)
+	}
+	if len(byNode["cpu-1"]) != 1 || byNode["cpu-1"][0].Name != "api" || len(byNode["gpu-1"]) != 1 || len(byNode) != 2 {
+		t.Errorf("Expected only scheduled, non-terminated pods by node, got %v", byNode)
+	}
+
+	deps, err := kc.listDeployments(ctx, "dynamo", "app=api")
+	if err != nil || len(deps) != 1 || deps[0].Name != "api" {
+		t.Errorf("Expected the api deployment, got %v (%v)", deps, err)
+	}
+	deps, err = kc.listDeployments(ctx, "", "")
+	if err != nil || len(deps) != 2 {
+		t.Errorf("Expected deployments of every namespace, got %v (%v)", deps, err)
+	}
+}
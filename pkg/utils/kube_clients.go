@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// KubeClients creates a command's KubernetesChecker on first use and hands the same one to every
+// check the command runs, so they share its connections, discovery cache, and server version
+// instead of each opening their own
+type KubeClients struct {
+	once sync.Once
+	kc   *KubernetesChecker
+	err  error
+}
+
+type kubeClientsKey struct{}
+
+// NewKubeClients returns clients that connect on the first call to Checker
+func NewKubeClients() *KubeClients {
+	return &KubeClients{}
+}
+
+// NewKubeClientsFor returns clients that hand out kc, without probing the API server
+func NewKubeClientsFor(kc *KubernetesChecker) *KubeClients {
+	clients := &KubeClients{kc: kc}
+	clients.once.Do(func() {})
+	return clients
+}
+
+// WithKubeClients attaches the clients to ctx
+func WithKubeClients(ctx context.Context, clients *KubeClients) context.Context {
+	return context.WithValue(ctx, kubeClientsKey{}, clients)
+}
+
+// KubeClientsFrom returns the clients attached to ctx, or new ones when there are none, as for
+// shell completions, which run without the root command's hooks
+func KubeClientsFrom(ctx context.Context) *KubeClients {
+	if ctx != nil {
+		if clients, ok := ctx.Value(kubeClientsKey{}).(*KubeClients); ok {
+			return clients
+		}
+	}
+	return NewKubeClients()
+}
+
+// Checker returns the shared KubernetesChecker. The first call loads the kubeconfig and probes the
+// API server's version, which doubles as the readiness check; its error is returned to every
+// later call too.
+func (c *KubeClients) Checker(ctx context.Context) (*KubernetesChecker, error) {
+	c.once.Do(func() {
+		kc, err := NewKubernetesChecker()
+		if err != nil {
+			c.err = err
+			return
+		}
+		if _, err := kc.ProbeServerVersion(ctx); err != nil {
+			c.err = fmt.Errorf("API server %s not reachable: %v", kc.config.Host, err)
+			return
+		}
+		c.kc = kc
+	})
+	return c.kc, c.err
+}
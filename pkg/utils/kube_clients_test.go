@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestKubeClientsFrom(t *testing.T) {
+	clients := NewKubeClients()
+	ctx := WithKubeClients(context.Background(), clients)
+	if KubeClientsFrom(ctx) != clients {
+		t.Error("Expected the clients attached to the context")
+	}
+	if KubeClientsFrom(context.Background()) == nil {
+		t.Error("Expected new clients for a context without any")
+	}
+}
+
+func TestKubeClientsCheckerConnectsOnce(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	clients := NewKubeClients()
+	_, first := clients.Checker(context.Background())
+	if first == nil {
+		t.Fatal("Expected an error without a kubeconfig")
+	}
+	if _, second := clients.Checker(context.Background()); second != first {
+		t.Errorf("Expected the first error to be returned again, got %v", second)
+	}
+}
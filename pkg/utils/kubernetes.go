@@ -2,22 +2,75 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// DefaultRequestTimeout bounds each Kubernetes API call unless overridden with --kube-timeout
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestTimeout is applied to every API request made by a KubernetesChecker. Zero disables it.
+var requestTimeout = DefaultRequestTimeout
+
+// SetRequestTimeout sets the per-request timeout for Kubernetes API calls
+func SetRequestTimeout(timeout time.Duration) {
+	requestTimeout = timeout
+}
+
+// Client-side rate limits of a KubernetesChecker, unless overridden with --kube-qps and
+// --kube-burst. client-go's own 5 QPS and burst of 10 throttle checks that list every pod of a
+// large cluster namespace by namespace.
+const (
+	DefaultKubeQPS   float32 = 50
+	DefaultKubeBurst         = 100
+)
+
+var (
+	kubeQPS   = DefaultKubeQPS
+	kubeBurst = DefaultKubeBurst
+)
+
+// SetRateLimits sets the client-side QPS and burst of Kubernetes API calls
+func SetRateLimits(qps float32, burst int) {
+	kubeQPS = qps
+	kubeBurst = burst
+}
+
 // KubernetesChecker handles Kubernetes cluster checks
 type KubernetesChecker struct {
-	clientset *kubernetes.Clientset
-	config    *rest.Config
+	clientset     kubernetes.Interface
+	dynamicClient dynamic.Interface
+	config        *rest.Config
+	// streamClientset has no request timeout so followed log streams are not cut off
+	streamClientset kubernetes.Interface
+	// discovery caches the API groups and resources the server serves for the checker's life
+	discovery discovery.CachedDiscoveryInterface
+	// cache serves node, pod, and deployment lists once StartCache has run
+	cache *listerCache
+	// platform is resolved from --platform on first use
+	platform string
+	// serverVersion is the API server's version as of the last probe
+	serverVersion *version.Info
+	// evidenceDir is where this checker saves API responses, when --evidence-dir is set
+	evidenceDir string
 }
 
 // NewKubernetesChecker creates a new Kubernetes checker
@@ -34,62 +87,207 @@ func NewKubernetesChecker() (*KubernetesChecker, error) {
 		}
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	config.QPS = kubeQPS
+	config.Burst = kubeBurst
+	var recorder *evidenceRecorder
+	if evidenceDir != "" {
+		if recorder, err = newEvidenceRecorder(evidenceDir); err != nil {
+			return nil, err
+		}
+		config.Wrap(recorder.wrap)
+		LogInfo("Saving API responses to %s", recorder.dir)
+	}
+	// All clients share one transport, so its connections and TLS sessions are reused
+	streamClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+	streamClientset, err := kubernetes.NewForConfigAndClient(config, streamClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+
+	config = rest.CopyConfig(config)
+	config.Timeout = requestTimeout
+	httpClient := &http.Client{Transport: streamClient.Transport, Timeout: requestTimeout}
+	clientset, err := kubernetes.NewForConfigAndClient(config, httpClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic kubernetes client: %v", err)
+	}
+
+	kc := &KubernetesChecker{
+		clientset:       clientset,
+		dynamicClient:   dynamicClient,
+		config:          config,
+		streamClientset: streamClientset,
+		discovery:       memory.NewMemCacheClient(clientset.Discovery()),
+	}
+	if recorder != nil {
+		kc.evidenceDir = recorder.dir
+	}
+	return kc, nil
+}
+
+// EvidenceDir returns the directory the checker saves API responses to, or "" if it does not
+func (kc *KubernetesChecker) EvidenceDir() string {
+	return kc.evidenceDir
+}
+
+// NewKubernetesCheckerForClients creates a checker around existing clients, such as the fake
+// clientsets of package kubetest. Both clients are used for streams too, and the checker has no
+// REST config, so port-forwards and exec are not available.
+func NewKubernetesCheckerForClients(clientset kubernetes.Interface, dynamicClient dynamic.Interface) *KubernetesChecker {
 	return &KubernetesChecker{
-		clientset: clientset,
-		config:    config,
-	}, nil
+		clientset:       clientset,
+		dynamicClient:   dynamicClient,
+		config:          &rest.Config{},
+		streamClientset: clientset,
+		discovery:       memory.NewMemCacheClient(clientset.Discovery()),
+	}
+}
+
+// CheckKubernetesVersion returns the Kubernetes cluster server version, probing the API server
+// only if it has not been yet
+func (kc *KubernetesChecker) CheckKubernetesVersion(ctx context.Context) (string, error) {
+	if kc.serverVersion != nil {
+		return kc.serverVersion.GitVersion, nil
+	}
+	return kc.ProbeServerVersion(ctx)
 }
 
-// CheckKubernetesVersion returns the Kubernetes cluster server version
-func (kc *KubernetesChecker) CheckKubernetesVersion() (string, error) {
-	version, err := kc.clientset.Discovery().ServerVersion()
+// ProbeServerVersion asks the API server for its version and remembers it. Long-running modes
+// probe on every run so an unreachable or upgraded server shows up.
+func (kc *KubernetesChecker) ProbeServerVersion(ctx context.Context) (string, error) {
+	restClient := kc.clientset.Discovery().RESTClient()
+	if restClient == nil {
+		// Fake clientsets have no REST client, only the version they were given
+		info, err := kc.clientset.Discovery().ServerVersion()
+		if err != nil {
+			return "", fmt.Errorf("failed to get server version: %v", err)
+		}
+		kc.serverVersion = info
+		return info.GitVersion, nil
+	}
+	body, err := restClient.Get().AbsPath("/version").Do(ctx).Raw()
 	if err != nil {
 		return "", fmt.Errorf("failed to get server version: %v", err)
 	}
-	return version.GitVersion, nil
+	var info version.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("failed to parse server version: %v", err)
+	}
+	kc.serverVersion = &info
+	return info.GitVersion, nil
 }
 
 // NodeResourceUsage holds resource usage information for a node
 type NodeResourceUsage struct {
-	Name                  string
-	CPURequests           float64
-	CPULimits             float64
-	MemoryRequests        float64
-	MemoryLimits          float64
-	GPURequests           int64
-	GPULimits             int64
-	CPUAllocatable        float64
-	MemoryAllocatable     float64
-	GPUAllocatable        int64
+	Name           string
+	InstanceType   string
+	NodePool       string
+	Zone           string
+	KubeletVersion string
+	// ContainerRuntime is the runtime's name, e.g. containerd, and ContainerRuntimeVersion its version
+	ContainerRuntime        string
+	ContainerRuntimeVersion string
+	OSImage                 string
+	KernelVersion           string
+	// VersionProblems lists node software outside SupportedNodeVersions or mixed within the pool
+	VersionProblems   []string `json:",omitempty"`
+	Taints            []string
+	GPUModel          string
+	GPUCount          int64
+	GPUMemoryMiB      int64
+	MIGProfiles       []string
+	CPURequests       float64
+	CPULimits         float64
+	MemoryRequests    float64
+	MemoryLimits      float64
+	GPURequests       int64
+	GPULimits         int64
+	CPUAllocatable    float64
+	MemoryAllocatable float64
+	GPUAllocatable    int64
+	// Accelerators totals each configured accelerator resource, nvidia.com/gpu included
+	Accelerators          []AcceleratorUsage
 	CPURequestsPercent    float64
 	CPULimitsPercent      float64
 	MemoryRequestsPercent float64
 	MemoryLimitsPercent   float64
+	// MonthlyCost is filled in by ApplyNodePricing; CostEstimated is set when the instance type
+	// was not in the price table and the cost was estimated from allocatable resources
+	MonthlyCost   float64 `json:",omitempty"`
+	CostEstimated bool    `json:",omitempty"`
 }
 
+// podListPageSize bounds each page of the cluster-wide pod list
+const podListPageSize = 500
+
 // GetNodeResourceUsage calculates resource usage percentages for a specific node
-func (kc *KubernetesChecker) GetNodeResourceUsage(nodeName string) (*NodeResourceUsage, error) {
-	// Get all pods in all namespaces to calculate resource usage
-	pods, err := kc.clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{
+func (kc *KubernetesChecker) GetNodeResourceUsage(ctx context.Context, nodeName string) (*NodeResourceUsage, error) {
+	pods, err := kc.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
 		FieldSelector: "spec.nodeName=" + nodeName,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods for node %s: %v", nodeName, err)
 	}
 
-	// Get node information
-	node, err := kc.clientset.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	node, err := kc.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
 	}
 
+	return nodeResourceUsage(node, pods.Items), nil
+}
+
+// listPodsByNode lists scheduled, non-terminated pods across the cluster in pages and groups
+// them by node name. One paginated list is far cheaper than a list per node on large clusters.
+func (kc *KubernetesChecker) listPodsByNode(ctx context.Context) (map[string][]corev1.Pod, error) {
+	byNode := map[string][]corev1.Pod{}
+	if kc.cache != nil {
+		pods, err := kc.listPods(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %v", err)
+		}
+		for _, pod := range pods {
+			if pod.Spec.NodeName != "" && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+				byNode[pod.Spec.NodeName] = append(byNode[pod.Spec.NodeName], pod)
+			}
+		}
+		return byNode, nil
+	}
+	opts := metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed",
+		Limit:         podListPageSize,
+	}
+	pages := 0
+	for {
+		pods, err := kc.clientset.CoreV1().Pods("").List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %v", err)
+		}
+		pages++
+		for _, pod := range pods.Items {
+			byNode[pod.Spec.NodeName] = append(byNode[pod.Spec.NodeName], pod)
+		}
+		if pods.Continue == "" {
+			break
+		}
+		opts.Continue = pods.Continue
+	}
+	LogDebug("Listed pods on %d nodes in %d pages", len(byNode), pages)
+	return byNode, nil
+}
+
+// nodeResourceUsage totals the effective requests and limits of running and pending pods on a node
+func nodeResourceUsage(node *corev1.Node, pods []corev1.Pod) *NodeResourceUsage {
 	usage := &NodeResourceUsage{
-		Name: nodeName,
+		Name: node.Name,
 	}
 
 	// Get allocatable resources
@@ -99,43 +297,51 @@ func (kc *KubernetesChecker) GetNodeResourceUsage(nodeName string) (*NodeResourc
 	if memory, ok := node.Status.Allocatable[corev1.ResourceMemory]; ok {
 		usage.MemoryAllocatable = float64((&memory).Value()) / (1024.0 * 1024.0 * 1024.0)
 	}
-	if gpu, ok := node.Status.Allocatable[corev1.ResourceName("nvidia.com/gpu")]; ok {
+	if gpu, ok := node.Status.Allocatable[gpuResource]; ok {
 		usage.GPUAllocatable = (&gpu).Value()
 	}
 
+	// Requests and limits of every resource, used to total each accelerator separately
+	podRequests := corev1.ResourceList{}
+	podLimits := corev1.ResourceList{}
+
 	// Calculate resource usage from pods
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		// Skip pods that are not running or are being terminated
 		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
 			continue
 		}
 
-		for _, container := range pod.Spec.Containers {
-			// CPU requests and limits
-			if req, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
-				usage.CPURequests += float64((&req).MilliValue()) / 1000.0
-			}
-			if lim, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
-				usage.CPULimits += float64((&lim).MilliValue()) / 1000.0
-			}
+		requests := PodEffectiveRequests(&pod)
+		limits := PodEffectiveLimits(&pod)
+		addResourceList(podRequests, requests)
+		addResourceList(podLimits, limits)
 
-			// Memory requests and limits
-			if req, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-				usage.MemoryRequests += float64((&req).Value()) / (1024.0 * 1024.0 * 1024.0)
-			}
-			if lim, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
-				usage.MemoryLimits += float64((&lim).Value()) / (1024.0 * 1024.0 * 1024.0)
-			}
+		// CPU requests and limits
+		if req, ok := requests[corev1.ResourceCPU]; ok {
+			usage.CPURequests += float64((&req).MilliValue()) / 1000.0
+		}
+		if lim, ok := limits[corev1.ResourceCPU]; ok {
+			usage.CPULimits += float64((&lim).MilliValue()) / 1000.0
+		}
 
-			// GPU requests and limits
-			if req, ok := container.Resources.Requests[corev1.ResourceName("nvidia.com/gpu")]; ok {
-				usage.GPURequests += (&req).Value()
-			}
-			if lim, ok := container.Resources.Limits[corev1.ResourceName("nvidia.com/gpu")]; ok {
-				usage.GPULimits += (&lim).Value()
-			}
+		// Memory requests and limits
+		if req, ok := requests[corev1.ResourceMemory]; ok {
+			usage.MemoryRequests += float64((&req).Value()) / (1024.0 * 1024.0 * 1024.0)
+		}
+		if lim, ok := limits[corev1.ResourceMemory]; ok {
+			usage.MemoryLimits += float64((&lim).Value()) / (1024.0 * 1024.0 * 1024.0)
+		}
+
+		// GPU requests and limits
+		if req, ok := requests[gpuResource]; ok {
+			usage.GPURequests += (&req).Value()
+		}
+		if lim, ok := limits[gpuResource]; ok {
+			usage.GPULimits += (&lim).Value()
 		}
 	}
+	usage.Accelerators = nodeAccelerators(node.Status.Allocatable, podRequests, podLimits)
 
 	if usage.CPUAllocatable > 0 {
 		usage.CPURequestsPercent = usage.CPURequests / usage.CPUAllocatable * 100
@@ -146,141 +352,276 @@ func (kc *KubernetesChecker) GetNodeResourceUsage(nodeName string) (*NodeResourc
 		usage.MemoryLimitsPercent = usage.MemoryLimits / usage.MemoryAllocatable * 100
 	}
 
-	return usage, nil
+	return usage
+}
+
+// ClusterResourceSummary aggregates allocatable and requested resources across ready nodes
+type ClusterResourceSummary struct {
+	TotalNodes            int
+	ReadyNodes            int
+	CPUAllocatable        float64
+	CPURequests           float64
+	CPUAvailable          float64
+	CPURequestsPercent    float64
+	MemoryAllocatable     float64
+	MemoryRequests        float64
+	MemoryAvailable       float64
+	MemoryRequestsPercent float64
+	GPUAllocatable        int64
+	GPURequests           int64
+	Accelerators          []AcceleratorUsage
+	ByInstanceType        []CapacityGroup
+	// ByNodePool is empty when no node carries a node pool label
+	ByNodePool []CapacityGroup
+	// MonthlyCost totals the node costs filled in by ApplyNodePricing, in Currency
+	MonthlyCost float64 `json:",omitempty"`
+	Currency    string  `json:",omitempty"`
+	// EstimatedInstanceTypes lists instance types missing from the price table
+	EstimatedInstanceTypes []string `json:",omitempty"`
+}
+
+// CapacityGroup totals allocatable and requested resources for a group of nodes
+type CapacityGroup struct {
+	Name                  string
+	Nodes                 int
+	CPUAllocatable        float64
+	CPURequests           float64
+	CPUAvailable          float64
+	CPURequestsPercent    float64
+	MemoryAllocatable     float64
+	MemoryRequests        float64
+	MemoryAvailable       float64
+	MemoryRequestsPercent float64
+	GPUAllocatable        int64
+	GPURequests           int64
+	Accelerators          []AcceleratorUsage
+}
+
+// String formats the summary as a one-line check result
+func (s ClusterResourceSummary) String() string {
+	return fmt.Sprintf("CPU: %.1f cores available, %.1f cores allocatable (%.1f%% already requested), Mem: %.1f GB available, %.1f GB allocatable (%.1f%% already requested)",
+		s.CPUAvailable, s.CPUAllocatable, s.CPURequestsPercent, s.MemoryAvailable, s.MemoryAllocatable, s.MemoryRequestsPercent)
 }
 
-// CheckResources checks available CPU and memory resources
-func (kc *KubernetesChecker) CheckResources(outputFormat string) (string, error) {
-	nodes, err := kc.clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+// Sort keys accepted by SortNodeResources
+const (
+	NodeSortName         = "name"
+	NodeSortInstanceType = "instance-type"
+	NodeSortCPURequests  = "cpu-req"
+	NodeSortMemRequests  = "mem-req"
+	NodeSortGPU          = "gpu"
+)
+
+// NodeSortKeys lists the valid --sort-by values for node resource output
+var NodeSortKeys = []string{NodeSortName, NodeSortInstanceType, NodeSortCPURequests, NodeSortMemRequests, NodeSortGPU}
+
+// GatherNodeResources returns resource usage for every ready node matching the label selector
+// (all nodes when empty), sorted by instance type, along with totals for those nodes
+func (kc *KubernetesChecker) GatherNodeResources(ctx context.Context, selector string) ([]NodeResourceUsage, ClusterResourceSummary, error) {
+	nodes, err := kc.listNodes(ctx, selector)
 	if err != nil {
-		return "", fmt.Errorf("failed to list nodes: %v", err)
+		return nil, ClusterResourceSummary{}, fmt.Errorf("failed to list nodes: %v", err)
 	}
 
-	var totalCPURequests, totalMemoryRequests float64
-	var totalCPUCores, totalMemoryGB float64
-	readyNodes := 0
-
-	LogInfo("Checking resources on %d nodes...", len(nodes.Items))
+	LogInfo("Checking resources on %d nodes...", len(nodes))
 
-	// Create a slice to hold node information for sorting
-	type nodeInfo struct {
-		node         *corev1.Node
-		instanceType string
+	podsByNode, err := kc.listPodsByNode(ctx)
+	if err != nil {
+		return nil, ClusterResourceSummary{}, err
 	}
-	var nodeInfos []nodeInfo
 
-	// Collect node information and instance types
-	for _, node := range nodes.Items {
-		instanceType := "unknown"
-		if it, ok := node.Labels["node.kubernetes.io/instance-type"]; ok {
-			instanceType = it
-		} else if it, ok := node.Labels["beta.kubernetes.io/instance-type"]; ok {
-			instanceType = it
-		} else if it, ok := node.Labels["node.k8s.io/instance-type"]; ok {
-			instanceType = it
+	readyNodes := 0
+	var usages []NodeResourceUsage
+	for i := range nodes {
+		node := &nodes[i]
+		if !isNodeReady(node) {
+			LogInfo("Skipping node '%s' - not ready", node.Name)
+			continue
 		}
+		readyNodes++
 
-		nodeInfos = append(nodeInfos, nodeInfo{node: &node, instanceType: instanceType})
+		usage := nodeResourceUsage(node, podsByNode[node.Name])
+		usage.InstanceType = instanceTypeFromLabels(node.Labels)
+		usage.NodePool = nodePoolLabel(node.Labels)
+		usage.Zone = node.Labels[corev1.LabelTopologyZone]
+		usage.KubeletVersion = node.Status.NodeInfo.KubeletVersion
+		usage.ContainerRuntime, usage.ContainerRuntimeVersion = splitContainerRuntime(node.Status.NodeInfo.ContainerRuntimeVersion)
+		usage.OSImage = node.Status.NodeInfo.OSImage
+		usage.KernelVersion = node.Status.NodeInfo.KernelVersion
+		for _, taint := range node.Spec.Taints {
+			usage.Taints = append(usage.Taints, formatTaint(taint))
+		}
+		usage.GPUModel, usage.GPUCount, usage.GPUMemoryMiB, usage.MIGProfiles = nodeGPUInfo(node)
+		usages = append(usages, *usage)
 	}
 
 	// Sort by instance type alphabetically
-	sort.Slice(nodeInfos, func(i, j int) bool {
-		return nodeInfos[i].instanceType < nodeInfos[j].instanceType
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].InstanceType != usages[j].InstanceType {
+			return usages[i].InstanceType < usages[j].InstanceType
+		}
+		return usages[i].Name < usages[j].Name
 	})
 
-	// Print header based on output format
-	if outputFormat == "csv" {
-		fmt.Printf("Name,Type,CPU_Capacity_Cores,Memory_Capaclity_GB,CPU_Requests_%%,CPU_Limits_%%,Memory_Requests_%%,Memory_Limits_%%,GPU_Alloc_Total\n")
+	serverVersion := ""
+	if version, err := kc.discovery.ServerVersion(); err != nil {
+		LogDebug("Skipping kubelet version skew check: %v", err)
 	} else {
-		// Print table header
-		fmt.Printf("Name\t\t\t\tType\t\tCPU\tMem(GB)\tCPU\tCPU\tMem\tMem\tGPU\n")
-		fmt.Printf("\t\t\t\t\t\tCapcty\tCapcty\t%%Req\t%%Limit\t%%Req\t%%Limit\tAlloc/Total\n")
-		fmt.Printf("----------------------------------------------------------------------------------------------------------------\n")
-	}
-
-	for _, nodeInfo := range nodeInfos {
-		node := nodeInfo.node
-		instanceType := nodeInfo.instanceType
-		// Check if node is ready
-		isReady := false
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
-				isReady = true
-				break
+		serverVersion = version.GitVersion
+	}
+	CheckNodeVersions(usages, serverVersion, SupportedNodeVersions)
+
+	summary := SummarizeNodeResources(usages)
+	summary.TotalNodes = len(nodes)
+	summary.ReadyNodes = readyNodes
+
+	LogInfo("Total ready nodes: %d", readyNodes)
+	LogInfo("Resource totals - CPU: %.1f cores allocatable; Memory: %.1f GB allocatable", summary.CPUAllocatable, summary.MemoryAllocatable)
+
+	return usages, summary, nil
+}
+
+// SortNodeResources orders nodes by the given key. Usage keys sort the most loaded nodes first;
+// name and instance-type sort alphabetically.
+func SortNodeResources(usages []NodeResourceUsage, key string) error {
+	var less func(a, b NodeResourceUsage) bool
+	switch key {
+	case NodeSortName:
+		less = func(a, b NodeResourceUsage) bool { return a.Name < b.Name }
+	case NodeSortInstanceType, "":
+		less = func(a, b NodeResourceUsage) bool { return a.InstanceType < b.InstanceType }
+	case NodeSortCPURequests:
+		less = func(a, b NodeResourceUsage) bool { return a.CPURequestsPercent > b.CPURequestsPercent }
+	case NodeSortMemRequests:
+		less = func(a, b NodeResourceUsage) bool { return a.MemoryRequestsPercent > b.MemoryRequestsPercent }
+	case NodeSortGPU:
+		less = func(a, b NodeResourceUsage) bool {
+			if a.GPUAllocatable != b.GPUAllocatable {
+				return a.GPUAllocatable > b.GPUAllocatable
 			}
+			return a.GPURequests > b.GPURequests
 		}
+	default:
+		return fmt.Errorf("unknown sort key %q (valid: %s)", key, strings.Join(NodeSortKeys, ", "))
+	}
 
-		if !isReady {
-			LogInfo("Skipping node '%s' - not ready", node.Name)
-			continue
+	sort.SliceStable(usages, func(i, j int) bool {
+		if less(usages[i], usages[j]) {
+			return true
 		}
+		if less(usages[j], usages[i]) {
+			return false
+		}
+		return usages[i].Name < usages[j].Name
+	})
+	return nil
+}
 
-		readyNodes++
-
-		// Get resource usage percentages
-		usage, err := kc.GetNodeResourceUsage(node.Name)
-		if err != nil {
-			LogInfo("Node '%s' - failed to get usage: %v", node.Name, err)
-			continue
+// NodeLabelSelector combines a label selector with key=value node label filters into one selector
+func NodeLabelSelector(selector string, nodeLabels []string) (string, error) {
+	parts := []string{}
+	if selector != "" {
+		parts = append(parts, selector)
+	}
+	for _, l := range nodeLabels {
+		key, _, _ := strings.Cut(l, "=")
+		if strings.TrimSpace(key) == "" {
+			return "", fmt.Errorf("invalid --node-label %q (expected key or key=value)", l)
 		}
+		parts = append(parts, l)
+	}
+	combined := strings.Join(parts, ",")
+	if _, err := labels.Parse(combined); err != nil {
+		return "", fmt.Errorf("invalid node selector %q: %v", combined, err)
+	}
+	return combined, nil
+}
 
-		// Accumulate the converted values for accurate metrics
-		totalCPUCores += usage.CPUAllocatable
-		totalMemoryGB += usage.MemoryAllocatable
-		totalCPURequests += usage.CPURequests
-		totalMemoryRequests += usage.MemoryRequests
+// SummarizeNodeResources totals per-node usage into cluster-wide figures and breaks them down by
+// instance type and node pool. Percentages are based on allocatable resources, matching the
+// per-node percentages.
+func SummarizeNodeResources(usages []NodeResourceUsage) ClusterResourceSummary {
+	total := sumCapacity("", usages)
+	summary := ClusterResourceSummary{
+		TotalNodes:            len(usages),
+		ReadyNodes:            len(usages),
+		CPUAllocatable:        total.CPUAllocatable,
+		CPURequests:           total.CPURequests,
+		CPUAvailable:          total.CPUAvailable,
+		CPURequestsPercent:    total.CPURequestsPercent,
+		MemoryAllocatable:     total.MemoryAllocatable,
+		MemoryRequests:        total.MemoryRequests,
+		MemoryAvailable:       total.MemoryAvailable,
+		MemoryRequestsPercent: total.MemoryRequestsPercent,
+		GPUAllocatable:        total.GPUAllocatable,
+		GPURequests:           total.GPURequests,
+		Accelerators:          total.Accelerators,
+	}
+	summary.ByInstanceType = groupCapacity(usages, func(u NodeResourceUsage) string { return u.InstanceType })
+	summary.ByNodePool = groupCapacity(usages, func(u NodeResourceUsage) string { return u.NodePool })
+	return summary
+}
 
-		// Format GPU info
-		gpuInfo := ""
-		if usage.GPUAllocatable > 0 {
-			gpuInfo = fmt.Sprintf("%d/%d", usage.GPURequests, usage.GPUAllocatable)
+// groupCapacity totals nodes by the key function, skipping nodes with an empty key
+func groupCapacity(usages []NodeResourceUsage, key func(NodeResourceUsage) string) []CapacityGroup {
+	members := map[string][]NodeResourceUsage{}
+	var names []string
+	for _, u := range usages {
+		k := key(u)
+		if k == "" {
+			continue
 		}
-
-		// Print row based on output format
-		if outputFormat == "csv" {
-			fmt.Printf("%s,%s,%.2f,%.2f,%.1f,%.1f,%.1f,%.1f,%s\n",
-				node.Name, instanceType, usage.CPUAllocatable, usage.MemoryAllocatable,
-				usage.CPURequestsPercent, usage.CPULimitsPercent, usage.MemoryRequestsPercent, usage.MemoryLimitsPercent, gpuInfo)
-		} else {
-			// Print table row
-			fmt.Printf("%s\t%s\t%.2f\t%.2f\t%.1f%%\t%.1f%%\t%.1f%%\t%.1f%%\t%s\n",
-				node.Name, instanceType, usage.CPUAllocatable, usage.MemoryAllocatable,
-				usage.CPURequestsPercent, usage.CPULimitsPercent, usage.MemoryRequestsPercent, usage.MemoryLimitsPercent, gpuInfo)
+		if _, ok := members[k]; !ok {
+			names = append(names, k)
 		}
+		members[k] = append(members[k], u)
 	}
+	sort.Strings(names)
 
-	LogInfo("Total ready nodes: %d", readyNodes)
-
-	// Log the difference between total capacity and allocatable for debugging
-	LogInfo("Resource totals - CPU: %.1f cores allocatable; Memory: %.1f GB allocatable", totalCPUCores, totalMemoryGB)
+	groups := make([]CapacityGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, sumCapacity(name, members[name]))
+	}
+	return groups
+}
 
-	// Calculate aggregated percentages based on allocatable resources (consistent with individual node percentages)
-	// This ensures the cluster summary percentages match what users see when they manually calculate
-	// using the individual node percentages shown in the table
-	aggregatedCPUPercent := float64(0)
-	aggregatedMemoryPercent := float64(0)
+func sumCapacity(name string, usages []NodeResourceUsage) CapacityGroup {
+	g := CapacityGroup{Name: name, Nodes: len(usages)}
+	for _, u := range usages {
+		g.CPUAllocatable += u.CPUAllocatable
+		g.CPURequests += u.CPURequests
+		g.MemoryAllocatable += u.MemoryAllocatable
+		g.MemoryRequests += u.MemoryRequests
+		g.GPUAllocatable += u.GPUAllocatable
+		g.GPURequests += u.GPURequests
+		g.Accelerators = addAccelerators(g.Accelerators, u.Accelerators)
+	}
 
-	if totalCPUCores > 0 {
-		aggregatedCPUPercent = float64(totalCPURequests) / float64(totalCPUCores) * 100
+	if g.CPUAllocatable > 0 {
+		g.CPURequestsPercent = g.CPURequests / g.CPUAllocatable * 100
 	}
-	if totalMemoryGB > 0 {
-		aggregatedMemoryPercent = float64(totalMemoryRequests) / float64(totalMemoryGB) * 100
+	if g.MemoryAllocatable > 0 {
+		g.MemoryRequestsPercent = g.MemoryRequests / g.MemoryAllocatable * 100
 	}
 
-	// Calculate available resources (allocatable minus what's already requested)
-	availableCPUCores := totalCPUCores - totalCPURequests
-	availableMemoryGB := totalMemoryGB - totalMemoryRequests
-
-	fmt.Printf("\nCLUSTER SUMMARY:\n")
-	fmt.Printf("CPU: %.1f cores available, %.1f cores allocatable (%.1f%% already requested)\n", availableCPUCores, totalCPUCores, aggregatedCPUPercent)
-	fmt.Printf("Mem: %.1f GB available, %.1f GB allocatable (%.1f%% already requested)\n", availableMemoryGB, totalMemoryGB, aggregatedMemoryPercent)
+	// Available is allocatable minus what's already requested
+	g.CPUAvailable = g.CPUAllocatable - g.CPURequests
+	g.MemoryAvailable = g.MemoryAllocatable - g.MemoryRequests
+	return g
+}
 
-	return fmt.Sprintf("CPU: %.1f cores available, %.1f cores allocatable (%.1f%% already requested), Mem: %.1f GB available, %.1f GB allocatable (%.1f%% already requested)",
-		availableCPUCores, totalCPUCores, aggregatedCPUPercent, availableMemoryGB, totalMemoryGB, aggregatedMemoryPercent), nil
+// CheckResources checks available CPU and memory resources across ready nodes
+func (kc *KubernetesChecker) CheckResources(ctx context.Context) (string, error) {
+	_, summary, err := kc.GatherNodeResources(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return summary.String(), nil
 }
 
-// CheckNamespaceRBAC checks RBAC permissions in the specified namespace using SelfSubjectAccessReview
-func (kc *KubernetesChecker) CheckNamespaceRBAC(namespace string) (string, error) {
+// CheckNamespaceRBAC checks RBAC permissions in the specified namespace for the subject, the
+// caller when it is the zero AccessSubject
+func (kc *KubernetesChecker) CheckNamespaceRBAC(ctx context.Context, namespace string, subject AccessSubject) (string, error) {
 	type nsPerm struct {
 		description string
 		group       string
@@ -295,115 +636,149 @@ func (kc *KubernetesChecker) CheckNamespaceRBAC(namespace string) (string, error
 		{description: "configmap create", group: "", resource: "configmaps", verb: "create"},
 		{description: "secret create", group: "", resource: "secrets", verb: "create"},
 	}
+	// OpenShift exposes the deployment through Routes rather than Ingresses
+	if kc.Platform() == PlatformOpenShift {
+		checks = append(checks, nsPerm{description: "route create", group: "route.openshift.io", resource: "routes", verb: "create"})
+	}
 
 	for _, c := range checks {
-		LogInfo("Checking permission: %s in namespace '%s'...", c.description, namespace)
-		ssar := &authorizationv1.SelfSubjectAccessReview{
-			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
-				ResourceAttributes: &authorizationv1.ResourceAttributes{
-					Namespace: namespace,
-					Group:     c.group,
-					Resource:  c.resource,
-					Verb:      c.verb,
-				},
-			},
-		}
-
-		resp, err := kc.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(), ssar, metav1.CreateOptions{})
+		LogInfo("Checking permission: %s in namespace '%s' for %s...", c.description, namespace, subject)
+		status, err := kc.reviewAccess(ctx, subject, authorizationv1.ResourceAttributes{
+			Namespace: namespace,
+			Group:     c.group,
+			Resource:  c.resource,
+			Verb:      c.verb,
+		})
 		if err != nil {
 			return "", fmt.Errorf("failed to perform access review for %s: %v", c.description, err)
 		}
-		if !resp.Status.Allowed {
-			return "", fmt.Errorf("missing permission: %s in namespace %s (%s)", c.description, namespace, resp.Status.Reason)
+		if !status.Allowed {
+			return "", fmt.Errorf("missing permission: %s in namespace %s%s (%s)", c.description, namespace, subjectSuffix(subject), status.Reason)
 		}
 	}
 
-	return "all required permissions available", nil
+	return "all required permissions available" + subjectSuffix(subject), nil
 }
 
-// CheckClusterRBAC checks cluster-level RBAC permissions using SelfSubjectAccessReview
-func (kc *KubernetesChecker) CheckClusterRBAC() (string, error) {
-	LogInfo("Checking cluster-level permission to create CRDs...")
-	ssar := &authorizationv1.SelfSubjectAccessReview{
-		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:    "apiextensions.k8s.io",
-				Resource: "customresourcedefinitions",
-				Verb:     "create",
-			},
-		},
-	}
-
-	resp, err := kc.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(), ssar, metav1.CreateOptions{})
+// CheckClusterRBAC checks cluster-level RBAC permissions for the subject, the caller when it is
+// the zero AccessSubject
+func (kc *KubernetesChecker) CheckClusterRBAC(ctx context.Context, subject AccessSubject) (string, error) {
+	LogInfo("Checking cluster-level permission to create CRDs for %s...", subject)
+	status, err := kc.reviewAccess(ctx, subject, authorizationv1.ResourceAttributes{
+		Group:    "apiextensions.k8s.io",
+		Resource: "customresourcedefinitions",
+		Verb:     "create",
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to perform cluster access review: %v", err)
 	}
-	if !resp.Status.Allowed {
-		return "", fmt.Errorf("missing cluster permission to create CRDs (%s)", resp.Status.Reason)
+	if !status.Allowed {
+		return "", fmt.Errorf("missing cluster permission to create CRDs%s (%s)", subjectSuffix(subject), status.Reason)
 	}
 
-	return "all required cluster permissions available", nil
+	return "all required cluster permissions available" + subjectSuffix(subject), nil
 }
 
-// CheckStorageCapacity checks available storage capacity
-func (kc *KubernetesChecker) CheckStorageCapacity() (string, error) {
-	pvs, err := kc.clientset.CoreV1().PersistentVolumes().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to list persistent volumes: %v", err)
+// subjectSuffix names the subject of a permission message when it isn't the caller
+func subjectSuffix(subject AccessSubject) string {
+	if subject.IsSelf() {
+		return ""
 	}
+	return " for " + subject.User
+}
 
-	var totalCapacity, usedCapacity int64
-	for _, pv := range pvs.Items {
-		if pv.Status.Phase == corev1.VolumeBound {
-			capacity := pv.Spec.Capacity[corev1.ResourceStorage]
-			usedCapacity += (&capacity).Value()
-		}
-		capVal := pv.Spec.Capacity[corev1.ResourceStorage]
-		totalCapacity += (&capVal).Value()
+// ListNodeInstanceTypes returns a mapping of node name to instance type label
+func (kc *KubernetesChecker) ListNodeInstanceTypes(ctx context.Context) (map[string]string, error) {
+	nodes, err := kc.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
 	}
 
-	if totalCapacity == 0 {
-		return "no storage configured", nil
+	result := make(map[string]string, len(nodes.Items))
+	for _, node := range nodes.Items {
+		result[node.Name] = instanceTypeFromLabels(node.Labels)
 	}
+	return result, nil
+}
 
-	usagePercent := float64(usedCapacity) / float64(totalCapacity) * 100
-	if usagePercent > 80 {
-		return fmt.Sprintf("limited storage capacity (%.1f%% used)", usagePercent),
-			fmt.Errorf("storage usage above 80%%")
+// ListNamespaces returns the names of all namespaces, sorted
+func (kc *KubernetesChecker) ListNamespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := kc.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
 	}
 
-	return fmt.Sprintf("adequate storage capacity (%.1f%% used)", usagePercent), nil
+	names := make([]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
-// ListNodeInstanceTypes returns a mapping of node name to instance type label
-func (kc *KubernetesChecker) ListNodeInstanceTypes() (map[string]string, error) {
-	nodes, err := kc.clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list nodes: %v", err)
+// instanceTypeFromLabels reads the instance type from the well-known node labels
+func instanceTypeFromLabels(labels map[string]string) string {
+	instanceType := labels["node.kubernetes.io/instance-type"]
+	if instanceType == "" {
+		instanceType = labels["beta.kubernetes.io/instance-type"]
+	}
+	if instanceType == "" {
+		instanceType = labels["node.k8s.io/instance-type"]
+	}
+	if instanceType == "" {
+		instanceType = "unknown"
 	}
+	return instanceType
+}
 
-	result := make(map[string]string, len(nodes.Items))
-	for _, node := range nodes.Items {
-		labels := node.Labels
-		instanceType := labels["node.kubernetes.io/instance-type"]
-		if instanceType == "" {
-			instanceType = labels["beta.kubernetes.io/instance-type"]
-		}
-		if instanceType == "" {
-			instanceType = labels["node.k8s.io/instance-type"]
+// Labels and resources published by NVIDIA GPU feature discovery
+const (
+	gpuProductLabel   = "nvidia.com/gpu.product"
+	gpuCountLabel     = "nvidia.com/gpu.count"
+	gpuMemoryLabel    = "nvidia.com/gpu.memory"
+	migResourcePrefix = "nvidia.com/mig-"
+)
+
+// nodeGPUInfo reads the GPU model, physical GPU count, per-GPU memory in MiB, and MIG slices
+// ("1g.5gb x7") from a node's feature discovery labels and allocatable resources
+func nodeGPUInfo(node *corev1.Node) (string, int64, int64, []string) {
+	model := node.Labels[gpuProductLabel]
+	count, _ := strconv.ParseInt(node.Labels[gpuCountLabel], 10, 64)
+	memory, _ := strconv.ParseInt(node.Labels[gpuMemoryLabel], 10, 64)
+
+	var mig []string
+	for name, qty := range node.Status.Allocatable {
+		profile, ok := strings.CutPrefix(string(name), migResourcePrefix)
+		if !ok || qty.IsZero() {
+			continue
 		}
-		if instanceType == "" {
-			instanceType = "unknown"
+		mig = append(mig, fmt.Sprintf("%s x%d", profile, qty.Value()))
+	}
+	sort.Strings(mig)
+
+	// With the single MIG strategy slices are advertised as nvidia.com/gpu and the product label
+	// carries the profile, e.g. NVIDIA-A100-SXM4-40GB-MIG-1g.5gb
+	if base, profile, ok := strings.Cut(model, "-MIG-"); ok {
+		model = base
+		if gpu, ok := node.Status.Allocatable[gpuResource]; ok && !gpu.IsZero() {
+			mig = append(mig, fmt.Sprintf("%s x%d", profile, gpu.Value()))
 		}
-		result[node.Name] = instanceType
 	}
-	return result, nil
+	return model, count, memory, mig
+}
+
+// formatTaint renders a taint the way kubectl describe does, e.g. nvidia.com/gpu=present:NoSchedule
+func formatTaint(taint corev1.Taint) string {
+	if taint.Value == "" {
+		return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
 }
 
 // CheckStorageClassesCompatibility checks StorageClasses for common database compatibility
-func (kc *KubernetesChecker) CheckStorageClassesCompatibility() (string, error) {
+func (kc *KubernetesChecker) CheckStorageClassesCompatibility(ctx context.Context) (string, error) {
 	LogInfo("Checking StorageClasses for database compatibility...")
-	storageClasses, err := kc.clientset.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	storageClasses, err := kc.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to list StorageClasses: %v", err)
 	}
@@ -439,18 +814,35 @@ type ContainerResourceSummary struct {
 	LimitsCPU      string
 	LimitsMemory   string
 	LimitsGPU      string
+	// Accelerators counts other accelerator resources per container, keyed by resource name
+	Accelerators map[string]int64 `json:",omitempty"`
 }
 
-// DeploymentResourceSummary holds resource info for a deployment
+// DeploymentResourceSummary holds resource info for a deployment or other pod-owning workload
 type DeploymentResourceSummary struct {
-	Name       string
-	Pods       int32
-	Containers []ContainerResourceSummary
+	Kind string
+	Name string
+	// Replicas is the desired replica count from the spec; ReadyReplicas and AvailableReplicas
+	// come from the status and trail it during rollouts
+	Replicas          int32
+	ReadyReplicas     int32
+	AvailableReplicas int32
+	Containers        []ContainerResourceSummary
+	// MonthlyCost prices the workload's requests at --show-cost unit rates
+	MonthlyCost float64 `json:",omitempty"`
+
+	// podSelector selects the workload's pods; not part of the rendered output
+	podSelector string
+	// podLabels are the labels stamped on the workload's pods by its template
+	podLabels map[string]string
 }
 
-// ListDeploymentResourceSummaries lists deployments and summarizes container resource requests/limits
-func (kc *KubernetesChecker) ListDeploymentResourceSummaries(namespace string) ([]DeploymentResourceSummary, error) {
-	deployments, err := kc.clientset.AppsV1().Deployments(namespace).List(context.Background(), metav1.ListOptions{})
+// ListDeploymentResourceSummaries lists deployments matching the label selector (empty for all)
+// and summarizes container resource requests/limits
+func (kc *KubernetesChecker) ListDeploymentResourceSummaries(ctx context.Context, namespace, selector string) ([]DeploymentResourceSummary, error) {
+	deployments, err := kc.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list deployments in %s: %v", namespace, err)
 	}
@@ -458,55 +850,160 @@ func (kc *KubernetesChecker) ListDeploymentResourceSummaries(namespace string) (
 	summaries := make([]DeploymentResourceSummary, 0, len(deployments.Items))
 
 	for _, d := range deployments.Items {
-		depSummary := DeploymentResourceSummary{
-			Name:       d.Name,
-			Pods:       d.Status.Replicas,
-			Containers: make([]ContainerResourceSummary, 0, len(d.Spec.Template.Spec.Containers)),
-		}
-		for _, c := range d.Spec.Template.Spec.Containers {
-			req := c.Resources.Requests
-			lim := c.Resources.Limits
-
-			// CPU
-			var reqCPU, limCPU string
-			if q, ok := req[corev1.ResourceCPU]; ok {
-				reqCPU = q.String()
-			}
-			if q, ok := lim[corev1.ResourceCPU]; ok {
-				limCPU = q.String()
-			}
+		summaries = append(summaries, DeploymentResourceSummary{
+			Kind:              WorkloadKindDeployment,
+			Name:              d.Name,
+			Replicas:          replicasOrDefault(d.Spec.Replicas),
+			ReadyReplicas:     d.Status.ReadyReplicas,
+			AvailableReplicas: d.Status.AvailableReplicas,
+			Containers:        summarizeContainers(d.Spec.Template.Spec.Containers),
+			podSelector:       selectorString(d.Spec.Selector),
+			podLabels:         d.Spec.Template.Labels,
+		})
+	}
+
+	return summaries, nil
+}
+
+// summarizeContainers extracts cpu/memory/gpu requests and limits for each container
+func summarizeContainers(containers []corev1.Container) []ContainerResourceSummary {
+	result := make([]ContainerResourceSummary, 0, len(containers))
+	for _, c := range containers {
+		req := c.Resources.Requests
+		lim := c.Resources.Limits
+
+		// CPU
+		var reqCPU, limCPU string
+		if q, ok := req[corev1.ResourceCPU]; ok {
+			reqCPU = q.String()
+		}
+		if q, ok := lim[corev1.ResourceCPU]; ok {
+			limCPU = q.String()
+		}
+
+		// Memory
+		var reqMem, limMem string
+		if q, ok := req[corev1.ResourceMemory]; ok {
+			reqMem = q.String()
+		}
+		if q, ok := lim[corev1.ResourceMemory]; ok {
+			limMem = q.String()
+		}
+
+		// GPU (nvidia.com/gpu)
+		var reqGPU, limGPU string
+		if q, ok := req[gpuResource]; ok {
+			reqGPU = q.String()
+		}
+		if q, ok := lim[gpuResource]; ok {
+			limGPU = q.String()
+		}
+
+		result = append(result, ContainerResourceSummary{
+			Name:           c.Name,
+			RequestsCPU:    reqCPU,
+			RequestsMemory: reqMem,
+			RequestsGPU:    reqGPU,
+			LimitsCPU:      limCPU,
+			LimitsMemory:   limMem,
+			LimitsGPU:      limGPU,
+			Accelerators:   containerAccelerators(c),
+		})
+	}
+	return result
+}
 
-			// Memory
-			var reqMem, limMem string
-			if q, ok := req[corev1.ResourceMemory]; ok {
-				reqMem = q.String()
+// selectorString converts a label selector to its string form, ignoring invalid selectors
+func selectorString(selector *metav1.LabelSelector) string {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return ""
+	}
+	return sel.String()
+}
+
+// PodStatusSummary holds placement and health info for a single pod of a workload
+type PodStatusSummary struct {
+	Deployment   string
+	Name         string
+	Node         string
+	InstanceType string
+	Phase        string
+	Ready        bool
+	Restarts     int32
+	StartTime    *time.Time
+}
+
+// ListDeploymentPodSummaries returns pod-level status for each of the given workloads
+func (kc *KubernetesChecker) ListDeploymentPodSummaries(ctx context.Context, namespace string, workloads []DeploymentResourceSummary) ([]PodStatusSummary, error) {
+	instanceTypes, err := kc.ListNodeInstanceTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []PodStatusSummary
+	for _, workload := range workloads {
+		if workload.podSelector == "" {
+			LogDebug("No pod selector known for %s %s, skipping", workload.Kind, workload.Name)
+			continue
+		}
+		pods, err := kc.listPodsBySelector(ctx, namespace, workload.podSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range pods {
+			summary := PodStatusSummary{
+				Deployment:   workload.Name,
+				Name:         pod.Name,
+				Node:         pod.Spec.NodeName,
+				InstanceType: instanceTypes[pod.Spec.NodeName],
+				Phase:        string(pod.Status.Phase),
 			}
-			if q, ok := lim[corev1.ResourceMemory]; ok {
-				limMem = q.String()
+			if pod.Status.StartTime != nil {
+				start := pod.Status.StartTime.Time
+				summary.StartTime = &start
 			}
-
-			// GPU (nvidia.com/gpu)
-			var reqGPU, limGPU string
-			gpuRes := corev1.ResourceName("nvidia.com/gpu")
-			if q, ok := req[gpuRes]; ok {
-				reqGPU = q.String()
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+					summary.Ready = true
+					break
+				}
 			}
-			if q, ok := lim[gpuRes]; ok {
-				limGPU = q.String()
+			for _, cs := range pod.Status.ContainerStatuses {
+				summary.Restarts += cs.RestartCount
 			}
-
-			depSummary.Containers = append(depSummary.Containers, ContainerResourceSummary{
-				Name:           c.Name,
-				RequestsCPU:    reqCPU,
-				RequestsMemory: reqMem,
-				RequestsGPU:    reqGPU,
-				LimitsCPU:      limCPU,
-				LimitsMemory:   limMem,
-				LimitsGPU:      limGPU,
-			})
+			summaries = append(summaries, summary)
 		}
-		summaries = append(summaries, depSummary)
 	}
 
 	return summaries, nil
 }
+
+// FilterDeploymentSummaries keeps deployments matching any include pattern (all when empty)
+// and drops those matching any exclude pattern. Patterns use path.Match glob syntax.
+func FilterDeploymentSummaries(summaries []DeploymentResourceSummary, include, exclude []string) []DeploymentResourceSummary {
+	filtered := make([]DeploymentResourceSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if len(include) > 0 && !matchesAnyPattern(s.Name, include) {
+			continue
+		}
+		if matchesAnyPattern(s.Name, exclude) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarizeNodeResources(t *testing.T) {
+	summary := SummarizeNodeResources([]NodeResourceUsage{
+		{CPUAllocatable: 4, CPURequests: 1, MemoryAllocatable: 16, MemoryRequests: 4, GPUAllocatable: 2, GPURequests: 1},
+		{CPUAllocatable: 4, CPURequests: 3, MemoryAllocatable: 16, MemoryRequests: 4},
+	})
+
+	if summary.TotalNodes != 2 {
+		t.Errorf("TotalNodes = %d, want 2", summary.TotalNodes)
+	}
+	if summary.CPUAvailable != 4 || summary.CPURequestsPercent != 50 {
+		t.Errorf("CPU available/percent = %.1f/%.1f, want 4/50", summary.CPUAvailable, summary.CPURequestsPercent)
+	}
+	if summary.MemoryAvailable != 24 || summary.MemoryRequestsPercent != 25 {
+		t.Errorf("memory available/percent = %.1f/%.1f, want 24/25", summary.MemoryAvailable, summary.MemoryRequestsPercent)
+	}
+	if summary.GPUAllocatable != 2 || summary.GPURequests != 1 {
+		t.Errorf("GPU = %d/%d, want 1/2", summary.GPURequests, summary.GPUAllocatable)
+	}
+}
+
+func TestSummarizeNodeResourcesEmpty(t *testing.T) {
+	summary := SummarizeNodeResources(nil)
+	if summary.CPURequestsPercent != 0 || summary.MemoryRequestsPercent != 0 {
+		t.Errorf("expected zero percentages for an empty cluster, got %+v", summary)
+	}
+}
+
+func TestFormatTaint(t *testing.T) {
+	if got := formatTaint(corev1.Taint{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule}); got != "nvidia.com/gpu=present:NoSchedule" {
+		t.Errorf("formatTaint with value = %q", got)
+	}
+	if got := formatTaint(corev1.Taint{Key: "dedicated", Effect: corev1.TaintEffectNoExecute}); got != "dedicated:NoExecute" {
+		t.Errorf("formatTaint without value = %q", got)
+	}
+}
+
+func TestSortNodeResources(t *testing.T) {
+	usages := []NodeResourceUsage{
+		{Name: "b", InstanceType: "m5.large", CPURequestsPercent: 20},
+		{Name: "a", InstanceType: "g5.xlarge", CPURequestsPercent: 90, GPUAllocatable: 1},
+		{Name: "c", InstanceType: "c5.large", CPURequestsPercent: 20},
+	}
+
+	if err := SortNodeResources(usages, NodeSortCPURequests); err != nil {
+		t.Fatalf("SortNodeResources returned error: %v", err)
+	}
+	if got := usages[0].Name + usages[1].Name + usages[2].Name; got != "abc" {
+		t.Errorf("cpu-req order = %s, want abc (ties broken by name)", got)
+	}
+
+	if err := SortNodeResources(usages, NodeSortInstanceType); err != nil {
+		t.Fatalf("SortNodeResources returned error: %v", err)
+	}
+	if usages[0].InstanceType != "c5.large" {
+		t.Errorf("instance-type order starts with %s, want c5.large", usages[0].InstanceType)
+	}
+
+	if err := SortNodeResources(usages, "zone"); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
+
+func TestNodeLabelSelector(t *testing.T) {
+	got, err := NodeLabelSelector("pool=gpu", []string{"nvidia.com/gpu.present=true", "dedicated"})
+	if err != nil {
+		t.Fatalf("NodeLabelSelector returned error: %v", err)
+	}
+	if got != "pool=gpu,nvidia.com/gpu.present=true,dedicated" {
+		t.Errorf("NodeLabelSelector = %q", got)
+	}
+
+	if _, err := NodeLabelSelector("", []string{"=value"}); err == nil {
+		t.Error("expected error for label without key")
+	}
+}
+
+func TestNodeGPUInfo(t *testing.T) {
+	mixed := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-80GB",
+			"nvidia.com/gpu.count":   "2",
+			"nvidia.com/gpu.memory":  "81920",
+		}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			"nvidia.com/mig-3g.40gb": resource.MustParse("2"),
+			"nvidia.com/mig-1g.10gb": resource.MustParse("4"),
+			"nvidia.com/mig-2g.20gb": resource.MustParse("0"),
+		}},
+	}
+	model, count, memory, mig := nodeGPUInfo(mixed)
+	if model != "NVIDIA-A100-SXM4-80GB" || count != 2 || memory != 81920 {
+		t.Errorf("nodeGPUInfo = %s/%d/%d", model, count, memory)
+	}
+	if len(mig) != 2 || mig[0] != "1g.10gb x4" || mig[1] != "3g.40gb x2" {
+		t.Errorf("mixed MIG profiles = %v", mig)
+	}
+
+	single := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-40GB-MIG-1g.5gb"}},
+		Status:     corev1.NodeStatus{Allocatable: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("7")}},
+	}
+	model, _, _, mig = nodeGPUInfo(single)
+	if model != "NVIDIA-A100-SXM4-40GB" || len(mig) != 1 || mig[0] != "1g.5gb x7" {
+		t.Errorf("single-strategy MIG = %s %v", model, mig)
+	}
+}
+
+func TestSummarizeNodeResourcesGroups(t *testing.T) {
+	summary := SummarizeNodeResources([]NodeResourceUsage{
+		{InstanceType: "g5.xlarge", NodePool: "gpu", CPUAllocatable: 4, CPURequests: 2, GPUAllocatable: 1, GPURequests: 1},
+		{InstanceType: "g5.xlarge", NodePool: "gpu", CPUAllocatable: 4, CPURequests: 0, GPUAllocatable: 1},
+		{InstanceType: "m5.large", CPUAllocatable: 2, CPURequests: 1},
+	})
+
+	if len(summary.ByInstanceType) != 2 {
+		t.Fatalf("expected 2 instance type groups, got %+v", summary.ByInstanceType)
+	}
+	gpu := summary.ByInstanceType[0]
+	if gpu.Name != "g5.xlarge" || gpu.Nodes != 2 || gpu.CPUAvailable != 6 || gpu.CPURequestsPercent != 25 || gpu.GPUAllocatable != 2 {
+		t.Errorf("unexpected g5.xlarge group: %+v", gpu)
+	}
+	if len(summary.ByNodePool) != 1 || summary.ByNodePool[0].Name != "gpu" || summary.ByNodePool[0].Nodes != 2 {
+		t.Errorf("unlabelled nodes should be left out of the node pool breakdown: %+v", summary.ByNodePool)
+	}
+}
+
+func TestNodeResourceUsage(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		}},
+	}
+	pod := func(phase corev1.PodPhase, cpu string) corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	usage := nodeResourceUsage(node, []corev1.Pod{
+		pod(corev1.PodRunning, "1"),
+		pod(corev1.PodPending, "500m"),
+		pod(corev1.PodSucceeded, "2"),
+	})
+	if usage.Name != "node-1" || usage.CPURequests != 1.5 || usage.MemoryRequests != 2 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if usage.CPURequestsPercent != 37.5 || usage.MemoryRequestsPercent != 25 {
+		t.Errorf("unexpected percentages cpu=%.1f mem=%.1f", usage.CPURequestsPercent, usage.MemoryRequestsPercent)
+	}
+}
+
+func TestDiagnoseNode(t *testing.T) {
+	changed := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Reason: "NodeStatusUnknown", LastTransitionTime: changed},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasDiskPressure"},
+		}},
+	}
+	var events []corev1.Event
+	for i := 0; i < 12; i++ {
+		events = append(events, corev1.Event{
+			Type:          corev1.EventTypeWarning,
+			Reason:        fmt.Sprintf("Reason%d", i),
+			LastTimestamp: metav1.NewTime(time.Now().Add(-time.Duration(i) * time.Minute)),
+		})
+	}
+
+	d := diagnoseNode(node, events)
+	if d.Name != "node-1" || !d.Unschedulable {
+		t.Errorf("unexpected diagnosis %+v", d)
+	}
+	if len(d.Conditions) != 2 || d.Conditions[0].Type != "Ready" || d.Conditions[1].Type != "DiskPressure" {
+		t.Errorf("expected Ready and DiskPressure conditions, got %+v", d.Conditions)
+	}
+	if !d.Conditions[0].LastTransitionTime.Equal(changed.Time) {
+		t.Errorf("transition time not kept: %v", d.Conditions[0].LastTransitionTime)
+	}
+	if len(d.Events) != nodeEventLimit || d.Events[0].Reason != "Reason0" {
+		t.Errorf("expected the %d newest events first, got %+v", nodeEventLimit, d.Events)
+	}
+
+	d = diagnoseNode(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new"}}, nil)
+	if len(d.Conditions) != 1 || d.Conditions[0].Status != "Unknown" {
+		t.Errorf("node without conditions should report Ready=Unknown, got %+v", d.Conditions)
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+)
+
+// fakeChecker returns a checker for a fake cluster holding the objects
+func fakeChecker(objects ...runtime.Object) *KubernetesChecker {
+	return NewKubernetesCheckerForClients(kubetest.NewClientset(objects...), kubetest.NewDynamicClient(nil))
+}
+
+func TestCheckNodeReadinessFake(t *testing.T) {
+	ctx := context.Background()
+	message, err := fakeChecker(kubetest.Cluster()...).CheckNodeReadiness(ctx)
+	if err != nil || message != "all 4 nodes Ready" {
+		t.Errorf("Expected all nodes Ready, got %q (%v)", message, err)
+	}
+
+	objects := append(kubetest.Cluster(), kubetest.Node("general-4", "us-east-1b", kubetest.NotReady))
+	message, err = fakeChecker(objects...).CheckNodeReadiness(ctx)
+	if err == nil || !strings.Contains(message, "general-4") {
+		t.Errorf("Expected general-4 to be reported NotReady, got %q (%v)", message, err)
+	}
+}
+
+func TestGatherNodeResourcesFake(t *testing.T) {
+	nodes, summary, err := fakeChecker(kubetest.Cluster()...).GatherNodeResources(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GatherNodeResources returned error: %v", err)
+	}
+	if summary.TotalNodes != 4 || summary.ReadyNodes != 4 || summary.GPUAllocatable != 1 || summary.GPURequests != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	requests := map[string]float64{}
+	for _, n := range nodes {
+		requests[n.Name] = n.CPURequests
+	}
+	// The completed migration pod on general-1 no longer holds its requests
+	want := map[string]float64{"general-1": 1, "general-2": 1.1, "general-3": 0.5, "gpu-1": 4}
+	for name, cpu := range want {
+		if requests[name] != cpu {
+			t.Errorf("Expected %v CPUs requested on %s, got %v", cpu, name, requests[name])
+		}
+	}
+}
+
+func TestCheckPodHealthFake(t *testing.T) {
+	objects := append(kubetest.Cluster(),
+		kubetest.Pod(kubetest.Namespace, "guard-worker-1", "gpu-1", kubetest.Waiting("CrashLoopBackOff")),
+		kubetest.Pod(kubetest.Namespace, "guard-worker-2", "", kubetest.Unschedulable("0/4 nodes are available: 1 Insufficient nvidia.com/gpu.")),
+	)
+	report, err := fakeChecker(objects...).CheckPodHealth(context.Background(), kubetest.Namespace, 0)
+	if err != nil {
+		t.Fatalf("CheckPodHealth returned error: %v", err)
+	}
+	if report.Pods != 7 || report.Healthy != 5 || !report.Failed() {
+		t.Errorf("Expected 5 of 7 pods healthy and a failure, got %d of %d: %+v", report.Healthy, report.Pods, report.Issues)
+	}
+	counts := report.Counts()
+	if counts[IssueCrashLoop] != 1 || counts[IssuePending] != 1 || counts[IssueNoLimits] != 0 {
+		t.Errorf("Unexpected issue counts: %v", counts)
+	}
+}
+
+func TestRunProviderChecksFake(t *testing.T) {
+	provider, results, err := fakeChecker(kubetest.Cluster()...).RunProviderChecks(context.Background(), PlatformAuto, "")
+	if err != nil {
+		t.Fatalf("RunProviderChecks returned error: %v", err)
+	}
+	if provider != ProviderEKS {
+		t.Errorf("Expected EKS detected from the provider IDs, got %s", provider)
+	}
+	statuses := map[string]string{}
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+	}
+	if statuses["irsa"] != CheckWarn || statuses["ebs-csi"] != CheckFail {
+		t.Errorf("Expected irsa to warn and ebs-csi to fail on a bare cluster, got %v", statuses)
+	}
+	if _, ok := statuses["vpc-cni-ips"]; ok {
+		t.Errorf("Expected no VPC CNI check without aws-node, got %v", statuses)
+	}
+}
+
+func TestCheckCIDRCapacityFake(t *testing.T) {
+	cidrs := map[string]string{"general-1": "10.244.0.0/24", "general-2": "10.244.1.0/24", "general-3": "10.244.2.0/24", "gpu-1": "10.244.3.0/24"}
+	objects := kubetest.Cluster(func(n *corev1.Node) { n.Spec.PodCIDRs = []string{cidrs[n.Name]} })
+	objects = append(objects, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: kubeSystemNamespace, Name: "kubeadm-config"},
+		Data:       map[string]string{"ClusterConfiguration": "networking:\n  podSubnet: 10.244.0.0/21\n  serviceSubnet: 10.96.0.0/12\n"},
+	})
+	report, err := fakeChecker(objects...).CheckCIDRCapacity(context.Background())
+	if err != nil {
+		t.Fatalf("CheckCIDRCapacity returned error: %v", err)
+	}
+	if len(report.PodCIDRs) != 1 || report.PodCIDRs[0].NodeMask != 24 || report.ServiceCIDRs[0].Source != CIDRSourceKubeadm {
+		t.Errorf("Unexpected ranges: %+v %+v", report.ServiceCIDRs, report.PodCIDRs)
+	}
+	for _, r := range report.Results {
+		if r.Name == "pod-cidr" && (r.Status != CheckPass || !strings.Contains(r.Message, "4 of 8 /24 node ranges")) {
+			t.Errorf("Expected half the node ranges used to pass, got %s: %s", r.Status, r.Message)
+		}
+	}
+}
+
+func TestProbeServerVersionFake(t *testing.T) {
+	clientset := kubetest.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.31.2"}
+	kc := NewKubernetesCheckerForClients(clientset, kubetest.NewDynamicClient(nil))
+	if v, err := kc.CheckKubernetesVersion(context.Background()); err != nil || v != "v1.31.2" {
+		t.Errorf("Expected the fake server version, got %q (%v)", v, err)
+	}
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LicenseArtifactType is the OCI artifact type of license manifests
+	LicenseArtifactType = "application/vnd.dynamoai.license.v1"
+	// LicenseDir is the directory under the output directory the license is pulled into
+	LicenseDir = "license"
+	// DefaultLicenseSecret is the Secret the Dynamo AI services read their license from
+	DefaultLicenseSecret = "dynamoai-license"
+	// DefaultLicenseSecretKey is the Secret key holding the license
+	DefaultLicenseSecretKey = "license"
+	// DefaultLicenseService is the service whose status endpoint reports the license state
+	DefaultLicenseService = "dynamoai-api"
+	// DefaultLicenseStatusPath is the license status endpoint of DefaultLicenseService
+	DefaultLicenseStatusPath = "/api/v1/license/status"
+	// LicenseExpiryAnnotation records on the license Secret when the license expires, for
+	// `cluster check` to warn ahead of it; the license file itself is opaque to dynactl
+	LicenseExpiryAnnotation = "dynactl.dynamo.ai/license-expiry"
+)
+
+// License points at the license blob stored alongside a release
+type License struct {
+	// URI is the OCI reference of the license artifact
+	URI string `json:"uri"`
+	// SHA256 is the checksum of the license file
+	SHA256 string `json:"sha256"`
+}
+
+// pullLicense pulls the license artifact into the license directory
+func pullLicense(component Component, outputDir string, opts RegistryClientOptions) error {
+	dir := filepath.Join(outputDir, LicenseDir)
+	LogInfo("🔑 Pulling license...")
+	layers, err := pullArtifactFiles(component, dir, opts)
+	if err != nil {
+		return err
+	}
+	if err := checkArtifactChecksum(component, layers); err != nil {
+		return err
+	}
+	LogInfo("  License saved to: %s", dir)
+	return nil
+}
+
+// FindLicenseFile returns the license file pulled into an artifacts directory
+func FindLicenseFile(artifactsDir string) (string, error) {
+	dir := filepath.Join(artifactsDir, LicenseDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("no license found in %s; pull the release first or pass --file: %w", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	switch len(files) {
+	case 0:
+		return "", fmt.Errorf("no license found in %s; pull the release first or pass --file", dir)
+	case 1:
+		return files[0], nil
+	default:
+		return "", fmt.Errorf("%s holds %d files; pass the license with --file", dir, len(files))
+	}
+}
+
+// LicenseSecret builds the Secret holding a license. A non-empty expiry is recorded in the
+// LicenseExpiryAnnotation.
+func LicenseSecret(namespace, name, key string, license []byte, expiry string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{key: license},
+	}
+	setLicenseExpiry(secret, expiry)
+	return secret
+}
+
+// setLicenseExpiry records the expiry of the license a Secret holds, or removes a recorded one
+// that belonged to the license being replaced
+func setLicenseExpiry(secret *corev1.Secret, expiry string) {
+	if expiry == "" {
+		delete(secret.Annotations, LicenseExpiryAnnotation)
+		return
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[LicenseExpiryAnnotation] = expiry
+}
+
+// ParseLicenseExpiry accepts an RFC 3339 time or a date, as in a manifest's license_expiry
+func ParseLicenseExpiry(expiry string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, expiry); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid license expiry %q: expected an RFC 3339 time or YYYY-MM-DD", expiry)
+}
+
+// ApplyLicenseSecret creates the license Secret or replaces the license key in an existing one,
+// leaving its other keys alone. It reports whether the Secret was created.
+func (kc *KubernetesChecker) ApplyLicenseSecret(ctx context.Context, namespace, name, key string, license []byte, expiry string) (bool, error) {
+	secrets := kc.clientset.CoreV1().Secrets(namespace)
+	existing, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := secrets.Create(ctx, LicenseSecret(namespace, name, key, license, expiry), metav1.CreateOptions{}); err != nil {
+			return false, fmt.Errorf("failed to create secret %s in %s: %w", name, namespace, err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get secret %s in %s: %w", name, namespace, err)
+	}
+	if existing.Data == nil {
+		existing.Data = map[string][]byte{}
+	}
+	existing.Data[key] = license
+	setLicenseExpiry(existing, expiry)
+	if _, err := secrets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to update secret %s in %s: %w", name, namespace, err)
+	}
+	return false, nil
+}
+
+// CheckLicenseSecret grades the expiry recorded on the license Secret in the namespace. A
+// missing Secret fails; one without a recorded expiry is a warning.
+func (kc *KubernetesChecker) CheckLicenseSecret(ctx context.Context, namespace string, now time.Time, warning time.Duration) CheckResult {
+	secret, err := kc.clientset.CoreV1().Secrets(namespace).Get(ctx, DefaultLicenseSecret, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return CheckResult{Name: PeriodicCheckLicense, Status: CheckFail, Message: fmt.Sprintf("no %s Secret in %s, apply it with deploy license apply", DefaultLicenseSecret, namespace)}
+	case err != nil:
+		return CheckResult{Name: PeriodicCheckLicense, Status: CheckFail, Message: fmt.Sprintf("failed to read the %s Secret: %v", DefaultLicenseSecret, err)}
+	}
+	expiry := secret.Annotations[LicenseExpiryAnnotation]
+	if expiry == "" {
+		return CheckResult{Name: PeriodicCheckLicense, Status: CheckWarn, Message: fmt.Sprintf("%s records no expiry; re-apply the license with deploy license apply --expiry", DefaultLicenseSecret)}
+	}
+	result := checkLicenseExpiry(&expiry, now, warning)
+	result.Name = PeriodicCheckLicense
+	return result
+}
+
+// LicenseVerifyOptions says where the application reports its license status
+type LicenseVerifyOptions struct {
+	// Service and Port select the application service; Port 0 uses its first port
+	Service string
+	Port    int32
+	// Path is the license status endpoint
+	Path string
+	// Timeout bounds how long to wait for the application to pick up the license
+	Timeout time.Duration
+	// Interval is the wait between attempts
+	Interval time.Duration
+}
+
+// VerifyLicense polls the application's license status through a port-forward until it reports
+// a valid license or the timeout passes. Mounted Secrets take up to a minute to refresh in pods.
+func (kc *KubernetesChecker) VerifyLicense(ctx context.Context, namespace string, opts LicenseVerifyOptions) error {
+	pf, err := kc.PortForwardService(ctx, namespace, opts.Service, opts.Port, 0)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	path := opts.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", pf.LocalPort, path)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	for {
+		err = checkLicenseStatus(ctx, client, url)
+		if err == nil {
+			return nil
+		}
+		LogDebug("License not accepted yet: %v", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not accept the license within %s: %w", opts.Service, opts.Timeout, err)
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// licenseStatus is the license status endpoint's answer
+type licenseStatus struct {
+	Valid   *bool  `json:"valid"`
+	Message string `json:"message"`
+}
+
+// checkLicenseStatus calls the status endpoint once. A 2xx answer is accepted unless its JSON body
+// says "valid": false.
+func checkLicenseStatus(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("license status returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var status licenseStatus
+	if json.Unmarshal(body, &status) == nil && status.Valid != nil && !*status.Valid {
+		if status.Message == "" {
+			status.Message = "no reason given"
+		}
+		return fmt.Errorf("license rejected: %s", status.Message)
+	}
+	return nil
+}
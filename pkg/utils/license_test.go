@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/file"
+)
+
+func TestPullLicense(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	data := []byte("-----BEGIN LICENSE-----\nacme\n-----END LICENSE-----\n")
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "acme.lic"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store, err := file.New(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	layer, err := store.Add(ctx, "acme.lic", "application/octet-stream", filepath.Join(src, "acme.lic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, LicenseArtifactType, oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, root, "3.22.2"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := newOrasRepository(host+"/dynamoai/licenses/acme", RegistryClientOptions{PlainHTTP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.Copy(ctx, store, "3.22.2", repo, "3.22.2", oras.DefaultCopyOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(data)
+	manifest := &ArtifactManifest{License: &License{
+		URI:    "oci://" + host + "/dynamoai/licenses/acme:3.22.2",
+		SHA256: "sha256:" + hex.EncodeToString(sum[:]),
+	}}
+	components := ManifestComponents(manifest, PullOptions{IncludeLicense: true})
+	if len(components) != 1 || components[0].Type != ArtifactTypeLicense {
+		t.Fatalf("expected only the license component, got %+v", components)
+	}
+
+	outputDir := t.TempDir()
+	if err := pullSingleArtifact(components[0], outputDir, RegistryClientOptions{PlainHTTP: true}); err != nil {
+		t.Fatal(err)
+	}
+	path, err := FindLicenseFile(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "acme.lic" {
+		t.Fatalf("unexpected license file %s", path)
+	}
+
+	components[0].SHA256 = strings.Repeat("0", 64)
+	if err := pullSingleArtifact(components[0], t.TempDir(), RegistryClientOptions{PlainHTTP: true}); err == nil {
+		t.Fatal("expected a checksum mismatch")
+	}
+}
+
+func TestNormalizePullOptionsLicense(t *testing.T) {
+	all := NormalizePullOptions(PullOptions{})
+	if !all.IncludeLicense || !all.IncludeImages || !all.IncludeDatasets {
+		t.Fatalf("expected every category by default, got %+v", all)
+	}
+	licenseOnly := NormalizePullOptions(PullOptions{IncludeLicense: true})
+	if licenseOnly.IncludeImages || licenseOnly.IncludeModels || licenseOnly.IncludeCharts || licenseOnly.IncludeDatasets {
+		t.Fatalf("expected only the license, got %+v", licenseOnly)
+	}
+	if NormalizePullOptions(PullOptions{IncludeImages: true}).IncludeLicense {
+		t.Fatal("expected no license when only images are selected")
+	}
+}
+
+func TestFindLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := FindLicenseFile(dir); err == nil {
+		t.Fatal("expected an error without a license directory")
+	}
+	licenseDir := filepath.Join(dir, LicenseDir)
+	if err := os.MkdirAll(licenseDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(licenseDir, "a.lic"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := FindLicenseFile(dir); err != nil || filepath.Base(path) != "a.lic" {
+		t.Fatalf("expected a.lic, got %q, %v", path, err)
+	}
+	if err := os.WriteFile(filepath.Join(licenseDir, "b.lic"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindLicenseFile(dir); err == nil || !strings.Contains(err.Error(), "--file") {
+		t.Fatalf("expected an ambiguity error, got %v", err)
+	}
+}
+
+func TestLicenseSecret(t *testing.T) {
+	secret := LicenseSecret("dynamo", DefaultLicenseSecret, DefaultLicenseSecretKey, []byte("lic"), "")
+	if secret.Namespace != "dynamo" || secret.Name != DefaultLicenseSecret {
+		t.Fatalf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+	}
+	if string(secret.Data[DefaultLicenseSecretKey]) != "lic" {
+		t.Fatalf("unexpected secret data %v", secret.Data)
+	}
+	if secret.Labels["app.kubernetes.io/managed-by"] != "dynactl" {
+		t.Fatalf("expected the managed-by label, got %v", secret.Labels)
+	}
+	if _, ok := secret.Annotations[LicenseExpiryAnnotation]; ok {
+		t.Fatalf("expected no expiry annotation without an expiry, got %v", secret.Annotations)
+	}
+}
+
+func TestCheckLicenseSecret(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	kc := fakeChecker()
+	if result := kc.CheckLicenseSecret(ctx, "dynamo", now, DefaultLicenseExpiryWarning); result.Status != CheckFail || !strings.Contains(result.Message, "no dynamoai-license Secret") {
+		t.Fatalf("expected a missing Secret to fail, got %+v", result)
+	}
+
+	apply := func(expiry string) CheckResult {
+		t.Helper()
+		if _, err := kc.ApplyLicenseSecret(ctx, "dynamo", DefaultLicenseSecret, DefaultLicenseSecretKey, []byte("lic"), expiry); err != nil {
+			t.Fatal(err)
+		}
+		return kc.CheckLicenseSecret(ctx, "dynamo", now, DefaultLicenseExpiryWarning)
+	}
+	tests := []struct {
+		expiry  string
+		status  string
+		message string
+	}{
+		{"2027-06-30", CheckPass, "valid until 2027-06-30"},
+		{"2026-11-01T00:00:00Z", CheckWarn, "in 15 days"},
+		{"2026-10-01", CheckFail, "expired on 2026-10-01"},
+		// Replacing the license without an expiry drops the one recorded for the old license
+		{"", CheckWarn, "records no expiry"},
+	}
+	for _, tt := range tests {
+		result := apply(tt.expiry)
+		if result.Name != PeriodicCheckLicense || result.Status != tt.status || !strings.Contains(result.Message, tt.message) {
+			t.Errorf("expiry %q: expected %s containing %q, got %+v", tt.expiry, tt.status, tt.message, result)
+		}
+	}
+
+	if _, err := ParseLicenseExpiry("next june"); err == nil {
+		t.Error("expected an unparseable expiry to be rejected")
+	}
+}
+
+func TestCheckLicenseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "valid", status: http.StatusOK, body: `{"valid":true}`},
+		{name: "plain ok", status: http.StatusOK, body: "ok"},
+		{name: "rejected", status: http.StatusOK, body: `{"valid":false,"message":"expired"}`, wantErr: "license rejected: expired"},
+		{name: "server error", status: http.StatusServiceUnavailable, body: "starting", wantErr: "HTTP 503"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := checkLicenseStatus(context.Background(), server.Client(), server.URL)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// MaintenanceReplicasAnnotation records a workload's replica count while maintenance mode
+	// has it scaled to zero
+	MaintenanceReplicasAnnotation = "dynactl.dynamo.ai/maintenance-replicas"
+	// DefaultMaintenanceSelector selects the ingestion and worker components, which write to the
+	// database in the background
+	DefaultMaintenanceSelector = "app.kubernetes.io/component in (ingestion,worker)"
+	// DefaultBannerPath is the platform API endpoint that sets the maintenance banner
+	DefaultBannerPath = "/api/v1/system/banner"
+	// maintenanceConfigMap records that maintenance mode is on, and the banner it posted
+	maintenanceConfigMap = "dynactl-maintenance"
+)
+
+// MaintenanceOptions configures maintenance mode
+type MaintenanceOptions struct {
+	Namespace string
+	// Selector picks the Deployments and StatefulSets to scale down
+	Selector string
+	// Banner is the message to show users; empty leaves the banner alone
+	Banner string
+	// BannerService, BannerPort, and BannerPath locate the banner endpoint; BannerPort 0 uses the
+	// service's first port
+	BannerService string
+	BannerPort    int32
+	BannerPath    string
+	// Timeout bounds the wait for workloads to scale; 0 does not wait
+	Timeout time.Duration
+}
+
+// MaintenanceWorkload is a workload maintenance mode scaled
+type MaintenanceWorkload struct {
+	Kind     string
+	Name     string
+	Replicas int32
+}
+
+// MaintenanceResult reports what turning maintenance mode on or off did
+type MaintenanceResult struct {
+	Workloads []MaintenanceWorkload
+	// Skipped lists workloads already at zero replicas, which are left alone
+	Skipped []string
+	// Banner is the banner message that was posted or cleared
+	Banner string
+	Since  time.Time
+}
+
+// maintenanceBanner is the banner endpoint's request body
+type maintenanceBanner struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// scalable is a Deployment or StatefulSet as maintenance mode sees it
+type scalable struct {
+	kind        string
+	name        string
+	replicas    int32
+	annotations map[string]string
+}
+
+// EnableMaintenance posts the banner, then scales the selected workloads to zero, recording
+// each one's replica count in an annotation. Running it again keeps the counts recorded first.
+func (kc *KubernetesChecker) EnableMaintenance(ctx context.Context, opts MaintenanceOptions) (*MaintenanceResult, error) {
+	workloads, err := kc.listScalables(ctx, opts.Namespace, opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("no Deployments or StatefulSets in %s match %q", opts.Namespace, opts.Selector)
+	}
+
+	result := &MaintenanceResult{Since: time.Now().UTC(), Banner: opts.Banner}
+	state, err := kc.maintenanceState(ctx, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		result.Since = state.Since
+		if opts.Banner == "" {
+			result.Banner = state.Banner
+		}
+	}
+	if err := kc.saveMaintenanceState(ctx, opts.Namespace, result); err != nil {
+		return nil, err
+	}
+	if opts.Banner != "" {
+		if err := kc.postBanner(ctx, opts, maintenanceBanner{Enabled: true, Message: opts.Banner}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, w := range workloads {
+		if _, ok := w.annotations[MaintenanceReplicasAnnotation]; ok {
+			// Already scaled down by an earlier run
+			continue
+		}
+		if w.replicas == 0 {
+			result.Skipped = append(result.Skipped, w.kind+"/"+w.name)
+			continue
+		}
+		patch := map[string]any{
+			"metadata": map[string]any{"annotations": map[string]any{MaintenanceReplicasAnnotation: strconv.Itoa(int(w.replicas))}},
+			"spec":     map[string]any{"replicas": 0},
+		}
+		if err := kc.patchScalable(ctx, opts.Namespace, w, patch); err != nil {
+			return result, err
+		}
+		result.Workloads = append(result.Workloads, MaintenanceWorkload{Kind: w.kind, Name: w.name, Replicas: w.replicas})
+	}
+	if opts.Timeout > 0 {
+		if err := kc.waitForScale(ctx, opts.Namespace, result.Workloads, true, opts.Timeout); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// PlanMaintenance lays out what EnableMaintenance would do, changing nothing: the banner it
+// posts and the workloads it scales to zero. Workloads it would leave alone are skipped.
+func (kc *KubernetesChecker) PlanMaintenance(ctx context.Context, opts MaintenanceOptions) (*Plan, error) {
+	workloads, err := kc.listScalables(ctx, opts.Namespace, opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("no Deployments or StatefulSets in %s match %q", opts.Namespace, opts.Selector)
+	}
+
+	plan := NewPlan("deploy maintenance on", 0)
+	if opts.Banner != "" {
+		plan.Add(PlanAction{Action: PlanActionPost, Kind: "Banner", Destination: opts.BannerService + opts.BannerPath, Note: opts.Banner})
+	}
+	for _, w := range workloads {
+		action := PlanAction{Action: PlanActionScale, Kind: w.kind, Source: fmt.Sprintf("%s (%d replicas)", w.name, w.replicas), Destination: "0 replicas"}
+		if value, ok := w.annotations[MaintenanceReplicasAnnotation]; ok {
+			action.Action, action.Source, action.Note = PlanActionSkip, w.name, fmt.Sprintf("already scaled down from %s replicas", value)
+		} else if w.replicas == 0 {
+			action.Action, action.Source, action.Note = PlanActionSkip, w.name, "already has no replicas"
+		}
+		plan.Add(action)
+	}
+	return plan, nil
+}
+
+// DisableMaintenance restores every workload maintenance mode scaled down, whatever selector
+// was used, waits for them to be ready, and then clears the banner it posted
+func (kc *KubernetesChecker) DisableMaintenance(ctx context.Context, opts MaintenanceOptions) (*MaintenanceResult, error) {
+	state, err := kc.maintenanceState(ctx, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	workloads, err := kc.listScalables(ctx, opts.Namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MaintenanceResult{}
+	if state != nil {
+		result.Since, result.Banner = state.Since, state.Banner
+	}
+	for _, w := range workloads {
+		value, ok := w.annotations[MaintenanceReplicasAnnotation]
+		if !ok {
+			continue
+		}
+		replicas, err := strconv.Atoi(value)
+		if err != nil {
+			return result, fmt.Errorf("%s/%s has an invalid %s annotation %q", w.kind, w.name, MaintenanceReplicasAnnotation, value)
+		}
+		patch := map[string]any{
+			"metadata": map[string]any{"annotations": map[string]any{MaintenanceReplicasAnnotation: nil}},
+			"spec":     map[string]any{"replicas": replicas},
+		}
+		if err := kc.patchScalable(ctx, opts.Namespace, w, patch); err != nil {
+			return result, err
+		}
+		result.Workloads = append(result.Workloads, MaintenanceWorkload{Kind: w.kind, Name: w.name, Replicas: int32(replicas)})
+	}
+	if state == nil && len(result.Workloads) == 0 {
+		return nil, fmt.Errorf("maintenance mode is not on in %s", opts.Namespace)
+	}
+
+	if opts.Timeout > 0 {
+		if err := kc.waitForScale(ctx, opts.Namespace, result.Workloads, false, opts.Timeout); err != nil {
+			return result, err
+		}
+	}
+	if result.Banner != "" {
+		if err := kc.postBanner(ctx, opts, maintenanceBanner{Enabled: false}); err != nil {
+			return result, err
+		}
+	}
+	err = kc.clientset.CoreV1().ConfigMaps(opts.Namespace).Delete(ctx, maintenanceConfigMap, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return result, fmt.Errorf("failed to delete ConfigMap %s: %v", maintenanceConfigMap, err)
+	}
+	return result, nil
+}
+
+// listScalables lists the Deployments and StatefulSets matching selector, by kind and name
+func (kc *KubernetesChecker) listScalables(ctx context.Context, namespace, selector string) ([]scalable, error) {
+	opts := metav1.ListOptions{LabelSelector: selector}
+	var out []scalable
+	deployments, err := kc.clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Deployments in %s: %v", namespace, err)
+	}
+	for _, d := range deployments.Items {
+		out = append(out, scalable{kind: "Deployment", name: d.Name, replicas: replicasOrOne(d.Spec.Replicas), annotations: d.Annotations})
+	}
+	statefulSets, err := kc.clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list StatefulSets in %s: %v", namespace, err)
+	}
+	for _, s := range statefulSets.Items {
+		out = append(out, scalable{kind: "StatefulSet", name: s.Name, replicas: replicasOrOne(s.Spec.Replicas), annotations: s.Annotations})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].kind != out[j].kind {
+			return out[i].kind < out[j].kind
+		}
+		return out[i].name < out[j].name
+	})
+	return out, nil
+}
+
+// replicasOrOne reads spec.replicas, which defaults to 1
+func replicasOrOne(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// patchScalable applies a merge patch, so the replica count and its annotation change together
+func (kc *KubernetesChecker) patchScalable(ctx context.Context, namespace string, w scalable, patch map[string]any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if w.kind == "StatefulSet" {
+		_, err = kc.clientset.AppsV1().StatefulSets(namespace).Patch(ctx, w.name, types.MergePatchType, data, metav1.PatchOptions{})
+	} else {
+		_, err = kc.clientset.AppsV1().Deployments(namespace).Patch(ctx, w.name, types.MergePatchType, data, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scale %s/%s: %v", w.kind, w.name, err)
+	}
+	return nil
+}
+
+// waitForScale polls until the workloads have no pods left (down) or all replicas ready (up)
+func (kc *KubernetesChecker) waitForScale(ctx context.Context, namespace string, workloads []MaintenanceWorkload, down bool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		var waiting []string
+		for _, w := range workloads {
+			var current, ready int32
+			if w.Kind == "StatefulSet" {
+				s, err := kc.clientset.AppsV1().StatefulSets(namespace).Get(ctx, w.Name, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get StatefulSet %s: %v", w.Name, err)
+				}
+				current, ready = s.Status.Replicas, s.Status.ReadyReplicas
+			} else {
+				d, err := kc.clientset.AppsV1().Deployments(namespace).Get(ctx, w.Name, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get Deployment %s: %v", w.Name, err)
+				}
+				current, ready = d.Status.Replicas, d.Status.ReadyReplicas
+			}
+			if (down && current > 0) || (!down && ready < w.Replicas) {
+				waiting = append(waiting, w.Kind+"/"+w.Name)
+			}
+		}
+		if len(waiting) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s waiting for %s", timeout, strings.Join(waiting, ", "))
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// maintenanceState reads the maintenance ConfigMap; nil means maintenance mode is off
+func (kc *KubernetesChecker) maintenanceState(ctx context.Context, namespace string) (*MaintenanceResult, error) {
+	cm, err := kc.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, maintenanceConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ConfigMap %s: %v", maintenanceConfigMap, err)
+	}
+	state := &MaintenanceResult{Banner: cm.Data["banner"]}
+	state.Since, _ = time.Parse(time.RFC3339, cm.Data["since"])
+	return state, nil
+}
+
+// saveMaintenanceState creates or replaces the maintenance ConfigMap
+func (kc *KubernetesChecker) saveMaintenanceState(ctx context.Context, namespace string, state *MaintenanceResult) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      maintenanceConfigMap,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Data: map[string]string{"since": state.Since.Format(time.RFC3339), "banner": state.Banner},
+	}
+	if _, err := applyObject(ctx, cm, kc.clientset.CoreV1().ConfigMaps(namespace)); err != nil {
+		return fmt.Errorf("failed to save ConfigMap %s: %v", maintenanceConfigMap, err)
+	}
+	return nil
+}
+
+// postBanner sets or clears the banner through a port-forward to the platform API
+func (kc *KubernetesChecker) postBanner(ctx context.Context, opts MaintenanceOptions, banner maintenanceBanner) error {
+	pf, err := kc.PortForwardService(ctx, opts.Namespace, opts.BannerService, opts.BannerPort, 0)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	path := opts.BannerPath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", pf.LocalPort, path)
+	return postBannerURL(ctx, &http.Client{Timeout: 10 * time.Second}, url, banner)
+}
+
+// postBannerURL sends the banner to the endpoint once
+func postBannerURL(ctx context.Context, client *http.Client, url string, banner maintenanceBanner) error {
+	body, err := json.Marshal(banner)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post the maintenance banner: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("maintenance banner returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
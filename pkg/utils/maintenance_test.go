@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPostBannerURL(t *testing.T) {
+	var got maintenanceBanner
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	banner := maintenanceBanner{Enabled: true, Message: "Database maintenance until 14:00 UTC"}
+	if err := postBannerURL(context.Background(), server.Client(), server.URL, banner); err != nil {
+		t.Fatalf("postBannerURL failed: %v", err)
+	}
+	if got != banner {
+		t.Errorf("unexpected banner %+v", got)
+	}
+}
+
+func TestPostBannerURLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "banner feature disabled", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := postBannerURL(context.Background(), server.Client(), server.URL, maintenanceBanner{})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 404: banner feature disabled") {
+		t.Errorf("expected the HTTP error, got %v", err)
+	}
+}
+
+func TestReplicasOrOne(t *testing.T) {
+	three := int32(3)
+	if replicasOrOne(nil) != 1 || replicasOrOne(&three) != 3 {
+		t.Error("unexpected replica counts")
+	}
+}
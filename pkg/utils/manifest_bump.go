@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// helmChartContentMediaType is the layer media type holding a chart archive
+const helmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+// BumpOptions controls how a manifest is rewritten for a new release
+type BumpOptions struct {
+	// Version is the new release version
+	Version string
+	// Refresh queries the registry for the digests, checksums, and sizes of the bumped artifacts
+	Refresh bool
+	// Registry controls how the registry is reached when refreshing
+	Registry RegistryClientOptions
+}
+
+// BumpChange records one rewritten manifest field
+type BumpChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// BumpResult is a manifest rewritten for a new release
+type BumpResult struct {
+	Manifest *ArtifactManifest
+	Changes  []BumpChange
+	// Warnings are fields that could not be refreshed and need a manual look
+	Warnings []string
+}
+
+// BumpManifest rewrites the release version segments of every URI in a manifest and, with
+// opts.Refresh, replaces pinned digests, checksums, and sizes with what the registry now serves.
+// The input manifest is left unchanged. Artifacts missing from the registry are reported together.
+func BumpManifest(ctx context.Context, manifest *ArtifactManifest, opts BumpOptions) (*BumpResult, error) {
+	oldVersion := manifest.ReleaseVersion
+	if oldVersion == "" {
+		return nil, fmt.Errorf("manifest has no release_version to bump")
+	}
+	if opts.Version == "" || strings.ContainsAny(opts.Version, "/:@") {
+		return nil, fmt.Errorf("invalid release version %q", opts.Version)
+	}
+	if opts.Version == oldVersion {
+		return nil, fmt.Errorf("manifest is already at release %s", oldVersion)
+	}
+
+	// Round-trip through JSON for a deep copy, as the manifest holds pointers
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy manifest: %w", err)
+	}
+	bumped := &ArtifactManifest{}
+	if err := json.Unmarshal(data, bumped); err != nil {
+		return nil, fmt.Errorf("failed to copy manifest: %w", err)
+	}
+
+	result := &BumpResult{Manifest: bumped}
+	set := func(field string, value *string, updated string) {
+		if *value != updated {
+			result.Changes = append(result.Changes, BumpChange{Field: field, Old: *value, New: updated})
+			*value = updated
+		}
+	}
+	bump := func(field string, value *string) {
+		set(field, value, bumpReference(*value, oldVersion, opts.Version))
+	}
+
+	set("release_version", &bumped.ReleaseVersion, opts.Version)
+	bump("artifacts.charts_root", &bumped.Artifacts.ChartsRoot)
+	bump("artifacts.images_root", &bumped.Artifacts.ImagesRoot)
+	bump("artifacts.models_root", &bumped.Artifacts.ModelsRoot)
+	bump("artifacts.datasets_root", &bumped.Artifacts.DatasetsRoot)
+	for i := range bumped.Images {
+		bump(fmt.Sprintf("images[%d]", i), &bumped.Images[i])
+	}
+	for i := range bumped.Models {
+		bump(fmt.Sprintf("models[%d]", i), &bumped.Models[i])
+	}
+	for i := range bumped.Charts {
+		chart := &bumped.Charts[i]
+		bump(fmt.Sprintf("charts[%d].harbor_path", i), &chart.HarborPath)
+		if chart.AppVersion == oldVersion {
+			set(fmt.Sprintf("charts[%d].appVersion", i), &chart.AppVersion, opts.Version)
+		}
+	}
+	for i := range bumped.Datasets {
+		bump(fmt.Sprintf("datasets[%d].uri", i), &bumped.Datasets[i].URI)
+	}
+	if bumped.License != nil {
+		bump("license.uri", &bumped.License.URI)
+	}
+
+	if !opts.Refresh {
+		for _, ref := range append(append([]string{}, bumped.Images...), bumped.Models...) {
+			if _, _, digest := splitImageReference(ref); digest != "" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s is pinned to a digest of the previous release", ref))
+			}
+		}
+		return result, nil
+	}
+	if err := refreshBumpedManifest(ctx, result, opts.Registry, set); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// refreshBumpedManifest queries the registry for every artifact in a bumped manifest
+func refreshBumpedManifest(ctx context.Context, result *BumpResult, registry RegistryClientOptions, set func(field string, value *string, updated string)) error {
+	craneOpts, err := bumpCraneOptions(ctx, registry)
+	if err != nil {
+		return err
+	}
+	manifest := result.Manifest
+	var errs []error
+
+	refreshDigest := func(field string, value *string) {
+		prefix := bumpPrefix(*value)
+		repo, tag, digest := splitImageReference(strings.TrimPrefix(*value, prefix))
+		if tag == "" && digest != "" {
+			// Only the digest is known, so the most that can be done is confirming it was published
+			if _, err := crane.Digest(repo+"@"+digest, craneOpts...); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s is pinned to a digest only and was not found: %w", field, *value, err))
+			}
+			return
+		}
+		ref := repo
+		if tag != "" {
+			ref += ":" + tag
+		}
+		current, err := crane.Digest(ref, craneOpts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", field, ref, err))
+			return
+		}
+		if digest != "" {
+			set(field, value, prefix+ref+"@"+current)
+		}
+	}
+	for i := range manifest.Images {
+		refreshDigest(fmt.Sprintf("images[%d]", i), &manifest.Images[i])
+	}
+	for i := range manifest.Models {
+		refreshDigest(fmt.Sprintf("models[%d]", i), &manifest.Models[i])
+	}
+
+	for i := range manifest.Charts {
+		chart := &manifest.Charts[i]
+		field := fmt.Sprintf("charts[%d]", i)
+		repo, err := helmChartRepository(chart.HarborPath, chart.Version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+			continue
+		}
+		layers, err := fetchManifestLayers(repo+":"+chart.Version, craneOpts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+			continue
+		}
+		layer, ok := findLayer(layers, helmChartContentMediaType)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s:%s is not a Helm chart", field, repo, chart.Version))
+			continue
+		}
+		set(field+".sha256", &chart.SHA256, keepDigestPrefix(chart.SHA256, layer.Digest))
+		setSize(result, field+".size_bytes", &chart.SizeBytes, layer.Size)
+	}
+
+	for i := range manifest.Datasets {
+		dataset := &manifest.Datasets[i]
+		field := fmt.Sprintf("datasets[%d]", i)
+		layers, err := fetchManifestLayers(strings.TrimPrefix(dataset.URI, "oci://"), craneOpts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+			continue
+		}
+		var size int64
+		for _, layer := range layers {
+			size += layer.Size
+		}
+		setSize(result, field+".size_bytes", &dataset.SizeBytes, size)
+		if len(layers) == 1 {
+			set(field+".sha256", &dataset.SHA256, keepDigestPrefix(dataset.SHA256, layers[0].Digest))
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s.sha256 not refreshed: %s has %d files", field, dataset.URI, len(layers)))
+		}
+	}
+
+	if license := manifest.License; license != nil {
+		layers, err := fetchManifestLayers(strings.TrimPrefix(license.URI, "oci://"), craneOpts)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("license: %w", err))
+		case len(layers) == 1:
+			set("license.sha256", &license.SHA256, keepDigestPrefix(license.SHA256, layers[0].Digest))
+		default:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("license.sha256 not refreshed: %s has %d files", license.URI, len(layers)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d artifacts could not be resolved in the registry:\n%w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// bumpCraneOptions returns crane options honoring the registry client options
+func bumpCraneOptions(ctx context.Context, registry RegistryClientOptions) ([]crane.Option, error) {
+	httpClient, err := registry.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	opts := []crane.Option{
+		crane.WithContext(ctx),
+		crane.WithAuthFromKeychain(NewDynactlKeychain()),
+		crane.WithTransport(httpClient.Transport),
+	}
+	if registry.PlainHTTP {
+		opts = append(opts, crane.Insecure)
+	}
+	return opts, nil
+}
+
+// fetchManifestLayers returns the layers of the manifest at ref
+func fetchManifestLayers(ref string, craneOpts []crane.Option) ([]v1.Descriptor, error) {
+	data, err := crane.Manifest(ref, craneOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	manifest, err := v1.ParseManifest(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse manifest: %w", ref, err)
+	}
+	return manifest.Layers, nil
+}
+
+// findLayer returns the first layer with the media type
+func findLayer(layers []v1.Descriptor, mediaType string) (v1.Descriptor, bool) {
+	for _, layer := range layers {
+		if string(layer.MediaType) == mediaType {
+			return layer, true
+		}
+	}
+	return v1.Descriptor{}, false
+}
+
+// setSize records a size change
+func setSize(result *BumpResult, field string, value *int64, updated int64) {
+	if *value != updated {
+		result.Changes = append(result.Changes, BumpChange{Field: field, Old: fmt.Sprint(*value), New: fmt.Sprint(updated)})
+		*value = updated
+	}
+}
+
+// keepDigestPrefix formats a digest the way the manifest already writes it, with or without
+// the sha256: prefix
+func keepDigestPrefix(old string, digest v1.Hash) string {
+	if strings.HasPrefix(old, "sha256:") {
+		return digest.String()
+	}
+	return digest.Hex
+}
+
+// bumpReference replaces the path segments and tag equal to the old release version
+func bumpReference(uri, oldVersion, newVersion string) string {
+	if uri == "" {
+		return uri
+	}
+	repo, tag, digest := splitImageReference(uri)
+	prefix := bumpPrefix(repo)
+	segments := strings.Split(strings.TrimPrefix(repo, prefix), "/")
+	for i, segment := range segments {
+		if segment == oldVersion {
+			segments[i] = newVersion
+		}
+	}
+	bumped := prefix + strings.Join(segments, "/")
+	if tag == oldVersion {
+		tag = newVersion
+	}
+	if tag != "" {
+		bumped += ":" + tag
+	}
+	if digest != "" {
+		bumped += "@" + digest
+	}
+	return bumped
+}
+
+// bumpPrefix returns the oci:// scheme of a manifest URI, if it has one
+func bumpPrefix(uri string) string {
+	if strings.HasPrefix(uri, "oci://") {
+		return "oci://"
+	}
+	return ""
+}
+
+// splitImageReference splits a reference into repository, tag, and digest; a reference may carry
+// both a tag and a digest (repo:tag@sha256:...). The oci:// scheme stays on the repository.
+func splitImageReference(uri string) (repo, tag, digest string) {
+	repo = uri
+	if i := strings.LastIndex(repo, "@"); i != -1 {
+		repo, digest = repo[:i], repo[i+1:]
+	}
+	if i := strings.LastIndex(repo, ":"); i != -1 && !strings.Contains(repo[i+1:], "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	return repo, tag, digest
+}
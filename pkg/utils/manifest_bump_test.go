@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestBumpReference(t *testing.T) {
+	tests := []struct {
+		uri, want string
+	}{
+		{"oci://artifacts.dynamo.ai/dynamoai/3.22.2/images/dynamoai-api:latest", "oci://artifacts.dynamo.ai/dynamoai/3.23.0/images/dynamoai-api:latest"},
+		{"artifacts.dynamo.ai/dynamoai/images/dynamoai-api:3.22.2", "artifacts.dynamo.ai/dynamoai/images/dynamoai-api:3.23.0"},
+		{"registry:5000/dynamoai/3.22.2/api:3.22.2@sha256:abc", "registry:5000/dynamoai/3.23.0/api:3.23.0@sha256:abc"},
+		// Only whole segments match, so chart file names and look-alike versions are kept
+		{"oci://host/dynamoai/3.22.2/charts/dynamoai-base-1.1.2.tgz", "oci://host/dynamoai/3.23.0/charts/dynamoai-base-1.1.2.tgz"},
+		{"host/dynamoai/3.22.20/api:3.22.2-rc1", "host/dynamoai/3.22.20/api:3.22.2-rc1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := bumpReference(tt.uri, "3.22.2", "3.23.0"); got != tt.want {
+			t.Errorf("bumpReference(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestBumpManifestWithoutRefresh(t *testing.T) {
+	manifest, err := LoadManifest("../../testdata/sample.manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := BumpManifest(context.Background(), manifest, BumpOptions{Version: "3.23.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bumped := result.Manifest
+	if bumped.ReleaseVersion != "3.23.0" || manifest.ReleaseVersion != "3.22.2" {
+		t.Fatalf("expected only the copy to be bumped, got %s and %s", bumped.ReleaseVersion, manifest.ReleaseVersion)
+	}
+	if bumped.Images[0] != "oci://artifacts.dynamo.ai/dynamoai/3.23.0/images/dynamoai-api:latest" {
+		t.Fatalf("unexpected image %s", bumped.Images[0])
+	}
+	if bumped.Charts[0].AppVersion != "3.23.0" || bumped.Charts[0].Version != "1.1.2" {
+		t.Fatalf("expected appVersion bumped and chart version kept, got %+v", bumped.Charts[0])
+	}
+	if strings.Contains(bumped.Artifacts.ModelsRoot, "3.22.2") {
+		t.Fatalf("expected models_root bumped, got %s", bumped.Artifacts.ModelsRoot)
+	}
+
+	if _, err := BumpManifest(context.Background(), manifest, BumpOptions{Version: "3.22.2"}); err == nil {
+		t.Fatal("expected bumping to the same version to fail")
+	}
+	if _, err := BumpManifest(context.Background(), manifest, BumpOptions{Version: "a/b"}); err == nil {
+		t.Fatal("expected an invalid version to be rejected")
+	}
+}
+
+func TestBumpManifestRefreshesFromRegistry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+
+	oldDigest := stageTestImage(t, host+"/dynamoai/3.22.2/images/api:3.22.2")
+	newDigest := stageTestImage(t, host+"/dynamoai/3.23.0/images/api:3.23.0")
+
+	packaged, err := chartutil.Save(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "dynamoai-base", Version: "1.2.0"},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	chartData, err := os.ReadFile(packaged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := registry.NewClient(registry.ClientOptPlainHTTP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Push(chartData, host+"/dynamoai/3.23.0/charts/dynamoai-base:1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	chartSum := sha256.Sum256(chartData)
+
+	manifest := &ArtifactManifest{
+		ReleaseVersion: "3.22.2",
+		Images:         []string{"oci://" + host + "/dynamoai/3.22.2/images/api:3.22.2@" + oldDigest},
+		Charts: []Chart{{
+			Name:       "dynamoai-base",
+			Version:    "1.2.0",
+			HarborPath: "oci://" + host + "/dynamoai/3.22.2/charts/dynamoai-base-1.2.0.tgz",
+			SHA256:     "stale",
+			SizeBytes:  1,
+		}},
+	}
+	opts := BumpOptions{Version: "3.23.0", Refresh: true, Registry: RegistryClientOptions{PlainHTTP: true}}
+	result, err := BumpManifest(context.Background(), manifest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "oci://" + host + "/dynamoai/3.23.0/images/api:3.23.0@" + newDigest; result.Manifest.Images[0] != want {
+		t.Fatalf("expected image %s, got %s", want, result.Manifest.Images[0])
+	}
+	bumpedChart := result.Manifest.Charts[0]
+	if bumpedChart.SHA256 != hex.EncodeToString(chartSum[:]) || bumpedChart.SizeBytes != int64(len(chartData)) {
+		t.Fatalf("expected chart checksum and size refreshed, got %+v", bumpedChart)
+	}
+
+	// Missing artifacts of the new release are reported together
+	manifest.Models = []string{host + "/dynamoai/3.22.2/models/missing:3.22.2"}
+	if _, err := BumpManifest(context.Background(), manifest, opts); err == nil || !strings.Contains(err.Error(), "models[0]") {
+		t.Fatalf("expected the missing model to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ManifestSchemaVersion is the newest manifest format this build understands. Manifests without
+// a schema_version field predate versioning and are treated as schema 1.
+const ManifestSchemaVersion = 1
+
+// SupportedReleases is the range of Dynamo AI releases this build has been validated against
+const SupportedReleases = ">= 1.0.0, < 4.0.0"
+
+// ManifestCompatibilityError lists why a manifest cannot safely be processed by this build
+type ManifestCompatibilityError struct {
+	Problems []string
+}
+
+func (e *ManifestCompatibilityError) Error() string {
+	return fmt.Sprintf("manifest is not supported by this dynactl: %s (update with `dynactl self-update`, or rerun with --force to continue anyway)",
+		strings.Join(e.Problems, "; "))
+}
+
+// Schema returns the manifest's format version, defaulting to 1 for unversioned manifests
+func (m *ArtifactManifest) Schema() int {
+	if m.SchemaVersion == 0 {
+		return 1
+	}
+	return m.SchemaVersion
+}
+
+// CheckManifestCompatibility compares the manifest's schema and release version against what
+// this build supports. It returns a *ManifestCompatibilityError when either is out of range; a
+// release version that is not semver is only logged, since older manifests used free-form tags.
+func CheckManifestCompatibility(m *ArtifactManifest) error {
+	var problems []string
+	if m.Schema() > ManifestSchemaVersion {
+		problems = append(problems, fmt.Sprintf("schema version %d is newer than the supported %d", m.Schema(), ManifestSchemaVersion))
+	}
+
+	if m.ReleaseVersion != "" {
+		v, err := semver.NewVersion(strings.TrimPrefix(m.ReleaseVersion, "v"))
+		if err != nil {
+			LogDebug("Skipping release compatibility check for %q: %v", m.ReleaseVersion, err)
+		} else if c, err := semver.NewConstraint(SupportedReleases); err == nil && !c.Check(releaseCore(v)) {
+			problems = append(problems, fmt.Sprintf("release %s is outside the supported range %s", m.ReleaseVersion, SupportedReleases))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ManifestCompatibilityError{Problems: problems}
+	}
+	return nil
+}
+
+// releaseCore drops prerelease and build metadata so release candidates match the same range
+// as the release they precede
+func releaseCore(v *semver.Version) *semver.Version {
+	core, err := v.SetPrerelease("")
+	if err != nil {
+		return v
+	}
+	core, _ = core.SetMetadata("")
+	return &core
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckManifestCompatibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest ArtifactManifest
+		problems int
+	}{
+		{"unversioned", ArtifactManifest{ReleaseVersion: "3.22.2"}, 0},
+		{"current schema", ArtifactManifest{SchemaVersion: ManifestSchemaVersion, ReleaseVersion: "1.0.0"}, 0},
+		{"release candidate", ArtifactManifest{ReleaseVersion: "3.23.0-rc.1"}, 0},
+		{"free-form release", ArtifactManifest{ReleaseVersion: "latest"}, 0},
+		{"newer schema", ArtifactManifest{SchemaVersion: ManifestSchemaVersion + 1, ReleaseVersion: "3.22.2"}, 1},
+		{"newer release", ArtifactManifest{ReleaseVersion: "4.0.0"}, 1},
+		{"both", ArtifactManifest{SchemaVersion: ManifestSchemaVersion + 1, ReleaseVersion: "v5.1.0"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckManifestCompatibility(&tt.manifest)
+			if tt.problems == 0 {
+				if err != nil {
+					t.Fatalf("expected manifest to be supported, got %v", err)
+				}
+				return
+			}
+			var compatErr *ManifestCompatibilityError
+			if !errors.As(err, &compatErr) {
+				t.Fatalf("expected a ManifestCompatibilityError, got %v", err)
+			}
+			if len(compatErr.Problems) != tt.problems {
+				t.Errorf("expected %d problems, got %v", tt.problems, compatErr.Problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterSummary counts what a manifest filter kept and removed
+type FilterSummary struct {
+	Entitlements []string
+	Kept         int
+	Removed      []string
+}
+
+// FilterManifestByEntitlements returns a copy of the manifest holding only shared artifacts and
+// those of the entitled products. With no entitlements given, the manifest's own entitlements are
+// used. Naming a product the manifest does not define is an error, so a typo cannot drop artifacts.
+func FilterManifestByEntitlements(manifest *ArtifactManifest, entitlements []string) (*ArtifactManifest, FilterSummary, error) {
+	var summary FilterSummary
+	if len(entitlements) == 0 {
+		entitlements = manifest.Entitlements
+	}
+	entitlements = uniqueSorted(entitlements)
+	if len(entitlements) == 0 {
+		return nil, summary, fmt.Errorf("no entitlements given and the manifest lists none")
+	}
+	if len(manifest.Products) == 0 {
+		return nil, summary, fmt.Errorf("manifest does not map artifacts to products")
+	}
+	var unknown []string
+	for _, product := range entitlements {
+		if _, ok := manifest.Products[product]; !ok {
+			unknown = append(unknown, product)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, summary, fmt.Errorf("unknown products %s; the manifest defines %s", strings.Join(unknown, ", "), strings.Join(productNames(manifest.Products), ", "))
+	}
+	summary.Entitlements = entitlements
+
+	// Round-trip through JSON for a deep copy, as the manifest holds pointers
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, summary, fmt.Errorf("failed to copy manifest: %w", err)
+	}
+	filtered := &ArtifactManifest{}
+	if err := json.Unmarshal(data, filtered); err != nil {
+		return nil, summary, fmt.Errorf("failed to copy manifest: %w", err)
+	}
+
+	// owned holds every artifact some product claims, allowed those of the entitled products
+	owned := map[string]bool{}
+	allowed := map[string]bool{}
+	for name, product := range manifest.Products {
+		entitled := containsString(entitlements, name)
+		for _, key := range productKeys(product) {
+			owned[key] = true
+			if entitled {
+				allowed[key] = true
+			}
+		}
+	}
+	keep := func(key, label string) bool {
+		if !owned[key] || allowed[key] {
+			summary.Kept++
+			return true
+		}
+		summary.Removed = append(summary.Removed, label)
+		return false
+	}
+
+	filtered.Images = filterStrings(manifest.Images, func(uri string) bool { return keep("image:"+uri, uri) })
+	filtered.Models = filterStrings(manifest.Models, func(uri string) bool { return keep("model:"+uri, uri) })
+	filtered.Charts = []Chart{}
+	for _, chart := range manifest.Charts {
+		if keep("chart:"+chart.Name, "chart "+chart.Name) {
+			filtered.Charts = append(filtered.Charts, chart)
+		}
+	}
+	filtered.Datasets = nil
+	for _, dataset := range manifest.Datasets {
+		if keep("dataset:"+dataset.Name, "dataset "+dataset.Name) {
+			filtered.Datasets = append(filtered.Datasets, dataset)
+		}
+	}
+
+	filtered.Entitlements = entitlements
+	for name := range filtered.Products {
+		if !containsString(entitlements, name) {
+			delete(filtered.Products, name)
+		}
+	}
+	return filtered, summary, nil
+}
+
+// productKeys returns the artifacts of a product, prefixed by kind so names cannot collide
+func productKeys(product Product) []string {
+	var keys []string
+	for _, uri := range product.Images {
+		keys = append(keys, "image:"+uri)
+	}
+	for _, uri := range product.Models {
+		keys = append(keys, "model:"+uri)
+	}
+	for _, name := range product.Charts {
+		keys = append(keys, "chart:"+name)
+	}
+	for _, name := range product.Datasets {
+		keys = append(keys, "dataset:"+name)
+	}
+	return keys
+}
+
+// filterStrings returns the values keep accepts, never nil so filtered lists stay in the JSON
+func filterStrings(values []string, keep func(string) bool) []string {
+	kept := []string{}
+	for _, value := range values {
+		if keep(value) {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// uniqueSorted trims, deduplicates, and sorts names, dropping empty ones
+func uniqueSorted(names []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// productNames returns the product names in order
+func productNames(products map[string]Product) []string {
+	keys := make([]string, 0, len(products))
+	for name := range products {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func entitlementTestManifest() *ArtifactManifest {
+	return &ArtifactManifest{
+		ReleaseVersion: "3.22.2",
+		Images: []string{
+			"oci://host/dynamoai/images/dynamoai-api:3.22.2",
+			"oci://host/dynamoai/images/dynamoai-guard:3.22.2",
+			"oci://host/dynamoai/images/dynamoai-dpd:3.22.2",
+		},
+		Models: []string{"oci://host/dynamoai/models/moderation:1"},
+		Charts: []Chart{{Name: "dynamoai-base"}, {Name: "dynamoai-guard"}},
+		Datasets: []Dataset{
+			{Name: "toxicity-eval"},
+		},
+		Entitlements: []string{"guard"},
+		Products: map[string]Product{
+			"guard": {
+				Images: []string{"oci://host/dynamoai/images/dynamoai-guard:3.22.2"},
+				Models: []string{"oci://host/dynamoai/models/moderation:1"},
+				Charts: []string{"dynamoai-guard"},
+			},
+			"dpd": {
+				Images:   []string{"oci://host/dynamoai/images/dynamoai-dpd:3.22.2"},
+				Datasets: []string{"toxicity-eval"},
+			},
+		},
+	}
+}
+
+func TestFilterManifestByEntitlements(t *testing.T) {
+	manifest := entitlementTestManifest()
+
+	filtered, summary, err := FilterManifestByEntitlements(manifest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(filtered.Images, ",") != "oci://host/dynamoai/images/dynamoai-api:3.22.2,oci://host/dynamoai/images/dynamoai-guard:3.22.2" {
+		t.Fatalf("expected shared and guard images, got %v", filtered.Images)
+	}
+	if len(filtered.Models) != 1 || len(filtered.Charts) != 2 || len(filtered.Datasets) != 0 {
+		t.Fatalf("unexpected filtered manifest %+v", filtered)
+	}
+	if _, ok := filtered.Products["dpd"]; ok {
+		t.Fatal("expected the dpd product to be dropped")
+	}
+	if summary.Kept != 5 || len(summary.Removed) != 2 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if len(manifest.Images) != 3 || len(manifest.Products) != 2 {
+		t.Fatal("expected the input manifest to be left alone")
+	}
+
+	// An artifact claimed by several products stays when any of them is entitled
+	manifest.Products["dpd"] = Product{Charts: []string{"dynamoai-guard"}}
+	filtered, _, err = FilterManifestByEntitlements(manifest, []string{"dpd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.Charts) != 2 || len(filtered.Images) != 2 {
+		t.Fatalf("expected the shared chart kept for dpd, got %+v", filtered)
+	}
+}
+
+func TestFilterManifestByEntitlementsRejectsUnknownProducts(t *testing.T) {
+	manifest := entitlementTestManifest()
+	if _, _, err := FilterManifestByEntitlements(manifest, []string{"gaurd"}); err == nil || !strings.Contains(err.Error(), "dpd, guard") {
+		t.Fatalf("expected an unknown product error listing the products, got %v", err)
+	}
+	manifest.Entitlements = nil
+	if _, _, err := FilterManifestByEntitlements(manifest, nil); err == nil {
+		t.Fatal("expected an error without entitlements")
+	}
+	manifest.Products = nil
+	if _, _, err := FilterManifestByEntitlements(manifest, []string{"guard"}); err == nil {
+		t.Fatal("expected an error for a manifest without products")
+	}
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ManifestPublicKey is the key release manifests are signed with, embedded at build time with
+// -ldflags "-X github.com/dynamofl/dynactl/pkg/utils.ManifestPublicKey=<minisign public key>".
+// It is used when --manifest-key is not given.
+var ManifestPublicKey = ""
+
+// Detached signature files looked for next to a manifest
+const (
+	// CosignSignatureSuffix is the base64 signature cosign sign-blob writes
+	CosignSignatureSuffix = ".sig"
+	// MinisignSignatureSuffix is the signature file minisign -S writes
+	MinisignSignatureSuffix = ".minisig"
+)
+
+// ManifestSignatureFile returns the detached signature next to a manifest, preferring minisign
+func ManifestSignatureFile(manifestPath string) (string, error) {
+	for _, suffix := range []string{MinisignSignatureSuffix, CosignSignatureSuffix} {
+		if _, err := os.Stat(manifestPath + suffix); err == nil {
+			return manifestPath + suffix, nil
+		}
+	}
+	return "", fmt.Errorf("no signature found for %s (expected %s or %s); the manifest was not signed or the signature was not published with it",
+		manifestPath, manifestPath+MinisignSignatureSuffix, manifestPath+CosignSignatureSuffix)
+}
+
+// VerifyManifestSignature checks a manifest against a detached cosign or minisign signature.
+// key is a PEM public key (cosign, ECDSA P-256 or Ed25519) or a minisign public key; it falls
+// back to ManifestPublicKey when empty.
+func VerifyManifestSignature(manifestPath, signaturePath string, key []byte) error {
+	if len(bytes.TrimSpace(key)) == 0 {
+		key = []byte(ManifestPublicKey)
+	}
+	if len(bytes.TrimSpace(key)) == 0 {
+		return fmt.Errorf("no manifest verification key: pass --manifest-key, as this build has none embedded")
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	sig, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest signature: %w", err)
+	}
+
+	if strings.HasPrefix(string(sig), "untrusted comment:") {
+		err = verifyMinisign(data, sig, key)
+	} else {
+		err = verifyCosignBlob(data, sig, key)
+	}
+	if err != nil {
+		return fmt.Errorf("manifest %s failed signature verification: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// verifyCosignBlob checks a cosign sign-blob signature: base64 of an ASN.1 ECDSA signature over
+// the SHA-256 of the data, or a plain Ed25519 signature
+func verifyCosignBlob(data, sig, key []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return fmt.Errorf("a cosign signature needs a PEM public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("signature is not valid base64: %w", err)
+	}
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(pub, digest[:], raw) {
+			return fmt.Errorf("signature does not match")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, raw) {
+			return fmt.Errorf("signature does not match")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// minisignKey is a decoded minisign public key
+type minisignKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+// parseMinisignKey accepts a minisign public key file or its base64 line
+func parseMinisignKey(key []byte) (*minisignKey, error) {
+	line := lastNonCommentLine(string(key))
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("a minisign signature needs a minisign public key")
+	}
+	return &minisignKey{id: raw[2:10], key: ed25519.PublicKey(raw[10:])}, nil
+}
+
+// verifyMinisign checks a minisign signature file: the signature over the data (prehashed with
+// BLAKE2b-512 for the default ED algorithm) and the global signature over the trusted comment
+func verifyMinisign(data, sig, key []byte) error {
+	pub, err := parseMinisignKey(key)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	algorithm, keyID, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(keyID, pub.id) {
+		return fmt.Errorf("signed with key %X, not the supplied key %X", reverse(keyID), reverse(pub.id))
+	}
+
+	message := data
+	switch algorithm {
+	case "ED":
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	case "Ed":
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", algorithm)
+	}
+	if !ed25519.Verify(pub.key, message, signature) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(pub.key, append(append([]byte{}, signature...), trusted...), global) {
+		return fmt.Errorf("trusted comment signature does not match")
+	}
+	return nil
+}
+
+func lastNonCommentLine(s string) string {
+	var last string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			last = line
+		}
+	}
+	return last
+}
+
+// reverse returns b reversed; minisign prints key IDs as little-endian hex
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const testManifest = `{"release_version":"3.22.2","images":["artifacts.dynamo.ai/dynamoai/api:3.22.2"]}`
+
+// minisignFixture signs data the way minisign -S does and returns the public key file and
+// signature file contents
+func minisignFixture(t *testing.T, data []byte) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)) + "\n"
+
+	hash := blake2b.Sum512(data)
+	signature := ed25519.Sign(priv, hash[:])
+	trusted := "timestamp:1767225600\tfile:manifest.json\thashed"
+	global := ed25519.Sign(priv, append(append([]byte{}, signature...), trusted...))
+	sig := "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), signature...)) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+	return publicKey, sig
+}
+
+func writeSignedManifest(t *testing.T, sigSuffix, sig string) string {
+	t.Helper()
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(manifest, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifest+sigSuffix, []byte(sig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestVerifyManifestSignatureMinisign(t *testing.T) {
+	publicKey, sig := minisignFixture(t, []byte(testManifest))
+	manifest := writeSignedManifest(t, MinisignSignatureSuffix, sig)
+
+	sigFile, err := ManifestSignatureFile(manifest)
+	if err != nil || sigFile != manifest+MinisignSignatureSuffix {
+		t.Fatalf("expected to find the minisign signature, got %q, %v", sigFile, err)
+	}
+	if err := VerifyManifestSignature(manifest, sigFile, []byte(publicKey)); err != nil {
+		t.Fatalf("expected a valid signature: %v", err)
+	}
+
+	// The bare base64 key line works too, which is how the embedded key is set
+	embedded := strings.TrimSpace(strings.Split(publicKey, "\n")[1])
+	ManifestPublicKey = embedded
+	defer func() { ManifestPublicKey = "" }()
+	if err := VerifyManifestSignature(manifest, sigFile, nil); err != nil {
+		t.Fatalf("expected the embedded key to be used: %v", err)
+	}
+
+	if err := os.WriteFile(manifest, []byte(strings.Replace(testManifest, "artifacts.dynamo.ai", "evil.example.com", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyManifestSignature(manifest, sigFile, []byte(publicKey)); err == nil {
+		t.Fatal("expected a tampered manifest to fail verification")
+	}
+
+	otherKey, _ := minisignFixture(t, []byte(testManifest))
+	if err := VerifyManifestSignature(manifest, sigFile, []byte(otherKey)); err == nil {
+		t.Fatal("expected a different key to fail verification")
+	}
+}
+
+func TestVerifyManifestSignatureCosign(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	digest := sha256.Sum256([]byte(testManifest))
+	raw, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := writeSignedManifest(t, CosignSignatureSuffix, base64.StdEncoding.EncodeToString(raw))
+
+	if err := VerifyManifestSignature(manifest, manifest+CosignSignatureSuffix, publicKey); err != nil {
+		t.Fatalf("expected a valid signature: %v", err)
+	}
+	if err := os.WriteFile(manifest, []byte(testManifest+" "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyManifestSignature(manifest, manifest+CosignSignatureSuffix, publicKey); err == nil {
+		t.Fatal("expected a tampered manifest to fail verification")
+	}
+}
+
+func TestVerifyManifestSignatureNeedsKeyAndSignature(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(manifest, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ManifestSignatureFile(manifest); err == nil {
+		t.Fatal("expected an unsigned manifest to be reported")
+	}
+	if err := VerifyManifestSignature(manifest, manifest+".sig", nil); err == nil || !strings.Contains(err.Error(), "--manifest-key") {
+		t.Fatalf("expected a missing key to be reported, got %v", err)
+	}
+}
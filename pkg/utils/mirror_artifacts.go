@@ -1,17 +1,21 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 // MirrorArtifacts pushes selected artifacts from the local cache into a target registry.
-// Currently only container images are supported.
+// Container images and datasets are supported.
 func MirrorArtifacts(manifest *ArtifactManifest, cacheDir, targetRegistry string, options MirrorOptions) error {
 	options = NormalizeMirrorOptions(options)
 	targetRegistry = strings.TrimSuffix(strings.TrimSpace(targetRegistry), "/")
@@ -21,6 +25,26 @@ func MirrorArtifacts(manifest *ArtifactManifest, cacheDir, targetRegistry string
 
 	keychain := NewDynactlKeychain()
 
+	if err := validateTargetOverrides(options.TargetOverrides); err != nil {
+		return err
+	}
+	if err := validateTargetOverrides(manifest.TargetOverrides); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	options.TargetOverrides = MergeTargetOverrides(manifest.TargetOverrides, options.TargetOverrides)
+	if len(options.TargetOverrides) > 0 {
+		var sources []string
+		for _, ref := range manifest.Images {
+			sources = append(sources, overrideKey(ref))
+		}
+		for _, dataset := range manifest.Datasets {
+			sources = append(sources, overrideKey(dataset.URI))
+		}
+		for _, source := range unusedTargetOverrides(options.TargetOverrides, sources) {
+			LogWarning("Target override for %s matches no image or dataset in the manifest", source)
+		}
+	}
+
 	if options.IncludeModels && len(manifest.Models) > 0 {
 		return fmt.Errorf("mirroring ML models is not supported yet; rerun with --images to mirror container images only")
 	}
@@ -30,22 +54,43 @@ func MirrorArtifacts(manifest *ArtifactManifest, cacheDir, targetRegistry string
 
 	if options.IncludeImages && len(manifest.Images) > 0 {
 		LogInfo("=== Mirroring Container Images ===")
-		if err := mirrorContainerImages(manifest.Images, cacheDir, targetRegistry, keychain); err != nil {
+		if err := mirrorContainerImages(manifest.Images, cacheDir, targetRegistry, keychain, options); err != nil {
 			return err
 		}
 	} else {
 		LogInfo("No container images selected for mirroring")
 	}
 
+	if options.IncludeDatasets && len(manifest.Datasets) > 0 {
+		LogInfo("=== Mirroring Datasets ===")
+		datasets := convertManifestToComponents(manifest, PullOptions{IncludeDatasets: true})
+		if err := mirrorDatasets(datasets, cacheDir, targetRegistry, options); err != nil {
+			return err
+		}
+	}
+
 	LogInfo("Mirror operation completed successfully")
 	return nil
 }
 
-func mirrorContainerImages(images []string, cacheDir, targetRegistry string, keychain authn.Keychain) error {
-	for idx, imageRef := range images {
-		current := idx + 1
-		total := len(images)
+// imagePush is the outcome of mirroring one cached image
+type imagePush struct {
+	source string
+	target string
+	digest string
+	// skipped is set when the journal records the push from an earlier run
+	skipped bool
+	// denied holds the pre-push hook rejection, which does not stop the other pushes
+	denied error
+	stat   *TransferStat
+	err    error
+}
 
+func mirrorContainerImages(images []string, cacheDir, targetRegistry string, keychain authn.Keychain, options MirrorOptions) error {
+	total := len(images)
+	results := make([]imagePush, total)
+	tarPaths := make([]string, total)
+	for idx, imageRef := range images {
 		componentRef := strings.TrimPrefix(imageRef, "oci://")
 		repoPart, tagOrDigest := splitRepositoryAndReference(componentRef)
 		if repoPart == "" {
@@ -55,31 +100,165 @@ func mirrorContainerImages(images []string, cacheDir, targetRegistry string, key
 			return fmt.Errorf("image reference missing tag or digest: %s", imageRef)
 		}
 
-		imageName := extractNameFromURI(componentRef)
-		tarPath := filepath.Join(cacheDir, fmt.Sprintf("%s.tar", imageName))
+		targetRepo, targetTag := mirrorTarget(repoPart, tagOrDigest, targetRegistry, options.TargetOverrides)
+		if options.TargetRepository != nil {
+			targetRepo = options.TargetRepository(targetRepo)
+		}
+		results[idx] = imagePush{source: componentRef, target: assembleTargetReference(targetRepo, targetTag)}
+		tarPaths[idx] = filepath.Join(cacheDir, fmt.Sprintf("%s.tar", extractNameFromURI(componentRef)))
+	}
 
-		targetRepo := buildTargetRepository(targetRegistry, repoPart)
-		targetRef := assembleTargetReference(targetRepo, tagOrDigest)
+	workers := options.PushConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+	if workers > 1 {
+		LogInfo("Pushing %d image(s) with %d concurrent uploads", total, workers)
+	}
+
+	// Workers push in any order; results are reported in manifest order as each one's
+	// predecessors finish, so logs and callbacks read the same as a serial run
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make([]chan struct{}, total)
+	jobs := make(chan int, total)
+	for idx := range results {
+		done[idx] = make(chan struct{})
+		if entry, ok := options.Journal.Completed(JournalStagePushed, results[idx].source, results[idx].target); ok {
+			results[idx].skipped = true
+			results[idx].digest = entry.Digest
+			close(done[idx])
+			continue
+		}
+		jobs <- idx
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[idx].err = err
+				} else {
+					pushCachedImage(&results[idx], tarPaths[idx], keychain, options.PrePushHooks)
+				}
+				close(done[idx])
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	var denied []string
+	var failed []error
+	for idx := range results {
+		<-done[idx]
+		result := results[idx]
+		current := idx + 1
 
 		LogInfo("📤 Pushing image %d/%d", current, total)
-		LogInfo("  Source: %s", componentRef)
-		LogInfo("  Target: %s", targetRef)
+		LogInfo("  Source: %s", result.source)
+		LogInfo("  Target: %s", result.target)
+
+		switch {
+		case result.skipped:
+			LogInfo("⏭️  Already pushed by an earlier run (%s); skipping", result.digest)
+			if options.Pushed != nil {
+				options.Pushed(result.source, result.target, result.digest)
+			}
+			continue
+		case result.denied != nil:
+			// Keep going so one run reports every artifact the gate rejects
+			LogError("⛔ %v", result.denied)
+			denied = append(denied, result.source)
+			continue
+		case result.err != nil:
+			LogError("❌ %v", result.err)
+			failed = append(failed, result.err)
+			continue
+		}
 
-		if err := pushImageFromTar(tarPath, targetRef, keychain); err != nil {
+		if options.Transferred != nil && result.stat != nil {
+			options.Transferred(*result.stat)
+		}
+		if options.Pushed != nil {
+			options.Pushed(result.source, result.target, result.digest)
+		}
+		err := options.Journal.Record(JournalEntry{
+			Stage:  JournalStagePushed,
+			Type:   ArtifactTypeContainerImage,
+			Source: result.source,
+			Target: result.target,
+			Digest: result.digest,
+		})
+		if err != nil {
+			cancel()
 			return err
 		}
 
-		LogInfo("✅ Pushed %s (%d/%d)", targetRef, current, total)
+		LogInfo("✅ Pushed %s (%d/%d)", result.target, current, total)
 	}
-	return nil
+
+	var errs []error
+	if len(failed) > 0 {
+		errs = append(errs, fmt.Errorf("failed to push %d of %d image(s): %w", len(failed), total, errors.Join(failed...)))
+	}
+	if len(denied) > 0 {
+		errs = append(errs, fmt.Errorf("pre-push hooks denied %d image(s): %s", len(denied), strings.Join(denied, ", ")))
+	}
+	return errors.Join(errs...)
 }
 
-func pushImageFromTar(tarPath, targetRef string, keychain authn.Keychain) error {
-	img, err := tarball.ImageFromPath(tarPath, nil)
+// pushCachedImage pushes one image archive from the cache, filling in result
+func pushCachedImage(result *imagePush, tarPath string, keychain authn.Keychain, hooks []ArtifactHook) {
+	img, closer, err := openCachedImage(tarPath)
+	if err != nil {
+		result.err = fmt.Errorf("failed to read image archive %s: %w", tarPath, err)
+		return
+	}
+	defer closer.Close()
+
+	digest, err := img.Digest()
 	if err != nil {
-		return fmt.Errorf("failed to read image archive %s: %w", tarPath, err)
+		result.err = fmt.Errorf("failed to compute digest of %s: %w", tarPath, err)
+		return
+	}
+	result.digest = digest.String()
+	if len(hooks) > 0 {
+		err = RunArtifactHooks(context.Background(), hooks, HookArtifact{
+			Stage:  HookStagePrePush,
+			Type:   ArtifactTypeContainerImage,
+			Source: result.source,
+			Target: result.target,
+			Path:   tarPath,
+			Digest: result.digest,
+		})
+		if err != nil {
+			result.denied = err
+			return
+		}
 	}
 
+	tracked, tracker, err := trackUploads(img)
+	if err != nil {
+		result.err = fmt.Errorf("failed to read layers of %s: %w", tarPath, err)
+		return
+	}
+	started := time.Now()
+	if err := pushImage(tracked, result.target, keychain); err != nil {
+		result.err = err
+		return
+	}
+	stat := tracker.stat(result.source, result.target, time.Since(started))
+	result.stat = &stat
+}
+
+func pushImage(img v1.Image, targetRef string, keychain authn.Keychain) error {
 	if err := crane.Push(img, targetRef, crane.WithAuthFromKeychain(keychain)); err != nil {
 		return fmt.Errorf("failed to push image to %s: %w", targetRef, err)
 	}
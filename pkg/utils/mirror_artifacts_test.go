@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestMirrorContainerImagesConcurrently(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	cacheDir := t.TempDir()
+
+	var images, want []string
+	for _, name := range []string{"dynamoai-api", "dynamoai-ui", "dynamoai-worker", "dynamoai-guard", "dynamoai-eval"} {
+		ref := "artifacts.dynamo.ai/dynamoai/images/" + name + ":3.22.2"
+		images = append(images, "oci://"+ref)
+		if name == "dynamoai-guard" {
+			// Never pulled, so its push fails without stopping the others
+			continue
+		}
+		img, err := random.Image(256, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := crane.Save(img, ref, filepath.Join(cacheDir, name+".tar")); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, host+"/dynamo/dynamoai/images/"+name+":3.22.2")
+	}
+
+	var pushed []string
+	var transferred int
+	err := MirrorArtifacts(&ArtifactManifest{Images: images}, cacheDir, host+"/dynamo", MirrorOptions{
+		IncludeImages:   true,
+		PushConcurrency: 3,
+		Pushed:          func(source, target, digest string) { pushed = append(pushed, target) },
+		Transferred:     func(stat TransferStat) { transferred++ },
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to push 1 of 5 image(s)") || !strings.Contains(err.Error(), "dynamoai-guard.tar") {
+		t.Fatalf("expected the missing archive to be reported, got %v", err)
+	}
+	if strings.Join(pushed, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected pushes reported in manifest order %v, got %v", want, pushed)
+	}
+	if transferred != len(want) {
+		t.Fatalf("expected %d transfer stats, got %d", len(want), transferred)
+	}
+	for _, ref := range want {
+		if _, err := crane.Digest(ref, crane.Insecure); err != nil {
+			t.Fatalf("expected %s in the registry: %v", ref, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MirrorJournalFile is the journal a mirror keeps in its cache directory
+const MirrorJournalFile = "dynactl-mirror-journal.jsonl"
+
+// Journal stages
+const (
+	JournalStagePulled = "pulled"
+	JournalStagePushed = "pushed"
+)
+
+// JournalEntry records one artifact a mirror finished pulling or pushing
+type JournalEntry struct {
+	Stage  string    `json:"stage"`
+	Type   string    `json:"type"`
+	Source string    `json:"source"`
+	Target string    `json:"target,omitempty"`
+	Digest string    `json:"digest,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// MirrorJournal appends each completed artifact to a JSON Lines file as soon as it completes, so
+// a later run can skip them even if this one is killed
+type MirrorJournal struct {
+	mu      sync.Mutex
+	file    *os.File
+	path    string
+	entries map[string]JournalEntry
+}
+
+// OpenMirrorJournal opens the journal in cacheDir. With resume the entries of earlier runs are
+// kept; otherwise the journal starts empty.
+func OpenMirrorJournal(cacheDir string, resume bool) (*MirrorJournal, error) {
+	path := filepath.Join(cacheDir, MirrorJournalFile)
+	journal := &MirrorJournal{path: path, entries: map[string]JournalEntry{}}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resume {
+		if err := journal.load(); err != nil {
+			return nil, err
+		}
+	} else {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(LongPath(path), flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror journal: %w", err)
+	}
+	journal.file = file
+	return journal, nil
+}
+
+// load reads the entries of earlier runs. A killed run can leave a partial last line, which is
+// ignored as that artifact never completed.
+func (j *MirrorJournal) load() error {
+	file, err := os.Open(LongPath(j.path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read mirror journal: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			LogWarning("Ignoring unreadable mirror journal line: %v", err)
+			continue
+		}
+		j.entries[journalKey(entry.Stage, entry.Source, entry.Target)] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read mirror journal: %w", err)
+	}
+	return nil
+}
+
+// Path returns the journal file
+func (j *MirrorJournal) Path() string {
+	return j.path
+}
+
+// Len returns how many entries have been recorded, including those of earlier runs
+func (j *MirrorJournal) Len(stage string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := 0
+	for _, entry := range j.entries {
+		if entry.Stage == stage {
+			n++
+		}
+	}
+	return n
+}
+
+// Record appends an entry and syncs it to disk
+func (j *MirrorJournal) Record(entry JournalEntry) error {
+	if j == nil {
+		return nil
+	}
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write mirror journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write mirror journal: %w", err)
+	}
+	j.entries[journalKey(entry.Stage, entry.Source, entry.Target)] = entry
+	return nil
+}
+
+// Completed returns the entry recording that source reached target at a stage; pulls have no target
+func (j *MirrorJournal) Completed(stage, source, target string) (JournalEntry, bool) {
+	if j == nil {
+		return JournalEntry{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.entries[journalKey(stage, source, target)]
+	return entry, ok
+}
+
+// Close closes the journal file
+func (j *MirrorJournal) Close() error {
+	return j.file.Close()
+}
+
+func journalKey(stage, source, target string) string {
+	return stage + "\x00" + source + "\x00" + target
+}
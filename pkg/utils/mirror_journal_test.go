@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirrorJournalResume(t *testing.T) {
+	cacheDir := t.TempDir()
+	journal, err := OpenMirrorJournal(cacheDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := JournalEntry{Stage: JournalStagePushed, Type: ArtifactTypeContainerImage, Source: "src/api:1", Target: "dst/api:1", Digest: "sha256:abc"}
+	if err := journal.Record(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(JournalEntry{Stage: JournalStagePulled, Type: ArtifactTypeContainerImage, Source: "src/api:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A run killed mid-write leaves a partial line behind
+	f, err := os.OpenFile(filepath.Join(cacheDir, MirrorJournalFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"stage":"pushed","source":"src/web`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	resumed, err := OpenMirrorJournal(cacheDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := resumed.Completed(JournalStagePushed, "src/api:1", "dst/api:1")
+	if !ok || got.Digest != "sha256:abc" {
+		t.Fatalf("expected the pushed entry to survive, got %+v, %v", got, ok)
+	}
+	if _, ok := resumed.Completed(JournalStagePushed, "src/api:1", "elsewhere/api:1"); ok {
+		t.Fatal("expected a push to another target not to count")
+	}
+	if resumed.Len(JournalStagePulled) != 1 || resumed.Len(JournalStagePushed) != 1 {
+		t.Fatalf("unexpected counts: %d pulled, %d pushed", resumed.Len(JournalStagePulled), resumed.Len(JournalStagePushed))
+	}
+	resumed.Close()
+
+	fresh, err := OpenMirrorJournal(cacheDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if _, ok := fresh.Completed(JournalStagePushed, "src/api:1", "dst/api:1"); ok {
+		t.Fatal("expected a run without resume to start an empty journal")
+	}
+}
+
+func TestMirrorArtifactsSkipsJournaledPushes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	cacheDir := t.TempDir()
+
+	journal, err := OpenMirrorJournal(cacheDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	// No archive is cached for the image, so only a skip lets the mirror succeed
+	source := "artifacts.dynamo.ai/dynamoai/images/dynamoai-api:3.22.2"
+	target := host + "/dynamo/dynamoai/images/dynamoai-api:3.22.2"
+	if err := journal.Record(JournalEntry{Stage: JournalStagePushed, Source: source, Target: target, Digest: "sha256:abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed []string
+	err = MirrorArtifacts(&ArtifactManifest{Images: []string{"oci://" + source}}, cacheDir, host+"/dynamo", MirrorOptions{
+		IncludeImages: true,
+		Journal:       journal,
+		Pushed:        func(source, target, digest string) { pushed = append(pushed, digest) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pushed) != 1 || pushed[0] != "sha256:abc" {
+		t.Fatalf("expected the journaled push to be reported, got %v", pushed)
+	}
+}
@@ -5,16 +5,33 @@ type MirrorOptions struct {
 	IncludeImages bool
 	IncludeModels bool
 	IncludeCharts bool
+	// IncludeDatasets selects evaluation datasets
+	IncludeDatasets bool
+	// TargetRepository, when set, rewrites each target repository to suit the registry product
+	TargetRepository func(repository string) string
+	// TargetOverrides routes source repositories elsewhere; they take precedence over the
+	// manifest's target_overrides
+	TargetOverrides map[string]TargetOverride
+	// PrePushHooks must all allow an artifact before it is pushed
+	PrePushHooks []ArtifactHook
+	// Pushed, when set, is called with the source, target, and manifest digest of each pushed
+	// artifact
+	Pushed func(source, target, digest string)
+	// Transferred, when set, receives the upload statistics of each pushed artifact
+	Transferred func(stat TransferStat)
+	// Journal, when set, records each pushed artifact and skips those an earlier run recorded
+	Journal *MirrorJournal
+	// PushConcurrency is how many images are uploaded at once; zero or less pushes one at a time
+	PushConcurrency int
 }
 
 // NormalizeMirrorOptions ensures at least one artifact category is included.
 func NormalizeMirrorOptions(opts MirrorOptions) MirrorOptions {
-	if !opts.IncludeImages && !opts.IncludeModels && !opts.IncludeCharts {
-		return MirrorOptions{
-			IncludeImages: true,
-			IncludeModels: true,
-			IncludeCharts: true,
-		}
+	if !opts.IncludeImages && !opts.IncludeModels && !opts.IncludeCharts && !opts.IncludeDatasets {
+		opts.IncludeImages = true
+		opts.IncludeModels = true
+		opts.IncludeCharts = true
+		opts.IncludeDatasets = true
 	}
 	return opts
 }
@@ -22,8 +39,9 @@ func NormalizeMirrorOptions(opts MirrorOptions) MirrorOptions {
 // MirrorOptionsFromPull converts pull options to mirror options.
 func MirrorOptionsFromPull(opts PullOptions) MirrorOptions {
 	return MirrorOptions{
-		IncludeImages: opts.IncludeImages,
-		IncludeModels: opts.IncludeModels,
-		IncludeCharts: opts.IncludeCharts,
+		IncludeImages:   opts.IncludeImages,
+		IncludeModels:   opts.IncludeModels,
+		IncludeCharts:   opts.IncludeCharts,
+		IncludeDatasets: opts.IncludeDatasets,
 	}
 }
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+)
+
+// ImageSizeFunc returns the bytes an image reference pulls
+type ImageSizeFunc func(reference string) (int64, error)
+
+// PlanMirror lays out what MirrorArtifacts would do without pulling or pushing anything: each
+// selected image is pulled into cacheDir and pushed to the target registry. Images already in
+// the cache or recorded in the options' journal are skipped. sizeOf, when set, sizes the images
+// that still need pulling; images already cached are sized from their archives.
+func PlanMirror(manifest *ArtifactManifest, cacheDir, targetRegistry string, options MirrorOptions, transferRate int64, sizeOf ImageSizeFunc) (*Plan, error) {
+	options = NormalizeMirrorOptions(options)
+	targetRegistry = strings.TrimSuffix(strings.TrimSpace(targetRegistry), "/")
+	if targetRegistry == "" {
+		return nil, fmt.Errorf("target registry cannot be empty")
+	}
+	if err := validateTargetOverrides(options.TargetOverrides); err != nil {
+		return nil, err
+	}
+	if err := validateTargetOverrides(manifest.TargetOverrides); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	overrides := MergeTargetOverrides(manifest.TargetOverrides, options.TargetOverrides)
+	if options.IncludeModels && len(manifest.Models) > 0 {
+		return nil, fmt.Errorf("mirroring ML models is not supported yet; rerun with --images to mirror container images only")
+	}
+	if options.IncludeCharts && len(manifest.Charts) > 0 {
+		return nil, fmt.Errorf("mirroring Helm charts is not supported yet; rerun with --images to mirror container images only")
+	}
+
+	plan := NewPlan("artifacts mirror", transferRate)
+	if options.IncludeImages {
+		for _, imageRef := range manifest.Images {
+			source := strings.TrimPrefix(imageRef, "oci://")
+			repoPart, tagOrDigest := splitRepositoryAndReference(source)
+			if repoPart == "" || tagOrDigest == "" {
+				return nil, fmt.Errorf("invalid image reference: %s", imageRef)
+			}
+			targetRepo, targetTag := mirrorTarget(repoPart, tagOrDigest, targetRegistry, overrides)
+			if options.TargetRepository != nil {
+				targetRepo = options.TargetRepository(targetRepo)
+			}
+			target := assembleTargetReference(targetRepo, targetTag)
+			tarPath := filepath.Join(cacheDir, fmt.Sprintf("%s.tar", extractNameFromURI(source)))
+
+			pull := PlanAction{Action: PlanActionPull, Kind: ArtifactTypeContainerImage, Source: source, Destination: tarPath}
+			if info, err := os.Stat(LongPath(tarPath)); err == nil {
+				pull.Action, pull.SizeBytes, pull.Note = PlanActionSkip, info.Size(), "already in the cache"
+			} else if _, done := options.Journal.Completed(JournalStagePulled, source, ""); done {
+				pull.Action, pull.Note = PlanActionSkip, "already pulled by an earlier run"
+			} else if sizeOf != nil {
+				size, err := sizeOf(source)
+				if err != nil {
+					pull.Note = fmt.Sprintf("size unknown: %v", err)
+				}
+				pull.SizeBytes = size
+			}
+			plan.Add(pull)
+
+			push := PlanAction{Action: PlanActionPush, Kind: ArtifactTypeContainerImage, Source: tarPath, Destination: target, SizeBytes: pull.SizeBytes}
+			if _, done := options.Journal.Completed(JournalStagePushed, source, target); done {
+				push.Action, push.Note = PlanActionSkip, "already pushed by an earlier run"
+			}
+			plan.Add(push)
+		}
+	}
+
+	if options.IncludeDatasets {
+		for _, dataset := range manifest.Datasets {
+			source := strings.TrimPrefix(dataset.URI, "oci://")
+			repoPart, tagOrDigest := splitRepositoryAndReference(source)
+			if tagOrDigest == "" || strings.HasPrefix(tagOrDigest, "sha256:") {
+				return nil, fmt.Errorf("dataset %s must be referenced by tag to be mirrored, not %q", source, tagOrDigest)
+			}
+			targetRepo, targetTag := mirrorTarget(repoPart, tagOrDigest, targetRegistry, overrides)
+			if options.TargetRepository != nil {
+				targetRepo = options.TargetRepository(targetRepo)
+			}
+			target := assembleTargetReference(targetRepo, targetTag)
+
+			pull := PlanAction{Action: PlanActionPull, Kind: ArtifactTypeDataset, Source: source, Destination: cacheDir}
+			if _, done := options.Journal.Completed(JournalStagePulled, source, ""); done {
+				pull.Action, pull.Note = PlanActionSkip, "already pulled by an earlier run"
+			}
+			plan.Add(pull)
+			push := PlanAction{Action: PlanActionPush, Kind: ArtifactTypeDataset, Source: source, Destination: target}
+			if _, done := options.Journal.Completed(JournalStagePushed, source, target); done {
+				push.Action, push.Note = PlanActionSkip, "already pushed by an earlier run"
+			}
+			plan.Add(push)
+		}
+	}
+	return plan, nil
+}
+
+// RemoteImageSize returns the compressed size of an image's config and layers as its registry
+// reports them, reading only the manifest
+func RemoteImageSize(reference string, opts RegistryClientOptions) (int64, error) {
+	httpClient, err := opts.HTTPClient()
+	if err != nil {
+		return 0, err
+	}
+	craneOpts := []crane.Option{crane.WithTransport(httpClient.Transport)}
+	if opts.PlainHTTP {
+		craneOpts = append(craneOpts, crane.Insecure)
+	}
+	img, err := crane.Pull(reference, craneOpts...)
+	if err != nil {
+		return 0, err
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return 0, err
+	}
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
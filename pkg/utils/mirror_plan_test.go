@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanMirror(t *testing.T) {
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "dynamoai-ui.tar"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := OpenMirrorJournal(cacheDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	err = journal.Record(JournalEntry{
+		Stage:  JournalStagePushed,
+		Source: "artifacts.dynamo.ai/dynamoai/images/dynamoai-ui:3.22.2",
+		Target: "harbor.example.com/dynamo/dynamoai/images/dynamoai-ui:3.22.2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &ArtifactManifest{
+		Images: []string{
+			"oci://artifacts.dynamo.ai/dynamoai/images/dynamoai-api:3.22.2",
+			"oci://artifacts.dynamo.ai/dynamoai/images/dynamoai-ui:3.22.2",
+			"oci://artifacts.dynamo.ai/dynamoai/images/dynamoai-worker:3.22.2",
+		},
+		TargetOverrides: map[string]TargetOverride{
+			"artifacts.dynamo.ai/dynamoai/images/dynamoai-worker": {Repository: "platform/worker"},
+		},
+	}
+	sizeOf := func(reference string) (int64, error) {
+		if strings.Contains(reference, "worker") {
+			return 0, fmt.Errorf("manifest unknown")
+		}
+		return 50 << 20, nil
+	}
+	plan, err := PlanMirror(manifest, cacheDir, "harbor.example.com/dynamo", MirrorOptions{IncludeImages: true, Journal: journal}, 25<<20, sizeOf)
+	if err != nil {
+		t.Fatalf("PlanMirror failed: %v", err)
+	}
+
+	var got []string
+	for _, a := range plan.Actions {
+		got = append(got, a.Action+" "+a.Destination)
+	}
+	want := []string{
+		"pull " + filepath.Join(cacheDir, "dynamoai-api.tar"),
+		"push harbor.example.com/dynamo/dynamoai/images/dynamoai-api:3.22.2",
+		"skip " + filepath.Join(cacheDir, "dynamoai-ui.tar"),
+		"skip harbor.example.com/dynamo/dynamoai/images/dynamoai-ui:3.22.2",
+		"pull " + filepath.Join(cacheDir, "dynamoai-worker.tar"),
+		"push harbor.example.com/platform/worker:3.22.2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if plan.Actions[3].SizeBytes != 4096 {
+		t.Errorf("expected the cached archive to size the skipped push, got %d", plan.Actions[3].SizeBytes)
+	}
+	if !strings.Contains(plan.Actions[4].Note, "manifest unknown") {
+		t.Errorf("expected the size lookup failure in the note, got %q", plan.Actions[4].Note)
+	}
+	if plan.TotalBytes != 100<<20 || plan.TotalEstimatedDuration.Seconds() != 4 {
+		t.Errorf("expected only the api pull and push in the totals, got %d bytes and %s", plan.TotalBytes, plan.TotalEstimatedDuration)
+	}
+}
+
+func TestPlanMirrorRejectsModels(t *testing.T) {
+	manifest := &ArtifactManifest{Models: []string{"oci://artifacts.dynamo.ai/dynamoai/models/guard:1.0"}}
+	if _, err := PlanMirror(manifest, t.TempDir(), "harbor.example.com/dynamo", MirrorOptions{IncludeModels: true}, 0, nil); err == nil {
+		t.Error("expected models to be rejected as they are by the mirror")
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// DefaultStagingRecord is where mirror --stage records what it pushed for a later promote
+const DefaultStagingRecord = "dynactl-staged.json"
+
+// StagingRecord lists the images a staged mirror pushed, pinned by digest, so promote copies
+// exactly what was scanned
+type StagingRecord struct {
+	ReleaseVersion  string        `json:"release_version,omitempty"`
+	StagingRegistry string        `json:"staging_registry"`
+	StagedAt        time.Time     `json:"staged_at"`
+	Images          []StagedImage `json:"images"`
+}
+
+// StagedImage is one image pushed into the staging registry
+type StagedImage struct {
+	// Source is the reference from the manifest
+	Source string `json:"source"`
+	// Staged is the reference pushed to the staging registry
+	Staged string `json:"staged"`
+	// Digest is the manifest digest that was pushed
+	Digest string `json:"digest"`
+	// Override is the target override the image was mirrored with, applied again on promote
+	Override *TargetOverride `json:"override,omitempty"`
+}
+
+// NewStagedImage records one staged push, keeping the target override that routed its source
+// so promote writes the same production repository and tag
+func NewStagedImage(source, staged, digest string, overrides map[string]TargetOverride) StagedImage {
+	image := StagedImage{Source: source, Staged: staged, Digest: digest}
+	repo, _ := splitRepositoryAndReference(source)
+	if override, ok := overrides[repo]; ok {
+		image.Override = &override
+	}
+	return image
+}
+
+// CheckStagingOverrides rejects target overrides that name a registry host. A staged mirror must
+// push everything into the staging registry, and such an override would bypass it.
+func CheckStagingOverrides(overrides map[string]TargetOverride) error {
+	var hosted []string
+	for source, override := range overrides {
+		if hasRegistryHost(strings.Trim(override.Repository, "/")) {
+			hosted = append(hosted, fmt.Sprintf("%s -> %s", source, override.Repository))
+		}
+	}
+	if len(hosted) > 0 {
+		sort.Strings(hosted)
+		return fmt.Errorf("--stage cannot be used with target overrides that name a registry host; use a path instead:\n  %s", strings.Join(hosted, "\n  "))
+	}
+	return nil
+}
+
+// promotionTarget returns the production repository and tag or digest a staged image is copied
+// to, mapped the same way mirror maps it
+func promotionTarget(image StagedImage, production string) (string, string) {
+	repo, tagOrDigest := splitRepositoryAndReference(image.Source)
+	var overrides map[string]TargetOverride
+	if image.Override != nil {
+		overrides = map[string]TargetOverride{repo: *image.Override}
+	}
+	return mirrorTarget(repo, tagOrDigest, production, overrides)
+}
+
+// PromoteOptions controls how staged images are copied into production
+type PromoteOptions struct {
+	// TargetRepository, when set, rewrites each production repository to suit the registry product
+	TargetRepository func(repository string) string
+}
+
+// PromotedImage is one image copied from staging into production
+type PromotedImage struct {
+	Staged string
+	Target string
+	Digest string
+}
+
+// WriteStagingRecord saves a staging record as indented JSON
+func WriteStagingRecord(path string, record *StagingRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode staging record: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write staging record: %w", err)
+	}
+	return nil
+}
+
+// LoadStagingRecord reads a staging record written by mirror --stage
+func LoadStagingRecord(path string) (*StagingRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staging record: %w", err)
+	}
+	var record StagingRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse staging record %s: %w", path, err)
+	}
+	if len(record.Images) == 0 {
+		return nil, fmt.Errorf("staging record %s lists no images", path)
+	}
+	for _, image := range record.Images {
+		if image.Source == "" || image.Staged == "" || !strings.HasPrefix(image.Digest, "sha256:") {
+			return nil, fmt.Errorf("staging record %s has an incomplete entry for %q", path, image.Source)
+		}
+	}
+	return &record, nil
+}
+
+// PromotionRepositories returns the production repositories promote will write, for target
+// preflight checks
+func PromotionRepositories(record *StagingRecord, production string) []string {
+	production = strings.TrimSuffix(strings.TrimSpace(production), "/")
+	seen := map[string]bool{}
+	var repositories []string
+	for _, image := range record.Images {
+		target, _ := promotionTarget(image, production)
+		if !seen[target] {
+			seen[target] = true
+			repositories = append(repositories, target)
+		}
+	}
+	return repositories
+}
+
+// PromoteImages copies staged images into the production registry under their original tags,
+// or the repository and tag their target override names.
+// Every staged reference is checked against its recorded digest first, so an image retagged in
+// staging after the scan is never promoted.
+func PromoteImages(ctx context.Context, record *StagingRecord, production string, opts PromoteOptions) ([]PromotedImage, error) {
+	production = strings.TrimSuffix(strings.TrimSpace(production), "/")
+	if production == "" {
+		return nil, fmt.Errorf("production registry cannot be empty")
+	}
+	craneOpts := []crane.Option{crane.WithContext(ctx), crane.WithAuthFromKeychain(NewDynactlKeychain())}
+
+	// Check everything before copying anything so a moved tag leaves production untouched
+	var moved []string
+	for _, image := range record.Images {
+		current, err := crane.Digest(image.Staged, craneOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve staged image %s: %w", image.Staged, err)
+		}
+		if current != image.Digest {
+			moved = append(moved, fmt.Sprintf("%s is now %s, staged as %s", image.Staged, current, image.Digest))
+		}
+	}
+	if len(moved) > 0 {
+		return nil, fmt.Errorf("staged images changed since they were mirrored; re-stage before promoting:\n  %s", strings.Join(moved, "\n  "))
+	}
+
+	var promoted []PromotedImage
+	for idx, image := range record.Images {
+		targetRepo, tagOrDigest := promotionTarget(image, production)
+		if opts.TargetRepository != nil {
+			targetRepo = opts.TargetRepository(targetRepo)
+		}
+		targetRef := assembleTargetReference(targetRepo, tagOrDigest)
+		staged, err := name.ParseReference(image.Staged)
+		if err != nil {
+			return promoted, fmt.Errorf("invalid staged reference %s: %w", image.Staged, err)
+		}
+
+		LogInfo("🚚 Promoting image %d/%d", idx+1, len(record.Images))
+		LogInfo("  Staged: %s", image.Staged)
+		LogInfo("  Target: %s", targetRef)
+		if err := crane.Copy(staged.Context().Digest(image.Digest).String(), targetRef, craneOpts...); err != nil {
+			return promoted, fmt.Errorf("failed to promote %s to %s: %w", image.Staged, targetRef, err)
+		}
+		promoted = append(promoted, PromotedImage{Staged: image.Staged, Target: targetRef, Digest: image.Digest})
+	}
+	return promoted, nil
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+// newTestRegistry starts an in-memory registry and returns its host
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// stageTestImage pushes a random image to ref and returns its digest
+func stageTestImage(t *testing.T, ref string) string {
+	t.Helper()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, ref); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest.String()
+}
+
+func TestPromoteImagesCopiesStagedDigests(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+
+	staged := host + "/quarantine/dynamoai/api:3.22.2"
+	record := &StagingRecord{
+		ReleaseVersion:  "3.22.2",
+		StagingRegistry: host + "/quarantine",
+		Images: []StagedImage{{
+			Source: "artifacts.dynamo.ai/dynamoai/api:3.22.2",
+			Staged: staged,
+			Digest: stageTestImage(t, staged),
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultStagingRecord)
+	if err := WriteStagingRecord(path, record); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadStagingRecord(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repos := PromotionRepositories(loaded, host+"/prod/"); len(repos) != 1 || repos[0] != host+"/prod/dynamoai/api" {
+		t.Fatalf("unexpected promotion repositories %v", repos)
+	}
+
+	promoted, err := PromoteImages(context.Background(), loaded, host+"/prod", PromoteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promoted) != 1 || promoted[0].Target != host+"/prod/dynamoai/api:3.22.2" {
+		t.Fatalf("unexpected promotion %+v", promoted)
+	}
+	digest, err := crane.Digest(promoted[0].Target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != record.Images[0].Digest {
+		t.Fatalf("promoted digest %s, want %s", digest, record.Images[0].Digest)
+	}
+}
+
+func TestPromoteImagesAppliesTargetOverrides(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+
+	source := "artifacts.dynamo.ai/dynamoai/python-base:3.11"
+	overrides := MergeTargetOverrides(nil, map[string]TargetOverride{
+		source: {Repository: "shared/python-base", Tag: "3.11-dynamo"},
+	})
+	staged := host + "/shared/python-base:3.11-dynamo"
+	record := &StagingRecord{
+		StagingRegistry: host + "/quarantine",
+		Images:          []StagedImage{NewStagedImage(source, staged, stageTestImage(t, staged), overrides)},
+	}
+	if record.Images[0].Override == nil {
+		t.Fatal("expected the staged image to keep its target override")
+	}
+
+	prod := newTestRegistry(t)
+	if repos := PromotionRepositories(record, prod+"/prod"); len(repos) != 1 || repos[0] != prod+"/shared/python-base" {
+		t.Fatalf("unexpected promotion repositories %v", repos)
+	}
+	promoted, err := PromoteImages(context.Background(), record, prod+"/prod", PromoteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promoted) != 1 || promoted[0].Target != prod+"/shared/python-base:3.11-dynamo" {
+		t.Fatalf("unexpected promotion %+v", promoted)
+	}
+	if _, err := crane.Digest(promoted[0].Target); err != nil {
+		t.Fatalf("expected the override target to be written: %v", err)
+	}
+}
+
+func TestCheckStagingOverrides(t *testing.T) {
+	if err := CheckStagingOverrides(map[string]TargetOverride{"a/b": {Repository: "shared/b"}}); err != nil {
+		t.Errorf("Expected a path override to be allowed, got %v", err)
+	}
+	err := CheckStagingOverrides(map[string]TargetOverride{"a/b": {Repository: "registry.example.com/shared/b"}})
+	if err == nil || !strings.Contains(err.Error(), "registry.example.com/shared/b") {
+		t.Errorf("Expected a host-qualified override to be rejected, got %v", err)
+	}
+}
+
+func TestPromoteImagesRefusesMovedTags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+
+	staged := host + "/quarantine/dynamoai/api:3.22.2"
+	record := &StagingRecord{
+		StagingRegistry: host + "/quarantine",
+		Images: []StagedImage{{
+			Source: "artifacts.dynamo.ai/dynamoai/api:3.22.2",
+			Staged: staged,
+			Digest: stageTestImage(t, staged),
+		}},
+	}
+	// Someone pushes over the staged tag after the scan
+	stageTestImage(t, staged)
+
+	_, err := PromoteImages(context.Background(), record, host+"/prod", PromoteOptions{})
+	if err == nil || !strings.Contains(err.Error(), "changed since they were mirrored") {
+		t.Fatalf("expected a moved tag to block promotion, got %v", err)
+	}
+	if _, err := crane.Digest(host + "/prod/dynamoai/api:3.22.2"); err == nil {
+		t.Fatal("expected nothing to be promoted")
+	}
+}
+
+func TestLoadStagingRecordRejectsIncompleteEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultStagingRecord)
+	if err := WriteStagingRecord(path, &StagingRecord{Images: []StagedImage{{Source: "a/b:1", Staged: "c/b:1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStagingRecord(path); err == nil {
+		t.Fatal("expected an entry without a digest to be rejected")
+	}
+}
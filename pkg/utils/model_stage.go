@@ -0,0 +1,332 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// stagingMountPath is where the staging pod mounts the PVC
+const stagingMountPath = "/data"
+
+// ModelStageOptions configures copying a pulled model into a PVC
+type ModelStageOptions struct {
+	Namespace string
+	PVC       string
+	// Source is the pulled model: an artifact directory or a single file
+	Source string
+	// TargetDir is the directory inside the PVC the model is copied into
+	TargetDir string
+	// Image runs the staging pod; it needs sh and tar
+	Image string
+	// Overwrite replaces an existing, non-empty target directory
+	Overwrite bool
+	// Timeout bounds how long to wait for the staging pod to start
+	Timeout time.Duration
+	// Progress, when set, is called as bytes are sent
+	Progress func(sent, total int64)
+}
+
+// ModelStageResult summarizes a finished copy
+type ModelStageResult struct {
+	Pod      string
+	Target   string
+	Files    int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// stageFile is one file to copy, with its path inside the target directory
+type stageFile struct {
+	path string
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+// ResolveModelArtifact finds a model pulled by `artifacts pull --models` in dir. model may also
+// be a path to the artifact itself. Pulls are saved as <name>.tar or <name>-<tag>.tar.
+func ResolveModelArtifact(dir, model string) (string, error) {
+	if strings.ContainsRune(model, filepath.Separator) || strings.Contains(model, "/") {
+		if _, err := os.Stat(model); err != nil {
+			return "", fmt.Errorf("model artifact %s not found: %w", model, err)
+		}
+		return model, nil
+	}
+
+	entries, err := os.ReadDir(LongPath(dir))
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifacts directory: %w", err)
+	}
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if name == model || name == model+".tar" || (strings.HasPrefix(name, model+"-") && strings.HasSuffix(name, ".tar")) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("model %s not found in %s; run dynactl artifacts pull --models first", model, dir)
+	case 1:
+		return filepath.Join(dir, matches[0]), nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("several pulls of model %s in %s (%s); pass the one to stage as --model <path>", model, dir, strings.Join(matches, ", "))
+}
+
+// collectStageFiles lists the files to copy. A directory's contents are copied into the target
+// directory; a single file is copied under its own name.
+func collectStageFiles(source string) ([]stageFile, int64, error) {
+	info, err := os.Stat(LongPath(source))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read model artifact: %w", err)
+	}
+	if !info.IsDir() {
+		return []stageFile{{path: source, name: filepath.Base(source), size: info.Size(), mode: info.Mode()}}, info.Size(), nil
+	}
+
+	var files []stageFile
+	var total int64
+	err = filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, stageFile{path: p, name: filepath.ToSlash(rel), size: info.Size(), mode: info.Mode()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read model artifact: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, 0, fmt.Errorf("model artifact %s contains no files", source)
+	}
+	return files, total, nil
+}
+
+// writeStageTar writes the files as a tar stream, counting content bytes into sent
+func writeStageTar(w io.Writer, files []stageFile, sent *atomic.Int64) error {
+	tw := tar.NewWriter(w)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Size: f.size, Mode: int64(f.mode.Perm()), ModTime: time.Now(), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		in, err := os.Open(LongPath(f.path))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, &countingReader{r: in, n: sent})
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("failed to send %s: %w", f.name, err)
+		}
+	}
+	return tw.Close()
+}
+
+// countingReader adds the bytes read to n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// stageScript unpacks the tar stream on stdin into the target directory, refusing to write into
+// a non-empty directory unless overwrite is set, in which case its contents are removed first
+func stageScript(target string, overwrite bool) string {
+	dir := shellQuote(path.Join(stagingMountPath, target))
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	if overwrite {
+		b.WriteString(fmt.Sprintf("rm -rf %s\n", dir))
+	} else {
+		msg := shellQuote(fmt.Sprintf("%s in the PVC is not empty; pass --overwrite to replace it", target))
+		b.WriteString(fmt.Sprintf("if [ -n \"$(ls -A %s 2>/dev/null)\" ]; then echo %s >&2; exit 3; fi\n", dir, msg))
+	}
+	b.WriteString(fmt.Sprintf("mkdir -p %s\n", dir))
+	b.WriteString(fmt.Sprintf("tar -xf - -C %s\n", dir))
+	return b.String()
+}
+
+// cleanStageTarget validates the directory inside the PVC, which must stay within the volume
+func cleanStageTarget(target string) (string, error) {
+	cleaned := path.Clean("/" + filepath.ToSlash(target))
+	if cleaned == "/" {
+		return "", fmt.Errorf("target path must name a directory inside the PVC, not its root")
+	}
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// StageModel copies a pulled model into a PVC through a temporary pod that mounts the claim.
+// The files are streamed as a tar archive over pod exec, so no kubectl is needed and large files
+// are not buffered. The staging pod is always deleted.
+func (kc *KubernetesChecker) StageModel(ctx context.Context, opts ModelStageOptions) (*ModelStageResult, error) {
+	target, err := cleanStageTarget(opts.TargetDir)
+	if err != nil {
+		return nil, err
+	}
+	files, total, err := collectStageFiles(opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := kc.clientset.CoreV1().PersistentVolumeClaims(opts.Namespace).Get(ctx, opts.PVC, metav1.GetOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to get PVC %s in %s: %v", opts.PVC, opts.Namespace, err)
+	}
+
+	pods := kc.clientset.CoreV1().Pods(opts.Namespace)
+	created, err := pods.Create(ctx, stagingPod(opts), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging pod in %s: %v", opts.Namespace, err)
+	}
+	LogInfo("Started staging pod %s/%s with PVC %s mounted", opts.Namespace, created.Name, opts.PVC)
+	defer func() {
+		if err := pods.Delete(context.WithoutCancel(ctx), created.Name, metav1.DeleteOptions{}); err != nil {
+			LogWarning("Failed to delete staging pod %s: %v", created.Name, err)
+		}
+	}()
+
+	if err := kc.waitForStagingPod(ctx, opts, created.Name); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var sent atomic.Int64
+	done := make(chan struct{})
+	if opts.Progress != nil {
+		go func() {
+			ticker := time.NewTicker(2 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					opts.Progress(sent.Load(), total)
+				}
+			}
+		}()
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeStageTar(pw, files, &sent))
+	}()
+	var stderr bytes.Buffer
+	err = kc.execWithStdin(ctx, opts.Namespace, created.Name, "stage", []string{"sh", "-c", stageScript(target, opts.Overwrite)}, pr, &stderr)
+	close(done)
+	pr.Close()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to copy model into PVC %s: %s", opts.PVC, msg)
+		}
+		return nil, fmt.Errorf("failed to copy model into PVC %s: %v", opts.PVC, err)
+	}
+	if opts.Progress != nil {
+		opts.Progress(sent.Load(), total)
+	}
+
+	return &ModelStageResult{Pod: created.Name, Target: target, Files: len(files), Bytes: sent.Load(), Duration: time.Since(start)}, nil
+}
+
+// waitForStagingPod waits until the staging pod is running, failing early when its image cannot
+// be pulled
+func (kc *KubernetesChecker) waitForStagingPod(ctx context.Context, opts ModelStageOptions, name string) error {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	var last *corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		p, err := kc.clientset.CoreV1().Pods(opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		last = p
+		if reason := podWaitingReason(p); reason == "ErrImagePull" || reason == "ImagePullBackOff" {
+			return false, fmt.Errorf("staging pod cannot pull image %s (%s); pass --image with a mirrored copy", opts.Image, reason)
+		}
+		if p.Status.Phase == corev1.PodFailed || p.Status.Phase == corev1.PodSucceeded {
+			return false, fmt.Errorf("staging pod exited before the copy started (phase %s)", p.Status.Phase)
+		}
+		return p.Status.Phase == corev1.PodRunning && isPodReady(p), nil
+	})
+	if wait.Interrupted(err) && last != nil && last.Status.Phase == corev1.PodPending {
+		// A ReadWriteOnce claim already mounted on another node keeps the pod pending
+		return fmt.Errorf("staging pod did not start: %v (is PVC %s bound, and not attached to a pod on another node?)", err, opts.PVC)
+	}
+	if err != nil {
+		return fmt.Errorf("staging pod did not start: %v", err)
+	}
+	return nil
+}
+
+// stagingPod builds the pod that mounts the PVC and receives the model over exec
+func stagingPod(opts ModelStageOptions) *corev1.Pod {
+	image := opts.Image
+	if image == "" {
+		image = DefaultLoaderImage
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dynactl-model-stage-",
+			Namespace:    opts.Namespace,
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": "dynactl"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:            "stage",
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sleep", "86400"},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "model", MountPath: stagingMountPath}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "model",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: opts.PVC}},
+			}},
+		},
+	}
+}
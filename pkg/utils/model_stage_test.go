@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResolveModelArtifact(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"guard-8b-1.2.0.tar", "guard-70b-1.0.0.tar", "guard-70b-1.1.0.tar", "other.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ResolveModelArtifact(dir, "guard-8b")
+	if err != nil || got != filepath.Join(dir, "guard-8b-1.2.0.tar") {
+		t.Errorf("expected the single guard-8b pull, got %q, %v", got, err)
+	}
+	if _, err := ResolveModelArtifact(dir, "guard-70b"); err == nil || !strings.Contains(err.Error(), "guard-70b-1.0.0.tar, guard-70b-1.1.0.tar") {
+		t.Errorf("expected an ambiguity error listing both pulls, got %v", err)
+	}
+	if _, err := ResolveModelArtifact(dir, "missing"); err == nil || !strings.Contains(err.Error(), "artifacts pull --models") {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+	path := filepath.Join(dir, "guard-70b-1.1.0.tar")
+	if got, err := ResolveModelArtifact(dir, path); err != nil || got != path {
+		t.Errorf("expected a path to be used as is, got %q, %v", got, err)
+	}
+}
+
+func TestWriteStageTar(t *testing.T) {
+	source := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(source, "weights"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "config.json"), []byte(`{"layers":32}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "weights", "model.safetensors"), bytes.Repeat([]byte("w"), 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, total, err := collectStageFiles(source)
+	if err != nil {
+		t.Fatalf("collectStageFiles returned error: %v", err)
+	}
+	if len(files) != 2 || total != 4096+13 {
+		t.Fatalf("expected 2 files totalling %d bytes, got %d files, %d bytes", 4096+13, len(files), total)
+	}
+
+	var buf bytes.Buffer
+	var sent atomic.Int64
+	if err := writeStageTar(&buf, files, &sent); err != nil {
+		t.Fatalf("writeStageTar returned error: %v", err)
+	}
+	if sent.Load() != total {
+		t.Errorf("expected %d bytes counted, got %d", total, sent.Load())
+	}
+	names := map[string]int64{}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names[hdr.Name] = hdr.Size
+	}
+	if names["config.json"] != 13 || names["weights/model.safetensors"] != 4096 {
+		t.Errorf("unexpected archive entries: %v", names)
+	}
+}
+
+func TestCleanStageTarget(t *testing.T) {
+	if got, err := cleanStageTarget("../../models/guard-8b/"); err != nil || got != "models/guard-8b" {
+		t.Errorf("expected the target to stay inside the PVC, got %q, %v", got, err)
+	}
+	if _, err := cleanStageTarget("/"); err == nil {
+		t.Error("expected the PVC root to be rejected")
+	}
+}
+
+func TestStageScript(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	for _, overwrite := range []bool{false, true} {
+		script := stageScript("models/o'brien", overwrite)
+		if out, err := exec.Command(sh, "-n", "-c", script).CombinedOutput(); err != nil {
+			t.Fatalf("generated script is not valid shell: %v\n%s\n%s", err, out, script)
+		}
+		if strings.Contains(script, "rm -rf") != overwrite {
+			t.Errorf("overwrite=%v: unexpected script:\n%s", overwrite, script)
+		}
+	}
+}
+
+func TestStagingPod(t *testing.T) {
+	pod := stagingPod(ModelStageOptions{Namespace: "guard", PVC: "model-cache"})
+	if claim := pod.Spec.Volumes[0].PersistentVolumeClaim; claim == nil || claim.ClaimName != "model-cache" {
+		t.Errorf("expected the PVC to be mounted, got %+v", pod.Spec.Volumes)
+	}
+	if c := pod.Spec.Containers[0]; c.Image != DefaultLoaderImage || c.VolumeMounts[0].MountPath != stagingMountPath {
+		t.Errorf("unexpected staging container: %+v", c)
+	}
+}
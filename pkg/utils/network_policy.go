@@ -0,0 +1,446 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// namespaceNameLabel is set on every namespace by the API server
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+var peerAuthenticationGVR = schema.GroupVersionResource{Group: "security.istio.io", Version: "v1beta1", Resource: "peerauthentications"}
+
+// NetworkEndpoint is one side of a flow: pods with the given labels, in the checked namespace
+// unless another is named, or an address range outside the cluster. Pods without labels stand
+// for any pod of the namespace.
+type NetworkEndpoint struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CIDR      string            `json:"cidr,omitempty"`
+}
+
+// NetworkFlow is a connection a deployment needs. Port 0 stands for every port.
+type NetworkFlow struct {
+	Name     string          `json:"name"`
+	From     NetworkEndpoint `json:"from"`
+	To       NetworkEndpoint `json:"to"`
+	Port     int32           `json:"port,omitempty"`
+	Protocol string          `json:"protocol,omitempty"`
+}
+
+// NetworkFlowsFile is the --flows file format
+type NetworkFlowsFile struct {
+	Flows []NetworkFlow `json:"flows"`
+}
+
+// ClusterEgressFlows are needed by every deployment: cluster DNS, and HTTPS to services outside
+// the cluster such as model providers and object storage
+var ClusterEgressFlows = []NetworkFlow{
+	{Name: "dns-udp", To: NetworkEndpoint{Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}}, Port: 53, Protocol: "UDP"},
+	{Name: "dns-tcp", To: NetworkEndpoint{Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}}, Port: 53, Protocol: "TCP"},
+	{Name: "external-https", To: NetworkEndpoint{CIDR: "0.0.0.0/0"}, Port: 443, Protocol: "TCP"},
+}
+
+// NetworkFlowResult says whether the namespace's policies let a flow through
+type NetworkFlowResult struct {
+	Flow    NetworkFlow
+	Allowed bool
+	// BlockedBy lists, as namespace/name, the policies that isolate an end of the flow without
+	// allowing it
+	BlockedBy []string
+	// Side is "egress", "ingress", or both, naming where the flow is blocked
+	Side string
+}
+
+// NetworkPolicyReport is the outcome of the network policy check
+type NetworkPolicyReport struct {
+	Namespace string
+	// Policies lists the NetworkPolicies in the namespace
+	Policies []string
+	// Mesh describes service mesh sidecar injection and mTLS settings that affect the deployment
+	Mesh  []string
+	Flows []NetworkFlowResult
+}
+
+// Blocked returns the flows the policies block
+func (r *NetworkPolicyReport) Blocked() []NetworkFlowResult {
+	var blocked []NetworkFlowResult
+	for _, f := range r.Flows {
+		if !f.Allowed {
+			blocked = append(blocked, f)
+		}
+	}
+	return blocked
+}
+
+// LoadNetworkFlows reads a YAML or JSON file of flows
+func LoadNetworkFlows(path string) ([]NetworkFlow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network flows: %w", err)
+	}
+	var file NetworkFlowsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse network flows %s: %w", path, err)
+	}
+	if len(file.Flows) == 0 {
+		return nil, fmt.Errorf("no flows listed in %s", path)
+	}
+	for i, f := range file.Flows {
+		if f.Name == "" {
+			return nil, fmt.Errorf("flow %d in %s has no name", i+1, path)
+		}
+		if f.To.CIDR != "" {
+			if _, _, err := net.ParseCIDR(f.To.CIDR); err != nil {
+				return nil, fmt.Errorf("flow %s: invalid cidr: %v", f.Name, err)
+			}
+		}
+	}
+	return file.Flows, nil
+}
+
+// NamespaceServiceFlows derives the component-to-component flows of a namespace from its
+// Services: any pod of the namespace must reach each Service's pods on each target port. With
+// no Services yet, as before an install, pods must reach each other on every port.
+func (kc *KubernetesChecker) NamespaceServiceFlows(ctx context.Context, namespace string) ([]NetworkFlow, error) {
+	services, err := kc.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+	flows := serviceFlows(services.Items)
+	if len(flows) == 0 {
+		flows = []NetworkFlow{{Name: "intra-namespace"}}
+	}
+	return flows, nil
+}
+
+// serviceFlows returns one flow per Service port that targets a numbered container port
+func serviceFlows(services []corev1.Service) []NetworkFlow {
+	var flows []NetworkFlow
+	for _, svc := range services {
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		for _, p := range svc.Spec.Ports {
+			port := p.TargetPort.IntVal
+			if p.TargetPort.StrVal != "" {
+				// Named target ports resolve per pod; the service port is the best stand-in
+				port = 0
+			}
+			if port == 0 {
+				port = p.Port
+			}
+			protocol := string(p.Protocol)
+			if protocol == "" {
+				protocol = string(corev1.ProtocolTCP)
+			}
+			flows = append(flows, NetworkFlow{
+				Name:     fmt.Sprintf("to-%s:%d", svc.Name, p.Port),
+				To:       NetworkEndpoint{Labels: svc.Spec.Selector},
+				Port:     port,
+				Protocol: protocol,
+			})
+		}
+	}
+	return flows
+}
+
+// CheckNetworkPolicies evaluates each flow against the NetworkPolicies of the namespaces it
+// touches and reports service mesh settings that change how pods connect
+func (kc *KubernetesChecker) CheckNetworkPolicies(ctx context.Context, namespace string, flows []NetworkFlow) (*NetworkPolicyReport, error) {
+	report := &NetworkPolicyReport{Namespace: namespace}
+
+	namespaces := map[string]bool{namespace: true}
+	for _, f := range flows {
+		for _, ep := range []NetworkEndpoint{f.From, f.To} {
+			if ep.Namespace != "" && ep.CIDR == "" {
+				namespaces[ep.Namespace] = true
+			}
+		}
+	}
+
+	policies := map[string][]networkingv1.NetworkPolicy{}
+	nsLabels := map[string]map[string]string{}
+	for ns := range namespaces {
+		list, err := kc.clientset.NetworkingV1().NetworkPolicies(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if ns == namespace {
+				return nil, fmt.Errorf("failed to list network policies in %s: %v", ns, err)
+			}
+			LogWarning("Failed to list network policies in %s, assuming none: %v", ns, err)
+		} else {
+			policies[ns] = list.Items
+		}
+		nsLabels[ns] = map[string]string{namespaceNameLabel: ns}
+		obj, err := kc.clientset.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			LogDebug("Failed to read namespace %s labels: %v", ns, err)
+		}
+		if err == nil {
+			for k, v := range obj.Labels {
+				nsLabels[ns][k] = v
+			}
+			if ns == namespace {
+				report.Mesh = append(report.Mesh, namespaceMeshInjection(obj)...)
+			}
+		}
+	}
+	for _, p := range policies[namespace] {
+		report.Policies = append(report.Policies, namespace+"/"+p.Name)
+	}
+
+	for _, f := range flows {
+		report.Flows = append(report.Flows, evaluateNetworkFlow(f, namespace, policies, nsLabels))
+	}
+	report.Mesh = append(report.Mesh, kc.meshSidecars(ctx, namespace)...)
+	report.Mesh = append(report.Mesh, kc.strictMTLS(ctx, namespace)...)
+	return report, nil
+}
+
+// evaluateNetworkFlow applies NetworkPolicy semantics: a flow passes when the source pod is not
+// isolated for egress or one of its egress policies allows the destination, and likewise the
+// destination pod for ingress
+func evaluateNetworkFlow(flow NetworkFlow, namespace string, policies map[string][]networkingv1.NetworkPolicy, nsLabels map[string]map[string]string) NetworkFlowResult {
+	from, to := flow.From, flow.To
+	if from.Namespace == "" && from.CIDR == "" {
+		from.Namespace = namespace
+	}
+	if to.Namespace == "" && to.CIDR == "" {
+		to.Namespace = namespace
+	}
+	protocol := flow.Protocol
+	if protocol == "" {
+		protocol = string(corev1.ProtocolTCP)
+	}
+
+	result := NetworkFlowResult{Flow: flow, Allowed: true}
+	var sides []string
+	if from.CIDR == "" {
+		if blocked := blockingPolicies(policies[from.Namespace], from, to, networkingv1.PolicyTypeEgress, flow.Port, protocol, nsLabels); len(blocked) > 0 {
+			result.Allowed = false
+			result.BlockedBy = append(result.BlockedBy, blocked...)
+			sides = append(sides, "egress")
+		}
+	}
+	if to.CIDR == "" {
+		if blocked := blockingPolicies(policies[to.Namespace], to, from, networkingv1.PolicyTypeIngress, flow.Port, protocol, nsLabels); len(blocked) > 0 {
+			result.Allowed = false
+			for _, name := range blocked {
+				if !containsString(result.BlockedBy, name) {
+					result.BlockedBy = append(result.BlockedBy, name)
+				}
+			}
+			sides = append(sides, "ingress")
+		}
+	}
+	result.Side = strings.Join(sides, "+")
+	return result
+}
+
+// blockingPolicies returns the policies that isolate the local endpoint in one direction when
+// none of them allows traffic with the remote endpoint on the port
+func blockingPolicies(nsPolicies []networkingv1.NetworkPolicy, local, remote NetworkEndpoint, direction networkingv1.PolicyType, port int32, protocol string, nsLabels map[string]map[string]string) []string {
+	var isolating []string
+	for _, p := range nsPolicies {
+		if !policyHasType(p, direction) || !selectorMatches(&p.Spec.PodSelector, local.Labels) {
+			continue
+		}
+		isolating = append(isolating, p.Namespace+"/"+p.Name)
+		if direction == networkingv1.PolicyTypeIngress {
+			for _, rule := range p.Spec.Ingress {
+				if portsMatch(rule.Ports, port, protocol) && peersMatch(rule.From, p.Namespace, remote, nsLabels) {
+					return nil
+				}
+			}
+		} else {
+			for _, rule := range p.Spec.Egress {
+				if portsMatch(rule.Ports, port, protocol) && peersMatch(rule.To, p.Namespace, remote, nsLabels) {
+					return nil
+				}
+			}
+		}
+	}
+	sort.Strings(isolating)
+	return isolating
+}
+
+// policyHasType reports whether a policy isolates pods in a direction; without policyTypes a
+// policy always covers ingress and covers egress when it has egress rules
+func policyHasType(p networkingv1.NetworkPolicy, direction networkingv1.PolicyType) bool {
+	if len(p.Spec.PolicyTypes) == 0 {
+		return direction == networkingv1.PolicyTypeIngress || len(p.Spec.Egress) > 0
+	}
+	for _, t := range p.Spec.PolicyTypes {
+		if t == direction {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorMatches reports whether a label selector selects a set of labels
+func selectorMatches(selector *metav1.LabelSelector, set map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+// peersMatch reports whether any peer of a rule covers the endpoint; a rule without peers
+// matches everything
+func peersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, ep NetworkEndpoint, nsLabels map[string]map[string]string) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if ep.CIDR != "" {
+			if peer.IPBlock != nil && ipBlockCovers(peer.IPBlock, ep.CIDR) {
+				return true
+			}
+			continue
+		}
+		if peer.IPBlock != nil {
+			continue
+		}
+		if peer.NamespaceSelector == nil {
+			if ep.Namespace == policyNamespace && (peer.PodSelector == nil || selectorMatches(peer.PodSelector, ep.Labels)) {
+				return true
+			}
+			continue
+		}
+		if selectorMatches(peer.NamespaceSelector, nsLabels[ep.Namespace]) && (peer.PodSelector == nil || selectorMatches(peer.PodSelector, ep.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipBlockCovers reports whether an ipBlock contains the whole CIDR, with its first address
+// outside every exception
+func ipBlockCovers(block *networkingv1.IPBlock, cidr string) bool {
+	_, blockNet, err := net.ParseCIDR(block.CIDR)
+	if err != nil {
+		return false
+	}
+	_, target, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	blockOnes, _ := blockNet.Mask.Size()
+	targetOnes, _ := target.Mask.Size()
+	if !blockNet.Contains(target.IP) || targetOnes < blockOnes {
+		return false
+	}
+	for _, except := range block.Except {
+		if _, exceptNet, err := net.ParseCIDR(except); err == nil && exceptNet.Contains(target.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// portsMatch reports whether a rule's ports cover the flow; port 0 needs a rule open on every
+// port. Named ports cannot be resolved without the pod and are assumed to match.
+func portsMatch(ports []networkingv1.NetworkPolicyPort, port int32, protocol string) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		ruleProtocol := string(corev1.ProtocolTCP)
+		if p.Protocol != nil {
+			ruleProtocol = string(*p.Protocol)
+		}
+		if ruleProtocol != protocol {
+			continue
+		}
+		if p.Port == nil {
+			return true
+		}
+		if port == 0 {
+			continue
+		}
+		if p.Port.StrVal != "" {
+			return true
+		}
+		end := p.Port.IntVal
+		if p.EndPort != nil {
+			end = *p.EndPort
+		}
+		if port >= p.Port.IntVal && port <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceMeshInjection reports sidecar injection enabled on the namespace
+func namespaceMeshInjection(ns *corev1.Namespace) []string {
+	var findings []string
+	if ns.Labels["istio-injection"] == "enabled" {
+		findings = append(findings, "Istio sidecar injection is enabled (istio-injection=enabled)")
+	} else if rev := ns.Labels["istio.io/rev"]; rev != "" {
+		findings = append(findings, fmt.Sprintf("Istio sidecar injection is enabled (istio.io/rev=%s)", rev))
+	}
+	if ns.Annotations["linkerd.io/inject"] == "enabled" {
+		findings = append(findings, "Linkerd proxy injection is enabled (linkerd.io/inject=enabled)")
+	}
+	return findings
+}
+
+// meshSidecars reports running pods that already carry a mesh sidecar
+func (kc *KubernetesChecker) meshSidecars(ctx context.Context, namespace string) []string {
+	pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		LogDebug("Failed to list pods in %s: %v", namespace, err)
+		return nil
+	}
+	counts := map[string]int{}
+	for _, pod := range pods.Items {
+		for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if c.Name == "istio-proxy" || c.Name == "linkerd-proxy" {
+				counts[c.Name]++
+			}
+		}
+	}
+	var findings []string
+	for _, name := range []string{"istio-proxy", "linkerd-proxy"} {
+		if counts[name] > 0 {
+			findings = append(findings, fmt.Sprintf("%d of %d pods run a %s sidecar", counts[name], len(pods.Items), name))
+		}
+	}
+	return findings
+}
+
+// strictMTLS reports Istio PeerAuthentication in STRICT mode, which rejects plaintext clients
+// such as pods without sidecars and probes from other namespaces
+func (kc *KubernetesChecker) strictMTLS(ctx context.Context, namespace string) []string {
+	var findings []string
+	for _, ns := range []string{namespace, "istio-system"} {
+		list, err := kc.dynamicClient.Resource(peerAuthenticationGVR).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			// Istio is not installed or the CRD is not readable
+			return findings
+		}
+		for _, pa := range list.Items {
+			mode, _, _ := unstructured.NestedString(pa.Object, "spec", "mtls", "mode")
+			if mode == "STRICT" {
+				findings = append(findings, fmt.Sprintf("PeerAuthentication %s/%s enforces STRICT mTLS; pods without a sidecar cannot connect to meshed pods", ns, pa.GetName()))
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testPolicy(namespace, name string, selector map[string]string, types ...networkingv1.PolicyType) networkingv1.NetworkPolicy {
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: selector},
+			PolicyTypes: types,
+		},
+	}
+}
+
+func tcpPort(port int32) networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	p := intstr.FromInt32(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p}
+}
+
+func testNamespaceLabels(names ...string) map[string]map[string]string {
+	labels := map[string]map[string]string{}
+	for _, ns := range names {
+		labels[ns] = map[string]string{namespaceNameLabel: ns}
+	}
+	return labels
+}
+
+func TestEvaluateNetworkFlow(t *testing.T) {
+	api := map[string]string{"app.kubernetes.io/name": "dynamoai-api"}
+	ui := map[string]string{"app.kubernetes.io/name": "dynamoai-ui"}
+	uiToAPI := NetworkFlow{Name: "ui-to-api", From: NetworkEndpoint{Labels: ui}, To: NetworkEndpoint{Labels: api}, Port: 8080}
+
+	defaultDeny := testPolicy("dynamo", "default-deny", nil, networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress)
+
+	allowUI := testPolicy("dynamo", "allow-ui", api, networkingv1.PolicyTypeIngress)
+	allowUI.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{
+		From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: ui}}},
+		Ports: []networkingv1.NetworkPolicyPort{tcpPort(8080)},
+	}}
+	allowEgress := testPolicy("dynamo", "allow-egress", nil, networkingv1.PolicyTypeEgress)
+	allowEgress.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{{}}
+
+	wrongPort := testPolicy("dynamo", "allow-ui-metrics", api, networkingv1.PolicyTypeIngress)
+	wrongPort.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{Ports: []networkingv1.NetworkPolicyPort{tcpPort(9090)}}}
+
+	apiOnly := testPolicy("other", "api-only", api, networkingv1.PolicyTypeIngress)
+
+	tests := []struct {
+		name      string
+		policies  []networkingv1.NetworkPolicy
+		allowed   bool
+		blockedBy []string
+		side      string
+	}{
+		{name: "no policies", allowed: true},
+		{name: "default deny", policies: []networkingv1.NetworkPolicy{defaultDeny}, blockedBy: []string{"dynamo/default-deny"}, side: "egress+ingress"},
+		{name: "ingress allowed, egress denied", policies: []networkingv1.NetworkPolicy{defaultDeny, allowUI}, blockedBy: []string{"dynamo/default-deny"}, side: "egress"},
+		{name: "both allowed", policies: []networkingv1.NetworkPolicy{defaultDeny, allowUI, allowEgress}, allowed: true},
+		{name: "wrong port", policies: []networkingv1.NetworkPolicy{wrongPort}, blockedBy: []string{"dynamo/allow-ui-metrics"}, side: "ingress"},
+		{name: "policy in another namespace", policies: []networkingv1.NetworkPolicy{apiOnly}, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies := map[string][]networkingv1.NetworkPolicy{}
+			for _, p := range tt.policies {
+				policies[p.Namespace] = append(policies[p.Namespace], p)
+			}
+			got := evaluateNetworkFlow(uiToAPI, "dynamo", policies, testNamespaceLabels("dynamo", "other"))
+			if got.Allowed != tt.allowed || !reflect.DeepEqual(got.BlockedBy, tt.blockedBy) || got.Side != tt.side {
+				t.Errorf("evaluateNetworkFlow() = allowed %v, blocked by %v (%s), want %v, %v (%s)", got.Allowed, got.BlockedBy, got.Side, tt.allowed, tt.blockedBy, tt.side)
+			}
+		})
+	}
+}
+
+func TestEvaluateNetworkFlowNamespaceSelector(t *testing.T) {
+	dns := ClusterEgressFlows[0]
+	allowDNS := testPolicy("dynamo", "allow-dns", nil, networkingv1.PolicyTypeEgress)
+	udp := corev1.ProtocolUDP
+	port := intstr.FromInt32(53)
+	allowDNS.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{{
+		To: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: "kube-system"}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+		}},
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &port}},
+	}}
+	policies := map[string][]networkingv1.NetworkPolicy{"dynamo": {allowDNS}}
+	nsLabels := testNamespaceLabels("dynamo", "kube-system")
+
+	if got := evaluateNetworkFlow(dns, "dynamo", policies, nsLabels); !got.Allowed {
+		t.Errorf("DNS over UDP blocked by %v", got.BlockedBy)
+	}
+	if got := evaluateNetworkFlow(ClusterEgressFlows[1], "dynamo", policies, nsLabels); got.Allowed {
+		t.Error("DNS over TCP allowed by a UDP-only rule")
+	}
+	// A pod selector without a namespace selector only matches pods in the policy's namespace
+	allowDNS.Spec.Egress[0].To[0].NamespaceSelector = nil
+	if got := evaluateNetworkFlow(dns, "dynamo", map[string][]networkingv1.NetworkPolicy{"dynamo": {allowDNS}}, nsLabels); got.Allowed {
+		t.Error("pod selector matched a pod in another namespace")
+	}
+}
+
+func TestIPBlockCovers(t *testing.T) {
+	tests := []struct {
+		block  networkingv1.IPBlock
+		cidr   string
+		covers bool
+	}{
+		{networkingv1.IPBlock{CIDR: "0.0.0.0/0"}, "0.0.0.0/0", true},
+		{networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: []string{"10.0.0.0/8"}}, "0.0.0.0/0", true},
+		{networkingv1.IPBlock{CIDR: "52.0.0.0/8"}, "0.0.0.0/0", false},
+		{networkingv1.IPBlock{CIDR: "10.0.0.0/8"}, "10.20.0.0/24", true},
+		{networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.20.0.0/16"}}, "10.20.0.0/24", false},
+		{networkingv1.IPBlock{CIDR: "10.20.0.0/24"}, "10.0.0.0/8", false},
+	}
+	for _, tt := range tests {
+		if got := ipBlockCovers(&tt.block, tt.cidr); got != tt.covers {
+			t.Errorf("ipBlockCovers(%v, %s) = %v, want %v", tt.block, tt.cidr, got, tt.covers)
+		}
+	}
+}
+
+func TestPortsMatch(t *testing.T) {
+	endPort := int32(9000)
+	ranged := tcpPort(8000)
+	ranged.EndPort = &endPort
+	named := intstr.FromString("http")
+	tcp := corev1.ProtocolTCP
+	allTCP := networkingv1.NetworkPolicyPort{Protocol: &tcp}
+
+	tests := []struct {
+		name     string
+		ports    []networkingv1.NetworkPolicyPort
+		port     int32
+		protocol string
+		want     bool
+	}{
+		{"no ports", nil, 8080, "TCP", true},
+		{"exact", []networkingv1.NetworkPolicyPort{tcpPort(8080)}, 8080, "TCP", true},
+		{"other port", []networkingv1.NetworkPolicyPort{tcpPort(8080)}, 8081, "TCP", false},
+		{"other protocol", []networkingv1.NetworkPolicyPort{tcpPort(53)}, 53, "UDP", false},
+		{"range", []networkingv1.NetworkPolicyPort{ranged}, 8500, "TCP", true},
+		{"named", []networkingv1.NetworkPolicyPort{{Port: &named}}, 8080, "TCP", true},
+		{"any port needs an open rule", []networkingv1.NetworkPolicyPort{tcpPort(8080)}, 0, "TCP", false},
+		{"any port with all ports of protocol", []networkingv1.NetworkPolicyPort{allTCP}, 0, "TCP", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := portsMatch(tt.ports, tt.port, tt.protocol); got != tt.want {
+				t.Errorf("portsMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceFlows(t *testing.T) {
+	services := []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "dynamoai-api"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app.kubernetes.io/name": "dynamoai-api"},
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt32(8080)},
+					{Port: 9090, TargetPort: intstr.FromString("metrics")},
+				},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "external-db"}, Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 5432}}}},
+	}
+	flows := serviceFlows(services)
+	if len(flows) != 2 {
+		t.Fatalf("serviceFlows() returned %d flows, want 2 (selectorless services skipped)", len(flows))
+	}
+	if flows[0].Name != "to-dynamoai-api:80" || flows[0].Port != 8080 || flows[0].Protocol != "TCP" {
+		t.Errorf("flows[0] = %+v", flows[0])
+	}
+	if flows[1].Port != 9090 {
+		t.Errorf("named target port should fall back to the service port, got %d", flows[1].Port)
+	}
+}
+
+func TestLoadNetworkFlows(t *testing.T) {
+	flows, err := LoadNetworkFlows(filepath.Join("..", "..", "examples", "network-flows.yaml"))
+	if err != nil {
+		t.Fatalf("LoadNetworkFlows() error = %v", err)
+	}
+	if len(flows) == 0 || flows[0].From.Labels["app.kubernetes.io/name"] != "dynamoai-ui" {
+		t.Errorf("unexpected flows: %+v", flows)
+	}
+
+	bad := filepath.Join(t.TempDir(), "flows.yaml")
+	if err := os.WriteFile(bad, []byte("flows:\n  - name: db\n    to:\n      cidr: 10.0.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNetworkFlows(bad); err == nil {
+		t.Error("LoadNetworkFlows() accepted an invalid CIDR")
+	}
+}
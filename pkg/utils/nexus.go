@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// nexusAPIPath is the Sonatype Nexus Repository 3 REST API root
+const nexusAPIPath = "/service/rest/v1"
+
+// nexusRepository is the subset of a Nexus Docker repository configuration the preflight uses
+type nexusRepository struct {
+	Name    string `json:"name"`
+	Format  string `json:"format"`
+	Type    string `json:"type"`
+	Online  bool   `json:"online"`
+	Storage struct {
+		WritePolicy string `json:"writePolicy"`
+	} `json:"storage"`
+	Docker struct {
+		HTTPPort  *int   `json:"httpPort"`
+		HTTPSPort *int   `json:"httpsPort"`
+		Subdomain string `json:"subdomain"`
+	} `json:"docker"`
+	Group *struct {
+		WritableMember string `json:"writableMember"`
+	} `json:"group"`
+}
+
+// nexusTarget validates the Nexus Docker repository behind a target. Nexus serves each Docker
+// repository on its own connector port, subdomain, or /repository/<name> path, so the target is
+// matched against every Docker repository's connector settings.
+type nexusTarget struct {
+	api    registryAPI
+	target string
+}
+
+func newNexusTarget(api registryAPI, targetRegistry string) *nexusTarget {
+	return &nexusTarget{api: api.withPath(nexusAPIPath), target: targetRegistry}
+}
+
+func (t *nexusTarget) Kind() string { return "Nexus" }
+
+func (t *nexusTarget) detect(ctx context.Context) bool {
+	status, err := t.api.do(ctx, http.MethodGet, "/status", nil, nil)
+	if err != nil || status != http.StatusOK {
+		LogDebug("Registry is not Nexus (status %d): %v", status, err)
+		return false
+	}
+	LogDebug("Detected Nexus Repository")
+	return true
+}
+
+func (t *nexusTarget) Prepare(ctx context.Context, repositories []string) error {
+	var summaries []struct {
+		Name   string `json:"name"`
+		Format string `json:"format"`
+		Type   string `json:"type"`
+	}
+	if _, err := t.api.do(ctx, http.MethodGet, "/repositories", nil, &summaries); err != nil {
+		return fmt.Errorf("failed to list Nexus repositories: %w", err)
+	}
+
+	for _, s := range summaries {
+		if s.Format != "docker" {
+			continue
+		}
+		var repo nexusRepository
+		path := fmt.Sprintf("/repositories/docker/%s/%s", url.PathEscape(s.Type), url.PathEscape(s.Name))
+		if _, err := t.api.do(ctx, http.MethodGet, path, nil, &repo); err != nil {
+			LogDebug("Could not read Nexus repository %s: %v", s.Name, err)
+			continue
+		}
+		repo.Type = s.Type
+		if !t.serves(&repo) {
+			continue
+		}
+		if problem := checkNexusRepository(&repo); problem != "" {
+			return fmt.Errorf("nexus target is not ready: %s", problem)
+		}
+		LogInfo("Pushing into Nexus %s repository %s", repo.Type, repo.Name)
+		return nil
+	}
+	return fmt.Errorf("no Nexus Docker repository serves %s; check the repository's connector port or subdomain", t.target)
+}
+
+// CheckCapacity accepts any size; Nexus quotas belong to blob stores, which only admins can read
+func (t *nexusTarget) CheckCapacity(ctx context.Context, sizes map[string]int64) error {
+	return nil
+}
+
+func (t *nexusTarget) TargetRepository(repository string) string { return repository }
+
+// serves reports whether a repository's connector matches the target's port, subdomain, or
+// /repository/<name> path
+func (t *nexusTarget) serves(repo *nexusRepository) bool {
+	host := RegistryHost(t.target)
+	if _, portText, err := net.SplitHostPort(host); err == nil {
+		port, _ := strconv.Atoi(portText)
+		return (repo.Docker.HTTPPort != nil && *repo.Docker.HTTPPort == port) ||
+			(repo.Docker.HTTPSPort != nil && *repo.Docker.HTTPSPort == port)
+	}
+	if label, _, _ := strings.Cut(host, "."); repo.Docker.Subdomain != "" && repo.Docker.Subdomain == label {
+		return true
+	}
+	return strings.HasPrefix(repositoryPath(t.target)+"/", "repository/"+repo.Name+"/")
+}
+
+// checkNexusRepository explains why a repository cannot receive Docker pushes, and warns about
+// write policies that reject re-pushed tags
+func checkNexusRepository(repo *nexusRepository) string {
+	switch {
+	case !repo.Online:
+		return fmt.Sprintf("repository %s is offline", repo.Name)
+	case repo.Type == "proxy":
+		return fmt.Sprintf("repository %s is a proxy repository and cannot be pushed to", repo.Name)
+	case repo.Type == "group" && (repo.Group == nil || repo.Group.WritableMember == ""):
+		return fmt.Sprintf("group repository %s has no writable member", repo.Name)
+	case strings.EqualFold(repo.Storage.WritePolicy, "deny"):
+		return fmt.Sprintf("repository %s is read-only (write policy DENY)", repo.Name)
+	}
+	if strings.EqualFold(repo.Storage.WritePolicy, "allow_once") {
+		LogWarning("Nexus repository %s only allows each tag to be written once; re-pushing existing tags will fail", repo.Name)
+	}
+	return ""
+}
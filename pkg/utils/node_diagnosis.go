@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeEventLimit caps how many recent events are reported per node
+const nodeEventLimit = 10
+
+// nodePressureConditions are reported as failing when their status is True
+var nodePressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+	corev1.NodeNetworkUnavailable,
+}
+
+// NodeConditionReport is a failing node condition and when it last changed
+type NodeConditionReport struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime time.Time
+}
+
+// NodeEventReport is a recent event recorded against a node
+type NodeEventReport struct {
+	Type     string
+	Reason   string
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+// NodeDiagnosis explains why a node is not ready
+type NodeDiagnosis struct {
+	Name          string
+	Unschedulable bool
+	Conditions    []NodeConditionReport
+	Events        []NodeEventReport
+}
+
+// ExplainNotReadyNodes diagnoses every node matching the label selector (all nodes when empty)
+// whose Ready condition is not true, reporting failing conditions and recent node events
+func (kc *KubernetesChecker) ExplainNotReadyNodes(ctx context.Context, selector string) ([]NodeDiagnosis, error) {
+	nodes, err := kc.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	var diagnoses []NodeDiagnosis
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if isNodeReady(node) {
+			continue
+		}
+		events, err := kc.clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{
+			FieldSelector: "involvedObject.kind=Node,involvedObject.name=" + node.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events for node %s: %v", node.Name, err)
+		}
+		diagnoses = append(diagnoses, diagnoseNode(node, events.Items))
+	}
+	sort.Slice(diagnoses, func(i, j int) bool { return diagnoses[i].Name < diagnoses[j].Name })
+	return diagnoses, nil
+}
+
+// diagnoseNode collects the node's failing conditions and its most recent events
+func diagnoseNode(node *corev1.Node, events []corev1.Event) NodeDiagnosis {
+	d := NodeDiagnosis{Name: node.Name, Unschedulable: node.Spec.Unschedulable}
+
+	for _, c := range node.Status.Conditions {
+		failing := c.Status == corev1.ConditionTrue && slices.Contains(nodePressureConditions, c.Type)
+		if c.Type == corev1.NodeReady {
+			failing = c.Status != corev1.ConditionTrue
+		}
+		if !failing {
+			continue
+		}
+		d.Conditions = append(d.Conditions, NodeConditionReport{
+			Type:               string(c.Type),
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime.Time,
+		})
+	}
+	// A node that never reported a Ready condition is still NotReady
+	if len(d.Conditions) == 0 {
+		d.Conditions = append(d.Conditions, NodeConditionReport{
+			Type:    string(corev1.NodeReady),
+			Status:  string(corev1.ConditionUnknown),
+			Message: "node has not reported a Ready condition",
+		})
+	}
+
+	for _, e := range events {
+		d.Events = append(d.Events, NodeEventReport{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Message:  e.Message,
+			Count:    e.Count,
+			LastSeen: eventTime(e),
+		})
+	}
+	sort.Slice(d.Events, func(i, j int) bool { return d.Events[i].LastSeen.After(d.Events[j].LastSeen) })
+	if len(d.Events) > nodeEventLimit {
+		d.Events = d.Events[:nodeEventLimit]
+	}
+	return d
+}
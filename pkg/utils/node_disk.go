@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultMinImageFsFree is the image filesystem space a node should have free, enough to pull
+// one large model-runtime image
+const DefaultMinImageFsFree int64 = 20 << 30
+
+// NodeDiskUsage is the ephemeral storage and disk state of a node
+type NodeDiskUsage struct {
+	Name         string
+	Ready        bool
+	DiskPressure bool
+	// Ephemeral storage allocatable and requested by running and pending pods
+	EphemeralAllocatable     int64
+	EphemeralRequests        int64
+	EphemeralRequestsPercent float64
+	// StatsAvailable is false when the kubelet stats summary could not be read
+	StatsAvailable bool
+	// NodeFs holds logs and emptyDir volumes; ImageFs holds container images and is the node
+	// filesystem itself when the runtime does not use a separate one
+	NodeFsCapacity   int64
+	NodeFsAvailable  int64
+	ImageFsCapacity  int64
+	ImageFsAvailable int64
+	// Problems explains why the node was flagged
+	Problems []string
+}
+
+// ListNodeDiskUsage reports ephemeral storage requests and node and image filesystem space for
+// every node. Filesystem figures come from kubelet stats through the API server node proxy
+// (get on nodes/proxy); nodes whose stats cannot be read are still checked for DiskPressure.
+func (kc *KubernetesChecker) ListNodeDiskUsage(ctx context.Context, minImageFsFree int64) ([]NodeDiskUsage, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	podsByNode, err := kc.listPodsByNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]NodeDiskUsage, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		var summary *kubeletStatsSummary
+		if isNodeReady(node) {
+			if summary, err = kc.kubeletStatsSummary(ctx, node.Name); err != nil {
+				LogWarning("Failed to read disk stats from node %s: %v", node.Name, err)
+			}
+		}
+		usages = append(usages, nodeDiskUsage(node, podsByNode[node.Name], summary, minImageFsFree))
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if len(usages[i].Problems) != len(usages[j].Problems) {
+			return len(usages[i].Problems) > len(usages[j].Problems)
+		}
+		return usages[i].Name < usages[j].Name
+	})
+	return usages, nil
+}
+
+// nodeDiskUsage totals ephemeral storage requests on a node and flags DiskPressure and an image
+// filesystem with less than minImageFsFree bytes free
+func nodeDiskUsage(node *corev1.Node, pods []corev1.Pod, summary *kubeletStatsSummary, minImageFsFree int64) NodeDiskUsage {
+	usage := NodeDiskUsage{Name: node.Name, Ready: isNodeReady(node)}
+	if allocatable, ok := node.Status.Allocatable[corev1.ResourceEphemeralStorage]; ok {
+		usage.EphemeralAllocatable = allocatable.Value()
+	}
+	for i := range pods {
+		if pods[i].Status.Phase != corev1.PodRunning && pods[i].Status.Phase != corev1.PodPending {
+			continue
+		}
+		if req, ok := PodEffectiveRequests(&pods[i])[corev1.ResourceEphemeralStorage]; ok {
+			usage.EphemeralRequests += req.Value()
+		}
+	}
+	if usage.EphemeralAllocatable > 0 {
+		usage.EphemeralRequestsPercent = float64(usage.EphemeralRequests) / float64(usage.EphemeralAllocatable) * 100
+	}
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeDiskPressure && cond.Status == corev1.ConditionTrue {
+			usage.DiskPressure = true
+			usage.Problems = append(usage.Problems, "DiskPressure")
+		}
+	}
+
+	if summary != nil && summary.Node.Fs != nil && summary.Node.Fs.CapacityBytes != nil && summary.Node.Fs.AvailableBytes != nil {
+		usage.StatsAvailable = true
+		usage.NodeFsCapacity = *summary.Node.Fs.CapacityBytes
+		usage.NodeFsAvailable = *summary.Node.Fs.AvailableBytes
+		usage.ImageFsCapacity, usage.ImageFsAvailable = usage.NodeFsCapacity, usage.NodeFsAvailable
+		if rt := summary.Node.Runtime; rt != nil && rt.ImageFs != nil && rt.ImageFs.CapacityBytes != nil && rt.ImageFs.AvailableBytes != nil {
+			usage.ImageFsCapacity = *rt.ImageFs.CapacityBytes
+			usage.ImageFsAvailable = *rt.ImageFs.AvailableBytes
+		}
+		if usage.ImageFsAvailable < minImageFsFree {
+			usage.Problems = append(usage.Problems, fmt.Sprintf("imagefs %.1fGi free", float64(usage.ImageFsAvailable)/(1<<30)))
+		}
+	}
+	return usage
+}
+
+// CheckNodeDisk flags nodes under DiskPressure or with less than minImageFsFree bytes free for
+// images, which is where pulls of large model images fail first
+func (kc *KubernetesChecker) CheckNodeDisk(ctx context.Context, minImageFsFree int64) (string, error) {
+	usages, err := kc.ListNodeDiskUsage(ctx, minImageFsFree)
+	if err != nil {
+		return "", err
+	}
+	return SummarizeNodeDisk(usages, minImageFsFree)
+}
+
+// SummarizeNodeDisk returns a one-line verdict and an error when any node was flagged
+func SummarizeNodeDisk(usages []NodeDiskUsage, minImageFsFree int64) (string, error) {
+	var flagged []string
+	withStats := 0
+	for _, u := range usages {
+		if u.StatsAvailable {
+			withStats++
+		}
+		if len(u.Problems) > 0 {
+			flagged = append(flagged, fmt.Sprintf("%s (%s)", u.Name, strings.Join(u.Problems, ", ")))
+		}
+	}
+	if len(flagged) > 0 {
+		return fmt.Sprintf("%d of %d nodes low on disk: %s", len(flagged), len(usages), strings.Join(flagged, ", ")),
+			fmt.Errorf("nodes under disk pressure or low on image space")
+	}
+	if withStats < len(usages) {
+		return fmt.Sprintf("no node under DiskPressure; image space known for %d of %d nodes", withStats, len(usages)), nil
+	}
+	return fmt.Sprintf("all %d nodes have at least %.0fGi free for images", len(usages), float64(minImageFsFree)/(1<<30)), nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testDiskNode(name string, diskPressure bool) *corev1.Node {
+	pressure := corev1.ConditionFalse
+	if diskPressure {
+		pressure = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("100Gi")},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: pressure},
+			},
+		},
+	}
+}
+
+func testStatsSummary(nodeFsFree, imageFsFree int64) *kubeletStatsSummary {
+	capacity := int64(200 << 30)
+	summary := &kubeletStatsSummary{}
+	summary.Node.Fs = &kubeletFsStats{CapacityBytes: &capacity, AvailableBytes: &nodeFsFree}
+	if imageFsFree >= 0 {
+		summary.Node.Runtime = &struct {
+			ImageFs *kubeletFsStats `json:"imageFs"`
+		}{ImageFs: &kubeletFsStats{CapacityBytes: &capacity, AvailableBytes: &imageFsFree}}
+	}
+	return summary
+}
+
+func TestNodeDiskUsage(t *testing.T) {
+	pods := []corev1.Pod{
+		{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("30Gi")},
+			}}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("50Gi")},
+			}}}},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}
+
+	healthy := nodeDiskUsage(testDiskNode("gpu-1", false), pods, testStatsSummary(150<<30, 80<<30), DefaultMinImageFsFree)
+	if len(healthy.Problems) != 0 || healthy.EphemeralRequests != 30<<30 || healthy.EphemeralRequestsPercent != 30 {
+		t.Fatalf("unexpected healthy node %+v", healthy)
+	}
+	if healthy.ImageFsAvailable != 80<<30 || healthy.NodeFsAvailable != 150<<30 {
+		t.Fatalf("expected separate node and image filesystems, got %+v", healthy)
+	}
+
+	// Without a separate image filesystem images share the node filesystem
+	full := nodeDiskUsage(testDiskNode("gpu-2", true), nil, testStatsSummary(8<<30, -1), DefaultMinImageFsFree)
+	if !full.DiskPressure || strings.Join(full.Problems, ", ") != "DiskPressure, imagefs 8.0Gi free" {
+		t.Fatalf("unexpected problems %v", full.Problems)
+	}
+
+	noStats := nodeDiskUsage(testDiskNode("gpu-3", false), nil, nil, DefaultMinImageFsFree)
+	if noStats.StatsAvailable || len(noStats.Problems) != 0 {
+		t.Fatalf("expected a node without stats to be unflagged, got %+v", noStats)
+	}
+
+	summary, err := SummarizeNodeDisk([]NodeDiskUsage{full, healthy, noStats}, DefaultMinImageFsFree)
+	if err == nil || summary != "1 of 3 nodes low on disk: gpu-2 (DiskPressure, imagefs 8.0Gi free)" {
+		t.Fatalf("unexpected summary %q, %v", summary, err)
+	}
+	summary, err = SummarizeNodeDisk([]NodeDiskUsage{healthy, noStats}, DefaultMinImageFsFree)
+	if err != nil || summary != "no node under DiskPressure; image space known for 1 of 2 nodes" {
+		t.Fatalf("unexpected summary %q, %v", summary, err)
+	}
+}
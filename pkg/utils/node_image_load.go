@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// RuntimeContainerd imports with the standalone ctr binary
+	RuntimeContainerd = "containerd"
+	// RuntimeK3s imports with the ctr embedded in the k3s binary
+	RuntimeK3s = "k3s"
+	// RuntimeRKE2 imports with the ctr RKE2 ships, against its containerd socket
+	RuntimeRKE2 = "rke2"
+
+	// imageLoaderName names the DaemonSet that loads images through the Kubernetes API
+	imageLoaderName = "dynactl-image-loader"
+	// DefaultLoaderImage is a busybox image included in the k3s and RKE2 air-gap image bundles
+	DefaultLoaderImage = "rancher/mirrored-library-busybox:1.36.1"
+)
+
+// ContainerRuntimes lists the node runtimes images can be loaded into
+var ContainerRuntimes = []string{RuntimeContainerd, RuntimeK3s, RuntimeRKE2}
+
+// ImportCommand returns the node command that imports an image archive from stdin into the
+// containerd namespace the kubelet uses
+func ImportCommand(runtime string) ([]string, error) {
+	importArgs := []string{"--namespace", "k8s.io", "images", "import", "-"}
+	switch runtime {
+	case RuntimeContainerd:
+		return append([]string{"ctr"}, importArgs...), nil
+	case RuntimeK3s:
+		return append([]string{"k3s", "ctr"}, importArgs...), nil
+	case RuntimeRKE2:
+		return append([]string{"/var/lib/rancher/rke2/bin/ctr", "--address", "/run/k3s/containerd/containerd.sock"}, importArgs...), nil
+	}
+	return nil, fmt.Errorf("unsupported runtime %q; use one of %s", runtime, strings.Join(ContainerRuntimes, ", "))
+}
+
+// ImageArchives returns the pulled archive of every image in the manifest
+func ImageArchives(manifest *ArtifactManifest, dir string) ([]string, error) {
+	var archives, missing []string
+	for _, imageRef := range manifest.Images {
+		name := extractNameFromURI(strings.TrimPrefix(imageRef, "oci://"))
+		tarPath := filepath.Join(dir, name+".tar")
+		if _, err := os.Stat(tarPath); err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		archives = append(archives, tarPath)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("image archive(s) for %s not found in %s; run dynactl artifacts pull --images first", strings.Join(missing, ", "), dir)
+	}
+	return archives, nil
+}
+
+// NodeLoadResult is the outcome of loading images into one node
+type NodeLoadResult struct {
+	Node   string
+	Loaded int
+	Err    error
+}
+
+// LoadImagesOverSSH streams each archive to the nodes over ssh and imports it with sudo. The
+// local ssh client's configuration and agent provide authentication.
+func LoadImagesOverSSH(ctx context.Context, nodes []string, user, runtime string, archives []string) ([]NodeLoadResult, error) {
+	command, err := ImportCommand(runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]NodeLoadResult, 0, len(nodes))
+	for _, node := range nodes {
+		target := node
+		if user != "" {
+			target = user + "@" + node
+		}
+		result := NodeLoadResult{Node: node}
+		for _, archive := range archives {
+			LogInfo("📦 Loading %s into %s", filepath.Base(archive), node)
+			args := append([]string{"-o", "BatchMode=yes", target, "sudo", "-n"}, command...)
+			if err := runWithArchive(ctx, archive, func(stdin io.Reader, stderr io.Writer) error {
+				cmd := exec.CommandContext(ctx, "ssh", args...)
+				cmd.Stdin = stdin
+				cmd.Stderr = stderr
+				return cmd.Run()
+			}); err != nil {
+				result.Err = fmt.Errorf("failed to load %s: %w", filepath.Base(archive), err)
+				break
+			}
+			result.Loaded++
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// DaemonSetLoadOptions configures loading images through a privileged DaemonSet
+type DaemonSetLoadOptions struct {
+	Namespace    string
+	Image        string
+	NodeSelector map[string]string
+	Runtime      string
+	// Timeout bounds how long to wait for the loader pods to start
+	Timeout time.Duration
+}
+
+// LoadImagesWithDaemonSet runs a privileged pod on every selected node and streams each
+// archive into the node's containerd through pod exec. The loader image itself must already be
+// present on the nodes. The DaemonSet is removed afterwards.
+func (kc *KubernetesChecker) LoadImagesWithDaemonSet(ctx context.Context, opts DaemonSetLoadOptions, archives []string) ([]NodeLoadResult, error) {
+	command, err := ImportCommand(opts.Runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSets := kc.clientset.AppsV1().DaemonSets(opts.Namespace)
+	if _, err := daemonSets.Create(ctx, imageLoaderDaemonSet(opts), metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create DaemonSet %s: %v", imageLoaderName, err)
+	}
+	defer func() {
+		policy := metav1.DeletePropagationForeground
+		if err := daemonSets.Delete(context.WithoutCancel(ctx), imageLoaderName, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+			LogWarning("Failed to delete DaemonSet %s/%s: %v", opts.Namespace, imageLoaderName, err)
+		}
+	}()
+
+	pods, err := kc.waitForLoaderPods(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	execCommand := append([]string{"chroot", "/host"}, command...)
+	results := make([]NodeLoadResult, 0, len(pods))
+	for _, pod := range pods {
+		result := NodeLoadResult{Node: pod.Spec.NodeName}
+		for _, archive := range archives {
+			LogInfo("📦 Loading %s into %s", filepath.Base(archive), pod.Spec.NodeName)
+			if err := runWithArchive(ctx, archive, func(stdin io.Reader, stderr io.Writer) error {
+				return kc.execWithStdin(ctx, opts.Namespace, pod.Name, "loader", execCommand, stdin, stderr)
+			}); err != nil {
+				result.Err = fmt.Errorf("failed to load %s: %w", filepath.Base(archive), err)
+				break
+			}
+			result.Loaded++
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// waitForLoaderPods waits until the DaemonSet has a ready pod on every node it schedules to
+func (kc *KubernetesChecker) waitForLoaderPods(ctx context.Context, opts DaemonSetLoadOptions) ([]corev1.Pod, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	var ready []corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		ds, err := kc.clientset.AppsV1().DaemonSets(opts.Namespace).Get(ctx, imageLoaderName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		pods, err := kc.listPodsBySelector(ctx, opts.Namespace, "app.kubernetes.io/name="+imageLoaderName)
+		if err != nil {
+			return false, err
+		}
+		ready = ready[:0]
+		for i := range pods {
+			if isPodReady(&pods[i]) {
+				ready = append(ready, pods[i])
+			}
+		}
+		desired := int(ds.Status.DesiredNumberScheduled)
+		return desired > 0 && len(ready) == desired, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loader pods did not become ready (is %s available on the nodes?): %v", opts.Image, err)
+	}
+	return ready, nil
+}
+
+// execWithStdin runs a command in a pod's container, streaming stdin to it
+func (kc *KubernetesChecker) execWithStdin(ctx context.Context, namespace, pod, container string, command []string, stdin io.Reader, stderr io.Writer) error {
+	return kc.execStream(ctx, namespace, pod, container, command, stdin, io.Discard, stderr)
+}
+
+// execStream runs a command in a pod's container, connecting whichever of stdin, stdout and stderr
+// are set
+func (kc *KubernetesChecker) execStream(ctx context.Context, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := kc.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(pod).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil,
+		}, scheme.ParameterCodec)
+
+	// Image archives, models and database dumps can take longer than the per-request timeout to stream
+	config := rest.CopyConfig(kc.config)
+	config.Timeout = 0
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create exec stream: %v", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: stderr})
+}
+
+// imageLoaderDaemonSet builds the privileged DaemonSet whose pods see the node filesystem at
+// /host so the node's own ctr binary can be run with chroot
+func imageLoaderDaemonSet(opts DaemonSetLoadOptions) *appsv1.DaemonSet {
+	labels := map[string]string{"app.kubernetes.io/name": imageLoaderName, "app.kubernetes.io/managed-by": "dynactl"}
+	privileged := true
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: imageLoaderName, Namespace: opts.Namespace, Labels: labels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": imageLoaderName}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					NodeSelector: opts.NodeSelector,
+					// Load onto tainted nodes too, such as GPU pools and control planes
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{{
+						Name:            "loader",
+						Image:           opts.Image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"sleep", "86400"},
+						SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+						VolumeMounts:    []corev1.VolumeMount{{Name: "host", MountPath: "/host"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "host",
+						VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// runWithArchive opens an archive and passes it to run, adding the command's stderr to errors
+func runWithArchive(ctx context.Context, archive string, run func(stdin io.Reader, stderr io.Writer) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var stderr bytes.Buffer
+	if err := run(f, &stderr); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return ctx.Err()
+}
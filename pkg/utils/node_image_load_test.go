@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImportCommand(t *testing.T) {
+	cmd, err := ImportCommand(RuntimeK3s)
+	if err != nil || strings.Join(cmd, " ") != "k3s ctr --namespace k8s.io images import -" {
+		t.Errorf("unexpected k3s import command %v (%v)", cmd, err)
+	}
+	if _, err := ImportCommand("docker"); err == nil {
+		t.Error("expected docker to be rejected")
+	}
+}
+
+func TestImageArchives(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &ArtifactManifest{Images: []string{
+		"artifacts.dynamo.ai/dynamoai/3.22.2/images/api:1.0",
+		"oci://artifacts.dynamo.ai/dynamoai/3.22.2/images/worker:1.0",
+	}}
+	if err := os.WriteFile(filepath.Join(dir, "api.tar"), []byte("tar"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImageArchives(manifest, dir); err == nil || !strings.Contains(err.Error(), "worker") {
+		t.Fatalf("expected the missing worker archive to be reported, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "worker.tar"), []byte("tar"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := ImageArchives(manifest, dir)
+	if err != nil || len(archives) != 2 {
+		t.Fatalf("expected both archives, got %v (%v)", archives, err)
+	}
+}
+
+func TestLoadImagesOverSSH(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh client is a shell script")
+	}
+	bin, out := t.TempDir(), t.TempDir()
+	// The fake ssh records its arguments and stdin, and fails for the node named "down"
+	script := `#!/bin/sh
+for arg; do host=$arg; case $arg in *@*|node*|down) break;; esac; done
+case $host in *down) echo "connection refused" >&2; exit 255;; esac
+echo "$@" >> "` + out + `/args"
+cat >> "` + out + `/stdin"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	archive := filepath.Join(t.TempDir(), "api.tar")
+	if err := os.WriteFile(archive, []byte("image-bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := LoadImagesOverSSH(context.Background(), []string{"node1", "down"}, "ops", RuntimeContainerd, []string{archive})
+	if err != nil {
+		t.Fatalf("LoadImagesOverSSH failed: %v", err)
+	}
+	if results[0].Err != nil || results[0].Loaded != 1 {
+		t.Errorf("expected node1 to load one image, got %+v", results[0])
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "connection refused") {
+		t.Errorf("expected the ssh error for down, got %+v", results[1])
+	}
+
+	args, _ := os.ReadFile(filepath.Join(out, "args"))
+	if !strings.Contains(string(args), "ops@node1 sudo -n ctr --namespace k8s.io images import -") {
+		t.Errorf("unexpected ssh arguments %q", args)
+	}
+	if stdin, _ := os.ReadFile(filepath.Join(out, "stdin")); string(stdin) != "image-bytes" {
+		t.Errorf("expected the archive on stdin, got %q", stdin)
+	}
+}
+
+func TestImageLoaderDaemonSet(t *testing.T) {
+	ds := imageLoaderDaemonSet(DaemonSetLoadOptions{Namespace: "kube-system", Image: DefaultLoaderImage, NodeSelector: map[string]string{"role": "gpu"}})
+	spec := ds.Spec.Template.Spec
+	if spec.NodeSelector["role"] != "gpu" || spec.Tolerations[0].Operator != corev1.TolerationOpExists {
+		t.Errorf("unexpected scheduling %+v", spec)
+	}
+	c := spec.Containers[0]
+	if c.ImagePullPolicy != corev1.PullIfNotPresent || !*c.SecurityContext.Privileged || c.VolumeMounts[0].MountPath != "/host" {
+		t.Errorf("unexpected loader container %+v", c)
+	}
+	if spec.Volumes[0].HostPath.Path != "/" {
+		t.Errorf("expected the host root to be mounted, got %+v", spec.Volumes[0])
+	}
+}
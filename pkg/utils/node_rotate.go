@@ -0,0 +1,454 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Node rotation check names
+const (
+	RotateCheckPods     = "pods"
+	RotateCheckCapacity = "capacity"
+	RotateCheckPDBs     = "pdbs"
+)
+
+// DisplacedPod is a pod a node drain evicts
+type DisplacedPod struct {
+	Namespace string
+	Name      string
+	// Owner is the pod's controller as Kind/name, empty for a bare pod that is not recreated
+	Owner    string
+	OwnerUID types.UID `json:"-"`
+	CPU      string
+	Memory   string
+	GPU      int64
+	// PDB names the PodDisruptionBudget covering the pod, if any
+	PDB string `json:",omitempty"`
+	// Blocked is set when that PodDisruptionBudget allows no disruptions right now
+	Blocked bool `json:",omitempty"`
+	pod     corev1.Pod
+}
+
+// NodeRotationPlan is what draining a node displaces and whether the rest of the cluster can
+// take it
+type NodeRotationPlan struct {
+	Node string
+	Pods []DisplacedPod
+	// DaemonSetPods stay on the node until it is removed
+	DaemonSetPods int
+	Fit           *SizingFitResult `json:",omitempty"`
+	Checks        []CheckResult
+}
+
+// NodeDrainOptions bounds a node drain
+type NodeDrainOptions struct {
+	// Timeout bounds the eviction of all pods, including retries while a PodDisruptionBudget
+	// blocks them
+	Timeout time.Duration
+	// Interval is the wait between eviction retries
+	Interval time.Duration
+	// OnEvict is called after each pod is evicted, and on each blocked attempt with the error
+	OnEvict func(pod DisplacedPod, err error)
+}
+
+// Failed reports whether any check of the plan failed
+func (p *NodeRotationPlan) Failed() bool {
+	for _, c := range p.Checks {
+		if c.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanNodeRotation lists the pods a drain of the node would displace, simulates rescheduling them
+// onto the other ready, schedulable nodes, and finds the PodDisruptionBudgets that would block it
+func (kc *KubernetesChecker) PlanNodeRotation(ctx context.Context, nodeName string) (*NodeRotationPlan, error) {
+	node, err := kc.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	pods, err := kc.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName + ",status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on %s: %v", nodeName, err)
+	}
+	plan := &NodeRotationPlan{Node: nodeName}
+	plan.Pods, plan.DaemonSetPods = displacedPods(pods.Items)
+
+	var pdbs []policyv1.PodDisruptionBudget
+	namespaces := map[string]bool{}
+	for _, p := range plan.Pods {
+		if namespaces[p.Namespace] {
+			continue
+		}
+		namespaces[p.Namespace] = true
+		list, err := kc.clientset.PolicyV1().PodDisruptionBudgets(p.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PodDisruptionBudgets in %s: %v", p.Namespace, err)
+		}
+		pdbs = append(pdbs, list.Items...)
+	}
+	matchPDBs(plan.Pods, pdbs)
+
+	nodes, err := kc.ListNodeCapacities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	others := nodes[:0]
+	for _, n := range nodes {
+		if n.Name != nodeName {
+			others = append(others, n)
+		}
+	}
+	if profile := displacedProfile(node, plan.Pods, others); len(profile.Components) > 0 {
+		if plan.Fit, err = SimulateSizingFit(others, profile); err != nil {
+			return nil, err
+		}
+	}
+	plan.Checks = rotationChecks(plan)
+	return plan, nil
+}
+
+// displacedPods returns the pods a drain evicts, skipping DaemonSet and static pods, which it
+// cannot move; it also returns how many DaemonSet pods stay
+func displacedPods(pods []corev1.Pod) ([]DisplacedPod, int) {
+	var displaced []DisplacedPod
+	daemonSets := 0
+	for _, pod := range pods {
+		if _, static := pod.Annotations[corev1.MirrorPodAnnotationKey]; static {
+			continue
+		}
+		owner := metav1.GetControllerOf(&pod)
+		if owner != nil && owner.Kind == "DaemonSet" {
+			daemonSets++
+			continue
+		}
+		requests := PodEffectiveRequests(&pod)
+		d := DisplacedPod{Namespace: pod.Namespace, Name: pod.Name, pod: pod}
+		if owner != nil {
+			d.Owner, d.OwnerUID = owner.Kind+"/"+owner.Name, owner.UID
+		}
+		if cpu, ok := requests[corev1.ResourceCPU]; ok {
+			d.CPU = cpu.String()
+		}
+		if mem, ok := requests[corev1.ResourceMemory]; ok {
+			d.Memory = mem.String()
+		}
+		if gpu, ok := requests[gpuResource]; ok {
+			d.GPU = gpu.Value()
+		}
+		displaced = append(displaced, d)
+	}
+	sort.Slice(displaced, func(i, j int) bool {
+		if displaced[i].GPU != displaced[j].GPU {
+			return displaced[i].GPU > displaced[j].GPU
+		}
+		return displaced[i].Namespace+"/"+displaced[i].Name < displaced[j].Namespace+"/"+displaced[j].Name
+	})
+	return displaced, daemonSets
+}
+
+// matchPDBs records the PodDisruptionBudget covering each pod and whether it allows no
+// disruptions
+func matchPDBs(pods []DisplacedPod, pdbs []policyv1.PodDisruptionBudget) {
+	for i := range pods {
+		for _, pdb := range pdbs {
+			if pdb.Namespace != pods[i].Namespace || !pdbSelects(pdb, pods[i].pod.Labels) {
+				continue
+			}
+			pods[i].PDB = pdb.Name
+			pods[i].Blocked = pdb.Status.DisruptionsAllowed == 0
+			break
+		}
+	}
+}
+
+// displacedProfile groups the displaced pods that will be recreated by controller into a sizing
+// profile, with each pod's node selector and tolerations, so the fit simulation places them as
+// the scheduler would. GPU pods are only placed on nodes with the same GPU product as the node
+// they leave, since models are sized for it.
+func displacedProfile(node *corev1.Node, pods []DisplacedPod, nodes []NodeCapacity) SizingProfile {
+	var allTaints []string
+	for _, n := range nodes {
+		for _, key := range n.TaintKeys {
+			if !containsString(allTaints, key) {
+				allTaints = append(allTaints, key)
+			}
+		}
+	}
+
+	profile := SizingProfile{Name: "pods on " + node.Name}
+	index := map[string]int{}
+	for _, p := range pods {
+		if p.Owner == "" {
+			continue
+		}
+		key := p.Namespace + "/" + p.Owner
+		if i, ok := index[key]; ok {
+			profile.Components[i].Replicas++
+			continue
+		}
+		index[key] = len(profile.Components)
+		profile.Components = append(profile.Components, ModelProfile{
+			Name:         key,
+			Replicas:     1,
+			CPU:          p.CPU,
+			Memory:       p.Memory,
+			GPU:          p.GPU,
+			NodeSelector: p.pod.Spec.NodeSelector,
+			Tolerations:  tolerationKeys(p.pod.Spec.Tolerations, allTaints),
+		})
+		if p.GPU > 0 {
+			profile.Components[len(profile.Components)-1].GPUType = node.Labels[gpuProductLabel]
+		}
+	}
+	return profile
+}
+
+// tolerationKeys lists the taint keys tolerations tolerate; a toleration without a key tolerates
+// every taint
+func tolerationKeys(tolerations []corev1.Toleration, allTaints []string) []string {
+	var keys []string
+	for _, t := range tolerations {
+		if t.Key == "" && t.Operator == corev1.TolerationOpExists {
+			return allTaints
+		}
+		if t.Key != "" {
+			keys = append(keys, t.Key)
+		}
+	}
+	return keys
+}
+
+// rotationChecks turns a plan into checks: bare pods and pods that do not fit elsewhere are
+// failures, and so are PodDisruptionBudgets that allow no disruptions, since the drain would
+// wait on them until it times out
+func rotationChecks(plan *NodeRotationPlan) []CheckResult {
+	var gpuPods int
+	var bare, blocked []string
+	for _, p := range plan.Pods {
+		if p.GPU > 0 {
+			gpuPods++
+		}
+		if p.Owner == "" {
+			bare = append(bare, p.Namespace+"/"+p.Name)
+		}
+		if p.Blocked {
+			blocked = append(blocked, fmt.Sprintf("%s/%s (%s/%s)", p.Namespace, p.PDB, p.Namespace, p.Name))
+		}
+	}
+
+	var checks []CheckResult
+	message := fmt.Sprintf("%d to evict (%d with GPUs), %d DaemonSet pods stay", len(plan.Pods), gpuPods, plan.DaemonSetPods)
+	if len(bare) > 0 {
+		checks = append(checks, CheckResult{Name: RotateCheckPods, Status: CheckFail, Message: message + "; not recreated by a controller: " + strings.Join(bare, ", ")})
+	} else {
+		checks = append(checks, CheckResult{Name: RotateCheckPods, Status: CheckPass, Message: message})
+	}
+
+	switch {
+	case plan.Fit == nil:
+		checks = append(checks, CheckResult{Name: RotateCheckCapacity, Status: CheckPass, Message: "nothing to reschedule"})
+	case plan.Fit.Fits:
+		checks = append(checks, CheckResult{Name: RotateCheckCapacity, Status: CheckPass, Message: fmt.Sprintf("the pods fit on the other %d nodes", plan.Fit.Nodes)})
+	default:
+		var unfit []string
+		for _, c := range plan.Fit.Components {
+			if !c.Fits {
+				reason := fmt.Sprintf("%s (%d of %d placed", c.Component, c.Placed, c.Replicas)
+				if len(c.Reasons) > 0 {
+					reason += ": " + strings.Join(c.Reasons, ", ")
+				}
+				unfit = append(unfit, reason+")")
+			}
+		}
+		checks = append(checks, CheckResult{Name: RotateCheckCapacity, Status: CheckFail, Message: "no room elsewhere for " + strings.Join(unfit, "; ")})
+	}
+
+	if len(blocked) > 0 {
+		checks = append(checks, CheckResult{Name: RotateCheckPDBs, Status: CheckFail, Message: "allow no disruptions: " + strings.Join(blocked, ", ")})
+	} else {
+		checks = append(checks, CheckResult{Name: RotateCheckPDBs, Status: CheckPass, Message: "no PodDisruptionBudget blocks the drain"})
+	}
+	return checks
+}
+
+// CordonNode marks the node unschedulable
+func (kc *KubernetesChecker) CordonNode(ctx context.Context, nodeName string) error {
+	patch, _ := json.Marshal(map[string]any{"spec": map[string]any{"unschedulable": true}})
+	if _, err := kc.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to cordon %s: %v", nodeName, err)
+	}
+	return nil
+}
+
+// DrainNode evicts the plan's pods through the Eviction API, so PodDisruptionBudgets are
+// honored: an eviction a budget refuses is retried until it allows it or the timeout passes. It
+// then waits for the evicted pods to be gone from the node.
+func (kc *KubernetesChecker) DrainNode(ctx context.Context, plan *NodeRotationPlan, opts NodeDrainOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	pending := append([]DisplacedPod(nil), plan.Pods...)
+	for len(pending) > 0 {
+		var retry []DisplacedPod
+		for _, p := range pending {
+			eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace}}
+			err := kc.clientset.PolicyV1().Evictions(p.Namespace).Evict(ctx, eviction)
+			switch {
+			case err == nil || apierrors.IsNotFound(err):
+				if opts.OnEvict != nil {
+					opts.OnEvict(p, nil)
+				}
+			case apierrors.IsTooManyRequests(err):
+				// The PodDisruptionBudget does not allow it yet
+				if opts.OnEvict != nil {
+					opts.OnEvict(p, err)
+				}
+				retry = append(retry, p)
+			default:
+				return fmt.Errorf("failed to evict %s/%s: %v", p.Namespace, p.Name, err)
+			}
+		}
+		pending = retry
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s with %d pod(s) not evicted", opts.Timeout, len(pending))
+		case <-time.After(opts.Interval):
+		}
+	}
+
+	for {
+		var remaining []string
+		for _, p := range plan.Pods {
+			pod, err := kc.clientset.CoreV1().Pods(p.Namespace).Get(ctx, p.Name, metav1.GetOptions{})
+			if err == nil && pod.UID == p.pod.UID && pod.Spec.NodeName == plan.Node {
+				remaining = append(remaining, p.Namespace+"/"+p.Name)
+			} else if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get pod %s/%s: %v", p.Namespace, p.Name, err)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s waiting for %s to terminate", opts.Timeout, strings.Join(remaining, ", "))
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// RescheduledOwner is a controller whose evicted pods have been replaced elsewhere
+type RescheduledOwner struct {
+	Namespace string
+	Owner     string
+	Ready     int
+	Nodes     []string
+}
+
+// WaitForRescheduled waits until each controller that lost pods has as many ready pods on other
+// nodes as it had in total before the drain. onReady is called as each controller gets there.
+// Jobs are not waited for, since their pods may finish instead.
+func (kc *KubernetesChecker) WaitForRescheduled(ctx context.Context, plan *NodeRotationPlan, before map[types.UID]int, timeout, interval time.Duration, onReady func(RescheduledOwner)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type owner struct {
+		namespace, name string
+		want            int
+	}
+	waiting := map[types.UID]*owner{}
+	for _, p := range plan.Pods {
+		if p.Owner == "" || strings.HasPrefix(p.Owner, "Job/") || waiting[p.OwnerUID] != nil {
+			continue
+		}
+		waiting[p.OwnerUID] = &owner{namespace: p.Namespace, name: p.Owner, want: before[p.OwnerUID]}
+	}
+
+	for len(waiting) > 0 {
+		namespaces := map[string]bool{}
+		for _, o := range waiting {
+			namespaces[o.namespace] = true
+		}
+		for ns := range namespaces {
+			pods, err := kc.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list pods in %s: %v", ns, err)
+			}
+			ready := map[types.UID][]string{}
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				ref := metav1.GetControllerOf(pod)
+				if ref == nil || pod.Spec.NodeName == plan.Node || pod.DeletionTimestamp != nil || !isPodReady(pod) {
+					continue
+				}
+				ready[ref.UID] = append(ready[ref.UID], pod.Spec.NodeName)
+			}
+			for uid, o := range waiting {
+				if o.namespace != ns || len(ready[uid]) < o.want {
+					continue
+				}
+				nodes := append([]string(nil), ready[uid]...)
+				sort.Strings(nodes)
+				nodes = slices.Compact(nodes)
+				if onReady != nil {
+					onReady(RescheduledOwner{Namespace: o.namespace, Owner: o.name, Ready: len(ready[uid]), Nodes: nodes})
+				}
+				delete(waiting, uid)
+			}
+		}
+		if len(waiting) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			var names []string
+			for _, o := range waiting {
+				names = append(names, o.namespace+"/"+o.name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("timed out after %s waiting for %s to be ready elsewhere", timeout, strings.Join(names, ", "))
+		case <-time.After(interval):
+		}
+	}
+	return nil
+}
+
+// ReadyPodsByOwner counts the ready pods of each controller that owns a displaced pod, across
+// all nodes, so WaitForRescheduled knows how many to wait for
+func (kc *KubernetesChecker) ReadyPodsByOwner(ctx context.Context, plan *NodeRotationPlan) (map[types.UID]int, error) {
+	counts := map[types.UID]int{}
+	namespaces := map[string]bool{}
+	for _, p := range plan.Pods {
+		namespaces[p.Namespace] = true
+	}
+	for ns := range namespaces {
+		pods, err := kc.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in %s: %v", ns, err)
+		}
+		for i := range pods.Items {
+			if ref := metav1.GetControllerOf(&pods.Items[i]); ref != nil && isPodReady(&pods.Items[i]) {
+				counts[ref.UID]++
+			}
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func rotatePod(name, ownerKind, ownerName string, requests corev1.ResourceList) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: name, Labels: map[string]string{"app": ownerName}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Resources: corev1.ResourceRequirements{Requests: requests}}}},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName, UID: types.UID("uid-" + ownerName), Controller: &controller}}
+	}
+	return pod
+}
+
+func TestDisplacedPods(t *testing.T) {
+	static := rotatePod("kube-proxy", "", "", nil)
+	static.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "x"}
+	pods := []corev1.Pod{
+		rotatePod("api-1", "ReplicaSet", "api", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")}),
+		rotatePod("node-exporter-x", "DaemonSet", "node-exporter", nil),
+		rotatePod("guard-0", "StatefulSet", "guard", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), gpuResource: resource.MustParse("1")}),
+		rotatePod("debug", "", "", nil),
+		static,
+	}
+
+	displaced, daemonSets := displacedPods(pods)
+	if daemonSets != 1 {
+		t.Errorf("Expected 1 DaemonSet pod, got %d", daemonSets)
+	}
+	var names []string
+	for _, p := range displaced {
+		names = append(names, p.Name)
+	}
+	if want := []string{"guard-0", "api-1", "debug"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("displaced = %q, want %q (GPU pods first)", names, want)
+	}
+	if guard := displaced[0]; guard.Owner != "StatefulSet/guard" || guard.GPU != 1 || guard.CPU != "4" {
+		t.Errorf("Unexpected guard pod %+v", guard)
+	}
+	if api := displaced[1]; api.CPU != "500m" || api.Memory != "1Gi" {
+		t.Errorf("Unexpected api pod requests %+v", api)
+	}
+	if debug := displaced[2]; debug.Owner != "" {
+		t.Errorf("Expected the bare pod to have no owner, got %q", debug.Owner)
+	}
+}
+
+func TestMatchPDBs(t *testing.T) {
+	pdb := func(name, app string, allowed int32) policyv1.PodDisruptionBudget {
+		minAvailable := intstr.FromInt32(1)
+		return policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "dynamo", Name: name},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	pods, _ := displacedPods([]corev1.Pod{
+		rotatePod("api-1", "ReplicaSet", "api", nil),
+		rotatePod("guard-0", "StatefulSet", "guard", nil),
+		rotatePod("worker-1", "ReplicaSet", "worker", nil),
+	})
+	other := pdb("api-pdb", "api", 0)
+	other.Namespace = "other"
+	matchPDBs(pods, []policyv1.PodDisruptionBudget{other, pdb("api-pdb", "api", 1), pdb("guard-pdb", "guard", 0)})
+
+	byName := map[string]DisplacedPod{}
+	for _, p := range pods {
+		byName[p.Name] = p
+	}
+	if api := byName["api-1"]; api.PDB != "api-pdb" || api.Blocked {
+		t.Errorf("Expected api covered by an unblocked api-pdb, got %+v", api)
+	}
+	if guard := byName["guard-0"]; guard.PDB != "guard-pdb" || !guard.Blocked {
+		t.Errorf("Expected guard blocked by guard-pdb, got %+v", guard)
+	}
+	if worker := byName["worker-1"]; worker.PDB != "" {
+		t.Errorf("Expected no PDB for the worker, got %q", worker.PDB)
+	}
+}
+
+func TestDisplacedProfile(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{gpuProductLabel: "NVIDIA-A100-SXM4-80GB"}}}
+	guard := rotatePod("guard-0", "StatefulSet", "guard", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), gpuResource: resource.MustParse("1")})
+	guard.Spec.Tolerations = []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}
+	guard.Spec.NodeSelector = map[string]string{"pool": "gpu"}
+	everywhere := rotatePod("agent-1", "ReplicaSet", "agent", nil)
+	everywhere.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	guard1 := guard
+	guard1.Name = "guard-1"
+	pods, _ := displacedPods([]corev1.Pod{guard, guard1, everywhere, rotatePod("debug", "", "", nil)})
+	nodes := []NodeCapacity{{Name: "gpu-2", TaintKeys: []string{"nvidia.com/gpu"}}, {Name: "infra-1", TaintKeys: []string{"dedicated"}}}
+
+	profile := displacedProfile(node, pods, nodes)
+	if len(profile.Components) != 2 {
+		t.Fatalf("Expected guard and agent components (the bare pod is not rescheduled), got %+v", profile.Components)
+	}
+	g := profile.Components[0]
+	if g.Name != "dynamo/StatefulSet/guard" || g.Replicas != 2 || g.GPU != 1 || g.GPUType != "NVIDIA-A100-SXM4-80GB" {
+		t.Errorf("Unexpected guard component %+v", g)
+	}
+	if !reflect.DeepEqual(g.Tolerations, []string{"nvidia.com/gpu"}) || g.NodeSelector["pool"] != "gpu" {
+		t.Errorf("Expected the guard pod's placement constraints, got %+v", g)
+	}
+	if a := profile.Components[1]; a.GPUType != "" || !reflect.DeepEqual(a.Tolerations, []string{"nvidia.com/gpu", "dedicated"}) {
+		t.Errorf("Expected the agent to tolerate every taint and need no GPU type, got %+v", a)
+	}
+}
+
+func TestRotationChecks(t *testing.T) {
+	plan := &NodeRotationPlan{
+		Node: "gpu-1",
+		Pods: []DisplacedPod{
+			{Namespace: "dynamo", Name: "guard-0", Owner: "StatefulSet/guard", GPU: 1, PDB: "guard-pdb", Blocked: true},
+			{Namespace: "dynamo", Name: "debug"},
+		},
+		DaemonSetPods: 2,
+		Fit: &SizingFitResult{Nodes: 3, Components: []ComponentFit{
+			{Component: "dynamo/StatefulSet/guard", Replicas: 1, Reasons: []string{"2 node(s) had insufficient gpu"}},
+		}},
+	}
+	plan.Checks = rotationChecks(plan)
+	checks := plan.Checks
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 checks, got %+v", checks)
+	}
+	for _, c := range checks {
+		if c.Status != CheckFail {
+			t.Errorf("Expected %s to fail, got %+v", c.Name, c)
+		}
+	}
+	if !strings.Contains(checks[0].Message, "2 to evict (1 with GPUs), 2 DaemonSet pods stay") || !strings.Contains(checks[0].Message, "dynamo/debug") {
+		t.Errorf("Unexpected pods message %q", checks[0].Message)
+	}
+	if want := "no room elsewhere for dynamo/StatefulSet/guard (0 of 1 placed: 2 node(s) had insufficient gpu)"; checks[1].Message != want {
+		t.Errorf("capacity message = %q, want %q", checks[1].Message, want)
+	}
+	if !strings.Contains(checks[2].Message, "dynamo/guard-pdb (dynamo/guard-0)") {
+		t.Errorf("Unexpected pdbs message %q", checks[2].Message)
+	}
+	if !plan.Failed() {
+		t.Error("Expected the plan to fail")
+	}
+
+	plan = &NodeRotationPlan{Node: "gpu-1", Pods: []DisplacedPod{{Namespace: "dynamo", Name: "api-1", Owner: "ReplicaSet/api"}},
+		Fit: &SizingFitResult{Nodes: 3, Fits: true}}
+	plan.Checks = rotationChecks(plan)
+	if plan.Failed() {
+		t.Errorf("Expected every check to pass, got %+v", plan.Checks)
+	}
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// NodeVersionMatrix is the node software Dynamo AI is validated on. Constraints use semver
+// ranges and are compared against the release core of each version, ignoring distribution
+// suffixes such as -eks-1a2b3c4.
+type NodeVersionMatrix struct {
+	Kubelet string
+	// MaxKubeletSkew is how many minor versions a kubelet may trail the API server; kubelets
+	// newer than the API server are never supported
+	MaxKubeletSkew int
+	// Runtimes maps each supported container runtime to its version range
+	Runtimes map[string]string
+	Kernel   string
+}
+
+// SupportedNodeVersions is the matrix the node report flags against
+var SupportedNodeVersions = NodeVersionMatrix{
+	Kubelet:        ">= 1.27.0",
+	MaxKubeletSkew: 3,
+	Runtimes: map[string]string{
+		"containerd": ">= 1.6.0",
+		"cri-o":      ">= 1.27.0",
+	},
+	Kernel: ">= 4.18.0",
+}
+
+// leadingVersion matches the numeric part of kernel and runtime versions, whose suffixes such as
+// .amzn2.x86_64 are not valid semver
+var leadingVersion = regexp.MustCompile(`^v?(\d+(?:\.\d+){0,2})`)
+
+// parseNodeVersion reads the major.minor.patch a version string starts with
+func parseNodeVersion(version string) (*semver.Version, error) {
+	m := leadingVersion.FindStringSubmatch(version)
+	if m == nil {
+		return nil, fmt.Errorf("unrecognized version %q", version)
+	}
+	return semver.NewVersion(m[1])
+}
+
+// splitContainerRuntime splits a node's runtime such as containerd://1.7.11 into name and version
+func splitContainerRuntime(runtime string) (string, string) {
+	name, version, found := strings.Cut(runtime, "://")
+	if !found {
+		return runtime, ""
+	}
+	return name, version
+}
+
+// CheckNodeVersions flags each node's kubelet, container runtime, and kernel against the matrix,
+// kubelets outside the supported skew from the API server, and nodes whose kubelet or runtime
+// differs from the rest of their node pool (or instance type, without a pool label). Mixed pools
+// schedule the same pod onto nodes that behave differently, which shows up as GPU pods that only
+// fail on some nodes. serverVersion may be empty when it is unknown.
+func CheckNodeVersions(usages []NodeResourceUsage, serverVersion string, matrix NodeVersionMatrix) {
+	var server *semver.Version
+	if serverVersion != "" {
+		if v, err := parseNodeVersion(serverVersion); err == nil {
+			server = v
+		}
+	}
+
+	for i := range usages {
+		u := &usages[i]
+		u.VersionProblems = nil
+		if problem := versionOutside("kubelet", u.KubeletVersion, matrix.Kubelet); problem != "" {
+			u.VersionProblems = append(u.VersionProblems, problem)
+		}
+		if problem := kubeletSkewProblem(u.KubeletVersion, server, matrix.MaxKubeletSkew); problem != "" {
+			u.VersionProblems = append(u.VersionProblems, problem)
+		}
+		if u.ContainerRuntime != "" {
+			if constraint, ok := matrix.Runtimes[u.ContainerRuntime]; !ok {
+				u.VersionProblems = append(u.VersionProblems, fmt.Sprintf("container runtime %s is not supported", u.ContainerRuntime))
+			} else if problem := versionOutside(u.ContainerRuntime, u.ContainerRuntimeVersion, constraint); problem != "" {
+				u.VersionProblems = append(u.VersionProblems, problem)
+			}
+		}
+		if problem := versionOutside("kernel", u.KernelVersion, matrix.Kernel); problem != "" {
+			u.VersionProblems = append(u.VersionProblems, problem)
+		}
+	}
+
+	pools := map[string][]int{}
+	for i, u := range usages {
+		pool := u.NodePool
+		if pool == "" {
+			pool = u.InstanceType
+		}
+		pools[pool] = append(pools[pool], i)
+	}
+	for _, pool := range sortedKeys(pools) {
+		members := pools[pool]
+		flagMixed(usages, members, pool, "kubelet", func(u NodeResourceUsage) string { return u.KubeletVersion })
+		flagMixed(usages, members, pool, "runtime", func(u NodeResourceUsage) string {
+			if u.ContainerRuntime == "" {
+				return ""
+			}
+			return u.ContainerRuntime + " " + u.ContainerRuntimeVersion
+		})
+	}
+}
+
+// versionOutside describes a version outside the constraint, or returns "" when it is inside or
+// cannot be judged
+func versionOutside(component, version, constraint string) string {
+	if version == "" || constraint == "" {
+		return ""
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		LogDebug("Invalid %s constraint %q: %v", component, constraint, err)
+		return ""
+	}
+	v, err := parseNodeVersion(version)
+	if err != nil {
+		LogDebug("Skipping %s version check: %v", component, err)
+		return ""
+	}
+	if !c.Check(v) {
+		return fmt.Sprintf("%s %s is outside the supported range %s", component, version, constraint)
+	}
+	return ""
+}
+
+// kubeletSkewProblem applies the Kubernetes version skew policy to a kubelet
+func kubeletSkewProblem(kubelet string, server *semver.Version, maxSkew int) string {
+	if server == nil || kubelet == "" {
+		return ""
+	}
+	v, err := parseNodeVersion(kubelet)
+	if err != nil {
+		return ""
+	}
+	behind := (int(server.Major())-int(v.Major()))*1000 + int(server.Minor()) - int(v.Minor())
+	switch {
+	case behind < 0:
+		return fmt.Sprintf("kubelet %s is newer than the API server %s", kubelet, server)
+	case behind > maxSkew:
+		return fmt.Sprintf("kubelet %s trails the API server %s by %d minor versions (at most %d supported)", kubelet, server, behind, maxSkew)
+	}
+	return ""
+}
+
+// flagMixed adds a problem to each pool member whose value differs from the pool's most common
+// one. Ties go to the lowest value so the result is stable.
+func flagMixed(usages []NodeResourceUsage, members []int, pool, component string, value func(NodeResourceUsage) string) {
+	counts := map[string]int{}
+	for _, i := range members {
+		if v := value(usages[i]); v != "" {
+			counts[v]++
+		}
+	}
+	if len(counts) < 2 {
+		return
+	}
+	values := sortedKeys(counts)
+	sort.SliceStable(values, func(a, b int) bool { return counts[values[a]] > counts[values[b]] })
+	common := values[0]
+	for _, i := range members {
+		if v := value(usages[i]); v != "" && v != common {
+			usages[i].VersionProblems = append(usages[i].VersionProblems,
+				fmt.Sprintf("%s %s differs from %s on the rest of pool %s", component, v, common, pool))
+		}
+	}
+}
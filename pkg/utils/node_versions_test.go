@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitContainerRuntime(t *testing.T) {
+	tests := []struct {
+		runtime, name, version string
+	}{
+		{"containerd://1.7.11-1", "containerd", "1.7.11-1"},
+		{"cri-o://1.29.1", "cri-o", "1.29.1"},
+		{"docker", "docker", ""},
+	}
+	for _, tt := range tests {
+		name, version := splitContainerRuntime(tt.runtime)
+		if name != tt.name || version != tt.version {
+			t.Errorf("splitContainerRuntime(%q) = %q, %q, want %q, %q", tt.runtime, name, version, tt.name, tt.version)
+		}
+	}
+}
+
+func TestCheckNodeVersions(t *testing.T) {
+	node := func(name, pool, kubelet, runtime, runtimeVersion, kernel string) NodeResourceUsage {
+		return NodeResourceUsage{Name: name, NodePool: pool, KubeletVersion: kubelet, ContainerRuntime: runtime, ContainerRuntimeVersion: runtimeVersion, KernelVersion: kernel}
+	}
+	usages := []NodeResourceUsage{
+		node("cpu-1", "cpu", "v1.30.4-eks-a737599", "containerd", "1.7.11", "5.10.210-201.852.amzn2.x86_64"),
+		node("cpu-2", "cpu", "v1.30.4-eks-a737599", "containerd", "1.7.11", "5.10.210-201.852.amzn2.x86_64"),
+		node("gpu-1", "gpu", "v1.30.4-eks-a737599", "containerd", "1.7.11", "5.10.210-201.852.amzn2.x86_64"),
+		node("gpu-2", "gpu", "v1.30.4-eks-a737599", "containerd", "1.7.11", "5.10.210-201.852.amzn2.x86_64"),
+		node("gpu-3", "gpu", "v1.28.8-eks-ae9a62a", "containerd", "1.6.28", "5.10.210-201.852.amzn2.x86_64"),
+		node("old-1", "legacy", "v1.26.4", "docker", "20.10.25", "3.10.0-1160.el7.x86_64"),
+		node("new-1", "edge", "v1.31.0", "cri-o", "1.31.0", "6.1.0"),
+	}
+	CheckNodeVersions(usages, "v1.30.4-eks-a737599", SupportedNodeVersions)
+
+	want := map[string][]string{
+		"gpu-3": {
+			"kubelet v1.28.8-eks-ae9a62a differs from v1.30.4-eks-a737599 on the rest of pool gpu",
+			"runtime containerd 1.6.28 differs from containerd 1.7.11 on the rest of pool gpu",
+		},
+		"old-1": {
+			"kubelet v1.26.4 is outside the supported range >= 1.27.0",
+			"kubelet v1.26.4 trails the API server 1.30.4 by 4 minor versions (at most 3 supported)",
+			"container runtime docker is not supported",
+			"kernel 3.10.0-1160.el7.x86_64 is outside the supported range >= 4.18.0",
+		},
+		"new-1": {"kubelet v1.31.0 is newer than the API server 1.30.4"},
+	}
+	for _, u := range usages {
+		if !reflect.DeepEqual(u.VersionProblems, want[u.Name]) {
+			t.Errorf("%s: VersionProblems = %q, want %q", u.Name, u.VersionProblems, want[u.Name])
+		}
+	}
+}
+
+func TestCheckNodeVersionsUnknownServer(t *testing.T) {
+	usages := []NodeResourceUsage{{Name: "node-1", KubeletVersion: "v1.31.0", KernelVersion: "unknown"}}
+	CheckNodeVersions(usages, "", SupportedNodeVersions)
+	if len(usages[0].VersionProblems) != 0 {
+		t.Errorf("VersionProblems = %q, want none without a server version or a parsable kernel", usages[0].VersionProblems)
+	}
+}
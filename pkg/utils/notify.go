@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CheckReport is a set of check results from one run against a cluster
+type CheckReport struct {
+	Cluster  string
+	Time     time.Time
+	Results  []CheckResult
+	Failures []CheckResult
+}
+
+// Notifier delivers a check report to an external channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, report CheckReport) error
+}
+
+// NotifyThrottle keeps a daemon from posting the same failures on every run. A report is sent
+// when its failing checks differ from the last one sent, or once Renotify has passed since then;
+// 0 never repeats unchanged failures.
+type NotifyThrottle struct {
+	Renotify time.Duration
+	sent     string
+	sentAt   time.Time
+}
+
+// ShouldNotify reports whether the report is worth sending and, if so, records it as sent. A run
+// without failures resets the throttle, so the next failure is always sent.
+func (t *NotifyThrottle) ShouldNotify(report CheckReport) bool {
+	names := make([]string, 0, len(report.Failures))
+	for _, f := range report.Failures {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	failing := strings.Join(names, ",")
+	if failing == "" {
+		t.sent = ""
+		return false
+	}
+	if failing == t.sent && (t.Renotify <= 0 || report.Time.Sub(t.sentAt) < t.Renotify) {
+		return false
+	}
+	t.sent, t.sentAt = failing, report.Time
+	return true
+}
+
+// SlackNotifier posts to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+// TeamsNotifier posts to a Microsoft Teams incoming webhook
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+// WebhookNotifier posts the report as JSON to an arbitrary URL
+type WebhookNotifier struct {
+	URL string
+}
+
+// Name identifies the notifier in logs
+func (n SlackNotifier) Name() string { return "slack" }
+
+// Notify posts a Slack message listing the failed checks
+func (n SlackNotifier) Notify(ctx context.Context, report CheckReport) error {
+	return postJSON(ctx, n.WebhookURL, map[string]string{"text": reportTitle(report) + "\n" + reportLines(report, "• ")})
+}
+
+// Name identifies the notifier in logs
+func (n TeamsNotifier) Name() string { return "teams" }
+
+// Notify posts a Teams MessageCard listing the failed checks
+func (n TeamsNotifier) Notify(ctx context.Context, report CheckReport) error {
+	return postJSON(ctx, n.WebhookURL, map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    reportTitle(report),
+		"title":      reportTitle(report),
+		"themeColor": "D70000",
+		"text":       strings.ReplaceAll(reportLines(report, "- "), "\n", "\n\n"),
+	})
+}
+
+// Name identifies the notifier in logs
+func (n WebhookNotifier) Name() string { return "webhook" }
+
+// Notify posts the full report as JSON
+func (n WebhookNotifier) Notify(ctx context.Context, report CheckReport) error {
+	return postJSON(ctx, n.URL, report)
+}
+
+func reportTitle(report CheckReport) string {
+	return fmt.Sprintf("dynactl: %d checks failing on %s", len(report.Failures), report.Cluster)
+}
+
+func reportLines(report CheckReport, bullet string) string {
+	lines := make([]string, 0, len(report.Failures))
+	for _, f := range report.Failures {
+		lines = append(lines, fmt.Sprintf("%s%s: %s", bullet, f.Name, f.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postJSON sends payload to url and treats any non-2xx response as an error
+func postJSON(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
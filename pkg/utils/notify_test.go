@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotifier(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	report := CheckReport{
+		Cluster:  "https://prod.example.com",
+		Failures: []CheckResult{{Name: "nodes", Status: CheckFail, Message: "1 of 3 nodes NotReady: gpu-1"}},
+	}
+	if err := (SlackNotifier{WebhookURL: server.URL}).Notify(context.Background(), report); err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	if !strings.Contains(payload["text"], "1 checks failing on https://prod.example.com") ||
+		!strings.Contains(payload["text"], "nodes: 1 of 3 nodes NotReady: gpu-1") {
+		t.Errorf("unexpected Slack payload: %q", payload["text"])
+	}
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := (WebhookNotifier{URL: server.URL}).Notify(context.Background(), CheckReport{})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("expected HTTP 403 error, got %v", err)
+	}
+}
+
+func TestNotifyThrottle(t *testing.T) {
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	report := func(hours int, failing ...string) CheckReport {
+		r := CheckReport{Time: start.Add(time.Duration(hours) * time.Hour)}
+		for _, name := range failing {
+			r.Failures = append(r.Failures, CheckResult{Name: name, Status: CheckFail})
+		}
+		return r
+	}
+
+	throttle := &NotifyThrottle{Renotify: 24 * time.Hour}
+	steps := []struct {
+		report CheckReport
+		want   bool
+	}{
+		{report(0), false},
+		{report(6, "storage"), true},
+		{report(12, "storage"), false},
+		{report(18, "storage", "license"), true},
+		{report(24, "license", "storage"), false},
+		{report(42, "license", "storage"), true},
+		{report(48), false},
+		{report(54, "license", "storage"), true},
+	}
+	for i, step := range steps {
+		if got := throttle.ShouldNotify(step.report); got != step.want {
+			t.Errorf("run %d (%d failing): ShouldNotify = %v, want %v", i, len(step.report.Failures), got, step.want)
+		}
+	}
+
+	never := &NotifyThrottle{}
+	if !never.ShouldNotify(report(0, "nodes")) || never.ShouldNotify(report(1000, "nodes")) {
+		t.Error("expected Renotify 0 to send unchanged failures only once")
+	}
+}
@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BundleChecksumsFile lists the SHA-256 of every file in a bucket bundle. It is uploaded last,
+// so a bundle without it is incomplete.
+const BundleChecksumsFile = "SHA256SUMS"
+
+const (
+	// DefaultPartSize is the multipart chunk size for bucket uploads
+	DefaultPartSize int64 = 64 << 20
+	// MinPartSize is the smallest part S3 accepts for all but the last part
+	MinPartSize int64 = 5 << 20
+)
+
+// ObjectStore is a cloud object storage bucket that bundles are transferred through
+type ObjectStore interface {
+	// Upload writes size bytes from r to key, in parts when the object is larger than one part
+	Upload(ctx context.Context, key string, r io.Reader, size int64) error
+	// Download streams the object at key into w
+	Download(ctx context.Context, key string, w io.Writer) error
+}
+
+// BundleFile is one file of a bucket bundle
+type BundleFile struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// OpenBucket resolves an s3://, gs://, or az:// URL to a store and the key prefix inside it.
+// Credentials come from the environment the cloud's own CLI uses.
+func OpenBucket(rawURL string, partSize int64) (ObjectStore, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid bucket URL %s: %w", rawURL, err)
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("bucket URL %s has no bucket", rawURL)
+	}
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize {
+		return nil, "", fmt.Errorf("part size must be at least %s", FormatBytes(MinPartSize))
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		store, err := newS3Store(u.Host, partSize)
+		return store, prefix, err
+	case "gs":
+		store, err := newGCSStore(u.Host, partSize)
+		return store, prefix, err
+	case "az":
+		// az://<account>/<container>/<prefix>
+		container, rest, _ := strings.Cut(prefix, "/")
+		if container == "" {
+			return nil, "", fmt.Errorf("azure URL %s must name a container, e.g. az://account/container/path", rawURL)
+		}
+		store, err := newAzureStore(u.Host, container, partSize)
+		return store, rest, err
+	}
+	return nil, "", fmt.Errorf("unsupported bucket URL scheme %q; use s3://, gs://, or az://", u.Scheme)
+}
+
+// PushBundle uploads a file, or every file under a directory, below prefix and then writes the
+// checksums file that marks the bundle complete
+func PushBundle(ctx context.Context, store ObjectStore, prefix, src string) ([]BundleFile, error) {
+	files, err := bundleSources(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to upload in %s", src)
+	}
+
+	var uploaded []BundleFile
+	var sums strings.Builder
+	for idx, file := range files {
+		LogInfo("📤 Uploading %s (%d/%d, %s)", file.Name, idx+1, len(files), FormatBytes(file.Size))
+		sum, err := uploadFile(ctx, store, objectKey(prefix, file.Name), file.localPath, file.Size)
+		if err != nil {
+			return uploaded, err
+		}
+		uploaded = append(uploaded, BundleFile{Name: file.Name, Size: file.Size, SHA256: sum})
+		fmt.Fprintf(&sums, "%s  %s\n", sum, file.Name)
+	}
+
+	data := sums.String()
+	if err := store.Upload(ctx, objectKey(prefix, BundleChecksumsFile), strings.NewReader(data), int64(len(data))); err != nil {
+		return uploaded, fmt.Errorf("failed to upload %s: %w", BundleChecksumsFile, err)
+	}
+	return uploaded, nil
+}
+
+// PullBundle downloads the files listed in a bundle's checksums file into dest, verifying each
+// one. A non-empty include limits the download to names or base names matching those patterns.
+func PullBundle(ctx context.Context, store ObjectStore, prefix, dest string, include []string) ([]BundleFile, error) {
+	var sums strings.Builder
+	if err := store.Download(ctx, objectKey(prefix, BundleChecksumsFile), &sums); err != nil {
+		return nil, fmt.Errorf("failed to read %s; the bundle may be incomplete: %w", BundleChecksumsFile, err)
+	}
+	entries, err := parseBundleChecksums(sums.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []BundleFile
+	for _, entry := range entries {
+		if matchesAny(entry.Name, include) {
+			selected = append(selected, entry)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no files in the bundle match %s", strings.Join(include, ", "))
+	}
+	names := make([]string, len(selected))
+	for i, entry := range selected {
+		names[i] = entry.Name
+	}
+	if err := checkCaseCollisions("bundle files", names); err != nil {
+		return nil, err
+	}
+
+	var pulled []BundleFile
+	for idx, entry := range selected {
+		LogInfo("📥 Downloading %s (%d/%d)", entry.Name, idx+1, len(selected))
+		target := filepath.Join(dest, filepath.FromSlash(entry.Name))
+		if !withinDir(dest, target) {
+			return pulled, fmt.Errorf("%s lists %q, which points outside %s", BundleChecksumsFile, entry.Name, dest)
+		}
+		size, err := downloadFile(ctx, store, objectKey(prefix, entry.Name), LongPath(target), entry.SHA256)
+		if err != nil {
+			return pulled, err
+		}
+		entry.Size = size
+		pulled = append(pulled, entry)
+	}
+	return pulled, nil
+}
+
+type bundleSource struct {
+	Name      string
+	localPath string
+	Size      int64
+}
+
+// bundleSources lists the files to upload with their slash-separated names relative to src
+func bundleSources(src string) ([]bundleSource, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []bundleSource{{Name: filepath.Base(src), localPath: src, Size: info.Size()}}, nil
+	}
+
+	var files []bundleSource
+	err = filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == BundleChecksumsFile {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, bundleSource{Name: name, localPath: p, Size: fi.Size()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
+
+func uploadFile(ctx context.Context, store ObjectStore, key, localPath string, size int64) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if err := store.Upload(ctx, key, io.TeeReader(f, hash), size); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// downloadFile streams an object to a temporary file and renames it into place once its
+// checksum matches
+func downloadFile(ctx context.Context, store ObjectStore, key, target, want string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".part-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, hash)}
+	err = store.Download(ctx, key, counter)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return 0, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", key, want, got)
+	}
+	return counter.n, os.Rename(tmp.Name(), target)
+}
+
+// parseBundleChecksums reads sha256sum output, rejecting names that would escape the download
+// directory
+func parseBundleChecksums(data string) ([]BundleFile, error) {
+	var entries []BundleFile
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("malformed %s line: %q", BundleChecksumsFile, line)
+		}
+		// Backslashes are separators on Windows, so ..\..\x would escape there
+		name := strings.ReplaceAll(strings.TrimPrefix(fields[1], "*"), `\`, "/")
+		if clean := path.Clean(name); clean != name || path.IsAbs(name) || strings.HasPrefix(name, "../") || name == ".." || filepath.VolumeName(filepath.FromSlash(name)) != "" {
+			return nil, fmt.Errorf("%s lists an unsafe path %q", BundleChecksumsFile, name)
+		}
+		entries = append(entries, BundleFile{Name: name, SHA256: strings.ToLower(fields[0])})
+	}
+	return entries, nil
+}
+
+func matchesAny(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func objectKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// partLayout returns the number of parts and part size for size bytes, growing partSize when the
+// object would exceed the service's part limit
+func partLayout(size, partSize int64, maxParts int64) (int64, int64) {
+	if minSize := (size + maxParts - 1) / maxParts; minSize > partSize {
+		partSize = minSize
+	}
+	parts := (size + partSize - 1) / partSize
+	return parts, partSize
+}
+
+// readPart fills a buffer with the next part of an upload
+func readPart(r io.Reader, buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return buf[:n], err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// azureMaxBlocks is the most blocks a block blob may be committed from
+	azureMaxBlocks = 50000
+	// azureAPIVersion is the Blob service REST version requests are made against
+	azureAPIVersion = "2021-08-06"
+)
+
+// azureStore talks the Azure Blob service REST API, authenticating with a SAS token
+// (AZURE_STORAGE_SAS_TOKEN) or the account key (AZURE_STORAGE_KEY)
+type azureStore struct {
+	endpoint  *url.URL
+	account   string
+	container string
+	sasToken  string
+	key       []byte
+	partSize  int64
+	client    *http.Client
+}
+
+func newAzureStore(account, container string, partSize int64) (*azureStore, error) {
+	store := &azureStore{
+		endpoint:  &url.URL{Scheme: "https", Host: account + ".blob.core.windows.net"},
+		account:   account,
+		container: container,
+		sasToken:  strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+		partSize:  partSize,
+		client:    &http.Client{},
+	}
+	if store.sasToken != "" {
+		return store, nil
+	}
+	key := firstEnv("AZURE_STORAGE_KEY", "AZURE_STORAGE_ACCESS_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("no Azure Storage credentials: set AZURE_STORAGE_SAS_TOKEN or AZURE_STORAGE_KEY")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("AZURE_STORAGE_KEY is not valid base64: %w", err)
+	}
+	store.key = decoded
+	return store, nil
+}
+
+func (a *azureStore) Upload(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size <= a.partSize {
+		data, err := io.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return err
+		}
+		return a.send(ctx, http.MethodPut, key, nil, data, map[string]string{"x-ms-blob-type": "BlockBlob"}, nil)
+	}
+
+	parts, partSize := partLayout(size, a.partSize, azureMaxBlocks)
+	buf := make([]byte, partSize)
+	ids := make([]string, 0, parts)
+	for n := 1; int64(n) <= parts; n++ {
+		data, err := readPart(r, buf)
+		if err != nil {
+			return err
+		}
+		// Block IDs must all have the same length before encoding
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%06d", n)))
+		query := url.Values{"comp": {"block"}, "blockid": {id}}
+		if err := a.send(ctx, http.MethodPut, key, query, data, nil, nil); err != nil {
+			return fmt.Errorf("failed to upload block %d/%d: %w", n, parts, err)
+		}
+		ids = append(ids, id)
+		LogDebug("Uploaded block %d/%d of %s", n, parts, key)
+	}
+
+	// Uncommitted blocks are discarded by the service after a week, so a failed upload needs
+	// no cleanup
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: ids})
+	if err != nil {
+		return err
+	}
+	if err := a.send(ctx, http.MethodPut, key, url.Values{"comp": {"blocklist"}}, append([]byte(xml.Header), body...), nil, nil); err != nil {
+		return fmt.Errorf("failed to commit block list: %w", err)
+	}
+	return nil
+}
+
+func (a *azureStore) Download(ctx context.Context, key string, w io.Writer) error {
+	return a.send(ctx, http.MethodGet, key, nil, nil, nil, w)
+}
+
+// send makes one authenticated request, copying the response body into w when set
+func (a *azureStore) send(ctx context.Context, method, key string, query url.Values, body []byte, headers map[string]string, w io.Writer) error {
+	escapedPath := "/" + s3Escape(a.container, false) + "/" + s3Escape(key, true)
+	rawQuery := query.Encode()
+	if a.sasToken != "" {
+		rawQuery = strings.TrimPrefix(rawQuery+"&"+a.sasToken, "&")
+	}
+	target := a.endpoint.Scheme + "://" + a.endpoint.Host + escapedPath
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if body != nil {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	if a.sasToken == "" {
+		a.sign(req, escapedPath, query)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if xml.Unmarshal(bytes.TrimPrefix(msg, []byte("\xef\xbb\xbf")), &e) == nil && e.Code != "" {
+			return fmt.Errorf("%s %s: HTTP %d %s: %s", method, key, resp.StatusCode, e.Code, strings.TrimSpace(e.Message))
+		}
+		return fmt.Errorf("%s %s: HTTP %d", method, key, resp.StatusCode)
+	}
+	if w != nil {
+		_, err = io.Copy(w, resp.Body)
+	}
+	return err
+}
+
+// sign adds a Shared Key Authorization header
+func (a *azureStore) sign(req *http.Request, escapedPath string, query url.Values) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonical strings.Builder
+	for _, name := range msHeaders {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	canonical.WriteString("/" + a.account + escapedPath)
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		fmt.Fprintf(&canonical, "\n%s:%s", strings.ToLower(name), strings.Join(values, ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, superseded by x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonical.String(),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", a.account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+}
@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// s3MaxParts is the most parts an S3 or GCS XML multipart upload may have
+const s3MaxParts = 10000
+
+// s3RegionEndpoint answers bucket region lookups for any bucket
+var s3RegionEndpoint = "https://s3.amazonaws.com"
+
+// s3Store talks the S3 REST API. Google Cloud Storage speaks the same API through its XML
+// endpoint, so gs:// buckets use this store with an OAuth bearer token instead of SigV4.
+type s3Store struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	region    string
+	bearer    string
+	partSize  int64
+	client    *http.Client
+
+	// aws refreshes temporary credentials before they expire; nil keeps creds as they are
+	aws   *awsConfig
+	mu    sync.Mutex
+	creds awsCredentials
+}
+
+func newS3Store(bucket string, partSize int64) (*s3Store, error) {
+	client := &http.Client{}
+	cfg, err := loadAWSConfig(client)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	creds, err := cfg.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := &s3Store{bucket: bucket, region: cfg.region(), aws: cfg, creds: creds, partSize: partSize, client: client}
+	// Custom endpoints such as MinIO generally only support path-style addressing
+	endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	if endpoint != "" {
+		store.pathStyle = true
+		if store.region == "" {
+			store.region = "us-east-1"
+		}
+	} else {
+		// Requests signed for another region are rejected, so ask S3 where the bucket lives
+		if region := s3BucketRegion(ctx, client, s3RegionEndpoint, bucket); region != "" {
+			store.region = region
+		} else if store.region == "" {
+			store.region = "us-east-1"
+		}
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", store.region)
+	}
+	if store.endpoint, err = url.Parse(endpoint); err != nil || store.endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	LogDebug("Using S3 bucket %s in %s", bucket, store.region)
+	return store, nil
+}
+
+// s3BucketRegion returns the region S3 reports for a bucket in the x-amz-bucket-region header,
+// which it sends even when the caller may not read the bucket. It returns "" if the lookup fails.
+func s3BucketRegion(ctx context.Context, client *http.Client, endpoint, bucket string) string {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, strings.TrimSuffix(endpoint, "/")+"/"+s3Escape(bucket, false), nil)
+	if err != nil {
+		return ""
+	}
+	// The answer for a bucket elsewhere is a redirect, which carries the header itself
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		LogDebug("Failed to look up the region of bucket %s: %v", bucket, err)
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("X-Amz-Bucket-Region")
+}
+
+// credentials returns the signing credentials, fetching temporary ones again shortly before
+// they expire so long uploads keep working
+func (s *s3Store) credentials(ctx context.Context) (awsCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.aws != nil && s.creds.expiring() {
+		creds, err := s.aws.credentials(ctx)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to refresh AWS credentials: %w", err)
+		}
+		s.creds = creds
+	}
+	return s.creds, nil
+}
+
+func newGCSStore(bucket string, partSize int64) (*s3Store, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return nil, fmt.Errorf("no Google Cloud credentials: set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud")
+		}
+		token = strings.TrimSpace(string(out))
+	}
+	return &s3Store{
+		endpoint:  &url.URL{Scheme: "https", Host: "storage.googleapis.com"},
+		bucket:    bucket,
+		pathStyle: true,
+		bearer:    token,
+		partSize:  partSize,
+		client:    &http.Client{},
+	}, nil
+}
+
+func (s *s3Store) Upload(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size <= s.partSize {
+		data, err := io.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return err
+		}
+		resp, err := s.send(ctx, http.MethodPut, key, nil, data)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.sendXML(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, &initiated); err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	if err := s.uploadParts(ctx, key, initiated.UploadID, r, size); err != nil {
+		// Abort so the bucket does not keep billing for orphaned parts
+		if resp, abortErr := s.send(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil); abortErr == nil {
+			resp.Body.Close()
+		} else {
+			LogWarning("Failed to abort multipart upload of %s: %v", key, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *s3Store) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, size int64) error {
+	parts, partSize := partLayout(size, s.partSize, s3MaxParts)
+	buf := make([]byte, partSize)
+	completed := make([]s3CompletedPart, 0, parts)
+	for n := 1; int64(n) <= parts; n++ {
+		data, err := readPart(r, buf)
+		if err != nil {
+			return err
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+		resp, err := s.send(ctx, http.MethodPut, key, query, data)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d/%d: %w", n, parts, err)
+		}
+		resp.Body.Close()
+		completed = append(completed, s3CompletedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+		LogDebug("Uploaded part %d/%d of %s", n, parts, key)
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: completed})
+	if err != nil {
+		return err
+	}
+	// S3 can report a failed completion with HTTP 200 and an Error document
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := s.sendXML(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, &result); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("failed to complete multipart upload: %s: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+func (s *s3Store) Download(ctx context.Context, key string, w io.Writer) error {
+	resp, err := s.send(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (s *s3Store) sendXML(ctx context.Context, method, key string, query url.Values, body []byte, out interface{}) error {
+	resp, err := s.send(ctx, method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+// send signs and sends one request, returning an error for non-2xx responses
+func (s *s3Store) send(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	host, escapedPath := s.location(key)
+	rawQuery := s3CanonicalQuery(query)
+	target := fmt.Sprintf("%s://%s%s", s.endpoint.Scheme, host, escapedPath)
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if body != nil {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	if s.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+s.bearer)
+	} else {
+		creds, err := s.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		signAWSRequest(req, host, escapedPath, rawQuery, body, creds, s.region, "s3", time.Now().UTC())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if xml.Unmarshal(msg, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("%s %s: HTTP %d %s: %s", method, key, resp.StatusCode, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%s %s: HTTP %d", method, key, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// location returns the request host and escaped path for a key
+func (s *s3Store) location(key string) (string, string) {
+	host := s.endpoint.Host
+	escaped := "/" + s3Escape(key, true)
+	if s.pathStyle {
+		return host, "/" + s3Escape(s.bucket, false) + escaped
+	}
+	return s.bucket + "." + host, escaped
+}
+
+// signAWSRequest adds an AWS Signature Version 4 Authorization header
+func signAWSRequest(req *http.Request, host, escapedPath, rawQuery string, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-md5" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, escapedPath, rawQuery, canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but RFC 3986 unreserved characters, and slashes when
+// keepSlash is set
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, s3Escape(name, false)+"="+s3Escape(value, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeBucket serves the S3 object and multipart upload calls, or the Azure block blob calls
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	parts   map[string][]byte
+	auth    []string
+}
+
+func newFakeBucket() *fakeBucket {
+	return &fakeBucket{objects: map[string][]byte{}, parts: map[string][]byte{}}
+}
+
+func (f *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	body, _ := io.ReadAll(r.Body)
+	if md := r.Header.Get("Content-MD5"); md != "" {
+		sum := md5.Sum(body)
+		if md != base64.StdEncoding.EncodeToString(sum[:]) {
+			http.Error(w, "<Error><Code>BadDigest</Code></Error>", http.StatusBadRequest)
+			return
+		}
+	}
+	key := r.URL.Path
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>u1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && q.Has("partNumber"):
+		f.parts[key+"#"+q.Get("partNumber")] = body
+		w.Header().Set("ETag", `"`+q.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		var complete struct {
+			Parts []struct {
+				PartNumber string `xml:"PartNumber"`
+			} `xml:"Part"`
+		}
+		_ = xml.Unmarshal(body, &complete)
+		var data []byte
+		for _, p := range complete.Parts {
+			data = append(data, f.parts[key+"#"+p.PartNumber]...)
+		}
+		f.objects[key] = data
+		fmt.Fprint(w, `<CompleteMultipartUploadResult/>`)
+	case r.Method == http.MethodPut && q.Get("comp") == "block":
+		f.parts[key+"#"+q.Get("blockid")] = body
+	case r.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		_ = xml.Unmarshal(body, &list)
+		var data []byte
+		for _, id := range list.Latest {
+			data = append(data, f.parts[key+"#"+id]...)
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3BundleRoundTrip(t *testing.T) {
+	bucket := newFakeBucket()
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+	endpoint, _ := url.Parse(srv.URL)
+	store := &s3Store{
+		endpoint:  endpoint,
+		bucket:    "releases",
+		pathStyle: true,
+		region:    "us-east-1",
+		creds:     awsCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		partSize:  16,
+		client:    srv.Client(),
+	}
+	ctx := context.Background()
+
+	src := t.TempDir()
+	files := map[string]string{
+		"manifest.json":       `{"release_version":"3.22.2"}`,
+		"images/api.tar":      strings.Repeat("layer-data-", 10),
+		"models/llama/config": "small",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pushed, err := PushBundle(ctx, store, "dynamoai/3.22.2", src)
+	if err != nil {
+		t.Fatalf("PushBundle failed: %v", err)
+	}
+	if len(pushed) != 3 {
+		t.Fatalf("expected 3 uploaded files, got %+v", pushed)
+	}
+	if got := string(bucket.objects["/releases/dynamoai/3.22.2/images/api.tar"]); got != files["images/api.tar"] {
+		t.Fatalf("multipart upload reassembled %q", got)
+	}
+	if !strings.Contains(string(bucket.objects["/releases/dynamoai/3.22.2/SHA256SUMS"]), "  images/api.tar\n") {
+		t.Fatalf("checksums file missing entries: %s", bucket.objects["/releases/dynamoai/3.22.2/SHA256SUMS"])
+	}
+	for _, auth := range bucket.auth {
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Fatalf("request was not signed with SigV4: %q", auth)
+		}
+	}
+
+	dest := t.TempDir()
+	pulled, err := PullBundle(ctx, store, "dynamoai/3.22.2", dest, []string{"*.tar"})
+	if err != nil {
+		t.Fatalf("PullBundle failed: %v", err)
+	}
+	if len(pulled) != 1 || pulled[0].Name != "images/api.tar" {
+		t.Fatalf("expected only the image archive, got %+v", pulled)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "images", "api.tar")); string(data) != files["images/api.tar"] {
+		t.Fatalf("downloaded archive differs: %q", data)
+	}
+
+	bucket.objects["/releases/dynamoai/3.22.2/manifest.json"] = []byte("tampered")
+	if _, err := PullBundle(ctx, store, "dynamoai/3.22.2", t.TempDir(), []string{"manifest.json"}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+	if _, err := PullBundle(ctx, store, "missing", t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Fatalf("expected a missing bundle error, got %v", err)
+	}
+}
+
+func TestAzureBlockUpload(t *testing.T) {
+	bucket := newFakeBucket()
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+	endpoint, _ := url.Parse(srv.URL)
+	store := &azureStore{
+		endpoint:  endpoint,
+		account:   "dynamo",
+		container: "releases",
+		key:       []byte("account-key"),
+		partSize:  8,
+		client:    srv.Client(),
+	}
+	ctx := context.Background()
+
+	data := strings.Repeat("0123456789", 5)
+	if err := store.Upload(ctx, "images/api.tar", strings.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	var got bytes.Buffer
+	if err := store.Download(ctx, "images/api.tar", &got); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if got.String() != data {
+		t.Fatalf("block upload reassembled %q", got.String())
+	}
+	for _, auth := range bucket.auth {
+		if !strings.HasPrefix(auth, "SharedKey dynamo:") {
+			t.Fatalf("request was not signed with the account key: %q", auth)
+		}
+	}
+
+	store.key, store.sasToken = nil, "sv=2021-08-06&sig=abc"
+	bucket.auth = nil
+	if err := store.Upload(ctx, "small", strings.NewReader("tiny"), 4); err != nil {
+		t.Fatalf("Upload with SAS failed: %v", err)
+	}
+	if bucket.auth[0] != "" || string(bucket.objects["/releases/small"]) != "tiny" {
+		t.Fatalf("unexpected SAS upload: auth %q, objects %v", bucket.auth, bucket.objects)
+	}
+}
+
+func TestOpenBucket(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sig=abc")
+	// The bucket's own region wins over the configured one
+	regions := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+		w.WriteHeader(http.StatusMovedPermanently)
+	}))
+	defer regions.Close()
+	defaultEndpoint := s3RegionEndpoint
+	s3RegionEndpoint = regions.URL
+	defer func() { s3RegionEndpoint = defaultEndpoint }()
+
+	store, prefix, err := OpenBucket("s3://releases/dynamoai/3.22.2/", 0)
+	if err != nil {
+		t.Fatalf("OpenBucket failed: %v", err)
+	}
+	s3 := store.(*s3Store)
+	if prefix != "dynamoai/3.22.2" || s3.endpoint.Host != "s3.eu-west-1.amazonaws.com" || s3.region != "eu-west-1" || s3.partSize != DefaultPartSize {
+		t.Fatalf("unexpected S3 store %+v with prefix %q", s3, prefix)
+	}
+	if host, p := s3.location("a b/c"); host != "releases.s3.eu-west-1.amazonaws.com" || p != "/a%20b/c" {
+		t.Fatalf("unexpected virtual-hosted location %s%s", host, p)
+	}
+
+	store, prefix, err = OpenBucket("az://dynamo/releases/3.22.2", 0)
+	if err != nil {
+		t.Fatalf("OpenBucket failed: %v", err)
+	}
+	if az := store.(*azureStore); az.container != "releases" || prefix != "3.22.2" || az.sasToken != "sig=abc" {
+		t.Fatalf("unexpected Azure store %+v with prefix %q", az, prefix)
+	}
+
+	for _, bad := range []string{"ftp://host/path", "az://dynamo", "s3:///path"} {
+		if _, _, err := OpenBucket(bad, 0); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+	if _, _, err := OpenBucket("s3://releases", 1<<20); err == nil {
+		t.Error("expected a part size below the S3 minimum to be rejected")
+	}
+}
+
+func TestParseBundleChecksums(t *testing.T) {
+	sum := strings.Repeat("a", 64)
+	entries, err := parseBundleChecksums(sum + "  images/api.tar\n" + sum + " *manifest.json\n")
+	if err != nil {
+		t.Fatalf("parseBundleChecksums failed: %v", err)
+	}
+	names := []string{entries[0].Name, entries[1].Name}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "images/api.tar,manifest.json" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	for _, unsafe := range []string{"../etc/passwd", "/etc/passwd", "images/../../x", `..\..\x`, `images\..\..\x`, `\etc\passwd`} {
+		if _, err := parseBundleChecksums(sum + "  " + unsafe + "\n"); err == nil {
+			t.Errorf("expected %s to be rejected", unsafe)
+		}
+	}
+	if entries, err := parseBundleChecksums(sum + "  images\\api.tar\n"); err != nil || entries[0].Name != "images/api.tar" {
+		t.Errorf("expected backslashes to be read as separators, got %+v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Check result statuses shared by validation-style checks
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// CheckResult is the outcome of one validation step
+type CheckResult struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+// OIDCValidationOptions holds the values a customer plans to configure for SSO
+type OIDCValidationOptions struct {
+	IssuerURL    string
+	RedirectURIs []string
+}
+
+type oidcDiscovery struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+type jsonWebKeySet struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+	} `json:"keys"`
+}
+
+// NewOIDCHTTPClient returns an HTTP client that trusts the system roots plus an optional CA bundle
+func NewOIDCHTTPClient(caFile string, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		pemData, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// ValidateOIDC checks that an OIDC issuer serves a usable discovery document and JWKS, and that
+// the planned redirect URIs are well-formed. Later steps are skipped when an earlier one fails.
+func ValidateOIDC(ctx context.Context, client *http.Client, opts OIDCValidationOptions) []CheckResult {
+	var results []CheckResult
+	add := func(name, status, format string, args ...interface{}) {
+		results = append(results, CheckResult{Name: name, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	issuer, err := url.Parse(opts.IssuerURL)
+	switch {
+	case err != nil || issuer.Host == "":
+		add("issuer-url", CheckFail, "issuer %q is not an absolute URL", opts.IssuerURL)
+		return results
+	case issuer.Scheme != "https":
+		add("issuer-url", CheckFail, "issuer must use https, got %s", issuer.Scheme)
+		return results
+	case issuer.RawQuery != "" || issuer.Fragment != "":
+		add("issuer-url", CheckFail, "issuer must not contain a query or fragment")
+		return results
+	default:
+		add("issuer-url", CheckPass, "%s", opts.IssuerURL)
+	}
+
+	discoveryURL := strings.TrimSuffix(opts.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var doc oidcDiscovery
+	if err := getJSON(ctx, client, discoveryURL, &doc); err != nil {
+		add("discovery", CheckFail, "%v", err)
+		return results
+	}
+	add("discovery", CheckPass, "served at %s", discoveryURL)
+
+	// Token validation compares the iss claim byte for byte, so a trailing slash mismatch breaks login
+	if doc.Issuer != opts.IssuerURL {
+		add("issuer-match", CheckFail, "discovery document reports issuer %q, configured %q", doc.Issuer, opts.IssuerURL)
+	} else {
+		add("issuer-match", CheckPass, "issuer matches discovery document")
+	}
+
+	var missing []string
+	for field, value := range map[string]string{
+		"authorization_endpoint": doc.AuthorizationEndpoint,
+		"token_endpoint":         doc.TokenEndpoint,
+		"jwks_uri":               doc.JWKSURI,
+	} {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		add("endpoints", CheckFail, "discovery document is missing %s", strings.Join(missing, ", "))
+	} else {
+		add("endpoints", CheckPass, "authorization, token, and JWKS endpoints advertised")
+	}
+
+	if len(doc.ResponseTypesSupported) > 0 && !slices.Contains(doc.ResponseTypesSupported, "code") {
+		add("response-types", CheckFail, "authorization code flow not supported (response_types_supported: %s)", strings.Join(doc.ResponseTypesSupported, ", "))
+	}
+	if len(doc.ScopesSupported) > 0 && !slices.Contains(doc.ScopesSupported, "openid") {
+		add("scopes", CheckWarn, "scopes_supported does not list openid")
+	}
+
+	if doc.JWKSURI != "" {
+		var jwks jsonWebKeySet
+		if err := getJSON(ctx, client, doc.JWKSURI, &jwks); err != nil {
+			add("jwks", CheckFail, "%v", err)
+		} else if len(jwks.Keys) == 0 {
+			add("jwks", CheckFail, "JWKS at %s contains no keys", doc.JWKSURI)
+		} else {
+			add("jwks", CheckPass, "%d signing keys retrieved", len(jwks.Keys))
+			if slices.Contains(doc.IDTokenSigningAlgValuesSupported, "RS256") || len(doc.IDTokenSigningAlgValuesSupported) == 0 {
+				hasRSA := false
+				for _, k := range jwks.Keys {
+					if k.Kty == "RSA" {
+						hasRSA = true
+					}
+				}
+				if !hasRSA {
+					add("jwks-rsa", CheckWarn, "no RSA keys published; RS256-signed ID tokens cannot be verified")
+				}
+			}
+		}
+	}
+
+	if len(opts.RedirectURIs) == 0 {
+		add("redirect-uris", CheckWarn, "no redirect URIs given; pass --redirect-uri to validate them")
+	}
+	for _, uri := range opts.RedirectURIs {
+		if msg := validateRedirectURI(uri); msg != "" {
+			add("redirect-uri", CheckFail, "%s: %s", uri, msg)
+		} else {
+			add("redirect-uri", CheckPass, "%s", uri)
+		}
+	}
+
+	return results
+}
+
+// validateRedirectURI returns a problem description, or an empty string when the URI is valid
+func validateRedirectURI(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Sprintf("not a valid URL: %v", err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "must be an absolute URL"
+	}
+	if u.Fragment != "" {
+		return "must not contain a fragment"
+	}
+	if strings.Contains(raw, "*") {
+		return "wildcards are not allowed by most identity providers"
+	}
+	if u.Scheme == "http" {
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "must use https (http is only allowed for localhost)"
+		}
+	} else if u.Scheme != "https" {
+		return fmt.Sprintf("unsupported scheme %s", u.Scheme)
+	}
+	return ""
+}
+
+// getJSON fetches a URL and decodes a JSON response body
+func getJSON(ctx context.Context, client *http.Client, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", rawURL, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s did not return valid JSON: %v", rawURL, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateOIDC(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"issuer":                   server.URL,
+				"authorization_endpoint":   server.URL + "/auth",
+				"token_endpoint":           server.URL + "/token",
+				"jwks_uri":                 server.URL + "/jwks",
+				"response_types_supported": []string{"code"},
+			})
+		case "/jwks":
+			w.Write([]byte(`{"keys":[{"kid":"1","kty":"RSA","use":"sig"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	statuses := func(results []CheckResult) map[string]string {
+		m := map[string]string{}
+		for _, r := range results {
+			m[r.Name] = r.Status
+		}
+		return m
+	}
+
+	results := ValidateOIDC(context.Background(), server.Client(), OIDCValidationOptions{
+		IssuerURL:    server.URL,
+		RedirectURIs: []string{"https://dynamo.example.com/callback"},
+	})
+	for _, r := range results {
+		if r.Status != CheckPass {
+			t.Errorf("expected %s to pass, got %s: %s", r.Name, r.Status, r.Message)
+		}
+	}
+
+	// A trailing slash on the configured issuer does not match the discovery document
+	got := statuses(ValidateOIDC(context.Background(), server.Client(), OIDCValidationOptions{
+		IssuerURL:    server.URL + "/",
+		RedirectURIs: []string{"http://dynamo.example.com/callback#x"},
+	}))
+	if got["issuer-match"] != CheckFail {
+		t.Errorf("expected issuer-match to fail, got %s", got["issuer-match"])
+	}
+	if got["redirect-uri"] != CheckFail {
+		t.Errorf("expected redirect-uri to fail, got %s", got["redirect-uri"])
+	}
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	tests := map[string]bool{
+		"https://dynamo.example.com/callback": true,
+		"http://localhost:8080/callback":      true,
+		"http://127.0.0.1/callback":           true,
+		"http://dynamo.example.com/callback":  false,
+		"https://*.example.com/callback":      false,
+		"/callback":                           false,
+	}
+	for uri, valid := range tests {
+		if got := validateRedirectURI(uri) == ""; got != valid {
+			t.Errorf("validateRedirectURI(%q) valid = %v, want %v", uri, got, valid)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// RequiredCRD is a CustomResourceDefinition the release creates objects of, and the API version
+// the charts use, which must be served
+type RequiredCRD struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// OperatorRequirement describes an operator the Dynamo charts depend on
+type OperatorRequirement struct {
+	Name string        `json:"name"`
+	CRDs []RequiredCRD `json:"crds"`
+	// Version is a semver range the installed operator must satisfy
+	Version string `json:"version,omitempty"`
+	// Image is the last path segment of the operator's controller image, used to read its
+	// version when the CRDs are not labeled with one
+	Image string `json:"image,omitempty"`
+	// Optional operators are only needed by some features; missing ones are warnings
+	Optional bool `json:"optional,omitempty"`
+}
+
+// OperatorRequirementsFile is the YAML format accepted by `cluster operators check --config`
+type OperatorRequirementsFile struct {
+	Operators []OperatorRequirement `json:"operators"`
+}
+
+// DefaultOperatorRequirements are the operators the Dynamo charts depend on
+var DefaultOperatorRequirements = []OperatorRequirement{
+	{
+		Name: "cert-manager",
+		CRDs: []RequiredCRD{
+			{Name: "certificates.cert-manager.io", Version: "v1"},
+			{Name: "issuers.cert-manager.io", Version: "v1"},
+			{Name: "clusterissuers.cert-manager.io", Version: "v1"},
+		},
+		Version: ">= 1.12.0",
+		Image:   "cert-manager-controller",
+	},
+	{
+		Name: "prometheus-operator",
+		CRDs: []RequiredCRD{
+			{Name: "servicemonitors.monitoring.coreos.com", Version: "v1"},
+			{Name: "podmonitors.monitoring.coreos.com", Version: "v1"},
+			{Name: "prometheusrules.monitoring.coreos.com", Version: "v1"},
+		},
+		Version:  ">= 0.65.0",
+		Image:    "prometheus-operator",
+		Optional: true,
+	},
+	{
+		Name: "kserve",
+		CRDs: []RequiredCRD{
+			{Name: "inferenceservices.serving.kserve.io", Version: "v1beta1"},
+			{Name: "servingruntimes.serving.kserve.io", Version: "v1alpha1"},
+		},
+		Version: ">= 0.11.0",
+		Image:   "kserve-controller",
+	},
+	{
+		Name: "kuberay",
+		CRDs: []RequiredCRD{
+			{Name: "rayclusters.ray.io", Version: "v1"},
+			{Name: "rayservices.ray.io", Version: "v1"},
+		},
+		Version:  ">= 1.1.0",
+		Image:    "kuberay-operator",
+		Optional: true,
+	},
+}
+
+// Operator actions reported when a requirement is not met
+const (
+	OperatorActionInstall = "install"
+	OperatorActionUpgrade = "upgrade"
+)
+
+// OperatorStatus is the result of checking one operator requirement
+type OperatorStatus struct {
+	Name     string
+	Required string `json:",omitempty"`
+	// Installed is the detected operator version, empty when it could not be determined
+	Installed   string `json:",omitempty"`
+	Optional    bool   `json:",omitempty"`
+	Status      string
+	Action      string   `json:",omitempty"`
+	MissingCRDs []string `json:",omitempty"`
+	Message     string
+}
+
+// installedCRD is what the check needs from a CustomResourceDefinition
+type installedCRD struct {
+	Served []string
+	// Version is the operator version the CRD is labeled with, if any
+	Version string
+}
+
+// LoadOperatorRequirements reads operator requirements from a YAML or JSON file
+func LoadOperatorRequirements(path string) ([]OperatorRequirement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operator requirements: %w", err)
+	}
+
+	var file OperatorRequirementsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse operator requirements %s: %w", path, err)
+	}
+	if len(file.Operators) == 0 {
+		return nil, fmt.Errorf("operator requirements %s list no operators", path)
+	}
+	for _, op := range file.Operators {
+		if op.Name == "" || len(op.CRDs) == 0 {
+			return nil, fmt.Errorf("operator requirements %s: every operator needs a name and at least one CRD", path)
+		}
+		if op.Version != "" {
+			if _, err := semver.NewConstraint(op.Version); err != nil {
+				return nil, fmt.Errorf("operator %s: invalid version range %q: %v", op.Name, op.Version, err)
+			}
+		}
+	}
+	return file.Operators, nil
+}
+
+// CheckOperators reports which operators are missing or too old for the Dynamo charts
+func (kc *KubernetesChecker) CheckOperators(ctx context.Context, requirements []OperatorRequirement) ([]OperatorStatus, error) {
+	items, _, err := kc.listCustomResources(ctx, crdGVR, "", "")
+	if err != nil {
+		return nil, err
+	}
+	crds := make(map[string]installedCRD, len(items))
+	for _, item := range items {
+		crds[item.GetName()] = crdInfo(item)
+	}
+
+	// Controller images are the fallback source of operator versions
+	deployments, err := kc.clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	var images []string
+	for _, d := range deployments.Items {
+		for _, c := range d.Spec.Template.Spec.Containers {
+			images = append(images, c.Image)
+		}
+	}
+
+	statuses := make([]OperatorStatus, 0, len(requirements))
+	for _, req := range requirements {
+		statuses = append(statuses, evaluateOperator(req, crds, images))
+	}
+	return statuses, nil
+}
+
+// SummarizeOperators condenses operator statuses into one line, returning an error when a
+// required operator must be installed or upgraded
+func SummarizeOperators(statuses []OperatorStatus) (string, error) {
+	var failed, warned []string
+	for _, s := range statuses {
+		switch s.Status {
+		case CheckFail:
+			failed = append(failed, fmt.Sprintf("%s (%s)", s.Name, s.Message))
+		case CheckWarn:
+			warned = append(warned, s.Name)
+		}
+	}
+	if len(failed) > 0 {
+		summary := fmt.Sprintf("%d of %d operators must be installed or upgraded: %s", len(failed), len(statuses), strings.Join(failed, "; "))
+		return summary, fmt.Errorf("%s", summary)
+	}
+	if len(warned) > 0 {
+		return fmt.Sprintf("%d operators ready, check %s", len(statuses)-len(warned), strings.Join(warned, ", ")), nil
+	}
+	return fmt.Sprintf("all %d operators installed at supported versions", len(statuses)), nil
+}
+
+// crdInfo reads the served versions and operator version label of a CRD
+func crdInfo(item unstructured.Unstructured) installedCRD {
+	var info installedCRD
+	versions, _, _ := unstructured.NestedSlice(item.Object, "spec", "versions")
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		if served, _, _ := unstructured.NestedBool(m, "served"); served && name != "" {
+			info.Served = append(info.Served, name)
+		}
+	}
+
+	// cert-manager labels its CRDs with the release, prometheus-operator annotates them
+	labels, annotations := item.GetLabels(), item.GetAnnotations()
+	switch {
+	case labels["app.kubernetes.io/version"] != "":
+		info.Version = labels["app.kubernetes.io/version"]
+	case annotations["operator.prometheus.io/version"] != "":
+		info.Version = annotations["operator.prometheus.io/version"]
+	case labels["helm.sh/chart"] != "":
+		chart := labels["helm.sh/chart"]
+		info.Version = chart[strings.LastIndex(chart, "-")+1:]
+	}
+	return info
+}
+
+// evaluateOperator checks one requirement against the installed CRDs and controller images
+func evaluateOperator(req OperatorRequirement, crds map[string]installedCRD, images []string) OperatorStatus {
+	status := OperatorStatus{Name: req.Name, Required: req.Version, Optional: req.Optional, Status: CheckPass}
+	failStatus := CheckFail
+	if req.Optional {
+		failStatus = CheckWarn
+	}
+
+	var unserved []string
+	for _, crd := range req.CRDs {
+		info, ok := crds[crd.Name]
+		if !ok {
+			status.MissingCRDs = append(status.MissingCRDs, crd.Name)
+			continue
+		}
+		if status.Installed == "" && info.Version != "" {
+			if _, err := parseNodeVersion(info.Version); err == nil {
+				status.Installed = info.Version
+			}
+		}
+		if crd.Version != "" && !containsString(info.Served, crd.Version) {
+			unserved = append(unserved, fmt.Sprintf("%s %s", crd.Name, crd.Version))
+		}
+	}
+	if status.Installed == "" {
+		status.Installed = operatorImageVersion(req.Image, images)
+	}
+
+	switch {
+	case len(status.MissingCRDs) == len(req.CRDs):
+		status.Status, status.Action = failStatus, OperatorActionInstall
+		status.Message = "not installed"
+		if req.Optional {
+			status.Message += " (optional)"
+		}
+		return status
+	case len(status.MissingCRDs) > 0:
+		status.Status, status.Action = failStatus, OperatorActionUpgrade
+		status.Message = "missing CRDs " + strings.Join(status.MissingCRDs, ", ")
+		return status
+	case len(unserved) > 0:
+		status.Status, status.Action = failStatus, OperatorActionUpgrade
+		status.Message = "API versions not served: " + strings.Join(unserved, ", ")
+		return status
+	}
+
+	if req.Version == "" {
+		status.Message = "installed"
+		return status
+	}
+	if status.Installed == "" {
+		status.Status = CheckWarn
+		status.Message = fmt.Sprintf("installed, but its version could not be determined (need %s)", req.Version)
+		return status
+	}
+	if problem := versionOutside(req.Name, status.Installed, req.Version); problem != "" {
+		status.Status, status.Action = failStatus, OperatorActionUpgrade
+		status.Message = problem
+		return status
+	}
+	status.Message = fmt.Sprintf("%s satisfies %s", status.Installed, req.Version)
+	return status
+}
+
+// operatorImageVersion returns the tag of the first image whose last path segment is the
+// operator's image name, or an empty string when none has a version tag
+func operatorImageVersion(name string, images []string) string {
+	if name == "" {
+		return ""
+	}
+	for _, image := range images {
+		repo, tag, _ := splitImageReference(image)
+		if path.Base(repo) != name || tag == "" {
+			continue
+		}
+		if _, err := parseNodeVersion(tag); err == nil {
+			return tag
+		}
+	}
+	return ""
+}
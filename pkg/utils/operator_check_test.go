@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLoadOperatorRequirements(t *testing.T) {
+	requirements, err := LoadOperatorRequirements("../../examples/operators.yaml")
+	if err != nil {
+		t.Fatalf("LoadOperatorRequirements returned error: %v", err)
+	}
+	if len(requirements) != 3 || requirements[0].Name != "cert-manager" || !requirements[2].Optional {
+		t.Errorf("Unexpected requirements: %+v", requirements)
+	}
+}
+
+func TestCRDInfo(t *testing.T) {
+	crd := unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "certificates.cert-manager.io",
+			"labels": map[string]interface{}{"app.kubernetes.io/version": "v1.14.4"},
+		},
+		"spec": map[string]interface{}{"versions": []interface{}{
+			map[string]interface{}{"name": "v1alpha2", "served": false},
+			map[string]interface{}{"name": "v1", "served": true},
+		}},
+	}}
+	info := crdInfo(crd)
+	if !reflect.DeepEqual(info.Served, []string{"v1"}) || info.Version != "v1.14.4" {
+		t.Errorf("crdInfo() = %+v", info)
+	}
+
+	crd.SetLabels(map[string]string{"helm.sh/chart": "kuberay-operator-1.1.1"})
+	if info := crdInfo(crd); info.Version != "1.1.1" {
+		t.Errorf("Expected the version from the chart label, got %q", info.Version)
+	}
+}
+
+func TestEvaluateOperator(t *testing.T) {
+	certManager := DefaultOperatorRequirements[0]
+	kuberay := DefaultOperatorRequirements[3]
+	served := func(version, label string) installedCRD {
+		return installedCRD{Served: []string{version}, Version: label}
+	}
+	images := []string{"quay.io/jetstack/cert-manager-controller:v1.11.0", "docker.io/library/nginx:1.25"}
+
+	tests := []struct {
+		name   string
+		req    OperatorRequirement
+		crds   map[string]installedCRD
+		status string
+		action string
+	}{
+		{
+			name:   "missing required operator",
+			req:    certManager,
+			crds:   map[string]installedCRD{},
+			status: CheckFail,
+			action: OperatorActionInstall,
+		},
+		{
+			name:   "missing optional operator",
+			req:    kuberay,
+			crds:   map[string]installedCRD{},
+			status: CheckWarn,
+			action: OperatorActionInstall,
+		},
+		{
+			name: "version from CRD label in range",
+			req:  certManager,
+			crds: map[string]installedCRD{
+				"certificates.cert-manager.io":   served("v1", "v1.14.4"),
+				"issuers.cert-manager.io":        served("v1", "v1.14.4"),
+				"clusterissuers.cert-manager.io": served("v1", "v1.14.4"),
+			},
+			status: CheckPass,
+		},
+		{
+			name: "version from controller image too old",
+			req:  certManager,
+			crds: map[string]installedCRD{
+				"certificates.cert-manager.io":   served("v1", ""),
+				"issuers.cert-manager.io":        served("v1", ""),
+				"clusterissuers.cert-manager.io": served("v1", ""),
+			},
+			status: CheckFail,
+			action: OperatorActionUpgrade,
+		},
+		{
+			name: "API version not served",
+			req:  kuberay,
+			crds: map[string]installedCRD{
+				"rayclusters.ray.io": served("v1alpha1", "0.6.0"),
+				"rayservices.ray.io": served("v1alpha1", "0.6.0"),
+			},
+			status: CheckWarn,
+			action: OperatorActionUpgrade,
+		},
+		{
+			name: "unknown version",
+			req:  kuberay,
+			crds: map[string]installedCRD{
+				"rayclusters.ray.io": served("v1", ""),
+				"rayservices.ray.io": served("v1", ""),
+			},
+			status: CheckWarn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateOperator(tt.req, tt.crds, images)
+			if got.Status != tt.status || got.Action != tt.action {
+				t.Errorf("evaluateOperator() = %s/%s (%s), want %s/%s", got.Status, got.Action, got.Message, tt.status, tt.action)
+			}
+		})
+	}
+}
+
+func TestSummarizeOperators(t *testing.T) {
+	summary, err := SummarizeOperators([]OperatorStatus{
+		{Name: "cert-manager", Status: CheckPass},
+		{Name: "kuberay", Status: CheckWarn},
+	})
+	if err != nil || summary != "1 operators ready, check kuberay" {
+		t.Errorf("SummarizeOperators() = %q, %v", summary, err)
+	}
+
+	_, err = SummarizeOperators([]OperatorStatus{{Name: "kserve", Status: CheckFail, Message: "not installed"}})
+	if err == nil || err.Error() != "1 of 1 operators must be installed or upgraded: kserve (not installed)" {
+		t.Errorf("Expected a failure naming kserve, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// windowsMaxPath is the length past which Windows APIs need the extended-length \\?\ form;
+// directories are limited to 248 characters so a file name still fits under MAX_PATH (260)
+const windowsMaxPath = 248
+
+// caseInsensitiveFS reports whether the local filesystem usually ignores case, as NTFS and
+// APFS do by default
+func caseInsensitiveFS() bool {
+	return runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+}
+
+// stateDir picks the directory for dynactl's config and state. Unix keeps ~/.dynactl; Windows
+// uses %APPDATA%\dynactl unless an existing ~/.dynactl from an earlier release is found.
+func stateDir(goos, home, appData string, exists func(string) bool) string {
+	legacy := filepath.Join(home, ".dynactl")
+	if goos != "windows" || appData == "" || exists(legacy) {
+		return legacy
+	}
+	return filepath.Join(appData, "dynactl")
+}
+
+// systemConfigPath returns the machine-wide config file administrators can provision, used
+// when the user has no config of their own. Only Windows has one, under %ProgramData%.
+func systemConfigPath(goos, programData string) string {
+	if goos != "windows" || programData == "" {
+		return ""
+	}
+	return filepath.Join(programData, "dynactl", configFileName)
+}
+
+// LongPath returns an absolute path in the extended-length \\?\ form on Windows when it is too
+// long for the legacy APIs helper libraries may call, so deeply nested chart and model files
+// can be written. Other platforms get the path unchanged.
+func LongPath(p string) string {
+	if runtime.GOOS != "windows" {
+		return p
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	return longPath(p, runtime.GOOS)
+}
+
+func longPath(p, goos string) string {
+	if goos != "windows" || len(p) < windowsMaxPath || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	p = strings.ReplaceAll(p, "/", `\`)
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		return `\\?\UNC\` + p[2:]
+	case len(p) >= 3 && p[1] == ':' && p[2] == '\\':
+		return `\\?\` + p
+	}
+	return p
+}
+
+// SafeFileName replaces characters Windows does not allow in file names, such as the colon in
+// a sha256: digest, so pulled artifacts get the same names on every platform
+func SafeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// CaseCollisions groups names that differ only in letter case; on a case-insensitive
+// filesystem each group would be written to the same file
+func CaseCollisions(names []string) [][]string {
+	groups := map[string][]string{}
+	for _, name := range names {
+		key := strings.ToLower(filepath.Clean(filepath.FromSlash(name)))
+		if !containsString(groups[key], name) {
+			groups[key] = append(groups[key], name)
+		}
+	}
+	var collisions [][]string
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Strings(group)
+			collisions = append(collisions, group)
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i][0] < collisions[j][0] })
+	return collisions
+}
+
+// checkCaseCollisions fails on a case-insensitive filesystem when names would overwrite each
+// other, and only warns elsewhere since the files may later be copied to one
+func checkCaseCollisions(what string, names []string) error {
+	collisions := CaseCollisions(names)
+	if len(collisions) == 0 {
+		return nil
+	}
+	descriptions := make([]string, 0, len(collisions))
+	for _, group := range collisions {
+		descriptions = append(descriptions, strings.Join(group, " and "))
+	}
+	msg := fmt.Sprintf("%s differ only in letter case: %s", what, strings.Join(descriptions, "; "))
+	if caseInsensitiveFS() {
+		return fmt.Errorf("%s; they would overwrite each other on this filesystem", msg)
+	}
+	LogWarning("%s; they will overwrite each other if copied to Windows or macOS", msg)
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pathExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStateDir(t *testing.T) {
+	none := func(string) bool { return false }
+	home := filepath.Join("home", "ops")
+	appData := filepath.Join("Users", "ops", "AppData", "Roaming")
+
+	if got, want := stateDir("linux", home, "", none), filepath.Join(home, ".dynactl"); got != want {
+		t.Fatalf("linux: expected %s, got %s", want, got)
+	}
+	if got, want := stateDir("windows", home, appData, none), filepath.Join(appData, "dynactl"); got != want {
+		t.Fatalf("windows: expected %s, got %s", want, got)
+	}
+	legacy := func(p string) bool { return p == filepath.Join(home, ".dynactl") }
+	if got, want := stateDir("windows", home, appData, legacy), filepath.Join(home, ".dynactl"); got != want {
+		t.Fatalf("windows with an existing ~/.dynactl: expected %s, got %s", want, got)
+	}
+}
+
+func TestSystemConfigPath(t *testing.T) {
+	if got := systemConfigPath("linux", "/ProgramData"); got != "" {
+		t.Fatalf("only Windows has a system config, got %s", got)
+	}
+	if got, want := systemConfigPath("windows", "ProgramData"), filepath.Join("ProgramData", "dynactl", "config.yaml"); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	deep := `C:\Users\ops\bundle\` + strings.Repeat(`nested\`, 40) + "model.tar"
+	tests := []struct {
+		name string
+		path string
+		goos string
+		want string
+	}{
+		{"short path", `C:\Users\ops\bundle\model.tar`, "windows", `C:\Users\ops\bundle\model.tar`},
+		{"drive path", deep, "windows", `\\?\` + deep},
+		{"forward slashes", strings.ReplaceAll(deep, `\`, "/"), "windows", `\\?\` + deep},
+		{"UNC path", `\\fileserver\share\` + deep[3:], "windows", `\\?\UNC\fileserver\share\` + deep[3:]},
+		{"already extended", `\\?\` + deep, "windows", `\\?\` + deep},
+		{"other platforms", "/" + strings.Repeat("nested/", 40), "linux", "/" + strings.Repeat("nested/", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longPath(tt.path, tt.goos); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	if got, want := SafeFileName("model-sha256:abc.tar"), "model-sha256_abc.tar"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got := SafeFileName("dynamoai-base-1.1.2.tgz"); got != "dynamoai-base-1.1.2.tgz" {
+		t.Fatalf("valid names must be unchanged, got %s", got)
+	}
+}
+
+func TestCaseCollisions(t *testing.T) {
+	names := []string{"charts/App.tgz", "charts/app.tgz", "images/api.tar", "README", "readme", "README"}
+	want := [][]string{{"README", "readme"}, {"charts/App.tgz", "charts/app.tgz"}}
+	if got := CaseCollisions(names); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := CaseCollisions([]string{"a.tar", "b.tar"}); got != nil {
+		t.Fatalf("expected no collisions, got %v", got)
+	}
+}
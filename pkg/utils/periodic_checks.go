@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Checks available to scheduled runs of `cluster check`
+const (
+	PeriodicCheckVersion   = "version"
+	PeriodicCheckNodes     = "nodes"
+	PeriodicCheckStorage   = "storage"
+	PeriodicCheckCerts     = "certs"
+	PeriodicCheckLicense   = "license"
+	PeriodicCheckClock     = "clock"
+	PeriodicCheckDisk      = "disk"
+	PeriodicCheckOperators = "operators"
+	PeriodicCheckHA        = "ha"
+)
+
+// AllPeriodicChecks lists the checks run when none are selected
+var AllPeriodicChecks = []string{PeriodicCheckVersion, PeriodicCheckNodes, PeriodicCheckStorage, PeriodicCheckCerts, PeriodicCheckLicense}
+
+// AvailablePeriodicChecks lists every check `cluster check` can run; the ones beyond
+// AllPeriodicChecks run only when selected or enabled by a check profile
+var AvailablePeriodicChecks = append(slices.Clone(AllPeriodicChecks), PeriodicCheckClock, PeriodicCheckDisk, PeriodicCheckOperators, PeriodicCheckHA)
+
+// certExpiryWarningDays is how far ahead scheduled checks warn about expiring certificates
+const certExpiryWarningDays = 14
+
+// PeriodicCheckOptions holds the thresholds scheduled checks grade against
+type PeriodicCheckOptions struct {
+	Storage        StorageThresholds
+	CertExpiryDays int
+	MaxClockSkew   time.Duration
+	MinImageFsFree int64
+	// LicenseWarning is how close to its expiry the license is reported; 0 uses
+	// DefaultLicenseExpiryWarning
+	LicenseWarning time.Duration
+}
+
+// DefaultPeriodicCheckOptions are the thresholds used without a check profile
+var DefaultPeriodicCheckOptions = PeriodicCheckOptions{
+	Storage:        DefaultStorageThresholds,
+	CertExpiryDays: certExpiryWarningDays,
+	MaxClockSkew:   DefaultMaxClockSkew,
+	MinImageFsFree: DefaultMinImageFsFree,
+	LicenseWarning: DefaultLicenseExpiryWarning,
+}
+
+// CheckNodeReadiness reports nodes whose Ready condition is not true
+func (kc *KubernetesChecker) CheckNodeReadiness(ctx context.Context) (string, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	var notReady []string
+	for i := range nodes {
+		if !isNodeReady(&nodes[i]) {
+			notReady = append(notReady, nodes[i].Name)
+		}
+	}
+	if len(notReady) > 0 {
+		return fmt.Sprintf("%d of %d nodes NotReady: %s", len(notReady), len(nodes), strings.Join(notReady, ", ")),
+			fmt.Errorf("nodes not ready")
+	}
+	return fmt.Sprintf("all %d nodes Ready", len(nodes)), nil
+}
+
+// RunPeriodicChecks runs the selected checks and returns one result per check. The certs and ha
+// checks need a namespace and are skipped without one.
+func (kc *KubernetesChecker) RunPeriodicChecks(ctx context.Context, namespace string, checks []string, opts PeriodicCheckOptions) []CheckResult {
+	var results []CheckResult
+	add := func(name, message string, err error) {
+		status := CheckPass
+		if err != nil {
+			status = CheckFail
+			if message == "" {
+				message = err.Error()
+			}
+		}
+		results = append(results, CheckResult{Name: name, Status: status, Message: message})
+	}
+
+	for _, check := range checks {
+		switch check {
+		case PeriodicCheckVersion:
+			version, err := kc.ProbeServerVersion(ctx)
+			add(check, version, err)
+		case PeriodicCheckNodes:
+			msg, err := kc.CheckNodeReadiness(ctx)
+			add(check, msg, err)
+		case PeriodicCheckStorage:
+			storage, err := kc.CheckStorageCapacity(ctx, opts.Storage)
+			if err != nil {
+				add(check, "", err)
+				continue
+			}
+			results = append(results, CheckResult{Name: check, Status: storage.Status, Message: storage.Message})
+		case PeriodicCheckCerts:
+			if namespace == "" {
+				LogDebug("Skipping certs check: no namespace given")
+				continue
+			}
+			certs, err := kc.CheckCertificateExpiry(ctx, namespace, opts.CertExpiryDays)
+			if err != nil {
+				add(check, "", err)
+				continue
+			}
+			var problems []string
+			for _, c := range certs {
+				if c.Status != CertStatusOK {
+					problems = append(problems, fmt.Sprintf("%s/%s (%s)", c.Source, c.Name, c.Status))
+				}
+			}
+			if len(problems) > 0 {
+				add(check, fmt.Sprintf("certificates expiring within %d days or invalid: %s", opts.CertExpiryDays, strings.Join(problems, ", ")),
+					fmt.Errorf("certificates need attention"))
+			} else {
+				add(check, fmt.Sprintf("%d certificates valid", len(certs)), nil)
+			}
+		case PeriodicCheckLicense:
+			if namespace == "" {
+				LogDebug("Skipping license check: no namespace given")
+				continue
+			}
+			warning := opts.LicenseWarning
+			if warning == 0 {
+				warning = DefaultLicenseExpiryWarning
+			}
+			results = append(results, kc.CheckLicenseSecret(ctx, namespace, time.Now(), warning))
+		case PeriodicCheckClock:
+			msg, err := kc.CheckClockSkew(ctx, opts.MaxClockSkew)
+			add(check, msg, err)
+		case PeriodicCheckDisk:
+			msg, err := kc.CheckNodeDisk(ctx, opts.MinImageFsFree)
+			add(check, msg, err)
+		case PeriodicCheckOperators:
+			statuses, err := kc.CheckOperators(ctx, DefaultOperatorRequirements)
+			if err != nil {
+				add(check, "", err)
+				continue
+			}
+			msg, err := SummarizeOperators(statuses)
+			add(check, msg, err)
+		case PeriodicCheckHA:
+			if namespace == "" {
+				LogDebug("Skipping ha check: no namespace given")
+				continue
+			}
+			ha, err := kc.CheckHAReadiness(ctx, namespace, nil)
+			if err != nil {
+				add(check, "", err)
+				continue
+			}
+			results = append(results, CheckResult{Name: check, Status: ha.Status, Message: haSummary(ha)})
+		}
+	}
+	return results
+}
+
+// NewCheckReport builds a report for the cluster from a set of results
+func (kc *KubernetesChecker) NewCheckReport(results []CheckResult) CheckReport {
+	report := CheckReport{Cluster: kc.config.Host, Time: time.Now().UTC(), Results: results}
+	for _, r := range results {
+		if r.Status == CheckFail {
+			report.Failures = append(report.Failures, r)
+		}
+	}
+	return report
+}
+
+// ParsePeriodicChecks validates a list of check names, returning all checks when empty
+func ParsePeriodicChecks(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return AllPeriodicChecks, nil
+	}
+	var checks []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(AvailablePeriodicChecks, name) {
+			return nil, fmt.Errorf("unknown check %q (valid: %s)", name, strings.Join(AvailablePeriodicChecks, ", "))
+		}
+		checks = append(checks, name)
+	}
+	return checks, nil
+}
+
+// haSummary is the HA verdict with the findings that are not passing
+func haSummary(ha *HAReadinessResult) string {
+	var problems []string
+	for _, f := range ha.Findings {
+		if f.Status != CheckPass {
+			problems = append(problems, fmt.Sprintf("%s (%s)", f.Subject, f.Message))
+		}
+	}
+	if len(problems) == 0 {
+		return ha.Verdict()
+	}
+	return fmt.Sprintf("%s: %s", ha.Verdict(), strings.Join(problems, ", "))
+}
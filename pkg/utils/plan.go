@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Plan action verbs
+const (
+	PlanActionPull  = "pull"
+	PlanActionPush  = "push"
+	PlanActionSkip  = "skip"
+	PlanActionScale = "scale"
+	PlanActionPost  = "post"
+)
+
+// DefaultPlanTransferRate is the transfer rate plans assume when estimating durations: 25 MiB/s,
+// roughly a 200 Mbit/s link
+const DefaultPlanTransferRate int64 = 25 << 20
+
+// PlanAction is one step of a plan. Transfers carry a size and an estimated duration; other
+// actions leave them zero.
+type PlanAction struct {
+	Step        int    `json:"step"`
+	Action      string `json:"action"`
+	Kind        string `json:"kind"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	// SizeBytes is zero when the size is unknown or does not apply
+	SizeBytes         int64         `json:"size_bytes,omitempty"`
+	EstimatedDuration time.Duration `json:"estimated_duration_ns,omitempty"`
+	Note              string        `json:"note,omitempty"`
+}
+
+// Plan is the ordered list of actions a command would take, produced by its dry run, for review
+// or a change advisory board submission
+type Plan struct {
+	Operation string    `json:"operation"`
+	CreatedAt time.Time `json:"created_at"`
+	// TransferRate is the rate, in bytes per second, durations were estimated with
+	TransferRate           int64         `json:"transfer_rate_bytes_per_second,omitempty"`
+	Actions                []PlanAction  `json:"actions"`
+	TotalBytes             int64         `json:"total_bytes"`
+	TotalEstimatedDuration time.Duration `json:"total_estimated_duration_ns"`
+}
+
+// NewPlan starts an empty plan for an operation. A transfer rate of zero leaves durations
+// unestimated.
+func NewPlan(operation string, transferRate int64) *Plan {
+	return &Plan{Operation: operation, CreatedAt: time.Now().UTC(), TransferRate: transferRate, Actions: []PlanAction{}}
+}
+
+// Add appends an action, numbering it and estimating how long a transfer of its size takes
+// unless it already has an estimate. Skipped actions keep their size for reference but count
+// toward neither total.
+func (p *Plan) Add(action PlanAction) {
+	action.Step = len(p.Actions) + 1
+	if action.Action == PlanActionSkip {
+		action.EstimatedDuration = 0
+		p.Actions = append(p.Actions, action)
+		return
+	}
+	if action.EstimatedDuration == 0 && action.SizeBytes > 0 && p.TransferRate > 0 {
+		action.EstimatedDuration = p.TransferDuration(action.SizeBytes)
+	}
+	p.Actions = append(p.Actions, action)
+	p.TotalBytes += action.SizeBytes
+	p.TotalEstimatedDuration += action.EstimatedDuration
+}
+
+// TransferDuration estimates how long moving size bytes takes at the plan's transfer rate
+func (p *Plan) TransferDuration(size int64) time.Duration {
+	if p.TransferRate <= 0 {
+		return 0
+	}
+	seconds := float64(size) / float64(p.TransferRate)
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
+
+// ParseTransferRate reads a rate in bytes per second given as a quantity, such as 25Mi or 100M
+func ParseTransferRate(value string) (int64, error) {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	return q.Value(), nil
+}
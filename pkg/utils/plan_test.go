@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanAddNumbersAndTotals(t *testing.T) {
+	plan := NewPlan("artifacts mirror", 10<<20)
+	plan.Add(PlanAction{Action: PlanActionPull, Kind: ArtifactTypeContainerImage, SizeBytes: 100 << 20})
+	plan.Add(PlanAction{Action: PlanActionSkip, Kind: ArtifactTypeContainerImage, SizeBytes: 50 << 20})
+	plan.Add(PlanAction{Action: PlanActionScale, Kind: "Deployment"})
+
+	for i, a := range plan.Actions {
+		if a.Step != i+1 {
+			t.Errorf("expected action %d to be step %d, got %d", i, i+1, a.Step)
+		}
+	}
+	if plan.Actions[0].EstimatedDuration != 10*time.Second {
+		t.Errorf("expected 100 MiB at 10 MiB/s to take 10s, got %s", plan.Actions[0].EstimatedDuration)
+	}
+	if plan.Actions[1].EstimatedDuration != 0 || plan.Actions[1].SizeBytes != 50<<20 {
+		t.Errorf("expected the skip to keep its size without a duration, got %+v", plan.Actions[1])
+	}
+	if plan.TotalBytes != 100<<20 || plan.TotalEstimatedDuration != 10*time.Second {
+		t.Errorf("expected totals of only the pull, got %d bytes and %s", plan.TotalBytes, plan.TotalEstimatedDuration)
+	}
+}
+
+func TestPlanWithoutTransferRate(t *testing.T) {
+	plan := NewPlan("artifacts mirror", 0)
+	plan.Add(PlanAction{Action: PlanActionPush, SizeBytes: 1 << 30})
+	if plan.TotalBytes != 1<<30 || plan.TotalEstimatedDuration != 0 {
+		t.Errorf("expected bytes without a duration, got %d bytes and %s", plan.TotalBytes, plan.TotalEstimatedDuration)
+	}
+}
+
+func TestParseTransferRate(t *testing.T) {
+	for value, want := range map[string]int64{"25Mi": 25 << 20, "100M": 100_000_000, "1024": 1024} {
+		got, err := ParseTransferRate(value)
+		if err != nil || got != want {
+			t.Errorf("ParseTransferRate(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	if _, err := ParseTransferRate("fast"); err == nil {
+		t.Error("expected an invalid rate to fail")
+	}
+}
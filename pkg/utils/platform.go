@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Platforms the cluster checks adapt to, chosen with --platform
+const (
+	PlatformAuto       = "auto"
+	PlatformKubernetes = "kubernetes"
+	PlatformOpenShift  = "openshift"
+)
+
+// Platforms lists the valid --platform values
+var Platforms = []string{PlatformAuto, PlatformKubernetes, PlatformOpenShift}
+
+// openShiftAPIGroups are served only by OpenShift; any one of them identifies it
+var openShiftAPIGroups = []string{"route.openshift.io", "security.openshift.io", "config.openshift.io"}
+
+var (
+	routeGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+	sccGVR   = schema.GroupVersionResource{Group: "security.openshift.io", Version: "v1", Resource: "securitycontextconstraints"}
+)
+
+// OpenShift's internal image registry, reachable in-cluster at its Service and from outside at
+// the default route once the registry operator exposes it
+const (
+	openShiftRegistryNamespace = "openshift-image-registry"
+	openShiftRegistryRoute     = "default-route"
+	OpenShiftInternalRegistry  = "image-registry.openshift-image-registry.svc:5000"
+)
+
+// platform is the --platform setting applied to every KubernetesChecker
+var platform = PlatformAuto
+
+// SetPlatform sets the platform the checks assume; auto detects it from the cluster
+func SetPlatform(p string) error {
+	if !containsString(Platforms, p) {
+		return fmt.Errorf("unknown platform %q, expected one of %v", p, Platforms)
+	}
+	platform = p
+	return nil
+}
+
+// Platform returns the platform to check against: the --platform setting, or when it is auto,
+// openshift if the cluster serves OpenShift's API groups and kubernetes otherwise
+func (kc *KubernetesChecker) Platform() string {
+	if kc.platform != "" {
+		return kc.platform
+	}
+	kc.platform = platform
+	if kc.platform == PlatformAuto {
+		kc.platform = PlatformKubernetes
+		groups, err := kc.discovery.ServerGroups()
+		if err != nil {
+			LogDebug("Assuming plain Kubernetes, API groups not discovered: %v", err)
+			return kc.platform
+		}
+		served := map[string]bool{}
+		for _, g := range groups.Groups {
+			served[g.Name] = true
+		}
+		if isOpenShift(served) {
+			kc.platform = PlatformOpenShift
+		}
+		LogDebug("Detected platform %s", kc.platform)
+	}
+	return kc.platform
+}
+
+// isOpenShift reports whether any OpenShift-only API group is served
+func isOpenShift(apiGroups map[string]bool) bool {
+	for _, g := range openShiftAPIGroups {
+		if apiGroups[g] {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenShiftRegistryHost returns the external host of OpenShift's internal registry, or its
+// in-cluster Service address when the registry's default route is not enabled
+func (kc *KubernetesChecker) OpenShiftRegistryHost(ctx context.Context) (string, error) {
+	route, err := kc.dynamicClient.Resource(routeGVR).Namespace(openShiftRegistryNamespace).Get(ctx, openShiftRegistryRoute, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return OpenShiftInternalRegistry, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get the image registry route: %v", err)
+	}
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	if host == "" {
+		return OpenShiftInternalRegistry, nil
+	}
+	return host, nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// PluginPrefix is the filename prefix of executables exposed as dynactl subcommands
+const PluginPrefix = "dynactl-"
+
+// Plugin is an executable on PATH named dynactl-<name>
+type Plugin struct {
+	Name string
+	Path string
+	// ShadowedBy is the path of an earlier plugin with the same name, which is the one run
+	ShadowedBy string `json:",omitempty"`
+}
+
+// PluginExitError carries a plugin's non-zero exit code so dynactl can exit with it
+type PluginExitError struct {
+	Plugin string
+	Code   int
+}
+
+func (e *PluginExitError) Error() string {
+	return fmt.Sprintf("plugin %s exited with status %d", e.Plugin, e.Code)
+}
+
+// ListPlugins returns every plugin on PATH in lookup order. Later plugins with the same name
+// as an earlier one are included with ShadowedBy set.
+func ListPlugins() []Plugin {
+	var plugins []Plugin
+	first := map[string]string{}
+	seenDirs := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" || seenDirs[dir] {
+			continue
+		}
+		seenDirs[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := pluginName(e.Name())
+			if !ok || e.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			p := Plugin{Name: name, Path: path, ShadowedBy: first[name]}
+			if p.ShadowedBy == "" {
+				first[name] = path
+			}
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// FindPlugin resolves command-line arguments to a plugin, preferring the longest match so
+// `dynactl foo bar` runs dynactl-foo-bar before dynactl-foo. It returns the plugin's path and
+// the arguments to pass it.
+func FindPlugin(args []string) (string, []string, bool) {
+	var parts []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		parts = append(parts, arg)
+	}
+	for n := len(parts); n > 0; n-- {
+		path, err := exec.LookPath(PluginPrefix + strings.Join(parts[:n], "-"))
+		if err == nil {
+			return path, args[n:], true
+		}
+	}
+	return "", nil, false
+}
+
+// PluginEnv returns the environment passed to plugins: the caller's environment plus the
+// context dynactl would use itself
+func PluginEnv() []string {
+	env := os.Environ()
+	set := func(key, value string) {
+		if value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		set("DYNACTL_BIN", exe)
+	}
+	if path, err := ConfigPath(); err == nil {
+		set("DYNACTL_CONFIG", path)
+	}
+	set("DYNACTL_PROFILE", awsProfileName())
+	if path, err := credentialStorePath(); err == nil {
+		set("DYNACTL_CREDENTIAL_STORE", path)
+	}
+	set("DYNACTL_LOG_LEVEL", logLevelName(CurrentLogLevel))
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	set("DYNACTL_KUBECONFIG", strings.Join(loadingRules.GetLoadingPrecedence(), string(filepath.ListSeparator)))
+	if raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).RawConfig(); err == nil {
+		set("DYNACTL_KUBE_CONTEXT", raw.CurrentContext)
+	}
+	return env
+}
+
+// RunPlugin runs a plugin with the terminal's standard streams. A non-zero exit is returned as
+// a *PluginExitError.
+func RunPlugin(ctx context.Context, path string, args []string) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = PluginEnv()
+	LogDebug("Running plugin %s %s", path, strings.Join(args, " "))
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &PluginExitError{Plugin: filepath.Base(path), Code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run plugin %s: %w", path, err)
+	}
+	return nil
+}
+
+// pluginName strips the prefix and, on Windows, the executable extension from a filename
+func pluginName(filename string) (string, bool) {
+	name, ok := strings.CutPrefix(filename, PluginPrefix)
+	if !ok {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !isWindowsExecutableExt(ext) {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name, name != ""
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return isWindowsExecutableExt(filepath.Ext(path))
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
+
+func isWindowsExecutableExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".exe", ".bat", ".cmd", ".com":
+		return true
+	}
+	return false
+}
+
+func logLevelName(level LogLevel) string {
+	switch level {
+	case LogLevelError:
+		return "error"
+	case LogLevelWarning:
+		return "warning"
+	case LogLevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are shell scripts")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	hello := writePlugin(t, first, "dynactl-hello", `test "$DYNACTL_LOG_LEVEL" = warning && exit 3`)
+	nested := writePlugin(t, first, "dynactl-hello-world", "exit 0")
+	shadowed := writePlugin(t, second, "dynactl-hello", "exit 0")
+	if err := os.WriteFile(filepath.Join(first, "dynactl-notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", first+string(filepath.ListSeparator)+second)
+
+	plugins := ListPlugins()
+	if len(plugins) != 3 {
+		t.Fatalf("expected 3 plugins, got %+v", plugins)
+	}
+	for _, p := range plugins {
+		if p.Path == shadowed && p.ShadowedBy != hello {
+			t.Errorf("expected %s to be shadowed by %s, got %+v", shadowed, hello, p)
+		}
+		if p.Path != shadowed && p.ShadowedBy != "" {
+			t.Errorf("unexpected shadowing %+v", p)
+		}
+	}
+
+	path, args, ok := FindPlugin([]string{"hello", "world", "--flag", "x"})
+	if !ok || path != nested || strings.Join(args, " ") != "--flag x" {
+		t.Errorf("FindPlugin preferred %s %v", path, args)
+	}
+	path, args, ok = FindPlugin([]string{"hello", "there"})
+	if !ok || path != hello || strings.Join(args, " ") != "there" {
+		t.Errorf("FindPlugin fell back to %s %v", path, args)
+	}
+	if _, _, ok := FindPlugin([]string{"missing"}); ok {
+		t.Error("expected no plugin for an unknown name")
+	}
+
+	SetLogLevel(0)
+	err := RunPlugin(context.Background(), hello, nil)
+	var exitErr *PluginExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("expected exit status 3 from the plugin, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Pod Security Admission levels, from the pod-security.kubernetes.io labels of a namespace
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+const (
+	podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+	// openShiftUIDRangeAnnotation holds the UIDs OpenShift runs the namespace's pods with
+	openShiftUIDRangeAnnotation = "openshift.io/sa.scc.uid-range"
+)
+
+// openShiftRestrictedSCCs run pods with an arbitrary UID from the namespace's range
+var openShiftRestrictedSCCs = []string{"restricted", "restricted-v2"}
+
+// PodAdmissionResult is whether a namespace's pod security admission lets Dynamo's pods in:
+// Pod Security Admission levels on Kubernetes, SecurityContextConstraints on OpenShift
+type PodAdmissionResult struct {
+	Namespace string
+	Platform  string
+	Status    string
+	Message   string
+	// Enforce, Audit, and Warn are the namespace's Pod Security Admission levels (Kubernetes)
+	Enforce string `json:",omitempty"`
+	Audit   string `json:",omitempty"`
+	Warn    string `json:",omitempty"`
+	// ServiceAccount is the account whose usable SCCs were checked, and UIDRange the UIDs its
+	// pods get under a restricted SCC (OpenShift)
+	ServiceAccount string   `json:",omitempty"`
+	UIDRange       string   `json:",omitempty"`
+	UsableSCCs     []string `json:",omitempty"`
+}
+
+// CheckPodAdmission checks what pod security admission will allow in the namespace. On
+// Kubernetes that is the Pod Security Admission level enforced on it; on OpenShift it is which
+// SecurityContextConstraints the ServiceAccount that runs the pods may use.
+func (kc *KubernetesChecker) CheckPodAdmission(ctx context.Context, namespace, serviceAccount string) (*PodAdmissionResult, error) {
+	ns, err := kc.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+	}
+	result := &PodAdmissionResult{Namespace: namespace, Platform: kc.Platform()}
+	if result.Platform != PlatformOpenShift {
+		result.Enforce = ns.Labels[podSecurityLabelPrefix+"enforce"]
+		result.Audit = ns.Labels[podSecurityLabelPrefix+"audit"]
+		result.Warn = ns.Labels[podSecurityLabelPrefix+"warn"]
+		result.Status, result.Message = podSecurityVerdict(result.Enforce)
+		return result, nil
+	}
+
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	result.ServiceAccount = namespace + "/" + serviceAccount
+	result.UIDRange = ns.Annotations[openShiftUIDRangeAnnotation]
+	subject, err := NewAccessSubject("", nil, result.ServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	sccs, _, err := kc.listCustomResources(ctx, sccGVR, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, scc := range sccs {
+		usable := sccGrants(scc, subject)
+		if !usable {
+			status, err := kc.reviewAccess(ctx, subject, authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Group:     sccGVR.Group,
+				Resource:  sccGVR.Resource,
+				Name:      scc.GetName(),
+				Verb:      "use",
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to check use of SCC %s: %v", scc.GetName(), err)
+			}
+			usable = status.Allowed
+		}
+		if usable {
+			result.UsableSCCs = append(result.UsableSCCs, scc.GetName())
+		}
+	}
+	sort.Strings(result.UsableSCCs)
+	result.Status, result.Message = sccVerdict(result.UsableSCCs, result.UIDRange)
+	return result, nil
+}
+
+// podSecurityVerdict grades the enforced Pod Security Admission level. Restricted is a warning:
+// it rejects pods unless they run as non-root, drop all capabilities, and set a seccomp profile.
+func podSecurityVerdict(enforce string) (string, string) {
+	switch enforce {
+	case "", PodSecurityPrivileged:
+		return CheckPass, "no Pod Security Admission restrictions enforced"
+	case PodSecurityBaseline:
+		return CheckPass, "baseline Pod Security Admission enforced"
+	case PodSecurityRestricted:
+		return CheckWarn, "restricted Pod Security Admission enforced: pods must run as non-root, drop all capabilities, and set a seccomp profile; set the chart's securityContext values accordingly or label the namespace baseline"
+	default:
+		return CheckWarn, fmt.Sprintf("unknown Pod Security Admission level %q enforced", enforce)
+	}
+}
+
+// sccGrants reports whether an SCC lists the subject in its users or groups, the way OpenShift
+// grants SCCs besides the RBAC use verb
+func sccGrants(scc unstructured.Unstructured, subject AccessSubject) bool {
+	users, _, _ := unstructured.NestedStringSlice(scc.Object, "users")
+	if containsString(users, subject.User) {
+		return true
+	}
+	groups, _, _ := unstructured.NestedStringSlice(scc.Object, "groups")
+	for _, g := range subject.Groups {
+		if containsString(groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// sccVerdict grades the SCCs a ServiceAccount may use. Without any, its pods are not admitted;
+// with only the restricted ones, they run as an arbitrary UID from the namespace's range.
+func sccVerdict(usable []string, uidRange string) (string, string) {
+	if len(usable) == 0 {
+		return CheckFail, "the ServiceAccount may not use any SecurityContextConstraints; its pods will not be admitted"
+	}
+	for _, scc := range usable {
+		if !containsString(openShiftRestrictedSCCs, scc) {
+			return CheckPass, "may use SCCs " + strings.Join(usable, ", ")
+		}
+	}
+	message := fmt.Sprintf("only restricted SCCs (%s): pods run as an arbitrary UID", strings.Join(usable, ", "))
+	if uidRange != "" {
+		message += " from " + uidRange
+	}
+	return CheckWarn, message + "; images that need a fixed UID need nonroot-v2 or anyuid granted to the ServiceAccount"
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodSecurityVerdict(t *testing.T) {
+	for enforce, want := range map[string]string{
+		"":                    CheckPass,
+		PodSecurityPrivileged: CheckPass,
+		PodSecurityBaseline:   CheckPass,
+		PodSecurityRestricted: CheckWarn,
+		"strict":              CheckWarn,
+	} {
+		if status, _ := podSecurityVerdict(enforce); status != want {
+			t.Errorf("enforce=%q: expected %s, got %s", enforce, want, status)
+		}
+	}
+}
+
+func TestSCCVerdict(t *testing.T) {
+	subject, _ := NewAccessSubject("", nil, "dynamo/default")
+	scc := func(users, groups []any) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{"users": users, "groups": groups}}
+	}
+	if !sccGrants(scc([]any{"system:serviceaccount:dynamo:default"}, nil), subject) {
+		t.Error("Expected an SCC listing the ServiceAccount to be usable")
+	}
+	if !sccGrants(scc(nil, []any{"system:serviceaccounts:dynamo"}), subject) {
+		t.Error("Expected an SCC listing the namespace's ServiceAccount group to be usable")
+	}
+	if sccGrants(scc([]any{"system:serviceaccount:other:default"}, []any{"system:cluster-admins"}), subject) {
+		t.Error("Expected an SCC for other subjects not to be usable")
+	}
+
+	if status, _ := sccVerdict(nil, ""); status != CheckFail {
+		t.Errorf("Expected no usable SCCs to fail, got %s", status)
+	}
+	status, message := sccVerdict([]string{"restricted-v2"}, "1000680000/10000")
+	if status != CheckWarn || !strings.Contains(message, "1000680000/10000") {
+		t.Errorf("Expected only restricted-v2 to warn with the UID range, got %s: %s", status, message)
+	}
+	if status, _ := sccVerdict([]string{"nonroot-v2", "restricted-v2"}, ""); status != CheckPass {
+		t.Errorf("Expected nonroot-v2 to pass, got %s", status)
+	}
+}
+
+func TestSetPlatform(t *testing.T) {
+	defer SetPlatform(PlatformAuto)
+	if err := SetPlatform(PlatformOpenShift); err != nil {
+		t.Fatalf("SetPlatform returned error: %v", err)
+	}
+	kc := &KubernetesChecker{}
+	if got := kc.Platform(); got != PlatformOpenShift {
+		t.Errorf("Expected --platform openshift to skip detection, got %s", got)
+	}
+	if err := SetPlatform("eks"); err == nil {
+		t.Error("Expected an unknown platform to be rejected")
+	}
+	if !isOpenShift(map[string]bool{"route.openshift.io": true}) || isOpenShift(map[string]bool{"apps": true}) {
+		t.Error("Expected OpenShift to be detected from its API groups only")
+	}
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Pod health issue categories, besides the triage ones
+const (
+	IssuePending  = "pending"
+	IssueNoLimits = "no-limits"
+)
+
+// podPendingGrace is how long a pod may stay Pending, pulling images or waiting for a node to
+// scale up, before the pods check fails it
+const podPendingGrace = 5 * time.Minute
+
+// PodHealthIssue is a problem with one pod or container, graded pass/warn/fail
+type PodHealthIssue struct {
+	TriageIssue
+	Status string
+}
+
+// PodHealthReport is the outcome of the pods check for a namespace
+type PodHealthReport struct {
+	Namespace string
+	Pods      int
+	// Healthy counts the pods that are running with every container ready, or that completed
+	Healthy int
+	Issues  []PodHealthIssue
+}
+
+// Counts returns how many issues there are per category
+func (r *PodHealthReport) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, issue := range r.Issues {
+		counts[issue.Category]++
+	}
+	return counts
+}
+
+// Failed reports whether any issue failed the check
+func (r *PodHealthReport) Failed() bool {
+	for _, issue := range r.Issues {
+		if issue.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPodHealth summarizes the health of a namespace's pods: containers in CrashLoopBackOff or
+// ImagePullBackOff, Pending pods with the reason they are stuck, OOMKilled terminations newer
+// than since, and containers without CPU or memory limits.
+func (kc *KubernetesChecker) CheckPodHealth(ctx context.Context, namespace string, since time.Duration) (*PodHealthReport, error) {
+	pods, err := kc.listPods(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %v", namespace, err)
+	}
+	return evaluatePodHealth(namespace, pods, time.Now(), since), nil
+}
+
+// evaluatePodHealth grades the pods as of now: back-offs fail, as do pods Pending for longer than
+// podPendingGrace; OOMKills and missing limits warn
+func evaluatePodHealth(namespace string, pods []corev1.Pod, now time.Time, since time.Duration) *PodHealthReport {
+	report := &PodHealthReport{Namespace: namespace, Pods: len(pods)}
+	cutoff := now.Add(-since)
+	for _, pod := range pods {
+		// A pod backing off already says why it is Pending
+		backingOff := false
+		for _, issue := range podStatusIssues(pod, cutoff) {
+			status := CheckFail
+			if issue.Category == IssueOOMKilled {
+				status = CheckWarn
+			} else {
+				backingOff = true
+			}
+			report.Issues = append(report.Issues, PodHealthIssue{TriageIssue: issue, Status: status})
+		}
+		if pod.Status.Phase == corev1.PodPending && !backingOff {
+			report.Issues = append(report.Issues, podPendingIssue(pod, now))
+		}
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			for _, c := range pod.Spec.Containers {
+				if missing := missingLimits(c); len(missing) > 0 {
+					report.Issues = append(report.Issues, PodHealthIssue{
+						TriageIssue: TriageIssue{
+							Category: IssueNoLimits,
+							Reason:   "NoLimits",
+							Object:   "Pod/" + pod.Name + "/" + c.Name,
+							Count:    1,
+							Message:  fmt.Sprintf("container %s has no %s limit", c.Name, strings.Join(missing, " or ")),
+						},
+						Status: CheckWarn,
+					})
+				}
+			}
+		}
+		if podHealthy(&pod) {
+			report.Healthy++
+		}
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i].Status, report.Issues[j].Status
+		return a != b && worseStatus(a, b) == a
+	})
+	return report
+}
+
+// podPendingIssue explains why a pod is Pending: the scheduler's reason while it is unscheduled,
+// otherwise the reason its containers are waiting, such as ContainerCreating on a volume mount
+func podPendingIssue(pod corev1.Pod, now time.Time) PodHealthIssue {
+	issue := PodHealthIssue{
+		TriageIssue: TriageIssue{Category: IssuePending, Reason: "Pending", Object: "Pod/" + pod.Name, Count: 1, LastSeen: pod.CreationTimestamp.Time},
+		Status:      CheckFail,
+	}
+	age := now.Sub(pod.CreationTimestamp.Time)
+	if age < podPendingGrace {
+		issue.Status = CheckWarn
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			if cond.Reason != "" {
+				issue.Reason = cond.Reason
+			}
+			issue.Message = strings.TrimSpace(cond.Message)
+		}
+	}
+	if issue.Message == "" {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if w := cs.State.Waiting; w != nil {
+				issue.Reason = w.Reason
+				issue.Message = strings.TrimSpace(fmt.Sprintf("container %s: %s %s", cs.Name, w.Reason, w.Message))
+				break
+			}
+		}
+	}
+	if issue.Message == "" {
+		issue.Message = "waiting to be scheduled"
+	}
+	issue.Message += fmt.Sprintf(" (pending %s)", age.Round(time.Second))
+	return issue
+}
+
+// missingLimits lists which of the cpu and memory limits a container does not set
+func missingLimits(c corev1.Container) []string {
+	var missing []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := c.Resources.Limits[name]; !ok {
+			missing = append(missing, string(name))
+		}
+	}
+	return missing
+}
+
+// podHealthy reports whether a pod completed, or runs with every container ready
+func podHealthy(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true
+	case corev1.PodRunning:
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady {
+				return cond.Status == corev1.ConditionTrue
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvaluatePodHealth(t *testing.T) {
+	now := time.Now()
+	limits := corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}}
+	pod := func(name string, phase corev1.PodPhase, age time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Resources: limits}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	healthy := pod("api", corev1.PodRunning, time.Hour)
+	healthy.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	healthy.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:                 "main",
+		RestartCount:         3,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Hour))}},
+	}}
+	healthy.Spec.Containers = append(healthy.Spec.Containers, corev1.Container{Name: "sidecar"})
+
+	crashing := pod("worker", corev1.PodRunning, time.Hour)
+	crashing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "main",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+
+	unschedulable := pod("gpu", corev1.PodPending, time.Hour)
+	unschedulable.Status.Conditions = []corev1.PodCondition{{
+		Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable",
+		Message: "0/3 nodes are available: 3 Insufficient nvidia.com/gpu.",
+	}}
+	pulling := pod("ui", corev1.PodPending, time.Minute)
+	pulling.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "main",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}}
+	backingOff := pod("guard", corev1.PodPending, time.Hour)
+	backingOff.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "main",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}}
+
+	report := evaluatePodHealth("dynamo", []corev1.Pod{healthy, crashing, unschedulable, pulling, backingOff}, now, 24*time.Hour)
+	if report.Pods != 5 || report.Healthy != 1 {
+		t.Errorf("Expected 1 of 5 pods healthy, got %d of %d", report.Healthy, report.Pods)
+	}
+	if !report.Failed() {
+		t.Error("Expected the report to fail")
+	}
+
+	counts := report.Counts()
+	want := map[string]int{IssueOOMKilled: 1, IssueNoLimits: 1, IssueCrashLoop: 1, IssueImagePull: 1, IssuePending: 2}
+	for category, n := range want {
+		if counts[category] != n {
+			t.Errorf("Expected %d %s issues, got %d (%v)", n, category, counts[category], counts)
+		}
+	}
+
+	statuses := map[string]string{}
+	for i, issue := range report.Issues {
+		statuses[issue.Object] = issue.Status
+		if i > 0 && issue.Status == CheckFail && report.Issues[i-1].Status != CheckFail {
+			t.Errorf("Expected failures first, got %+v", report.Issues)
+		}
+		if issue.Object == "Pod/gpu" && (issue.Reason != "Unschedulable" || !strings.Contains(issue.Message, "Insufficient nvidia.com/gpu")) {
+			t.Errorf("Expected the scheduler's reason for the GPU pod, got %s: %s", issue.Reason, issue.Message)
+		}
+	}
+	if statuses["Pod/gpu"] != CheckFail || statuses["Pod/ui"] != CheckWarn {
+		t.Errorf("Expected a long Pending pod to fail and a new one to warn, got %v", statuses)
+	}
+	if statuses["Pod/api/sidecar"] != CheckWarn {
+		t.Errorf("Expected the sidecar without limits to warn, got %v", statuses)
+	}
+
+	// An OOMKill before the window is not reported
+	if counts := evaluatePodHealth("dynamo", []corev1.Pod{healthy}, now, 30*time.Minute).Counts(); counts[IssueOOMKilled] != 0 {
+		t.Errorf("Expected no OOMKills in the last 30m, got %v", counts)
+	}
+}
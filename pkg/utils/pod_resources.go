@@ -0,0 +1,76 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodEffectiveRequests returns the requests the scheduler reserves for a pod, matching
+// `kubectl describe node`: the larger of the app containers (plus sidecars) and the largest init
+// container, plus pod overhead. Ephemeral containers cannot set resources and are not counted.
+func PodEffectiveRequests(pod *corev1.Pod) corev1.ResourceList {
+	return podEffectiveResources(pod, func(c *corev1.Container) corev1.ResourceList { return c.Resources.Requests }, false)
+}
+
+// PodEffectiveLimits returns the pod's limits computed the same way as PodEffectiveRequests.
+// Overhead is only added to resources that already have a limit.
+func PodEffectiveLimits(pod *corev1.Pod) corev1.ResourceList {
+	return podEffectiveResources(pod, func(c *corev1.Container) corev1.ResourceList { return c.Resources.Limits }, true)
+}
+
+func podEffectiveResources(pod *corev1.Pod, get func(*corev1.Container) corev1.ResourceList, limits bool) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for i := range pod.Spec.Containers {
+		addResourceList(total, get(&pod.Spec.Containers[i]))
+	}
+
+	// Sidecars (init containers with restartPolicy Always) keep running alongside the app
+	// containers, and stay running while later init containers execute
+	sidecars := corev1.ResourceList{}
+	initPeak := corev1.ResourceList{}
+	for i := range pod.Spec.InitContainers {
+		c := &pod.Spec.InitContainers[i]
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResourceList(total, get(c))
+			addResourceList(sidecars, get(c))
+			maxResourceList(initPeak, sidecars)
+			continue
+		}
+		step := corev1.ResourceList{}
+		addResourceList(step, get(c))
+		addResourceList(step, sidecars)
+		maxResourceList(initPeak, step)
+	}
+	maxResourceList(total, initPeak)
+
+	for name, overhead := range pod.Spec.Overhead {
+		value, ok := total[name]
+		if limits && (!ok || value.IsZero()) {
+			continue
+		}
+		value.Add(overhead)
+		total[name] = value
+	}
+	return total
+}
+
+// addResourceList adds every quantity in extra to list
+func addResourceList(list, extra corev1.ResourceList) {
+	for name, quantity := range extra {
+		value, ok := list[name]
+		if !ok {
+			list[name] = quantity.DeepCopy()
+			continue
+		}
+		value.Add(quantity)
+		list[name] = value
+	}
+}
+
+// maxResourceList raises each quantity in list to the one in other when other is larger
+func maxResourceList(list, other corev1.ResourceList) {
+	for name, quantity := range other {
+		if value, ok := list[name]; !ok || quantity.Cmp(value) > 0 {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func cpuContainer(requests, limits string) corev1.Container {
+	c := corev1.Container{Resources: corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(requests)},
+	}}
+	if limits != "" {
+		c.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(limits)}
+	}
+	return c
+}
+
+func TestPodEffectiveResources(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	sidecar := cpuContainer("200m", "")
+	sidecar.RestartPolicy = &always
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		requests string
+		limits   string
+	}{
+		{
+			name:     "containers are summed",
+			spec:     corev1.PodSpec{Containers: []corev1.Container{cpuContainer("500m", "1"), cpuContainer("250m", "")}},
+			requests: "750m",
+			limits:   "1",
+		},
+		{
+			name: "init container larger than containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{cpuContainer("2", "3")},
+				Containers:     []corev1.Container{cpuContainer("500m", "1")},
+			},
+			requests: "2",
+			limits:   "3",
+		},
+		{
+			name: "sidecar runs alongside containers and later init containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{sidecar, cpuContainer("1", "")},
+				Containers:     []corev1.Container{cpuContainer("500m", "")},
+			},
+			requests: "1200m",
+		},
+		{
+			name: "overhead added to requests and only existing limits",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{cpuContainer("500m", "1")},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+				},
+			},
+			requests: "600m",
+			limits:   "1100m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: tt.spec}
+			requests := PodEffectiveRequests(pod)
+			limits := PodEffectiveLimits(pod)
+
+			if got := requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse(tt.requests)) != 0 {
+				t.Errorf("cpu requests = %s, want %s", got.String(), tt.requests)
+			}
+			got, ok := limits[corev1.ResourceCPU]
+			if tt.limits == "" {
+				if ok && !got.IsZero() {
+					t.Errorf("cpu limits = %s, want none", got.String())
+				}
+			} else if got.Cmp(resource.MustParse(tt.limits)) != 0 {
+				t.Errorf("cpu limits = %s, want %s", got.String(), tt.limits)
+			}
+			if _, ok := limits[corev1.ResourceMemory]; ok {
+				t.Errorf("overhead added a memory limit to a pod without one")
+			}
+		})
+	}
+}
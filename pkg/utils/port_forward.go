@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward is an active port-forward to a pod backing a service
+type PortForward struct {
+	Pod        string
+	LocalPort  uint16
+	RemotePort int32
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
+	err        error
+}
+
+// Close stops the port-forward
+func (pf *PortForward) Close() {
+	pf.stopOnce.Do(func() { close(pf.stopCh) })
+}
+
+// Done is closed when the port-forward ends, either through Close or because the connection to
+// the pod was lost
+func (pf *PortForward) Done() <-chan struct{} {
+	return pf.done
+}
+
+// Err returns why the port-forward ended once Done is closed; it is nil after Close
+func (pf *PortForward) Err() error {
+	return pf.err
+}
+
+// PortForwardService forwards a local port to a ready pod behind the given service. servicePort
+// selects the service port; 0 uses the service's first port. localPort 0 picks a free port.
+func (kc *KubernetesChecker) PortForwardService(ctx context.Context, namespace, service string, servicePort int32, localPort uint16) (*PortForward, error) {
+	svc, err := kc.clientset.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s in %s: %v", service, namespace, err)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s has no selector", service)
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("service %s exposes no ports", service)
+	}
+
+	port := svc.Spec.Ports[0]
+	if servicePort != 0 {
+		found := false
+		for _, p := range svc.Spec.Ports {
+			if p.Port == servicePort {
+				port = p
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("service %s has no port %d", service, servicePort)
+		}
+	}
+
+	pods, err := kc.listPodsBySelector(ctx, namespace, labels.SelectorFromSet(svc.Spec.Selector).String())
+	if err != nil {
+		return nil, err
+	}
+	var pod *corev1.Pod
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && isPodReady(&pods[i]) {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no ready pods back service %s", service)
+	}
+
+	remotePort, err := resolveTargetPort(pod, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return kc.PortForwardPod(ctx, namespace, pod.Name, remotePort, localPort)
+}
+
+// PortForwardPod forwards a local port to a port on a pod and waits until the tunnel is ready or
+// ctx is done
+func (kc *KubernetesChecker) PortForwardPod(ctx context.Context, namespace, pod string, remotePort int32, localPort uint16) (*PortForward, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(kc.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port-forward transport: %v", err)
+	}
+
+	reqURL, err := url.Parse(kc.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build port-forward URL: %v", err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, reqURL)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, ports, stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up port-forward: %v", err)
+	}
+
+	pf := &PortForward{Pod: pod, RemotePort: remotePort, stopCh: stopCh, done: make(chan struct{})}
+	go func() {
+		pf.err = fw.ForwardPorts()
+		close(pf.done)
+	}()
+
+	select {
+	case <-readyCh:
+	case <-pf.done:
+		return nil, fmt.Errorf("port-forward to %s failed: %v", pod, pf.err)
+	case <-ctx.Done():
+		// Stops the forwarder once the dial returns; a hung dial no longer holds up the caller
+		pf.Close()
+		return nil, fmt.Errorf("port-forward to %s not ready: %v", pod, ctx.Err())
+	}
+
+	forwarded, err := fw.GetPorts()
+	if err != nil || len(forwarded) == 0 {
+		pf.Close()
+		return nil, fmt.Errorf("failed to determine forwarded port: %v", err)
+	}
+	pf.LocalPort = forwarded[0].Local
+
+	LogDebug("Forwarding 127.0.0.1:%d -> %s:%d", pf.LocalPort, pod, remotePort)
+	return pf, nil
+}
+
+// resolveTargetPort maps a service port to the container port on a specific pod
+func resolveTargetPort(pod *corev1.Pod, port corev1.ServicePort) (int32, error) {
+	target := port.TargetPort
+	switch {
+	case target.Type == intstr.Int && target.IntVal != 0:
+		return target.IntVal, nil
+	case target.Type == intstr.String && target.StrVal != "":
+		for _, c := range pod.Spec.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == target.StrVal {
+					return cp.ContainerPort, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("pod %s has no container port named %s", pod.Name, target.StrVal)
+	default:
+		return port.Port, nil
+	}
+}
+
+// isPodReady reports whether the pod's Ready condition is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
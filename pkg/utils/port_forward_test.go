@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPortForwardPodCanceled(t *testing.T) {
+	// An API server that accepts the port-forward request and never answers it
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := &rest.Config{Host: server.URL}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kc := &KubernetesChecker{clientset: clientset, config: config}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := kc.PortForwardPod(ctx, "dynamo", "dynamoai-api-0", 8080, 0)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "not ready") {
+			t.Errorf("Expected the canceled port-forward to fail, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PortForwardPod did not return after its context was canceled")
+	}
+}
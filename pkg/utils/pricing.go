@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// HoursPerMonth converts hourly prices to monthly ones, as the cloud pricing calculators do
+const HoursPerMonth = 730
+
+// defaultCurrency is the currency of the built-in price tables
+const defaultCurrency = "USD"
+
+// Pricing holds approximate on-demand prices for `--show-cost`. Nodes are priced by instance
+// type; workloads, and nodes whose instance type is not listed, are priced by the unit rates.
+type Pricing struct {
+	Currency string `json:"currency,omitempty"`
+	// InstanceTypes maps node instance types to an hourly price
+	InstanceTypes map[string]float64 `json:"instance_types,omitempty"`
+	CPUCoreHour   float64            `json:"cpu_core_hour,omitempty"`
+	MemoryGBHour  float64            `json:"memory_gb_hour,omitempty"`
+	GPUHour       float64            `json:"gpu_hour,omitempty"`
+}
+
+// DefaultPricing is approximate list on-demand pricing in AWS us-east-1, GCP us-central1, and
+// Azure eastus. The unit rates split a general purpose instance's price between its vCPUs and
+// memory; the GPU rate is a rough average across the GPU instance types below.
+var DefaultPricing = Pricing{
+	Currency: defaultCurrency,
+	InstanceTypes: map[string]float64{
+		// AWS
+		"m5.large":      0.096,
+		"m5.xlarge":     0.192,
+		"m5.2xlarge":    0.384,
+		"m5.4xlarge":    0.768,
+		"m5.8xlarge":    1.536,
+		"m6i.large":     0.096,
+		"m6i.xlarge":    0.192,
+		"m6i.2xlarge":   0.384,
+		"m6i.4xlarge":   0.768,
+		"c5.xlarge":     0.17,
+		"c5.2xlarge":    0.34,
+		"c5.4xlarge":    0.68,
+		"r5.xlarge":     0.252,
+		"r5.2xlarge":    0.504,
+		"g4dn.xlarge":   0.526,
+		"g4dn.2xlarge":  0.752,
+		"g4dn.12xlarge": 3.912,
+		"g5.xlarge":     1.006,
+		"g5.2xlarge":    1.212,
+		"g5.4xlarge":    1.624,
+		"g5.12xlarge":   5.672,
+		"g5.48xlarge":   16.288,
+		"p3.2xlarge":    3.06,
+		"p4d.24xlarge":  32.773,
+		"p5.48xlarge":   98.32,
+		// GCP
+		"e2-standard-4":  0.134,
+		"e2-standard-8":  0.268,
+		"n2-standard-4":  0.194,
+		"n2-standard-8":  0.388,
+		"n2-standard-16": 0.777,
+		"g2-standard-8":  0.854,
+		"a2-highgpu-1g":  3.673,
+		"a2-highgpu-8g":  29.387,
+		// Azure
+		"Standard_D4s_v5":          0.192,
+		"Standard_D8s_v5":          0.384,
+		"Standard_NC4as_T4_v3":     0.526,
+		"Standard_NV36ads_A10_v5":  3.2,
+		"Standard_NC24ads_A100_v4": 3.673,
+	},
+	CPUCoreHour:  0.0316,
+	MemoryGBHour: 0.0042,
+	GPUHour:      1.2,
+}
+
+// LoadPricing reads a pricing file in the Pricing format. Its instance types are added to the
+// built-in ones, and rates it sets replace the built-in rates. A file in another currency
+// replaces the built-in tables entirely, so prices in different currencies are never mixed.
+func LoadPricing(path string) (*Pricing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+	var file Pricing
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing file %s: %w", path, err)
+	}
+	for name, price := range file.InstanceTypes {
+		if price < 0 {
+			return nil, fmt.Errorf("pricing file %s: negative price for %s", path, name)
+		}
+	}
+	if file.CPUCoreHour < 0 || file.MemoryGBHour < 0 || file.GPUHour < 0 {
+		return nil, fmt.Errorf("pricing file %s: rates cannot be negative", path)
+	}
+
+	if file.Currency != "" && !strings.EqualFold(file.Currency, defaultCurrency) {
+		file.Currency = strings.ToUpper(file.Currency)
+		return &file, nil
+	}
+
+	p := DefaultPricing
+	p.InstanceTypes = make(map[string]float64, len(DefaultPricing.InstanceTypes)+len(file.InstanceTypes))
+	for name, price := range DefaultPricing.InstanceTypes {
+		p.InstanceTypes[name] = price
+	}
+	for name, price := range file.InstanceTypes {
+		p.InstanceTypes[name] = price
+	}
+	if file.CPUCoreHour > 0 {
+		p.CPUCoreHour = file.CPUCoreHour
+	}
+	if file.MemoryGBHour > 0 {
+		p.MemoryGBHour = file.MemoryGBHour
+	}
+	if file.GPUHour > 0 {
+		p.GPUHour = file.GPUHour
+	}
+	return &p, nil
+}
+
+// ResourcesMonthlyCost prices CPU cores, memory in GB, and GPUs for a month at the unit rates
+func (p *Pricing) ResourcesMonthlyCost(cpuCores, memoryGB float64, gpus int64) float64 {
+	return (cpuCores*p.CPUCoreHour + memoryGB*p.MemoryGBHour + float64(gpus)*p.GPUHour) * HoursPerMonth
+}
+
+// NodeMonthlyCost prices a node for a month by its instance type. Nodes of unlisted types are
+// estimated from their allocatable resources at the unit rates, and estimated is set.
+func (p *Pricing) NodeMonthlyCost(u NodeResourceUsage) (cost float64, estimated bool) {
+	if price, ok := p.InstanceTypes[u.InstanceType]; ok {
+		return price * HoursPerMonth, false
+	}
+	return p.ResourcesMonthlyCost(u.CPUAllocatable, u.MemoryAllocatable, u.GPUAllocatable), true
+}
+
+// ApplyNodePricing fills in the monthly cost of each node and the cluster total, and lists the
+// instance types whose cost had to be estimated
+func ApplyNodePricing(nodes []NodeResourceUsage, summary *ClusterResourceSummary, p *Pricing) {
+	estimated := map[string]bool{}
+	summary.MonthlyCost = 0
+	for i := range nodes {
+		cost, est := p.NodeMonthlyCost(nodes[i])
+		nodes[i].MonthlyCost, nodes[i].CostEstimated = cost, est
+		summary.MonthlyCost += cost
+		if est {
+			name := nodes[i].InstanceType
+			if name == "" {
+				name = "unknown"
+			}
+			estimated[name] = true
+		}
+	}
+	summary.Currency = p.Currency
+	summary.EstimatedInstanceTypes = nil
+	for name := range estimated {
+		summary.EstimatedInstanceTypes = append(summary.EstimatedInstanceTypes, name)
+	}
+	sort.Strings(summary.EstimatedInstanceTypes)
+}
+
+// FormatCost formats a cost with two decimals, prefixed with ~ when it is an estimate
+func FormatCost(cost float64, estimated bool) string {
+	if estimated {
+		return fmt.Sprintf("~%.2f", cost)
+	}
+	return fmt.Sprintf("%.2f", cost)
+}
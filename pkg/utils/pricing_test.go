@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPricing(t *testing.T) {
+	p, err := LoadPricing("../../examples/pricing.yaml")
+	if err != nil {
+		t.Fatalf("LoadPricing returned error: %v", err)
+	}
+	if p.Currency != "USD" || p.InstanceTypes["g5.2xlarge"] != 0.85 || p.InstanceTypes["dgx-a100"] != 12.50 {
+		t.Errorf("Expected the file's prices, got %+v", p)
+	}
+	if p.InstanceTypes["g4dn.xlarge"] != DefaultPricing.InstanceTypes["g4dn.xlarge"] {
+		t.Errorf("Expected built-in prices for instance types the file does not list")
+	}
+	if p.GPUHour != 0.90 {
+		t.Errorf("Expected the file's GPU rate, got %v", p.GPUHour)
+	}
+	if DefaultPricing.InstanceTypes["g5.2xlarge"] != 1.212 {
+		t.Errorf("LoadPricing modified the built-in prices")
+	}
+
+	dir := t.TempDir()
+	eur := filepath.Join(dir, "eur.yaml")
+	if err := os.WriteFile(eur, []byte("currency: eur\ninstance_types:\n  m5.large: 0.09\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err = LoadPricing(eur)
+	if err != nil {
+		t.Fatalf("LoadPricing returned error: %v", err)
+	}
+	if p.Currency != "EUR" || len(p.InstanceTypes) != 1 || p.CPUCoreHour != 0 {
+		t.Errorf("Expected only the EUR file's prices, got %+v", p)
+	}
+
+	negative := filepath.Join(dir, "negative.yaml")
+	if err := os.WriteFile(negative, []byte("gpu_hour: -1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPricing(negative); err == nil {
+		t.Error("Expected an error for a negative rate")
+	}
+}
+
+func TestApplyNodePricing(t *testing.T) {
+	p := &Pricing{Currency: "USD", InstanceTypes: map[string]float64{"g5.2xlarge": 1}, CPUCoreHour: 0.01, MemoryGBHour: 0.001, GPUHour: 1}
+	nodes := []NodeResourceUsage{
+		{Name: "gpu-1", InstanceType: "g5.2xlarge"},
+		{Name: "onprem-1", InstanceType: "custom", CPUAllocatable: 10, MemoryAllocatable: 100, GPUAllocatable: 1},
+	}
+	var summary ClusterResourceSummary
+	ApplyNodePricing(nodes, &summary, p)
+
+	if nodes[0].MonthlyCost != HoursPerMonth || nodes[0].CostEstimated {
+		t.Errorf("Expected the listed price for gpu-1, got %+v", nodes[0])
+	}
+	if want := 1.2 * HoursPerMonth; math.Abs(nodes[1].MonthlyCost-want) > 1e-9 || !nodes[1].CostEstimated {
+		t.Errorf("Expected an estimate of %.2f for onprem-1, got %+v", want, nodes[1])
+	}
+	if math.Abs(summary.MonthlyCost-2.2*HoursPerMonth) > 1e-9 || summary.Currency != "USD" {
+		t.Errorf("Unexpected cluster cost %.2f %s", summary.MonthlyCost, summary.Currency)
+	}
+	if !reflect.DeepEqual(summary.EstimatedInstanceTypes, []string{"custom"}) {
+		t.Errorf("EstimatedInstanceTypes = %v", summary.EstimatedInstanceTypes)
+	}
+	if got := FormatCost(nodes[1].MonthlyCost, true); got != "~876.00" {
+		t.Errorf("FormatCost() = %q", got)
+	}
+}
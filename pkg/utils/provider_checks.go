@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProviderChecks lists the managed Kubernetes offerings that have a check pack
+var ProviderChecks = []string{ProviderEKS, ProviderAKS, ProviderGKE}
+
+// DefaultMinPodIPHeadroom is how many more pod IPs a node should have room for before the EKS
+// VPC CNI check warns
+const DefaultMinPodIPHeadroom = 5
+
+// Well-known add-ons and markers of the managed offerings
+const (
+	eksIRSAWebhook        = "pod-identity-webhook"
+	eksPodIdentityAgent   = "eks-pod-identity-agent"
+	eksRoleAnnotation     = "eks.amazonaws.com/role-arn"
+	eksVPCCNI             = "aws-node"
+	eksEBSCSIDriver       = "ebs.csi.aws.com"
+	eksEBSCSIController   = "ebs-csi-controller"
+	aksWorkloadIdentity   = "azure-wi-webhook"
+	aksClientIDAnnotation = "azure.workload.identity/client-id"
+	aksAGICDeployment     = "ingress-appgw-deployment"
+	aksAGICController     = "azure/application-gateway"
+	gkeMetadataServer     = "iam.gke.io/gke-metadata-server-enabled"
+	gkeAcceleratorLabel   = "cloud.google.com/gke-accelerator"
+	gkeGPUDriverLabel     = "cloud.google.com/gke-gpu-driver-version"
+	gkeDriverInstaller    = "nvidia-driver-installer"
+	gpuOperatorDriver     = "nvidia-driver-daemonset"
+	kubeSystemNamespace   = "kube-system"
+)
+
+// providerInventory is what the managed-service checks look at, gathered once
+type providerInventory struct {
+	nodes              []corev1.Node
+	podsByNode         map[string][]corev1.Pod
+	daemonSets         []appsv1.DaemonSet
+	kubeSystemDeploys  []appsv1.Deployment
+	webhooks           []string
+	csiDrivers         []string
+	ingressControllers []string
+	// serviceAccounts are those of the namespace being checked, if any
+	serviceAccounts []corev1.ServiceAccount
+}
+
+// DetectCloudProvider identifies the managed Kubernetes offering or distribution, as in the
+// environment fingerprint
+func (kc *KubernetesChecker) DetectCloudProvider(ctx context.Context) (string, error) {
+	nodes, err := kc.listNodes(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %v", err)
+	}
+	return detectCloudProvider(nodes, kc.Platform() == PlatformOpenShift), nil
+}
+
+// RunProviderChecks runs the check pack of a managed Kubernetes offering, detecting it from the
+// nodes when provider is empty or auto. Namespace, when given, is where the deployment's
+// ServiceAccounts are checked for cloud identity annotations. It returns the provider checked and
+// no results when the detected one has no check pack.
+func (kc *KubernetesChecker) RunProviderChecks(ctx context.Context, provider, namespace string) (string, []CheckResult, error) {
+	if provider == "" || provider == PlatformAuto {
+		var err error
+		if provider, err = kc.DetectCloudProvider(ctx); err != nil {
+			return "", nil, err
+		}
+		if !containsString(ProviderChecks, provider) {
+			return provider, nil, nil
+		}
+	} else if !containsString(ProviderChecks, provider) {
+		return "", nil, fmt.Errorf("no check pack for %q, expected one of %v", provider, ProviderChecks)
+	}
+
+	inv, err := kc.gatherProviderInventory(ctx, namespace)
+	if err != nil {
+		return provider, nil, err
+	}
+	switch provider {
+	case ProviderEKS:
+		return provider, eksChecks(inv, DefaultMinPodIPHeadroom), nil
+	case ProviderAKS:
+		return provider, aksChecks(inv), nil
+	default:
+		return provider, gkeChecks(inv), nil
+	}
+}
+
+func (kc *KubernetesChecker) gatherProviderInventory(ctx context.Context, namespace string) (*providerInventory, error) {
+	inv := &providerInventory{}
+	var err error
+	if inv.nodes, err = kc.listNodes(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	if inv.podsByNode, err = kc.listPodsByNode(ctx); err != nil {
+		return nil, err
+	}
+	daemonSets, err := kc.clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %v", err)
+	}
+	inv.daemonSets = daemonSets.Items
+	if inv.kubeSystemDeploys, err = kc.listDeployments(ctx, kubeSystemNamespace, ""); err != nil {
+		return nil, fmt.Errorf("failed to list deployments in %s: %v", kubeSystemNamespace, err)
+	}
+	webhooks, err := kc.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhooks: %v", err)
+	}
+	for _, w := range webhooks.Items {
+		inv.webhooks = append(inv.webhooks, w.Name)
+	}
+	csiDrivers, err := kc.clientset.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSI drivers: %v", err)
+	}
+	for _, d := range csiDrivers.Items {
+		inv.csiDrivers = append(inv.csiDrivers, d.Name)
+	}
+	ingressClasses, err := kc.clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingress classes: %v", err)
+	}
+	for _, ic := range ingressClasses.Items {
+		inv.ingressControllers = appendUnique(inv.ingressControllers, ic.Spec.Controller)
+	}
+	if namespace != "" {
+		sas, err := kc.clientset.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ServiceAccounts in %s: %v", namespace, err)
+		}
+		inv.serviceAccounts = sas.Items
+	}
+	return inv, nil
+}
+
+// eksChecks checks IAM roles for service accounts, VPC CNI pod IP headroom, and the EBS CSI
+// add-on that gp2/gp3 volumes need since Kubernetes 1.23
+func eksChecks(inv *providerInventory, minHeadroom int) []CheckResult {
+	var results []CheckResult
+
+	irsa := CheckResult{Name: "irsa", Status: CheckPass}
+	switch {
+	case containsString(inv.webhooks, eksIRSAWebhook) || inv.daemonSet(eksPodIdentityAgent) != nil:
+		irsa.Message = "pod identity webhook installed" + annotatedAccounts(inv.serviceAccounts, eksRoleAnnotation, "an IAM role")
+	default:
+		irsa.Status = CheckWarn
+		irsa.Message = "neither the IRSA pod identity webhook nor the EKS Pod Identity agent found; pods fall back to the node's IAM role"
+	}
+	results = append(results, irsa)
+
+	if inv.daemonSet(eksVPCCNI) != nil {
+		results = append(results, podIPHeadroom(inv, minHeadroom))
+	}
+
+	ebs := CheckResult{Name: "ebs-csi", Status: CheckPass, Message: "EBS CSI driver installed"}
+	if !containsString(inv.csiDrivers, eksEBSCSIDriver) {
+		ebs.Status = CheckFail
+		ebs.Message = "EBS CSI driver not installed; EBS-backed PVCs won't provision until the aws-ebs-csi-driver add-on is added"
+	} else if d := inv.kubeSystemDeployment(eksEBSCSIController); d != nil && d.Status.AvailableReplicas == 0 {
+		ebs.Status = CheckFail
+		ebs.Message = fmt.Sprintf("%s has no available replicas; check its IAM role", eksEBSCSIController)
+	}
+	results = append(results, ebs)
+	return results
+}
+
+// podIPHeadroom compares each ready node's pod capacity, which the VPC CNI derives from the IPs
+// its ENIs can hold, with the pods on it that take a VPC IP
+func podIPHeadroom(inv *providerInventory, minHeadroom int) CheckResult {
+	var low []string
+	free := 0
+	for i := range inv.nodes {
+		node := &inv.nodes[i]
+		if !isNodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		used := 0
+		for _, pod := range inv.podsByNode[node.Name] {
+			if !pod.Spec.HostNetwork {
+				used++
+			}
+		}
+		headroom := int(node.Status.Allocatable.Pods().Value()) - used
+		free += max(headroom, 0)
+		if headroom < minHeadroom {
+			low = append(low, fmt.Sprintf("%s (%d left)", node.Name, max(headroom, 0)))
+		}
+	}
+	if len(low) > 0 {
+		return CheckResult{Name: "vpc-cni-ips", Status: CheckWarn,
+			Message: fmt.Sprintf("%d node(s) with fewer than %d pod IPs left: %s; new pods there stay ContainerCreating", len(low), minHeadroom, strings.Join(low, ", "))}
+	}
+	return CheckResult{Name: "vpc-cni-ips", Status: CheckPass, Message: fmt.Sprintf("%d pod IPs free across ready nodes", free)}
+}
+
+// aksChecks checks workload identity, which gives pods a managed identity, and the Application
+// Gateway Ingress Controller when it is installed
+func aksChecks(inv *providerInventory) []CheckResult {
+	var results []CheckResult
+
+	identity := CheckResult{Name: "managed-identity", Status: CheckPass}
+	if inv.webhookWithPrefix(aksWorkloadIdentity) {
+		identity.Message = "workload identity enabled" + annotatedAccounts(inv.serviceAccounts, aksClientIDAnnotation, "a managed identity")
+	} else {
+		identity.Status = CheckWarn
+		identity.Message = "workload identity not enabled; pods can only use the kubelet's managed identity (az aks update --enable-oidc-issuer --enable-workload-identity)"
+	}
+	results = append(results, identity)
+
+	agic := CheckResult{Name: "agic", Status: CheckPass}
+	d := inv.kubeSystemDeployment(aksAGICDeployment)
+	switch {
+	case d != nil && d.Status.AvailableReplicas == 0:
+		agic.Status = CheckFail
+		agic.Message = fmt.Sprintf("%s has no available replicas", aksAGICDeployment)
+	case d != nil || containsString(inv.ingressControllers, aksAGICController):
+		agic.Message = "Application Gateway Ingress Controller running"
+	case len(inv.ingressControllers) > 0:
+		agic.Message = "not installed; ingress served by " + strings.Join(inv.ingressControllers, ", ")
+	default:
+		agic.Status = CheckWarn
+		agic.Message = "no Application Gateway Ingress Controller or other ingress controller found"
+	}
+	results = append(results, agic)
+	return results
+}
+
+// gkeChecks checks Workload Identity on the node pools and, when there are GPU nodes, that
+// something installs the NVIDIA driver on them
+func gkeChecks(inv *providerInventory) []CheckResult {
+	var results []CheckResult
+
+	var withoutWI, gpuNodes, autoDriver []string
+	for _, node := range inv.nodes {
+		if node.Labels[gkeMetadataServer] != "true" {
+			withoutWI = append(withoutWI, nodePoolFromLabels(node.Labels))
+		}
+		if node.Labels[gkeAcceleratorLabel] != "" {
+			gpuNodes = append(gpuNodes, node.Name)
+			if node.Labels[gkeGPUDriverLabel] != "" {
+				autoDriver = append(autoDriver, node.Name)
+			}
+		}
+	}
+	wi := CheckResult{Name: "workload-identity", Status: CheckPass, Message: "GKE metadata server enabled on all nodes"}
+	if len(withoutWI) > 0 {
+		wi.Status = CheckWarn
+		wi.Message = fmt.Sprintf("GKE metadata server not enabled on node pool(s) %s; pods there can't use Workload Identity", strings.Join(uniqueSorted(withoutWI), ", "))
+	}
+	results = append(results, wi)
+
+	if len(gpuNodes) == 0 {
+		return results
+	}
+	driver := CheckResult{Name: "gpu-driver", Status: CheckPass}
+	installer := inv.daemonSet(gkeDriverInstaller)
+	switch {
+	case len(autoDriver) == len(gpuNodes):
+		driver.Message = "GKE installs the GPU driver on all GPU nodes"
+	case installer != nil && installer.Status.NumberReady < installer.Status.DesiredNumberScheduled:
+		driver.Status = CheckFail
+		driver.Message = fmt.Sprintf("%s ready on %d of %d nodes", gkeDriverInstaller, installer.Status.NumberReady, installer.Status.DesiredNumberScheduled)
+	case installer != nil:
+		driver.Message = gkeDriverInstaller + " DaemonSet ready"
+	case inv.daemonSetWithPrefix(gpuOperatorDriver):
+		driver.Message = "GPU Operator manages the driver"
+	default:
+		driver.Status = CheckFail
+		driver.Message = fmt.Sprintf("%d GPU node(s) without a driver installer; apply the %s DaemonSet or create the node pool with gpu-driver-version", len(gpuNodes)-len(autoDriver), gkeDriverInstaller)
+	}
+	results = append(results, driver)
+	return results
+}
+
+// annotatedAccounts notes how many of the namespace's ServiceAccounts carry an identity annotation
+func annotatedAccounts(accounts []corev1.ServiceAccount, annotation, what string) string {
+	if len(accounts) == 0 {
+		return ""
+	}
+	n := 0
+	for _, sa := range accounts {
+		if sa.Annotations[annotation] != "" {
+			n++
+		}
+	}
+	return fmt.Sprintf("; %d of %d ServiceAccounts in %s have %s", n, len(accounts), accounts[0].Namespace, what)
+}
+
+func (inv *providerInventory) daemonSet(name string) *appsv1.DaemonSet {
+	for i := range inv.daemonSets {
+		if inv.daemonSets[i].Name == name {
+			return &inv.daemonSets[i]
+		}
+	}
+	return nil
+}
+
+func (inv *providerInventory) daemonSetWithPrefix(prefix string) bool {
+	for _, ds := range inv.daemonSets {
+		if strings.HasPrefix(ds.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (inv *providerInventory) kubeSystemDeployment(name string) *appsv1.Deployment {
+	for i := range inv.kubeSystemDeploys {
+		if inv.kubeSystemDeploys[i].Name == name {
+			return &inv.kubeSystemDeploys[i]
+		}
+	}
+	return nil
+}
+
+func (inv *providerInventory) webhookWithPrefix(prefix string) bool {
+	for _, w := range inv.webhooks {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
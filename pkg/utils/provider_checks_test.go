@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func providerNode(name string, maxPods int64, labels map[string]string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(maxPods, resource.DecimalSI)},
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func checkStatus(results []CheckResult, name string) string {
+	for _, r := range results {
+		if r.Name == name {
+			return r.Status
+		}
+	}
+	return ""
+}
+
+func TestEKSChecks(t *testing.T) {
+	inv := &providerInventory{
+		nodes:      []corev1.Node{providerNode("ip-10-0-1-1", 17, nil), providerNode("ip-10-0-1-2", 17, nil)},
+		podsByNode: map[string][]corev1.Pod{"ip-10-0-1-1": make([]corev1.Pod, 15)},
+		daemonSets: []appsv1.DaemonSet{{ObjectMeta: metav1.ObjectMeta{Name: eksVPCCNI}}},
+	}
+	results := eksChecks(inv, DefaultMinPodIPHeadroom)
+	if got := checkStatus(results, "irsa"); got != CheckWarn {
+		t.Errorf("Expected irsa to warn without the webhook, got %s", got)
+	}
+	if got := checkStatus(results, "ebs-csi"); got != CheckFail {
+		t.Errorf("Expected ebs-csi to fail without the driver, got %s", got)
+	}
+	headroom := podIPHeadroom(inv, DefaultMinPodIPHeadroom)
+	if headroom.Status != CheckWarn || !strings.Contains(headroom.Message, "ip-10-0-1-1 (2 left)") {
+		t.Errorf("Expected the full node to be reported, got %s: %s", headroom.Status, headroom.Message)
+	}
+
+	// Host network pods don't take a VPC IP
+	for i := range inv.podsByNode["ip-10-0-1-1"] {
+		inv.podsByNode["ip-10-0-1-1"][i].Spec.HostNetwork = i < 10
+	}
+	inv.webhooks = []string{eksIRSAWebhook}
+	inv.csiDrivers = []string{eksEBSCSIDriver}
+	inv.serviceAccounts = []corev1.ServiceAccount{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "dynamo"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "dynamo", Namespace: "dynamo", Annotations: map[string]string{eksRoleAnnotation: "arn:aws:iam::123456789012:role/dynamo"}}},
+	}
+	results = eksChecks(inv, DefaultMinPodIPHeadroom)
+	for _, r := range results {
+		if r.Status != CheckPass {
+			t.Errorf("Expected %s to pass, got %s: %s", r.Name, r.Status, r.Message)
+		}
+	}
+	if len(results) != 3 || !strings.Contains(results[0].Message, "1 of 2 ServiceAccounts") {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestAKSChecks(t *testing.T) {
+	inv := &providerInventory{ingressControllers: []string{"k8s.io/ingress-nginx"}}
+	results := aksChecks(inv)
+	if got := checkStatus(results, "managed-identity"); got != CheckWarn {
+		t.Errorf("Expected managed-identity to warn without workload identity, got %s", got)
+	}
+	if got := checkStatus(results, "agic"); got != CheckPass {
+		t.Errorf("Expected agic to pass with another ingress controller, got %s", got)
+	}
+
+	inv.webhooks = []string{aksWorkloadIdentity + "-mutating-webhook-configuration"}
+	inv.kubeSystemDeploys = []appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Name: aksAGICDeployment}}}
+	results = aksChecks(inv)
+	if got := checkStatus(results, "managed-identity"); got != CheckPass {
+		t.Errorf("Expected managed-identity to pass, got %s", got)
+	}
+	if got := checkStatus(results, "agic"); got != CheckFail {
+		t.Errorf("Expected agic without available replicas to fail, got %s", got)
+	}
+}
+
+func TestGKEChecks(t *testing.T) {
+	wi := map[string]string{gkeMetadataServer: "true", "cloud.google.com/gke-nodepool": "default-pool"}
+	inv := &providerInventory{nodes: []corev1.Node{
+		providerNode("gke-default-1", 110, wi),
+		providerNode("gke-legacy-1", 110, map[string]string{"cloud.google.com/gke-nodepool": "legacy"}),
+	}}
+	results := gkeChecks(inv)
+	if len(results) != 1 || results[0].Status != CheckWarn || !strings.Contains(results[0].Message, "legacy") {
+		t.Errorf("Expected only a workload-identity warning for the legacy pool, got %+v", results)
+	}
+
+	inv.nodes = []corev1.Node{providerNode("gke-gpu-1", 110, map[string]string{gkeMetadataServer: "true", gkeAcceleratorLabel: "nvidia-l4"})}
+	if got := checkStatus(gkeChecks(inv), "gpu-driver"); got != CheckFail {
+		t.Errorf("Expected GPU nodes without a driver installer to fail, got %s", got)
+	}
+	inv.daemonSets = []appsv1.DaemonSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: gkeDriverInstaller},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, NumberReady: 1},
+	}}
+	if got := checkStatus(gkeChecks(inv), "gpu-driver"); got != CheckPass {
+		t.Errorf("Expected a ready driver installer to pass, got %s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Artifact types used in Component.Type
+const (
+	ArtifactTypeContainerImage = "containerImage"
+	ArtifactTypeMLModel        = "mlModel"
+	ArtifactTypeHelmChart      = "helmChart"
+	ArtifactTypeDataset        = "dataset"
+	ArtifactTypeLicense        = "license"
+)
+
+// Puller fetches one kind of artifact into a local directory
+type Puller interface {
+	Pull(component Component, outputDir string, opts RegistryClientOptions) error
+}
+
+// PullerFunc adapts a function to the Puller interface
+type PullerFunc func(component Component, outputDir string, opts RegistryClientOptions) error
+
+// Pull calls f
+func (f PullerFunc) Pull(component Component, outputDir string, opts RegistryClientOptions) error {
+	return f(component, outputDir, opts)
+}
+
+// ArtifactKind describes an artifact type dynactl knows how to pull
+type ArtifactKind struct {
+	// Type is the Component.Type of the kind, e.g. helmChart
+	Type string
+	// Label names the kind in pull logs, e.g. "Helm Charts"
+	Label string
+	// MediaTypes are matched when a component's Type is not registered; the first one is the
+	// media type reported for components of this kind
+	MediaTypes []string
+	// Puller fetches components of this kind
+	Puller Puller
+}
+
+var (
+	artifactKindsMu sync.RWMutex
+	artifactKinds   []ArtifactKind
+)
+
+// defaultPuller fetches components no registered kind matches as generic OCI artifacts
+var defaultPuller Puller = PullerFunc(pullOrasArtifact)
+
+// RegisterArtifactKind adds a pullable artifact kind. It panics if the type is already
+// registered, as that is a programming error.
+func RegisterArtifactKind(kind ArtifactKind) {
+	artifactKindsMu.Lock()
+	defer artifactKindsMu.Unlock()
+	if kind.Type == "" || kind.Puller == nil {
+		panic("dynactl: artifact kind needs a type and a puller")
+	}
+	for _, existing := range artifactKinds {
+		if existing.Type == kind.Type {
+			panic(fmt.Sprintf("dynactl: artifact kind %q registered twice", kind.Type))
+		}
+	}
+	artifactKinds = append(artifactKinds, kind)
+}
+
+// ArtifactKinds returns the registered artifact kinds in registration order
+func ArtifactKinds() []ArtifactKind {
+	artifactKindsMu.RLock()
+	defer artifactKindsMu.RUnlock()
+	return append([]ArtifactKind(nil), artifactKinds...)
+}
+
+// lookupArtifactKind finds the kind registered for a type
+func lookupArtifactKind(artifactType string) (ArtifactKind, bool) {
+	for _, kind := range ArtifactKinds() {
+		if kind.Type == artifactType {
+			return kind, true
+		}
+	}
+	return ArtifactKind{}, false
+}
+
+// artifactMediaType is the media type reported for components of a registered type
+func artifactMediaType(artifactType string) string {
+	if kind, ok := lookupArtifactKind(artifactType); ok && len(kind.MediaTypes) > 0 {
+		return kind.MediaTypes[0]
+	}
+	return ""
+}
+
+// pullerFor picks the puller for a component by its type, then its media type, falling back to
+// a generic OCI artifact pull
+func pullerFor(component Component) Puller {
+	if kind, ok := lookupArtifactKind(component.Type); ok {
+		return kind.Puller
+	}
+	if component.MediaType != "" {
+		for _, kind := range ArtifactKinds() {
+			if containsString(kind.MediaTypes, component.MediaType) {
+				return kind.Puller
+			}
+		}
+	}
+	return defaultPuller
+}
+
+func init() {
+	RegisterArtifactKind(ArtifactKind{
+		Type:       ArtifactTypeContainerImage,
+		Label:      "Container Images",
+		MediaTypes: []string{"application/vnd.oci.image.manifest.v1+json", "application/vnd.docker.distribution.manifest.v2+json"},
+		Puller:     PullerFunc(pullContainerImage),
+	})
+	RegisterArtifactKind(ArtifactKind{
+		Type:       ArtifactTypeMLModel,
+		Label:      "ML Models",
+		MediaTypes: []string{"application/vnd.dynamoai.model.v1+tar.gz"},
+		Puller:     PullerFunc(pullOrasArtifact),
+	})
+	RegisterArtifactKind(ArtifactKind{
+		Type:  ArtifactTypeHelmChart,
+		Label: "Helm Charts",
+		// Charts are listed with the OCI manifest type; the Helm config type identifies charts
+		// given by media type alone
+		MediaTypes: []string{"application/vnd.oci.image.manifest.v1+json", "application/vnd.cncf.helm.config.v1+json"},
+		Puller:     PullerFunc(pullHelmChart),
+	})
+	RegisterArtifactKind(ArtifactKind{
+		Type:       ArtifactTypeDataset,
+		Label:      "Datasets",
+		MediaTypes: []string{DatasetArtifactType},
+		Puller:     PullerFunc(pullDataset),
+	})
+	RegisterArtifactKind(ArtifactKind{
+		Type:       ArtifactTypeLicense,
+		Label:      "License",
+		MediaTypes: []string{LicenseArtifactType},
+		Puller:     PullerFunc(pullLicense),
+	})
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestArtifactKindsCoverBuiltInTypes(t *testing.T) {
+	var types []string
+	for _, kind := range ArtifactKinds() {
+		types = append(types, kind.Type)
+	}
+	for _, want := range []string{ArtifactTypeContainerImage, ArtifactTypeMLModel, ArtifactTypeHelmChart} {
+		if !containsString(types, want) {
+			t.Errorf("expected %s to be registered, got %v", want, types)
+		}
+	}
+	if got := artifactMediaType(ArtifactTypeMLModel); got != "application/vnd.dynamoai.model.v1+tar.gz" {
+		t.Errorf("unexpected model media type %q", got)
+	}
+}
+
+func TestRegisteredPullerHandlesNewKind(t *testing.T) {
+	saved := ArtifactKinds()
+	defer func() { artifactKinds = saved }()
+
+	var pulled []string
+	RegisterArtifactKind(ArtifactKind{
+		Type:       "example-config",
+		Label:      "Example Configs",
+		MediaTypes: []string{"application/vnd.example.config.v1"},
+		Puller: PullerFunc(func(component Component, outputDir string, opts RegistryClientOptions) error {
+			pulled = append(pulled, component.Name)
+			return nil
+		}),
+	})
+
+	if err := pullSingleArtifact(Component{Name: "by-type", Type: "example-config"}, t.TempDir(), RegistryClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pullSingleArtifact(Component{Name: "by-media-type", MediaType: "application/vnd.example.config.v1"}, t.TempDir(), RegistryClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(pulled) != 2 {
+		t.Fatalf("expected both components to use the registered puller, got %v", pulled)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a duplicate registration to panic")
+		}
+	}()
+	RegisterArtifactKind(ArtifactKind{Type: "example-config", Puller: defaultPuller})
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitRetries is how many times a throttled request is retried
+	rateLimitRetries = 6
+	// rateLimitBackoff is the first wait when the registry gives no Retry-After
+	rateLimitBackoff = 5 * time.Second
+	// maxRateLimitWait caps a single wait, whatever Retry-After asks for
+	maxRateLimitWait = 10 * time.Minute
+)
+
+// RateLimitStatus is what a registry reported about its rate limit during a run
+type RateLimitStatus struct {
+	Host string `json:"host"`
+	// Limit and Remaining come from RateLimit-* headers; -1 means the registry did not send them
+	Limit     int           `json:"limit"`
+	Remaining int           `json:"remaining"`
+	Window    time.Duration `json:"window,omitempty"`
+	// Throttled counts 429 answers and Waited the time spent backing off
+	Throttled int           `json:"throttled"`
+	Waited    time.Duration `json:"waited"`
+}
+
+// String summarizes the status for logs
+func (s RateLimitStatus) String() string {
+	var parts []string
+	if s.Remaining >= 0 && s.Limit >= 0 {
+		quota := fmt.Sprintf("%d/%d requests remaining", s.Remaining, s.Limit)
+		if s.Window > 0 {
+			quota += fmt.Sprintf(" per %s", s.Window)
+		}
+		parts = append(parts, quota)
+	}
+	if s.Throttled > 0 {
+		parts = append(parts, fmt.Sprintf("throttled %d times, waited %s", s.Throttled, s.Waited.Round(time.Second)))
+	}
+	return fmt.Sprintf("%s: %s", s.Host, strings.Join(parts, "; "))
+}
+
+// RateLimitTracker collects the rate limit state of every registry a run talks to
+type RateLimitTracker struct {
+	mu     sync.Mutex
+	hosts  map[string]*RateLimitStatus
+	warned map[string]bool
+}
+
+// NewRateLimitTracker returns an empty tracker
+func NewRateLimitTracker() *RateLimitTracker {
+	return &RateLimitTracker{hosts: map[string]*RateLimitStatus{}, warned: map[string]bool{}}
+}
+
+// Statuses returns the registries that sent rate limit headers or throttled, by host
+func (t *RateLimitTracker) Statuses() []RateLimitStatus {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]RateLimitStatus, 0, len(t.hosts))
+	for _, status := range t.hosts {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Host < statuses[j].Host })
+	return statuses
+}
+
+// status returns the entry for a host, creating it; t.mu must be held
+func (t *RateLimitTracker) status(host string) *RateLimitStatus {
+	status, ok := t.hosts[host]
+	if !ok {
+		status = &RateLimitStatus{Host: host, Limit: -1, Remaining: -1}
+		t.hosts[host] = status
+	}
+	return status
+}
+
+// observe records the rate limit headers of a response
+func (t *RateLimitTracker) observe(host string, header http.Header) {
+	if t == nil {
+		return
+	}
+	limit, window, okLimit := parseRateLimitHeader(header, "Ratelimit-Limit", "X-Ratelimit-Limit")
+	remaining, _, okRemaining := parseRateLimitHeader(header, "Ratelimit-Remaining", "X-Ratelimit-Remaining")
+	if !okLimit && !okRemaining {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.status(host)
+	if okLimit {
+		status.Limit = limit
+		status.Window = window
+	}
+	if okRemaining {
+		status.Remaining = remaining
+	}
+	if status.Limit > 0 && status.Remaining >= 0 && status.Remaining*10 < status.Limit && !t.warned[host] {
+		t.warned[host] = true
+		LogWarning("Registry %s reports %d of %d requests left; pulls will slow down when it runs out", host, status.Remaining, status.Limit)
+	}
+}
+
+// throttled records one backoff
+func (t *RateLimitTracker) throttled(host string, wait time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.status(host)
+	status.Throttled++
+	status.Waited += wait
+}
+
+// rateLimitTransport retries reads the registry throttles with 429, waiting as long as it asks
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	tracker *RateLimitTracker
+	// sleep waits for d or until the request is cancelled; replaced in tests
+	sleep func(req *http.Request, d time.Duration) error
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Only bodiless reads can be replayed; pushes surface the 429 to the caller
+	retryable := (req.Method == http.MethodGet || req.Method == http.MethodHead) && req.Body == nil
+	backoff := rateLimitBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.tracker.observe(req.URL.Host, resp.Header)
+		if resp.StatusCode != http.StatusTooManyRequests || !retryable || attempt == rateLimitRetries {
+			return resp, nil
+		}
+
+		wait, ok := retryAfter(resp.Header, time.Now())
+		if !ok {
+			wait = backoff
+			backoff *= 2
+		}
+		if wait > maxRateLimitWait {
+			wait = maxRateLimitWait
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		LogWarning("Registry %s is rate limiting requests (HTTP 429); waiting %s before resuming", req.URL.Host, wait.Round(time.Second))
+		t.tracker.throttled(req.URL.Host, wait)
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepForRequest
+		}
+		if err := sleep(req, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepForRequest waits for d unless the request is cancelled first
+func sleepForRequest(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := when.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// parseRateLimitHeader reads the first of the named headers, in the Docker Hub form "100;w=21600"
+// or as a plain number, and returns the count and the window
+func parseRateLimitHeader(header http.Header, names ...string) (int, time.Duration, bool) {
+	for _, name := range names {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		count, params, _ := strings.Cut(value, ";")
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			continue
+		}
+		var window time.Duration
+		for _, param := range strings.Split(params, ";") {
+			key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "w" {
+				if seconds, err := strconv.Atoi(val); err == nil {
+					window = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+		return n, window, true
+	}
+	return 0, 0, false
+}
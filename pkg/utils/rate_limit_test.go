@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransportWaitsAndResumes(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		if calls.Add(1) <= 2 {
+			w.Header().Set("RateLimit-Remaining", "0;w=21600")
+			if calls.Load() == 1 {
+				w.Header().Set("Retry-After", "7")
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("RateLimit-Remaining", "42;w=21600")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tracker := NewRateLimitTracker()
+	var waits []time.Duration
+	transport := &rateLimitTransport{
+		base:    http.DefaultTransport,
+		tracker: tracker,
+		sleep: func(req *http.Request, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/v2/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the request to resume after throttling, got %d", resp.StatusCode)
+	}
+	// Retry-After is honored first, then the backoff applies
+	if len(waits) != 2 || waits[0] != 7*time.Second || waits[1] != rateLimitBackoff {
+		t.Fatalf("unexpected waits %v", waits)
+	}
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("expected one registry, got %+v", statuses)
+	}
+	status := statuses[0]
+	if status.Limit != 100 || status.Remaining != 42 || status.Window != 6*time.Hour || status.Throttled != 2 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if !strings.Contains(status.String(), "42/100 requests remaining per 6h0m0s") {
+		t.Fatalf("unexpected summary %q", status.String())
+	}
+}
+
+func TestRateLimitTransportDoesNotReplayWrites(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := &rateLimitTransport{base: http.DefaultTransport}
+	resp, err := (&http.Client{Transport: transport}).Post(server.URL, "text/plain", strings.NewReader("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Fatalf("expected the 429 to be returned without a retry, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	if _, ok := retryAfter(header, now); ok {
+		t.Fatal("expected no Retry-After")
+	}
+	header.Set("Retry-After", now.Add(90*time.Second).Format(http.TimeFormat))
+	if d, ok := retryAfter(header, now); !ok || d != 90*time.Second {
+		t.Fatalf("expected 90s from an HTTP date, got %s", d)
+	}
+	header.Set("Retry-After", "soon")
+	if _, ok := retryAfter(header, now); ok {
+		t.Fatal("expected an unparseable Retry-After to be ignored")
+	}
+}
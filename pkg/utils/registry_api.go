@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// registryAPIRequestTimeout bounds each call to a registry management API
+const registryAPIRequestTimeout = 30 * time.Second
+
+// registryAPI sends JSON requests to a registry product's management API with basic auth
+type registryAPI struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newRegistryAPI(baseURL, username, password string) registryAPI {
+	return registryAPI{
+		baseURL:  baseURL,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: registryAPIRequestTimeout},
+	}
+}
+
+// withPath returns a copy rooted at a sub-path such as /api/v2.0
+func (a registryAPI) withPath(path string) registryAPI {
+	a.baseURL += path
+	return a
+}
+
+// do sends a request and decodes a JSON response into out. Non-2xx responses are returned as
+// errors along with their status code.
+func (a registryAPI) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.username != "" {
+		req.SetBasicAuth(a.username, a.password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return resp.StatusCode, fmt.Errorf("credentials were rejected (HTTP 401); run dynactl registry login")
+	case resp.StatusCode == http.StatusForbidden:
+		return resp.StatusCode, fmt.Errorf("the account is not permitted to %s %s (HTTP 403)", method, path)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse response from %s: %w", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// FormatBytes renders a size in MB or GB, matching the units used in pull logs
+func FormatBytes(n int64) string {
+	const mb = 1024 * 1024
+	if n >= 1024*mb {
+		return fmt.Sprintf("%.2f GB", float64(n)/(1024*mb))
+	}
+	return fmt.Sprintf("%.2f MB", float64(n)/mb)
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	oras_auth "oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// RegistryClientOptions controls how artifacts are fetched from the source registry
+type RegistryClientOptions struct {
+	// PlainHTTP talks to the registry over HTTP instead of HTTPS
+	PlainHTTP bool
+	// InsecureSkipTLSVerify accepts any certificate the registry presents
+	InsecureSkipTLSVerify bool
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string
+	// Timeout bounds each HTTP request; zero means no limit
+	Timeout time.Duration
+	// RateLimits, when set, collects the rate limit state the registries report
+	RateLimits *RateLimitTracker
+}
+
+// HTTPClient returns an HTTP client configured with the TLS and timeout options. Reads the
+// registry throttles are retried once it allows them again.
+func (o RegistryClientOptions) HTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.CAFile != "" || o.InsecureSkipTLSVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: o.InsecureSkipTLSVerify}
+		if o.CAFile != "" {
+			pemData, err := os.ReadFile(o.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pemData) {
+				return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: &rateLimitTransport{base: transport, tracker: o.RateLimits}, Timeout: o.Timeout}, nil
+}
+
+// newHelmRegistryClient builds a Helm OCI client that authenticates with dynactl's credential
+// resolution (Docker config, then the dynactl store) instead of only Helm's registry config
+func newHelmRegistryClient(opts RegistryClientOptions) (*registry.Client, error) {
+	httpClient, err := opts.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	clientOpts := []registry.ClientOption{
+		registry.ClientOptHTTPClient(httpClient),
+		registry.ClientOptAuthorizer(oras_auth.Client{
+			Client: httpClient,
+			Cache:  oras_auth.NewCache(),
+			Credential: func(ctx context.Context, host string) (oras_auth.Credential, error) {
+				return resolveRegistryCredential(host)
+			},
+		}),
+	}
+	if opts.PlainHTTP {
+		clientOpts = append(clientOpts, registry.ClientOptPlainHTTP())
+	}
+	client, err := registry.NewClient(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Helm registry client: %w", err)
+	}
+	return client, nil
+}
+
+// helmGetterOptions returns the getter options matching opts, using client for OCI charts
+func helmGetterOptions(opts RegistryClientOptions, client *registry.Client) []getter.Option {
+	return []getter.Option{
+		getter.WithPassCredentialsAll(true),
+		getter.WithRegistryClient(client),
+		getter.WithPlainHTTP(opts.PlainHTTP),
+		getter.WithInsecureSkipVerifyTLS(opts.InsecureSkipTLSVerify),
+		getter.WithTLSClientConfig("", "", opts.CAFile),
+		getter.WithTimeout(opts.Timeout),
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestPullHelmChartOverPlainHTTP(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+
+	packaged, err := chartutil.Save(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "dynamoai-base", Version: "1.1.2"},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(packaged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := registry.NewClient(registry.ClientOptPlainHTTP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Push(data, host+"/charts/dynamoai-base:1.1.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	component := Component{
+		Name: "dynamoai-base",
+		Type: "helmChart",
+		URI:  host + "/charts/dynamoai-base-1.1.2.tgz",
+		Tag:  "1.1.2",
+	}
+	outputDir := t.TempDir()
+	opts := RegistryClientOptions{PlainHTTP: true, Timeout: 10 * time.Second}
+	if err := pullHelmChart(component, outputDir, opts); err != nil {
+		t.Fatalf("expected the chart to be pulled over plain HTTP: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "dynamoai-base-1.1.2.tgz")); err != nil {
+		t.Fatalf("expected the chart archive to be saved: %v", err)
+	}
+
+	// Without --plain-http the client insists on HTTPS
+	if err := pullHelmChart(component, t.TempDir(), RegistryClientOptions{Timeout: 10 * time.Second}); err == nil {
+		t.Fatal("expected an HTTPS pull from a plain HTTP registry to fail")
+	}
+}
+
+func TestRegistryClientOptionsRejectsBadCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (RegistryClientOptions{CAFile: caFile}).HTTPClient(); err == nil {
+		t.Fatal("expected a CA file without certificates to be rejected")
+	}
+	client, err := RegistryClientOptions{InsecureSkipTLSVerify: true, Timeout: time.Minute}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Timeout != time.Minute {
+		t.Fatalf("expected the timeout to be applied, got %s", client.Timeout)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -47,7 +48,33 @@ func SaveRegistryCredential(registry string, cred RegistryCredential) error {
 	}
 
 	store.Credentials[registry] = cred
+	return writeCredentialStore(store)
+}
+
+// RemoveRegistryCredential deletes a registry's credentials from the dynactl credential store. It
+// reports whether the registry had stored credentials.
+func RemoveRegistryCredential(registry string) (bool, error) {
+	if registry == "" {
+		return false, fmt.Errorf("registry cannot be empty")
+	}
+
+	store, err := loadCredentialStore()
+	if err != nil {
+		return false, fmt.Errorf("failed to load credential store: %w", err)
+	}
+	if _, ok := store.Credentials[registry]; !ok {
+		return false, nil
+	}
+
+	delete(store.Credentials, registry)
+	if err := writeCredentialStore(store); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
+// writeCredentialStore persists the store and refreshes the cached copy
+func writeCredentialStore(store *credentialStore) error {
 	path, err := credentialStorePath()
 	if err != nil {
 		return fmt.Errorf("failed to resolve credential store path: %w", err)
@@ -89,6 +116,35 @@ func GetRegistryCredential(registry string) (RegistryCredential, bool, error) {
 	return cred, ok, nil
 }
 
+// StoredRegistry describes a registry in the dynactl credential store without exposing secrets.
+type StoredRegistry struct {
+	Registry string `json:"registry"`
+	Username string `json:"username,omitempty"`
+	AuthType string `json:"auth_type"`
+}
+
+// ListRegistryCredentials returns the registries in the dynactl credential store, sorted by name.
+func ListRegistryCredentials() ([]StoredRegistry, error) {
+	store, err := loadCredentialStore()
+	if err != nil {
+		return nil, err
+	}
+
+	registries := make([]StoredRegistry, 0, len(store.Credentials))
+	for registry, cred := range store.Credentials {
+		authType := "password"
+		switch {
+		case cred.IdentityToken != "":
+			authType = "identity-token"
+		case cred.AccessToken != "":
+			authType = "access-token"
+		}
+		registries = append(registries, StoredRegistry{Registry: registry, Username: cred.Username, AuthType: authType})
+	}
+	sort.Slice(registries, func(i, j int) bool { return registries[i].Registry < registries[j].Registry })
+	return registries, nil
+}
+
 // resolveRegistryCredential merges credentials from docker/oras config and the dynactl store.
 func resolveRegistryCredential(registry string) (oras_auth.Credential, error) {
 	if registry == "" {
@@ -226,11 +282,11 @@ func loadCredentialStore() (*credentialStore, error) {
 }
 
 func credentialStorePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	dir, err := dynactlHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+		return "", err
 	}
-	return filepath.Join(homeDir, ".dynactl", credentialStoreFileName), nil
+	return filepath.Join(dir, credentialStoreFileName), nil
 }
 
 // simpleRegistry wraps a registry string to satisfy the authn.Resource interface.
@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// RegistryServer serves pulled image archives through the read-only part of the OCI
+// distribution API, so nodes inside an air gap can pull from the operator's machine. Images
+// are served under their original repository path without the source registry host.
+type RegistryServer struct {
+	// repos maps repository -> tag -> image
+	repos map[string]map[string]*servedImage
+}
+
+// servedImage is an image archive that is opened on first use, since computing layer digests
+// reads the whole archive
+type servedImage struct {
+	archive string
+	tag     name.Tag
+	once    sync.Once
+	img     v1.Image
+	err     error
+}
+
+func (s *servedImage) image() (v1.Image, error) {
+	s.once.Do(func() {
+		tag := s.tag
+		s.img, s.err = tarball.ImageFromPath(s.archive, &tag)
+	})
+	return s.img, s.err
+}
+
+// NewRegistryServer indexes the image archives (*.tar) in dir by the tags recorded in them.
+// Archives that are not image tarballs are skipped.
+func NewRegistryServer(dir string) (*RegistryServer, error) {
+	archives, err := filepath.Glob(filepath.Join(dir, "*.tar"))
+	if err != nil {
+		return nil, err
+	}
+	s := &RegistryServer{repos: map[string]map[string]*servedImage{}}
+	for _, archive := range archives {
+		path := archive
+		manifest, err := tarball.LoadManifest(func() (io.ReadCloser, error) { return os.Open(path) })
+		if err != nil {
+			LogDebug("Skipping %s: not an image archive: %v", archive, err)
+			continue
+		}
+		for _, desc := range manifest {
+			for _, repoTag := range desc.RepoTags {
+				tag, err := name.NewTag(repoTag)
+				if err != nil {
+					LogWarning("Skipping tag %s in %s: %v", repoTag, archive, err)
+					continue
+				}
+				repo := tag.Context().RepositoryStr()
+				if s.repos[repo] == nil {
+					s.repos[repo] = map[string]*servedImage{}
+				}
+				s.repos[repo][tag.TagStr()] = &servedImage{archive: archive, tag: tag}
+			}
+		}
+	}
+	if len(s.repos) == 0 {
+		return nil, fmt.Errorf("no image archives found in %s; run dynactl artifacts pull --images first", dir)
+	}
+	return s, nil
+}
+
+// Repositories returns the served repositories with their tags, as repo:tag references
+func (s *RegistryServer) Repositories() []string {
+	var refs []string
+	for repo, tags := range s.repos {
+		for tag := range tags {
+			refs = append(refs, repo+":"+tag)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Serve accepts connections on listener until ctx is canceled, using TLS when certFile and
+// keyFile are set
+func (s *RegistryServer) Serve(ctx context.Context, listener net.Listener, certFile, keyFile string) error {
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if certFile != "" {
+		err = srv.ServeTLS(listener, certFile, keyFile)
+	} else {
+		err = srv.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *RegistryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		registryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "this registry is read-only")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case r.URL.Path == "/v2/" || r.URL.Path == "/v2":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "{}")
+	case path == "_catalog":
+		repos := make([]string, 0, len(s.repos))
+		for repo := range s.repos {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+		writeJSON(w, map[string][]string{"repositories": repos})
+	case strings.HasSuffix(path, "/tags/list"):
+		repo := strings.TrimSuffix(path, "/tags/list")
+		tags, ok := s.repos[repo]
+		if !ok {
+			registryError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository "+repo+" is not served")
+			return
+		}
+		list := make([]string, 0, len(tags))
+		for tag := range tags {
+			list = append(list, tag)
+		}
+		sort.Strings(list)
+		writeJSON(w, map[string]interface{}{"name": repo, "tags": list})
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		s.serveManifest(w, r, path[:i], path[i+len("/manifests/"):])
+	case strings.Contains(path, "/blobs/"):
+		i := strings.LastIndex(path, "/blobs/")
+		s.serveBlob(w, r, path[:i], path[i+len("/blobs/"):])
+	default:
+		registryError(w, http.StatusNotFound, "UNSUPPORTED", "unknown endpoint")
+	}
+}
+
+func (s *RegistryServer) serveManifest(w http.ResponseWriter, r *http.Request, repo, reference string) {
+	img, err := s.lookup(repo, reference)
+	if err != nil {
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	if img == nil {
+		registryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("%s:%s is not served", repo, reference))
+		return
+	}
+	raw, err := img.RawManifest()
+	if err != nil {
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	mediaType, _ := img.MediaType()
+	digest, _ := img.Digest()
+
+	LogInfo("%s %s:%s", r.Method, repo, reference)
+	w.Header().Set("Content-Type", string(mediaType))
+	w.Header().Set("Docker-Content-Digest", digest.String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(raw)))
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(raw)
+	}
+}
+
+func (s *RegistryServer) serveBlob(w http.ResponseWriter, r *http.Request, repo, digest string) {
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		registryError(w, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+		return
+	}
+	for _, served := range s.repos[repo] {
+		img, err := served.image()
+		if err != nil {
+			continue
+		}
+		if cfg, _ := img.ConfigName(); cfg == hash {
+			raw, err := img.RawConfigFile()
+			if err != nil {
+				registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+				return
+			}
+			writeBlob(w, r, hash, int64(len(raw)), func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(raw)), nil
+			})
+			return
+		}
+		layer, err := img.LayerByDigest(hash)
+		if err != nil {
+			continue
+		}
+		size, err := layer.Size()
+		if err != nil {
+			registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			return
+		}
+		LogDebug("%s %s@%s", r.Method, repo, digest)
+		writeBlob(w, r, hash, size, layer.Compressed)
+		return
+	}
+	registryError(w, http.StatusNotFound, "BLOB_UNKNOWN", fmt.Sprintf("%s@%s is not served", repo, digest))
+}
+
+// lookup finds an image by tag or manifest digest. Digests are matched against the manifests
+// rebuilt from the archives, which can differ from the source registry's.
+func (s *RegistryServer) lookup(repo, reference string) (v1.Image, error) {
+	tags := s.repos[repo]
+	if served, ok := tags[reference]; ok {
+		return served.image()
+	}
+	if !strings.Contains(reference, ":") {
+		return nil, nil
+	}
+	for _, served := range tags {
+		img, err := served.image()
+		if err != nil {
+			return nil, err
+		}
+		if digest, err := img.Digest(); err == nil && digest.String() == reference {
+			return img, nil
+		}
+	}
+	return nil, nil
+}
+
+func writeBlob(w http.ResponseWriter, r *http.Request, digest v1.Hash, size int64, open func() (io.ReadCloser, error)) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", digest.String())
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	rc, err := open()
+	if err != nil {
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	defer rc.Close()
+	if _, err := io.Copy(w, rc); err != nil {
+		LogDebug("Blob %s transfer interrupted: %v", digest, err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// registryError writes an error in the distribution API's error format
+func registryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
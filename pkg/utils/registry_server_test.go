@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func TestRegistryServer(t *testing.T) {
+	dir := t.TempDir()
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := name.NewTag("artifacts.dynamo.ai/dynamoai/3.22.2/images/api:1.0")
+	if err := tarball.WriteToFile(filepath.Join(dir, "api.tar"), tag, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "model.tar"), []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := NewRegistryServer(dir)
+	if err != nil {
+		t.Fatalf("NewRegistryServer failed: %v", err)
+	}
+	if refs := server.Repositories(); strings.Join(refs, ",") != "dynamoai/3.22.2/images/api:1.0" {
+		t.Fatalf("unexpected repositories %v", refs)
+	}
+
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pulled, err := crane.Pull(host+"/dynamoai/3.22.2/images/api:1.0", crane.Insecure)
+	if err != nil {
+		t.Fatalf("pulling from the served registry failed: %v", err)
+	}
+	want, _ := img.Layers()
+	got, err := pulled.Layers()
+	if err != nil || len(got) != len(want) {
+		t.Fatalf("expected %d layers, got %d (%v)", len(want), len(got), err)
+	}
+	for i := range got {
+		gotDigest, _ := got[i].Digest()
+		wantDigest, _ := want[i].Digest()
+		if gotDigest != wantDigest {
+			t.Errorf("layer %d digest %s, want %s", i, gotDigest, wantDigest)
+		}
+		rc, err := got[i].Compressed()
+		if err != nil {
+			t.Fatalf("failed to fetch layer %d: %v", i, err)
+		}
+		rc.Close()
+	}
+
+	digest, _ := pulled.Digest()
+	if _, err := crane.Pull(host+"/dynamoai/3.22.2/images/api@"+digest.String(), crane.Insecure); err != nil {
+		t.Errorf("pulling by digest failed: %v", err)
+	}
+	if _, err := crane.Pull(host+"/dynamoai/3.22.2/images/api:2.0", crane.Insecure); err == nil {
+		t.Error("expected an unknown tag to fail")
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/v2/dynamoai/3.22.2/images/api/manifests/2.0", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected pushes to be rejected, got HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RegistryTarget validates a mirror destination through the registry product's own API so
+// problems surface before anything is pulled or pushed, and adapts push paths to its rules
+type RegistryTarget interface {
+	// Kind names the registry product, e.g. Harbor
+	Kind() string
+	// Prepare verifies the destination of every target repository, creating it when the
+	// options allow
+	Prepare(ctx context.Context, repositories []string) error
+	// CheckCapacity verifies there is room for the given bytes per target repository.
+	// Products without a quota API accept any size.
+	CheckCapacity(ctx context.Context, sizes map[string]int64) error
+	// TargetRepository rewrites a target repository to satisfy the product's path rules
+	TargetRepository(repository string) string
+}
+
+// TargetOptions controls what target preflight checks may change on the registry
+type TargetOptions struct {
+	// CreateProject makes missing Harbor projects instead of failing
+	CreateProject bool
+	// RetainLatest adds a retention policy keeping the N most recently pushed tags per
+	// repository to Harbor projects dynactl creates; 0 leaves retention unset
+	RetainLatest int
+	// APIURL is the management API base URL when it differs from the registry host, as with
+	// Nexus Docker connectors served on their own port
+	APIURL string
+}
+
+// DetectRegistryTarget identifies Harbor, Artifactory, or Nexus behind a target registry. It
+// returns nil for other registries, which are pushed to without preflight checks.
+func DetectRegistryTarget(ctx context.Context, targetRegistry string, opts TargetOptions) RegistryTarget {
+	host := RegistryHost(targetRegistry)
+	cred, err := resolveRegistryCredential(host)
+	if err != nil {
+		LogWarning("Skipping registry preflight checks: %v", err)
+		return nil
+	}
+	base := strings.TrimSuffix(opts.APIURL, "/")
+	if base == "" {
+		base = "https://" + host
+	}
+	api := newRegistryAPI(base, cred.Username, cred.Password)
+
+	harbor := &HarborClient{registryAPI: api.withPath(harborAPIPath)}
+	if harbor.IsHarbor(ctx) {
+		return &harborRegistryTarget{client: harbor, opts: HarborProjectOptions{Create: opts.CreateProject, RetainLatest: opts.RetainLatest}}
+	}
+	if artifactory := newArtifactoryTarget(api, host, cred.Username); artifactory.detect(ctx) {
+		return artifactory
+	}
+	if nexus := newNexusTarget(api, targetRegistry); nexus.detect(ctx) {
+		return nexus
+	}
+	return nil
+}
+
+// MirrorTargetRepositories returns the distinct repositories images would be pushed to
+func MirrorTargetRepositories(images []string, targetRegistry string, overrides map[string]TargetOverride) []string {
+	return sortedKeys(MirrorBundleSizes(images, "", targetRegistry, overrides))
+}
+
+// MirrorBundleSizes totals the cached image archives to be pushed into each target repository.
+// An empty cacheDir only collects the repositories.
+func MirrorBundleSizes(images []string, cacheDir, targetRegistry string, overrides map[string]TargetOverride) map[string]int64 {
+	sizes := map[string]int64{}
+	for _, imageRef := range images {
+		componentRef := strings.TrimPrefix(imageRef, "oci://")
+		repoPart, _ := splitRepositoryAndReference(componentRef)
+		if repoPart == "" {
+			continue
+		}
+		repo, _ := mirrorTarget(repoPart, "", targetRegistry, overrides)
+		if _, ok := sizes[repo]; !ok {
+			sizes[repo] = 0
+		}
+		if cacheDir == "" {
+			continue
+		}
+		tarPath := filepath.Join(cacheDir, fmt.Sprintf("%s.tar", extractNameFromURI(componentRef)))
+		if info, err := os.Stat(tarPath); err == nil {
+			sizes[repo] += info.Size()
+		}
+	}
+	return sizes
+}
+
+// RegistryHost returns the host part of a target such as harbor.example.com/project
+func RegistryHost(targetRegistry string) string {
+	host, _, _ := strings.Cut(strings.TrimSpace(targetRegistry), "/")
+	return host
+}
+
+// repositoryPath returns a target repository without its host
+func repositoryPath(repository string) string {
+	_, path, _ := strings.Cut(repository, "/")
+	return path
+}
+
+// firstPathSegment returns the top-level namespace of a target repository, which Harbor calls a
+// project and Artifactory a repository key
+func firstPathSegment(repository string) string {
+	first, _, _ := strings.Cut(repositoryPath(repository), "/")
+	return first
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestArtifactoryTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/system/version":
+			fmt.Fprint(w, `{"version":"7.77.3"}`)
+		case "/artifactory/api/repositories/docker-local":
+			fmt.Fprint(w, `{"key":"docker-local","rclass":"local","packageType":"docker"}`)
+		case "/artifactory/api/repositories/docker-remote":
+			fmt.Fprint(w, `{"key":"docker-remote","rclass":"remote","packageType":"docker"}`)
+		case "/artifactory/api/repositories/docker":
+			fmt.Fprint(w, `{"key":"docker","rclass":"virtual","packageType":"docker"}`)
+		case "/artifactory/api/storage/docker-local":
+			fmt.Fprint(w, `{"principals":{"users":{"mirror":["r","w"]}}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	api := registryAPI{baseURL: srv.URL, client: srv.Client()}
+
+	target := newArtifactoryTarget(api, "art.example.com", "mirror")
+	if !target.detect(ctx) {
+		t.Fatal("expected the fake registry to be detected as Artifactory")
+	}
+	if err := target.Prepare(ctx, []string{"art.example.com/docker-local/dynamoai/api"}); err != nil {
+		t.Errorf("expected docker-local to be accepted, got %v", err)
+	}
+
+	cases := map[string]string{
+		"art.example.com/docker-remote/dynamoai/api": "remote (proxy) repository",
+		"art.example.com/docker/dynamoai/api":        "no default deployment repository",
+		"art.example.com/missing/dynamoai/api":       "missing does not exist",
+		"art.example.com/docker-local":               "no image path after the repository key",
+	}
+	for repo, want := range cases {
+		err := newArtifactoryTarget(api, "art.example.com", "mirror").Prepare(ctx, []string{repo})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Prepare(%s) = %v, want an error containing %q", repo, err, want)
+		}
+	}
+
+	if got := target.TargetRepository("art.example.com/docker-local/DynamoAI/API"); got != "art.example.com/docker-local/dynamoai/api" {
+		t.Errorf("TargetRepository = %s", got)
+	}
+}
+
+func TestNexusTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/service/rest/v1/status":
+		case "/service/rest/v1/repositories":
+			fmt.Fprint(w, `[{"name":"maven","format":"maven2","type":"hosted"},{"name":"hub","format":"docker","type":"proxy"},{"name":"images","format":"docker","type":"hosted"}]`)
+		case "/service/rest/v1/repositories/docker/proxy/hub":
+			fmt.Fprint(w, `{"name":"hub","online":true,"docker":{"httpsPort":8082}}`)
+		case "/service/rest/v1/repositories/docker/hosted/images":
+			fmt.Fprint(w, `{"name":"images","online":true,"storage":{"writePolicy":"ALLOW_ONCE"},"docker":{"httpsPort":8083,"subdomain":"images"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	api := registryAPI{baseURL: srv.URL, client: srv.Client()}
+
+	if !newNexusTarget(api, "nexus.example.com:8083").detect(ctx) {
+		t.Fatal("expected the fake registry to be detected as Nexus")
+	}
+	for _, target := range []string{"nexus.example.com:8083", "images.nexus.example.com", "nexus.example.com/repository/images"} {
+		if err := newNexusTarget(api, target).Prepare(ctx, []string{target + "/dynamoai/api"}); err != nil {
+			t.Errorf("expected %s to resolve to the hosted repository, got %v", target, err)
+		}
+	}
+	if err := newNexusTarget(api, "nexus.example.com:8082").Prepare(ctx, nil); err == nil || !strings.Contains(err.Error(), "proxy repository") {
+		t.Errorf("expected the proxy repository to be rejected, got %v", err)
+	}
+	if err := newNexusTarget(api, "nexus.example.com:9000").Prepare(ctx, nil); err == nil || !strings.Contains(err.Error(), "no Nexus Docker repository serves") {
+		t.Errorf("expected no matching repository, got %v", err)
+	}
+}
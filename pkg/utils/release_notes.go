@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	// ReleaseNotesArtifactType is the OCI artifact type of release notes attached to a manifest
+	// artifact as a referrer
+	ReleaseNotesArtifactType = "application/vnd.dynamoai.release-notes.v1"
+	// ReleaseNotesAnnotation on a manifest artifact points at its release notes artifact, by tag or
+	// digest in the same repository or by a full reference
+	ReleaseNotesAnnotation = "ai.dynamo.release-notes"
+
+	// Where the release notes were found
+	ReleaseNotesFromAnnotation = "annotation"
+	ReleaseNotesFromReferrer   = "referrer"
+	ReleaseNotesFromLayer      = "manifest layer"
+
+	// maxReleaseNotesSize bounds the release notes read into memory
+	maxReleaseNotesSize = 4 << 20
+)
+
+// releaseNotesFiles are the file names a release notes layer of the manifest artifact may have
+var releaseNotesFiles = []string{"release-notes.md", "release_notes.md", "releasenotes.md", "changelog.md"}
+
+// ReleaseNotes are the notes published with a release manifest
+type ReleaseNotes struct {
+	Manifest string
+	// Source says how they were found: annotation, referrer, or manifest layer
+	Source string
+	// Artifact is the reference of the artifact holding them, or the manifest's for a layer
+	Artifact string
+	File     string `json:",omitempty"`
+	Markdown string
+}
+
+// FetchReleaseNotes finds the release notes of a manifest artifact. They are looked up, in order,
+// through the manifest's ReleaseNotesAnnotation, through an attached artifact of type
+// ReleaseNotesArtifactType (the newest, if several were attached), and as a markdown layer of the
+// manifest artifact itself.
+func FetchReleaseNotes(ctx context.Context, reference string, opts RegistryClientOptions) (*ReleaseNotes, error) {
+	trimmedRef := strings.TrimPrefix(reference, "oci://")
+	repoPart, refPart := splitRepositoryAndReference(trimmedRef)
+	if repoPart == "" {
+		return nil, fmt.Errorf("invalid manifest reference: %s", reference)
+	}
+	if refPart == "" {
+		refPart = "latest"
+	}
+	repo, err := newOrasRepository(repoPart, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, manifest, err := fetchOCIManifest(ctx, repo, refPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest %s: %v", trimmedRef, err)
+	}
+	notes := &ReleaseNotes{Manifest: assembleTargetReference(repoPart, refPart)}
+
+	if target := manifest.Annotations[ReleaseNotesAnnotation]; target != "" {
+		targetRepo, targetRef := repo, target
+		notes.Artifact = assembleTargetReference(repoPart, target)
+		if strings.Contains(target, "/") {
+			otherRepo, otherRef := splitRepositoryAndReference(strings.TrimPrefix(target, "oci://"))
+			if targetRepo, err = newOrasRepository(otherRepo, opts); err != nil {
+				return nil, err
+			}
+			targetRef, notes.Artifact = otherRef, strings.TrimPrefix(target, "oci://")
+		}
+		notes.Source = ReleaseNotesFromAnnotation
+		if notes.File, notes.Markdown, err = readReleaseNotesArtifact(ctx, targetRepo, targetRef); err != nil {
+			return nil, fmt.Errorf("failed to read the release notes %s named by the manifest: %v", notes.Artifact, err)
+		}
+		return notes, nil
+	}
+
+	attached, err := latestReferrer(ctx, repo, desc, ReleaseNotesArtifactType)
+	if err != nil {
+		// Registries without the referrers API or its tag fallback simply have none attached
+		LogDebug("Failed to list the artifacts attached to %s: %v", trimmedRef, err)
+	}
+	if attached != nil {
+		notes.Source = ReleaseNotesFromReferrer
+		notes.Artifact = repoPart + "@" + attached.Digest.String()
+		if notes.File, notes.Markdown, err = readReleaseNotesArtifact(ctx, repo, attached.Digest.String()); err != nil {
+			return nil, fmt.Errorf("failed to read the release notes attached to %s: %v", trimmedRef, err)
+		}
+		return notes, nil
+	}
+
+	for _, layer := range manifest.Layers {
+		if !isReleaseNotesLayer(layer) {
+			continue
+		}
+		notes.Source = ReleaseNotesFromLayer
+		notes.Artifact = notes.Manifest
+		notes.File = layer.Annotations[ocispec.AnnotationTitle]
+		if notes.Markdown, err = fetchReleaseNotesBlob(ctx, repo, layer); err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %v", notes.File, trimmedRef, err)
+		}
+		return notes, nil
+	}
+	return nil, fmt.Errorf("no release notes published with %s", trimmedRef)
+}
+
+// fetchOCIManifest fetches and decodes an image manifest
+func fetchOCIManifest(ctx context.Context, repo *remote.Repository, ref string) (ocispec.Descriptor, *ocispec.Manifest, error) {
+	desc, data, err := oras.FetchBytes(ctx, repo, ref, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return desc, &manifest, nil
+}
+
+// latestReferrer returns the most recently created artifact of a type attached to a manifest
+func latestReferrer(ctx context.Context, repo *remote.Repository, desc ocispec.Descriptor, artifactType string) (*ocispec.Descriptor, error) {
+	var latest *ocispec.Descriptor
+	err := repo.Referrers(ctx, desc, artifactType, func(referrers []ocispec.Descriptor) error {
+		for i := range referrers {
+			if latest == nil || referrers[i].Annotations[ocispec.AnnotationCreated] >= latest.Annotations[ocispec.AnnotationCreated] {
+				latest = &referrers[i]
+			}
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// readReleaseNotesArtifact returns the markdown layer of a release notes artifact, or its only
+// layer
+func readReleaseNotesArtifact(ctx context.Context, repo *remote.Repository, ref string) (string, string, error) {
+	_, manifest, err := fetchOCIManifest(ctx, repo, ref)
+	if err != nil {
+		return "", "", err
+	}
+	var layer *ocispec.Descriptor
+	for i := range manifest.Layers {
+		if isReleaseNotesLayer(manifest.Layers[i]) || len(manifest.Layers) == 1 {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return "", "", fmt.Errorf("the artifact has %d layers and none is markdown", len(manifest.Layers))
+	}
+	markdown, err := fetchReleaseNotesBlob(ctx, repo, *layer)
+	return layer.Annotations[ocispec.AnnotationTitle], markdown, err
+}
+
+// isReleaseNotesLayer reports whether a layer holds release notes, by media type or file name
+func isReleaseNotesLayer(layer ocispec.Descriptor) bool {
+	if strings.HasPrefix(layer.MediaType, "text/markdown") {
+		return true
+	}
+	title := strings.ToLower(path.Base(layer.Annotations[ocispec.AnnotationTitle]))
+	return containsString(releaseNotesFiles, title)
+}
+
+func fetchReleaseNotesBlob(ctx context.Context, repo *remote.Repository, layer ocispec.Descriptor) (string, error) {
+	if layer.Size > maxReleaseNotesSize {
+		return "", fmt.Errorf("release notes are %s, more than the %s limit", FormatBytes(layer.Size), FormatBytes(maxReleaseNotesSize))
+	}
+	data, err := content.FetchAll(ctx, repo, layer)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownImage   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownStrong  = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	markdownCode    = regexp.MustCompile("`([^`]+)`")
+)
+
+// RenderMarkdownText renders markdown as plain text for a terminal: headings are underlined,
+// bullets, links, and emphasis are simplified, and code blocks are indented
+func RenderMarkdownText(markdown string) string {
+	var b strings.Builder
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			b.WriteString("    " + line + "\n")
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			text := renderInlineMarkdown(m[2])
+			b.WriteString(text + "\n")
+			switch len(m[1]) {
+			case 1:
+				b.WriteString(strings.Repeat("=", len([]rune(text))) + "\n")
+			case 2:
+				b.WriteString(strings.Repeat("-", len([]rune(text))) + "\n")
+			}
+			continue
+		}
+		if m := markdownBullet.FindStringSubmatch(line); m != nil {
+			b.WriteString(m[1] + "  • " + renderInlineMarkdown(m[2]) + "\n")
+			continue
+		}
+		b.WriteString(renderInlineMarkdown(line) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func renderInlineMarkdown(s string) string {
+	s = markdownImage.ReplaceAllString(s, "$1")
+	s = markdownLink.ReplaceAllString(s, "$1 ($2)")
+	s = markdownStrong.ReplaceAllString(s, "$2")
+	return markdownCode.ReplaceAllString(s, "$1")
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/file"
+)
+
+// pushTestArtifact pushes files as an artifact to repository:tag and returns its descriptor
+func pushTestArtifact(t *testing.T, repository, tag, artifactType string, files map[string]string, opts oras.PackManifestOptions) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	src := t.TempDir()
+	store, err := file.New(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mediaType := "application/json"
+		if filepath.Ext(name) == ".md" {
+			mediaType = "text/markdown"
+		}
+		layer, err := store.Add(ctx, name, mediaType, filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Layers = append(opts.Layers, layer)
+	}
+	root, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, artifactType, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, root, tag); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := newOrasRepository(repository, RegistryClientOptions{PlainHTTP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := oras.Copy(ctx, store, tag, repo, tag, oras.DefaultCopyOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func TestFetchReleaseNotes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	repository := host + "/dynamoai/manifest"
+	opts := RegistryClientOptions{PlainHTTP: true}
+	ctx := context.Background()
+	manifestFile := map[string]string{"manifest.json": `{"release_version": "3.23.0"}`}
+
+	// No notes at all
+	pushTestArtifact(t, repository, "3.22.0", "application/vnd.dynamoai.manifest.v1", manifestFile, oras.PackManifestOptions{})
+	if _, err := FetchReleaseNotes(ctx, repository+":3.22.0", opts); err == nil {
+		t.Error("Expected an error for a manifest without release notes")
+	}
+
+	// A markdown layer of the manifest artifact
+	pushTestArtifact(t, repository, "3.23.0", "application/vnd.dynamoai.manifest.v1",
+		map[string]string{"manifest.json": `{}`, "RELEASE_NOTES.md": "# 3.23.0\n"}, oras.PackManifestOptions{})
+	notes, err := FetchReleaseNotes(ctx, "oci://"+repository+":3.23.0", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.Source != ReleaseNotesFromLayer || notes.File != "RELEASE_NOTES.md" || notes.Markdown != "# 3.23.0\n" {
+		t.Errorf("Unexpected notes from the layer %+v", notes)
+	}
+
+	// An attached artifact takes precedence over the layer; the newest one wins
+	subject := pushTestArtifact(t, repository, "3.23.1", "application/vnd.dynamoai.manifest.v1",
+		map[string]string{"manifest.json": `{}`, "CHANGELOG.md": "old"}, oras.PackManifestOptions{})
+	for _, n := range []struct{ tag, created, text string }{
+		{"notes-b", "2026-10-02T00:00:00Z", "# 3.23.1\n\nFixed the guard timeout.\n"},
+		{"notes-a", "2026-10-01T00:00:00Z", "draft"},
+	} {
+		pushTestArtifact(t, repository, n.tag, ReleaseNotesArtifactType, map[string]string{"notes.md": n.text}, oras.PackManifestOptions{
+			Subject:             &subject,
+			ManifestAnnotations: map[string]string{ocispec.AnnotationCreated: n.created},
+		})
+	}
+	notes, err = FetchReleaseNotes(ctx, repository+":3.23.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.Source != ReleaseNotesFromReferrer || notes.Markdown != "# 3.23.1\n\nFixed the guard timeout.\n" {
+		t.Errorf("Unexpected attached notes %+v", notes)
+	}
+
+	// The annotation names the notes explicitly
+	pushTestArtifact(t, host+"/dynamoai/release-notes", "3.24.0", ReleaseNotesArtifactType, map[string]string{"notes.md": "# 3.24.0\n"}, oras.PackManifestOptions{})
+	pushTestArtifact(t, repository, "3.24.0", "application/vnd.dynamoai.manifest.v1", manifestFile, oras.PackManifestOptions{
+		ManifestAnnotations: map[string]string{ReleaseNotesAnnotation: "oci://" + host + "/dynamoai/release-notes:3.24.0"},
+	})
+	notes, err = FetchReleaseNotes(ctx, repository+":3.24.0", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.Source != ReleaseNotesFromAnnotation || notes.Artifact != host+"/dynamoai/release-notes:3.24.0" || notes.Markdown != "# 3.24.0\n" {
+		t.Errorf("Unexpected annotated notes %+v", notes)
+	}
+}
+
+func TestRenderMarkdownText(t *testing.T) {
+	markdown := "# Dynamo AI 3.23.0\n\n## Highlights\n\n- **Faster** guard models, see [docs](https://docs.dynamo.ai)\n  * Use `vllm` 0.6\n\n```\nhelm upgrade\n```\n### Fixes ###\n"
+	want := "Dynamo AI 3.23.0\n================\n\nHighlights\n----------\n\n  • Faster guard models, see docs (https://docs.dynamo.ai)\n    • Use vllm 0.6\n\n    helm upgrade\nFixes\n"
+	if got := RenderMarkdownText(markdown); got != want {
+		t.Errorf("RenderMarkdownText =\n%s\nwant\n%s", got, want)
+	}
+}
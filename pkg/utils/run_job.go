@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// runJobWorkDir is the Job's working directory and $HOME, holding caches and dynactl's config
+	runJobWorkDir = "/work"
+	// runJobDockerConfigDir is where the Docker config Secret is mounted; DOCKER_CONFIG points at it
+	runJobDockerConfigDir = "/etc/dynactl/docker"
+	// DefaultRunJobTimeout bounds an in-cluster run, long enough for a full mirror
+	DefaultRunJobTimeout = 6 * time.Hour
+	// DefaultRunJobCPU and DefaultRunJobMemory size the run's container
+	DefaultRunJobCPU    = "2"
+	DefaultRunJobMemory = "4Gi"
+)
+
+// RunJobOptions configures a Job that runs a dynactl command inside the cluster
+type RunJobOptions struct {
+	Namespace string
+	// Image is a container image with dynactl on its PATH
+	Image string
+	// ServiceAccount the Job runs as, granting the command its cluster permissions; empty uses
+	// the namespace's default
+	ServiceAccount string
+	// Args is the dynactl command line to run, without the dynactl itself
+	Args []string
+	// DockerConfigSecret names a kubernetes.io/dockerconfigjson Secret the command reads registry
+	// credentials from
+	DockerConfigSecret string
+	// SecretMounts mounts Secrets, by name, at the given paths, e.g. a CA bundle for --ca-file
+	SecretMounts map[string]string
+	// PullSecrets are imagePullSecrets for Image
+	PullSecrets []string
+	// CPU and Memory are the container's requests and limits
+	CPU    string
+	Memory string
+	// WorkPVC is mounted at /work for caches that outlive the pod; empty uses an emptyDir
+	WorkPVC string
+	// Timeout becomes the Job's active deadline
+	Timeout time.Duration
+}
+
+// RunJobResult reports how an in-cluster run ended
+type RunJobResult struct {
+	Job       string
+	Pod       string
+	Succeeded bool
+	// ExitCode and Message come from the container's terminated state; the message is the tail
+	// of its output when it failed
+	ExitCode int32
+	Message  string
+	Duration time.Duration
+}
+
+// NewRunJob builds the Job that runs dynactl with opts.Args. It never retries, since a failed
+// mirror or backup should be looked at rather than repeated, and is removed a day after finishing.
+func NewRunJob(opts RunJobOptions) (*batchv1.Job, error) {
+	if opts.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if opts.Image == "" {
+		return nil, fmt.Errorf("an image with dynactl is required")
+	}
+	if len(opts.Args) == 0 {
+		return nil, fmt.Errorf("a dynactl command to run is required")
+	}
+	if opts.Args[0] == "run" {
+		return nil, fmt.Errorf("dynactl run cannot run itself in the cluster")
+	}
+	if opts.CPU == "" {
+		opts.CPU = DefaultRunJobCPU
+	}
+	if opts.Memory == "" {
+		opts.Memory = DefaultRunJobMemory
+	}
+	cpu, err := resource.ParseQuantity(opts.CPU)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CPU %q: %v", opts.CPU, err)
+	}
+	memory, err := resource.ParseQuantity(opts.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory %q: %v", opts.Memory, err)
+	}
+	resources := corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory}
+
+	env := []corev1.EnvVar{{Name: "HOME", Value: runJobWorkDir}}
+	mounts := []corev1.VolumeMount{{Name: "work", MountPath: runJobWorkDir}}
+	work := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	if opts.WorkPVC != "" {
+		work = corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: opts.WorkPVC}}
+	}
+	volumes := []corev1.Volume{{Name: "work", VolumeSource: work}}
+	if opts.DockerConfigSecret != "" {
+		env = append(env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: runJobDockerConfigDir})
+		mounts = append(mounts, corev1.VolumeMount{Name: "docker-config", MountPath: runJobDockerConfigDir, ReadOnly: true})
+		volumes = append(volumes, corev1.Volume{
+			Name: "docker-config",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: opts.DockerConfigSecret,
+				Items:      []corev1.KeyToPath{{Key: corev1.DockerConfigJsonKey, Path: "config.json"}},
+			}},
+		})
+	}
+	secrets := make([]string, 0, len(opts.SecretMounts))
+	for name := range opts.SecretMounts {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(secrets)
+	for i, name := range secrets {
+		path := opts.SecretMounts[name]
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("mount path %q of Secret %s must be absolute", path, name)
+		}
+		volume := fmt.Sprintf("secret-%d", i)
+		mounts = append(mounts, corev1.VolumeMount{Name: volume, MountPath: path, ReadOnly: true})
+		volumes = append(volumes, corev1.Volume{Name: volume, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: name}}})
+	}
+	var pullSecrets []corev1.LocalObjectReference
+	for _, name := range opts.PullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+
+	labels := map[string]string{"app.kubernetes.io/name": "dynactl-run", "app.kubernetes.io/managed-by": "dynactl"}
+	backoff := int32(0)
+	ttl := int32(24 * 60 * 60)
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dynactl-run-",
+			Namespace:    opts.Namespace,
+			Labels:       labels,
+			Annotations:  map[string]string{"dynactl.dynamo.ai/command": strings.Join(opts.Args, " ")},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoff,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: opts.ServiceAccount,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ImagePullSecrets:   pullSecrets,
+					Containers: []corev1.Container{{
+						Name:                     "dynactl",
+						Image:                    opts.Image,
+						Command:                  []string{"dynactl"},
+						Args:                     opts.Args,
+						WorkingDir:               runJobWorkDir,
+						Env:                      env,
+						VolumeMounts:             mounts,
+						Resources:                corev1.ResourceRequirements{Requests: resources, Limits: resources},
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					}},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+	if opts.Timeout > 0 {
+		deadline := int64(opts.Timeout.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	return job, nil
+}
+
+// RenderRunJob returns the Job as YAML
+func RenderRunJob(job *batchv1.Job) ([]byte, error) {
+	return yaml.Marshal(job)
+}
+
+// RunJob creates the Job, streams its pod's output to out as it runs, and waits for it to
+// finish. The Job is deleted afterwards unless keep is set.
+func (kc *KubernetesChecker) RunJob(ctx context.Context, job *batchv1.Job, keep bool, out io.Writer) (*RunJobResult, error) {
+	ns := job.Namespace
+	jobs := kc.clientset.BatchV1().Jobs(ns)
+	created, err := jobs.Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Job in %s: %v", ns, err)
+	}
+	started := time.Now()
+	result := &RunJobResult{Job: created.Name}
+	LogInfo("Created Job %s/%s", ns, created.Name)
+	if !keep {
+		defer func() {
+			propagation := metav1.DeletePropagationBackground
+			err := jobs.Delete(context.Background(), created.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+			if err != nil {
+				LogWarning("Failed to delete Job %s: %v", created.Name, err)
+			}
+		}()
+	}
+
+	pod, err := kc.waitForRunPod(ctx, ns, created.Name)
+	if err != nil {
+		return result, err
+	}
+	result.Pod = pod.Name
+
+	stream, err := kc.streamClientset.CoreV1().Pods(ns).GetLogs(pod.Name, &corev1.PodLogOptions{Follow: true}).Stream(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to stream logs of %s: %v", pod.Name, err)
+	}
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintln(out, scanner.Text())
+	}
+	stream.Close()
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		LogWarning("Log stream of %s ended early: %v", pod.Name, err)
+	}
+
+	// The log stream ends when the container exits; the Job's status follows shortly after
+	for {
+		j, err := jobs.Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return result, fmt.Errorf("failed to get Job %s: %v", created.Name, err)
+		}
+		if done, failed := jobFinished(j); done {
+			result.Succeeded = !failed
+			if failed {
+				result.Message = jobFailure(j)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	result.Duration = time.Since(started).Round(time.Second)
+
+	p, err := kc.clientset.CoreV1().Pods(ns).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err == nil {
+		for _, cs := range p.Status.ContainerStatuses {
+			if t := cs.State.Terminated; t != nil {
+				result.ExitCode = t.ExitCode
+				if msg := strings.TrimSpace(t.Message); msg != "" && !result.Succeeded {
+					result.Message = msg
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// waitForRunPod waits for the Job's pod to start running, or to have finished already. A pod
+// that cannot pull its image, or a Job that fails first, ends the wait rather than hanging.
+func (kc *KubernetesChecker) waitForRunPod(ctx context.Context, namespace, job string) (*corev1.Pod, error) {
+	selector := metav1.ListOptions{LabelSelector: batchv1.JobNameLabel + "=" + job}
+	for {
+		j, err := kc.clientset.BatchV1().Jobs(namespace).Get(ctx, job, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Job %s: %v", job, err)
+		}
+		if done, failed := jobFinished(j); done && failed {
+			// e.g. the deadline passed while the pod could not be scheduled
+			return nil, fmt.Errorf("job %s failed before its pod ran: %s", job, jobFailure(j))
+		}
+		pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods of Job %s: %v", job, err)
+		}
+		for i := range pods.Items {
+			p := &pods.Items[i]
+			switch p.Status.Phase {
+			case corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed:
+				return p, nil
+			}
+			if reason := podWaitingReason(p); reason == "ErrImagePull" || reason == "ImagePullBackOff" || reason == "InvalidImageName" {
+				return nil, fmt.Errorf("pod %s cannot pull its image (%s); pass --image with a mirrored copy and --pull-secret if it needs one", p.Name, reason)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// jobFinished reports whether a Job has completed or failed
+func jobFinished(job *batchv1.Job) (done, failed bool) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, false
+		case batchv1.JobFailed:
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// jobFailure describes why a Job failed, e.g. that it passed its deadline
+func jobFailure(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return strings.TrimSpace(c.Reason + ": " + c.Message)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestNewRunJob(t *testing.T) {
+	job, err := NewRunJob(RunJobOptions{
+		Namespace:          "dynamo",
+		Image:              "registry.example.com/dynactl:v1.4.0",
+		ServiceAccount:     "dynactl-mirror",
+		Args:               []string{"artifacts", "mirror", "--file", "manifest.json", "--target-registry", "harbor.example.com/dynamo"},
+		DockerConfigSecret: "registry-creds",
+		SecretMounts:       map[string]string{"registry-ca": "/etc/dynactl/ca"},
+		WorkPVC:            "mirror-cache",
+		Timeout:            2 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewRunJob failed: %v", err)
+	}
+
+	if *job.Spec.BackoffLimit != 0 || *job.Spec.ActiveDeadlineSeconds != 7200 {
+		t.Errorf("expected no retries and a 2h deadline, got %d and %d", *job.Spec.BackoffLimit, *job.Spec.ActiveDeadlineSeconds)
+	}
+	pod := job.Spec.Template.Spec
+	if pod.ServiceAccountName != "dynactl-mirror" || pod.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("unexpected pod spec %+v", pod)
+	}
+	c := pod.Containers[0]
+	if strings.Join(c.Args, " ") != "artifacts mirror --file manifest.json --target-registry harbor.example.com/dynamo" {
+		t.Errorf("unexpected args %v", c.Args)
+	}
+	if c.Resources.Limits.Memory().String() != DefaultRunJobMemory || c.Resources.Requests.Cpu().String() != DefaultRunJobCPU {
+		t.Errorf("expected default resources, got %+v", c.Resources)
+	}
+	env := map[string]string{}
+	for _, e := range c.Env {
+		env[e.Name] = e.Value
+	}
+	if env["HOME"] != "/work" || env["DOCKER_CONFIG"] != "/etc/dynactl/docker" {
+		t.Errorf("unexpected env %v", env)
+	}
+	volumes := map[string]corev1.VolumeSource{}
+	for _, v := range pod.Volumes {
+		volumes[v.Name] = v.VolumeSource
+	}
+	if volumes["work"].PersistentVolumeClaim == nil || volumes["work"].PersistentVolumeClaim.ClaimName != "mirror-cache" {
+		t.Errorf("expected /work on the PVC, got %+v", volumes["work"])
+	}
+	if s := volumes["docker-config"].Secret; s == nil || s.SecretName != "registry-creds" || s.Items[0].Key != corev1.DockerConfigJsonKey {
+		t.Errorf("unexpected Docker config volume %+v", volumes["docker-config"])
+	}
+	if s := volumes["secret-0"].Secret; s == nil || s.SecretName != "registry-ca" {
+		t.Errorf("unexpected Secret volume %+v", volumes["secret-0"])
+	}
+
+	data, err := RenderRunJob(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed batchv1.Job
+	if err := yaml.Unmarshal(data, &parsed); err != nil || parsed.GenerateName != "dynactl-run-" {
+		t.Errorf("expected the Job to render as YAML, got %v", err)
+	}
+}
+
+func TestNewRunJobValidation(t *testing.T) {
+	base := RunJobOptions{Namespace: "dynamo", Image: "dynactl:v1", Args: []string{"backup", "create", "-n", "dynamo"}}
+	cases := map[string]func(o *RunJobOptions){
+		"namespace":  func(o *RunJobOptions) { o.Namespace = "" },
+		"image":      func(o *RunJobOptions) { o.Image = "" },
+		"command":    func(o *RunJobOptions) { o.Args = nil },
+		"run itself": func(o *RunJobOptions) { o.Args = []string{"run", "--in-cluster"} },
+		"memory":     func(o *RunJobOptions) { o.Memory = "lots" },
+		"mount path": func(o *RunJobOptions) { o.SecretMounts = map[string]string{"ca": "etc/ca"} },
+	}
+	for name, mutate := range cases {
+		opts := base
+		mutate(&opts)
+		if _, err := NewRunJob(opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJobFinished(t *testing.T) {
+	job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"},
+	}}}
+	if done, failed := jobFinished(job); !done || !failed {
+		t.Errorf("expected a failed Job, got done=%v failed=%v", done, failed)
+	}
+	if msg := jobFailure(job); msg != "DeadlineExceeded: Job was active longer than specified deadline" {
+		t.Errorf("unexpected failure %q", msg)
+	}
+	if done, _ := jobFinished(&batchv1.Job{}); done {
+		t.Error("expected a Job without conditions to be running")
+	}
+}
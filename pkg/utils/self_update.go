@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Release channels accepted by self-update
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// ReleaseChannels lists the supported release channels
+var ReleaseChannels = []string{ChannelStable, ChannelBeta}
+
+// defaultReleasesURL lists dynactl releases; releasesURLEnv points self-update at a mirror
+const (
+	defaultReleasesURL = "https://api.github.com/repos/dynamofl/dynactl/releases?per_page=20"
+	releasesURLEnv     = "DYNACTL_RELEASES_URL"
+)
+
+// checksumsAsset is the release asset listing the SHA-256 of every archive
+const checksumsAsset = "checksums.txt"
+
+// Update check settings for the startup hint
+const (
+	updateCheckFileName = "update-check.json"
+	updateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 3 * time.Second
+	// noUpdateCheckEnv disables the startup hint when set to any value
+	noUpdateCheckEnv = "DYNACTL_NO_UPDATE_CHECK"
+)
+
+// maxBinarySize bounds downloaded archives and the extracted binary
+const maxBinarySize = 512 << 20
+
+// Release is a published dynactl release
+type Release struct {
+	Tag        string         `json:"tag_name"`
+	Prerelease bool           `json:"prerelease"`
+	Draft      bool           `json:"draft"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a downloadable file attached to a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release version without the leading "v"
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// asset returns the release asset with the given name
+func (r Release) asset(name string) (ReleaseAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+// ArchiveName returns the release archive for this platform, matching the release workflow
+func (r Release) ArchiveName() string {
+	ext := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("dynactl-v%s-%s-%s%s", r.Version(), runtime.GOOS, runtime.GOARCH, ext)
+}
+
+// LatestRelease returns the newest release on the channel. Stable skips prereleases; beta
+// includes them.
+func LatestRelease(ctx context.Context, client *http.Client, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown release channel %q (use %s)", channel, strings.Join(ReleaseChannels, " or "))
+	}
+	url := defaultReleasesURL
+	if override := os.Getenv(releasesURLEnv); override != "" {
+		url = override
+	}
+
+	var releases []Release
+	if err := getJSON(ctx, client, url, &releases); err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var latest *Release
+	var latestVersion *semver.Version
+	for i := range releases {
+		r := releases[i]
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		v, err := semver.NewVersion(r.Version())
+		if err != nil {
+			LogDebug("Ignoring release %s: %v", r.Tag, err)
+			continue
+		}
+		if latestVersion == nil || v.GreaterThan(latestVersion) {
+			latest, latestVersion = &r, v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no %s releases found", channel)
+	}
+	return latest, nil
+}
+
+// IsNewerVersion reports whether candidate is a higher version than current. Unparseable
+// current versions (such as development builds) are never considered outdated.
+func IsNewerVersion(current, candidate string) bool {
+	c, err := semver.NewVersion(strings.TrimPrefix(current, "v"))
+	if err != nil {
+		return false
+	}
+	n, err := semver.NewVersion(strings.TrimPrefix(candidate, "v"))
+	if err != nil {
+		return false
+	}
+	return n.GreaterThan(c)
+}
+
+// DownloadReleaseBinary downloads this platform's archive from the release, checks it against
+// the release's SHA-256 checksums, and returns the dynactl binary inside it
+func DownloadReleaseBinary(ctx context.Context, client *http.Client, release *Release) ([]byte, error) {
+	name := release.ArchiveName()
+	archive, ok := release.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s (%s)", release.Tag, runtime.GOOS, runtime.GOARCH, name)
+	}
+	sums, ok := release.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s does not publish %s; refusing to install an unverified binary", release.Tag, checksumsAsset)
+	}
+
+	sumData, err := download(ctx, client, sums.URL, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	want, err := checksumFor(sumData, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := download(ctx, client, archive.URL, maxBinarySize)
+	if err != nil {
+		return nil, err
+	}
+	got := sha256.Sum256(data)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %x", name, want, got)
+	}
+	LogInfo("Verified SHA-256 of %s", name)
+
+	if strings.HasSuffix(name, ".zip") {
+		return binaryFromZip(data)
+	}
+	return binaryFromTarGz(data)
+}
+
+// ReplaceExecutable swaps the running binary for data. The new file is written next to the
+// current one and renamed over it so a failed update leaves the old binary in place.
+func ReplaceExecutable(data []byte) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", exe, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".dynactl-update-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to %s (try again with sudo): %w", filepath.Dir(exe), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return "", fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	// Windows cannot replace a running executable, but it can rename it out of the way
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return "", fmt.Errorf("failed to move the current binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return "", fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return exe, nil
+}
+
+// updateCheckState caches the last startup version check
+type updateCheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Channel   string    `json:"channel"`
+	Latest    string    `json:"latest"`
+}
+
+// UpdateCheckDisabled reports whether the startup hint is turned off by environment or config
+func UpdateCheckDisabled() bool {
+	if os.Getenv(noUpdateCheckEnv) != "" {
+		return true
+	}
+	cfg, err := LoadConfig()
+	return err == nil && cfg.Update.DisableCheck
+}
+
+// UpdateChannel returns the channel configured for update checks, defaulting to stable
+func UpdateChannel() string {
+	cfg, err := LoadConfig()
+	if err != nil || cfg.Update.Channel == "" {
+		return ChannelStable
+	}
+	return cfg.Update.Channel
+}
+
+// RefreshUpdateCheck looks up the latest release at most once per day and caches the result
+// for NewVersionHint. Failures, including being offline, are only logged at debug level, and
+// also wait out the day, keeping the release found by the last successful check.
+func RefreshUpdateCheck(ctx context.Context, channel string) {
+	path, err := updateCheckPath()
+	if err != nil {
+		return
+	}
+	state, err := readUpdateCheck(path)
+	if err != nil || state.Channel != channel {
+		state = &updateCheckState{Channel: channel}
+	} else if time.Since(state.CheckedAt) < updateCheckInterval {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+	release, err := LatestRelease(ctx, &http.Client{Timeout: updateCheckTimeout}, channel)
+	if err != nil {
+		LogDebug("Update check failed: %v", err)
+	} else {
+		state.Latest = release.Version()
+	}
+	state.CheckedAt = time.Now().UTC()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		LogDebug("Failed to cache update check: %v", err)
+	}
+}
+
+// NewVersionHint returns a one-line notice when the cached update check found a newer release
+// than current, or an empty string
+func NewVersionHint(current, channel string) string {
+	path, err := updateCheckPath()
+	if err != nil {
+		return ""
+	}
+	state, err := readUpdateCheck(path)
+	if err != nil || state.Channel != channel || !IsNewerVersion(current, state.Latest) {
+		return ""
+	}
+	return fmt.Sprintf("A new version of dynactl is available: v%s (current v%s). Run `dynactl self-update` to install it, or set %s=1 to hide this notice.",
+		state.Latest, strings.TrimPrefix(current, "v"), noUpdateCheckEnv)
+}
+
+func updateCheckPath() (string, error) {
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, updateCheckFileName), nil
+}
+
+func readUpdateCheck(path string) (*updateCheckState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state updateCheckState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// writeFileAtomic writes data through a temporary file so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// download fetches a URL into memory, failing if the body is larger than limit
+func download(ctx context.Context, client *http.Client, rawURL string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", rawURL, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", rawURL, limit)
+	}
+	return data, nil
+}
+
+// checksumFor finds a file's SHA-256 in sha256sum output
+func checksumFor(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s does not list %s", checksumsAsset, name)
+}
+
+// isDynactlBinary reports whether an archive entry is the dynactl executable
+func isDynactlBinary(name string) bool {
+	base := path.Base(name)
+	return base == "dynactl" || base == "dynactl.exe"
+}
+
+func binaryFromTarGz(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && isDynactlBinary(hdr.Name) {
+			return io.ReadAll(io.LimitReader(tr, maxBinarySize))
+		}
+	}
+	return nil, fmt.Errorf("release archive does not contain a dynactl binary")
+}
+
+func binaryFromZip(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isDynactlBinary(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release archive: %w", err)
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxBinarySize))
+	}
+	return nil, fmt.Errorf("release archive does not contain a dynactl binary")
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func tarGzWith(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// releaseServer serves a release list and the assets of its newest release
+func releaseServer(t *testing.T, releases []Release, archive []byte, checksum string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/releases":
+			for i := range releases {
+				name := releases[i].ArchiveName()
+				releases[i].Assets = []ReleaseAsset{
+					{Name: name, URL: srv.URL + "/download/" + name},
+					{Name: checksumsAsset, URL: srv.URL + "/download/" + checksumsAsset},
+				}
+			}
+			_ = json.NewEncoder(w).Encode(releases)
+		case r.URL.Path == "/download/"+checksumsAsset:
+			fmt.Fprint(w, checksum)
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv(releasesURLEnv, srv.URL+"/releases")
+	return srv
+}
+
+func TestLatestRelease(t *testing.T) {
+	releaseServer(t, []Release{
+		{Tag: "v0.4.0-beta.1", Prerelease: true},
+		{Tag: "v0.5.0", Draft: true},
+		{Tag: "v0.3.1"},
+		{Tag: "v0.3.0"},
+		{Tag: "nightly"},
+	}, nil, "")
+
+	for channel, want := range map[string]string{ChannelStable: "v0.3.1", ChannelBeta: "v0.4.0-beta.1"} {
+		r, err := LatestRelease(context.Background(), http.DefaultClient, channel)
+		if err != nil {
+			t.Fatalf("LatestRelease(%s) failed: %v", channel, err)
+		}
+		if r.Tag != want {
+			t.Errorf("LatestRelease(%s) = %s, want %s", channel, r.Tag, want)
+		}
+	}
+	if _, err := LatestRelease(context.Background(), http.DefaultClient, "nightly"); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+}
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		current, candidate string
+		want               bool
+	}{
+		{"0.2.3", "0.3.0", true},
+		{"0.2.3", "v0.2.3", false},
+		{"0.3.0", "0.2.9", false},
+		{"0.3.0-beta.1", "0.3.0", true},
+		{"dev", "0.3.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewerVersion(tt.current, tt.candidate); got != tt.want {
+			t.Errorf("IsNewerVersion(%q, %q) = %v, want %v", tt.current, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadReleaseBinary(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho dynactl\n")
+	archive := tarGzWith(t, "dynactl", binary)
+	release := Release{Tag: "v0.3.0"}
+	sum := sha256.Sum256(archive)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("windows releases are zip archives")
+	}
+
+	releaseServer(t, []Release{release}, archive, fmt.Sprintf("%x  %s\n", sum, release.ArchiveName()))
+	latest, err := LatestRelease(context.Background(), http.DefaultClient, ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DownloadReleaseBinary(context.Background(), http.DefaultClient, latest)
+	if err != nil {
+		t.Fatalf("DownloadReleaseBinary failed: %v", err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Errorf("unexpected binary %q", got)
+	}
+
+	releaseServer(t, []Release{release}, archive, fmt.Sprintf("%064x  %s\n", 0, release.ArchiveName()))
+	latest, _ = LatestRelease(context.Background(), http.DefaultClient, ChannelStable)
+	if _, err := DownloadReleaseBinary(context.Background(), http.DefaultClient, latest); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestNewVersionHint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	releaseServer(t, []Release{{Tag: "v0.3.0"}}, nil, "")
+
+	if hint := NewVersionHint("0.2.3", ChannelStable); hint != "" {
+		t.Errorf("expected no hint before a check, got %q", hint)
+	}
+	RefreshUpdateCheck(context.Background(), ChannelStable)
+	if hint := NewVersionHint("0.2.3", ChannelStable); !strings.Contains(hint, "v0.3.0") {
+		t.Errorf("expected a hint for v0.3.0, got %q", hint)
+	}
+	if hint := NewVersionHint("0.3.0", ChannelStable); hint != "" {
+		t.Errorf("expected no hint when up to date, got %q", hint)
+	}
+	if hint := NewVersionHint("0.2.3", ChannelBeta); hint != "" {
+		t.Errorf("a check on another channel should not produce a hint, got %q", hint)
+	}
+}
+
+func TestRefreshUpdateCheckFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	t.Setenv(releasesURLEnv, srv.URL)
+
+	RefreshUpdateCheck(context.Background(), ChannelStable)
+	RefreshUpdateCheck(context.Background(), ChannelStable)
+	if requests != 1 {
+		t.Errorf("expected a failed check to wait out the interval, got %d requests", requests)
+	}
+	path, _ := updateCheckPath()
+	if state, err := readUpdateCheck(path); err != nil || state.CheckedAt.IsZero() || state.Latest != "" {
+		t.Errorf("expected the failed check to be recorded without a release, got %+v (%v)", state, err)
+	}
+}
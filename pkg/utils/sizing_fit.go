@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SizingProfile is the per-component resource profile of a release tier, e.g. sizing/medium.yaml
+type SizingProfile struct {
+	Name       string         `json:"name"`
+	Components []ModelProfile `json:"components"`
+}
+
+// ComponentFit is where one component's replicas land in a sizing simulation
+type ComponentFit struct {
+	Component  string
+	Replicas   int
+	Placed     int
+	Fits       bool
+	Placements []PoolPlacement `json:",omitempty"`
+	// Reasons summarizes why the remaining nodes reject an unplaced replica, e.g.
+	// "2 node(s) had untolerated taint nvidia.com/gpu"
+	Reasons []string `json:",omitempty"`
+}
+
+// SizingFitResult is the outcome of simulating a sizing profile against the cluster's nodes
+type SizingFitResult struct {
+	Profile    string
+	Nodes      int
+	Fits       bool
+	Components []ComponentFit
+}
+
+// LoadSizingProfile reads a release sizing profile from a YAML or JSON file
+func LoadSizingProfile(path string) (*SizingProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sizing profile: %w", err)
+	}
+
+	var profile SizingProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse sizing profile %s: %w", path, err)
+	}
+	if len(profile.Components) == 0 {
+		return nil, fmt.Errorf("sizing profile %s has no components", path)
+	}
+	for i := range profile.Components {
+		c := &profile.Components[i]
+		if c.Name == "" {
+			return nil, fmt.Errorf("sizing profile %s: component %d has no name", path, i+1)
+		}
+		if c.Replicas <= 0 {
+			c.Replicas = 1
+		}
+	}
+	if profile.Name == "" {
+		profile.Name = path
+	}
+	return &profile, nil
+}
+
+// SimulateSizingFit bin-packs a sizing profile onto the cluster's ready, schedulable nodes
+func (kc *KubernetesChecker) SimulateSizingFit(ctx context.Context, profile SizingProfile) (*SizingFitResult, error) {
+	nodes, err := kc.ListNodeCapacities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return SimulateSizingFit(nodes, profile)
+}
+
+// SimulateSizingFit places every component's replicas onto one shared copy of the nodes, so each
+// component only sees what the ones before it left free. Components are packed largest first
+// (GPUs, then memory, then CPU) the way a first-fit-decreasing bin packer does, which keeps small
+// components from fragmenting the GPU nodes; results are reported in profile order.
+func SimulateSizingFit(nodes []NodeCapacity, profile SizingProfile) (*SizingFitResult, error) {
+	type demand struct {
+		index    int
+		cpu, mem float64
+	}
+	demands := make([]demand, len(profile.Components))
+	for i, c := range profile.Components {
+		cpu, mem, err := profileQuantities(c)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %v", c.Name, err)
+		}
+		demands[i] = demand{index: i, cpu: cpu, mem: mem}
+	}
+	sort.SliceStable(demands, func(a, b int) bool {
+		ca, cb := profile.Components[demands[a].index], profile.Components[demands[b].index]
+		if ca.GPU != cb.GPU {
+			return ca.GPU > cb.GPU
+		}
+		if demands[a].mem != demands[b].mem {
+			return demands[a].mem > demands[b].mem
+		}
+		return demands[a].cpu > demands[b].cpu
+	})
+
+	free := make([]NodeCapacity, len(nodes))
+	copy(free, nodes)
+
+	result := &SizingFitResult{Profile: profile.Name, Nodes: len(nodes), Fits: true, Components: make([]ComponentFit, len(profile.Components))}
+	for _, d := range demands {
+		c := profile.Components[d.index]
+		var eligible []*NodeCapacity
+		for i := range free {
+			if nodeEligible(&free[i], c) {
+				eligible = append(eligible, &free[i])
+			}
+		}
+
+		fit := ComponentFit{Component: c.Name, Replicas: c.Replicas}
+		fit.Placements, fit.Placed = placeReplicas(eligible, c.Replicas, d.cpu, d.mem, c.GPU)
+		fit.Fits = fit.Placed == c.Replicas
+		if !fit.Fits {
+			fit.Reasons = unschedulableReasons(free, c, d.cpu, d.mem)
+			result.Fits = false
+		}
+		result.Components[d.index] = fit
+	}
+	return result, nil
+}
+
+// unschedulableReasons counts, per reason, the nodes that cannot take one more replica, like the
+// scheduler's "0/5 nodes are available" message
+func unschedulableReasons(nodes []NodeCapacity, profile ModelProfile, cpu, mem float64) []string {
+	if len(nodes) == 0 {
+		return []string{"no ready, schedulable nodes"}
+	}
+	counts := map[string]int{}
+	for i := range nodes {
+		n := &nodes[i]
+		if reason := nodeIneligibility(n, profile); reason != "" {
+			counts[reason]++
+			continue
+		}
+		var short []string
+		if n.CPUFree < cpu {
+			short = append(short, "cpu")
+		}
+		if n.MemFree < mem {
+			short = append(short, "memory")
+		}
+		if n.GPUFree < profile.GPU {
+			short = append(short, "gpu")
+		}
+		if len(short) > 0 {
+			counts["had insufficient "+strings.Join(short, ", ")]++
+		}
+	}
+
+	var reasons []string
+	for _, reason := range sortedKeys(counts) {
+		reasons = append(reasons, fmt.Sprintf("%d node(s) %s", counts[reason], reason))
+	}
+	return reasons
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadSizingProfile(t *testing.T) {
+	profile, err := LoadSizingProfile("../../examples/sizing/medium.yaml")
+	if err != nil {
+		t.Fatalf("LoadSizingProfile returned error: %v", err)
+	}
+	if profile.Name != "medium" || len(profile.Components) != 4 {
+		t.Fatalf("Expected 4 components in medium, got %s with %d", profile.Name, len(profile.Components))
+	}
+	if guard := profile.Components[0]; guard.GPU != 1 || guard.GPUType != "A100" {
+		t.Errorf("Expected the guard model to need one A100, got %+v", guard)
+	}
+}
+
+func TestSimulateSizingFit(t *testing.T) {
+	gpuTaint := []string{"nvidia.com/gpu"}
+	nodes := []NodeCapacity{
+		{Name: "cpu-1", Pool: "general", Labels: map[string]string{}, CPUAllocatable: 8, MemAllocatable: 32, CPUFree: 6, MemFree: 24},
+		{Name: "gpu-a100", Pool: "a100", Labels: map[string]string{gpuProductLabel: "NVIDIA-A100-SXM4-80GB"}, TaintKeys: gpuTaint,
+			CPUAllocatable: 32, MemAllocatable: 128, GPUAllocatable: 2, CPUFree: 20, MemFree: 100, GPUFree: 2},
+		{Name: "gpu-t4", Pool: "t4", Labels: map[string]string{gpuProductLabel: "Tesla-T4"}, TaintKeys: gpuTaint,
+			CPUAllocatable: 8, MemAllocatable: 32, GPUAllocatable: 1, CPUFree: 8, MemFree: 30, GPUFree: 1},
+	}
+	profile := SizingProfile{Name: "medium", Components: []ModelProfile{
+		{Name: "api", Replicas: 2, CPU: "2", Memory: "4Gi"},
+		{Name: "guard", Replicas: 3, CPU: "4", Memory: "16Gi", GPU: 1, GPUType: "a100", Tolerations: gpuTaint},
+		{Name: "worker", Replicas: 1, CPU: "4", Memory: "8Gi"},
+	}}
+
+	result, err := SimulateSizingFit(nodes, profile)
+	if err != nil {
+		t.Fatalf("SimulateSizingFit returned error: %v", err)
+	}
+	if result.Fits || len(result.Components) != 3 {
+		t.Fatalf("Expected 3 components not all fitting, got %+v", result)
+	}
+
+	// The worker is packed before the smaller api replicas and takes most of the general node
+	api, guard, worker := result.Components[0], result.Components[1], result.Components[2]
+	if api.Component != "api" || api.Placed != 1 || api.Fits {
+		t.Errorf("Expected 1 of 2 api replicas placed, got %+v", api)
+	}
+	if want := []string{"1 node(s) had insufficient cpu", "2 node(s) had untolerated taint nvidia.com/gpu"}; !reflect.DeepEqual(api.Reasons, want) {
+		t.Errorf("api reasons = %q, want %q", api.Reasons, want)
+	}
+	if guard.Placed != 2 || len(guard.Placements) != 1 || guard.Placements[0].Pool != "a100" {
+		t.Errorf("Expected 2 guard replicas on the a100 pool, got %+v", guard)
+	}
+	if want := []string{"1 node(s) didn't have GPU type a100", "1 node(s) had insufficient gpu", "1 node(s) had no GPUs"}; !reflect.DeepEqual(guard.Reasons, want) {
+		t.Errorf("guard reasons = %q, want %q", guard.Reasons, want)
+	}
+	if !worker.Fits || worker.Reasons != nil {
+		t.Errorf("Expected the worker to fit, got %+v", worker)
+	}
+
+	// The simulation must not consume the caller's nodes
+	if nodes[0].CPUFree != 6 || nodes[1].GPUFree != 2 {
+		t.Errorf("SimulateSizingFit modified its input nodes: %+v", nodes)
+	}
+}
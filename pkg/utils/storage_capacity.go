@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// noProvisioner is the provisioner of StorageClasses backed by pre-created local volumes
+const noProvisioner = "kubernetes.io/no-provisioner"
+
+// StorageThresholds are the usage percentages at which the storage check warns and fails
+type StorageThresholds struct {
+	Warn float64
+	Fail float64
+}
+
+// DefaultStorageThresholds warns at the PVC usage threshold and fails when a volume is nearly full
+var DefaultStorageThresholds = StorageThresholds{Warn: PVCUsageThreshold, Fail: 95}
+
+// Validate checks that 0 < Warn <= Fail <= 100
+func (t StorageThresholds) Validate() error {
+	if t.Warn <= 0 || t.Fail > 100 || t.Warn > t.Fail {
+		return fmt.Errorf("thresholds must satisfy 0 < warn (%.0f) <= fail (%.0f) <= 100", t.Warn, t.Fail)
+	}
+	return nil
+}
+
+// status grades a usage percentage
+func (t StorageThresholds) status(percent float64) string {
+	switch {
+	case percent > t.Fail:
+		return CheckFail
+	case percent > t.Warn:
+		return CheckWarn
+	}
+	return CheckPass
+}
+
+// StorageClassCapacity breaks storage down for one StorageClass
+type StorageClassCapacity struct {
+	StorageClass string
+	// Dynamic classes provision volumes on demand, so the PVs that exist say nothing about room
+	// left; classes without a provisioner can only bind the PVs an administrator created
+	Dynamic     bool
+	PVCs        int
+	MountedPVCs int
+	// Filesystem usage of the mounted claims, from kubelet volume stats
+	CapacityBytes  int64
+	UsedBytes      int64
+	AvailableBytes int64
+	UsedPercent    float64
+	// PersistentVolume capacity bound to claims and still available to bind. Bound capacity is
+	// allocated, not used.
+	BoundBytes   int64
+	UnboundBytes int64
+	Status       string
+}
+
+// BoundPercent is the share of the class's PV capacity bound to claims
+func (c StorageClassCapacity) BoundPercent() float64 {
+	if total := c.BoundBytes + c.UnboundBytes; total > 0 {
+		return float64(c.BoundBytes) / float64(total) * 100
+	}
+	return 0
+}
+
+// StorageCapacityResult is the outcome of the storage capacity check
+type StorageCapacityResult struct {
+	Status     string
+	Message    string
+	Thresholds StorageThresholds
+	// VolumeStats is false when no kubelet volume stats were available and the check fell back
+	// to how much statically provisioned PV capacity is bound
+	VolumeStats bool
+	Classes     []StorageClassCapacity
+	// Warning and Failing list the mounted PVCs above each threshold
+	Warning []PVCUsage
+	Failing []PVCUsage
+}
+
+// Err returns an error carrying the message unless the check passed
+func (r *StorageCapacityResult) Err() error {
+	if r.Status == CheckPass {
+		return nil
+	}
+	return fmt.Errorf("%s", r.Message)
+}
+
+// CheckStorageCapacity grades actual PVC filesystem usage reported by kubelet volume stats
+// against the thresholds, with a per-StorageClass breakdown. It errors only when the cluster
+// cannot be queried; problems found are reported through the result's status.
+func (kc *KubernetesChecker) CheckStorageCapacity(ctx context.Context, thresholds StorageThresholds) (*StorageCapacityResult, error) {
+	if err := thresholds.Validate(); err != nil {
+		return nil, err
+	}
+	usages, err := kc.ListPVCUsage(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	pvs, err := kc.clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %v", err)
+	}
+	classes, err := kc.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		LogWarning("Failed to list storage classes, treating every class as statically provisioned: %v", err)
+		classes = &storagev1.StorageClassList{}
+	}
+	return summarizeStorageCapacity(usages, pvs.Items, classes.Items, thresholds), nil
+}
+
+// summarizeStorageCapacity builds the check result from claim usage and the cluster's PVs
+func summarizeStorageCapacity(usages []PVCUsage, pvs []corev1.PersistentVolume, storageClasses []storagev1.StorageClass, thresholds StorageThresholds) *StorageCapacityResult {
+	result := &StorageCapacityResult{Status: CheckPass, Thresholds: thresholds}
+	provisioners := map[string]string{}
+	for _, sc := range storageClasses {
+		provisioners[sc.Name] = sc.Provisioner
+	}
+
+	byClass := map[string]*StorageClassCapacity{}
+	class := func(name string) *StorageClassCapacity {
+		c, ok := byClass[name]
+		if !ok {
+			provisioner, known := provisioners[name]
+			c = &StorageClassCapacity{StorageClass: name, Dynamic: known && provisioner != noProvisioner, Status: CheckPass}
+			byClass[name] = c
+		}
+		return c
+	}
+
+	var mounted int
+	var capacity, used int64
+	for _, u := range usages {
+		c := class(u.StorageClass)
+		c.PVCs++
+		if !u.Mounted {
+			continue
+		}
+		mounted++
+		capacity += u.CapacityBytes
+		used += u.UsedBytes
+		c.MountedPVCs++
+		c.CapacityBytes += u.CapacityBytes
+		c.UsedBytes += u.UsedBytes
+		c.AvailableBytes += u.AvailableBytes
+		switch thresholds.status(u.UsedPercent) {
+		case CheckFail:
+			result.Failing = append(result.Failing, u)
+			c.Status = worseStatus(c.Status, CheckFail)
+		case CheckWarn:
+			result.Warning = append(result.Warning, u)
+			c.Status = worseStatus(c.Status, CheckWarn)
+		}
+	}
+	for _, pv := range pvs {
+		size := pv.Spec.Capacity[corev1.ResourceStorage]
+		c := class(pv.Spec.StorageClassName)
+		switch pv.Status.Phase {
+		case corev1.VolumeBound:
+			c.BoundBytes += size.Value()
+		case corev1.VolumeAvailable:
+			c.UnboundBytes += size.Value()
+		}
+	}
+
+	for _, c := range byClass {
+		if c.CapacityBytes > 0 {
+			c.UsedPercent = float64(c.UsedBytes) / float64(c.CapacityBytes) * 100
+		}
+		result.Classes = append(result.Classes, *c)
+	}
+	sort.Slice(result.Classes, func(i, j int) bool { return result.Classes[i].StorageClass < result.Classes[j].StorageClass })
+
+	result.VolumeStats = mounted > 0
+	if result.VolumeStats {
+		result.Status = thresholdStatus(len(result.Failing), len(result.Warning))
+		if result.Status == CheckPass {
+			result.Message = fmt.Sprintf("adequate storage capacity (%.1f%% used across %d mounted PVCs)", float64(used)/float64(capacity)*100, mounted)
+		} else {
+			var parts []string
+			if len(result.Failing) > 0 {
+				parts = append(parts, fmt.Sprintf("%d PVCs above %.0f%% used: %s", len(result.Failing), thresholds.Fail, pvcList(result.Failing)))
+			}
+			if len(result.Warning) > 0 {
+				parts = append(parts, fmt.Sprintf("%d PVCs above %.0f%% used: %s", len(result.Warning), thresholds.Warn, pvcList(result.Warning)))
+			}
+			result.Message = strings.Join(parts, "; ")
+		}
+		return result
+	}
+
+	// Without volume stats only statically provisioned pools can run out: a dynamic class makes
+	// a new volume for each claim, however much is already bound
+	var static []string
+	var bound, total int64
+	for i := range result.Classes {
+		c := &result.Classes[i]
+		if c.Dynamic || c.BoundBytes+c.UnboundBytes == 0 {
+			continue
+		}
+		bound += c.BoundBytes
+		total += c.BoundBytes + c.UnboundBytes
+		c.Status = thresholds.status(c.BoundPercent())
+		result.Status = worseStatus(result.Status, c.Status)
+		if c.Status != CheckPass {
+			static = append(static, fmt.Sprintf("%s (%.1f%%)", storageClassLabel(c.StorageClass), c.BoundPercent()))
+		}
+	}
+	switch {
+	case total == 0 && len(result.Classes) == 0:
+		result.Message = "no storage configured"
+	case total == 0:
+		result.Message = "volume stats unavailable; storage is provisioned dynamically"
+	case len(static) > 0:
+		result.Message = fmt.Sprintf("statically provisioned PV capacity nearly all bound: %s; volume stats unavailable", strings.Join(static, ", "))
+	default:
+		result.Message = fmt.Sprintf("adequate storage capacity (%.1f%% of statically provisioned PV capacity bound; volume stats unavailable)", float64(bound)/float64(total)*100)
+	}
+	return result
+}
+
+// thresholdStatus is fail when anything failed, else warn when anything warned
+func thresholdStatus(failing, warning int) string {
+	switch {
+	case failing > 0:
+		return CheckFail
+	case warning > 0:
+		return CheckWarn
+	}
+	return CheckPass
+}
+
+// worseStatus returns the more severe of two check statuses
+func worseStatus(a, b string) string {
+	rank := map[string]int{CheckPass: 0, CheckWarn: 1, CheckFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// pvcList renders claims as namespace/name (used%)
+func pvcList(usages []PVCUsage) string {
+	names := make([]string, 0, len(usages))
+	for _, u := range usages {
+		names = append(names, fmt.Sprintf("%s/%s (%.1f%%)", u.Namespace, u.Name, u.UsedPercent))
+	}
+	return strings.Join(names, ", ")
+}
+
+// storageClassLabel names claims and volumes without a StorageClass
+func storageClassLabel(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
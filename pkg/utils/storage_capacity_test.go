@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPV(class string, size string, phase corev1.PersistentVolumePhase) corev1.PersistentVolume {
+	return corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{
+			StorageClassName: class,
+			Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	}
+}
+
+func testStorageClass(name, provisioner string) storagev1.StorageClass {
+	return storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Provisioner: provisioner}
+}
+
+func TestSummarizeStorageCapacityGradesVolumeStats(t *testing.T) {
+	usages := []PVCUsage{
+		{Namespace: "dynamo", Name: "data-postgres-0", StorageClass: "gp3", Mounted: true, CapacityBytes: 100, UsedBytes: 97, UsedPercent: 97},
+		{Namespace: "dynamo", Name: "redis", StorageClass: "gp3", Mounted: true, CapacityBytes: 100, UsedBytes: 85, UsedPercent: 85},
+		{Namespace: "dynamo", Name: "model-cache", StorageClass: "efs", Mounted: true, CapacityBytes: 1000, UsedBytes: 60, UsedPercent: 6},
+		{Namespace: "dynamo", Name: "orphaned", StorageClass: "efs"},
+	}
+	pvs := []corev1.PersistentVolume{testPV("gp3", "100Gi", corev1.VolumeBound), testPV("gp3", "100Gi", corev1.VolumeBound)}
+	classes := []storagev1.StorageClass{testStorageClass("gp3", "ebs.csi.aws.com"), testStorageClass("efs", "efs.csi.aws.com")}
+
+	result := summarizeStorageCapacity(usages, pvs, classes, DefaultStorageThresholds)
+	if result.Status != CheckFail || !result.VolumeStats {
+		t.Fatalf("expected a failing volume stats result, got %+v", result)
+	}
+	if len(result.Failing) != 1 || result.Failing[0].Name != "data-postgres-0" || len(result.Warning) != 1 || result.Warning[0].Name != "redis" {
+		t.Fatalf("unexpected failing %v and warning %v", result.Failing, result.Warning)
+	}
+	if !strings.Contains(result.Message, "1 PVCs above 95% used: dynamo/data-postgres-0") || !strings.Contains(result.Message, "1 PVCs above 80% used: dynamo/redis") {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+	if len(result.Classes) != 2 || result.Classes[0].StorageClass != "efs" || result.Classes[1].StorageClass != "gp3" {
+		t.Fatalf("expected efs and gp3 classes, got %+v", result.Classes)
+	}
+	efs, gp3 := result.Classes[0], result.Classes[1]
+	if efs.Status != CheckPass || efs.PVCs != 2 || efs.MountedPVCs != 1 || !efs.Dynamic {
+		t.Fatalf("unexpected efs breakdown %+v", efs)
+	}
+	if gp3.Status != CheckFail || gp3.UsedPercent != 91 || gp3.BoundBytes != 200<<30 {
+		t.Fatalf("unexpected gp3 breakdown %+v", gp3)
+	}
+
+	// Raising the thresholds turns the same usage into a pass
+	if result := summarizeStorageCapacity(usages, pvs, classes, StorageThresholds{Warn: 98, Fail: 99}); result.Status != CheckPass {
+		t.Fatalf("expected a pass with higher thresholds, got %q", result.Message)
+	}
+}
+
+func TestSummarizeStorageCapacityWithoutVolumeStats(t *testing.T) {
+	classes := []storagev1.StorageClass{testStorageClass("gp3", "ebs.csi.aws.com"), testStorageClass("local", noProvisioner)}
+
+	// Dynamically provisioned volumes are all bound, which says nothing about room left
+	dynamic := []corev1.PersistentVolume{testPV("gp3", "100Gi", corev1.VolumeBound), testPV("gp3", "50Gi", corev1.VolumeBound)}
+	result := summarizeStorageCapacity(nil, dynamic, classes, DefaultStorageThresholds)
+	if result.Status != CheckPass || result.VolumeStats || !strings.Contains(result.Message, "provisioned dynamically") {
+		t.Fatalf("expected dynamic storage to pass, got %s: %s", result.Status, result.Message)
+	}
+
+	// A static pool with one of ten volumes left is nearly exhausted
+	var static []corev1.PersistentVolume
+	for i := 0; i < 9; i++ {
+		static = append(static, testPV("local", "10Gi", corev1.VolumeBound))
+	}
+	static = append(static, testPV("local", "10Gi", corev1.VolumeAvailable))
+	result = summarizeStorageCapacity(nil, append(dynamic, static...), classes, DefaultStorageThresholds)
+	if result.Status != CheckWarn || !strings.Contains(result.Message, "local (90.0%)") {
+		t.Fatalf("expected the static pool to warn, got %s: %s", result.Status, result.Message)
+	}
+
+	if result := summarizeStorageCapacity(nil, nil, nil, DefaultStorageThresholds); result.Message != "no storage configured" {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+}
+
+func TestStorageThresholdsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		thresholds StorageThresholds
+		ok         bool
+	}{
+		{DefaultStorageThresholds, true},
+		{StorageThresholds{Warn: 90, Fail: 90}, true},
+		{StorageThresholds{Warn: 95, Fail: 90}, false},
+		{StorageThresholds{Warn: 0, Fail: 90}, false},
+		{StorageThresholds{Warn: 80, Fail: 101}, false},
+	} {
+		if err := tc.thresholds.Validate(); (err == nil) != tc.ok {
+			t.Errorf("Validate(%+v) = %v", tc.thresholds, err)
+		}
+	}
+}
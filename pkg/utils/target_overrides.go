@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// TargetOverride routes one source repository somewhere other than its mirrored path
+type TargetOverride struct {
+	// Repository is the destination repository. A path such as shared/python-base is placed on
+	// the target registry's host; a reference starting with a host is used as is.
+	Repository string `json:"repository"`
+	// Tag, when set, replaces the source tag or digest
+	Tag string `json:"tag,omitempty"`
+}
+
+// TargetOverridesFile is the sidecar file format, matching the manifest's target_overrides key
+type TargetOverridesFile struct {
+	TargetOverrides map[string]TargetOverride `json:"target_overrides"`
+}
+
+// LoadTargetOverrides reads a YAML or JSON sidecar file of target overrides
+func LoadTargetOverrides(path string) (map[string]TargetOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target overrides: %w", err)
+	}
+	var file TargetOverridesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse target overrides %s: %w", path, err)
+	}
+	if len(file.TargetOverrides) == 0 {
+		return nil, fmt.Errorf("no target_overrides listed in %s", path)
+	}
+	if err := validateTargetOverrides(file.TargetOverrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file.TargetOverrides, nil
+}
+
+// validateTargetOverrides rejects overrides without a destination or with a malformed source
+func validateTargetOverrides(overrides map[string]TargetOverride) error {
+	for source, override := range overrides {
+		if strings.TrimSpace(override.Repository) == "" {
+			return fmt.Errorf("target override for %s has no repository", source)
+		}
+		if strings.ContainsAny(override.Repository, "@") || strings.Contains(override.Tag, ":") {
+			return fmt.Errorf("target override for %s: put the tag in the tag field", source)
+		}
+	}
+	return nil
+}
+
+// MergeTargetOverrides combines the manifest's overrides with a sidecar's, the sidecar winning.
+// Source keys are normalized so oci:// prefixes and tags in the keys do not matter.
+func MergeTargetOverrides(manifest, sidecar map[string]TargetOverride) map[string]TargetOverride {
+	if len(manifest) == 0 && len(sidecar) == 0 {
+		return nil
+	}
+	merged := map[string]TargetOverride{}
+	for _, overrides := range []map[string]TargetOverride{manifest, sidecar} {
+		for source, override := range overrides {
+			merged[overrideKey(source)] = override
+		}
+	}
+	return merged
+}
+
+// overrideKey is the source repository an override key names
+func overrideKey(source string) string {
+	repo, _, _ := splitImageReference(strings.TrimPrefix(strings.TrimSpace(source), "oci://"))
+	return repo
+}
+
+// mirrorTarget returns the target repository and tag or digest a source repository is pushed to,
+// applying its override if there is one
+func mirrorTarget(repoPart, tagOrDigest, targetRegistry string, overrides map[string]TargetOverride) (string, string) {
+	override, ok := overrides[repoPart]
+	if !ok {
+		return buildTargetRepository(targetRegistry, repoPart), tagOrDigest
+	}
+	repo := strings.Trim(override.Repository, "/")
+	if !hasRegistryHost(repo) {
+		repo = RegistryHost(targetRegistry) + "/" + repo
+	}
+	if override.Tag != "" {
+		tagOrDigest = override.Tag
+	}
+	return repo, tagOrDigest
+}
+
+// hasRegistryHost reports whether a repository starts with a registry host, which Docker tells
+// apart from a path by a dot, a port, or localhost in the first segment
+func hasRegistryHost(repository string) bool {
+	first, _, found := strings.Cut(repository, "/")
+	return found && (strings.ContainsAny(first, ".:") || first == "localhost")
+}
+
+// unusedTargetOverrides lists override sources that match none of the given repositories
+func unusedTargetOverrides(overrides map[string]TargetOverride, repositories []string) []string {
+	var unused []string
+	for source := range overrides {
+		if !containsString(repositories, source) {
+			unused = append(unused, source)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestMirrorTarget(t *testing.T) {
+	overrides := MergeTargetOverrides(
+		map[string]TargetOverride{
+			"oci://artifacts.dynamo.ai/dynamoai/images/python-base:3.11": {Repository: "shared/python-base"},
+			"artifacts.dynamo.ai/dynamoai/images/nginx":                  {Repository: "old/nginx"},
+		},
+		map[string]TargetOverride{
+			"artifacts.dynamo.ai/dynamoai/images/nginx": {Repository: "other.example.com:5000/infra/nginx", Tag: "stable"},
+		},
+	)
+
+	tests := []struct {
+		repo, ref, wantRepo, wantRef string
+	}{
+		{"artifacts.dynamo.ai/dynamoai/images/dynamoai-api", "3.22.2", "harbor.example.com/dynamo/dynamoai/images/dynamoai-api", "3.22.2"},
+		{"artifacts.dynamo.ai/dynamoai/images/python-base", "3.11", "harbor.example.com/shared/python-base", "3.11"},
+		// The sidecar wins, and a repository starting with a host is used as is
+		{"artifacts.dynamo.ai/dynamoai/images/nginx", "sha256:abc", "other.example.com:5000/infra/nginx", "stable"},
+	}
+	for _, tt := range tests {
+		repo, ref := mirrorTarget(tt.repo, tt.ref, "harbor.example.com/dynamo", overrides)
+		if repo != tt.wantRepo || ref != tt.wantRef {
+			t.Errorf("mirrorTarget(%s) = %s, %s; want %s, %s", tt.repo, repo, ref, tt.wantRepo, tt.wantRef)
+		}
+	}
+
+	unused := unusedTargetOverrides(overrides, []string{"artifacts.dynamo.ai/dynamoai/images/nginx"})
+	if len(unused) != 1 || unused[0] != "artifacts.dynamo.ai/dynamoai/images/python-base" {
+		t.Fatalf("unexpected unused overrides %v", unused)
+	}
+}
+
+func TestLoadTargetOverrides(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "overrides.yaml")
+	if err := os.WriteFile(path, []byte("target_overrides:\n  artifacts.dynamo.ai/dynamoai/images/python-base:\n    repository: shared/python-base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := LoadTargetOverrides(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overrides["artifacts.dynamo.ai/dynamoai/images/python-base"].Repository != "shared/python-base" {
+		t.Fatalf("unexpected overrides %v", overrides)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"target_overrides": {"a/b": {"tag": "1"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTargetOverrides(path); err == nil || !strings.Contains(err.Error(), "no repository") {
+		t.Fatalf("expected an override without a repository to be rejected, got %v", err)
+	}
+}
+
+func TestMirrorArtifactsAppliesTargetOverrides(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := newTestRegistry(t)
+	cacheDir := t.TempDir()
+
+	var images []string
+	for _, name := range []string{"dynamoai-api", "python-base"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := "artifacts.dynamo.ai/dynamoai/images/" + name + ":3.22.2"
+		if err := crane.Save(img, ref, filepath.Join(cacheDir, name+".tar")); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, "oci://"+ref)
+	}
+	manifest := &ArtifactManifest{
+		Images: images,
+		TargetOverrides: map[string]TargetOverride{
+			"artifacts.dynamo.ai/dynamoai/images/python-base": {Repository: "shared/python-base", Tag: "base"},
+		},
+	}
+
+	var pushed []string
+	err := MirrorArtifacts(manifest, cacheDir, host+"/dynamo", MirrorOptions{
+		IncludeImages: true,
+		Pushed:        func(source, target, digest string) { pushed = append(pushed, target) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{host + "/dynamo/dynamoai/images/dynamoai-api:3.22.2", host + "/shared/python-base:base"}
+	if strings.Join(pushed, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected pushes to %v, got %v", want, pushed)
+	}
+	if _, err := crane.Digest(host + "/shared/python-base:base"); err != nil {
+		t.Fatalf("expected the overridden image in the registry: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTelemetryEndpoint receives usage events unless telemetry.endpoint is configured
+	DefaultTelemetryEndpoint = "https://telemetry.dynamo.ai/v1/dynactl/events"
+	// telemetryEnv turns telemetry off (0, false, off) or on (1, true, on) regardless of the
+	// saved choice
+	telemetryEnv = "DYNACTL_TELEMETRY"
+	// telemetryTimeout bounds sending queued events, so an unreachable endpoint never slows
+	// a command noticeably
+	telemetryTimeout = 3 * time.Second
+	// maxTelemetryQueue bounds the events kept while offline; newer events are dropped
+	maxTelemetryQueue = 256 << 10
+
+	telemetryStateFile = "telemetry.json"
+	telemetryQueueFile = "telemetry-queue.jsonl"
+)
+
+// TelemetryEvent is everything reported about one command run. Arguments, flag values, and
+// error messages are never included since they can name customer resources.
+type TelemetryEvent struct {
+	Time       time.Time `json:"time"`
+	InstallID  string    `json:"install_id"`
+	Command    string    `json:"command"`
+	Version    string    `json:"version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	DurationMS int64     `json:"duration_ms"`
+	Success    bool      `json:"success"`
+}
+
+// TelemetryStatus describes whether usage reporting is on and why
+type TelemetryStatus struct {
+	Enabled   bool
+	Source    string
+	Endpoint  string
+	InstallID string
+	Queued    int
+}
+
+// telemetryState is the choice saved by dynactl telemetry on|off
+type telemetryState struct {
+	Enabled   bool      `json:"enabled"`
+	InstallID string    `json:"install_id,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// SetTelemetry saves the user's telemetry choice. Turning it off also discards queued events.
+func SetTelemetry(enabled bool) error {
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return err
+	}
+	state, _ := readTelemetryState(dir)
+	state.Enabled = enabled
+	state.ChangedAt = time.Now().UTC()
+	if enabled && state.InstallID == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("failed to generate install ID: %w", err)
+		}
+		state.InstallID = hex.EncodeToString(id)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, telemetryStateFile), data); err != nil {
+		return fmt.Errorf("failed to save telemetry setting: %w", err)
+	}
+	if !enabled {
+		for _, name := range []string{telemetryQueueFile, telemetryQueueFile + ".sending"} {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to discard queued telemetry: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetTelemetryStatus resolves the telemetry setting. It is off unless the user ran
+// dynactl telemetry on or set DYNACTL_TELEMETRY=1; DO_NOT_TRACK, DYNACTL_TELEMETRY=0, and
+// telemetry.disabled in the config file always turn it off.
+func GetTelemetryStatus() TelemetryStatus {
+	status := TelemetryStatus{Source: "default", Endpoint: DefaultTelemetryEndpoint}
+	cfg, err := LoadConfig()
+	if err == nil && cfg.Telemetry.Endpoint != "" {
+		status.Endpoint = cfg.Telemetry.Endpoint
+	}
+	dir, dirErr := dynactlHomeDir()
+	var state telemetryState
+	if dirErr == nil {
+		state, _ = readTelemetryState(dir)
+		status.InstallID = state.InstallID
+		status.Queued = countQueuedEvents(dir)
+	}
+
+	switch value := strings.ToLower(os.Getenv(telemetryEnv)); {
+	case err == nil && cfg.Telemetry.Disabled:
+		status.Source = "config file (telemetry.disabled)"
+	case os.Getenv("DO_NOT_TRACK") != "" && os.Getenv("DO_NOT_TRACK") != "0":
+		status.Source = "DO_NOT_TRACK"
+	case value == "0" || value == "false" || value == "off":
+		status.Source = telemetryEnv
+	case value == "1" || value == "true" || value == "on":
+		status.Enabled, status.Source = dirErr == nil, telemetryEnv
+	case !state.ChangedAt.IsZero():
+		status.Enabled, status.Source = state.Enabled, "dynactl telemetry on|off"
+	}
+	return status
+}
+
+// RecordTelemetry queues an event for the next flush when telemetry is enabled. It only
+// touches the local disk, so it is safe to call offline.
+func RecordTelemetry(command, version string, duration time.Duration, success bool) {
+	status := GetTelemetryStatus()
+	if !status.Enabled {
+		return
+	}
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(dir, telemetryQueueFile)
+	if info, err := os.Stat(path); err == nil && info.Size() >= maxTelemetryQueue {
+		LogDebug("Telemetry queue is full; dropping event for %s", command)
+		return
+	}
+	line, err := json.Marshal(TelemetryEvent{
+		Time:       time.Now().UTC(),
+		InstallID:  status.InstallID,
+		Command:    command,
+		Version:    version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		DurationMS: duration.Milliseconds(),
+		Success:    success,
+	})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		LogDebug("Failed to queue telemetry: %v", err)
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// FlushTelemetry sends queued events to the telemetry endpoint when telemetry is enabled.
+// Events that cannot be sent, including while offline, stay queued for a later run; failures
+// are only logged at debug level.
+func FlushTelemetry(ctx context.Context) {
+	status := GetTelemetryStatus()
+	if !status.Enabled {
+		return
+	}
+	dir, err := dynactlHomeDir()
+	if err != nil {
+		return
+	}
+	// Events recorded while sending go to a fresh queue file and are sent next time
+	queue := filepath.Join(dir, telemetryQueueFile)
+	sending := queue + ".sending"
+	if _, err := os.Stat(sending); os.IsNotExist(err) {
+		if err := os.Rename(queue, sending); err != nil {
+			return
+		}
+	}
+	events, err := readQueuedEvents(sending)
+	if err != nil || len(events) == 0 {
+		_ = os.Remove(sending)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, telemetryTimeout)
+	defer cancel()
+	if err := postTelemetry(ctx, status.Endpoint, events); err != nil {
+		LogDebug("Telemetry not sent, keeping %d event(s) queued: %v", len(events), err)
+		return
+	}
+	_ = os.Remove(sending)
+}
+
+func postTelemetry(ctx context.Context, endpoint string, events []TelemetryEvent) error {
+	body, err := json.Marshal(map[string]interface{}{"events": events})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: telemetryTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func readTelemetryState(dir string) (telemetryState, error) {
+	var state telemetryState
+	data, err := os.ReadFile(filepath.Join(dir, telemetryStateFile))
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// readQueuedEvents parses a queue file, skipping lines damaged by an interrupted write
+func readQueuedEvents(path string) ([]TelemetryEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []TelemetryEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event TelemetryEvent
+		if json.Unmarshal(scanner.Bytes(), &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+func countQueuedEvents(dir string) int {
+	count := 0
+	for _, name := range []string{telemetryQueueFile, telemetryQueueFile + ".sending"} {
+		events, err := readQueuedEvents(filepath.Join(dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		count += len(events)
+	}
+	return count
+}
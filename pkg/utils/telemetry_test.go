@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupTelemetryHome(t *testing.T, endpoint string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("DYNACTL_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+	config := filepath.Join(home, "config.yaml")
+	if err := os.WriteFile(config, []byte("telemetry:\n  endpoint: "+endpoint+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DYNACTL_CONFIG", config)
+	return home
+}
+
+func TestTelemetryIsOffByDefault(t *testing.T) {
+	home := setupTelemetryHome(t, "http://127.0.0.1:1")
+
+	if status := GetTelemetryStatus(); status.Enabled || status.Source != "default" {
+		t.Fatalf("telemetry must default to off, got %+v", status)
+	}
+	RecordTelemetry("dynactl cluster check", "1.0.0", time.Second, true)
+	if _, err := os.Stat(filepath.Join(home, ".dynactl", telemetryQueueFile)); !os.IsNotExist(err) {
+		t.Fatalf("no events may be queued before opting in: %v", err)
+	}
+}
+
+func TestTelemetryRecordsAndFlushesEvents(t *testing.T) {
+	var received []TelemetryEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []TelemetryEvent `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		received = append(received, body.Events...)
+	}))
+	defer server.Close()
+	setupTelemetryHome(t, server.URL)
+
+	if err := SetTelemetry(true); err != nil {
+		t.Fatal(err)
+	}
+	status := GetTelemetryStatus()
+	if !status.Enabled || status.InstallID == "" || status.Endpoint != server.URL {
+		t.Fatalf("unexpected status after opting in: %+v", status)
+	}
+
+	RecordTelemetry("dynactl artifacts mirror", "1.0.0", 1500*time.Millisecond, false)
+	RecordTelemetry("dynactl cluster check", "1.0.0", time.Second, true)
+	if got := GetTelemetryStatus().Queued; got != 2 {
+		t.Fatalf("expected 2 queued events, got %d", got)
+	}
+
+	FlushTelemetry(context.Background())
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events sent, got %d", len(received))
+	}
+	first := received[0]
+	if first.Command != "dynactl artifacts mirror" || first.Success || first.DurationMS != 1500 || first.InstallID != status.InstallID {
+		t.Fatalf("unexpected event %+v", first)
+	}
+	if got := GetTelemetryStatus().Queued; got != 0 {
+		t.Fatalf("sent events must leave the queue, %d left", got)
+	}
+}
+
+func TestTelemetryKeepsEventsWhileOffline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	endpoint := server.URL
+	server.Close()
+	setupTelemetryHome(t, endpoint)
+
+	if err := SetTelemetry(true); err != nil {
+		t.Fatal(err)
+	}
+	RecordTelemetry("dynactl cluster check", "1.0.0", time.Second, true)
+	FlushTelemetry(context.Background())
+	RecordTelemetry("dynactl cluster info", "1.0.0", time.Second, true)
+	if got := GetTelemetryStatus().Queued; got != 2 {
+		t.Fatalf("unsent events must stay queued, got %d", got)
+	}
+
+	if err := SetTelemetry(false); err != nil {
+		t.Fatal(err)
+	}
+	if status := GetTelemetryStatus(); status.Enabled || status.Queued != 0 {
+		t.Fatalf("turning telemetry off must discard queued events, got %+v", status)
+	}
+}
+
+func TestTelemetryOverrides(t *testing.T) {
+	setupTelemetryHome(t, "http://127.0.0.1:1")
+	if err := SetTelemetry(true); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DYNACTL_TELEMETRY", "off")
+	if status := GetTelemetryStatus(); status.Enabled {
+		t.Fatalf("DYNACTL_TELEMETRY=off must win over the saved choice, got %+v", status)
+	}
+	t.Setenv("DYNACTL_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "1")
+	if status := GetTelemetryStatus(); status.Enabled || status.Source != "DO_NOT_TRACK" {
+		t.Fatalf("DO_NOT_TRACK must disable telemetry, got %+v", status)
+	}
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// TransferStat describes how much of one pushed artifact was actually uploaded. Layers the
+// target registry already had, or mounted from another repository, count as skipped.
+type TransferStat struct {
+	Artifact       string        `json:"artifact"`
+	Target         string        `json:"target"`
+	Layers         int           `json:"layers"`
+	UploadedLayers int           `json:"uploaded_layers"`
+	TotalBytes     int64         `json:"total_bytes"`
+	UploadedBytes  int64         `json:"uploaded_bytes"`
+	Duration       time.Duration `json:"duration_ns"`
+}
+
+// SkippedBytes is the layer data the registry already had
+func (s TransferStat) SkippedBytes() int64 {
+	return s.TotalBytes - s.UploadedBytes
+}
+
+// TransferReport sums the transfer statistics of a mirror run
+type TransferReport struct {
+	Artifacts []TransferStat `json:"artifacts"`
+	Total     TransferStat   `json:"total"`
+	// SavedPercent is the share of layer bytes that did not need uploading
+	SavedPercent float64 `json:"saved_percent"`
+	// BytesPerSecond is the upload rate achieved for layers that were sent
+	BytesPerSecond int64 `json:"bytes_per_second"`
+}
+
+// NewTransferReport totals per-artifact statistics
+func NewTransferReport(stats []TransferStat) *TransferReport {
+	report := &TransferReport{Artifacts: stats, Total: TransferStat{Artifact: "TOTAL"}}
+	if report.Artifacts == nil {
+		report.Artifacts = []TransferStat{}
+	}
+	for _, s := range stats {
+		report.Total.Layers += s.Layers
+		report.Total.UploadedLayers += s.UploadedLayers
+		report.Total.TotalBytes += s.TotalBytes
+		report.Total.UploadedBytes += s.UploadedBytes
+		report.Total.Duration += s.Duration
+	}
+	if report.Total.TotalBytes > 0 {
+		report.SavedPercent = float64(report.Total.SkippedBytes()) * 100 / float64(report.Total.TotalBytes)
+	}
+	if seconds := report.Total.Duration.Seconds(); seconds > 0 {
+		report.BytesPerSecond = int64(float64(report.Total.UploadedBytes) / seconds)
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *TransferReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Summary is a one-line description of the savings
+func (r *TransferReport) Summary() string {
+	return fmt.Sprintf("uploaded %s of %s in layers (%d of %d layers); %s (%.1f%%) already present in the target",
+		FormatBytes(r.Total.UploadedBytes), FormatBytes(r.Total.TotalBytes),
+		r.Total.UploadedLayers, r.Total.Layers, FormatBytes(r.Total.SkippedBytes()), r.SavedPercent)
+}
+
+// uploadTracker records which layers of an image remote.Write actually streams. The writer
+// only opens a layer's compressed contents when the registry does not already have the blob,
+// so a layer that is never opened was skipped or mounted.
+type uploadTracker struct {
+	mu       sync.Mutex
+	sizes    map[v1.Hash]int64
+	uploaded map[v1.Hash]bool
+}
+
+// trackUploads wraps an image so pushes through it can be measured
+func trackUploads(img v1.Image) (v1.Image, *uploadTracker, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, err
+	}
+	tracker := &uploadTracker{sizes: map[v1.Hash]int64{}, uploaded: map[v1.Hash]bool{}}
+	wrapped := make([]v1.Layer, 0, len(layers))
+	for _, l := range layers {
+		digest, err := l.Digest()
+		if err != nil {
+			return nil, nil, err
+		}
+		size, err := l.Size()
+		if err != nil {
+			return nil, nil, err
+		}
+		tracker.sizes[digest] = size
+		wrapped = append(wrapped, &trackedLayer{Layer: l, digest: digest, tracker: tracker})
+	}
+	return &trackedImage{Image: img, layers: wrapped}, tracker, nil
+}
+
+func (t *uploadTracker) markUploaded(digest v1.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.uploaded[digest] = true
+}
+
+// stat summarizes the tracked uploads for one artifact
+func (t *uploadTracker) stat(artifact, target string, duration time.Duration) TransferStat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stat := TransferStat{Artifact: artifact, Target: target, Layers: len(t.sizes), Duration: duration}
+	for digest, size := range t.sizes {
+		stat.TotalBytes += size
+		if t.uploaded[digest] {
+			stat.UploadedLayers++
+			stat.UploadedBytes += size
+		}
+	}
+	return stat
+}
+
+type trackedImage struct {
+	v1.Image
+	layers []v1.Layer
+}
+
+func (i *trackedImage) Layers() ([]v1.Layer, error) {
+	return i.layers, nil
+}
+
+type trackedLayer struct {
+	v1.Layer
+	digest  v1.Hash
+	tracker *uploadTracker
+}
+
+func (l *trackedLayer) Compressed() (io.ReadCloser, error) {
+	l.tracker.markUploaded(l.digest)
+	return l.Layer.Compressed()
+}
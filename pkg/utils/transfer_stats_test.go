@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestTrackUploadsCountsOnlySentLayers(t *testing.T) {
+	host := newTestRegistry(t)
+
+	base, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, tracker, err := trackUploads(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pushImage(first, host+"/dynamoai/api:1", authn.DefaultKeychain); err != nil {
+		t.Fatal(err)
+	}
+	stat := tracker.stat("api:1", host+"/dynamoai/api:1", time.Second)
+	if stat.Layers != 2 || stat.UploadedLayers != 2 || stat.SkippedBytes() != 0 {
+		t.Fatalf("expected both layers to be uploaded, got %+v", stat)
+	}
+
+	// A new release shares the base layers and adds one more
+	extra, err := random.Layer(512, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := mutate.AppendLayers(base, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, tracker, err := trackUploads(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pushImage(second, host+"/dynamoai/api:2", authn.DefaultKeychain); err != nil {
+		t.Fatal(err)
+	}
+	stat = tracker.stat("api:2", host+"/dynamoai/api:2", time.Second)
+	extraSize, _ := extra.Size()
+	if stat.Layers != 3 || stat.UploadedLayers != 1 || stat.UploadedBytes != extraSize {
+		t.Fatalf("expected only the new layer to be uploaded, got %+v", stat)
+	}
+
+	report := NewTransferReport([]TransferStat{
+		tracker.stat("api:2", "", time.Second),
+		{Artifact: "web:2", Layers: 1, TotalBytes: 100, Duration: time.Second},
+	})
+	if report.Total.Layers != 4 || report.Total.UploadedLayers != 1 || report.Total.TotalBytes != stat.TotalBytes+100 {
+		t.Fatalf("unexpected totals %+v", report.Total)
+	}
+	if report.SavedPercent <= 0 || report.SavedPercent >= 100 {
+		t.Fatalf("unexpected saved percentage %.1f", report.SavedPercent)
+	}
+}
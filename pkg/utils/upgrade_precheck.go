@@ -0,0 +1,563 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Upgrade pre-check names
+const (
+	PrecheckManifest       = "manifest"
+	PrecheckReleases       = "releases"
+	PrecheckArtifacts      = "artifacts"
+	PrecheckResources      = "resources"
+	PrecheckAPIDeprecation = "api-deprecations"
+	PrecheckLicense        = "license"
+)
+
+// DefaultLicenseExpiryWarning is how close to its expiry a license is reported
+const DefaultLicenseExpiryWarning = 30 * 24 * time.Hour
+
+// Release changes
+const (
+	ReleaseUpgrade      = "upgrade"
+	ReleaseUnchanged    = "unchanged"
+	ReleaseDowngrade    = "downgrade"
+	ReleaseNotInstalled = "not-installed"
+	ReleaseNotInTarget  = "not-in-release"
+)
+
+// Artifact changes
+const (
+	ArtifactAdded   = "added"
+	ArtifactRemoved = "removed"
+	ArtifactChanged = "changed"
+)
+
+// UpgradePrecheckOptions configures an upgrade pre-check
+type UpgradePrecheckOptions struct {
+	Namespace string
+	// Target is the manifest of the release to upgrade to
+	Target *ArtifactManifest
+	// Current is the manifest of the installed release; when nil, the installed charts and the
+	// images of the namespace's pods are read from the cluster
+	Current *ArtifactManifest
+	// Profile is the target release's sizing profile; the resources check is skipped without it
+	Profile *SizingProfile
+	// LicenseWarning is how close to its expiry the license is reported
+	LicenseWarning time.Duration
+	// Now is the time license expiry is measured from
+	Now time.Time
+}
+
+// ReleaseChange is what the upgrade does to one chart
+type ReleaseChange struct {
+	// Release is the installed Helm release, empty when the chart is not installed
+	Release string `json:"release,omitempty"`
+	Chart   string `json:"chart"`
+	// Status is the Helm status of the release's latest revision
+	Status string `json:"status,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Change string `json:"change"`
+}
+
+// ArtifactChange is an image, model, or chart that differs between the releases
+type ArtifactChange struct {
+	// Type is chart, image, or model
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Change string `json:"change"`
+}
+
+// UpgradePrecheckReport is the go/no-go report of an upgrade pre-check
+type UpgradePrecheckReport struct {
+	Namespace string           `json:"namespace"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Releases  []ReleaseChange  `json:"releases"`
+	Artifacts []ArtifactChange `json:"artifacts"`
+	Checks    []CheckResult    `json:"checks"`
+	Go        bool             `json:"go"`
+}
+
+// removedAPI is an API version Kubernetes stopped serving; an empty Kind covers the whole version
+type removedAPI struct {
+	APIVersion  string
+	Kind        string
+	RemovedIn   string
+	Replacement string
+}
+
+// removedAPIs are the API versions removed since Kubernetes 1.22 that charts commonly used
+var removedAPIs = []removedAPI{
+	{"extensions/v1beta1", "", "1.22", "apps/v1 or networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "", "1.22", "networking.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "", "1.22", "apiextensions.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "", "1.22", "admissionregistration.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "", "1.22", "scheduling.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "", "1.22", "certificates.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "1.25", "batch/v1"},
+	{"policy/v1beta1", "PodDisruptionBudget", "1.25", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "1.25", "Pod Security Admission"},
+	{"discovery.k8s.io/v1beta1", "", "1.25", "discovery.k8s.io/v1"},
+	{"autoscaling/v2beta1", "", "1.25", "autoscaling/v2"},
+	{"autoscaling/v2beta2", "", "1.26", "autoscaling/v2"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "1.27", "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// manifestObject is the part of a rendered object the API scan reads
+type manifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+}
+
+// UpgradePrecheck cross-references the installed release with the target manifest and reports
+// whether the upgrade can go ahead. Only a failed check makes it a no-go.
+func (kc *KubernetesChecker) UpgradePrecheck(ctx context.Context, opts UpgradePrecheckOptions) (*UpgradePrecheckReport, error) {
+	if opts.LicenseWarning == 0 {
+		opts.LicenseWarning = DefaultLicenseExpiryWarning
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	releases, err := kc.helmReleases(ctx, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	current := opts.Current
+	if current == nil {
+		if current, err = kc.installedManifest(ctx, opts.Namespace, releases, opts.Target); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &UpgradePrecheckReport{
+		Namespace: opts.Namespace,
+		From:      current.ReleaseVersion,
+		To:        opts.Target.ReleaseVersion,
+		Releases:  releaseChanges(releases, opts.Target),
+		// Models live on PVCs, so they are only compared against a manifest
+		Artifacts: diffArtifacts(current, opts.Target, opts.Current != nil),
+	}
+	report.Checks = append(report.Checks,
+		checkUpgradeManifest(current, opts.Target),
+		checkReleaseChanges(report.Releases),
+		summarizeArtifactChanges(report.Artifacts),
+		kc.checkUpgradeResources(ctx, opts.Namespace, opts.Profile),
+		kc.checkRemovedAPIs(ctx, releases),
+		kc.checkUpgradeLicense(ctx, opts),
+	)
+	report.Go = true
+	for _, c := range report.Checks {
+		if c.Status == CheckFail {
+			report.Go = false
+		}
+	}
+	return report, nil
+}
+
+// helmReleases returns the latest revision of each Helm release in the namespace, whatever its
+// status
+func (kc *KubernetesChecker) helmReleases(ctx context.Context, namespace string) ([]*release.Release, error) {
+	store := driver.NewSecrets(kc.clientset.CoreV1().Secrets(namespace))
+	releases, err := store.List(func(r *release.Release) bool {
+		return r.Info == nil || r.Info.Status != release.StatusUninstalled
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Helm releases in %s: %v", namespace, err)
+	}
+	return latestReleases(releases), nil
+}
+
+// installedManifest describes what is installed as a manifest: the charts of the Helm releases
+// and the images the namespace's pods run. The release version is the app version of the
+// installed charts the target manifest ships.
+func (kc *KubernetesChecker) installedManifest(ctx context.Context, namespace string, releases []*release.Release, target *ArtifactManifest) (*ArtifactManifest, error) {
+	m := &ArtifactManifest{}
+	targetCharts := map[string]bool{}
+	for _, c := range target.Charts {
+		targetCharts[c.Name] = true
+	}
+	for _, r := range releases {
+		name, version, appVersion := releaseChart(r)
+		m.Charts = append(m.Charts, Chart{Name: name, Version: version, AppVersion: appVersion})
+		if targetCharts[name] && m.ReleaseVersion == "" {
+			m.ReleaseVersion = appVersion
+		}
+	}
+
+	pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %v", namespace, err)
+	}
+	seen := map[string]bool{}
+	for _, pod := range pods.Items {
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, c := range containers {
+			if !seen[c.Image] {
+				seen[c.Image] = true
+				m.Images = append(m.Images, c.Image)
+			}
+		}
+	}
+	sort.Strings(m.Images)
+	return m, nil
+}
+
+// releaseChart returns the chart name, version, and app version of a release
+func releaseChart(r *release.Release) (string, string, string) {
+	if r.Chart == nil || r.Chart.Metadata == nil {
+		return r.Name, "", ""
+	}
+	return r.Chart.Metadata.Name, r.Chart.Metadata.Version, r.Chart.Metadata.AppVersion
+}
+
+// releaseChanges matches each installed release to the target chart of the same name
+func releaseChanges(releases []*release.Release, target *ArtifactManifest) []ReleaseChange {
+	var changes []ReleaseChange
+	installed := map[string]bool{}
+	for _, r := range releases {
+		name, version, _ := releaseChart(r)
+		installed[name] = true
+		change := ReleaseChange{Release: r.Name, Chart: name, From: version, Change: ReleaseNotInTarget}
+		if r.Info != nil {
+			change.Status = r.Info.Status.String()
+		}
+		for _, c := range target.Charts {
+			if c.Name == name {
+				change.To = c.Version
+				change.Change = compareChartVersions(version, c.Version)
+			}
+		}
+		changes = append(changes, change)
+	}
+	for _, c := range target.Charts {
+		if !installed[c.Name] {
+			changes = append(changes, ReleaseChange{Chart: c.Name, To: c.Version, Change: ReleaseNotInstalled})
+		}
+	}
+	return changes
+}
+
+// compareChartVersions classifies a chart version change; versions that are not semver only
+// compare equal or not
+func compareChartVersions(from, to string) string {
+	if from == to {
+		return ReleaseUnchanged
+	}
+	f, errFrom := semver.NewVersion(from)
+	t, errTo := semver.NewVersion(to)
+	if errFrom == nil && errTo == nil && t.LessThan(f) {
+		return ReleaseDowngrade
+	}
+	return ReleaseUpgrade
+}
+
+// checkUpgradeManifest checks this dynactl supports the target manifest and that it is newer than
+// the installed release
+func checkUpgradeManifest(current, target *ArtifactManifest) CheckResult {
+	if err := CheckManifestCompatibility(target); err != nil {
+		return CheckResult{Name: PrecheckManifest, Status: CheckFail, Message: err.Error()}
+	}
+	if current.ReleaseVersion == "" || target.ReleaseVersion == "" {
+		return CheckResult{Name: PrecheckManifest, Status: CheckWarn, Message: "release version unknown; cannot tell whether this is an upgrade"}
+	}
+	from, errFrom := semver.NewVersion(strings.TrimPrefix(current.ReleaseVersion, "v"))
+	to, errTo := semver.NewVersion(strings.TrimPrefix(target.ReleaseVersion, "v"))
+	switch {
+	case errFrom != nil || errTo != nil:
+		return CheckResult{Name: PrecheckManifest, Status: CheckWarn, Message: fmt.Sprintf("%s -> %s; versions are not semver, so the direction is not checked", current.ReleaseVersion, target.ReleaseVersion)}
+	case to.LessThan(from):
+		return CheckResult{Name: PrecheckManifest, Status: CheckFail, Message: fmt.Sprintf("%s is older than the installed %s; downgrades are not supported", target.ReleaseVersion, current.ReleaseVersion)}
+	case to.Equal(from):
+		return CheckResult{Name: PrecheckManifest, Status: CheckWarn, Message: fmt.Sprintf("%s is already installed", target.ReleaseVersion)}
+	}
+	return CheckResult{Name: PrecheckManifest, Status: CheckPass, Message: fmt.Sprintf("%s -> %s", current.ReleaseVersion, target.ReleaseVersion)}
+}
+
+// checkReleaseChanges fails on releases Helm cannot upgrade: those stuck in a failed or pending
+// state, and charts the target release ships older
+func checkReleaseChanges(changes []ReleaseChange) CheckResult {
+	var stuck, downgrades, upgrades, missing []string
+	for _, c := range changes {
+		if c.Release != "" && c.Status != "" && c.Status != release.StatusDeployed.String() {
+			stuck = append(stuck, fmt.Sprintf("%s is %s", c.Release, c.Status))
+		}
+		switch c.Change {
+		case ReleaseDowngrade:
+			downgrades = append(downgrades, fmt.Sprintf("%s %s -> %s", c.Chart, c.From, c.To))
+		case ReleaseUpgrade:
+			upgrades = append(upgrades, c.Chart)
+		case ReleaseNotInstalled:
+			missing = append(missing, c.Chart)
+		}
+	}
+	switch {
+	case len(stuck) > 0:
+		return CheckResult{Name: PrecheckReleases, Status: CheckFail, Message: strings.Join(stuck, ", ") + "; roll back or fix the release with helm first"}
+	case len(downgrades) > 0:
+		return CheckResult{Name: PrecheckReleases, Status: CheckFail, Message: "chart downgrades: " + strings.Join(downgrades, ", ")}
+	case len(missing) > 0 && len(upgrades) == 0 && len(changes) == len(missing):
+		return CheckResult{Name: PrecheckReleases, Status: CheckFail, Message: "none of the release's charts are installed in the namespace"}
+	}
+	message := fmt.Sprintf("%d to upgrade", len(upgrades))
+	if len(missing) > 0 {
+		message += fmt.Sprintf(", %d new: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return CheckResult{Name: PrecheckReleases, Status: CheckPass, Message: message}
+}
+
+// diffArtifacts compares images, charts, and optionally models by name and tag rather than full
+// reference, so a mirrored registry matches the release's
+func diffArtifacts(current, target *ArtifactManifest, models bool) []ArtifactChange {
+	var changes []ArtifactChange
+	diff := func(kind string, from, to map[string]string) {
+		names := make([]string, 0, len(from)+len(to))
+		for name := range from {
+			names = append(names, name)
+		}
+		for name := range to {
+			if _, ok := from[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f, inFrom := from[name]
+			t, inTo := to[name]
+			switch {
+			case !inFrom:
+				changes = append(changes, ArtifactChange{Type: kind, Name: name, To: t, Change: ArtifactAdded})
+			case !inTo:
+				changes = append(changes, ArtifactChange{Type: kind, Name: name, From: f, Change: ArtifactRemoved})
+			case f != t:
+				changes = append(changes, ArtifactChange{Type: kind, Name: name, From: f, To: t, Change: ArtifactChanged})
+			}
+		}
+	}
+	charts := func(m *ArtifactManifest) map[string]string {
+		out := map[string]string{}
+		for _, c := range m.Charts {
+			out[c.Name] = c.Version
+		}
+		return out
+	}
+	diff("chart", charts(current), charts(target))
+	diff("image", artifactTags(current.Images), artifactTags(target.Images))
+	if models {
+		diff("model", artifactTags(current.Models), artifactTags(target.Models))
+	}
+	return changes
+}
+
+// artifactTags maps the repository name of each reference to its tag, or digest when untagged
+func artifactTags(refs []string) map[string]string {
+	out := map[string]string{}
+	for _, ref := range refs {
+		repo, tag, digest := splitImageReference(strings.TrimPrefix(ref, "oci://"))
+		if tag == "" {
+			tag = digest
+		}
+		out[repo[strings.LastIndex(repo, "/")+1:]] = tag
+	}
+	return out
+}
+
+// summarizeArtifactChanges counts the artifact changes; they are reported for planning, not as
+// a problem
+func summarizeArtifactChanges(changes []ArtifactChange) CheckResult {
+	counts := map[string]map[string]int{}
+	for _, c := range changes {
+		if counts[c.Type] == nil {
+			counts[c.Type] = map[string]int{}
+		}
+		counts[c.Type][c.Change]++
+	}
+	var parts []string
+	for _, kind := range []string{"chart", "image", "model"} {
+		c := counts[kind]
+		if c == nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%ss %d added, %d changed, %d removed", kind, c[ArtifactAdded], c[ArtifactChanged], c[ArtifactRemoved]))
+	}
+	if len(parts) == 0 {
+		return CheckResult{Name: PrecheckArtifacts, Status: CheckPass, Message: "no artifact changes"}
+	}
+	message := strings.Join(parts, "; ")
+	if counts["image"][ArtifactAdded]+counts["image"][ArtifactChanged] > 0 {
+		message += "; mirror the new images before the upgrade window"
+	}
+	return CheckResult{Name: PrecheckArtifacts, Status: CheckPass, Message: message}
+}
+
+// checkUpgradeResources simulates the target sizing profile on the cluster's nodes with the
+// namespace's current pods removed, as they will be once the upgrade has rolled out
+func (kc *KubernetesChecker) checkUpgradeResources(ctx context.Context, namespace string, profile *SizingProfile) CheckResult {
+	if profile == nil {
+		return CheckResult{Name: PrecheckResources, Status: CheckWarn, Message: "not checked; pass the target release's sizing profile with --profile"}
+	}
+	nodes, err := kc.ListNodeCapacities(ctx)
+	if err != nil {
+		return CheckResult{Name: PrecheckResources, Status: CheckFail, Message: err.Error()}
+	}
+	pods, err := kc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return CheckResult{Name: PrecheckResources, Status: CheckFail, Message: fmt.Sprintf("failed to list pods in %s: %v", namespace, err)}
+	}
+	releaseNodeCapacity(nodes, pods.Items)
+
+	result, err := SimulateSizingFit(nodes, *profile)
+	if err != nil {
+		return CheckResult{Name: PrecheckResources, Status: CheckFail, Message: err.Error()}
+	}
+	var unfit []string
+	for _, c := range result.Components {
+		if !c.Fits {
+			unfit = append(unfit, fmt.Sprintf("%s (%d of %d replicas)", c.Component, c.Placed, c.Replicas))
+		}
+	}
+	if len(unfit) > 0 {
+		return CheckResult{Name: PrecheckResources, Status: CheckFail, Message: fmt.Sprintf("%s does not fit: %s; see cluster fit --profile", result.Profile, strings.Join(unfit, ", "))}
+	}
+	return CheckResult{Name: PrecheckResources, Status: CheckPass, Message: fmt.Sprintf("%s fits on %d nodes", result.Profile, result.Nodes)}
+}
+
+// releaseNodeCapacity adds the requests of running and pending pods back to their nodes' free
+// capacity
+func releaseNodeCapacity(nodes []NodeCapacity, pods []corev1.Pod) {
+	byName := map[string]*NodeCapacity{}
+	for i := range nodes {
+		byName[nodes[i].Name] = &nodes[i]
+	}
+	for i := range pods {
+		pod := &pods[i]
+		node := byName[pod.Spec.NodeName]
+		if node == nil || (pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending) {
+			continue
+		}
+		requests := PodEffectiveRequests(pod)
+		if cpu, ok := requests[corev1.ResourceCPU]; ok {
+			node.CPUFree += float64(cpu.MilliValue()) / 1000.0
+		}
+		if mem, ok := requests[corev1.ResourceMemory]; ok {
+			node.MemFree += float64(mem.Value()) / (1024.0 * 1024.0 * 1024.0)
+		}
+		if gpu, ok := requests[gpuResource]; ok {
+			node.GPUFree += gpu.Value()
+		}
+	}
+}
+
+// checkRemovedAPIs looks for removed API versions in the installed releases. Helm reads the
+// installed objects during an upgrade, so one the cluster no longer serves fails it; one removed
+// in a later Kubernetes version will fail it after the next cluster upgrade.
+func (kc *KubernetesChecker) checkRemovedAPIs(ctx context.Context, releases []*release.Release) CheckResult {
+	gitVersion, err := kc.CheckKubernetesVersion(ctx)
+	if err != nil {
+		return CheckResult{Name: PrecheckAPIDeprecation, Status: CheckWarn, Message: err.Error()}
+	}
+	cluster, err := parseNodeVersion(gitVersion)
+	if err != nil {
+		return CheckResult{Name: PrecheckAPIDeprecation, Status: CheckWarn, Message: err.Error()}
+	}
+	return findRemovedAPIs(releases, cluster)
+}
+
+// findRemovedAPIs scans the rendered manifests of releases for removedAPIs
+func findRemovedAPIs(releases []*release.Release, cluster *semver.Version) CheckResult {
+	var removed, deprecated []string
+	for _, r := range releases {
+		for _, doc := range strings.Split(r.Manifest, "\n---") {
+			var obj manifestObject
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.APIVersion == "" {
+				continue
+			}
+			api, ok := lookupRemovedAPI(obj.APIVersion, obj.Kind)
+			if !ok {
+				continue
+			}
+			entry := fmt.Sprintf("%s/%s %s %s (removed in %s, use %s)", r.Name, obj.Kind, obj.Metadata.Name, obj.APIVersion, api.RemovedIn, api.Replacement)
+			if removedIn, err := semver.NewVersion(api.RemovedIn); err == nil && !cluster.LessThan(removedIn) {
+				removed = append(removed, entry)
+			} else {
+				deprecated = append(deprecated, entry)
+			}
+		}
+	}
+	switch {
+	case len(removed) > 0:
+		return CheckResult{Name: PrecheckAPIDeprecation, Status: CheckFail, Message: fmt.Sprintf("Kubernetes %d.%d no longer serves: %s; migrate the release with helm-mapkubeapis first", cluster.Major(), cluster.Minor(), strings.Join(removed, "; "))}
+	case len(deprecated) > 0:
+		return CheckResult{Name: PrecheckAPIDeprecation, Status: CheckWarn, Message: "deprecated APIs in use: " + strings.Join(deprecated, "; ")}
+	}
+	return CheckResult{Name: PrecheckAPIDeprecation, Status: CheckPass, Message: fmt.Sprintf("no removed APIs in %d release(s)", len(releases))}
+}
+
+// lookupRemovedAPI finds the removedAPIs entry for an object's API version and kind
+func lookupRemovedAPI(apiVersion, kind string) (removedAPI, bool) {
+	for _, api := range removedAPIs {
+		if api.APIVersion == apiVersion && (api.Kind == "" || api.Kind == kind) {
+			return api, true
+		}
+	}
+	return removedAPI{}, false
+}
+
+// checkUpgradeLicense checks the target manifest's license expiry and that the namespace has a
+// license Secret
+func (kc *KubernetesChecker) checkUpgradeLicense(ctx context.Context, opts UpgradePrecheckOptions) CheckResult {
+	result := checkLicenseExpiry(opts.Target.LicenseExpiry, opts.Now, opts.LicenseWarning)
+	if result.Status == CheckFail {
+		return result
+	}
+	_, err := kc.clientset.CoreV1().Secrets(opts.Namespace).Get(ctx, DefaultLicenseSecret, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return CheckResult{Name: PrecheckLicense, Status: CheckWarn, Message: fmt.Sprintf("%s; no %s Secret in %s, apply it with deploy license apply", result.Message, DefaultLicenseSecret, opts.Namespace)}
+	case err != nil:
+		return CheckResult{Name: PrecheckLicense, Status: CheckWarn, Message: fmt.Sprintf("%s; failed to read the %s Secret: %v", result.Message, DefaultLicenseSecret, err)}
+	}
+	return result
+}
+
+// checkLicenseExpiry classifies a manifest's license expiry; manifests without one are not
+// checked
+func checkLicenseExpiry(expiry *string, now time.Time, warning time.Duration) CheckResult {
+	if expiry == nil || *expiry == "" {
+		return CheckResult{Name: PrecheckLicense, Status: CheckPass, Message: "the manifest sets no license expiry"}
+	}
+	expires, err := ParseLicenseExpiry(*expiry)
+	if err != nil {
+		return CheckResult{Name: PrecheckLicense, Status: CheckWarn, Message: fmt.Sprintf("unrecognized license expiry %q", *expiry)}
+	}
+	left := expires.Sub(now)
+	switch {
+	case left <= 0:
+		return CheckResult{Name: PrecheckLicense, Status: CheckFail, Message: fmt.Sprintf("expired on %s", expires.Format("2006-01-02"))}
+	case left < warning:
+		return CheckResult{Name: PrecheckLicense, Status: CheckWarn, Message: fmt.Sprintf("expires on %s, in %d days", expires.Format("2006-01-02"), int(left.Hours()/24))}
+	}
+	return CheckResult{Name: PrecheckLicense, Status: CheckPass, Message: fmt.Sprintf("valid until %s", expires.Format("2006-01-02"))}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func testRelease(name, chartName, version string, status release.Status, manifest string) *release.Release {
+	return &release.Release{
+		Name:     name,
+		Version:  1,
+		Info:     &release.Info{Status: status},
+		Chart:    &chart.Chart{Metadata: &chart.Metadata{Name: chartName, Version: version, AppVersion: "3.22.2"}},
+		Manifest: manifest,
+	}
+}
+
+func TestReleaseChanges(t *testing.T) {
+	target := &ArtifactManifest{Charts: []Chart{
+		{Name: "dynamoai-base", Version: "1.2.0"},
+		{Name: "dynamoai-dynamoguard", Version: "1.1.2"},
+		{Name: "dynamoai-dynamoeval", Version: "1.0.0"},
+		{Name: "dynamoai-new", Version: "0.1.0"},
+	}}
+	releases := []*release.Release{
+		testRelease("base", "dynamoai-base", "1.1.2", release.StatusDeployed, ""),
+		testRelease("guard", "dynamoai-dynamoguard", "1.1.2", release.StatusDeployed, ""),
+		testRelease("eval", "dynamoai-dynamoeval", "1.1.2", release.StatusDeployed, ""),
+		testRelease("ingress", "ingress-nginx", "4.10.0", release.StatusDeployed, ""),
+	}
+	changes := releaseChanges(releases, target)
+	want := map[string]string{
+		"dynamoai-base":        ReleaseUpgrade,
+		"dynamoai-dynamoguard": ReleaseUnchanged,
+		"dynamoai-dynamoeval":  ReleaseDowngrade,
+		"ingress-nginx":        ReleaseNotInTarget,
+		"dynamoai-new":         ReleaseNotInstalled,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	for _, c := range changes {
+		if want[c.Chart] != c.Change {
+			t.Errorf("%s: expected %s, got %s", c.Chart, want[c.Chart], c.Change)
+		}
+	}
+
+	result := checkReleaseChanges(changes)
+	if result.Status != CheckFail || !strings.Contains(result.Message, "dynamoai-dynamoeval 1.1.2 -> 1.0.0") {
+		t.Errorf("expected the downgrade to fail, got %+v", result)
+	}
+
+	releases[2].Chart.Metadata.Version = "1.0.0"
+	result = checkReleaseChanges(releaseChanges(releases, target))
+	if result.Status != CheckPass || result.Message != "1 to upgrade, 1 new: dynamoai-new" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	releases[0].Info.Status = release.StatusPendingUpgrade
+	result = checkReleaseChanges(releaseChanges(releases, target))
+	if result.Status != CheckFail || !strings.Contains(result.Message, "base is pending-upgrade") {
+		t.Errorf("expected the pending release to fail, got %+v", result)
+	}
+
+	result = checkReleaseChanges(releaseChanges(nil, target))
+	if result.Status != CheckFail || !strings.Contains(result.Message, "none of the release's charts") {
+		t.Errorf("expected an empty namespace to fail, got %+v", result)
+	}
+}
+
+func TestCheckUpgradeManifest(t *testing.T) {
+	cases := []struct {
+		from, to string
+		status   string
+		want     string
+	}{
+		{"3.22.2", "3.23.0", CheckPass, "3.22.2 -> 3.23.0"},
+		{"3.22.2", "3.22.2", CheckWarn, "already installed"},
+		{"3.23.0", "3.22.2", CheckFail, "downgrades are not supported"},
+		{"", "3.23.0", CheckWarn, "release version unknown"},
+		{"3.22.2", "9.0.0", CheckFail, "outside the supported range"},
+	}
+	for _, tc := range cases {
+		result := checkUpgradeManifest(&ArtifactManifest{ReleaseVersion: tc.from}, &ArtifactManifest{ReleaseVersion: tc.to})
+		if result.Status != tc.status || !strings.Contains(result.Message, tc.want) {
+			t.Errorf("%s -> %s: expected %s containing %q, got %+v", tc.from, tc.to, tc.status, tc.want, result)
+		}
+	}
+}
+
+func TestDiffArtifacts(t *testing.T) {
+	// The installed images come from a mirror, so only names and tags are compared
+	current := &ArtifactManifest{
+		Charts: []Chart{{Name: "dynamoai-base", Version: "1.1.2"}},
+		Images: []string{
+			"harbor.internal/dynamoai/guard-inference:dynamoai-3.22.2",
+			"harbor.internal/dynamoai/pen-testing:dynamoai-3.22.2",
+			"harbor.internal/dynamoai/legacy-worker:dynamoai-3.22.2",
+		},
+	}
+	target := &ArtifactManifest{
+		Charts: []Chart{{Name: "dynamoai-base", Version: "1.2.0"}},
+		Images: []string{
+			"oci://artifacts.dynamo.ai/dynamoai/images/guard-inference:dynamoai-3.23.0",
+			"oci://artifacts.dynamo.ai/dynamoai/images/pen-testing:dynamoai-3.22.2",
+			"oci://artifacts.dynamo.ai/dynamoai/images/guard-router:dynamoai-3.23.0",
+		},
+		Models: []string{"oci://artifacts.dynamo.ai/dynamoai/pii-redaction:v9"},
+	}
+	changes := diffArtifacts(current, target, false)
+	got := make([]string, 0, len(changes))
+	for _, c := range changes {
+		got = append(got, c.Type+" "+c.Name+" "+c.Change)
+	}
+	want := []string{
+		"chart dynamoai-base changed",
+		"image guard-inference changed",
+		"image guard-router added",
+		"image legacy-worker removed",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+	if changes := diffArtifacts(current, target, true); changes[len(changes)-1].Type != "model" {
+		t.Errorf("expected models compared, got %+v", changes)
+	}
+
+	result := summarizeArtifactChanges(changes)
+	if result.Message != "charts 0 added, 1 changed, 0 removed; images 1 added, 1 changed, 1 removed; mirror the new images before the upgrade window" {
+		t.Errorf("unexpected summary %q", result.Message)
+	}
+}
+
+func TestFindRemovedAPIs(t *testing.T) {
+	manifest := `---
+# Source: base/templates/hpa.yaml
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: api
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: api
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+`
+	releases := []*release.Release{testRelease("base", "dynamoai-base", "1.1.2", release.StatusDeployed, manifest)}
+
+	result := findRemovedAPIs(releases, semver.MustParse("1.24.9"))
+	if result.Status != CheckWarn || !strings.Contains(result.Message, "base/HorizontalPodAutoscaler api autoscaling/v2beta2 (removed in 1.26") {
+		t.Errorf("expected deprecations, got %+v", result)
+	}
+
+	result = findRemovedAPIs(releases, semver.MustParse("1.25.3"))
+	if result.Status != CheckFail || !strings.Contains(result.Message, "PodDisruptionBudget") || strings.Contains(result.Message, "HorizontalPodAutoscaler") {
+		t.Errorf("expected only the PDB to block, got %+v", result)
+	}
+
+	releases[0].Manifest = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n"
+	if result := findRemovedAPIs(releases, semver.MustParse("1.30.0")); result.Status != CheckPass {
+		t.Errorf("expected a pass, got %+v", result)
+	}
+}
+
+func TestCheckLicenseExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	expiry := func(s string) *string { return &s }
+	cases := []struct {
+		expiry *string
+		status string
+		want   string
+	}{
+		{nil, CheckPass, "no license expiry"},
+		{expiry("2027-06-30"), CheckPass, "valid until 2027-06-30"},
+		{expiry("2026-11-01T00:00:00Z"), CheckWarn, "in 14 days"},
+		{expiry("2026-10-01"), CheckFail, "expired on 2026-10-01"},
+		{expiry("next year"), CheckWarn, "unrecognized license expiry"},
+	}
+	for _, tc := range cases {
+		result := checkLicenseExpiry(tc.expiry, now, DefaultLicenseExpiryWarning)
+		if result.Status != tc.status || !strings.Contains(result.Message, tc.want) {
+			t.Errorf("expected %s containing %q, got %+v", tc.status, tc.want, result)
+		}
+	}
+}
+
+func TestReleaseNodeCapacity(t *testing.T) {
+	nodes := []NodeCapacity{{Name: "node-a", CPUFree: 1, MemFree: 2}}
+	pod := func(node string, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	releaseNodeCapacity(nodes, []corev1.Pod{
+		pod("node-a", corev1.PodRunning),
+		pod("node-a", corev1.PodSucceeded),
+		pod("node-b", corev1.PodRunning),
+	})
+	if nodes[0].CPUFree != 1.5 || nodes[0].MemFree != 3 {
+		t.Errorf("unexpected capacity %+v", nodes[0])
+	}
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PVCUsageThreshold is the filesystem usage percentage above which a PVC is flagged
+const PVCUsageThreshold = 80.0
+
+// PVCUsage is the actual filesystem consumption of a PersistentVolumeClaim as reported by the
+// kubelet of the node it is mounted on
+type PVCUsage struct {
+	Namespace      string
+	Name           string
+	StorageClass   string
+	Component      string
+	Node           string
+	Mounted        bool
+	CapacityBytes  int64
+	UsedBytes      int64
+	AvailableBytes int64
+	UsedPercent    float64
+}
+
+// kubeletFsStats is a filesystem in the kubelet stats summary
+type kubeletFsStats struct {
+	CapacityBytes  *int64 `json:"capacityBytes"`
+	AvailableBytes *int64 `json:"availableBytes"`
+}
+
+// kubeletStatsSummary is the subset of the kubelet /stats/summary response needed for volumes
+// and node disks
+type kubeletStatsSummary struct {
+	Node struct {
+		Fs      *kubeletFsStats `json:"fs"`
+		Runtime *struct {
+			ImageFs *kubeletFsStats `json:"imageFs"`
+		} `json:"runtime"`
+	} `json:"node"`
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volume []struct {
+			Name           string `json:"name"`
+			CapacityBytes  *int64 `json:"capacityBytes"`
+			UsedBytes      *int64 `json:"usedBytes"`
+			AvailableBytes *int64 `json:"availableBytes"`
+			PVCRef         *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// ListPVCUsage reports real filesystem usage for every PVC in a namespace (all namespaces when
+// empty), read from kubelet volume stats through the API server node proxy. PVCs that are not
+// mounted by any running pod are returned with Mounted set to false and no usage figures.
+func (kc *KubernetesChecker) ListPVCUsage(ctx context.Context, namespace string) ([]PVCUsage, error) {
+	pvcs, err := kc.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %v", err)
+	}
+	if len(pvcs.Items) == 0 {
+		return nil, nil
+	}
+
+	pods, err := kc.listPods(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	// Deployment names only label the report, so usage is still shown without them
+	var rsOwners map[string]string
+	replicaSets, err := kc.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		LogWarning("Failed to list replicasets, showing pod names instead of deployments: %v", err)
+	} else {
+		rsOwners = map[string]string{}
+		for _, rs := range replicaSets.Items {
+			rsOwners[rs.Name] = ownerWorkload(rs.OwnerReferences, nil, "ReplicaSet/"+rs.Name)
+		}
+	}
+
+	// Map each mounted claim to the workload of the pod using it and collect the nodes to query
+	owners := map[string]string{}
+	nodes := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		owner := ownerWorkload(pod.OwnerReferences, rsOwners, "Pod/"+pod.Name)
+		if rsOwners == nil && strings.HasPrefix(owner, "ReplicaSet/") {
+			owner = "Pod/" + pod.Name
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim == nil {
+				continue
+			}
+			owners[pod.Namespace+"/"+v.PersistentVolumeClaim.ClaimName] = owner
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	stats := map[string]PVCUsage{}
+	for node := range nodes {
+		summary, err := kc.kubeletStatsSummary(ctx, node)
+		if err != nil {
+			LogWarning("Failed to read volume stats from node %s: %v", node, err)
+			continue
+		}
+		for _, pod := range summary.Pods {
+			for _, vol := range pod.Volume {
+				if vol.PVCRef == nil || vol.CapacityBytes == nil || vol.UsedBytes == nil {
+					continue
+				}
+				u := PVCUsage{
+					Node:          node,
+					Mounted:       true,
+					CapacityBytes: *vol.CapacityBytes,
+					UsedBytes:     *vol.UsedBytes,
+				}
+				if vol.AvailableBytes != nil {
+					u.AvailableBytes = *vol.AvailableBytes
+				}
+				if u.CapacityBytes > 0 {
+					u.UsedPercent = float64(u.UsedBytes) / float64(u.CapacityBytes) * 100
+				}
+				stats[vol.PVCRef.Namespace+"/"+vol.PVCRef.Name] = u
+			}
+		}
+	}
+
+	usages := make([]PVCUsage, 0, len(pvcs.Items))
+	for _, pvc := range pvcs.Items {
+		key := pvc.Namespace + "/" + pvc.Name
+		u, ok := stats[key]
+		if !ok {
+			capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+			u = PVCUsage{CapacityBytes: capacity.Value()}
+		}
+		u.Namespace = pvc.Namespace
+		u.Name = pvc.Name
+		if pvc.Spec.StorageClassName != nil {
+			u.StorageClass = *pvc.Spec.StorageClassName
+		}
+		u.Component = owners[key]
+		if u.Component == "" {
+			u.Component = "-"
+		}
+		usages = append(usages, u)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].UsedPercent != usages[j].UsedPercent {
+			return usages[i].UsedPercent > usages[j].UsedPercent
+		}
+		if usages[i].Namespace != usages[j].Namespace {
+			return usages[i].Namespace < usages[j].Namespace
+		}
+		return usages[i].Name < usages[j].Name
+	})
+	return usages, nil
+}
+
+// kubeletStatsSummary fetches the kubelet stats summary for a node via the API server proxy
+func (kc *KubernetesChecker) kubeletStatsSummary(ctx context.Context, node string) (*kubeletStatsSummary, error) {
+	data, err := kc.clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary kubeletStatsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse stats summary: %v", err)
+	}
+	return &summary, nil
+}
+
+// PVCsOverThreshold returns the mounted PVCs whose usage exceeds threshold percent
+func PVCsOverThreshold(usages []PVCUsage, threshold float64) []PVCUsage {
+	var over []PVCUsage
+	for _, u := range usages {
+		if u.Mounted && u.UsedPercent > threshold {
+			over = append(over, u)
+		}
+	}
+	return over
+}
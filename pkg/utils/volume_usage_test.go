@@ -0,0 +1,17 @@
+package utils
+
+import "testing"
+
+func TestPVCsOverThreshold(t *testing.T) {
+	usages := []PVCUsage{
+		{Name: "data-postgres-0", Mounted: true, UsedPercent: 91.3},
+		{Name: "model-cache", Mounted: true, UsedPercent: 6.2},
+		{Name: "orphaned", Mounted: false, UsedPercent: 0},
+		{Name: "redis", Mounted: true, UsedPercent: 80},
+	}
+
+	over := PVCsOverThreshold(usages, PVCUsageThreshold)
+	if len(over) != 1 || over[0].Name != "data-postgres-0" {
+		t.Errorf("expected only data-postgres-0 over threshold, got %v", over)
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WarmupOptions configures warming up a model endpoint until its latency settles
+type WarmupOptions struct {
+	// Request describes one round of warm-up requests
+	Request BenchmarkOptions
+	// Threshold is the p95 latency a round must stay at or under to count as stable
+	Threshold time.Duration
+	// StableRounds is how many consecutive stable rounds make the endpoint ready
+	StableRounds int
+	// MaxErrorRate is the highest error rate a stable round may have
+	MaxErrorRate float64
+	// Interval is the pause between rounds
+	Interval time.Duration
+	// MaxWait bounds the whole warm-up; zero means no limit
+	MaxWait time.Duration
+	// OnRound, when set, is called after each round
+	OnRound func(WarmupRound)
+}
+
+// WarmupRound is the outcome of one round of warm-up requests
+type WarmupRound struct {
+	Round     int
+	P50       time.Duration
+	P95       time.Duration
+	Errors    int
+	ErrorRate float64
+	Stable    bool
+	// FirstError is the first failure of the round
+	FirstError string `json:",omitempty"`
+}
+
+// WarmupResult reports whether the endpoint became ready and how long it took
+type WarmupResult struct {
+	URL       string
+	Ready     bool
+	Threshold time.Duration
+	Rounds    []WarmupRound
+	Duration  time.Duration
+	// Reason explains why the endpoint is not ready
+	Reason string `json:",omitempty"`
+}
+
+// RunWarmup sends rounds of requests until StableRounds consecutive rounds have a p95 latency at
+// or under Threshold and an acceptable error rate, or MaxWait passes. The first requests to a
+// freshly started model are often slow while weights load and caches fill, so early slow rounds
+// are expected; only a streak of stable rounds counts.
+func RunWarmup(ctx context.Context, opts WarmupOptions) (*WarmupResult, error) {
+	if opts.Threshold <= 0 {
+		return nil, fmt.Errorf("latency threshold must be greater than zero")
+	}
+	if opts.StableRounds <= 0 {
+		opts.StableRounds = 1
+	}
+
+	result := &WarmupResult{URL: opts.Request.URL, Threshold: opts.Threshold}
+	start := time.Now()
+	deadline := start.Add(opts.MaxWait)
+	streak := 0
+	for n := 1; ; n++ {
+		r, err := RunBenchmark(ctx, opts.Request)
+		if err != nil {
+			return nil, err
+		}
+		round := WarmupRound{Round: n, P50: r.P50, P95: r.P95, Errors: r.Errors, ErrorRate: r.ErrorRate, FirstError: r.FirstError}
+		round.Stable = r.P95 <= opts.Threshold && r.ErrorRate <= opts.MaxErrorRate
+		result.Rounds = append(result.Rounds, round)
+		if opts.OnRound != nil {
+			opts.OnRound(round)
+		}
+
+		if round.Stable {
+			streak++
+		} else {
+			streak = 0
+		}
+		if streak >= opts.StableRounds {
+			result.Ready = true
+			break
+		}
+		if opts.MaxWait > 0 && time.Now().Add(opts.Interval).After(deadline) {
+			result.Reason = warmupFailureReason(round, opts, streak)
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.Interval):
+		}
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// warmupFailureReason describes why the last round left the endpoint not ready
+func warmupFailureReason(last WarmupRound, opts WarmupOptions, streak int) string {
+	switch {
+	case last.ErrorRate > opts.MaxErrorRate:
+		return fmt.Sprintf("error rate %.1f%% above %.1f%% (first error: %s)", last.ErrorRate*100, opts.MaxErrorRate*100, last.FirstError)
+	case last.P95 > opts.Threshold:
+		return fmt.Sprintf("p95 latency %s still above %s", last.P95.Round(time.Millisecond), opts.Threshold)
+	}
+	return fmt.Sprintf("only %d of %d consecutive rounds stable", streak, opts.StableRounds)
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunWarmup(t *testing.T) {
+	// The first six requests are slow, as when a model is still loading
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 6 {
+			time.Sleep(60 * time.Millisecond)
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	var seen []int
+	result, err := RunWarmup(context.Background(), WarmupOptions{
+		Request:      BenchmarkOptions{URL: server.URL, Requests: 3, Concurrency: 1, Timeout: 5 * time.Second},
+		Threshold:    30 * time.Millisecond,
+		StableRounds: 2,
+		MaxWait:      10 * time.Second,
+		OnRound:      func(r WarmupRound) { seen = append(seen, r.Round) },
+	})
+	if err != nil {
+		t.Fatalf("RunWarmup returned error: %v", err)
+	}
+	if !result.Ready {
+		t.Fatalf("expected the endpoint to become ready: %+v", result)
+	}
+	if len(result.Rounds) != 4 || result.Rounds[1].Stable || !result.Rounds[2].Stable || !result.Rounds[3].Stable {
+		t.Errorf("expected two slow rounds then two stable ones, got %+v", result.Rounds)
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected OnRound for every round, got %v", seen)
+	}
+}
+
+func TestRunWarmupNotReady(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	result, err := RunWarmup(context.Background(), WarmupOptions{
+		Request:      BenchmarkOptions{URL: server.URL, Requests: 2, Concurrency: 1, Timeout: 5 * time.Second},
+		Threshold:    time.Second,
+		StableRounds: 3,
+		Interval:     10 * time.Millisecond,
+		MaxWait:      50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("RunWarmup returned error: %v", err)
+	}
+	if result.Ready || !strings.Contains(result.Reason, "error rate 100.0%") {
+		t.Errorf("expected not ready because of errors, got %+v", result)
+	}
+}
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Workload kinds understood by ListWorkloadResourceSummaries
+const (
+	WorkloadKindDeployment       = "Deployment"
+	WorkloadKindStatefulSet      = "StatefulSet"
+	WorkloadKindDaemonSet        = "DaemonSet"
+	WorkloadKindInferenceService = "InferenceService"
+	WorkloadKindRayService       = "RayService"
+)
+
+// AllWorkloadKinds lists every workload kind in display order
+var AllWorkloadKinds = []string{
+	WorkloadKindDeployment,
+	WorkloadKindStatefulSet,
+	WorkloadKindDaemonSet,
+	WorkloadKindInferenceService,
+	WorkloadKindRayService,
+}
+
+const (
+	kserveInferenceServiceLabel = "serving.kserve.io/inferenceservice"
+	kserveComponentLabel        = "component"
+	rayClusterLabel             = "ray.io/cluster"
+	rayGroupLabel               = "ray.io/group"
+)
+
+var (
+	inferenceServiceGVR = schema.GroupVersionResource{Group: "serving.kserve.io", Version: "v1beta1", Resource: "inferenceservices"}
+	rayServiceGVR       = schema.GroupVersionResource{Group: "ray.io", Version: "v1", Resource: "rayservices"}
+)
+
+// ParseWorkloadKinds normalizes user-supplied kind names (case-insensitive, plural allowed)
+func ParseWorkloadKinds(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return AllWorkloadKinds, nil
+	}
+
+	var kinds []string
+	for _, v := range values {
+		v = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), "s")
+		if v == "" {
+			continue
+		}
+		matched := false
+		for _, kind := range AllWorkloadKinds {
+			if strings.ToLower(kind) == v {
+				kinds = append(kinds, kind)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("unknown workload kind %q (supported: %s)", v, strings.Join(AllWorkloadKinds, ", "))
+		}
+	}
+	return kinds, nil
+}
+
+// ListWorkloadResourceSummaries summarizes resource requests/limits for every pod-owning workload
+// kind requested. Model-serving CRs are skipped when their CRDs are not installed, and Deployments
+// created by KServe are folded into their InferenceService to avoid double counting.
+func (kc *KubernetesChecker) ListWorkloadResourceSummaries(ctx context.Context, namespace, selector string, kinds []string) ([]DeploymentResourceSummary, error) {
+	wanted := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		wanted[k] = true
+	}
+
+	var summaries []DeploymentResourceSummary
+
+	var inferenceServices []DeploymentResourceSummary
+	kserveInstalled := false
+	if wanted[WorkloadKindInferenceService] {
+		var err error
+		inferenceServices, kserveInstalled, err = kc.listInferenceServiceSummaries(ctx, namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if wanted[WorkloadKindDeployment] {
+		deployments, err := kc.ListDeploymentResourceSummaries(ctx, namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deployments {
+			if kserveInstalled && d.podLabels[kserveInferenceServiceLabel] != "" {
+				LogDebug("Deployment %s is managed by InferenceService %s", d.Name, d.podLabels[kserveInferenceServiceLabel])
+				continue
+			}
+			summaries = append(summaries, d)
+		}
+	}
+
+	if wanted[WorkloadKindStatefulSet] {
+		statefulSets, err := kc.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets in %s: %v", namespace, err)
+		}
+		for _, s := range statefulSets.Items {
+			summaries = append(summaries, DeploymentResourceSummary{
+				Kind:              WorkloadKindStatefulSet,
+				Name:              s.Name,
+				Replicas:          replicasOrDefault(s.Spec.Replicas),
+				ReadyReplicas:     s.Status.ReadyReplicas,
+				AvailableReplicas: s.Status.AvailableReplicas,
+				Containers:        summarizeContainers(s.Spec.Template.Spec.Containers),
+				podSelector:       selectorString(s.Spec.Selector),
+				podLabels:         s.Spec.Template.Labels,
+			})
+		}
+	}
+
+	if wanted[WorkloadKindDaemonSet] {
+		daemonSets, err := kc.clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemonsets in %s: %v", namespace, err)
+		}
+		for _, d := range daemonSets.Items {
+			summaries = append(summaries, DeploymentResourceSummary{
+				Kind:              WorkloadKindDaemonSet,
+				Name:              d.Name,
+				Replicas:          d.Status.DesiredNumberScheduled,
+				ReadyReplicas:     d.Status.NumberReady,
+				AvailableReplicas: d.Status.NumberAvailable,
+				Containers:        summarizeContainers(d.Spec.Template.Spec.Containers),
+				podSelector:       selectorString(d.Spec.Selector),
+				podLabels:         d.Spec.Template.Labels,
+			})
+		}
+	}
+
+	summaries = append(summaries, inferenceServices...)
+
+	if wanted[WorkloadKindRayService] {
+		rayServices, err := kc.listRayServiceSummaries(ctx, namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, rayServices...)
+	}
+
+	return summaries, nil
+}
+
+// listCustomResources lists custom resources, reporting installed=false when the CRD is absent
+func (kc *KubernetesChecker) listCustomResources(ctx context.Context, gvr schema.GroupVersionResource, namespace, selector string) ([]unstructured.Unstructured, bool, error) {
+	list, err := kc.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			LogDebug("%s not available in cluster, skipping", gvr.GroupResource())
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to list %s in %s: %v", gvr.GroupResource(), namespace, err)
+	}
+	return list.Items, true, nil
+}
+
+func (kc *KubernetesChecker) listInferenceServiceSummaries(ctx context.Context, namespace, selector string) ([]DeploymentResourceSummary, bool, error) {
+	items, installed, err := kc.listCustomResources(ctx, inferenceServiceGVR, namespace, selector)
+	if err != nil || !installed {
+		return nil, installed, err
+	}
+
+	var summaries []DeploymentResourceSummary
+	for _, isvc := range items {
+		podSelector := fmt.Sprintf("%s=%s", kserveInferenceServiceLabel, isvc.GetName())
+		groups, err := kc.summarizePodGroups(ctx, namespace, podSelector, kserveComponentLabel)
+		if err != nil {
+			return nil, true, err
+		}
+		summaries = append(summaries, namePodGroups(WorkloadKindInferenceService, isvc.GetName(), podSelector, kserveComponentLabel, groups)...)
+	}
+	return summaries, true, nil
+}
+
+func (kc *KubernetesChecker) listRayServiceSummaries(ctx context.Context, namespace, selector string) ([]DeploymentResourceSummary, error) {
+	items, installed, err := kc.listCustomResources(ctx, rayServiceGVR, namespace, selector)
+	if err != nil || !installed {
+		return nil, err
+	}
+
+	var summaries []DeploymentResourceSummary
+	for _, rs := range items {
+		cluster, _, _ := unstructured.NestedString(rs.Object, "status", "activeServiceStatus", "rayClusterName")
+		if cluster == "" {
+			summaries = append(summaries, DeploymentResourceSummary{Kind: WorkloadKindRayService, Name: rs.GetName()})
+			continue
+		}
+		podSelector := fmt.Sprintf("%s=%s", rayClusterLabel, cluster)
+		groups, err := kc.summarizePodGroups(ctx, namespace, podSelector, rayGroupLabel)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, namePodGroups(WorkloadKindRayService, rs.GetName(), podSelector, rayGroupLabel, groups)...)
+	}
+	return summaries, nil
+}
+
+// podGroup is a set of pods sharing a value for a grouping label
+type podGroup struct {
+	key        string
+	pods       int32
+	ready      int32
+	containers []ContainerResourceSummary
+}
+
+// summarizePodGroups lists live pods matching selector and groups them by groupLabel, using the
+// first pod of each group as the resource template
+func (kc *KubernetesChecker) summarizePodGroups(ctx context.Context, namespace, selector, groupLabel string) ([]podGroup, error) {
+	pods, err := kc.listPodsBySelector(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*podGroup{}
+	var keys []string
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		key := pod.Labels[groupLabel]
+		g, ok := byKey[key]
+		if !ok {
+			g = &podGroup{key: key, containers: summarizeContainers(pod.Spec.Containers)}
+			byKey[key] = g
+			keys = append(keys, key)
+		}
+		g.pods++
+		if isPodReady(&pod) {
+			g.ready++
+		}
+	}
+
+	sort.Strings(keys)
+	groups := make([]podGroup, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, *byKey[k])
+	}
+	return groups, nil
+}
+
+// namePodGroups turns pod groups of a custom resource into workload summaries
+func namePodGroups(kind, name, podSelector, groupLabel string, groups []podGroup) []DeploymentResourceSummary {
+	if len(groups) == 0 {
+		return []DeploymentResourceSummary{{Kind: kind, Name: name, podSelector: podSelector}}
+	}
+
+	summaries := make([]DeploymentResourceSummary, 0, len(groups))
+	for _, g := range groups {
+		// Live pods stand in for desired replicas; without minReadySeconds ready pods are available
+		summary := DeploymentResourceSummary{
+			Kind:              kind,
+			Name:              name,
+			Replicas:          g.pods,
+			ReadyReplicas:     g.ready,
+			AvailableReplicas: g.ready,
+			Containers:        g.containers,
+			podSelector:       podSelector,
+		}
+		if g.key != "" {
+			summary.Name = name + "-" + g.key
+			summary.podSelector = fmt.Sprintf("%s,%s=%s", podSelector, groupLabel, g.key)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dynamofl/dynactl/pkg/kubetest"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseWorkloadKinds(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{values: nil, want: AllWorkloadKinds},
+		{values: []string{"deployments", "StatefulSet"}, want: []string{WorkloadKindDeployment, WorkloadKindStatefulSet}},
+		{values: []string{" ", "daemonset"}, want: []string{WorkloadKindDaemonSet}},
+		{values: []string{"InferenceServices", "rayservice"}, want: []string{WorkloadKindInferenceService, WorkloadKindRayService}},
+		{values: []string{"jobs"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWorkloadKinds(tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWorkloadKinds(%q) error = %v, wantErr %v", tt.values, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseWorkloadKinds(%q) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestNamePodGroups(t *testing.T) {
+	tests := []struct {
+		name         string
+		groups       []podGroup
+		wantNames    []string
+		wantSelector []string
+	}{
+		{name: "no pods", wantNames: []string{"llama"}, wantSelector: []string{"app=llama"}},
+		{name: "ungrouped", groups: []podGroup{{pods: 2, ready: 1}}, wantNames: []string{"llama"}, wantSelector: []string{"app=llama"}},
+		{
+			name:         "grouped",
+			groups:       []podGroup{{key: "predictor", pods: 2, ready: 2}, {key: "transformer", pods: 1}},
+			wantNames:    []string{"llama-predictor", "llama-transformer"},
+			wantSelector: []string{"app=llama,component=predictor", "app=llama,component=transformer"},
+		},
+	}
+	for _, tt := range tests {
+		summaries := namePodGroups(WorkloadKindInferenceService, "llama", "app=llama", "component", tt.groups)
+		if len(summaries) != len(tt.wantNames) {
+			t.Fatalf("%s: expected %d summaries, got %+v", tt.name, len(tt.wantNames), summaries)
+		}
+		for i, s := range summaries {
+			if s.Kind != WorkloadKindInferenceService || s.Name != tt.wantNames[i] || s.podSelector != tt.wantSelector[i] {
+				t.Errorf("%s: got %s %s selecting %q, want %s selecting %q", tt.name, s.Kind, s.Name, s.podSelector, tt.wantNames[i], tt.wantSelector[i])
+			}
+			if i < len(tt.groups) && (s.Replicas != tt.groups[i].pods || s.ReadyReplicas != tt.groups[i].ready || s.AvailableReplicas != tt.groups[i].ready) {
+				t.Errorf("%s: expected the group's pod counts, got %+v", tt.name, s)
+			}
+		}
+	}
+}
+
+// customResource returns a namespaced custom resource with the given status
+func customResource(gvr schema.GroupVersionResource, kind, name string, status map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{"status": status}}
+	obj.SetAPIVersion(gvr.GroupVersion().String())
+	obj.SetKind(kind)
+	obj.SetNamespace(kubetest.Namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func withLabels(labels map[string]string) func(*corev1.Pod) {
+	return func(pod *corev1.Pod) { pod.Labels = labels }
+}
+
+func TestListWorkloadResourceSummaries(t *testing.T) {
+	replicas := int32(2)
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubetest.Namespace, Name: "dynamoai-api"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2, AvailableReplicas: 2},
+		},
+		// Created by KServe for the llama InferenceService
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubetest.Namespace, Name: "llama-predictor"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{kserveInferenceServiceLabel: "llama"}},
+			}},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubetest.Namespace, Name: "postgres"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1, AvailableReplicas: 1},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubetest.Namespace, Name: "node-agent"},
+			Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 2, NumberAvailable: 2},
+		},
+		kubetest.Pod(kubetest.Namespace, "llama-predictor-a", "gpu-1", withLabels(map[string]string{kserveInferenceServiceLabel: "llama", kserveComponentLabel: "predictor"})),
+		kubetest.Pod(kubetest.Namespace, "llama-predictor-b", "gpu-1", kubetest.Waiting("ContainerCreating"), withLabels(map[string]string{kserveInferenceServiceLabel: "llama", kserveComponentLabel: "predictor"})),
+		kubetest.Pod(kubetest.Namespace, "ray-llm-head", "general-1", withLabels(map[string]string{rayClusterLabel: "ray-llm-abc", rayGroupLabel: "headgroup"})),
+		kubetest.Pod(kubetest.Namespace, "ray-llm-worker-0", "gpu-1", withLabels(map[string]string{rayClusterLabel: "ray-llm-abc", rayGroupLabel: "workers"})),
+		kubetest.Pod(kubetest.Namespace, "ray-llm-worker-1", "gpu-1", kubetest.Succeeded, withLabels(map[string]string{rayClusterLabel: "ray-llm-abc", rayGroupLabel: "workers"})),
+	}
+	listKinds := map[schema.GroupVersionResource]string{
+		inferenceServiceGVR: "InferenceServiceList",
+		rayServiceGVR:       "RayServiceList",
+	}
+	dynamicClient := kubetest.NewDynamicClient(listKinds,
+		customResource(inferenceServiceGVR, "InferenceService", "llama", nil),
+		customResource(rayServiceGVR, "RayService", "ray-llm", map[string]any{"activeServiceStatus": map[string]any{"rayClusterName": "ray-llm-abc"}}),
+		customResource(rayServiceGVR, "RayService", "ray-pending", nil),
+	)
+	kc := NewKubernetesCheckerForClients(kubetest.NewClientset(objects...), dynamicClient)
+
+	summaries, err := kc.ListWorkloadResourceSummaries(context.Background(), kubetest.Namespace, "", AllWorkloadKinds)
+	if err != nil {
+		t.Fatalf("ListWorkloadResourceSummaries returned error: %v", err)
+	}
+	type row struct {
+		kind, name             string
+		replicas, ready, avail int32
+	}
+	var got []row
+	for _, s := range summaries {
+		got = append(got, row{s.Kind, s.Name, s.Replicas, s.ReadyReplicas, s.AvailableReplicas})
+	}
+	// The KServe Deployment is folded into its InferenceService, and the completed Ray worker is
+	// not counted
+	want := []row{
+		{WorkloadKindDeployment, "dynamoai-api", 2, 2, 2},
+		{WorkloadKindStatefulSet, "postgres", 1, 1, 1},
+		{WorkloadKindDaemonSet, "node-agent", 3, 2, 2},
+		{WorkloadKindInferenceService, "llama-predictor", 2, 1, 1},
+		{WorkloadKindRayService, "ray-llm-headgroup", 1, 1, 1},
+		{WorkloadKindRayService, "ray-llm-workers", 1, 1, 1},
+		{WorkloadKindRayService, "ray-pending", 0, 0, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected summaries:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Without the KServe and Ray CRDs only the built-in kinds are listed, and the KServe
+	// Deployment is reported as a plain Deployment
+	kc = NewKubernetesCheckerForClients(kubetest.NewClientset(objects...), kubetest.NewDynamicClient(nil))
+	summaries, err = kc.ListWorkloadResourceSummaries(context.Background(), kubetest.Namespace, "", []string{WorkloadKindDeployment, WorkloadKindInferenceService, WorkloadKindRayService})
+	if err != nil {
+		t.Fatalf("ListWorkloadResourceSummaries returned error: %v", err)
+	}
+	var names []string
+	for _, s := range summaries {
+		names = append(names, s.Kind+"/"+s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Deployment/dynamoai-api", "Deployment/llama-predictor"}) {
+		t.Errorf("Expected only Deployments without the CRDs, got %v", names)
+	}
+}